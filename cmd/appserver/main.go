@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/gin-gonic/gin"
@@ -28,6 +30,48 @@ const (
 	defaultEnv      = "staging"
 	defaultLogLevel = "info"
 	defaultPort     = "9000"
+
+	defaultCopyBarcodeFormats = "3:14:mod10"
+
+	defaultLibraryTimeZone = "UTC"
+	defaultLoanDays        = "21"
+	defaultMaxRenewals     = "2"
+	defaultFinePerDayCents = "25"
+	defaultMaxFineCents    = "1000"
+
+	defaultMaxOutstandingCents = "1000"
+	defaultMaxOverdueLoans     = "3"
+
+	defaultLostAfterDays           = "90"
+	defaultLostProcessingFeeCents  = "500"
+	defaultDefaultReplacementCents = "2500"
+	defaultLostItemCheckInterval   = "24h"
+
+	defaultPatronBarcodeFormats = "2:14:mod10"
+	defaultMembershipTerms      = "Adult:12,Child:12,Staff:0,Guest:3"
+
+	defaultReadingHistoryDays          = "30"
+	defaultReadingHistoryPurgeInterval = "24h"
+
+	defaultBlobStoreDir = "./data/blobs"
+
+	defaultAppBaseURL           = "http://localhost:8080"
+	defaultMailBackend          = "file"
+	defaultMailFrom             = "Page Turner Library <library@localhost>"
+	defaultMailDir              = "./data/mail"
+	defaultEmailVerificationTTL = "48h"
+	defaultPasswordResetTTL     = "1h"
+
+	defaultNotificationChannel  = "email"
+	defaultNotificationLocale   = "en"
+	defaultDueSoonDays          = "3"
+	defaultNotificationInterval = "1h"
+
+	defaultWebhookInterval       = "15s"
+	defaultWebhookTimeout        = "10s"
+	defaultWebhookMaxAttempts    = "8"
+	defaultWebhookRetryBaseDelay = "30s"
+	defaultWebhookRetryMaxDelay  = "6h"
 )
 
 type AppConfig struct {
@@ -38,6 +82,60 @@ type AppConfig struct {
 	// Database configuration
 	DatabaseDSN *string
 
+	// Catalog configuration
+	CopyBarcodeFormats *string
+
+	// Circulation configuration
+	LibraryTimeZone         *string
+	LoanDays                *int
+	MaxRenewals             *int
+	FinePerDayCents         *int64
+	MaxFineCents            *int64
+	MaxOutstandingCents     *int64
+	MaxOverdueLoans         *int
+	LostAfterDays           *int
+	LostProcessingFeeCents  *int64
+	DefaultReplacementCents *int64
+	LostItemCheckInterval   *time.Duration
+
+	// Patron configuration
+	PatronBarcodeFormats *string
+	MembershipTerms      *string
+
+	// Privacy configuration
+	ReadingHistoryDays          *int
+	ReadingHistoryPurgeInterval *time.Duration
+
+	// Storage configuration
+	BlobStoreDir *string
+
+	// Account configuration
+	AppBaseURL           *string
+	EmailVerificationTTL *time.Duration
+	PasswordResetTTL     *time.Duration
+
+	// Mail configuration
+	MailBackend  *string
+	MailFrom     *string
+	MailDir      *string
+	SMTPAddr     *string
+	SMTPUsername *string
+	SMTPPassword *string
+
+	// Notification configuration
+	NotificationChannel  *string
+	NotificationLogFile  *string
+	NotificationLocale   *string
+	DueSoonDays          *int
+	NotificationInterval *time.Duration
+
+	// Webhook configuration
+	WebhookInterval       *time.Duration
+	WebhookTimeout        *time.Duration
+	WebhookMaxAttempts    *int
+	WebhookRetryBaseDelay *time.Duration
+	WebhookRetryMaxDelay  *time.Duration
+
 	// HTTP configuration
 	Port *int
 }
@@ -89,6 +187,30 @@ func runHTTPServer(rootCtx context.Context, wg *sync.WaitGroup, port int, app *a
 	}()
 }
 
+// runPeriodicJob runs a job every interval until rootCtx is done. A run in
+// progress is not interrupted by the shutdown but no new one starts.
+func runPeriodicJob(rootCtx context.Context, wg *sync.WaitGroup, name string, interval time.Duration, job func(ctx context.Context)) {
+	go func() {
+		defer wg.Done()
+
+		logger := zerolog.Ctx(rootCtx).With().Str("job", name).Logger()
+		ctx := logger.WithContext(context.Background())
+		logger.Info().Msgf("job runs every %s", interval)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-rootCtx.Done():
+				logger.Info().Msg("job is stopped")
+				return
+			case <-ticker.C:
+				job(ctx)
+			}
+		}
+	}()
+}
+
 func initAppConfig() AppConfig {
 	// Setup basic application information
 	app := kingpin.New(AppName, "Page Turner PRO Server").Version(fmt.Sprintf("version: %s, build: %s", AppVersion, AppBuild))
@@ -110,6 +232,150 @@ func initAppConfig() AppConfig {
 		Flag("database_dsn", "The database DSN").
 		Envar("DATABASE_DSN").Required().String()
 
+	config.CopyBarcodeFormats = app.
+		Flag("copy_barcode_formats", "Accepted copy barcode formats as prefix:length:check, comma-separated; new barcodes use the first").
+		Envar("COPY_BARCODE_FORMATS").Default(defaultCopyBarcodeFormats).String()
+
+	config.LibraryTimeZone = app.
+		Flag("library_time_zone", "The IANA time zone of the library, in which opening hours and due dates fall").
+		Envar("LIBRARY_TIME_ZONE").Default(defaultLibraryTimeZone).String()
+
+	config.LoanDays = app.
+		Flag("loan_days", "Days a copy is lent for before it is due").
+		Envar("LOAN_DAYS").Default(defaultLoanDays).Int()
+
+	config.MaxRenewals = app.
+		Flag("max_renewals", "How many times a loan may be renewed").
+		Envar("MAX_RENEWALS").Default(defaultMaxRenewals).Int()
+
+	config.FinePerDayCents = app.
+		Flag("fine_per_day_cents", "Fine charged for every open day a copy is returned late, in cents").
+		Envar("FINE_PER_DAY_CENTS").Default(defaultFinePerDayCents).Int64()
+
+	config.MaxFineCents = app.
+		Flag("max_fine_cents", "Most a late return is fined, in cents; 0 is no cap").
+		Envar("MAX_FINE_CENTS").Default(defaultMaxFineCents).Int64()
+
+	config.MaxOutstandingCents = app.
+		Flag("max_outstanding_cents", "Most a patron may owe and still borrow, renew or place holds, in cents; 0 is no limit").
+		Envar("MAX_OUTSTANDING_CENTS").Default(defaultMaxOutstandingCents).Int64()
+
+	config.MaxOverdueLoans = app.
+		Flag("max_overdue_loans", "Most loans a patron may have overdue and still borrow, renew or place holds; 0 is no limit").
+		Envar("MAX_OVERDUE_LOANS").Default(defaultMaxOverdueLoans).Int()
+
+	config.LostAfterDays = app.
+		Flag("lost_after_days", "Days a copy may be overdue before it is declared lost; 0 turns automatic declaration off").
+		Envar("LOST_AFTER_DAYS").Default(defaultLostAfterDays).Int()
+
+	config.LostProcessingFeeCents = app.
+		Flag("lost_processing_fee_cents", "Processing fee charged for a lost copy, in cents").
+		Envar("LOST_PROCESSING_FEE_CENTS").Default(defaultLostProcessingFeeCents).Int64()
+
+	config.DefaultReplacementCents = app.
+		Flag("default_replacement_cents", "Replacement cost charged for a lost copy with no price, in cents").
+		Envar("DEFAULT_REPLACEMENT_CENTS").Default(defaultDefaultReplacementCents).Int64()
+
+	config.LostItemCheckInterval = app.
+		Flag("lost_item_check_interval", "How often overdue loans are checked for copies to declare lost; 0 turns the check off").
+		Envar("LOST_ITEM_CHECK_INTERVAL").Default(defaultLostItemCheckInterval).Duration()
+
+	config.PatronBarcodeFormats = app.
+		Flag("patron_barcode_formats", "Accepted library card barcode formats as prefix:length:check, comma-separated; new cards use the first").
+		Envar("PATRON_BARCODE_FORMATS").Default(defaultPatronBarcodeFormats).String()
+
+	config.MembershipTerms = app.
+		Flag("membership_terms", "Months a membership runs for by patron category as category:months, comma-separated; 0 never lapses").
+		Envar("MEMBERSHIP_TERMS").Default(defaultMembershipTerms).String()
+
+	config.ReadingHistoryDays = app.
+		Flag("reading_history_days", "Days returned loans stay linked to patrons who did not opt in to keeping their reading history; 0 keeps them linked").
+		Envar("READING_HISTORY_DAYS").Default(defaultReadingHistoryDays).Int()
+
+	config.ReadingHistoryPurgeInterval = app.
+		Flag("reading_history_purge_interval", "How often returned loans are checked for reading history to forget; 0 turns the purge off").
+		Envar("READING_HISTORY_PURGE_INTERVAL").Default(defaultReadingHistoryPurgeInterval).Duration()
+
+	config.BlobStoreDir = app.
+		Flag("blob_store_dir", "The directory keeping uploaded files such as condition photos").
+		Envar("BLOB_STORE_DIR").Default(defaultBlobStoreDir).String()
+
+	config.AppBaseURL = app.
+		Flag("app_base_url", "The URL of the library site, which links in account emails point to").
+		Envar("APP_BASE_URL").Default(defaultAppBaseURL).String()
+
+	config.EmailVerificationTTL = app.
+		Flag("email_verification_ttl", "How long an email verification link stays valid").
+		Envar("EMAIL_VERIFICATION_TTL").Default(defaultEmailVerificationTTL).Duration()
+
+	config.PasswordResetTTL = app.
+		Flag("password_reset_ttl", "How long a password reset link stays valid").
+		Envar("PASSWORD_RESET_TTL").Default(defaultPasswordResetTTL).Duration()
+
+	config.MailBackend = app.
+		Flag("mail_backend", "How email is delivered: smtp, or file to write it to mail_dir").
+		Envar("MAIL_BACKEND").Default(defaultMailBackend).Enum("smtp", "file")
+
+	config.MailFrom = app.
+		Flag("mail_from", "The sender of emails to patrons").
+		Envar("MAIL_FROM").Default(defaultMailFrom).String()
+
+	config.MailDir = app.
+		Flag("mail_dir", "The directory emails are written to by the file mail backend").
+		Envar("MAIL_DIR").Default(defaultMailDir).String()
+
+	config.SMTPAddr = app.
+		Flag("smtp_addr", "The host:port of the SMTP server").
+		Envar("SMTP_ADDR").String()
+
+	config.SMTPUsername = app.
+		Flag("smtp_username", "The user to authenticate to the SMTP server as; empty skips authentication").
+		Envar("SMTP_USERNAME").String()
+
+	config.SMTPPassword = app.
+		Flag("smtp_password", "The password to authenticate to the SMTP server with").
+		Envar("SMTP_PASSWORD").String()
+
+	config.NotificationChannel = app.
+		Flag("notification_channel", "How notifications reach patrons: email, or log to write them to notification_log_file").
+		Envar("NOTIFICATION_CHANNEL").Default(defaultNotificationChannel).Enum("email", "log")
+
+	config.NotificationLogFile = app.
+		Flag("notification_log_file", "The file the log notification channel appends to; empty writes to stdout").
+		Envar("NOTIFICATION_LOG_FILE").String()
+
+	config.NotificationLocale = app.
+		Flag("notification_locale", "The locale notifications are written in").
+		Envar("NOTIFICATION_LOCALE").Default(defaultNotificationLocale).String()
+
+	config.DueSoonDays = app.
+		Flag("due_soon_days", "Days before its due date a loan is reminded of; 0 turns reminders off").
+		Envar("DUE_SOON_DAYS").Default(defaultDueSoonDays).Int()
+
+	config.NotificationInterval = app.
+		Flag("notification_interval", "How often due soon, overdue and hold ready notifications are sent; 0 turns them off").
+		Envar("NOTIFICATION_INTERVAL").Default(defaultNotificationInterval).Duration()
+
+	config.WebhookInterval = app.
+		Flag("webhook_interval", "How often due webhook deliveries are attempted; 0 turns delivery off, events still queue").
+		Envar("WEBHOOK_INTERVAL").Default(defaultWebhookInterval).Duration()
+
+	config.WebhookTimeout = app.
+		Flag("webhook_timeout", "How long a webhook endpoint has to answer").
+		Envar("WEBHOOK_TIMEOUT").Default(defaultWebhookTimeout).Duration()
+
+	config.WebhookMaxAttempts = app.
+		Flag("webhook_max_attempts", "How many times a webhook delivery is tried before it is dead-lettered").
+		Envar("WEBHOOK_MAX_ATTEMPTS").Default(defaultWebhookMaxAttempts).Int()
+
+	config.WebhookRetryBaseDelay = app.
+		Flag("webhook_retry_base_delay", "The wait after the first failed webhook delivery, doubling after each failure").
+		Envar("WEBHOOK_RETRY_BASE_DELAY").Default(defaultWebhookRetryBaseDelay).Duration()
+
+	config.WebhookRetryMaxDelay = app.
+		Flag("webhook_retry_max_delay", "The longest wait between attempts to deliver a webhook").
+		Envar("WEBHOOK_RETRY_MAX_DELAY").Default(defaultWebhookRetryMaxDelay).Duration()
+
 	kingpin.MustParse(app.Parse(os.Args[1:]))
 
 	return config
@@ -152,16 +418,113 @@ func main() {
 
 	wg := sync.WaitGroup{}
 
+	notificationLog := io.Writer(os.Stdout)
+	if *cfg.NotificationLogFile != "" {
+		f, err := os.OpenFile(*cfg.NotificationLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			rootLogger.Fatal().Err(err).Msg("failed to open notification log file")
+		}
+		defer f.Close()
+		notificationLog = f
+	}
+
 	// Create application
 	app := app.MustNewApplication(rootCtx, &wg, app.ApplicationParams{
 		Env:         *cfg.Env,
 		DatabaseDSN: *cfg.DatabaseDSN,
+
+		CopyBarcodeFormats: *cfg.CopyBarcodeFormats,
+
+		LibraryTimeZone:         *cfg.LibraryTimeZone,
+		LoanDays:                *cfg.LoanDays,
+		MaxRenewals:             *cfg.MaxRenewals,
+		FinePerDayCents:         *cfg.FinePerDayCents,
+		MaxFineCents:            *cfg.MaxFineCents,
+		MaxOutstandingCents:     *cfg.MaxOutstandingCents,
+		MaxOverdueLoans:         *cfg.MaxOverdueLoans,
+		LostAfterDays:           *cfg.LostAfterDays,
+		LostProcessingFeeCents:  *cfg.LostProcessingFeeCents,
+		DefaultReplacementCents: *cfg.DefaultReplacementCents,
+
+		PatronBarcodeFormats: *cfg.PatronBarcodeFormats,
+		MembershipTerms:      *cfg.MembershipTerms,
+
+		ReadingHistoryDays: *cfg.ReadingHistoryDays,
+
+		BlobStoreDir: *cfg.BlobStoreDir,
+
+		AppBaseURL:           *cfg.AppBaseURL,
+		EmailVerificationTTL: *cfg.EmailVerificationTTL,
+		PasswordResetTTL:     *cfg.PasswordResetTTL,
+
+		MailBackend:  *cfg.MailBackend,
+		MailFrom:     *cfg.MailFrom,
+		MailDir:      *cfg.MailDir,
+		SMTPAddr:     *cfg.SMTPAddr,
+		SMTPUsername: *cfg.SMTPUsername,
+		SMTPPassword: *cfg.SMTPPassword,
+
+		NotificationChannel: *cfg.NotificationChannel,
+		NotificationLog:     notificationLog,
+		NotificationLocale:  *cfg.NotificationLocale,
+		DueSoonDays:         *cfg.DueSoonDays,
+
+		WebhookTimeout:        *cfg.WebhookTimeout,
+		WebhookMaxAttempts:    *cfg.WebhookMaxAttempts,
+		WebhookRetryBaseDelay: *cfg.WebhookRetryBaseDelay,
+		WebhookRetryMaxDelay:  *cfg.WebhookRetryMaxDelay,
 	})
 
 	// Run server
 	wg.Add(1)
 	runHTTPServer(rootCtx, &wg, *cfg.Port, app)
 
+	// Run scheduled jobs
+	if *cfg.LostAfterDays > 0 && *cfg.LostItemCheckInterval > 0 {
+		wg.Add(1)
+		runPeriodicJob(rootCtx, &wg, "declare_overdue_lost", *cfg.LostItemCheckInterval, func(ctx context.Context) {
+			declared, err := app.CirculationService.DeclareOverdueLoansLost(ctx)
+			if err != nil {
+				return
+			}
+			zerolog.Ctx(ctx).Info().Int("declared", declared).Msg("overdue loans declared lost")
+		})
+	}
+
+	if *cfg.ReadingHistoryDays > 0 && *cfg.ReadingHistoryPurgeInterval > 0 {
+		wg.Add(1)
+		runPeriodicJob(rootCtx, &wg, "purge_reading_history", *cfg.ReadingHistoryPurgeInterval, func(ctx context.Context) {
+			purged, err := app.PatronService.PurgeReadingHistory(ctx)
+			if err != nil {
+				return
+			}
+			zerolog.Ctx(ctx).Info().Int("purged", purged).Msg("reading history purged")
+		})
+	}
+
+	if *cfg.NotificationInterval > 0 {
+		wg.Add(1)
+		runPeriodicJob(rootCtx, &wg, "send_notifications", *cfg.NotificationInterval, func(ctx context.Context) {
+			sent, err := app.NotificationService.SendNotifications(ctx)
+			if err != nil {
+				return
+			}
+			zerolog.Ctx(ctx).Info().Int("sent", sent).Msg("notifications sent")
+		})
+	}
+
+	if *cfg.WebhookInterval > 0 {
+		wg.Add(1)
+		runPeriodicJob(rootCtx, &wg, "deliver_webhooks", *cfg.WebhookInterval, func(ctx context.Context) {
+			delivered, err := app.WebhookService.DeliverWebhooks(ctx)
+			// the job runs often, so quiet runs are not logged
+			if err != nil || delivered == 0 {
+				return
+			}
+			zerolog.Ctx(ctx).Info().Int("delivered", delivered).Msg("webhooks delivered")
+		})
+	}
+
 	// Listen to SIGTERM/SIGINT to close
 	var gracefulStop = make(chan os.Signal, 1)
 	signal.Notify(gracefulStop, syscall.SIGTERM, syscall.SIGINT)
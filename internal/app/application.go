@@ -2,12 +2,44 @@ package app
 
 import (
 	"context"
+	"io"
 	"log"
 	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/lzzzzl/page-turner-pro/internal/app/blobstore"
+	"github.com/lzzzzl/page-turner-pro/internal/app/mailer"
+	"github.com/lzzzzl/page-turner-pro/internal/app/notifier"
+	"github.com/lzzzzl/page-turner-pro/internal/app/repository"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/account"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/acquisition"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/catalog"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/circulation"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/importer"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/inventory"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/notification"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/patron"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/report"
+	webhooksvc "github.com/lzzzzl/page-turner-pro/internal/app/service/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/pkg/errors"
 )
 
 type Application struct {
-	Params ApplicationParams
+	Params              ApplicationParams
+	CatalogService      *catalog.CatalogService
+	CirculationService  *circulation.CirculationService
+	ImportService       *importer.ImportService
+	InventoryService    *inventory.InventoryService
+	AcquisitionService  *acquisition.AcquisitionService
+	ReportService       *report.ReportService
+	PatronService       *patron.PatronService
+	AccountService      *account.AccountService
+	NotificationService *notification.NotificationService
+	WebhookService      *webhooksvc.WebhookService
 }
 
 type ApplicationParams struct {
@@ -16,6 +48,80 @@ type ApplicationParams struct {
 
 	// Database parameters
 	DatabaseDSN string
+
+	// Catalog parameters
+	// CopyBarcodeFormats is a comma-separated list of prefix:length:check
+	// formats; new copy barcodes are generated in the first one.
+	CopyBarcodeFormats string
+
+	// Circulation parameters
+	// LibraryTimeZone is the IANA time zone opening hours and due dates fall in.
+	LibraryTimeZone string
+	LoanDays        int
+	MaxRenewals     int
+	FinePerDayCents int64
+	MaxFineCents    int64
+	// MaxOutstandingCents and MaxOverdueLoans block patrons going over
+	// them; zero turns a limit off.
+	MaxOutstandingCents int64
+	MaxOverdueLoans     int
+	// LostAfterDays is how long a copy may be overdue before it is declared
+	// lost; zero turns automatic declaration off.
+	LostAfterDays           int
+	LostProcessingFeeCents  int64
+	DefaultReplacementCents int64
+
+	// Patron parameters
+	// PatronBarcodeFormats is a comma-separated list of prefix:length:check
+	// formats; new library card barcodes are generated in the first one.
+	PatronBarcodeFormats string
+	// MembershipTerms is a comma-separated list of category:months terms.
+	MembershipTerms string
+	// ReadingHistoryDays is how long returned loans stay linked to patrons
+	// who did not opt in to keeping their reading history.
+	ReadingHistoryDays int
+
+	// Storage parameters
+	// BlobStoreDir is the directory keeping uploaded files, such as the
+	// photos of condition reports.
+	BlobStoreDir string
+
+	// Account parameters
+	// AppBaseURL is where patrons reach the library site, which the links in
+	// account emails point to.
+	AppBaseURL           string
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+
+	// Mail parameters
+	// MailBackend is smtp, or file to write emails to MailDir.
+	MailBackend  string
+	MailFrom     string
+	MailDir      string
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// Notification parameters
+	// NotificationChannel is the channel notifications go out on: email, or
+	// log to write them to NotificationLog.
+	NotificationChannel string
+	NotificationLog     io.Writer
+	// NotificationLocale is the locale notifications are written in.
+	NotificationLocale string
+	// DueSoonDays is how long before its due date a loan is reminded of;
+	// zero turns reminders off.
+	DueSoonDays int
+
+	// Webhook parameters
+	// WebhookTimeout is how long an endpoint has to answer a delivery.
+	WebhookTimeout time.Duration
+	// WebhookMaxAttempts is how many times a delivery is tried before it is
+	// dead-lettered; the wait between attempts doubles from
+	// WebhookRetryBaseDelay up to WebhookRetryMaxDelay.
+	WebhookMaxAttempts    int
+	WebhookRetryBaseDelay time.Duration
+	WebhookRetryMaxDelay  time.Duration
 }
 
 func MustNewApplication(ctx context.Context, wg *sync.WaitGroup, params ApplicationParams) *Application {
@@ -27,10 +133,216 @@ func MustNewApplication(ctx context.Context, wg *sync.WaitGroup, params Applicat
 }
 
 func NewApplication(ctx context.Context, wg *sync.WaitGroup, params ApplicationParams) (*Application, error) {
+	barcodeFormats, err := model.ParseBarcodeFormats(params.CopyBarcodeFormats)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid copy barcode formats")
+	}
+
+	patronBarcodeFormats, err := model.ParseBarcodeFormats(params.PatronBarcodeFormats)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid patron barcode formats")
+	}
+
+	membershipTerms, err := model.ParseMembershipTerms(params.MembershipTerms)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid membership terms")
+	}
+
+	location, err := time.LoadLocation(params.LibraryTimeZone)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid library time zone")
+	}
+
+	photoStore, err := blobstore.NewLocalStore(params.BlobStoreDir)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open blob store")
+	}
+
+	mail, err := newMailer(params)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to set up mailer")
+	}
+
+	templates, err := notifier.LoadTemplates()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load notification templates")
+	}
+	if !templates.HasLocale(params.NotificationLocale) {
+		return nil, errors.Errorf("no notification templates in locale %q", params.NotificationLocale)
+	}
+	notificationChannel, err := model.ParseNotificationChannel(params.NotificationChannel)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid notification channel")
+	}
+	if params.WebhookMaxAttempts < 1 || params.WebhookTimeout <= 0 {
+		return nil, errors.New("webhooks need at least one attempt and a timeout")
+	}
+
+	// Create repositories
+	db, err := sqlx.Connect("postgres", params.DatabaseDSN)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to connect to database")
+	}
+	pgRepo := repository.NewPostgresRepository(ctx, db)
+	if err := pgRepo.CheckBookStatusEnum(ctx); err != nil {
+		return nil, errors.WithMessage(err, "failed to check database schema")
+	}
+
+	webhookService := webhooksvc.NewWebhookService(ctx, webhooksvc.WebhookServiceParam{
+		WebhookRepo: pgRepo,
+
+		Sender: webhook.NewClient(params.WebhookTimeout),
+		RetryPolicy: model.WebhookRetryPolicy{
+			MaxAttempts: params.WebhookMaxAttempts,
+			BaseDelay:   params.WebhookRetryBaseDelay,
+			MaxDelay:    params.WebhookRetryMaxDelay,
+		},
+		SendTimeout: params.WebhookTimeout,
+	})
+
 	// Create application
 	app := &Application{
-		Params: params,
+		Params:         params,
+		WebhookService: webhookService,
+		CatalogService: catalog.NewCatalogService(ctx, catalog.CatalogServiceParam{
+			WorkRepo:     pgRepo,
+			BookRepo:     pgRepo,
+			SubjectRepo:  pgRepo,
+			ShelfRepo:    pgRepo,
+			ExchangeRepo: pgRepo,
+			CopyRepo:     pgRepo,
+			BranchRepo:   pgRepo,
+			LabelRepo:    pgRepo,
+
+			EventPublisher: webhookService,
+			BarcodeFormats: barcodeFormats,
+		}),
+		CirculationService: circulation.NewCirculationService(ctx, circulation.CirculationServiceParam{
+			HoldRepo:      pgRepo,
+			WorkRepo:      pgRepo,
+			UserRepo:      pgRepo,
+			BranchRepo:    pgRepo,
+			BookRepo:      pgRepo,
+			CopyRepo:      pgRepo,
+			CalendarRepo:  pgRepo,
+			LoanRepo:      pgRepo,
+			ChargeRepo:    pgRepo,
+			StandingRepo:  pgRepo,
+			HouseholdRepo: pgRepo,
+
+			EventPublisher: webhookService,
+			Location:       location,
+			LoanPolicy: model.LoanPolicy{
+				LoanDays:        params.LoanDays,
+				MaxRenewals:     params.MaxRenewals,
+				FinePerDayCents: params.FinePerDayCents,
+				MaxFineCents:    params.MaxFineCents,
+			},
+			StandingPolicy: model.StandingPolicy{
+				MaxOutstandingCents: params.MaxOutstandingCents,
+				MaxOverdueLoans:     params.MaxOverdueLoans,
+				Location:            location,
+			},
+			LostItemPolicy: model.LostItemPolicy{
+				LostAfterDays:           params.LostAfterDays,
+				ProcessingFeeCents:      params.LostProcessingFeeCents,
+				DefaultReplacementCents: params.DefaultReplacementCents,
+			},
+		}),
+		ReportService: report.NewReportService(ctx, report.ReportServiceParam{
+			WeedingRepo: pgRepo,
+			BranchRepo:  pgRepo,
+			SubjectRepo: pgRepo,
+		}),
+		PatronService: patron.NewPatronService(ctx, patron.PatronServiceParam{
+			UserRepo:         pgRepo,
+			MembershipRepo:   pgRepo,
+			CardRepo:         pgRepo,
+			HouseholdRepo:    pgRepo,
+			LoanRepo:         pgRepo,
+			HoldRepo:         pgRepo,
+			ChargeRepo:       pgRepo,
+			PrivacyRepo:      pgRepo,
+			NotificationRepo: pgRepo,
+
+			Location:           location,
+			BarcodeFormats:     patronBarcodeFormats,
+			MembershipTerms:    membershipTerms,
+			ReadingHistoryDays: params.ReadingHistoryDays,
+		}),
+		AccountService: account.NewAccountService(ctx, account.AccountServiceParam{
+			UserRepo:    pgRepo,
+			AccountRepo: pgRepo,
+			Mailer:      mail,
+
+			BaseURL:         params.AppBaseURL,
+			VerificationTTL: params.EmailVerificationTTL,
+			ResetTTL:        params.PasswordResetTTL,
+		}),
+		NotificationService: notification.NewNotificationService(ctx, notification.NotificationServiceParam{
+			NotificationRepo: pgRepo,
+			PreferenceRepo:   pgRepo,
+			UserRepo:         pgRepo,
+
+			Templates: templates,
+			Channels: map[model.NotificationChannel]notifier.Channel{
+				model.ChannelEmail: notifier.NewEmailChannel(mail),
+				model.ChannelLog:   notifier.NewLogChannel(params.NotificationLog),
+			},
+			Channel:     notificationChannel,
+			Locale:      params.NotificationLocale,
+			Location:    location,
+			DueSoonDays: params.DueSoonDays,
+		}),
+		ImportService: importer.NewImportService(ctx, importer.ImportServiceParam{
+			BulkRepo:   pgRepo,
+			ReportRepo: pgRepo,
+
+			BarcodeFormats: barcodeFormats,
+			EventPublisher: webhookService,
+		}),
 	}
+	app.InventoryService = inventory.NewInventoryService(ctx, inventory.InventoryServiceParam{
+		BranchRepo:    pgRepo,
+		TransferRepo:  pgRepo,
+		StocktakeRepo: pgRepo,
+		BookRepo:      pgRepo,
+		CopyRepo:      pgRepo,
+		WorkRepo:      pgRepo,
+		ConditionRepo: pgRepo,
+		RepairRepo:    pgRepo,
+		VendorRepo:    pgRepo,
+		CalendarRepo:  pgRepo,
+		HoldRepo:      pgRepo,
+		HoldTrapper:   app.CirculationService,
+		PhotoStore:    photoStore,
+		Location:      location,
+
+		EventPublisher: webhookService,
+	})
+	app.AcquisitionService = acquisition.NewAcquisitionService(ctx, acquisition.AcquisitionServiceParam{
+		VendorRepo: pgRepo,
+		FundRepo:   pgRepo,
+		OrderRepo:  pgRepo,
+		BookRepo:   pgRepo,
+		BranchRepo: pgRepo,
+		CopyRepo:   pgRepo,
+
+		HoldTrapper:    app.CirculationService,
+		BarcodeFormats: barcodeFormats,
+	})
 
 	return app, nil
 }
+
+// newMailer sets up the mail backend picked in params.
+func newMailer(params ApplicationParams) (mailer.Mailer, error) {
+	switch params.MailBackend {
+	case "smtp":
+		return mailer.NewSMTPMailer(params.SMTPAddr, params.SMTPUsername, params.SMTPPassword, params.MailFrom)
+	case "file":
+		return mailer.NewFileMailer(params.MailDir, params.MailFrom)
+	default:
+		return nil, errors.Errorf("unknown mail backend %q", params.MailBackend)
+	}
+}
@@ -0,0 +1,42 @@
+// Package blobstore keeps binary objects, such as photos, out of the
+// database. Objects are addressed by slash-separated keys.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned for a key holding no object.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a backend keeping objects by key.
+type Store interface {
+	// Put writes an object, replacing any object under the key.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens an object for reading; the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidateKey checks a key is a relative slash-separated path with no empty,
+// "." or ".." segment, so that no backend can be led outside its root.
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("blob key is empty")
+	}
+	for _, segment := range strings.Split(key, "/") {
+		switch segment {
+		case "", ".", "..":
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+		if strings.ContainsAny(segment, "\\\x00") {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
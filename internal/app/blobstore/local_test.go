@@ -0,0 +1,45 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "condition/1/a.jpg", strings.NewReader("photo")))
+	rc, err := store.Get(ctx, "condition/1/a.jpg")
+	require.NoError(t, err)
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, "photo", string(content))
+
+	// a second put replaces the object
+	require.NoError(t, store.Put(ctx, "condition/1/a.jpg", strings.NewReader("retake")))
+	rc, err = store.Get(ctx, "condition/1/a.jpg")
+	require.NoError(t, err)
+	content, _ = io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "retake", string(content))
+
+	require.NoError(t, store.Delete(ctx, "condition/1/a.jpg"))
+	require.NoError(t, store.Delete(ctx, "condition/1/a.jpg"))
+	_, err = store.Get(ctx, "condition/1/a.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestValidateKey(t *testing.T) {
+	assert.NoError(t, ValidateKey("condition/1/a.jpg"))
+	for _, key := range []string{"", "/etc/passwd", "../a", "a/../../b", "a//b", "a/./b", "a\\b"} {
+		assert.Error(t, ValidateKey(key), key)
+	}
+}
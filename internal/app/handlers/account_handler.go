@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/account"
+)
+
+func signupHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Email    string `json:"email" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		user, err := app.AccountService.Signup(c.Request.Context(), account.SignupParam{
+			Email:    body.Email,
+			Name:     body.Name,
+			Password: body.Password,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newPatronResponse(*user))
+	}
+}
+
+func verifyEmailHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		user, err := app.AccountService.VerifyEmail(c.Request.Context(), body.Token)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPatronResponse(*user))
+	}
+}
+
+// resendVerificationHandler answers the same whether or not the address is
+// known, so it cannot be used to find out who is a patron.
+func resendVerificationHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		if err := app.AccountService.ResendVerification(c.Request.Context(), body.Email); err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithoutBody(c, http.StatusAccepted)
+	}
+}
+
+// requestPasswordResetHandler answers the same whether or not the address is
+// known, so it cannot be used to find out who is a patron.
+func requestPasswordResetHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		if err := app.AccountService.RequestPasswordReset(c.Request.Context(), body.Email); err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithoutBody(c, http.StatusAccepted)
+	}
+}
+
+func resetPasswordHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		user, err := app.AccountService.ResetPassword(c.Request.Context(), body.Token, body.Password)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPatronResponse(*user))
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/inventory"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type branchResponse struct {
+	ID        int       `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address,omitempty"`
+	IsMain    bool      `json:"is_main"`
+	Latitude  *float64  `json:"latitude,omitempty"`
+	Longitude *float64  `json:"longitude,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newBranchResponse(b model.Branch) branchResponse {
+	resp := branchResponse{
+		ID:        b.ID,
+		Code:      b.Code,
+		Name:      b.Name,
+		Address:   b.Address,
+		IsMain:    b.IsMain,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+	}
+	if b.Location != nil {
+		lat, lng := b.Location.Lat(), b.Location.Lon()
+		resp.Latitude, resp.Longitude = &lat, &lng
+	}
+	return resp
+}
+
+type branchAvailabilityResponse struct {
+	BranchID   int                      `json:"branch_id"`
+	BranchCode string                   `json:"branch_code"`
+	BranchName string                   `json:"branch_name"`
+	Total      int                      `json:"total"`
+	Available  int                      `json:"available"`
+	ByStatus   map[model.BookStatus]int `json:"by_status"`
+}
+
+func newBranchAvailabilityResponses(availability []*model.BranchAvailability) []branchAvailabilityResponse {
+	resp := make([]branchAvailabilityResponse, 0, len(availability))
+	for _, a := range availability {
+		resp = append(resp, branchAvailabilityResponse{
+			BranchID:   a.BranchID,
+			BranchCode: a.BranchCode,
+			BranchName: a.BranchName,
+			Total:      a.Total(),
+			Available:  a.Available(),
+			ByStatus:   a.ByStatus,
+		})
+	}
+	return resp
+}
+
+type branchBody struct {
+	Code      string   `json:"code" binding:"required"`
+	Name      string   `json:"name" binding:"required"`
+	Address   string   `json:"address"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+func (b branchBody) toParam() inventory.BranchParam {
+	return inventory.BranchParam{
+		Code:      b.Code,
+		Name:      b.Name,
+		Address:   b.Address,
+		Latitude:  b.Latitude,
+		Longitude: b.Longitude,
+	}
+}
+
+func listBranchesHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		branches, err := app.InventoryService.ListBranches(c.Request.Context())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]branchResponse, 0, len(branches))
+		for _, b := range branches {
+			resp = append(resp, newBranchResponse(*b))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func createBranchHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body branchBody
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		branch, err := app.InventoryService.CreateBranch(c.Request.Context(), body.toParam())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newBranchResponse(*branch))
+	}
+}
+
+func getBranchHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		branch, err := app.InventoryService.GetBranch(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBranchResponse(*branch))
+	}
+}
+
+func updateBranchHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body branchBody
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		branch, err := app.InventoryService.UpdateBranch(c.Request.Context(), id, body.toParam())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBranchResponse(*branch))
+	}
+}
+
+func getWorkAvailabilityHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		availability, err := app.InventoryService.GetWorkAvailability(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBranchAvailabilityResponses(availability))
+	}
+}
+
+func getBookAvailabilityHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		availability, err := app.InventoryService.GetBookAvailability(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBranchAvailabilityResponses(availability))
+	}
+}
+
+func findNearestBranchesHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Latitude  *float64 `form:"lat" binding:"required"`
+		Longitude *float64 `form:"lng" binding:"required"`
+		Limit     int      `form:"limit"`
+	}
+	type Response struct {
+		branchResponse
+		Available      int     `json:"available"`
+		DistanceMeters float64 `json:"distance_meters"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+		from, err := model.NewLocation(*query.Latitude, *query.Longitude)
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		nearby, cErr := app.InventoryService.FindNearestBranchesWithCopy(c.Request.Context(), id, from, query.Limit)
+		if cErr != nil {
+			respondWithError(c, cErr)
+			return
+		}
+
+		resp := make([]Response, 0, len(nearby))
+		for _, n := range nearby {
+			resp = append(resp, Response{
+				branchResponse: newBranchResponse(n.Branch),
+				Available:      n.Available,
+				DistanceMeters: math.Round(n.Meters),
+			})
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
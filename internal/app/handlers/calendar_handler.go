@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/inventory"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type openingHoursResponse struct {
+	// Weekday counts from 0 for Sunday.
+	Weekday time.Weekday `json:"weekday"`
+	Day     string       `json:"day"`
+	Opens   string       `json:"opens"`
+	Closes  string       `json:"closes"`
+}
+
+func newOpeningHoursResponses(hours []model.OpeningHours) []openingHoursResponse {
+	resp := make([]openingHoursResponse, 0, len(hours))
+	for _, h := range hours {
+		resp = append(resp, openingHoursResponse{
+			Weekday: h.Weekday,
+			Day:     h.Weekday.String(),
+			Opens:   h.Opens.String(),
+			Closes:  h.Closes.String(),
+		})
+	}
+	return resp
+}
+
+type closureResponse struct {
+	ID        int               `json:"id"`
+	BranchID  *int              `json:"branch_id,omitempty"`
+	Kind      model.ClosureKind `json:"kind"`
+	Name      string            `json:"name"`
+	StartDate string            `json:"start_date"`
+	EndDate   string            `json:"end_date"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func newClosureResponse(c model.Closure) closureResponse {
+	return closureResponse{
+		ID:        c.ID,
+		BranchID:  c.BranchID,
+		Kind:      c.Kind,
+		Name:      c.Name,
+		StartDate: c.StartDate.Format(dateLayout),
+		EndDate:   c.EndDate.Format(dateLayout),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func newClosureResponses(closures []*model.Closure) []closureResponse {
+	resp := make([]closureResponse, 0, len(closures))
+	for _, c := range closures {
+		resp = append(resp, newClosureResponse(*c))
+	}
+	return resp
+}
+
+func listOpeningHoursHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		hours, err := app.InventoryService.ListOpeningHours(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newOpeningHoursResponses(hours))
+	}
+}
+
+func setOpeningHoursHandler(app *app.Application) gin.HandlerFunc {
+	type Hours struct {
+		Weekday *time.Weekday `json:"weekday" binding:"required,min=0,max=6"`
+		Opens   string        `json:"opens" binding:"required"`
+		Closes  string        `json:"closes" binding:"required"`
+	}
+	type Body struct {
+		Hours []Hours `json:"hours" binding:"dive"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+		hours := make([]model.OpeningHours, 0, len(body.Hours))
+		for _, h := range body.Hours {
+			opens, err := model.ParseTimeOfDay(h.Opens)
+			if err != nil {
+				respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+				return
+			}
+			closes, err := model.ParseTimeOfDay(h.Closes)
+			if err != nil {
+				respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+				return
+			}
+			hours = append(hours, model.OpeningHours{Weekday: *h.Weekday, Opens: opens, Closes: closes})
+		}
+
+		set, err := app.InventoryService.SetOpeningHours(c.Request.Context(), id, hours)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newOpeningHoursResponses(set))
+	}
+}
+
+func createClosureHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		BranchID  *int              `json:"branch_id"`
+		Kind      model.ClosureKind `json:"kind" binding:"required"`
+		Name      string            `json:"name" binding:"required"`
+		StartDate string            `json:"start_date" binding:"required"`
+		EndDate   string            `json:"end_date" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+		startDate, ok := parseDate(c, "start_date", body.StartDate)
+		if !ok {
+			return
+		}
+		endDate, ok := parseDate(c, "end_date", body.EndDate)
+		if !ok {
+			return
+		}
+
+		closure, err := app.InventoryService.CreateClosure(c.Request.Context(), inventory.ClosureParam{
+			BranchID:  body.BranchID,
+			Kind:      body.Kind,
+			Name:      body.Name,
+			StartDate: startDate,
+			EndDate:   endDate,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newClosureResponse(*closure))
+	}
+}
+
+func listClosuresHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		BranchID int    `form:"branch_id"`
+		From     string `form:"from"`
+		To       string `form:"to"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+		// the closures of the coming year by default
+		from := time.Now()
+		to := from.AddDate(1, 0, 0)
+		var ok bool
+		if query.From != "" {
+			if from, ok = parseDate(c, "from", query.From); !ok {
+				return
+			}
+		}
+		if query.To != "" {
+			if to, ok = parseDate(c, "to", query.To); !ok {
+				return
+			}
+		}
+
+		closures, err := app.InventoryService.ListClosures(c.Request.Context(), query.BranchID, from, to)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newClosureResponses(closures))
+	}
+}
+
+func deleteClosureHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		if err := app.InventoryService.DeleteClosure(c.Request.Context(), id); err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithoutBody(c, http.StatusNoContent)
+	}
+}
+
+func exportBranchCalendarHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="branch-%d.ics"`, id))
+		if err := app.InventoryService.WriteBranchCalendar(c.Request.Context(), id, c.Writer); err != nil {
+			c.Header("Content-Disposition", "")
+			respondWithError(c, err)
+		}
+	}
+}
+
+// parseDate parses a calendar day of a request, responding with an error
+// when it is malformed.
+func parseDate(c *gin.Context, name, raw string) (time.Time, bool) {
+	t, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		msg := fmt.Sprintf("%s must be a date as YYYY-MM-DD", name)
+		respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg)))
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type chargeResponse struct {
+	ID             int              `json:"id"`
+	UserID         int              `json:"user_id"`
+	LoanID         *int             `json:"loan_id,omitempty"`
+	CopyID         *int             `json:"copy_id,omitempty"`
+	Kind           model.ChargeKind `json:"kind"`
+	AmountCents    int64            `json:"amount_cents"`
+	Description    string           `json:"description"`
+	CreatedBy      string           `json:"created_by"`
+	ReversedAt     *time.Time       `json:"reversed_at,omitempty"`
+	ReversedBy     string           `json:"reversed_by,omitempty"`
+	ReversalReason string           `json:"reversal_reason,omitempty"`
+	PaidAt         *time.Time       `json:"paid_at,omitempty"`
+	PaidByUserID   *int             `json:"paid_by_user_id,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+func newChargeResponse(c model.Charge) chargeResponse {
+	return chargeResponse(c)
+}
+
+func newChargeResponses(charges []*model.Charge) []chargeResponse {
+	resp := make([]chargeResponse, 0, len(charges))
+	for _, c := range charges {
+		resp = append(resp, newChargeResponse(*c))
+	}
+	return resp
+}
+
+func declareCopyLostHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		DeclaredBy string `json:"declared_by" binding:"required"`
+		Reason     string `json:"reason"`
+	}
+	type Response struct {
+		Loan    loanResponse     `json:"loan"`
+		Charges []chargeResponse `json:"charges"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		loan, charges, err := app.CirculationService.DeclareCopyLost(c.Request.Context(), id, body.DeclaredBy, body.Reason)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, Response{
+			Loan:    newLoanResponse(*loan),
+			Charges: newChargeResponses(charges),
+		})
+	}
+}
+
+func returnLostCopyHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		ChangedBy string `json:"changed_by" binding:"required"`
+	}
+	type Response struct {
+		Copy            bookCopyResponse `json:"copy"`
+		ReversedCharges []chargeResponse `json:"reversed_charges"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		bookCopy, reversed, err := app.CirculationService.ReturnLostCopy(c.Request.Context(), id, body.ChangedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, Response{
+			Copy:            newBookCopyResponse(*bookCopy),
+			ReversedCharges: newChargeResponses(reversed),
+		})
+	}
+}
+
+func listUserChargesHandler(app *app.Application) gin.HandlerFunc {
+	type Response struct {
+		OutstandingCents int64            `json:"outstanding_cents"`
+		Charges          []chargeResponse `json:"charges"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		charges, err := app.CirculationService.ListUserCharges(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, Response{
+			OutstandingCents: model.OutstandingCents(charges),
+			Charges:          newChargeResponses(charges),
+		})
+	}
+}
+
+func getChargeHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		charge, err := app.CirculationService.GetCharge(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newChargeResponse(*charge))
+	}
+}
+
+func reverseChargeHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		ReversedBy string `json:"reversed_by" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		charge, err := app.CirculationService.ReverseCharge(c.Request.Context(), id, body.ReversedBy, body.Reason)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newChargeResponse(*charge))
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/inventory"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+// dateLayout is the layout of calendar days in requests.
+const dateLayout = "2006-01-02"
+
+type conditionPhotoResponse struct {
+	ID          int       `json:"id"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type conditionReportResponse struct {
+	ID         int                      `json:"id"`
+	CopyID     int                      `json:"copy_id"`
+	Note       string                   `json:"note,omitempty"`
+	Damaged    bool                     `json:"damaged"`
+	RecordedBy string                   `json:"recorded_by"`
+	ChargeID   *int                     `json:"charge_id,omitempty"`
+	Photos     []conditionPhotoResponse `json:"photos"`
+	CreatedAt  time.Time                `json:"created_at"`
+}
+
+func newConditionReportResponse(r model.ConditionReport) conditionReportResponse {
+	resp := conditionReportResponse{
+		ID:         r.ID,
+		CopyID:     r.CopyID,
+		Note:       r.Note,
+		Damaged:    r.Damaged,
+		RecordedBy: r.RecordedBy,
+		ChargeID:   r.ChargeID,
+		Photos:     make([]conditionPhotoResponse, 0, len(r.Photos)),
+		CreatedAt:  r.CreatedAt,
+	}
+	for _, p := range r.Photos {
+		resp.Photos = append(resp.Photos, conditionPhotoResponse{
+			ID:          p.ID,
+			ContentType: p.ContentType,
+			SizeBytes:   p.SizeBytes,
+			URL:         fmt.Sprintf("/api/v1/condition_photos/%d", p.ID),
+			CreatedAt:   p.CreatedAt,
+		})
+	}
+	return resp
+}
+
+// recordConditionHandler takes a multipart form, with any number of image
+// files in the photos field.
+func recordConditionHandler(app *app.Application) gin.HandlerFunc {
+	type Form struct {
+		Note        string `form:"note"`
+		Damaged     bool   `form:"damaged"`
+		ChargeCents int64  `form:"charge_cents"`
+		RecordedBy  string `form:"recorded_by" binding:"required"`
+	}
+	type Response struct {
+		Report conditionReportResponse `json:"report"`
+		Charge *chargeResponse         `json:"charge,omitempty"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var form Form
+		if err := c.ShouldBind(&form); err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		var fileHeaders []*multipart.FileHeader
+		if c.Request.MultipartForm != nil {
+			fileHeaders = c.Request.MultipartForm.File["photos"]
+		}
+		photos := make([]inventory.PhotoParam, 0, len(fileHeaders))
+		for _, fh := range fileHeaders {
+			file, err := fh.Open()
+			if err != nil {
+				respondWithError(c, common.NewError(common.ErrorCodeInternalProcess, err))
+				return
+			}
+			defer file.Close()
+			photos = append(photos, inventory.PhotoParam{Size: fh.Size, Content: file})
+		}
+
+		report, charge, err := app.InventoryService.RecordCondition(c.Request.Context(), inventory.RecordConditionParam{
+			CopyID:      id,
+			Note:        form.Note,
+			Damaged:     form.Damaged,
+			Photos:      photos,
+			ChargeCents: form.ChargeCents,
+			RecordedBy:  form.RecordedBy,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := Response{Report: newConditionReportResponse(*report)}
+		if charge != nil {
+			chargeResp := newChargeResponse(*charge)
+			resp.Charge = &chargeResp
+		}
+		respondWithJSON(c, http.StatusCreated, resp)
+	}
+}
+
+func listConditionReportsHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		reports, err := app.InventoryService.ListConditionReports(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]conditionReportResponse, 0, len(reports))
+		for _, r := range reports {
+			resp = append(resp, newConditionReportResponse(*r))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func getConditionPhotoHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		photo, content, err := app.InventoryService.OpenConditionPhoto(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		defer content.Close()
+
+		c.DataFromReader(http.StatusOK, photo.SizeBytes, photo.ContentType, content, nil)
+	}
+}
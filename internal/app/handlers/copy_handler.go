@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/catalog"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type bookStatusChangeResponse struct {
+	ID        int               `json:"id"`
+	CopyID    int               `json:"copy_id"`
+	From      *model.BookStatus `json:"from,omitempty"`
+	To        model.BookStatus  `json:"to"`
+	ChangedBy string            `json:"changed_by"`
+	Reason    string            `json:"reason,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func newBookStatusChangeResponse(change model.BookStatusChange) bookStatusChangeResponse {
+	return bookStatusChangeResponse{
+		ID:        change.ID,
+		CopyID:    change.CopyID,
+		From:      change.From,
+		To:        change.To,
+		ChangedBy: change.ChangedBy,
+		Reason:    change.Reason,
+		CreatedAt: change.CreatedAt,
+	}
+}
+
+type bookCopyResponse struct {
+	ID              int              `json:"id"`
+	BookID          int              `json:"book_id"`
+	Barcode         string           `json:"barcode,omitempty"`
+	HomeBranchID    int              `json:"home_branch_id"`
+	CurrentBranchID int              `json:"current_branch_id"`
+	Status          model.BookStatus `json:"status"`
+	CallNumber      string           `json:"call_number,omitempty"`
+	PriceCents      *int64           `json:"price_cents,omitempty"`
+	RetiredAt       *time.Time       `json:"retired_at,omitempty"`
+	RetiredReason   string           `json:"retired_reason,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+func newBookCopyResponse(c model.BookCopies) bookCopyResponse {
+	resp := bookCopyResponse{
+		ID:              c.ID,
+		BookID:          c.BookID,
+		Barcode:         c.Barcode,
+		HomeBranchID:    c.HomeBranchID,
+		CurrentBranchID: c.CurrentBranchID,
+		Status:          c.Status,
+		PriceCents:      c.PriceCents,
+		RetiredAt:       c.RetiredAt,
+		RetiredReason:   c.RetiredReason,
+		CreatedAt:       c.CreatedAt,
+		UpdatedAt:       c.UpdatedAt,
+	}
+	if c.CallNumber != nil {
+		resp.CallNumber = c.CallNumber.Raw
+	}
+	return resp
+}
+
+func addBookCopyHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Barcode      string `json:"barcode"`
+		CallNumber   string `json:"call_number"`
+		HomeBranchID int    `json:"home_branch_id"`
+		PriceCents   *int64 `json:"price_cents"`
+		ChangedBy    string `json:"changed_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		bookCopy, err := app.CatalogService.AddBookCopy(c.Request.Context(), catalog.AddBookCopyParam{
+			BookID:       id,
+			Barcode:      body.Barcode,
+			CallNumber:   body.CallNumber,
+			HomeBranchID: body.HomeBranchID,
+			PriceCents:   body.PriceCents,
+			ChangedBy:    body.ChangedBy,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newBookCopyResponse(*bookCopy))
+	}
+}
+
+func listBookCopiesHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		IncludeRetired bool `form:"include_retired"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		copies, err := app.CatalogService.ListBookCopies(c.Request.Context(), id, query.IncludeRetired)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]bookCopyResponse, 0, len(copies))
+		for _, bookCopy := range copies {
+			resp = append(resp, newBookCopyResponse(*bookCopy))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func getBookCopyHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		bookCopy, err := app.CatalogService.GetBookCopy(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBookCopyResponse(*bookCopy))
+	}
+}
+
+func lookupBookCopyHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Barcode string `form:"barcode" binding:"required"`
+	}
+	type Response struct {
+		bookCopyResponse
+		Book bookResponse `json:"book"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		bookCopy, book, err := app.CatalogService.LookupBookCopy(c.Request.Context(), query.Barcode)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, Response{
+			bookCopyResponse: newBookCopyResponse(*bookCopy),
+			Book:             newBookResponse(*book),
+		})
+	}
+}
+
+func retireBookCopyHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Reason    string `json:"reason" binding:"required"`
+		ChangedBy string `json:"changed_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		bookCopy, err := app.CatalogService.RetireBookCopy(c.Request.Context(), id, body.ChangedBy, body.Reason)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBookCopyResponse(*bookCopy))
+	}
+}
+
+func changeBookCopyStatusHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Status    *model.BookStatus `json:"status" binding:"required"`
+		ChangedBy string            `json:"changed_by" binding:"required"`
+		Reason    string            `json:"reason"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		bookCopy, err := app.CatalogService.ChangeBookCopyStatus(c.Request.Context(), catalog.ChangeCopyStatusParam{
+			CopyID:    id,
+			Status:    *body.Status,
+			ChangedBy: body.ChangedBy,
+			Reason:    body.Reason,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBookCopyResponse(*bookCopy))
+	}
+}
+
+func listBookCopyStatusHistoryHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		changes, err := app.CatalogService.ListBookCopyStatusHistory(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]bookStatusChangeResponse, 0, len(changes))
+		for _, change := range changes {
+			resp = append(resp, newBookStatusChangeResponse(*change))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func setBookCopyPriceHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		// PriceCents clears the price when null.
+		PriceCents *int64 `json:"price_cents"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		bookCopy, err := app.CatalogService.SetBookCopyPrice(c.Request.Context(), id, body.PriceCents)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBookCopyResponse(*bookCopy))
+	}
+}
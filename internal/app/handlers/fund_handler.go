@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/acquisition"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type fundResponse struct {
+	ID              int       `json:"id"`
+	Code            string    `json:"code"`
+	Name            string    `json:"name"`
+	FiscalYear      int       `json:"fiscal_year"`
+	BudgetCents     int64     `json:"budget_cents"`
+	EncumberedCents int64     `json:"encumbered_cents"`
+	SpentCents      int64     `json:"spent_cents"`
+	AvailableCents  int64     `json:"available_cents"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func newFundResponse(f model.Fund) fundResponse {
+	return fundResponse{
+		ID:              f.ID,
+		Code:            f.Code,
+		Name:            f.Name,
+		FiscalYear:      f.FiscalYear,
+		BudgetCents:     f.BudgetCents,
+		EncumberedCents: f.EncumberedCents,
+		SpentCents:      f.SpentCents,
+		AvailableCents:  f.AvailableCents(),
+		CreatedAt:       f.CreatedAt,
+		UpdatedAt:       f.UpdatedAt,
+	}
+}
+
+type fundBody struct {
+	Code        string `json:"code" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	FiscalYear  int    `json:"fiscal_year" binding:"required"`
+	BudgetCents int64  `json:"budget_cents"`
+}
+
+func (b fundBody) toParam() acquisition.FundParam {
+	return acquisition.FundParam(b)
+}
+
+func listFundsHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		FiscalYear int `form:"fiscal_year"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		funds, err := app.AcquisitionService.ListFunds(c.Request.Context(), query.FiscalYear)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]fundResponse, 0, len(funds))
+		for _, f := range funds {
+			resp = append(resp, newFundResponse(*f))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func createFundHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body fundBody
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		fund, err := app.AcquisitionService.CreateFund(c.Request.Context(), body.toParam())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newFundResponse(*fund))
+	}
+}
+
+func getFundHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		fund, err := app.AcquisitionService.GetFund(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newFundResponse(*fund))
+	}
+}
+
+func updateFundHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body fundBody
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		fund, err := app.AcquisitionService.UpdateFund(c.Request.Context(), id, body.toParam())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newFundResponse(*fund))
+	}
+}
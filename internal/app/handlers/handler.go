@@ -16,4 +16,194 @@ func registerHandlers(router *gin.Engine, app *app.Application) {
 
 	// Add health-check
 	v1.GET("/health", healthCheckHandler())
+
+	// Add catalog namespace
+	v1.GET("/works", searchWorksHandler(app))
+	v1.POST("/works", createWorkHandler(app))
+	v1.GET("/works/:id", getWorkHandler(app))
+	v1.POST("/books", createBookHandler(app))
+	v1.GET("/books/:id", getBookHandler(app))
+	v1.GET("/books/:id/subjects", listBookSubjectsHandler(app))
+	v1.PUT("/books/:id/subjects", setBookSubjectsHandler(app))
+	v1.PUT("/books/:id/call_number", setBookCallNumberHandler(app))
+
+	// Add copy inventory namespace
+	v1.GET("/books/:id/copies", listBookCopiesHandler(app))
+	v1.POST("/books/:id/copies", addBookCopyHandler(app))
+	v1.GET("/copies", lookupBookCopyHandler(app))
+	v1.GET("/copies/:id", getBookCopyHandler(app))
+	v1.POST("/copies/:id/retire", retireBookCopyHandler(app))
+	v1.PUT("/copies/:id/status", changeBookCopyStatusHandler(app))
+	v1.GET("/copies/:id/status_history", listBookCopyStatusHistoryHandler(app))
+	v1.PUT("/copies/:id/price", setBookCopyPriceHandler(app))
+
+	// Add label printing namespace
+	v1.GET("/copies/:id/label", getCopyLabelHandler(app))
+	v1.GET("/labels/stocks", listLabelStocksHandler(app))
+	v1.POST("/labels/sheets", printLabelSheetsHandler(app))
+
+	// Add branch namespace
+	v1.GET("/branches", listBranchesHandler(app))
+	v1.POST("/branches", createBranchHandler(app))
+	v1.GET("/branches/:id", getBranchHandler(app))
+	v1.PUT("/branches/:id", updateBranchHandler(app))
+	v1.GET("/works/:id/availability", getWorkAvailabilityHandler(app))
+	v1.GET("/books/:id/availability", getBookAvailabilityHandler(app))
+	v1.GET("/books/:id/nearest_branches", findNearestBranchesHandler(app))
+
+	// Add library calendar namespace
+	v1.GET("/branches/:id/hours", listOpeningHoursHandler(app))
+	v1.PUT("/branches/:id/hours", setOpeningHoursHandler(app))
+	v1.GET("/branches/:id/calendar.ics", exportBranchCalendarHandler(app))
+	v1.GET("/closures", listClosuresHandler(app))
+	v1.POST("/closures", createClosureHandler(app))
+	v1.DELETE("/closures/:id", deleteClosureHandler(app))
+
+	// Add transfer namespace
+	v1.GET("/transfers", listTransfersHandler(app))
+	v1.POST("/transfers", requestTransferHandler(app))
+	v1.GET("/transfers/:id", getTransferHandler(app))
+	v1.POST("/transfers/:id/dispatch", dispatchTransferHandler(app))
+	v1.POST("/transfers/:id/receive", receiveTransferHandler(app))
+	v1.POST("/transfers/:id/cancel", cancelTransferHandler(app))
+
+	// Add stocktake namespace
+	v1.GET("/stocktakes", listStocktakesHandler(app))
+	v1.POST("/stocktakes", startStocktakeHandler(app))
+	v1.GET("/stocktakes/:id", getStocktakeHandler(app))
+	v1.POST("/stocktakes/:id/scans", scanStocktakeHandler(app))
+	v1.POST("/stocktakes/:id/close", closeStocktakeHandler(app))
+	v1.GET("/stocktakes/:id/discrepancies", listStocktakeDiscrepanciesHandler(app))
+	v1.POST("/stocktakes/:id/mark_missing_lost", markStocktakeMissingLostHandler(app))
+
+	// Add condition and repair namespace
+	v1.GET("/copies/:id/condition", listConditionReportsHandler(app))
+	v1.POST("/copies/:id/condition", recordConditionHandler(app))
+	v1.GET("/condition_photos/:id", getConditionPhotoHandler(app))
+	v1.POST("/copies/:id/repairs", sendToRepairHandler(app))
+	v1.GET("/repairs", listRepairsHandler(app))
+	v1.GET("/repairs/:id", getRepairHandler(app))
+	v1.POST("/repairs/:id/return", returnFromRepairHandler(app))
+
+	// Add acquisitions namespace
+	v1.GET("/vendors", listVendorsHandler(app))
+	v1.POST("/vendors", createVendorHandler(app))
+	v1.GET("/vendors/:id", getVendorHandler(app))
+	v1.PUT("/vendors/:id", updateVendorHandler(app))
+	v1.GET("/funds", listFundsHandler(app))
+	v1.POST("/funds", createFundHandler(app))
+	v1.GET("/funds/:id", getFundHandler(app))
+	v1.PUT("/funds/:id", updateFundHandler(app))
+	v1.GET("/purchase_orders", listPurchaseOrdersHandler(app))
+	v1.POST("/purchase_orders", createPurchaseOrderHandler(app))
+	v1.GET("/purchase_orders/:id", getPurchaseOrderHandler(app))
+	v1.POST("/purchase_orders/:id/lines", addPurchaseOrderLineHandler(app))
+	v1.DELETE("/purchase_orders/:id/lines/:line_id", removePurchaseOrderLineHandler(app))
+	v1.POST("/purchase_orders/:id/lines/:line_id/receive", receivePurchaseOrderLineHandler(app))
+	v1.POST("/purchase_orders/:id/place", placePurchaseOrderHandler(app))
+	v1.POST("/purchase_orders/:id/cancel", cancelPurchaseOrderHandler(app))
+
+	// Add collection report namespace
+	v1.GET("/reports/weeding/dormant", listDormantCopiesHandler(app))
+	v1.GET("/reports/weeding/high_circulation", listHighCirculationCopiesHandler(app))
+	v1.GET("/reports/weeding/holds_over_copies", listHoldPressureHandler(app))
+
+	// Add shelf namespace
+	v1.GET("/shelf", browseShelfHandler(app))
+	v1.GET("/copies/:id/shelf", browseShelfAroundCopyHandler(app))
+	v1.PUT("/copies/:id/call_number", setCopyCallNumberHandler(app))
+
+	// Add catalog exchange namespace
+	v1.POST("/catalog/marc/import", importMARCHandler(app))
+	v1.GET("/catalog/marc/export", exportMARCHandler(app))
+
+	// Add bulk import namespace
+	v1.POST("/imports/csv/:entity", importCSVHandler(app))
+	v1.GET("/imports/:id", getImportReportHandler(app))
+	v1.GET("/imports/:id/errors.csv", downloadImportErrorsHandler(app))
+
+	// Add subject taxonomy namespace
+	v1.GET("/subjects", getSubjectTreeHandler(app))
+	v1.POST("/subjects", createSubjectHandler(app))
+	v1.GET("/subjects/:id", getSubjectHandler(app))
+	v1.PUT("/subjects/:id", updateSubjectHandler(app))
+	v1.DELETE("/subjects/:id", deleteSubjectHandler(app))
+	v1.GET("/subjects/:id/books", listSubjectBooksHandler(app))
+
+	// Add hold namespace
+	v1.GET("/works/:id/holds", listHoldQueueHandler(app))
+	v1.POST("/works/:id/holds", placeHoldHandler(app))
+	v1.POST("/works/:id/holds/trap", trapCopiesHandler(app))
+	v1.DELETE("/holds/:id", cancelHoldHandler(app))
+
+	// Add loan namespace
+	v1.POST("/loans", checkoutHandler(app))
+	v1.GET("/loans/:id", getLoanHandler(app))
+	v1.POST("/loans/:id/renew", renewLoanHandler(app))
+	v1.POST("/copies/:id/return", returnCopyHandler(app))
+	v1.GET("/users/:id/loans", listUserLoansHandler(app))
+
+	// Add account standing namespace
+	v1.GET("/users/:id/standing", getAccountStandingHandler(app))
+	v1.GET("/users/:id/blocks", listAccountBlocksHandler(app))
+	v1.POST("/users/:id/blocks", blockAccountHandler(app))
+	v1.POST("/blocks/:id/lift", liftBlockHandler(app))
+
+	// Add patron membership namespace
+	v1.PUT("/users/:id/membership", updateMembershipHandler(app))
+	v1.POST("/users/:id/membership/renew", renewMembershipHandler(app))
+	v1.GET("/users/:id/cards", listLibraryCardsHandler(app))
+	v1.POST("/users/:id/cards", issueLibraryCardHandler(app))
+	v1.GET("/cards", lookupLibraryCardHandler(app))
+	v1.POST("/cards/:id/revoke", revokeLibraryCardHandler(app))
+
+	// Add patron privacy namespace
+	v1.GET("/users/:id/export", exportPatronDataHandler(app))
+	v1.POST("/users/:id/erase", erasePatronHandler(app))
+	v1.PUT("/users/:id/privacy", setReadingHistoryPreferenceHandler(app))
+
+	// Add account namespace
+	v1.POST("/accounts", signupHandler(app))
+	v1.POST("/accounts/verify_email", verifyEmailHandler(app))
+	v1.POST("/accounts/verification", resendVerificationHandler(app))
+	v1.POST("/accounts/password_reset", requestPasswordResetHandler(app))
+	v1.POST("/accounts/password_reset/confirm", resetPasswordHandler(app))
+
+	// Add notification namespace
+	v1.GET("/users/:id/notifications", listSentNotificationsHandler(app))
+	v1.GET("/users/:id/notification_preferences", getNotificationPreferencesHandler(app))
+	v1.PUT("/users/:id/notification_preferences", setNotificationPreferencesHandler(app))
+
+	// Add household namespace
+	v1.POST("/households", createHouseholdHandler(app))
+	v1.GET("/households/:id", getHouseholdHandler(app))
+	v1.POST("/households/:id/members", addHouseholdMemberHandler(app))
+	v1.PUT("/households/:id/members/:user_id", updateHouseholdMemberHandler(app))
+	v1.DELETE("/households/:id/members/:user_id", removeHouseholdMemberHandler(app))
+	v1.GET("/users/:id/restrictions", listContentRestrictionsHandler(app))
+	v1.POST("/users/:id/charges/pay", payChargesHandler(app))
+
+	// Add guardian namespace
+	v1.GET("/guardians/:id/accounts", listHouseholdAccountsHandler(app))
+	v1.POST("/guardians/:id/loans/:loan_id/renew", guardianRenewLoanHandler(app))
+	v1.POST("/guardians/:id/holds", guardianPlaceHoldHandler(app))
+	v1.DELETE("/guardians/:id/holds/:hold_id", guardianCancelHoldHandler(app))
+	v1.PUT("/guardians/:id/dependents/:user_id/restrictions", setContentRestrictionsHandler(app))
+
+	// Add lost item namespace
+	v1.POST("/copies/:id/lost", declareCopyLostHandler(app))
+	v1.POST("/copies/:id/found", returnLostCopyHandler(app))
+	v1.GET("/users/:id/charges", listUserChargesHandler(app))
+	v1.GET("/charges/:id", getChargeHandler(app))
+	v1.POST("/charges/:id/reverse", reverseChargeHandler(app))
+
+	// Add webhook namespace
+	v1.GET("/webhooks", listWebhookSubscriptionsHandler(app))
+	v1.POST("/webhooks", createWebhookSubscriptionHandler(app))
+	v1.GET("/webhooks/:id", getWebhookSubscriptionHandler(app))
+	v1.PUT("/webhooks/:id", updateWebhookSubscriptionHandler(app))
+	v1.DELETE("/webhooks/:id", deleteWebhookSubscriptionHandler(app))
+	v1.GET("/webhook_deliveries", listWebhookDeliveriesHandler(app))
+	v1.GET("/webhook_deliveries/:id", getWebhookDeliveryHandler(app))
+	v1.POST("/webhook_deliveries/:id/redeliver", redeliverWebhookHandler(app))
 }
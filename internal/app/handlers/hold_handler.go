@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type holdResponse struct {
+	ID             int              `json:"id"`
+	UserID         int              `json:"user_id"`
+	WorkID         int              `json:"work_id"`
+	PickupBranchID int              `json:"pickup_branch_id"`
+	CopyID         *int             `json:"copy_id,omitempty"`
+	Status         model.HoldStatus `json:"status"`
+	ReadyAt        *time.Time       `json:"ready_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+func newHoldResponse(h model.Hold) holdResponse {
+	return holdResponse(h)
+}
+
+func newHoldResponses(holds []*model.Hold) []holdResponse {
+	resp := make([]holdResponse, 0, len(holds))
+	for _, h := range holds {
+		resp = append(resp, newHoldResponse(*h))
+	}
+	return resp
+}
+
+func placeHoldHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		UserID         int `json:"user_id" binding:"required"`
+		PickupBranchID int `json:"pickup_branch_id" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		workID, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		hold, err := app.CirculationService.PlaceHold(c.Request.Context(), body.UserID, workID, body.PickupBranchID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newHoldResponse(*hold))
+	}
+}
+
+func listHoldQueueHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workID, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		holds, err := app.CirculationService.ListHoldQueue(c.Request.Context(), workID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newHoldResponses(holds))
+	}
+}
+
+func trapCopiesHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workID, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		holds, err := app.CirculationService.TrapAvailableCopies(c.Request.Context(), workID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newHoldResponses(holds))
+	}
+}
+
+func cancelHoldHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		hold, err := app.CirculationService.CancelHold(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newHoldResponse(*hold))
+	}
+}
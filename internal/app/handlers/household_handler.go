@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/patron"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type householdResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type guardianPermissionsBody struct {
+	ManageLoans     bool `json:"manage_loans"`
+	ManageHolds     bool `json:"manage_holds"`
+	PayCharges      bool `json:"pay_charges"`
+	SetRestrictions bool `json:"set_restrictions"`
+}
+
+type householdMemberResponse struct {
+	HouseholdID int                     `json:"household_id"`
+	UserID      int                     `json:"user_id"`
+	Role        model.HouseholdRole     `json:"role"`
+	Permissions guardianPermissionsBody `json:"permissions"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+func newHouseholdMemberResponse(m model.HouseholdMember) householdMemberResponse {
+	return householdMemberResponse{
+		HouseholdID: m.HouseholdID,
+		UserID:      m.UserID,
+		Role:        m.Role,
+		Permissions: guardianPermissionsBody(m.Permissions),
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+type contentRestrictionResponse struct {
+	UserID      int       `json:"user_id"`
+	SubjectID   int       `json:"subject_id"`
+	SetByUserID int       `json:"set_by_user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newContentRestrictionResponses(restrictions []*model.ContentRestriction) []contentRestrictionResponse {
+	resp := make([]contentRestrictionResponse, 0, len(restrictions))
+	for _, r := range restrictions {
+		resp = append(resp, contentRestrictionResponse(*r))
+	}
+	return resp
+}
+
+func createHouseholdHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		household, err := app.PatronService.CreateHousehold(c.Request.Context(), body.Name)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, householdResponse(*household))
+	}
+}
+
+func getHouseholdHandler(app *app.Application) gin.HandlerFunc {
+	type Response struct {
+		householdResponse
+		Members []householdMemberResponse `json:"members"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		household, members, err := app.PatronService.GetHousehold(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := Response{
+			householdResponse: householdResponse(*household),
+			Members:           make([]householdMemberResponse, 0, len(members)),
+		}
+		for _, m := range members {
+			resp.Members = append(resp.Members, newHouseholdMemberResponse(*m))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func addHouseholdMemberHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		UserID      int                     `json:"user_id" binding:"required"`
+		Role        model.HouseholdRole     `json:"role" binding:"required"`
+		Permissions guardianPermissionsBody `json:"permissions"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		member, err := app.PatronService.AddHouseholdMember(c.Request.Context(), patron.HouseholdMemberParam{
+			HouseholdID: id,
+			UserID:      body.UserID,
+			Role:        body.Role,
+			Permissions: model.GuardianPermissions(body.Permissions),
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newHouseholdMemberResponse(*member))
+	}
+}
+
+func updateHouseholdMemberHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Role        model.HouseholdRole     `json:"role" binding:"required"`
+		Permissions guardianPermissionsBody `json:"permissions"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		userID, ok := pathID(c, "user_id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		member, err := app.PatronService.UpdateHouseholdMember(c.Request.Context(), patron.HouseholdMemberParam{
+			HouseholdID: id,
+			UserID:      userID,
+			Role:        body.Role,
+			Permissions: model.GuardianPermissions(body.Permissions),
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newHouseholdMemberResponse(*member))
+	}
+}
+
+func removeHouseholdMemberHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		userID, ok := pathID(c, "user_id")
+		if !ok {
+			return
+		}
+
+		if err := app.PatronService.RemoveHouseholdMember(c.Request.Context(), id, userID); err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithoutBody(c, http.StatusNoContent)
+	}
+}
+
+func listContentRestrictionsHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		restrictions, err := app.PatronService.ListContentRestrictions(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newContentRestrictionResponses(restrictions))
+	}
+}
+
+func payChargesHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		// ChargeIDs are the charges to pay; every charge the patron may pay
+		// and still owes when left out.
+		ChargeIDs []int `json:"charge_ids"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		charges, err := app.CirculationService.PayCharges(c.Request.Context(), id, body.ChargeIDs)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newChargeResponses(charges))
+	}
+}
+
+func listHouseholdAccountsHandler(app *app.Application) gin.HandlerFunc {
+	type Account struct {
+		UserID           int                 `json:"user_id"`
+		Role             model.HouseholdRole `json:"role,omitempty"`
+		Loans            []loanResponse      `json:"loans"`
+		Holds            []holdResponse      `json:"holds"`
+		Charges          []chargeResponse    `json:"charges"`
+		OutstandingCents int64               `json:"outstanding_cents"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		accounts, err := app.CirculationService.ListHouseholdAccounts(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]Account, 0, len(accounts))
+		for _, a := range accounts {
+			resp = append(resp, Account{
+				UserID:           a.UserID,
+				Role:             a.Role,
+				Loans:            newLoanResponses(a.Loans),
+				Holds:            newHoldResponses(a.Holds),
+				Charges:          newChargeResponses(a.Charges),
+				OutstandingCents: a.OutstandingCents,
+			})
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func guardianRenewLoanHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		loanID, ok := pathID(c, "loan_id")
+		if !ok {
+			return
+		}
+
+		loan, err := app.CirculationService.RenewLoanFor(c.Request.Context(), id, loanID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newLoanResponse(*loan))
+	}
+}
+
+func guardianPlaceHoldHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		UserID         int `json:"user_id" binding:"required"`
+		WorkID         int `json:"work_id" binding:"required"`
+		PickupBranchID int `json:"pickup_branch_id" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		hold, err := app.CirculationService.PlaceHoldFor(c.Request.Context(), id, body.UserID, body.WorkID, body.PickupBranchID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newHoldResponse(*hold))
+	}
+}
+
+func guardianCancelHoldHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		holdID, ok := pathID(c, "hold_id")
+		if !ok {
+			return
+		}
+
+		hold, err := app.CirculationService.CancelHoldFor(c.Request.Context(), id, holdID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newHoldResponse(*hold))
+	}
+}
+
+func setContentRestrictionsHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		SubjectIDs []int `json:"subject_ids"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		userID, ok := pathID(c, "user_id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		restrictions, err := app.PatronService.SetContentRestrictions(c.Request.Context(), id, userID, body.SubjectIDs)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newContentRestrictionResponses(restrictions))
+	}
+}
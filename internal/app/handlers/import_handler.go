@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/importer"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+const (
+	importModeInsert = "insert"
+	importModeUpsert = "upsert"
+)
+
+type storedImportReportResponse struct {
+	ID        int                 `json:"id"`
+	Source    string              `json:"source"`
+	DryRun    bool                `json:"dry_run"`
+	Created   int                 `json:"created"`
+	Updated   int                 `json:"updated"`
+	Failed    int                 `json:"failed"`
+	Errors    []importRowResponse `json:"errors"`
+	ErrorsURL string              `json:"errors_url"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// newStoredImportReportResponse lists only the failed rows; the full list of
+// rows of a large file is rarely useful to a client.
+func newStoredImportReportResponse(report *model.ImportReport) storedImportReportResponse {
+	resp := storedImportReportResponse{
+		ID:        report.ID,
+		Source:    report.Source,
+		DryRun:    report.DryRun,
+		Created:   report.Created,
+		Updated:   report.Updated,
+		Failed:    report.Failed,
+		Errors:    make([]importRowResponse, 0, report.Failed),
+		ErrorsURL: fmt.Sprintf("/api/v1/imports/%d/errors.csv", report.ID),
+		CreatedAt: report.CreatedAt,
+	}
+	for _, row := range report.Errors() {
+		resp.Errors = append(resp.Errors, importRowResponse(row))
+	}
+	return resp
+}
+
+func importCSVHandler(app *app.Application) gin.HandlerFunc {
+	type Form struct {
+		// Mapping is a JSON object from field name to CSV column name.
+		Mapping string `form:"mapping"`
+		Mode    string `form:"mode"`
+		DryRun  bool   `form:"dry_run"`
+	}
+
+	return func(c *gin.Context) {
+		var form Form
+		if err := c.ShouldBind(&form); err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		var mapping map[string]string
+		if form.Mapping != "" {
+			if err := json.Unmarshal([]byte(form.Mapping), &mapping); err != nil {
+				respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("mapping must be a JSON object of field to column names")))
+				return
+			}
+		}
+
+		upsert := false
+		switch form.Mode {
+		case "", importModeInsert:
+		case importModeUpsert:
+			upsert = true
+		default:
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, nil, common.WithMsg("mode must be insert or upsert")))
+			return
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("a CSV file is required in the file field")))
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeInternalProcess, err))
+			return
+		}
+		defer file.Close()
+
+		report, domainErr := app.ImportService.ImportCSV(c.Request.Context(), file, importer.CSVImportParam{
+			Entity:  importer.Entity(c.Param("entity")),
+			Mapping: mapping,
+			Upsert:  upsert,
+			DryRun:  form.DryRun,
+		})
+		if domainErr != nil {
+			respondWithError(c, domainErr)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newStoredImportReportResponse(report))
+	}
+}
+
+func getImportReportHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		report, err := app.ImportService.GetImportReport(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newStoredImportReportResponse(report))
+	}
+}
+
+func downloadImportErrorsHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%d-errors.csv"`, id))
+		if err := app.ImportService.WriteErrorReport(c.Request.Context(), id, c.Writer); err != nil {
+			c.Header("Content-Disposition", "")
+			respondWithError(c, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/catalog"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/label"
+)
+
+const (
+	labelFormatSVG = "svg"
+	labelFormatPNG = "png"
+)
+
+type labelStockResponse struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`
+	LabelWidth  float64 `json:"label_width_pt"`
+	LabelHeight float64 `json:"label_height_pt"`
+}
+
+func listLabelStocksHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stocks := app.CatalogService.ListLabelStocks()
+
+		resp := make([]labelStockResponse, 0, len(stocks))
+		for _, s := range stocks {
+			resp = append(resp, labelStockResponse{
+				Name:        s.Name,
+				Description: s.Description,
+				Columns:     s.Columns,
+				Rows:        s.Rows,
+				LabelWidth:  s.LabelWidth,
+				LabelHeight: s.LabelHeight,
+			})
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func getCopyLabelHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Kind      catalog.LabelKind `form:"kind"`
+		Format    string            `form:"format"`
+		Symbology label.Symbology   `form:"symbology"`
+		Stock     string            `form:"stock"`
+		DPI       int               `form:"dpi"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+		if query.Format == "" {
+			query.Format = labelFormatSVG
+		}
+		if query.Format != labelFormatSVG && query.Format != labelFormatPNG {
+			err := fmt.Errorf("unknown label format %q, want %s or %s", query.Format, labelFormatSVG, labelFormatPNG)
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+		if query.DPI == 0 {
+			query.DPI = label.DefaultDPI
+		}
+
+		drawing, cErr := app.CatalogService.DrawCopyLabel(c.Request.Context(), id, catalog.LabelParam{
+			Kind:      query.Kind,
+			Symbology: query.Symbology,
+			Stock:     query.Stock,
+		})
+		if cErr != nil {
+			respondWithError(c, cErr)
+			return
+		}
+
+		if query.Format == labelFormatSVG {
+			c.Data(http.StatusOK, "image/svg+xml", drawing.SVG())
+			return
+		}
+		data, err := drawing.PNG(query.DPI)
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+		c.Data(http.StatusOK, "image/png", data)
+	}
+}
+
+func printLabelSheetsHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		CopyIDs   []int             `json:"copy_ids" binding:"required"`
+		Kind      catalog.LabelKind `json:"kind"`
+		Symbology label.Symbology   `json:"symbology"`
+		Stock     string            `json:"stock"`
+		Skip      int               `json:"skip"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		pdf, err := app.CatalogService.PrintLabelSheets(c.Request.Context(), catalog.LabelSheetParam{
+			LabelParam: catalog.LabelParam{
+				Kind:      body.Kind,
+				Symbology: body.Symbology,
+				Stock:     body.Stock,
+			},
+			CopyIDs: body.CopyIDs,
+			Skip:    body.Skip,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		c.Header("Content-Disposition", `attachment; filename="labels.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", pdf)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type loanResponse struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	CopyID     int        `json:"copy_id"`
+	BorrowDate time.Time  `json:"borrow_date"`
+	DueDate    time.Time  `json:"due_date"`
+	ReturnDate *time.Time `json:"return_date,omitempty"`
+	LostAt     *time.Time `json:"lost_at,omitempty"`
+	BranchID   *int       `json:"branch_id,omitempty"`
+	Renewals   int        `json:"renewals"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func newLoanResponse(l model.BorrowedBook) loanResponse {
+	return loanResponse(l)
+}
+
+func newLoanResponses(loans []*model.BorrowedBook) []loanResponse {
+	resp := make([]loanResponse, 0, len(loans))
+	for _, l := range loans {
+		resp = append(resp, newLoanResponse(*l))
+	}
+	return resp
+}
+
+func checkoutHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		UserID    int    `json:"user_id" binding:"required"`
+		CopyID    int    `json:"copy_id" binding:"required"`
+		ChangedBy string `json:"changed_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		loan, err := app.CirculationService.Checkout(c.Request.Context(), body.UserID, body.CopyID, body.ChangedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newLoanResponse(*loan))
+	}
+}
+
+func getLoanHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		loan, err := app.CirculationService.GetLoan(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newLoanResponse(*loan))
+	}
+}
+
+func renewLoanHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		loan, err := app.CirculationService.RenewLoan(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newLoanResponse(*loan))
+	}
+}
+
+func returnCopyHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		ReturnedBy string `json:"returned_by" binding:"required"`
+	}
+	type Response struct {
+		Loan loanResponse    `json:"loan"`
+		Fine *chargeResponse `json:"fine,omitempty"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		loan, fine, err := app.CirculationService.ReturnCopy(c.Request.Context(), id, body.ReturnedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := Response{Loan: newLoanResponse(*loan)}
+		if fine != nil {
+			charge := newChargeResponse(*fine)
+			resp.Fine = &charge
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func listUserLoansHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		OpenOnly bool `form:"open_only"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		loans, err := app.CirculationService.ListUserLoans(c.Request.Context(), id, query.OpenOnly)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newLoanResponses(loans))
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/catalog"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/marc"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+const (
+	marcFormatBinary = "marc21"
+	marcFormatXML    = "marcxml"
+)
+
+type importRowResponse struct {
+	Index   int                `json:"index"`
+	Key     string             `json:"key,omitempty"`
+	Action  model.ImportAction `json:"action"`
+	ID      int                `json:"id,omitempty"`
+	Message string             `json:"message,omitempty"`
+}
+
+type importReportResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Created int                 `json:"created"`
+	Updated int                 `json:"updated"`
+	Failed  int                 `json:"failed"`
+	Rows    []importRowResponse `json:"rows"`
+}
+
+func newImportReportResponse(report *model.ImportReport) importReportResponse {
+	resp := importReportResponse{
+		DryRun:  report.DryRun,
+		Created: report.Created,
+		Updated: report.Updated,
+		Failed:  report.Failed,
+		Rows:    make([]importRowResponse, 0, len(report.Rows)),
+	}
+	for _, row := range report.Rows {
+		resp.Rows = append(resp.Rows, importRowResponse(row))
+	}
+	return resp
+}
+
+// marcFormat picks the format from the query, falling back to the file extension.
+func marcFormat(format, filename string) (string, error) {
+	switch strings.ToLower(format) {
+	case marcFormatBinary, marcFormatXML:
+		return strings.ToLower(format), nil
+	case "":
+		if strings.EqualFold(filepath.Ext(filename), ".xml") {
+			return marcFormatXML, nil
+		}
+		return marcFormatBinary, nil
+	default:
+		return "", fmt.Errorf("unknown MARC format %q", format)
+	}
+}
+
+func importMARCHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Format    string `form:"format"`
+		DryRun    bool   `form:"dry_run"`
+		BatchSize int    `form:"batch_size"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("a MARC file is required in the file field")))
+			return
+		}
+		format, err := marcFormat(query.Format, fileHeader.Filename)
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeInternalProcess, err))
+			return
+		}
+		defer file.Close()
+
+		var reader marc.RecordReader = marc.NewReader(file)
+		if format == marcFormatXML {
+			reader = marc.NewXMLReader(file)
+		}
+
+		report, domainErr := app.CatalogService.ImportMARC(c.Request.Context(), reader, catalog.ImportParam{
+			DryRun:    query.DryRun,
+			BatchSize: query.BatchSize,
+		})
+		if domainErr != nil {
+			respondWithError(c, domainErr)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newImportReportResponse(report))
+	}
+}
+
+func exportMARCHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Format    string `form:"format"`
+		BookIDs   []int  `form:"book_id"`
+		SubjectID int    `form:"subject_id"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+		format, err := marcFormat(query.Format, "")
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		var writer marc.RecordWriter
+		if format == marcFormatXML {
+			c.Header("Content-Type", "application/marcxml+xml")
+			c.Header("Content-Disposition", `attachment; filename="catalog.xml"`)
+			writer = marc.NewXMLWriter(c.Writer)
+		} else {
+			c.Header("Content-Type", "application/marc")
+			c.Header("Content-Disposition", `attachment; filename="catalog.mrc"`)
+			writer = marc.NewWriter(c.Writer)
+		}
+
+		err = app.CatalogService.ExportMARC(c.Request.Context(), writer, catalog.ExportFilter{
+			BookIDs:   query.BookIDs,
+			SubjectID: query.SubjectID,
+		})
+		if err == nil {
+			return
+		}
+
+		// records are streamed, so a failure midway can only be logged
+		if c.Writer.Written() {
+			_ = c.Error(err)
+			return
+		}
+		c.Header("Content-Disposition", "")
+		respondWithError(c, err)
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type sentNotificationResponse struct {
+	ID          int                       `json:"id"`
+	Type        model.NotificationType    `json:"type"`
+	Channel     model.NotificationChannel `json:"channel"`
+	ReferenceID int                       `json:"reference_id"`
+	Digest      bool                      `json:"digest"`
+	Locale      string                    `json:"locale"`
+	Subject     string                    `json:"subject"`
+	Body        string                    `json:"body"`
+	SentAt      time.Time                 `json:"sent_at"`
+}
+
+func newSentNotificationResponse(n model.SentNotification) sentNotificationResponse {
+	return sentNotificationResponse{
+		ID:          n.ID,
+		Type:        n.Type,
+		Channel:     n.Channel,
+		ReferenceID: n.ReferenceID,
+		Digest:      n.Digest,
+		Locale:      n.Locale,
+		Subject:     n.Subject,
+		Body:        n.Body,
+		SentAt:      n.SentAt,
+	}
+}
+
+func newSentNotificationResponses(sent []*model.SentNotification) []sentNotificationResponse {
+	resp := make([]sentNotificationResponse, 0, len(sent))
+	for _, n := range sent {
+		resp = append(resp, newSentNotificationResponse(*n))
+	}
+	return resp
+}
+
+func listSentNotificationsHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		sent, err := app.NotificationService.ListSentNotifications(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newSentNotificationResponses(sent))
+	}
+}
+
+type quietHoursBody struct {
+	Start string `json:"start" binding:"required"`
+	End   string `json:"end" binding:"required"`
+}
+
+type notificationPreferencesResponse struct {
+	Types      []model.NotificationType   `json:"types"`
+	Channel    model.NotificationChannel  `json:"channel"`
+	Delivery   model.NotificationDelivery `json:"delivery"`
+	TimeZone   string                     `json:"time_zone"`
+	QuietHours *quietHoursBody            `json:"quiet_hours,omitempty"`
+	DigestTime string                     `json:"digest_time"`
+}
+
+func newNotificationPreferencesResponse(p model.NotificationPreferences) notificationPreferencesResponse {
+	resp := notificationPreferencesResponse{
+		Types:      p.Types,
+		Channel:    p.Channel,
+		Delivery:   p.Delivery,
+		TimeZone:   p.TimeZone,
+		DigestTime: p.DigestTime.String(),
+	}
+	if p.QuietStart != p.QuietEnd {
+		resp.QuietHours = &quietHoursBody{Start: p.QuietStart.String(), End: p.QuietEnd.String()}
+	}
+	return resp
+}
+
+func getNotificationPreferencesHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		prefs, err := app.NotificationService.GetPreferences(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newNotificationPreferencesResponse(*prefs))
+	}
+}
+
+func setNotificationPreferencesHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Types    []model.NotificationType   `json:"types" binding:"required"`
+		Channel  string                     `json:"channel" binding:"required"`
+		Delivery model.NotificationDelivery `json:"delivery" binding:"required"`
+		// TimeZone defaults to the time zone of the library.
+		TimeZone   string          `json:"time_zone"`
+		QuietHours *quietHoursBody `json:"quiet_hours"`
+		// DigestTime defaults to 08:00.
+		DigestTime string `json:"digest_time"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		channel, err := model.ParseNotificationChannel(body.Channel)
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+		prefs := model.NotificationPreferences{
+			UserID:   id,
+			Types:    body.Types,
+			Channel:  channel,
+			Delivery: body.Delivery,
+			TimeZone: body.TimeZone,
+		}
+		prefs.DigestTime, err = parseOptionalTimeOfDay(body.DigestTime, model.DefaultDigestTime)
+		if err == nil && body.QuietHours != nil {
+			prefs.QuietStart, err = model.ParseTimeOfDay(body.QuietHours.Start)
+			if err == nil {
+				prefs.QuietEnd, err = model.ParseTimeOfDay(body.QuietHours.End)
+			}
+		}
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		set, cErr := app.NotificationService.SetPreferences(c.Request.Context(), prefs)
+		if cErr != nil {
+			respondWithError(c, cErr)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newNotificationPreferencesResponse(*set))
+	}
+}
+
+// parseOptionalTimeOfDay parses a time as HH:MM, or returns def if there is none.
+func parseOptionalTimeOfDay(raw string, def model.TimeOfDay) (model.TimeOfDay, error) {
+	if raw == "" {
+		return def, nil
+	}
+	return model.ParseTimeOfDay(raw)
+}
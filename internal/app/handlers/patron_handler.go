@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/patron"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type patronResponse struct {
+	ID                  int                  `json:"id"`
+	Email               string               `json:"email"`
+	Name                string               `json:"name"`
+	Category            model.PatronCategory `json:"category"`
+	MembershipStartsAt  string               `json:"membership_starts_at"`
+	MembershipExpiresAt string               `json:"membership_expires_at,omitempty"`
+	EmailVerifiedAt     *time.Time           `json:"email_verified_at,omitempty"`
+	KeepReadingHistory  bool                 `json:"keep_reading_history"`
+	ErasedAt            *time.Time           `json:"erased_at,omitempty"`
+}
+
+func newPatronResponse(u model.User) patronResponse {
+	resp := patronResponse{
+		ID:                 u.ID,
+		Email:              u.Email,
+		Name:               u.Name,
+		Category:           u.Category,
+		MembershipStartsAt: u.MembershipStartsAt.Format(dateLayout),
+		EmailVerifiedAt:    u.EmailVerifiedAt,
+		KeepReadingHistory: u.KeepReadingHistory,
+		ErasedAt:           u.ErasedAt,
+	}
+	if u.MembershipExpiresAt != nil {
+		resp.MembershipExpiresAt = u.MembershipExpiresAt.Format(dateLayout)
+	}
+	return resp
+}
+
+type libraryCardResponse struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	Barcode      string     `json:"barcode"`
+	Active       bool       `json:"active"`
+	IssuedBy     string     `json:"issued_by"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy    string     `json:"revoked_by,omitempty"`
+	RevokeReason string     `json:"revoke_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func newLibraryCardResponse(c model.LibraryCard) libraryCardResponse {
+	return libraryCardResponse{
+		ID:           c.ID,
+		UserID:       c.UserID,
+		Barcode:      c.Barcode,
+		Active:       c.IsActive(),
+		IssuedBy:     c.IssuedBy,
+		RevokedAt:    c.RevokedAt,
+		RevokedBy:    c.RevokedBy,
+		RevokeReason: c.RevokeReason,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
+
+func updateMembershipHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Category string `json:"category" binding:"required"`
+		// StartsAt is the first day of the membership; today when left out.
+		StartsAt *string `json:"starts_at"`
+		// ExpiresAt is the last day of the membership; the term of the
+		// category sets it when left out.
+		ExpiresAt *string `json:"expires_at"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+		param := patron.MembershipParam{Category: model.PatronCategory(body.Category)}
+		if body.StartsAt != nil {
+			day, ok := parseDate(c, "starts_at", *body.StartsAt)
+			if !ok {
+				return
+			}
+			param.StartsAt = &day
+		}
+		if body.ExpiresAt != nil {
+			day, ok := parseDate(c, "expires_at", *body.ExpiresAt)
+			if !ok {
+				return
+			}
+			param.ExpiresAt = &day
+		}
+
+		user, err := app.PatronService.UpdateMembership(c.Request.Context(), id, param)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPatronResponse(*user))
+	}
+}
+
+func renewMembershipHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		user, err := app.PatronService.RenewMembership(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPatronResponse(*user))
+	}
+}
+
+func listLibraryCardsHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		cards, err := app.PatronService.ListCards(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]libraryCardResponse, 0, len(cards))
+		for _, card := range cards {
+			resp = append(resp, newLibraryCardResponse(*card))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func issueLibraryCardHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		// Barcode is the barcode of a preprinted card; one is generated when left out.
+		Barcode  string `json:"barcode"`
+		IssuedBy string `json:"issued_by" binding:"required"`
+		// ReplaceReason is required when the patron already holds a card.
+		ReplaceReason string `json:"replace_reason"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		card, err := app.PatronService.IssueCard(c.Request.Context(), patron.IssueCardParam{
+			UserID:        id,
+			Barcode:       body.Barcode,
+			IssuedBy:      body.IssuedBy,
+			ReplaceReason: body.ReplaceReason,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newLibraryCardResponse(*card))
+	}
+}
+
+func revokeLibraryCardHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		RevokedBy string `json:"revoked_by" binding:"required"`
+		Reason    string `json:"reason" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		card, err := app.PatronService.RevokeCard(c.Request.Context(), id, body.RevokedBy, body.Reason)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newLibraryCardResponse(*card))
+	}
+}
+
+func lookupLibraryCardHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Barcode string `form:"barcode" binding:"required"`
+	}
+	type Response struct {
+		libraryCardResponse
+		Patron patronResponse `json:"patron"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		card, user, err := app.PatronService.LookupCard(c.Request.Context(), query.Barcode)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, Response{
+			libraryCardResponse: newLibraryCardResponse(*card),
+			Patron:              newPatronResponse(*user),
+		})
+	}
+}
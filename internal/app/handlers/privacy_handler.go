@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+const (
+	exportFormatJSON = "json"
+	exportFormatZIP  = "zip"
+)
+
+type patronExportResponse struct {
+	GeneratedAt   time.Time                  `json:"generated_at"`
+	Profile       patronResponse             `json:"profile"`
+	Cards         []libraryCardResponse      `json:"cards"`
+	Loans         []loanResponse             `json:"loans"`
+	Holds         []holdResponse             `json:"holds"`
+	Charges       []chargeResponse           `json:"charges"`
+	Notifications []sentNotificationResponse `json:"notifications"`
+}
+
+func newPatronExportResponse(e model.PatronExport) patronExportResponse {
+	resp := patronExportResponse{
+		GeneratedAt:   e.GeneratedAt,
+		Profile:       newPatronResponse(e.Profile),
+		Cards:         make([]libraryCardResponse, 0, len(e.Cards)),
+		Loans:         newLoanResponses(e.Loans),
+		Holds:         newHoldResponses(e.Holds),
+		Charges:       newChargeResponses(e.Charges),
+		Notifications: newSentNotificationResponses(e.Notifications),
+	}
+	for _, card := range e.Cards {
+		resp.Cards = append(resp.Cards, newLibraryCardResponse(*card))
+	}
+	return resp
+}
+
+// files lays the export out as one JSON document per section.
+func (r patronExportResponse) files() []exportFile {
+	return []exportFile{
+		{Name: "profile.json", Body: r.Profile},
+		{Name: "cards.json", Body: r.Cards},
+		{Name: "loans.json", Body: r.Loans},
+		{Name: "holds.json", Body: r.Holds},
+		{Name: "charges.json", Body: r.Charges},
+		{Name: "notifications.json", Body: r.Notifications},
+	}
+}
+
+type exportFile struct {
+	Name string
+	Body interface{}
+}
+
+// respondWithZIP sends JSON documents as a ZIP attachment, dated when they
+// were generated.
+func respondWithZIP(c *gin.Context, filename string, generatedAt time.Time, files []exportFile) {
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: generatedAt})
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(f.Body); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func exportPatronDataHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Format string `form:"format"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+		if query.Format == "" {
+			query.Format = exportFormatJSON
+		}
+		if query.Format != exportFormatJSON && query.Format != exportFormatZIP {
+			err := fmt.Errorf("unknown export format %q, want %s or %s", query.Format, exportFormatJSON, exportFormatZIP)
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+			return
+		}
+
+		export, err := app.PatronService.ExportPatronData(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := newPatronExportResponse(*export)
+		if query.Format == exportFormatZIP {
+			respondWithZIP(c, fmt.Sprintf("patron-%d.zip", id), resp.GeneratedAt, resp.files())
+			return
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func erasePatronHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		user, err := app.PatronService.ErasePatron(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPatronResponse(*user))
+	}
+}
+
+func setReadingHistoryPreferenceHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		KeepReadingHistory *bool `json:"keep_reading_history" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		user, err := app.PatronService.SetReadingHistoryPreference(c.Request.Context(), id, *body.KeepReadingHistory)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPatronResponse(*user))
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/acquisition"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type purchaseOrderLineResponse struct {
+	ID               int       `json:"id"`
+	BookID           int       `json:"book_id"`
+	FundID           int       `json:"fund_id"`
+	Quantity         int       `json:"quantity"`
+	ReceivedQuantity int       `json:"received_quantity"`
+	UnitPriceCents   int64     `json:"unit_price_cents"`
+	TotalCents       int64     `json:"total_cents"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type purchaseOrderResponse struct {
+	ID         int                         `json:"id"`
+	VendorID   int                         `json:"vendor_id"`
+	BranchID   int                         `json:"branch_id"`
+	Reference  string                      `json:"reference,omitempty"`
+	Notes      string                      `json:"notes,omitempty"`
+	Status     model.PurchaseOrderStatus   `json:"status"`
+	CreatedBy  string                      `json:"created_by"`
+	TotalCents int64                       `json:"total_cents"`
+	Lines      []purchaseOrderLineResponse `json:"lines"`
+	OrderedAt  *time.Time                  `json:"ordered_at,omitempty"`
+	ClosedAt   *time.Time                  `json:"closed_at,omitempty"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	UpdatedAt  time.Time                   `json:"updated_at"`
+}
+
+func newPurchaseOrderResponse(o model.PurchaseOrder) purchaseOrderResponse {
+	lines := make([]purchaseOrderLineResponse, 0, len(o.Lines))
+	for _, l := range o.Lines {
+		lines = append(lines, purchaseOrderLineResponse{
+			ID:               l.ID,
+			BookID:           l.BookID,
+			FundID:           l.FundID,
+			Quantity:         l.Quantity,
+			ReceivedQuantity: l.ReceivedQuantity,
+			UnitPriceCents:   l.UnitPriceCents,
+			TotalCents:       l.TotalCents(),
+			CreatedAt:        l.CreatedAt,
+			UpdatedAt:        l.UpdatedAt,
+		})
+	}
+	return purchaseOrderResponse{
+		ID:         o.ID,
+		VendorID:   o.VendorID,
+		BranchID:   o.BranchID,
+		Reference:  o.Reference,
+		Notes:      o.Notes,
+		Status:     o.Status,
+		CreatedBy:  o.CreatedBy,
+		TotalCents: o.TotalCents(),
+		Lines:      lines,
+		OrderedAt:  o.OrderedAt,
+		ClosedAt:   o.ClosedAt,
+		CreatedAt:  o.CreatedAt,
+		UpdatedAt:  o.UpdatedAt,
+	}
+}
+
+func listPurchaseOrdersHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		VendorID int                       `form:"vendor_id"`
+		Status   model.PurchaseOrderStatus `form:"status"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		orders, err := app.AcquisitionService.ListPurchaseOrders(c.Request.Context(), query.VendorID, query.Status)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]purchaseOrderResponse, 0, len(orders))
+		for _, o := range orders {
+			resp = append(resp, newPurchaseOrderResponse(*o))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func createPurchaseOrderHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		VendorID  int    `json:"vendor_id" binding:"required"`
+		BranchID  int    `json:"branch_id"`
+		Reference string `json:"reference"`
+		Notes     string `json:"notes"`
+		CreatedBy string `json:"created_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		order, err := app.AcquisitionService.CreatePurchaseOrder(c.Request.Context(), acquisition.CreatePurchaseOrderParam(body))
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newPurchaseOrderResponse(*order))
+	}
+}
+
+func getPurchaseOrderHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		order, err := app.AcquisitionService.GetPurchaseOrder(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPurchaseOrderResponse(*order))
+	}
+}
+
+func addPurchaseOrderLineHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		BookID         int   `json:"book_id" binding:"required"`
+		FundID         int   `json:"fund_id" binding:"required"`
+		Quantity       int   `json:"quantity" binding:"required"`
+		UnitPriceCents int64 `json:"unit_price_cents"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		order, err := app.AcquisitionService.AddPurchaseOrderLine(c.Request.Context(), id, acquisition.PurchaseOrderLineParam(body))
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newPurchaseOrderResponse(*order))
+	}
+}
+
+func removePurchaseOrderLineHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		lineID, ok := pathID(c, "line_id")
+		if !ok {
+			return
+		}
+
+		order, err := app.AcquisitionService.RemovePurchaseOrderLine(c.Request.Context(), id, lineID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPurchaseOrderResponse(*order))
+	}
+}
+
+func placePurchaseOrderHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		order, err := app.AcquisitionService.PlacePurchaseOrder(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPurchaseOrderResponse(*order))
+	}
+}
+
+func cancelPurchaseOrderHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		order, err := app.AcquisitionService.CancelPurchaseOrder(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newPurchaseOrderResponse(*order))
+	}
+}
+
+func receivePurchaseOrderLineHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		// Quantity may be left out when barcodes are given.
+		Quantity   int      `json:"quantity"`
+		Barcodes   []string `json:"barcodes"`
+		ReceivedBy string   `json:"received_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		lineID, ok := pathID(c, "line_id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		copies, err := app.AcquisitionService.ReceivePurchaseOrderLine(c.Request.Context(), acquisition.ReceivePurchaseOrderLineParam{
+			OrderID:    id,
+			LineID:     lineID,
+			Quantity:   body.Quantity,
+			Barcodes:   body.Barcodes,
+			ReceivedBy: body.ReceivedBy,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]bookCopyResponse, 0, len(copies))
+		for _, bc := range copies {
+			resp = append(resp, newBookCopyResponse(*bc))
+		}
+		respondWithJSON(c, http.StatusCreated, resp)
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/inventory"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repairResponse struct {
+	ID           int        `json:"id"`
+	CopyID       int        `json:"copy_id"`
+	VendorID     int        `json:"vendor_id"`
+	ReportID     *int       `json:"report_id,omitempty"`
+	Note         string     `json:"note,omitempty"`
+	SentBy       string     `json:"sent_by"`
+	ExpectedBack string     `json:"expected_back"`
+	Overdue      bool       `json:"overdue"`
+	ReturnedAt   *time.Time `json:"returned_at,omitempty"`
+	ReturnedBy   string     `json:"returned_by,omitempty"`
+	Repaired     *bool      `json:"repaired,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func newRepairResponse(r model.Repair) repairResponse {
+	return repairResponse{
+		ID:           r.ID,
+		CopyID:       r.CopyID,
+		VendorID:     r.VendorID,
+		ReportID:     r.ReportID,
+		Note:         r.Note,
+		SentBy:       r.SentBy,
+		ExpectedBack: r.ExpectedBack.Format(dateLayout),
+		Overdue:      r.IsOverdue(time.Now()),
+		ReturnedAt:   r.ReturnedAt,
+		ReturnedBy:   r.ReturnedBy,
+		Repaired:     r.Repaired,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+}
+
+func newRepairResponses(repairs []*model.Repair) []repairResponse {
+	resp := make([]repairResponse, 0, len(repairs))
+	for _, r := range repairs {
+		resp = append(resp, newRepairResponse(*r))
+	}
+	return resp
+}
+
+func sendToRepairHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		VendorID     int    `json:"vendor_id" binding:"required"`
+		ReportID     *int   `json:"report_id"`
+		ExpectedBack string `json:"expected_back" binding:"required"`
+		Note         string `json:"note"`
+		SentBy       string `json:"sent_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+		expectedBack, err := time.Parse(dateLayout, body.ExpectedBack)
+		if err != nil {
+			respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("expected_back must be a date as YYYY-MM-DD")))
+			return
+		}
+
+		repair, domainErr := app.InventoryService.SendToRepair(c.Request.Context(), inventory.SendToRepairParam{
+			CopyID:       id,
+			VendorID:     body.VendorID,
+			ReportID:     body.ReportID,
+			ExpectedBack: expectedBack,
+			Note:         body.Note,
+			SentBy:       body.SentBy,
+		})
+		if domainErr != nil {
+			respondWithError(c, domainErr)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newRepairResponse(*repair))
+	}
+}
+
+func listRepairsHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		CopyID   int  `form:"copy_id"`
+		VendorID int  `form:"vendor_id"`
+		Open     bool `form:"open"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		repairs, err := app.InventoryService.ListRepairs(c.Request.Context(), model.RepairFilter{
+			CopyID:   query.CopyID,
+			VendorID: query.VendorID,
+			OpenOnly: query.Open,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newRepairResponses(repairs))
+	}
+}
+
+func getRepairHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		repair, err := app.InventoryService.GetRepair(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newRepairResponse(*repair))
+	}
+}
+
+func returnFromRepairHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		// Repaired is false when the vendor could not repair the copy.
+		Repaired   *bool  `json:"repaired" binding:"required"`
+		ReturnedBy string `json:"returned_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		repair, err := app.InventoryService.ReturnFromRepair(c.Request.Context(), id, *body.Repaired, body.ReturnedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newRepairResponse(*repair))
+	}
+}
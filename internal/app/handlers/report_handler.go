@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/report"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+const (
+	reportFormatJSON = "json"
+	reportFormatCSV  = "csv"
+)
+
+type weedingCopyResponse struct {
+	CopyID         int              `json:"copy_id"`
+	Barcode        string           `json:"barcode,omitempty"`
+	BookID         int              `json:"book_id"`
+	Title          string           `json:"title"`
+	Author         string           `json:"author"`
+	HomeBranchID   int              `json:"home_branch_id"`
+	Status         model.BookStatus `json:"status"`
+	CallNumber     string           `json:"call_number,omitempty"`
+	Loans          int              `json:"loans"`
+	LastBorrowedAt *time.Time       `json:"last_borrowed_at,omitempty"`
+	AddedAt        time.Time        `json:"added_at"`
+}
+
+type holdPressureResponse struct {
+	WorkID    int    `json:"work_id"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Holds     int    `json:"holds"`
+	Copies    int    `json:"copies"`
+	Shortfall int    `json:"shortfall"`
+}
+
+// weedingQuery is the query string shared by the weeding reports.
+type weedingQuery struct {
+	BranchID  int    `form:"branch_id"`
+	SubjectID int    `form:"subject_id"`
+	Format    string `form:"format"`
+}
+
+func (q weedingQuery) filter() model.WeedingFilter {
+	return model.WeedingFilter{BranchID: q.BranchID, SubjectID: q.SubjectID}
+}
+
+// bindWeedingQuery decodes the query string of a weeding report and checks its format.
+func bindWeedingQuery(c *gin.Context, query interface{}, format *string) bool {
+	if !bindQuery(c, query) {
+		return false
+	}
+	if *format == "" {
+		*format = reportFormatJSON
+	}
+	if *format != reportFormatJSON && *format != reportFormatCSV {
+		err := fmt.Errorf("unknown report format %q, want %s or %s", *format, reportFormatJSON, reportFormatCSV)
+		respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+		return false
+	}
+	return true
+}
+
+// respondWithCSV sends a report as a CSV attachment.
+func respondWithCSV(c *gin.Context, filename string, write func(c *gin.Context) common.Error) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if err := write(c); err != nil {
+		c.Header("Content-Disposition", "")
+		respondWithError(c, err)
+	}
+}
+
+func respondWithWeedingCopies(c *gin.Context, format, filename string, copies []*model.WeedingCopy) {
+	if format == reportFormatCSV {
+		respondWithCSV(c, filename, func(c *gin.Context) common.Error {
+			return report.WriteWeedingCopiesCSV(c.Writer, copies)
+		})
+		return
+	}
+
+	resp := make([]weedingCopyResponse, 0, len(copies))
+	for _, wc := range copies {
+		resp = append(resp, weedingCopyResponse(*wc))
+	}
+	respondWithJSON(c, http.StatusOK, resp)
+}
+
+func listDormantCopiesHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		weedingQuery
+		Years int `form:"years"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindWeedingQuery(c, &query, &query.Format) {
+			return
+		}
+
+		copies, err := app.ReportService.ListDormantCopies(c.Request.Context(), report.DormantCopiesParam{
+			WeedingFilter: query.filter(),
+			Years:         query.Years,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithWeedingCopies(c, query.Format, "dormant-copies.csv", copies)
+	}
+}
+
+func listHighCirculationCopiesHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		weedingQuery
+		MinLoans int `form:"min_loans"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindWeedingQuery(c, &query, &query.Format) {
+			return
+		}
+
+		copies, err := app.ReportService.ListHighCirculationCopies(c.Request.Context(), report.HighCirculationParam{
+			WeedingFilter: query.filter(),
+			MinLoans:      query.MinLoans,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithWeedingCopies(c, query.Format, "high-circulation-copies.csv", copies)
+	}
+}
+
+func listHoldPressureHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query weedingQuery
+		if !bindWeedingQuery(c, &query, &query.Format) {
+			return
+		}
+
+		pressure, err := app.ReportService.ListHoldPressure(c.Request.Context(), query.filter())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		if query.Format == reportFormatCSV {
+			respondWithCSV(c, "holds-over-copies.csv", func(c *gin.Context) common.Error {
+				return report.WriteHoldPressureCSV(c.Writer, pressure)
+			})
+			return
+		}
+
+		resp := make([]holdPressureResponse, 0, len(pressure))
+		for _, p := range pressure {
+			resp = append(resp, holdPressureResponse{
+				WorkID:    p.WorkID,
+				Title:     p.Title,
+				Author:    p.Author,
+				Holds:     p.Holds,
+				Copies:    p.Copies,
+				Shortfall: p.Shortfall(),
+			})
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/rs/zerolog"
+)
+
+// ErrorMessage is the JSON body returned to clients when a request fails.
+type ErrorMessage struct {
+	Name       string                 `json:"name"`
+	Code       int                    `json:"code"`
+	Message    string                 `json:"message,omitempty"`
+	RemoteCode int                    `json:"remoteCode,omitempty"`
+	Detail     map[string]interface{} `json:"detail,omitempty"`
+}
+
+func respondWithJSON(c *gin.Context, code int, payload interface{}) {
+	c.JSON(code, payload)
+}
+
+func respondWithoutBody(c *gin.Context, code int) {
+	c.Status(code)
+}
+
+func respondWithError(c *gin.Context, err error) {
+	errMessage := parseError(err)
+
+	zerolog.Ctx(c.Request.Context()).Error().Err(err).Str("component", "handler").Msg(errMessage.Name)
+	_ = c.Error(err)
+	c.AbortWithStatusJSON(errMessage.Code, errMessage)
+}
+
+func parseError(err error) ErrorMessage {
+	domainError, ok := err.(common.DomainError)
+	if !ok {
+		return ErrorMessage{
+			Name: common.UnknownErrorName,
+			Code: http.StatusInternalServerError,
+		}
+	}
+
+	return ErrorMessage{
+		Name:       domainError.Name(),
+		Code:       domainError.HTTPStatus(),
+		Message:    domainError.ClientMsg(),
+		RemoteCode: domainError.RemoteHTTPStatus(),
+		Detail:     domainError.Detail(),
+	}
+}
+
+// bindJSON decodes the request body and reports malformed input as a parameter error.
+func bindJSON(c *gin.Context, body interface{}) bool {
+	if err := c.ShouldBindJSON(body); err != nil {
+		respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+		return false
+	}
+	return true
+}
+
+// pathID parses a numeric path parameter.
+func pathID(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		msg := "invalid " + name
+		respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg)))
+		return 0, false
+	}
+	return id, true
+}
+
+// bindQuery decodes the query string and reports malformed input as a parameter error.
+func bindQuery(c *gin.Context, query interface{}) bool {
+	if err := c.ShouldBindQuery(query); err != nil {
+		respondWithError(c, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error())))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type shelfItemResponse struct {
+	CopyID         int                        `json:"copy_id"`
+	BookID         int                        `json:"book_id"`
+	Title          string                     `json:"title"`
+	Author         string                     `json:"author"`
+	Classification model.ClassificationScheme `json:"classification"`
+	CallNumber     string                     `json:"call_number"`
+}
+
+func newShelfItemResponse(item *model.ShelfItem) *shelfItemResponse {
+	if item == nil {
+		return nil
+	}
+	return &shelfItemResponse{
+		CopyID:         item.CopyID,
+		BookID:         item.BookID,
+		Title:          item.Title,
+		Author:         item.Author,
+		Classification: item.Scheme,
+		CallNumber:     item.CallNumber,
+	}
+}
+
+type shelfBrowseResponse struct {
+	Anchor *shelfItemResponse   `json:"anchor,omitempty"`
+	Before []*shelfItemResponse `json:"before"`
+	After  []*shelfItemResponse `json:"after"`
+}
+
+func newShelfBrowseResponse(browse *model.ShelfBrowse) shelfBrowseResponse {
+	resp := shelfBrowseResponse{
+		Anchor: newShelfItemResponse(browse.Anchor),
+		Before: make([]*shelfItemResponse, 0, len(browse.Before)),
+		After:  make([]*shelfItemResponse, 0, len(browse.After)),
+	}
+	for _, item := range browse.Before {
+		resp.Before = append(resp.Before, newShelfItemResponse(item))
+	}
+	for _, item := range browse.After {
+		resp.After = append(resp.After, newShelfItemResponse(item))
+	}
+	return resp
+}
+
+func setBookCallNumberHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Classification model.ClassificationScheme `json:"classification"`
+		CallNumber     string                     `json:"call_number"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		book, err := app.CatalogService.SetBookCallNumber(c.Request.Context(), id, body.Classification, body.CallNumber)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBookResponse(*book))
+	}
+}
+
+func setCopyCallNumberHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		CallNumber string `json:"call_number"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		item, err := app.CatalogService.SetCopyCallNumber(c.Request.Context(), id, body.CallNumber)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newShelfItemResponse(item))
+	}
+}
+
+func browseShelfAroundCopyHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Window int `form:"window"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		browse, err := app.CatalogService.BrowseShelfAroundCopy(c.Request.Context(), id, query.Window)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newShelfBrowseResponse(browse))
+	}
+}
+
+func browseShelfHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Classification model.ClassificationScheme `form:"classification" binding:"required"`
+		CallNumber     string                     `form:"call_number" binding:"required"`
+		Window         int                        `form:"window"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		browse, err := app.CatalogService.BrowseShelfAtCallNumber(c.Request.Context(), query.Classification, query.CallNumber, query.Window)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newShelfBrowseResponse(browse))
+	}
+}
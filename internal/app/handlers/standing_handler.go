@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type manualBlockResponse struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Reason    string     `json:"reason"`
+	BlockedBy string     `json:"blocked_by"`
+	LiftedAt  *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy  string     `json:"lifted_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func newManualBlockResponse(b model.ManualBlock) manualBlockResponse {
+	return manualBlockResponse(b)
+}
+
+func newManualBlockResponses(blocks []*model.ManualBlock) []manualBlockResponse {
+	resp := make([]manualBlockResponse, 0, len(blocks))
+	for _, b := range blocks {
+		resp = append(resp, newManualBlockResponse(*b))
+	}
+	return resp
+}
+
+func getAccountStandingHandler(app *app.Application) gin.HandlerFunc {
+	type Block struct {
+		Kind   model.BlockKind `json:"kind"`
+		Reason string          `json:"reason"`
+	}
+	type Response struct {
+		UserID              int                   `json:"user_id"`
+		Blocked             bool                  `json:"blocked"`
+		Explanation         string                `json:"explanation"`
+		Blocks              []Block               `json:"blocks"`
+		OutstandingCents    int64                 `json:"outstanding_cents"`
+		OverdueLoans        int                   `json:"overdue_loans"`
+		MembershipExpiresAt string                `json:"membership_expires_at,omitempty"`
+		ManualBlocks        []manualBlockResponse `json:"manual_blocks"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		standing, err := app.CirculationService.GetAccountStanding(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := Response{
+			UserID:           standing.UserID,
+			Blocked:          standing.IsBlocked(),
+			Explanation:      standing.Explain(),
+			Blocks:           make([]Block, 0, len(standing.Blocks)),
+			OutstandingCents: standing.OutstandingCents,
+			OverdueLoans:     standing.OverdueLoans,
+			ManualBlocks:     make([]manualBlockResponse, 0, len(standing.ManualBlocks)),
+		}
+		for _, b := range standing.Blocks {
+			resp.Blocks = append(resp.Blocks, Block(b))
+		}
+		if standing.MembershipExpiresAt != nil {
+			resp.MembershipExpiresAt = standing.MembershipExpiresAt.Format(dateLayout)
+		}
+		for _, b := range standing.ManualBlocks {
+			resp.ManualBlocks = append(resp.ManualBlocks, newManualBlockResponse(b))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func listAccountBlocksHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		blocks, err := app.CirculationService.ListAccountBlocks(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newManualBlockResponses(blocks))
+	}
+}
+
+func blockAccountHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Reason    string `json:"reason" binding:"required"`
+		BlockedBy string `json:"blocked_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		block, err := app.CirculationService.BlockAccount(c.Request.Context(), id, body.Reason, body.BlockedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newManualBlockResponse(*block))
+	}
+}
+
+func liftBlockHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		LiftedBy string `json:"lifted_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		block, err := app.CirculationService.LiftBlock(c.Request.Context(), id, body.LiftedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newManualBlockResponse(*block))
+	}
+}
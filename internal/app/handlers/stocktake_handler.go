@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/inventory"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type shelfRangeResponse struct {
+	Scheme model.ClassificationScheme `json:"scheme"`
+	From   string                     `json:"from"`
+	To     string                     `json:"to"`
+}
+
+type stocktakeResponse struct {
+	ID        int                   `json:"id"`
+	BranchID  int                   `json:"branch_id"`
+	Range     *shelfRangeResponse   `json:"range,omitempty"`
+	Status    model.StocktakeStatus `json:"status"`
+	StartedBy string                `json:"started_by"`
+	ClosedBy  string                `json:"closed_by,omitempty"`
+	ClosedAt  *time.Time            `json:"closed_at,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+func newStocktakeResponse(s model.Stocktake) stocktakeResponse {
+	resp := stocktakeResponse{
+		ID:        s.ID,
+		BranchID:  s.BranchID,
+		Status:    s.Status,
+		StartedBy: s.StartedBy,
+		ClosedBy:  s.ClosedBy,
+		ClosedAt:  s.ClosedAt,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+	if s.Range != nil {
+		resp.Range = &shelfRangeResponse{
+			Scheme: s.Range.Scheme,
+			From:   s.Range.From.Raw,
+			To:     s.Range.To.Raw,
+		}
+	}
+	return resp
+}
+
+type stocktakeDiscrepancyResponse struct {
+	Kind            model.DiscrepancyKind      `json:"kind"`
+	Barcode         string                     `json:"barcode"`
+	CopyID          *int                       `json:"copy_id,omitempty"`
+	BookID          int                        `json:"book_id,omitempty"`
+	Title           string                     `json:"title,omitempty"`
+	Status          model.BookStatus           `json:"status,omitempty"`
+	CurrentBranchID int                        `json:"current_branch_id,omitempty"`
+	Scheme          model.ClassificationScheme `json:"scheme,omitempty"`
+	CallNumber      string                     `json:"call_number,omitempty"`
+}
+
+func newStocktakeDiscrepancyResponse(d model.StocktakeDiscrepancy) stocktakeDiscrepancyResponse {
+	return stocktakeDiscrepancyResponse{
+		Kind:            d.Kind,
+		Barcode:         d.Item.Barcode,
+		CopyID:          d.Item.CopyID,
+		BookID:          d.Item.BookID,
+		Title:           d.Item.Title,
+		Status:          d.Item.Status,
+		CurrentBranchID: d.Item.CurrentBranchID,
+		Scheme:          d.Item.Scheme,
+		CallNumber:      d.Item.CallNumber,
+	}
+}
+
+func listStocktakesHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		BranchID int `form:"branch_id"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		stocktakes, err := app.InventoryService.ListStocktakes(c.Request.Context(), query.BranchID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]stocktakeResponse, 0, len(stocktakes))
+		for _, s := range stocktakes {
+			resp = append(resp, newStocktakeResponse(*s))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func startStocktakeHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		BranchID  int                        `json:"branch_id" binding:"required"`
+		Scheme    model.ClassificationScheme `json:"scheme"`
+		From      string                     `json:"from"`
+		To        string                     `json:"to"`
+		StartedBy string                     `json:"started_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		stocktake, err := app.InventoryService.StartStocktake(c.Request.Context(), inventory.StartStocktakeParam{
+			BranchID:  body.BranchID,
+			Scheme:    body.Scheme,
+			From:      body.From,
+			To:        body.To,
+			StartedBy: body.StartedBy,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newStocktakeResponse(*stocktake))
+	}
+}
+
+func getStocktakeHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		stocktake, err := app.InventoryService.GetStocktake(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newStocktakeResponse(*stocktake))
+	}
+}
+
+func scanStocktakeHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Barcodes  []string `json:"barcodes" binding:"required"`
+		ScannedBy string   `json:"scanned_by" binding:"required"`
+	}
+	type Response struct {
+		Scanned int `json:"scanned"`
+		Added   int `json:"added"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		added, err := app.InventoryService.ScanStocktakeBarcodes(c.Request.Context(), id, body.Barcodes, body.ScannedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, Response{Scanned: len(body.Barcodes), Added: added})
+	}
+}
+
+func closeStocktakeHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		ClosedBy string `json:"closed_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		stocktake, err := app.InventoryService.CloseStocktake(c.Request.Context(), id, body.ClosedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newStocktakeResponse(*stocktake))
+	}
+}
+
+func listStocktakeDiscrepanciesHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		discrepancies, err := app.InventoryService.ListStocktakeDiscrepancies(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]stocktakeDiscrepancyResponse, 0, len(discrepancies))
+		for _, d := range discrepancies {
+			resp = append(resp, newStocktakeDiscrepancyResponse(*d))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func markStocktakeMissingLostHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		ChangedBy string `json:"changed_by" binding:"required"`
+	}
+	type Response struct {
+		CopyIDs []int `json:"copy_ids"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		copyIDs, err := app.InventoryService.MarkStocktakeMissingLost(c.Request.Context(), id, body.ChangedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		if copyIDs == nil {
+			copyIDs = []int{}
+		}
+		respondWithJSON(c, http.StatusOK, Response{CopyIDs: copyIDs})
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type subjectResponse struct {
+	ID       int                `json:"id"`
+	ParentID *int               `json:"parent_id"`
+	Name     string             `json:"name"`
+	Children []*subjectResponse `json:"children,omitempty"`
+}
+
+func newSubjectResponse(s model.Subject) *subjectResponse {
+	return &subjectResponse{
+		ID:       s.ID,
+		ParentID: s.ParentID,
+		Name:     s.Name,
+	}
+}
+
+func newSubjectResponses(subjects []*model.Subject) []*subjectResponse {
+	resp := make([]*subjectResponse, 0, len(subjects))
+	for _, s := range subjects {
+		resp = append(resp, newSubjectResponse(*s))
+	}
+	return resp
+}
+
+func newSubjectTreeResponse(node *model.SubjectNode) *subjectResponse {
+	resp := newSubjectResponse(node.Subject)
+	for _, child := range node.Children {
+		resp.Children = append(resp.Children, newSubjectTreeResponse(child))
+	}
+	return resp
+}
+
+func getSubjectTreeHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roots, err := app.CatalogService.GetSubjectTree(c.Request.Context())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]*subjectResponse, 0, len(roots))
+		for _, root := range roots {
+			resp = append(resp, newSubjectTreeResponse(root))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func getSubjectHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		node, err := app.CatalogService.GetSubjectSubtree(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newSubjectTreeResponse(node))
+	}
+}
+
+func createSubjectHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Name     string `json:"name" binding:"required"`
+		ParentID *int   `json:"parent_id"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		subject, err := app.CatalogService.CreateSubject(c.Request.Context(), body.Name, body.ParentID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newSubjectResponse(*subject))
+	}
+}
+
+func updateSubjectHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Name     string `json:"name" binding:"required"`
+		ParentID *int   `json:"parent_id"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		subject, err := app.CatalogService.UpdateSubject(c.Request.Context(), id, body.Name, body.ParentID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newSubjectResponse(*subject))
+	}
+}
+
+func deleteSubjectHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		if err := app.CatalogService.DeleteSubject(c.Request.Context(), id); err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithoutBody(c, http.StatusNoContent)
+	}
+}
+
+func listSubjectBooksHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Limit  int `form:"limit"`
+		Offset int `form:"offset" binding:"min=0"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		books, err := app.CatalogService.ListBooksBySubject(c.Request.Context(), id, query.Limit, query.Offset)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]bookResponse, 0, len(books))
+		for _, b := range books {
+			resp = append(resp, newBookResponse(*b))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func listBookSubjectsHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		subjects, err := app.CatalogService.ListBookSubjects(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newSubjectResponses(subjects))
+	}
+}
+
+func setBookSubjectsHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		SubjectIDs []int `json:"subject_ids"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		subjects, err := app.CatalogService.SetBookSubjects(c.Request.Context(), id, body.SubjectIDs)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newSubjectResponses(subjects))
+	}
+}
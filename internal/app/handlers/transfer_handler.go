@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/inventory"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type transferResponse struct {
+	ID           int                  `json:"id"`
+	CopyID       int                  `json:"copy_id"`
+	FromBranchID int                  `json:"from_branch_id"`
+	ToBranchID   int                  `json:"to_branch_id"`
+	HoldID       *int                 `json:"hold_id,omitempty"`
+	Status       model.TransferStatus `json:"status"`
+	RequestedBy  string               `json:"requested_by"`
+	Reason       string               `json:"reason,omitempty"`
+	DispatchedAt *time.Time           `json:"dispatched_at,omitempty"`
+	ReceivedAt   *time.Time           `json:"received_at,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+func newTransferResponse(t model.Transfer) transferResponse {
+	return transferResponse(t)
+}
+
+func listTransfersHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		BranchID int                  `form:"branch_id"`
+		Status   model.TransferStatus `form:"status"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		transfers, err := app.InventoryService.ListTransfers(c.Request.Context(), query.BranchID, query.Status)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]transferResponse, 0, len(transfers))
+		for _, t := range transfers {
+			resp = append(resp, newTransferResponse(*t))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func requestTransferHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		CopyID      int    `json:"copy_id" binding:"required"`
+		ToBranchID  int    `json:"to_branch_id" binding:"required"`
+		RequestedBy string `json:"requested_by" binding:"required"`
+		Reason      string `json:"reason"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		transfer, err := app.InventoryService.RequestTransfer(c.Request.Context(), inventory.RequestTransferParam{
+			CopyID:      body.CopyID,
+			ToBranchID:  body.ToBranchID,
+			RequestedBy: body.RequestedBy,
+			Reason:      body.Reason,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newTransferResponse(*transfer))
+	}
+}
+
+func getTransferHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		transfer, err := app.InventoryService.GetTransfer(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newTransferResponse(*transfer))
+	}
+}
+
+func dispatchTransferHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		ChangedBy string `json:"changed_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		transfer, err := app.InventoryService.DispatchTransfer(c.Request.Context(), id, body.ChangedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newTransferResponse(*transfer))
+	}
+}
+
+func receiveTransferHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		ChangedBy string `json:"changed_by" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		transfer, err := app.InventoryService.ReceiveTransfer(c.Request.Context(), id, body.ChangedBy)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newTransferResponse(*transfer))
+	}
+}
+
+func cancelTransferHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		transfer, err := app.InventoryService.CancelTransfer(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newTransferResponse(*transfer))
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/acquisition"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type vendorResponse struct {
+	ID        int       `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	Address   string    `json:"address,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newVendorResponse(v model.Vendor) vendorResponse {
+	return vendorResponse(v)
+}
+
+type vendorBody struct {
+	Code    string `json:"code" binding:"required"`
+	Name    string `json:"name" binding:"required"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+}
+
+func (b vendorBody) toParam() acquisition.VendorParam {
+	return acquisition.VendorParam(b)
+}
+
+func listVendorsHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vendors, err := app.AcquisitionService.ListVendors(c.Request.Context())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]vendorResponse, 0, len(vendors))
+		for _, v := range vendors {
+			resp = append(resp, newVendorResponse(*v))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func createVendorHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body vendorBody
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		vendor, err := app.AcquisitionService.CreateVendor(c.Request.Context(), body.toParam())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newVendorResponse(*vendor))
+	}
+}
+
+func getVendorHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		vendor, err := app.AcquisitionService.GetVendor(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newVendorResponse(*vendor))
+	}
+}
+
+func updateVendorHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body vendorBody
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		vendor, err := app.AcquisitionService.UpdateVendor(c.Request.Context(), id, body.toParam())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newVendorResponse(*vendor))
+	}
+}
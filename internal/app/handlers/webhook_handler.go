@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type webhookSubscriptionResponse struct {
+	ID          int                      `json:"id"`
+	URL         string                   `json:"url"`
+	Description string                   `json:"description,omitempty"`
+	Events      []model.WebhookEventType `json:"events"`
+	Active      bool                     `json:"active"`
+	// Secret is only told when the subscription is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newWebhookSubscriptionResponse(s model.WebhookSubscription) webhookSubscriptionResponse {
+	return webhookSubscriptionResponse{
+		ID:          s.ID,
+		URL:         s.URL,
+		Description: s.Description,
+		Events:      s.Events,
+		Active:      s.Active,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+type webhookDeliveryResponse struct {
+	ID             int                         `json:"id"`
+	SubscriptionID int                         `json:"subscription_id"`
+	EventID        string                      `json:"event_id"`
+	EventType      model.WebhookEventType      `json:"event_type"`
+	Payload        json.RawMessage             `json:"payload"`
+	Status         model.WebhookDeliveryStatus `json:"status"`
+	Attempts       int                         `json:"attempts"`
+	NextAttemptAt  *time.Time                  `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int                        `json:"last_status_code,omitempty"`
+	LastError      string                      `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time                  `json:"delivered_at,omitempty"`
+	DeadLetteredAt *time.Time                  `json:"dead_lettered_at,omitempty"`
+	CreatedAt      time.Time                   `json:"created_at"`
+	UpdatedAt      time.Time                   `json:"updated_at"`
+}
+
+func newWebhookDeliveryResponse(d model.WebhookDelivery) webhookDeliveryResponse {
+	resp := webhookDeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        json.RawMessage(d.Payload),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		DeadLetteredAt: d.DeadLetteredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+	// only pending deliveries have a next attempt
+	if d.Status == model.WebhookPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	return resp
+}
+
+func listWebhookSubscriptionsHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subs, err := app.WebhookService.ListSubscriptions(c.Request.Context())
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]webhookSubscriptionResponse, 0, len(subs))
+		for _, s := range subs {
+			resp = append(resp, newWebhookSubscriptionResponse(*s))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func createWebhookSubscriptionHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		URL         string                   `json:"url" binding:"required"`
+		Description string                   `json:"description"`
+		Events      []model.WebhookEventType `json:"events" binding:"required"`
+		// Secret is generated when empty.
+		Secret string `json:"secret"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		sub, err := app.WebhookService.CreateSubscription(c.Request.Context(), webhook.CreateSubscriptionParam{
+			URL:         body.URL,
+			Description: body.Description,
+			Events:      body.Events,
+			Secret:      body.Secret,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := newWebhookSubscriptionResponse(*sub)
+		resp.Secret = sub.Secret
+		respondWithJSON(c, http.StatusCreated, resp)
+	}
+}
+
+func getWebhookSubscriptionHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		sub, err := app.WebhookService.GetSubscription(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newWebhookSubscriptionResponse(*sub))
+	}
+}
+
+func updateWebhookSubscriptionHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		URL         string                   `json:"url" binding:"required"`
+		Description string                   `json:"description"`
+		Events      []model.WebhookEventType `json:"events" binding:"required"`
+		Active      *bool                    `json:"active" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		sub, err := app.WebhookService.UpdateSubscription(c.Request.Context(), webhook.UpdateSubscriptionParam{
+			ID:          id,
+			URL:         body.URL,
+			Description: body.Description,
+			Events:      body.Events,
+			Active:      *body.Active,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newWebhookSubscriptionResponse(*sub))
+	}
+}
+
+func deleteWebhookSubscriptionHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		if err := app.WebhookService.DeleteSubscription(c.Request.Context(), id); err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithoutBody(c, http.StatusNoContent)
+	}
+}
+
+func listWebhookDeliveriesHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		SubscriptionID int                         `form:"subscription_id"`
+		Status         model.WebhookDeliveryStatus `form:"status"`
+		Limit          int                         `form:"limit"`
+		Offset         int                         `form:"offset" binding:"min=0"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		deliveries, err := app.WebhookService.ListDeliveries(c.Request.Context(), query.SubscriptionID, query.Status, query.Limit, query.Offset)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]webhookDeliveryResponse, 0, len(deliveries))
+		for _, d := range deliveries {
+			resp = append(resp, newWebhookDeliveryResponse(*d))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func getWebhookDeliveryHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		delivery, err := app.WebhookService.GetDelivery(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newWebhookDeliveryResponse(*delivery))
+	}
+}
+
+// redeliverWebhookHandler queues a delivery again; it is sent by the next
+// delivery run, hence 202.
+func redeliverWebhookHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		delivery, err := app.WebhookService.Redeliver(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusAccepted, newWebhookDeliveryResponse(*delivery))
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lzzzzl/page-turner-pro/internal/app"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/catalog"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type bookResponse struct {
+	ID             int                        `json:"id"`
+	WorkID         int                        `json:"work_id"`
+	Title          string                     `json:"title"`
+	Author         string                     `json:"author"`
+	PublishedYear  int                        `json:"published_year"`
+	ISBN           string                     `json:"isbn"`
+	Edition        string                     `json:"edition,omitempty"`
+	Language       string                     `json:"language,omitempty"`
+	Classification model.ClassificationScheme `json:"classification,omitempty"`
+	CallNumber     string                     `json:"call_number,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+}
+
+func newBookResponse(b model.Book) bookResponse {
+	resp := bookResponse{
+		ID:            b.ID,
+		WorkID:        b.WorkID,
+		Title:         b.Title,
+		Author:        b.Author,
+		PublishedYear: b.PublishedYear.Year(),
+		ISBN:          b.ISBN,
+		Edition:       b.Edition,
+		Language:      b.Language,
+		CreatedAt:     b.CreatedAt,
+		UpdatedAt:     b.UpdatedAt,
+	}
+	if b.CallNumber != nil {
+		resp.Classification = b.CallNumber.Scheme
+		resp.CallNumber = b.CallNumber.Raw
+	}
+	return resp
+}
+
+type workResponse struct {
+	ID        int            `json:"id"`
+	Title     string         `json:"title"`
+	Author    string         `json:"author"`
+	Editions  []bookResponse `json:"editions"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func newWorkResponse(w model.WorkWithEditions) workResponse {
+	editions := make([]bookResponse, 0, len(w.Editions))
+	for _, b := range w.Editions {
+		editions = append(editions, newBookResponse(b))
+	}
+	return workResponse{
+		ID:        w.ID,
+		Title:     w.Title,
+		Author:    w.Author,
+		Editions:  editions,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func createWorkHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		Title  string `json:"title" binding:"required"`
+		Author string `json:"author" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		work, err := app.CatalogService.CreateWork(c.Request.Context(), body.Title, body.Author)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newWorkResponse(model.WorkWithEditions{Work: *work}))
+	}
+}
+
+func getWorkHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		work, err := app.CatalogService.GetWork(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newWorkResponse(*work))
+	}
+}
+
+func searchWorksHandler(app *app.Application) gin.HandlerFunc {
+	type Query struct {
+		Keyword string `form:"q"`
+		Limit   int    `form:"limit"`
+		Offset  int    `form:"offset" binding:"min=0"`
+	}
+
+	return func(c *gin.Context) {
+		var query Query
+		if !bindQuery(c, &query) {
+			return
+		}
+
+		works, err := app.CatalogService.SearchWorks(c.Request.Context(), catalog.SearchWorksParam{
+			Keyword: query.Keyword,
+			Limit:   query.Limit,
+			Offset:  query.Offset,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		resp := make([]workResponse, 0, len(works))
+		for _, w := range works {
+			resp = append(resp, newWorkResponse(*w))
+		}
+		respondWithJSON(c, http.StatusOK, resp)
+	}
+}
+
+func createBookHandler(app *app.Application) gin.HandlerFunc {
+	type Body struct {
+		WorkID         int                        `json:"work_id"`
+		Title          string                     `json:"title" binding:"required"`
+		Author         string                     `json:"author" binding:"required"`
+		ISBN           string                     `json:"isbn" binding:"required"`
+		PublishedYear  int                        `json:"published_year" binding:"required"`
+		Edition        string                     `json:"edition"`
+		Language       string                     `json:"language"`
+		Classification model.ClassificationScheme `json:"classification"`
+		CallNumber     string                     `json:"call_number"`
+	}
+
+	return func(c *gin.Context) {
+		var body Body
+		if !bindJSON(c, &body) {
+			return
+		}
+
+		book, err := app.CatalogService.CreateBook(c.Request.Context(), catalog.CreateBookParam{
+			WorkID:         body.WorkID,
+			Title:          body.Title,
+			Author:         body.Author,
+			ISBN:           body.ISBN,
+			PublishedYear:  body.PublishedYear,
+			Edition:        body.Edition,
+			Language:       body.Language,
+			Classification: body.Classification,
+			CallNumber:     body.CallNumber,
+		})
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusCreated, newBookResponse(*book))
+	}
+}
+
+func getBookHandler(app *app.Application) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := pathID(c, "id")
+		if !ok {
+			return
+		}
+
+		book, err := app.CatalogService.GetBook(c.Request.Context(), id)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		respondWithJSON(c, http.StatusOK, newBookResponse(*book))
+	}
+}
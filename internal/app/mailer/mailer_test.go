@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewFileMailer(dir, "library@pageturnerpro.com")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), Message{
+		To:      "user1@pageturnerpro.com",
+		Subject: "Confirm your address",
+		Body:    "Hello,\nwelcome.",
+	}))
+	assert.Error(t, m.Send(context.Background(), Message{To: "not an address", Subject: "x"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "To: user1@pageturnerpro.com\r\n")
+	assert.True(t, strings.HasSuffix(string(content), "\r\n\r\nHello,\r\nwelcome."))
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemoryMailer()
+	require.NoError(t, m.Send(context.Background(), Message{To: "user1@pageturnerpro.com", Subject: "a"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "user2@pageturnerpro.com", Subject: "b"}))
+
+	messages := m.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "b", messages[1].Subject)
+}
+
+func TestValidateMessage(t *testing.T) {
+	assert.NoError(t, ValidateMessage(Message{To: "User One <user1@pageturnerpro.com>", Subject: "Hello"}))
+	assert.Error(t, ValidateMessage(Message{To: "", Subject: "Hello"}))
+	assert.Error(t, ValidateMessage(Message{To: "user1@pageturnerpro.com", Subject: "Hello\r\nBcc: x@example.com"}))
+}
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2023, 9, 1, 9, 0, 0, 0, time.UTC)
+	out := string(format("library@pageturnerpro.com", Message{To: "user1@pageturnerpro.com", Subject: "Réservation", Body: "ok"}, date))
+	assert.Contains(t, out, "Subject: =?utf-8?q?R=C3=A9servation?=\r\n")
+	assert.Contains(t, out, "Date: Fri, 01 Sep 2023 09:00:00 +0000\r\n")
+}
@@ -0,0 +1,59 @@
+// Package notifier renders notifications to patrons and delivers them over
+// channels such as email.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/mailer"
+)
+
+// Message is a rendered notification.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Channel delivers notifications to patrons.
+type Channel interface {
+	// Deliver sends a message, or fails without having sent it.
+	Deliver(ctx context.Context, msg Message) error
+}
+
+// EmailChannel delivers notifications by email.
+type EmailChannel struct {
+	mailer mailer.Mailer
+}
+
+func NewEmailChannel(m mailer.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: m}
+}
+
+func (c *EmailChannel) Deliver(ctx context.Context, msg Message) error {
+	return c.mailer.Send(ctx, mailer.Message{To: msg.To, Subject: msg.Subject, Body: msg.Body})
+}
+
+// LogChannel writes notifications out instead of sending them, for
+// development.
+type LogChannel struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+func NewLogChannel(w io.Writer) *LogChannel {
+	return &LogChannel{w: w, now: time.Now}
+}
+
+func (c *LogChannel) Deliver(_ context.Context, msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := fmt.Fprintf(c.w, "--- %s\nTo: %s\nSubject: %s\n\n%s\n",
+		c.now().UTC().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
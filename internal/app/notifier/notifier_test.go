@@ -0,0 +1,88 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/mailer"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_Render(t *testing.T) {
+	templates, err := LoadTemplates()
+	require.NoError(t, err)
+	assert.True(t, templates.HasLocale("es"))
+
+	notice := model.Notice{
+		Type:    model.NotificationDueSoon,
+		Email:   "user1@pageturnerpro.com",
+		Name:    "user1",
+		Title:   "Don Quixote",
+		DueDate: time.Date(2023, 5, 22, 23, 59, 0, 0, time.UTC),
+	}
+
+	msg, locale, err := templates.Render("en", notice)
+	require.NoError(t, err)
+	assert.Equal(t, "en", locale)
+	assert.Equal(t, "user1@pageturnerpro.com", msg.To)
+	assert.Equal(t, "Due soon: Don Quixote", msg.Subject)
+	assert.Contains(t, msg.Body, "Hello user1,\n")
+	assert.Contains(t, msg.Body, "due back on Monday 22 May 2023")
+
+	msg, locale, err = templates.Render("es", notice)
+	require.NoError(t, err)
+	assert.Equal(t, "es", locale)
+	assert.Equal(t, "Vence pronto: Don Quixote", msg.Subject)
+	assert.Contains(t, msg.Body, "el 22/05/2023")
+
+	// no templates in French
+	msg, locale, err = templates.Render("fr", model.Notice{Type: model.NotificationHoldReady, Title: "The Little Prince", BranchName: "Main Library"})
+	require.NoError(t, err)
+	assert.Equal(t, "en", locale)
+	assert.Equal(t, "Ready for pickup: The Little Prince", msg.Subject)
+	assert.Contains(t, msg.Body, "waiting for you at Main Library")
+
+	_, _, err = templates.Render("en", model.Notice{Type: "Unknown"})
+	assert.Error(t, err)
+}
+
+func TestTemplates_RenderDigest(t *testing.T) {
+	templates, err := LoadTemplates()
+	require.NoError(t, err)
+
+	notices := []model.Notice{
+		{Type: model.NotificationOverdue, Title: "Don Quixote", DueDate: time.Date(2023, 5, 22, 23, 59, 0, 0, time.UTC)},
+		{Type: model.NotificationHoldReady, Title: "The Little Prince", BranchName: "Main Library"},
+	}
+	msg, locale, err := templates.RenderDigest("en", "user1@pageturnerpro.com", "user1", notices)
+	require.NoError(t, err)
+	assert.Equal(t, "en", locale)
+	assert.Equal(t, "user1@pageturnerpro.com", msg.To)
+	assert.Equal(t, "Your library update: 2 notices", msg.Subject)
+	assert.Contains(t, msg.Body, "Hello user1,\n")
+	assert.Contains(t, msg.Body, "* \"Don Quixote\" was due back on Monday 22 May 2023 and is overdue.\n"+
+		"* \"The Little Prince\" is ready for pickup at Main Library.\n")
+
+	msg, locale, err = templates.RenderDigest("es", "user1@pageturnerpro.com", "user1", notices[:1])
+	require.NoError(t, err)
+	assert.Equal(t, "es", locale)
+	assert.Equal(t, "Novedades de su biblioteca: 1 aviso", msg.Subject)
+}
+
+func TestChannels(t *testing.T) {
+	msg := Message{To: "user1@pageturnerpro.com", Subject: "Overdue: Don Quixote", Body: "Please return it.\n"}
+
+	m := mailer.NewMemoryMailer()
+	require.NoError(t, NewEmailChannel(m).Deliver(context.Background(), msg))
+	assert.Equal(t, []mailer.Message{{To: msg.To, Subject: msg.Subject, Body: msg.Body}}, m.Messages())
+
+	var buf bytes.Buffer
+	c := NewLogChannel(&buf)
+	c.now = func() time.Time { return time.Date(2023, 6, 23, 8, 0, 0, 0, time.UTC) }
+	require.NoError(t, c.Deliver(context.Background(), msg))
+	assert.Equal(t, "--- 2023-06-23T08:00:00Z\nTo: user1@pageturnerpro.com\nSubject: Overdue: Don Quixote\n\nPlease return it.\n\n", buf.String())
+}
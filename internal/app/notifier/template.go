@@ -0,0 +1,157 @@
+package notifier
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+// DefaultLocale is the locale every notification has a template in, used
+// when there is none in the locale asked for.
+const DefaultLocale = "en"
+
+//go:embed templates/*/*.tmpl
+var templateFS embed.FS
+
+// templateNames are the files, one per locale directory, defining the
+// subject and body of each type of notification, and the summary line it
+// takes in digests.
+var templateNames = map[model.NotificationType]string{
+	model.NotificationDueSoon:   "due_soon.tmpl",
+	model.NotificationOverdue:   "overdue.tmpl",
+	model.NotificationHoldReady: "hold_ready.tmpl",
+}
+
+// digestTemplateName is the file, in each locale directory, defining the
+// daily digest gathering several notifications.
+const digestTemplateName = "digest.tmpl"
+
+// Templates renders notifications in the locales there are templates for.
+type Templates struct {
+	byLocale map[string]map[model.NotificationType]*template.Template
+	digests  map[string]*template.Template
+}
+
+// LoadTemplates parses the templates shipped with the application. Every
+// locale must have a template for every type of notification.
+func LoadTemplates() (*Templates, error) {
+	dirs, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{
+		byLocale: map[string]map[model.NotificationType]*template.Template{},
+		digests:  map[string]*template.Template{},
+	}
+	for _, dir := range dirs {
+		locale := dir.Name()
+		t.byLocale[locale] = map[model.NotificationType]*template.Template{}
+		for typ, name := range templateNames {
+			tmpl, err := parseTemplate(locale, name, "subject", "body", "summary")
+			if err != nil {
+				return nil, err
+			}
+			t.byLocale[locale][typ] = tmpl
+		}
+		tmpl, err := parseTemplate(locale, digestTemplateName, "subject", "body")
+		if err != nil {
+			return nil, err
+		}
+		t.digests[locale] = tmpl
+	}
+	if _, ok := t.byLocale[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("no templates in default locale %s", DefaultLocale)
+	}
+	return t, nil
+}
+
+// parseTemplate parses a template of a locale, which must define the blocks.
+func parseTemplate(locale, name string, blocks ...string) (*template.Template, error) {
+	tmpl, err := template.ParseFS(templateFS, path.Join("templates", locale, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s of locale %s: %w", name, locale, err)
+	}
+	for _, block := range blocks {
+		if tmpl.Lookup(block) == nil {
+			return nil, fmt.Errorf("template %s of locale %s lacks a %s", name, locale, block)
+		}
+	}
+	return tmpl, nil
+}
+
+// HasLocale reports whether there are templates in a locale.
+func (t *Templates) HasLocale(locale string) bool {
+	_, ok := t.byLocale[locale]
+	return ok
+}
+
+// Render returns the message of a notice in a locale, falling back to the
+// default locale, and the locale it was rendered in.
+func (t *Templates) Render(locale string, notice model.Notice) (Message, string, error) {
+	if !t.HasLocale(locale) {
+		locale = DefaultLocale
+	}
+	tmpl, ok := t.byLocale[locale][notice.Type]
+	if !ok {
+		return Message{}, "", fmt.Errorf("unknown notification type %q", notice.Type)
+	}
+
+	msg, err := render(tmpl, notice)
+	if err != nil {
+		return Message{}, "", err
+	}
+	msg.To = notice.Email
+	return msg, locale, nil
+}
+
+// RenderDigest gathers the notices of a patron into one message, in a locale
+// as Render does.
+func (t *Templates) RenderDigest(locale, to, name string, notices []model.Notice) (Message, string, error) {
+	if !t.HasLocale(locale) {
+		locale = DefaultLocale
+	}
+	summaries := make([]string, 0, len(notices))
+	for _, notice := range notices {
+		tmpl, ok := t.byLocale[locale][notice.Type]
+		if !ok {
+			return Message{}, "", fmt.Errorf("unknown notification type %q", notice.Type)
+		}
+		var summary bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&summary, "summary", notice); err != nil {
+			return Message{}, "", fmt.Errorf("failed to render summary: %w", err)
+		}
+		summaries = append(summaries, strings.TrimSpace(summary.String()))
+	}
+
+	msg, err := render(t.digests[locale], struct {
+		Name      string
+		Notices   []model.Notice
+		Summaries []string
+	}{Name: name, Notices: notices, Summaries: summaries})
+	if err != nil {
+		return Message{}, "", err
+	}
+	msg.To = to
+	return msg, locale, nil
+}
+
+func render(tmpl *template.Template, data interface{}) (Message, error) {
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render body: %w", err)
+	}
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimLeft(body.String(), "\n"),
+	}, nil
+}
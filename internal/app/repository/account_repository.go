@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoColumnPatternCredential struct {
+	UserID       string
+	PasswordHash string
+	CreatedAt    string
+	UpdatedAt    string
+}
+
+const repoTableCredential = "user_credentials"
+
+var repoColumnCredential = repoColumnPatternCredential{
+	UserID:       "user_id",
+	PasswordHash: "password_hash",
+	CreatedAt:    "created_at",
+	UpdatedAt:    "updated_at",
+}
+
+type repoAccountToken struct {
+	ID        int                       `db:"id"`
+	UserID    int                       `db:"user_id"`
+	Purpose   model.AccountTokenPurpose `db:"purpose"`
+	TokenHash string                    `db:"token_hash"`
+	ExpiresAt time.Time                 `db:"expires_at"`
+	UsedAt    *time.Time                `db:"used_at"`
+	CreatedAt time.Time                 `db:"created_at"`
+}
+
+type repoColumnPatternAccountToken struct {
+	ID        string
+	UserID    string
+	Purpose   string
+	TokenHash string
+	ExpiresAt string
+	UsedAt    string
+	CreatedAt string
+}
+
+const repoTableAccountToken = "account_tokens"
+
+var repoColumnAccountToken = repoColumnPatternAccountToken{
+	ID:        "id",
+	UserID:    "user_id",
+	Purpose:   "purpose",
+	TokenHash: "token_hash",
+	ExpiresAt: "expires_at",
+	UsedAt:    "used_at",
+	CreatedAt: "created_at",
+}
+
+func (c *repoColumnPatternAccountToken) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.UserID,
+		c.Purpose,
+		c.TokenHash,
+		c.ExpiresAt,
+		c.UsedAt,
+		c.CreatedAt,
+	}, ", ")
+}
+
+// CreateAccount signs a patron up with a password, along with the token
+// verifying their address.
+func (r *PostgresRepository) CreateAccount(ctx context.Context, param model.User, passwordHash string, token model.AccountToken) (*model.User, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.createAccount(ctx, tx, param, passwordHash, token)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *PostgresRepository) createAccount(ctx context.Context, db sqlContextGetter, param model.User, passwordHash string, token model.AccountToken) (*model.User, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableUser).
+		SetMap(map[string]interface{}{
+			repoColumnUser.UID:   param.UID,
+			repoColumnUser.Email: param.Email,
+			repoColumnUser.Name:  param.Name,
+		}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnUser.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoUser
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the email is already registered"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	user := model.User(row)
+	if cErr := r.setPasswordHash(ctx, db, user.ID, passwordHash); cErr != nil {
+		return nil, cErr
+	}
+	token.UserID = user.ID
+	if _, cErr := r.insertAccountToken(ctx, db, token); cErr != nil {
+		return nil, cErr
+	}
+	return &user, nil
+}
+
+// IssueAccountToken records a new token for a patron, superseding any token
+// of the same purpose not used yet.
+func (r *PostgresRepository) IssueAccountToken(ctx context.Context, param model.AccountToken) (*model.AccountToken, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := r.issueAccountToken(ctx, tx, param)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *PostgresRepository) issueAccountToken(ctx context.Context, db sqlContextGetter, param model.AccountToken) (*model.AccountToken, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableAccountToken).
+		Set(repoColumnAccountToken.UsedAt, sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{
+			repoColumnAccountToken.UserID:  param.UserID,
+			repoColumnAccountToken.Purpose: param.Purpose,
+			repoColumnAccountToken.UsedAt:  nil,
+		}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	return r.insertAccountToken(ctx, db, param)
+}
+
+func (r *PostgresRepository) insertAccountToken(ctx context.Context, db sqlContextGetter, param model.AccountToken) (*model.AccountToken, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableAccountToken).
+		SetMap(map[string]interface{}{
+			repoColumnAccountToken.UserID:    param.UserID,
+			repoColumnAccountToken.Purpose:   param.Purpose,
+			repoColumnAccountToken.TokenHash: param.TokenHash,
+			repoColumnAccountToken.ExpiresAt: param.ExpiresAt,
+		}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnAccountToken.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoAccountToken
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	token := model.AccountToken(row)
+	return &token, nil
+}
+
+// useAccountToken spends the token with a hash for a purpose. Unknown,
+// spent and expired tokens are all refused alike.
+func (r *PostgresRepository) useAccountToken(ctx context.Context, db sqlContextGetter, tokenHash string, purpose model.AccountTokenPurpose, now time.Time) (*model.AccountToken, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnAccountToken.columns()).
+		From(repoTableAccountToken).
+		Where(sq.Eq{repoColumnAccountToken.TokenHash: tokenHash}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoAccountToken
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the token is invalid or expired"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	token := model.AccountToken(row)
+	if err := model.ValidateAccountToken(token, purpose, now); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the token is invalid or expired"))
+	}
+
+	// build SQL query
+	query, args, err = r.pgsq.Update(repoTableAccountToken).
+		Set(repoColumnAccountToken.UsedAt, now).
+		Where(sq.Eq{repoColumnAccountToken.ID: token.ID}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	token.UsedAt = &now
+	return &token, nil
+}
+
+// VerifyEmail spends an email verification token and marks the address of
+// its patron verified.
+func (r *PostgresRepository) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (*model.User, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.verifyEmail(ctx, tx, tokenHash, model.TokenVerifyEmail, now)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *PostgresRepository) verifyEmail(ctx context.Context, db sqlContextGetter, tokenHash string, purpose model.AccountTokenPurpose, now time.Time) (*model.User, common.Error) {
+	token, cErr := r.useAccountToken(ctx, db, tokenHash, purpose, now)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableUser).
+		SetMap(map[string]interface{}{
+			repoColumnUser.EmailVerifiedAt: sq.Expr(fmt.Sprintf("COALESCE(%s, ?)", repoColumnUser.EmailVerifiedAt), now),
+			repoColumnUser.UpdatedAt:       sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnUser.ID: token.UserID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnUser.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoUser
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	user := model.User(row)
+	return &user, nil
+}
+
+// ResetPassword spends a password reset token and sets the password of its
+// patron. Having received the token, the patron owns the address, which is
+// marked verified too.
+func (r *PostgresRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (*model.User, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.resetPassword(ctx, tx, tokenHash, passwordHash, now)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *PostgresRepository) resetPassword(ctx context.Context, db sqlContextGetter, tokenHash, passwordHash string, now time.Time) (*model.User, common.Error) {
+	user, cErr := r.verifyEmail(ctx, db, tokenHash, model.TokenResetPassword, now)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if cErr = r.setPasswordHash(ctx, db, user.ID, passwordHash); cErr != nil {
+		return nil, cErr
+	}
+	return user, nil
+}
+
+// setPasswordHash sets the password of a patron, who may have had none, e.g.
+// when imported.
+func (r *PostgresRepository) setPasswordHash(ctx context.Context, db sqlContextGetter, userID int, passwordHash string) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableCredential).
+		SetMap(map[string]interface{}{
+			repoColumnCredential.UserID:       userID,
+			repoColumnCredential.PasswordHash: passwordHash,
+		}).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s, %s = CURRENT_TIMESTAMP",
+			repoColumnCredential.UserID,
+			repoColumnCredential.PasswordHash, repoColumnCredential.PasswordHash,
+			repoColumnCredential.UpdatedAt)).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return nil
+}
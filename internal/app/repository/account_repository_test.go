@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storedPasswordHash reads the password hash of a user from the credentials table.
+func storedPasswordHash(t *testing.T, db *sqlx.DB, userID int) (string, bool) {
+	var hashes []string
+	err := db.Select(&hashes, "SELECT password_hash FROM user_credentials WHERE user_id = $1", userID)
+	require.NoError(t, err)
+	if len(hashes) == 0 {
+		return "", false
+	}
+	return hashes[0], true
+}
+
+func TestAccountRepository_CreateAccount(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db)
+	now := time.Now()
+
+	token, secret, tErr := model.NewAccountToken(0, model.TokenVerifyEmail, now, time.Hour)
+	require.NoError(t, tErr)
+	param := model.User{UID: "5b0e8c1d-6f2a-4c7e-8d3b-2a9f1e7c4d60", Email: "reader@pageturnerpro.com", Name: "reader"}
+
+	user, err := repo.CreateAccount(context.Background(), param, "hash", token)
+	require.NoError(t, err)
+	assert.Equal(t, param.Email, user.Email)
+	assert.Nil(t, user.EmailVerifiedAt)
+	hash, ok := storedPasswordHash(t, db, user.ID)
+	require.True(t, ok)
+	assert.Equal(t, "hash", hash)
+
+	// the address is taken
+	_, err = repo.CreateAccount(context.Background(), param, "hash", token)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	// a reset token cannot verify the address
+	_, err = repo.ResetPassword(context.Background(), model.HashAccountToken(secret), "new hash", now)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	verified, err := repo.VerifyEmail(context.Background(), model.HashAccountToken(secret), now)
+	require.NoError(t, err)
+	assert.NotNil(t, verified.EmailVerifiedAt)
+
+	// tokens are single-use
+	_, err = repo.VerifyEmail(context.Background(), model.HashAccountToken(secret), now)
+	require.Error(t, err)
+	assert.Equal(t, "the token is invalid or expired", err.(common.DomainError).ClientMsg())
+}
+
+func TestAccountRepository_ResetPassword(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataUser))
+	now := time.Now()
+	userID := 1
+
+	// user 1 was enrolled at the desk and has no password yet
+	_, ok := storedPasswordHash(t, db, userID)
+	require.False(t, ok)
+
+	first, firstSecret, tErr := model.NewAccountToken(userID, model.TokenResetPassword, now, time.Hour)
+	require.NoError(t, tErr)
+	_, err := repo.IssueAccountToken(context.Background(), first)
+	require.NoError(t, err)
+	second, secondSecret, tErr := model.NewAccountToken(userID, model.TokenResetPassword, now, time.Hour)
+	require.NoError(t, tErr)
+	_, err = repo.IssueAccountToken(context.Background(), second)
+	require.NoError(t, err)
+
+	// the second token superseded the first
+	_, err = repo.ResetPassword(context.Background(), model.HashAccountToken(firstSecret), "hash", now)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	// expired
+	_, err = repo.ResetPassword(context.Background(), model.HashAccountToken(secondSecret), "hash", now.Add(2*time.Hour))
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	user, err := repo.ResetPassword(context.Background(), model.HashAccountToken(secondSecret), "hash", now)
+	require.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	hash, ok := storedPasswordHash(t, db, userID)
+	require.True(t, ok)
+	assert.Equal(t, "hash", hash)
+}
@@ -0,0 +1,433 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoBookCopies struct {
+	ID              int        `db:"id"`
+	BookID          int        `db:"book_id"`
+	Barcode         *string    `db:"barcode"`
+	HomeBranchID    int        `db:"home_branch_id"`
+	CurrentBranchID int        `db:"current_branch_id"`
+	Status          string     `db:"status"`
+	CallNumber      *string    `db:"call_number"`
+	CallNumberSort  *string    `db:"call_number_sort"`
+	PriceCents      *int64     `db:"price_cents"`
+	RetiredAt       *time.Time `db:"retired_at"`
+	RetiredReason   *string    `db:"retired_reason"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+}
+
+type repoColumnPatternBookCopies struct {
+	ID              string
+	BookID          string
+	Barcode         string
+	HomeBranchID    string
+	CurrentBranchID string
+	Status          string
+	CallNumber      string
+	CallNumberSort  string
+	PriceCents      string
+	RetiredAt       string
+	RetiredReason   string
+	CreatedAt       string
+	UpdatedAt       string
+}
+
+const repoTableBookCopies = "book_copies"
+
+var repoColumnBookCopies = repoColumnPatternBookCopies{
+	ID:              "id",
+	BookID:          "book_id",
+	Barcode:         "barcode",
+	HomeBranchID:    "home_branch_id",
+	CurrentBranchID: "current_branch_id",
+	Status:          "status",
+	CallNumber:      "call_number",
+	CallNumberSort:  "call_number_sort",
+	PriceCents:      "price_cents",
+	RetiredAt:       "retired_at",
+	RetiredReason:   "retired_reason",
+	CreatedAt:       "created_at",
+	UpdatedAt:       "updated_at",
+}
+
+func (c *repoColumnPatternBookCopies) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.BookID,
+		c.Barcode,
+		c.HomeBranchID,
+		c.CurrentBranchID,
+		c.Status,
+		c.CallNumber,
+		c.CallNumberSort,
+		c.PriceCents,
+		c.RetiredAt,
+		c.RetiredReason,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+// repoBookStatus returns the book_status label of a status, which is its name.
+func repoBookStatus(status model.BookStatus) (string, common.Error) {
+	if !status.IsValid() {
+		return "", common.NewError(common.ErrorCodeInternalProcess, fmt.Errorf("unknown book status %d", status))
+	}
+	return status.String(), nil
+}
+
+func bookStatusFromRepo(label string) (model.BookStatus, error) {
+	return model.ParseBookStatus(label)
+}
+
+// toModel maps a copy row. The scheme of a copy's call number is the one of its book.
+func (row repoBookCopies) toModel(scheme model.ClassificationScheme) (model.BookCopies, error) {
+	status, err := bookStatusFromRepo(row.Status)
+	if err != nil {
+		return model.BookCopies{}, err
+	}
+
+	bookCopy := model.BookCopies{
+		ID:              row.ID,
+		BookID:          row.BookID,
+		HomeBranchID:    row.HomeBranchID,
+		CurrentBranchID: row.CurrentBranchID,
+		Status:          status,
+		PriceCents:      row.PriceCents,
+		RetiredAt:       row.RetiredAt,
+		CreatedAt:       row.CreatedAt,
+		UpdatedAt:       row.UpdatedAt,
+	}
+	if row.Barcode != nil {
+		bookCopy.Barcode = *row.Barcode
+	}
+	if row.RetiredReason != nil {
+		bookCopy.RetiredReason = *row.RetiredReason
+	}
+	if row.CallNumber != nil && row.CallNumberSort != nil {
+		bookCopy.CallNumber = &model.CallNumber{
+			Scheme:  scheme,
+			Raw:     *row.CallNumber,
+			SortKey: *row.CallNumberSort,
+		}
+	}
+	return bookCopy, nil
+}
+
+// repoBookCopiesWithScheme is a copy row joined with the classification of its book.
+type repoBookCopiesWithScheme struct {
+	repoBookCopies
+	Classification *string `db:"classification"`
+}
+
+func (row repoBookCopiesWithScheme) toModel() (model.BookCopies, error) {
+	var scheme model.ClassificationScheme
+	if row.Classification != nil {
+		scheme = model.ClassificationScheme(*row.Classification)
+	}
+	return row.repoBookCopies.toModel(scheme)
+}
+
+// createBookCopy adds a copy and starts its status history.
+func (r *PostgresRepository) createBookCopy(ctx context.Context, db sqlContextGetter, param model.BookCopies, changedBy string) (*model.BookCopies, common.Error) {
+	status, cErr := repoBookStatus(param.Status)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	insert := map[string]interface{}{
+		repoColumnBookCopies.BookID: param.BookID,
+		repoColumnBookCopies.Status: status,
+	}
+	if param.Barcode != "" {
+		insert[repoColumnBookCopies.Barcode] = param.Barcode
+	}
+	if param.PriceCents != nil {
+		insert[repoColumnBookCopies.PriceCents] = *param.PriceCents
+	}
+	// a new copy sits at its home branch, the main branch unless told otherwise
+	homeBranch := interface{}(param.HomeBranchID)
+	if param.HomeBranchID == 0 {
+		homeBranch = sq.Expr(repoMainBranchID)
+	}
+	insert[repoColumnBookCopies.HomeBranchID] = homeBranch
+	insert[repoColumnBookCopies.CurrentBranchID] = homeBranch
+	scheme := model.ClassificationScheme("")
+	if cn := param.CallNumber; cn != nil {
+		insert[repoColumnBookCopies.CallNumber] = cn.Raw
+		insert[repoColumnBookCopies.CallNumberSort] = cn.SortKey
+		scheme = cn.Scheme
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableBookCopies).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBookCopies.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBookCopies
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("barcode %s is already in use", param.Barcode)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	bookCopy, err := row.toModel(scheme)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	change := model.NewBookStatusChange(bookCopy.ID, nil, bookCopy.Status, changedBy, "copy added")
+	if _, err := r.createBookStatusChange(ctx, db, change); err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+// CreateBookCopy adds a copy of a book. The call number scheme of the copy must be the one of the book.
+func (r *PostgresRepository) CreateBookCopy(ctx context.Context, param model.BookCopies, changedBy string) (*model.BookCopies, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	bookCopy, err := r.createBookCopy(ctx, tx, param, changedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return bookCopy, nil
+}
+
+// NextCopyBarcodeNumber returns a number never handed out before, to generate the barcode of an unlabelled copy.
+func (r *PostgresRepository) NextCopyBarcodeNumber(ctx context.Context) (int64, common.Error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, "SELECT nextval('book_copies_barcode_seq')"); err != nil {
+		return 0, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return seq, nil
+}
+
+func (r *PostgresRepository) GetBookCopyByID(ctx context.Context, id int) (*model.BookCopies, common.Error) {
+	return r.getBookCopy(ctx, r.db, sq.Eq{"bc." + repoColumnBookCopies.ID: id}, false)
+}
+
+func (r *PostgresRepository) GetBookCopyByBarcode(ctx context.Context, barcode string) (*model.BookCopies, common.Error) {
+	return r.getBookCopy(ctx, r.db, sq.Eq{"bc." + repoColumnBookCopies.Barcode: barcode}, false)
+}
+
+func (r *PostgresRepository) getBookCopy(ctx context.Context, db sqlContextGetter, where sq.Sqlizer, forUpdate bool) (*model.BookCopies, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(aliasColumns("bc", repoColumnBookCopies.columns()), "b."+repoColumnBook.Classification).
+		From(repoTableBookCopies + " bc").
+		Join(fmt.Sprintf("%s b ON b.%s = bc.%s", repoTableBook, repoColumnBook.ID, repoColumnBookCopies.BookID)).
+		Where(where)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE OF bc")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBookCopiesWithScheme
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	bookCopy, err := row.toModel()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return &bookCopy, nil
+}
+
+// ChangeBookCopyStatus moves a copy to another status by hand and records
+// the change in its history. Only the transitions staff may make are allowed;
+// statuses owned by loans, holds, transfers and losses are left to them.
+func (r *PostgresRepository) ChangeBookCopyStatus(ctx context.Context, id int, to model.BookStatus, changedBy, reason string) (*model.BookCopies, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	bookCopy, err := r.setBookCopyStatus(ctx, tx, id, to, changedBy, reason, model.ValidateManualTransition)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return bookCopy, nil
+}
+
+// changeBookCopyStatus moves a copy along any transition of its lifecycle,
+// for the workflows which keep their own records of the change.
+func (r *PostgresRepository) changeBookCopyStatus(ctx context.Context, db sqlContextGetter, id int, to model.BookStatus, changedBy, reason string) (*model.BookCopies, common.Error) {
+	return r.setBookCopyStatus(ctx, db, id, to, changedBy, reason, model.ValidateTransition)
+}
+
+func (r *PostgresRepository) setBookCopyStatus(ctx context.Context, db sqlContextGetter, id int, to model.BookStatus, changedBy, reason string, validate func(from, to model.BookStatus) error) (*model.BookCopies, common.Error) {
+	bookCopy, cErr := r.getBookCopy(ctx, db, sq.Eq{"bc." + repoColumnBookCopies.ID: id}, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	from := bookCopy.Status
+	if err := validate(from, to); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	status, cErr := repoBookStatus(to)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	now := time.Now()
+	update := map[string]interface{}{
+		repoColumnBookCopies.Status:    status,
+		repoColumnBookCopies.UpdatedAt: now,
+	}
+	// a withdrawn copy has left the collection for good
+	if to == model.Withdrawn {
+		update[repoColumnBookCopies.RetiredAt] = now
+		update[repoColumnBookCopies.RetiredReason] = reason
+		bookCopy.RetiredAt = &now
+		bookCopy.RetiredReason = reason
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBookCopies).
+		SetMap(update).
+		Where(sq.Eq{repoColumnBookCopies.ID: id}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	change := model.NewBookStatusChange(id, &from, to, changedBy, reason)
+	if _, err := r.createBookStatusChange(ctx, db, change); err != nil {
+		return nil, err
+	}
+
+	bookCopy.Status = to
+	bookCopy.UpdatedAt = now
+	return bookCopy, nil
+}
+
+// SetBookCopyPrice records what a copy cost; a nil price makes it unknown.
+func (r *PostgresRepository) SetBookCopyPrice(ctx context.Context, id int, priceCents *int64) (*model.BookCopies, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBookCopies).
+		SetMap(map[string]interface{}{
+			repoColumnBookCopies.PriceCents: priceCents,
+			repoColumnBookCopies.UpdatedAt:  sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnBookCopies.ID: id}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	} else if n == 0 {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows)
+	}
+
+	return r.GetBookCopyByID(ctx, id)
+}
+
+func (r *PostgresRepository) setBookCopyCurrentBranch(ctx context.Context, db sqlContextGetter, id int, branchID int) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBookCopies).
+		SetMap(map[string]interface{}{
+			repoColumnBookCopies.CurrentBranchID: branchID,
+			repoColumnBookCopies.UpdatedAt:       sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnBookCopies.ID: id}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return nil
+}
+
+// ListBookCopiesByBookIDs returns the copies of the given books, ordered by book and copy.
+func (r *PostgresRepository) ListBookCopiesByBookIDs(ctx context.Context, bookIDs []int) ([]*model.BookCopies, common.Error) {
+	if len(bookIDs) == 0 {
+		return nil, nil
+	}
+	return r.listBookCopies(ctx, sq.Eq{"bc." + repoColumnBookCopies.BookID: bookIDs})
+}
+
+func (r *PostgresRepository) ListBookCopiesByBarcodes(ctx context.Context, barcodes []string) ([]*model.BookCopies, common.Error) {
+	if len(barcodes) == 0 {
+		return nil, nil
+	}
+	return r.listBookCopies(ctx, sq.Eq{"bc." + repoColumnBookCopies.Barcode: barcodes})
+}
+
+func (r *PostgresRepository) listBookCopies(ctx context.Context, where sq.Sqlizer) ([]*model.BookCopies, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(aliasColumns("bc", repoColumnBookCopies.columns()), "b."+repoColumnBook.Classification).
+		From(repoTableBookCopies+" bc").
+		Join(fmt.Sprintf("%s b ON b.%s = bc.%s", repoTableBook, repoColumnBook.ID, repoColumnBookCopies.BookID)).
+		Where(where).
+		OrderBy("bc."+repoColumnBookCopies.BookID, "bc."+repoColumnBookCopies.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoBookCopiesWithScheme
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var copies []*model.BookCopies
+	for _, row := range rows {
+		bookCopy, err := row.toModel()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+		copies = append(copies, &bookCopy)
+	}
+
+	return copies, nil
+}
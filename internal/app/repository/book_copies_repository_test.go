@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initBookCopiesRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataHold),
+	)
+}
+
+func TestBookCopiesRepository_CreateBookCopy(t *testing.T) {
+	repo := initBookCopiesRepository(t)
+
+	created, err := repo.CreateBookCopy(context.Background(), model.NewBookCopies(3, "30000000000038", model.InLibrary), "librarian")
+	require.NoError(t, err)
+	assert.Equal(t, 3, created.BookID)
+	assert.Equal(t, "30000000000038", created.Barcode)
+	// copies catalogued without a branch belong to the main branch
+	assert.Equal(t, 1, created.HomeBranchID)
+	assert.Equal(t, 1, created.CurrentBranchID)
+
+	found, err := repo.GetBookCopyByBarcode(context.Background(), "30000000000038")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, found.ID)
+
+	// barcodes are unique
+	_, err = repo.CreateBookCopy(context.Background(), model.NewBookCopies(3, "30000000000038", model.InLibrary), "librarian")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+}
+
+func TestBookCopiesRepository_GetBookCopyByBarcode(t *testing.T) {
+	repo := initBookCopiesRepository(t)
+
+	bookCopy, err := repo.GetBookCopyByBarcode(context.Background(), "30000000000020")
+	require.NoError(t, err)
+	assert.Equal(t, 2, bookCopy.ID)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+	assert.False(t, bookCopy.IsRetired())
+
+	_, err = repo.GetBookCopyByBarcode(context.Background(), "30000000000046")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
+
+func TestBookCopiesRepository_NextCopyBarcodeNumber(t *testing.T) {
+	repo := initBookCopiesRepository(t)
+
+	first, err := repo.NextCopyBarcodeNumber(context.Background())
+	require.NoError(t, err)
+	second, err := repo.NextCopyBarcodeNumber(context.Background())
+	require.NoError(t, err)
+	assert.Greater(t, second, first)
+}
+
+func TestBookCopiesRepository_ChangeBookCopyStatus(t *testing.T) {
+	repo := initBookCopiesRepository(t)
+
+	bookCopy, err := repo.ChangeBookCopyStatus(context.Background(), 2, model.Damaged, "librarian", "spine torn")
+	require.NoError(t, err)
+	assert.Equal(t, model.Damaged, bookCopy.Status)
+
+	bookCopy, err = repo.ChangeBookCopyStatus(context.Background(), 2, model.InRepair, "librarian", "")
+	require.NoError(t, err)
+	assert.Equal(t, model.InRepair, bookCopy.Status)
+
+	// a copy in repair cannot be lent out
+	_, err = repo.ChangeBookCopyStatus(context.Background(), 2, model.Borrowed, "librarian", "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	history, err := repo.ListBookStatusHistory(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.NotNil(t, history[0].From)
+	assert.Equal(t, model.InLibrary, *history[0].From)
+	assert.Equal(t, model.Damaged, history[0].To)
+	assert.Equal(t, "spine torn", history[0].Reason)
+	assert.Equal(t, model.InRepair, history[1].To)
+	assert.Equal(t, "librarian", history[1].ChangedBy)
+}
+
+func TestBookCopiesRepository_Withdraw(t *testing.T) {
+	repo := initBookCopiesRepository(t)
+
+	retired, err := repo.ChangeBookCopyStatus(context.Background(), 2, model.Withdrawn, "librarian", "water damage")
+	require.NoError(t, err)
+	assert.True(t, retired.IsRetired())
+	assert.Equal(t, "water damage", retired.RetiredReason)
+
+	// a retired copy leaves the shelf
+	_, err = repo.GetShelfItemByCopyID(context.Background(), 2)
+	assert.Error(t, err)
+
+	// withdrawal is final
+	_, err = repo.ChangeBookCopyStatus(context.Background(), 2, model.InLibrary, "librarian", "")
+	assert.Error(t, err)
+
+	// copies on loan cannot be retired
+	_, err = repo.ChangeBookCopyStatus(context.Background(), 1, model.Withdrawn, "librarian", "lost by patron")
+	assert.Error(t, err)
+}
+
+func TestBookCopiesRepository_ChangeBookCopyStatus_Workflow(t *testing.T) {
+	repo := initBookCopiesRepository(t)
+
+	// checking in is left to the loan, which would otherwise stay open
+	_, err := repo.ChangeBookCopyStatus(context.Background(), 1, model.InLibrary, "librarian", "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	_, err = repo.ChangeBookCopyStatus(context.Background(), 2, model.Lost, "librarian", "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, model.Borrowed, bookCopy.Status)
+}
+
+func TestBookCopiesRepository_CheckBookStatusEnum(t *testing.T) {
+	repo := initBookCopiesRepository(t)
+
+	err := repo.CheckBookStatusEnum(context.Background())
+	assert.NoError(t, err)
+}
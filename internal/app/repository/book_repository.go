@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoBook struct {
+	ID             int       `db:"id"`
+	WorkID         int       `db:"work_id"`
+	Title          string    `db:"title"`
+	Author         string    `db:"author"`
+	PublishedYear  int       `db:"published_year"`
+	ISBN           string    `db:"isbn"`
+	Edition        string    `db:"edition"`
+	Language       string    `db:"language"`
+	Classification *string   `db:"classification"`
+	CallNumber     *string   `db:"call_number"`
+	CallNumberSort *string   `db:"call_number_sort"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+type repoColumnPatternBook struct {
+	ID             string
+	WorkID         string
+	Title          string
+	Author         string
+	PublishedYear  string
+	ISBN           string
+	Edition        string
+	Language       string
+	Classification string
+	CallNumber     string
+	CallNumberSort string
+	CreatedAt      string
+	UpdatedAt      string
+}
+
+const repoTableBook = "books"
+
+var repoColumnBook = repoColumnPatternBook{
+	ID:             "id",
+	WorkID:         "work_id",
+	Title:          "title",
+	Author:         "author",
+	PublishedYear:  "published_year",
+	ISBN:           "isbn",
+	Edition:        "edition",
+	Language:       "language",
+	Classification: "classification",
+	CallNumber:     "call_number",
+	CallNumberSort: "call_number_sort",
+	CreatedAt:      "created_at",
+	UpdatedAt:      "updated_at",
+}
+
+func (c *repoColumnPatternBook) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.WorkID,
+		c.Title,
+		c.Author,
+		c.PublishedYear,
+		c.ISBN,
+		c.Edition,
+		c.Language,
+		c.Classification,
+		c.CallNumber,
+		c.CallNumberSort,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+// books stores only the year of publication, so it is mapped to January 1st of that year.
+func (row repoBook) toModel() model.Book {
+	var callNumber *model.CallNumber
+	if row.Classification != nil && row.CallNumber != nil && row.CallNumberSort != nil {
+		callNumber = &model.CallNumber{
+			Scheme:  model.ClassificationScheme(*row.Classification),
+			Raw:     *row.CallNumber,
+			SortKey: *row.CallNumberSort,
+		}
+	}
+
+	return model.Book{
+		ID:            row.ID,
+		WorkID:        row.WorkID,
+		Title:         row.Title,
+		Author:        row.Author,
+		PublishedYear: time.Date(row.PublishedYear, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ISBN:          row.ISBN,
+		Edition:       row.Edition,
+		Language:      row.Language,
+		CallNumber:    callNumber,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}
+}
+
+func (r *PostgresRepository) CreateBook(ctx context.Context, param model.Book) (*model.Book, common.Error) {
+	return r.createBook(ctx, r.db, param)
+}
+
+// CreateBookWithWork creates a work and its first edition in one
+// transaction, so that a book refused by the catalog leaves no work behind.
+func (r *PostgresRepository) CreateBookWithWork(ctx context.Context, work model.Work, book model.Book) (*model.Work, *model.Book, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	createdWork, createdBook, err := r.createBookWithWork(ctx, tx, work, book)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, nil, err
+	}
+
+	return createdWork, createdBook, nil
+}
+
+func (r *PostgresRepository) createBookWithWork(ctx context.Context, db sqlContextGetter, work model.Work, book model.Book) (*model.Work, *model.Book, common.Error) {
+	createdWork, err := r.createWork(ctx, db, work)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	book.WorkID = createdWork.ID
+	createdBook, err := r.createBook(ctx, db, book)
+	if err != nil {
+		return nil, nil, err
+	}
+	return createdWork, createdBook, nil
+}
+
+func (r *PostgresRepository) createBook(ctx context.Context, db sqlContextGetter, param model.Book) (*model.Book, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnBook.WorkID:        param.WorkID,
+		repoColumnBook.Title:         param.Title,
+		repoColumnBook.Author:        param.Author,
+		repoColumnBook.PublishedYear: param.PublishedYear.Year(),
+		repoColumnBook.ISBN:          param.ISBN,
+		repoColumnBook.Edition:       param.Edition,
+		repoColumnBook.Language:      param.Language,
+	}
+	for column, value := range callNumberColumns(param.CallNumber) {
+		insert[column] = value
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableBook).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBook.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBook
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("ISBN already catalogued"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	book := row.toModel()
+	return &book, nil
+}
+
+// updateBook overwrites the descriptive fields of a book. The work it belongs to is kept.
+func (r *PostgresRepository) updateBook(ctx context.Context, db sqlContextGetter, param model.Book) (*model.Book, common.Error) {
+	update := map[string]interface{}{
+		repoColumnBook.Title:         param.Title,
+		repoColumnBook.Author:        param.Author,
+		repoColumnBook.PublishedYear: param.PublishedYear.Year(),
+		repoColumnBook.ISBN:          param.ISBN,
+		repoColumnBook.Edition:       param.Edition,
+		repoColumnBook.Language:      param.Language,
+		repoColumnBook.UpdatedAt:     sq.Expr("CURRENT_TIMESTAMP"),
+	}
+	for column, value := range callNumberColumns(param.CallNumber) {
+		update[column] = value
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBook).
+		SetMap(update).
+		Where(sq.Eq{repoColumnBook.ID: param.ID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBook.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBook
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	book := row.toModel()
+	return &book, nil
+}
+
+func (r *PostgresRepository) GetBookByID(ctx context.Context, id int) (*model.Book, common.Error) {
+	where := sq.And{
+		sq.Eq{repoColumnBook.ID: id},
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnBook.columns()).
+		From(repoTableBook).
+		Where(where).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBook
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	book := row.toModel()
+	return &book, nil
+}
+
+// ListBooksByWorkIDs returns every edition belonging to the given works.
+func (r *PostgresRepository) ListBooksByWorkIDs(ctx context.Context, workIDs []int) ([]*model.Book, common.Error) {
+	if len(workIDs) == 0 {
+		return nil, nil
+	}
+
+	where := sq.And{
+		sq.Eq{repoColumnBook.WorkID: workIDs},
+	}
+
+	return r.listBooks(ctx, where, 0, repoColumnBook.WorkID, repoColumnBook.PublishedYear, repoColumnBook.ID)
+}
+
+// ListBooksByIDs returns the books with the given IDs, ordered by ID.
+func (r *PostgresRepository) ListBooksByIDs(ctx context.Context, ids []int) ([]*model.Book, common.Error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	where := sq.And{
+		sq.Eq{repoColumnBook.ID: ids},
+	}
+
+	return r.listBooks(ctx, where, 0, repoColumnBook.ID)
+}
+
+// ListBooksByISBNs returns the books with any of the given ISBNs.
+func (r *PostgresRepository) ListBooksByISBNs(ctx context.Context, isbns []string) ([]*model.Book, common.Error) {
+	if len(isbns) == 0 {
+		return nil, nil
+	}
+
+	where := sq.And{
+		sq.Eq{repoColumnBook.ISBN: isbns},
+	}
+
+	return r.listBooks(ctx, where, 0, repoColumnBook.ID)
+}
+
+// ListBooksAfterID pages through the whole catalog in ID order.
+func (r *PostgresRepository) ListBooksAfterID(ctx context.Context, afterID int, limit int) ([]*model.Book, common.Error) {
+	where := sq.And{
+		sq.Gt{repoColumnBook.ID: afterID},
+	}
+
+	return r.listBooks(ctx, where, limit, repoColumnBook.ID)
+}
+
+func (r *PostgresRepository) listBooks(ctx context.Context, where sq.And, limit int, orderBy ...string) ([]*model.Book, common.Error) {
+	builder := r.pgsq.Select(repoColumnBook.columns()).
+		From(repoTableBook).
+		Where(where).
+		OrderBy(orderBy...)
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit))
+	}
+
+	// build SQL query
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoBook
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var books []*model.Book
+	for _, row := range rows {
+		book := row.toModel()
+		books = append(books, &book)
+	}
+
+	return books, nil
+}
+
+// callNumberColumns maps a call number to the book columns storing it.
+func callNumberColumns(cn *model.CallNumber) map[string]interface{} {
+	if cn == nil {
+		return map[string]interface{}{
+			repoColumnBook.Classification: nil,
+			repoColumnBook.CallNumber:     nil,
+			repoColumnBook.CallNumberSort: nil,
+		}
+	}
+	return map[string]interface{}{
+		repoColumnBook.Classification: cn.Scheme,
+		repoColumnBook.CallNumber:     cn.Raw,
+		repoColumnBook.CallNumberSort: cn.SortKey,
+	}
+}
+
+// SetBookCallNumber classifies a book, or clears its classification when cn is nil.
+func (r *PostgresRepository) SetBookCallNumber(ctx context.Context, bookID int, cn *model.CallNumber) (*model.Book, common.Error) {
+	update := callNumberColumns(cn)
+	update[repoColumnBook.UpdatedAt] = sq.Expr("CURRENT_TIMESTAMP")
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBook).
+		SetMap(update).
+		Where(sq.Eq{repoColumnBook.ID: bookID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBook.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBook
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	book := row.toModel()
+	return &book, nil
+}
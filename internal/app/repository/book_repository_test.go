@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookRepository_CreateBook(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataWork))
+
+	param := model.NewBook(1, "El ingenioso hidalgo", "Miguel de Cervantes", "9788491050292",
+		time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC))
+	param.Language = "es"
+
+	book, err := repo.CreateBook(context.Background(), param)
+	require.NoError(t, err)
+	assert.Equal(t, 1, book.WorkID)
+	assert.Equal(t, param.ISBN, book.ISBN)
+	assert.Equal(t, 2015, book.PublishedYear.Year())
+	assert.Equal(t, "es", book.Language)
+}
+
+func TestBookRepository_CreateBookWithWork(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataWork), testdata.Path(testdata.TestDataBook))
+
+	param := model.NewBook(0, "Rayuela", "Julio Cortázar", "9788437604572",
+		time.Date(1963, time.January, 1, 0, 0, 0, 0, time.UTC))
+	work, book, err := repo.CreateBookWithWork(context.Background(), model.NewWork(param.Title, param.Author), param)
+	require.NoError(t, err)
+	assert.Equal(t, "Rayuela", work.Title)
+	assert.Equal(t, work.ID, book.WorkID)
+
+	var works int
+	require.NoError(t, db.Get(&works, "SELECT COUNT(*) FROM works"))
+
+	// a catalogued ISBN is refused, and the work created for it rolled back
+	param.ISBN = "9788424116590"
+	_, _, err = repo.CreateBookWithWork(context.Background(), model.NewWork("Another title", param.Author), param)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	var after int
+	require.NoError(t, db.Get(&after, "SELECT COUNT(*) FROM works"))
+	assert.Equal(t, works, after)
+}
+
+func TestBookRepository_ListBooksByWorkIDs(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBook),
+	)
+
+	books, err := repo.ListBooksByWorkIDs(context.Background(), []int{1})
+	require.NoError(t, err)
+	assert.Len(t, books, 2)
+
+	books, err = repo.ListBooksByWorkIDs(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, books)
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoBookStatusChange struct {
+	ID         int       `db:"id"`
+	CopyID     int       `db:"copy_id"`
+	FromStatus *string   `db:"from_status"`
+	ToStatus   string    `db:"to_status"`
+	ChangedBy  string    `db:"changed_by"`
+	Reason     string    `db:"reason"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type repoColumnPatternBookStatusChange struct {
+	ID         string
+	CopyID     string
+	FromStatus string
+	ToStatus   string
+	ChangedBy  string
+	Reason     string
+	CreatedAt  string
+}
+
+const repoTableBookStatusHistory = "book_status_history"
+
+var repoColumnBookStatusChange = repoColumnPatternBookStatusChange{
+	ID:         "id",
+	CopyID:     "copy_id",
+	FromStatus: "from_status",
+	ToStatus:   "to_status",
+	ChangedBy:  "changed_by",
+	Reason:     "reason",
+	CreatedAt:  "created_at",
+}
+
+func (c *repoColumnPatternBookStatusChange) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.CopyID,
+		c.FromStatus,
+		c.ToStatus,
+		c.ChangedBy,
+		c.Reason,
+		c.CreatedAt,
+	}, ", ")
+}
+
+func (row repoBookStatusChange) toModel() (model.BookStatusChange, error) {
+	to, err := bookStatusFromRepo(row.ToStatus)
+	if err != nil {
+		return model.BookStatusChange{}, err
+	}
+
+	change := model.BookStatusChange{
+		ID:        row.ID,
+		CopyID:    row.CopyID,
+		To:        to,
+		ChangedBy: row.ChangedBy,
+		Reason:    row.Reason,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.FromStatus != nil {
+		from, err := bookStatusFromRepo(*row.FromStatus)
+		if err != nil {
+			return model.BookStatusChange{}, err
+		}
+		change.From = &from
+	}
+	return change, nil
+}
+
+func (r *PostgresRepository) createBookStatusChange(ctx context.Context, db sqlContextGetter, param model.BookStatusChange) (*model.BookStatusChange, common.Error) {
+	to, cErr := repoBookStatus(param.To)
+	if cErr != nil {
+		return nil, cErr
+	}
+	insert := map[string]interface{}{
+		repoColumnBookStatusChange.CopyID:    param.CopyID,
+		repoColumnBookStatusChange.ToStatus:  to,
+		repoColumnBookStatusChange.ChangedBy: param.ChangedBy,
+		repoColumnBookStatusChange.Reason:    param.Reason,
+	}
+	if param.From != nil {
+		from, cErr := repoBookStatus(*param.From)
+		if cErr != nil {
+			return nil, cErr
+		}
+		insert[repoColumnBookStatusChange.FromStatus] = from
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableBookStatusHistory).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBookStatusChange.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBookStatusChange
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	change, err := row.toModel()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return &change, nil
+}
+
+// ListBookStatusHistory returns the status changes of a copy, oldest first.
+func (r *PostgresRepository) ListBookStatusHistory(ctx context.Context, copyID int) ([]*model.BookStatusChange, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnBookStatusChange.columns()).
+		From(repoTableBookStatusHistory).
+		Where(sq.Eq{repoColumnBookStatusChange.CopyID: copyID}).
+		OrderBy(repoColumnBookStatusChange.CreatedAt, repoColumnBookStatusChange.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoBookStatusChange
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	changes := make([]*model.BookStatusChange, 0, len(rows))
+	for _, row := range rows {
+		change, err := row.toModel()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
+
+// ListBookStatusLabels returns the labels of the book_status enum in their declaration order.
+func (r *PostgresRepository) ListBookStatusLabels(ctx context.Context) ([]string, common.Error) {
+	var labels []string
+	if err := r.db.SelectContext(ctx, &labels, "SELECT unnest(enum_range(NULL::book_status))::text"); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return labels, nil
+}
+
+// CheckBookStatusEnum verifies that the book_status enum has a label for
+// every model.BookStatus and no other, so a status added on one side only
+// is caught when the application starts rather than on the first write.
+func (r *PostgresRepository) CheckBookStatusEnum(ctx context.Context) common.Error {
+	labels, err := r.ListBookStatusLabels(ctx)
+	if err != nil {
+		return err
+	}
+	if mismatch := diffBookStatusLabels(labels); mismatch != "" {
+		return common.NewError(common.ErrorCodeInternalProcess, fmt.Errorf("book_status enum is out of sync with model.BookStatus: %s", mismatch))
+	}
+	return nil
+}
+
+// diffBookStatusLabels describes how enum labels differ from the model statuses, or returns "" when they match.
+func diffBookStatusLabels(labels []string) string {
+	inDB := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		inDB[l] = true
+	}
+
+	var problems []string
+	inModel := make(map[string]bool)
+	for _, s := range model.BookStatuses() {
+		inModel[s.String()] = true
+		if !inDB[s.String()] {
+			problems = append(problems, fmt.Sprintf("%s is missing from the enum", s))
+		}
+	}
+	for _, l := range labels {
+		if !inModel[l] {
+			problems = append(problems, fmt.Sprintf("%s is not a model status", l))
+		}
+	}
+	return strings.Join(problems, "; ")
+}
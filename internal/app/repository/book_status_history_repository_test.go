@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBookStatusEnumMigrations replays the book_status labels declared by the
+// migrations, so a status added to the model without a migration fails here
+// even without a database.
+func TestBookStatusEnumMigrations(t *testing.T) {
+	files, err := filepath.Glob("../../../scripts/migrations/*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	sort.Strings(files)
+
+	createType := regexp.MustCompile(`(?s)CREATE TYPE book_status AS ENUM \((.*?)\);`)
+	addValue := regexp.MustCompile(`ALTER TYPE book_status ADD VALUE (?:IF NOT EXISTS )?'(\w+)'`)
+	label := regexp.MustCompile(`'(\w+)'`)
+
+	var labels []string
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		if m := createType.FindSubmatch(script); m != nil {
+			labels = nil
+			for _, l := range label.FindAllSubmatch(m[1], -1) {
+				labels = append(labels, string(l[1]))
+			}
+		}
+		for _, m := range addValue.FindAllSubmatch(script, -1) {
+			labels = append(labels, string(m[1]))
+		}
+	}
+
+	assert.Empty(t, diffBookStatusLabels(labels))
+}
+
+func TestDiffBookStatusLabels(t *testing.T) {
+	mismatch := diffBookStatusLabels([]string{"InLibrary", "Borrowed", "Lost", "Missing"})
+	assert.Contains(t, mismatch, "Damaged is missing from the enum")
+	assert.Contains(t, mismatch, "Missing is not a model status")
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/paulmach/orb"
+)
+
+type repoBranch struct {
+	ID        int       `db:"id"`
+	Code      string    `db:"code"`
+	Name      string    `db:"name"`
+	Address   string    `db:"address"`
+	IsMain    bool      `db:"is_main"`
+	Latitude  *float64  `db:"latitude"`
+	Longitude *float64  `db:"longitude"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type repoColumnPatternBranch struct {
+	ID        string
+	Code      string
+	Name      string
+	Address   string
+	IsMain    string
+	Latitude  string
+	Longitude string
+	CreatedAt string
+	UpdatedAt string
+}
+
+const repoTableBranch = "branches"
+
+// repoMainBranchID selects the ID of the main branch.
+const repoMainBranchID = "(SELECT id FROM branches WHERE is_main)"
+
+var repoColumnBranch = repoColumnPatternBranch{
+	ID:        "id",
+	Code:      "code",
+	Name:      "name",
+	Address:   "address",
+	IsMain:    "is_main",
+	Latitude:  "latitude",
+	Longitude: "longitude",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (c *repoColumnPatternBranch) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.Code,
+		c.Name,
+		c.Address,
+		c.IsMain,
+		c.Latitude,
+		c.Longitude,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (row repoBranch) toModel() model.Branch {
+	branch := model.Branch{
+		ID:        row.ID,
+		Code:      row.Code,
+		Name:      row.Name,
+		Address:   row.Address,
+		IsMain:    row.IsMain,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if row.Latitude != nil && row.Longitude != nil {
+		branch.Location = &orb.Point{*row.Longitude, *row.Latitude}
+	}
+	return branch
+}
+
+// repoBranchLocation returns the latitude and longitude columns of a location.
+func repoBranchLocation(location *orb.Point) (lat, lng interface{}) {
+	if location == nil {
+		return nil, nil
+	}
+	return location.Lat(), location.Lon()
+}
+
+func (r *PostgresRepository) CreateBranch(ctx context.Context, param model.Branch) (*model.Branch, common.Error) {
+	lat, lng := repoBranchLocation(param.Location)
+	insert := map[string]interface{}{
+		repoColumnBranch.Code:      param.Code,
+		repoColumnBranch.Name:      param.Name,
+		repoColumnBranch.Address:   param.Address,
+		repoColumnBranch.Latitude:  lat,
+		repoColumnBranch.Longitude: lng,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableBranch).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBranch.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBranch
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("branch code %s is already in use", param.Code)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	branch := row.toModel()
+	return &branch, nil
+}
+
+func (r *PostgresRepository) GetBranchByID(ctx context.Context, id int) (*model.Branch, common.Error) {
+	return r.getBranch(ctx, sq.Eq{repoColumnBranch.ID: id})
+}
+
+func (r *PostgresRepository) GetBranchByCode(ctx context.Context, code string) (*model.Branch, common.Error) {
+	return r.getBranch(ctx, sq.Eq{repoColumnBranch.Code: code})
+}
+
+func (r *PostgresRepository) getBranch(ctx context.Context, where sq.Sqlizer) (*model.Branch, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnBranch.columns()).
+		From(repoTableBranch).
+		Where(where).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBranch
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("branch not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	branch := row.toModel()
+	return &branch, nil
+}
+
+// ListBranches returns every branch, the main branch first.
+func (r *PostgresRepository) ListBranches(ctx context.Context) ([]*model.Branch, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnBranch.columns()).
+		From(repoTableBranch).
+		OrderBy(repoColumnBranch.IsMain+" DESC", repoColumnBranch.Name, repoColumnBranch.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoBranch
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var branches []*model.Branch
+	for _, row := range rows {
+		branch := row.toModel()
+		branches = append(branches, &branch)
+	}
+
+	return branches, nil
+}
+
+// UpdateBranch changes the code, name, address and location of a branch.
+func (r *PostgresRepository) UpdateBranch(ctx context.Context, param model.Branch) (*model.Branch, common.Error) {
+	lat, lng := repoBranchLocation(param.Location)
+	update := map[string]interface{}{
+		repoColumnBranch.Code:      param.Code,
+		repoColumnBranch.Name:      param.Name,
+		repoColumnBranch.Address:   param.Address,
+		repoColumnBranch.Latitude:  lat,
+		repoColumnBranch.Longitude: lng,
+		repoColumnBranch.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBranch).
+		SetMap(update).
+		Where(sq.Eq{repoColumnBranch.ID: param.ID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBranch.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBranch
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("branch not found"))
+		}
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("branch code %s is already in use", param.Code)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	branch := row.toModel()
+	return &branch, nil
+}
+
+// ListAvailabilityByBranch counts the copies of books by the branch they sit
+// at and their status. Retired copies are left out.
+func (r *PostgresRepository) ListAvailabilityByBranch(ctx context.Context, bookIDs []int) ([]*model.BranchAvailability, common.Error) {
+	if len(bookIDs) == 0 {
+		return nil, nil
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(
+		"br."+repoColumnBranch.ID, "br."+repoColumnBranch.Code, "br."+repoColumnBranch.Name,
+		"bc."+repoColumnBookCopies.Status, "COUNT(*) AS copies",
+	).
+		From(repoTableBookCopies+" bc").
+		Join(fmt.Sprintf("%s br ON br.%s = bc.%s", repoTableBranch, repoColumnBranch.ID, repoColumnBookCopies.CurrentBranchID)).
+		Where(sq.And{
+			sq.Eq{"bc." + repoColumnBookCopies.BookID: bookIDs},
+			sq.Eq{"bc." + repoColumnBookCopies.RetiredAt: nil},
+		}).
+		GroupBy("br."+repoColumnBranch.ID, "bc."+repoColumnBookCopies.Status).
+		OrderBy("br."+repoColumnBranch.IsMain+" DESC", "br."+repoColumnBranch.Name, "br."+repoColumnBranch.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []struct {
+		BranchID   int    `db:"id"`
+		BranchCode string `db:"code"`
+		BranchName string `db:"name"`
+		Status     string `db:"status"`
+		Copies     int    `db:"copies"`
+	}
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var availability []*model.BranchAvailability
+	for _, row := range rows {
+		status, err := bookStatusFromRepo(row.Status)
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+		// rows of a branch are adjacent
+		if n := len(availability); n == 0 || availability[n-1].BranchID != row.BranchID {
+			availability = append(availability, &model.BranchAvailability{
+				BranchID:   row.BranchID,
+				BranchCode: row.BranchCode,
+				BranchName: row.BranchName,
+				ByStatus:   map[model.BookStatus]int{},
+			})
+		}
+		availability[len(availability)-1].ByStatus[status] = row.Copies
+	}
+
+	return availability, nil
+}
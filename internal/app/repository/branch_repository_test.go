@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initBranchRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+	)
+}
+
+func TestBranchRepository_CreateBranch(t *testing.T) {
+	repo := initBranchRepository(t)
+
+	param := model.NewBranch("WEST", "West Branch", "")
+	location, lErr := model.NewLocation(51.5074, -0.2240)
+	require.NoError(t, lErr)
+	param.Location = &location
+	branch, err := repo.CreateBranch(context.Background(), param)
+	require.NoError(t, err)
+	assert.False(t, branch.IsMain)
+	require.NotNil(t, branch.Location)
+	assert.Equal(t, 51.5074, branch.Location.Lat())
+	assert.Equal(t, -0.2240, branch.Location.Lon())
+
+	found, err := repo.GetBranchByCode(context.Background(), "WEST")
+	require.NoError(t, err)
+	assert.Equal(t, branch.ID, found.ID)
+
+	// codes are unique
+	_, err = repo.CreateBranch(context.Background(), model.NewBranch("EAST", "Another East", ""))
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+}
+
+func TestBranchRepository_ListBranches(t *testing.T) {
+	repo := initBranchRepository(t)
+
+	branches, err := repo.ListBranches(context.Background())
+	require.NoError(t, err)
+	require.Len(t, branches, 2)
+	// the main branch comes first
+	assert.Equal(t, "MAIN", branches[0].Code)
+	assert.Equal(t, "EAST", branches[1].Code)
+}
+
+func TestBranchRepository_UpdateBranch(t *testing.T) {
+	repo := initBranchRepository(t)
+
+	param := model.NewBranch("EAST", "East Side Branch", "22 East Street")
+	param.ID = 2
+	branch, err := repo.UpdateBranch(context.Background(), param)
+	require.NoError(t, err)
+	assert.Equal(t, "East Side Branch", branch.Name)
+	assert.Equal(t, "22 East Street", branch.Address)
+	// a branch updated without coordinates loses its location
+	assert.Nil(t, branch.Location)
+
+	param.ID = 99
+	_, err = repo.UpdateBranch(context.Background(), param)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
+
+func TestBranchRepository_ListAvailabilityByBranch(t *testing.T) {
+	repo := initBranchRepository(t)
+
+	param := model.NewBookCopies(1, "", model.InLibrary)
+	param.HomeBranchID = 2
+	_, err := repo.CreateBookCopy(context.Background(), param, "librarian")
+	require.NoError(t, err)
+
+	availability, err := repo.ListAvailabilityByBranch(context.Background(), []int{1, 2})
+	require.NoError(t, err)
+	require.Len(t, availability, 2)
+
+	assert.Equal(t, "MAIN", availability[0].BranchCode)
+	assert.Equal(t, 2, availability[0].Total())
+	assert.Equal(t, 1, availability[0].Available())
+	assert.Equal(t, 1, availability[0].ByStatus[model.Borrowed])
+
+	assert.Equal(t, "EAST", availability[1].BranchCode)
+	assert.Equal(t, 1, availability[1].Available())
+}
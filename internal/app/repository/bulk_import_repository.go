@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+// The bulk loaders stream rows into a temporary staging table with COPY and
+// merge the staging table into the real one with a single statement, which
+// is far cheaper than one INSERT per row for large files. Empty optional
+// values are staged as NULL so an upsert keeps what is already stored.
+
+const stageBooks = `CREATE TEMP TABLE import_books (
+	isbn VARCHAR(255) NOT NULL,
+	title VARCHAR(255) NOT NULL,
+	author VARCHAR(255) NOT NULL,
+	published_year INT NOT NULL,
+	edition VARCHAR(255),
+	language VARCHAR(35),
+	classification classification_scheme,
+	call_number VARCHAR(255),
+	call_number_sort VARCHAR(255),
+	work_title VARCHAR(255) NOT NULL
+) ON COMMIT DROP`
+
+// New books join the work with the same title and author, created when missing.
+const mergeBookWorks = `INSERT INTO works (title, author)
+SELECT DISTINCT ON (LOWER(s.work_title), LOWER(s.author)) s.work_title, s.author
+FROM import_books s
+WHERE NOT EXISTS (SELECT 1 FROM books b WHERE b.isbn = s.isbn)
+	AND NOT EXISTS (
+		SELECT 1 FROM works w WHERE LOWER(w.title) = LOWER(s.work_title) AND LOWER(w.author) = LOWER(s.author)
+	)`
+
+const mergeBooks = `INSERT INTO books (work_id, isbn, title, author, published_year, edition, language, classification, call_number, call_number_sort)
+SELECT
+	(SELECT w.id FROM works w WHERE LOWER(w.title) = LOWER(s.work_title) AND LOWER(w.author) = LOWER(s.author) ORDER BY w.id LIMIT 1),
+	s.isbn, s.title, s.author, s.published_year, COALESCE(s.edition, ''), COALESCE(s.language, ''),
+	s.classification, s.call_number, s.call_number_sort
+FROM import_books s
+%s
+RETURNING id, isbn AS key`
+
+const mergeBooksOnConflict = `ON CONFLICT (isbn) DO UPDATE SET
+	title = EXCLUDED.title,
+	author = EXCLUDED.author,
+	published_year = EXCLUDED.published_year,
+	edition = COALESCE(NULLIF(EXCLUDED.edition, ''), books.edition),
+	language = COALESCE(NULLIF(EXCLUDED.language, ''), books.language),
+	classification = COALESCE(EXCLUDED.classification, books.classification),
+	call_number = COALESCE(EXCLUDED.call_number, books.call_number),
+	call_number_sort = COALESCE(EXCLUDED.call_number_sort, books.call_number_sort),
+	updated_at = CURRENT_TIMESTAMP`
+
+const stageBookCopies = `CREATE TEMP TABLE import_book_copies (
+	book_id INT NOT NULL,
+	barcode VARCHAR(64),
+	home_branch_id INT,
+	status book_status NOT NULL,
+	call_number VARCHAR(255),
+	call_number_sort VARCHAR(255)
+) ON COMMIT DROP`
+
+// Copies without a branch belong to the main branch. The initial status of
+// every loaded copy starts its status history.
+const mergeBookCopies = `WITH main AS (
+	SELECT id FROM branches WHERE is_main
+), inserted AS (
+	INSERT INTO book_copies (book_id, barcode, home_branch_id, current_branch_id, status, call_number, call_number_sort)
+	SELECT c.book_id, c.barcode, COALESCE(c.home_branch_id, main.id), COALESCE(c.home_branch_id, main.id),
+		c.status, c.call_number, c.call_number_sort
+	FROM import_book_copies c, main
+	RETURNING id, status
+)
+INSERT INTO book_status_history (copy_id, to_status, changed_by, reason)
+SELECT id, status, %s, 'CSV import' FROM inserted`
+
+const stageUsers = `CREATE TEMP TABLE import_users (
+	uid VARCHAR(36) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL
+) ON COMMIT DROP`
+
+const mergeUsers = `INSERT INTO users (uid, email, name)
+SELECT uid, email, name FROM import_users
+%s
+RETURNING id, email AS key`
+
+const mergeUsersOnConflict = `ON CONFLICT (email) DO UPDATE SET
+	name = EXCLUDED.name,
+	updated_at = CURRENT_TIMESTAMP`
+
+// BulkUpsertBooks loads books in one transaction. With upsert, a book whose
+// ISBN exists is updated, otherwise an existing ISBN fails the whole load.
+// It returns the IDs of the loaded books by ISBN.
+func (r *PostgresRepository) BulkUpsertBooks(ctx context.Context, records []model.CatalogRecord, upsert bool) (map[string]int, common.Error) {
+	rows := make([][]interface{}, 0, len(records))
+	for _, rec := range records {
+		b := rec.Book
+		var scheme, callNumber, sortKey interface{}
+		if cn := b.CallNumber; cn != nil {
+			scheme, callNumber, sortKey = string(cn.Scheme), cn.Raw, cn.SortKey
+		}
+		rows = append(rows, []interface{}{
+			b.ISBN, b.Title, b.Author, b.PublishedYear.Year(), nullIfEmpty(b.Edition), nullIfEmpty(b.Language),
+			scheme, callNumber, sortKey, rec.WorkTitle,
+		})
+	}
+
+	onConflict := ""
+	if upsert {
+		onConflict = mergeBooksOnConflict
+	}
+
+	return r.bulkLoad(ctx, stageBooks, "import_books", []string{
+		"isbn", "title", "author", "published_year", "edition", "language",
+		"classification", "call_number", "call_number_sort", "work_title",
+	}, rows, []string{mergeBookWorks}, fmt.Sprintf(mergeBooks, onConflict))
+}
+
+// BulkCreateBookCopies loads copies of existing books in one transaction.
+func (r *PostgresRepository) BulkCreateBookCopies(ctx context.Context, copies []model.BookCopies) common.Error {
+	rows := make([][]interface{}, 0, len(copies))
+	for _, c := range copies {
+		status, err := repoBookStatus(c.Status)
+		if err != nil {
+			return err
+		}
+		var callNumber, sortKey interface{}
+		if cn := c.CallNumber; cn != nil {
+			callNumber, sortKey = cn.Raw, cn.SortKey
+		}
+		var homeBranch interface{}
+		if c.HomeBranchID != 0 {
+			homeBranch = c.HomeBranchID
+		}
+		rows = append(rows, []interface{}{c.BookID, nullIfEmpty(c.Barcode), homeBranch, status, callNumber, sortKey})
+	}
+
+	_, err := r.bulkLoad(ctx, stageBookCopies, "import_book_copies", []string{
+		"book_id", "barcode", "home_branch_id", "status", "call_number", "call_number_sort",
+	}, rows, []string{fmt.Sprintf(mergeBookCopies, pq.QuoteLiteral(model.SystemActor))}, "")
+	return err
+}
+
+// BulkUpsertUsers loads users in one transaction. With upsert, a user whose
+// email exists is renamed, otherwise an existing email fails the whole load.
+// It returns the IDs of the loaded users by email.
+func (r *PostgresRepository) BulkUpsertUsers(ctx context.Context, users []model.User, upsert bool) (map[string]int, common.Error) {
+	rows := make([][]interface{}, 0, len(users))
+	for _, u := range users {
+		rows = append(rows, []interface{}{u.UID, u.Email, u.Name})
+	}
+
+	onConflict := ""
+	if upsert {
+		onConflict = mergeUsersOnConflict
+	}
+
+	return r.bulkLoad(ctx, stageUsers, "import_users", []string{"uid", "email", "name"},
+		rows, nil, fmt.Sprintf(mergeUsers, onConflict))
+}
+
+// bulkLoad creates the staging table, copies the rows into it and runs the
+// merge statements. The last statement may return (id, key) pairs.
+func (r *PostgresRepository) bulkLoad(ctx context.Context, stage string, table string, columns []string, rows [][]interface{}, merges []string, returning string) (map[string]int, common.Error) {
+	if len(rows) == 0 {
+		return map[string]int{}, nil
+	}
+
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := r.bulkLoadTx(ctx, tx, stage, table, columns, rows, merges, returning)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *PostgresRepository) bulkLoadTx(ctx context.Context, tx *sqlx.Tx, stage string, table string, columns []string, rows [][]interface{}, merges []string, returning string) (map[string]int, common.Error) {
+	if _, err := tx.ExecContext(ctx, stage); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			_ = stmt.Close()
+			return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+		}
+	}
+	// an Exec without arguments flushes the COPY buffer
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		_ = stmt.Close()
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	for _, merge := range merges {
+		if _, err := tx.ExecContext(ctx, merge); err != nil {
+			return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+		}
+	}
+
+	ids := make(map[string]int)
+	if returning == "" {
+		return ids, nil
+	}
+
+	var loaded []struct {
+		ID  int    `db:"id"`
+		Key string `db:"key"`
+	}
+	if err := sqlx.SelectContext(ctx, tx, &loaded, returning); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	for _, l := range loaded {
+		ids[l.Key] = l.ID
+	}
+	return ids, nil
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkImportRepository_BulkUpsertBooks(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+	)
+
+	year := time.Date(2010, time.January, 1, 0, 0, 0, 0, time.UTC)
+	updated := model.NewBook(0, "The Little Prince", "Antoine de Saint-Exupéry", "9780156012195", year)
+	created := model.NewBook(0, "Don Quijote", "Miguel de Cervantes", "9780306406157", year)
+	records := []model.CatalogRecord{
+		{Book: updated, WorkTitle: updated.Title},
+		{Book: created, WorkTitle: "Don Quixote"},
+	}
+
+	// without upsert the existing ISBN fails the whole load
+	_, err := repo.BulkUpsertBooks(context.Background(), records, false)
+	require.Error(t, err)
+
+	ids, err := repo.BulkUpsertBooks(context.Background(), records, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, ids[updated.ISBN])
+
+	book, err := repo.GetBookByID(context.Background(), ids[created.ISBN])
+	require.NoError(t, err)
+	assert.Equal(t, 1, book.WorkID)
+
+	// the stored call number survives an upsert without one
+	book, err = repo.GetBookByID(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 2010, book.PublishedYear.Year())
+	assert.NotNil(t, book.CallNumber)
+}
+
+func TestBulkImportRepository_BulkUpsertUsers(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataUser))
+
+	users := []model.User{
+		model.NewUser("0b0c7a1e-3b53-4b9f-9d39-2ef0b8e7c0aa", "user1@pageturnerpro.com", "renamed"),
+		model.NewUser("5a4c0b7e-8d0f-4a7b-9a3e-0c9e3b2f1d11", "user4@pageturnerpro.com", "user4"),
+	}
+
+	ids, err := repo.BulkUpsertUsers(context.Background(), users, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ids["user1@pageturnerpro.com"])
+
+	user, err := repo.GetUserByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", user.Name)
+	assert.Equal(t, "d8a4a06d-ab77-4188-a2eb-ad01ecc24e9b", user.UID)
+}
+
+func TestBulkImportRepository_BulkCreateBookCopies(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+	)
+
+	err := repo.BulkCreateBookCopies(context.Background(), []model.BookCopies{
+		{BookID: 1, Status: model.InLibrary},
+		{BookID: 1, Status: model.Lost},
+	})
+	require.NoError(t, err)
+
+	copies, err := repo.ListBookCopiesByBookIDs(context.Background(), []int{1})
+	require.NoError(t, err)
+	assert.Len(t, copies, 2)
+}
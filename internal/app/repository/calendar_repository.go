@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoOpeningHours struct {
+	BranchID     int `db:"branch_id"`
+	Weekday      int `db:"weekday"`
+	OpensMinute  int `db:"opens_minute"`
+	ClosesMinute int `db:"closes_minute"`
+}
+
+type repoColumnPatternOpeningHours struct {
+	BranchID     string
+	Weekday      string
+	OpensMinute  string
+	ClosesMinute string
+}
+
+const repoTableOpeningHours = "branch_opening_hours"
+
+var repoColumnOpeningHours = repoColumnPatternOpeningHours{
+	BranchID:     "branch_id",
+	Weekday:      "weekday",
+	OpensMinute:  "opens_minute",
+	ClosesMinute: "closes_minute",
+}
+
+func (c *repoColumnPatternOpeningHours) columns() string {
+	return strings.Join([]string{
+		c.BranchID,
+		c.Weekday,
+		c.OpensMinute,
+		c.ClosesMinute,
+	}, ", ")
+}
+
+func (row repoOpeningHours) toModel() model.OpeningHours {
+	return model.OpeningHours{
+		Weekday: time.Weekday(row.Weekday),
+		Opens:   model.TimeOfDay(row.OpensMinute),
+		Closes:  model.TimeOfDay(row.ClosesMinute),
+	}
+}
+
+type repoClosure struct {
+	ID        int               `db:"id"`
+	BranchID  *int              `db:"branch_id"`
+	Kind      model.ClosureKind `db:"kind"`
+	Name      string            `db:"name"`
+	StartDate time.Time         `db:"start_date"`
+	EndDate   time.Time         `db:"end_date"`
+	CreatedAt time.Time         `db:"created_at"`
+	UpdatedAt time.Time         `db:"updated_at"`
+}
+
+type repoColumnPatternClosure struct {
+	ID        string
+	BranchID  string
+	Kind      string
+	Name      string
+	StartDate string
+	EndDate   string
+	CreatedAt string
+	UpdatedAt string
+}
+
+const repoTableClosure = "calendar_closures"
+
+var repoColumnClosure = repoColumnPatternClosure{
+	ID:        "id",
+	BranchID:  "branch_id",
+	Kind:      "kind",
+	Name:      "name",
+	StartDate: "start_date",
+	EndDate:   "end_date",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (c *repoColumnPatternClosure) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.BranchID,
+		c.Kind,
+		c.Name,
+		c.StartDate,
+		c.EndDate,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+// ListOpeningHours returns the weekly hours of a branch from Sunday on.
+func (r *PostgresRepository) ListOpeningHours(ctx context.Context, branchID int) ([]model.OpeningHours, common.Error) {
+	return r.listOpeningHours(ctx, r.db, branchID)
+}
+
+func (r *PostgresRepository) listOpeningHours(ctx context.Context, db sqlContextGetter, branchID int) ([]model.OpeningHours, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnOpeningHours.columns()).
+		From(repoTableOpeningHours).
+		Where(sq.Eq{repoColumnOpeningHours.BranchID: branchID}).
+		OrderBy(repoColumnOpeningHours.Weekday).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoOpeningHours
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	hours := make([]model.OpeningHours, 0, len(rows))
+	for _, row := range rows {
+		hours = append(hours, row.toModel())
+	}
+	return hours, nil
+}
+
+// SetOpeningHours replaces the weekly hours of a branch.
+func (r *PostgresRepository) SetOpeningHours(ctx context.Context, branchID int, hours []model.OpeningHours) ([]model.OpeningHours, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := r.setOpeningHours(ctx, tx, branchID, hours)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+func (r *PostgresRepository) setOpeningHours(ctx context.Context, db sqlContextGetter, branchID int, hours []model.OpeningHours) ([]model.OpeningHours, common.Error) {
+	if err := model.ValidateOpeningHours(hours); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Delete(repoTableOpeningHours).
+		Where(sq.Eq{repoColumnOpeningHours.BranchID: branchID}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	if len(hours) > 0 {
+		builder := r.pgsq.Insert(repoTableOpeningHours).
+			Columns(repoColumnOpeningHours.BranchID, repoColumnOpeningHours.Weekday, repoColumnOpeningHours.OpensMinute, repoColumnOpeningHours.ClosesMinute)
+		for _, h := range hours {
+			builder = builder.Values(branchID, int(h.Weekday), int(h.Opens), int(h.Closes))
+		}
+
+		// build SQL query
+		query, args, err = builder.ToSql()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+
+		// execute SQL query
+		if _, err = db.ExecContext(ctx, query, args...); err != nil {
+			return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+		}
+	}
+
+	return r.listOpeningHours(ctx, db, branchID)
+}
+
+func (r *PostgresRepository) CreateClosure(ctx context.Context, param model.Closure) (*model.Closure, common.Error) {
+	if err := model.ValidateClosure(param); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	insert := map[string]interface{}{
+		repoColumnClosure.BranchID:  param.BranchID,
+		repoColumnClosure.Kind:      param.Kind,
+		repoColumnClosure.Name:      param.Name,
+		repoColumnClosure.StartDate: param.StartDate,
+		repoColumnClosure.EndDate:   param.EndDate,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableClosure).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnClosure.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoClosure
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	closure := model.Closure(row)
+	return &closure, nil
+}
+
+func (r *PostgresRepository) GetClosureByID(ctx context.Context, id int) (*model.Closure, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnClosure.columns()).
+		From(repoTableClosure).
+		Where(sq.Eq{repoColumnClosure.ID: id}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoClosure
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("closure not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	closure := model.Closure(row)
+	return &closure, nil
+}
+
+// ListClosures returns the closures overlapping a run of days, by start. A
+// closure of every branch applies to each one: with a branch ID, they are
+// listed along with its own closures; with zero, every closure is.
+func (r *PostgresRepository) ListClosures(ctx context.Context, branchID int, from, to time.Time) ([]*model.Closure, common.Error) {
+	where := sq.And{
+		sq.GtOrEq{repoColumnClosure.EndDate: from},
+		sq.LtOrEq{repoColumnClosure.StartDate: to},
+	}
+	if branchID != 0 {
+		where = append(where, sq.Or{
+			sq.Eq{repoColumnClosure.BranchID: branchID},
+			sq.Eq{repoColumnClosure.BranchID: nil},
+		})
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnClosure.columns()).
+		From(repoTableClosure).
+		Where(where).
+		OrderBy(repoColumnClosure.StartDate, repoColumnClosure.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoClosure
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	closures := make([]*model.Closure, 0, len(rows))
+	for _, row := range rows {
+		closure := model.Closure(row)
+		closures = append(closures, &closure)
+	}
+	return closures, nil
+}
+
+func (r *PostgresRepository) DeleteClosure(ctx context.Context, id int) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Delete(repoTableClosure).
+		Where(sq.Eq{repoColumnClosure.ID: id}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("closure not found"))
+	}
+	return nil
+}
+
+// GetBranchCalendar returns the hours of a branch, or of the main branch
+// when branchID is zero, and its closures over a run of days. The time zone
+// of the calendar is left for the caller to set.
+func (r *PostgresRepository) GetBranchCalendar(ctx context.Context, branchID int, from, to time.Time) (*model.BranchCalendar, common.Error) {
+	var branch *model.Branch
+	var cErr common.Error
+	if branchID == 0 {
+		branch, cErr = r.getBranch(ctx, sq.Expr(repoColumnBranch.IsMain))
+	} else {
+		branch, cErr = r.GetBranchByID(ctx, branchID)
+	}
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	hours, cErr := r.ListOpeningHours(ctx, branch.ID)
+	if cErr != nil {
+		return nil, cErr
+	}
+	closures, cErr := r.ListClosures(ctx, branch.ID, from, to)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	cal := model.BranchCalendar{BranchID: branch.ID, Hours: hours}
+	for _, c := range closures {
+		cal.Closures = append(cal.Closures, *c)
+	}
+	return &cal, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initCalendarRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataOpeningHours),
+		testdata.Path(testdata.TestDataClosure),
+	)
+}
+
+func utcDay(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalendarRepository_SetOpeningHours(t *testing.T) {
+	repo := initCalendarRepository(t)
+
+	hours, err := repo.ListOpeningHours(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, hours, 6)
+	assert.Equal(t, time.Monday, hours[0].Weekday)
+	assert.Equal(t, "09:00", hours[0].Opens.String())
+	assert.Equal(t, "20:00", hours[3].Closes.String())
+
+	set, err := repo.SetOpeningHours(context.Background(), 2, []model.OpeningHours{
+		{Weekday: time.Saturday, Opens: 600, Closes: 780},
+		{Weekday: time.Tuesday, Opens: 540, Closes: 1020},
+	})
+	require.NoError(t, err)
+	require.Len(t, set, 2)
+	assert.Equal(t, time.Tuesday, set[0].Weekday)
+
+	// the hours of a branch are replaced as a whole
+	set, err = repo.SetOpeningHours(context.Background(), 2, []model.OpeningHours{
+		{Weekday: time.Wednesday, Opens: 540, Closes: 1020},
+	})
+	require.NoError(t, err)
+	require.Len(t, set, 1)
+	assert.Equal(t, time.Wednesday, set[0].Weekday)
+
+	_, err = repo.SetOpeningHours(context.Background(), 2, []model.OpeningHours{
+		{Weekday: time.Wednesday, Opens: 1020, Closes: 540},
+	})
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	hours, err = repo.ListOpeningHours(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Len(t, hours, 6)
+}
+
+func TestCalendarRepository_Closures(t *testing.T) {
+	repo := initCalendarRepository(t)
+
+	closures, err := repo.ListClosures(context.Background(), 1, utcDay(2023, 1, 1), utcDay(2023, 12, 31))
+	require.NoError(t, err)
+	require.Len(t, closures, 2)
+	assert.Equal(t, 3, closures[0].ID)
+	assert.Nil(t, closures[1].BranchID)
+
+	closures, err = repo.ListClosures(context.Background(), 0, utcDay(2023, 8, 2), utcDay(2023, 8, 31))
+	require.NoError(t, err)
+	assert.Len(t, closures, 2)
+
+	branchID := 2
+	closure := model.NewClosure(&branchID, model.ClosureOneOff, "Flood", utcDay(2024, 2, 5), utcDay(2024, 2, 6))
+	created, err := repo.CreateClosure(context.Background(), closure)
+	require.NoError(t, err)
+	assert.Equal(t, utcDay(2024, 2, 5), created.StartDate.UTC())
+
+	got, err := repo.GetClosureByID(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Flood", got.Name)
+
+	require.NoError(t, repo.DeleteClosure(context.Background(), created.ID))
+	err = repo.DeleteClosure(context.Background(), created.ID)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
+
+func TestCalendarRepository_GetBranchCalendar(t *testing.T) {
+	repo := initCalendarRepository(t)
+
+	cal, err := repo.GetBranchCalendar(context.Background(), 0, utcDay(2023, 8, 1), utcDay(2023, 12, 31))
+	require.NoError(t, err)
+	assert.Equal(t, 1, cal.BranchID)
+	assert.Len(t, cal.Hours, 6)
+	assert.Len(t, cal.Closures, 2)
+
+	cal.Location = time.UTC
+	assert.False(t, cal.IsOpenOn(utcDay(2023, 8, 27)))
+	assert.False(t, cal.IsOpenOn(utcDay(2023, 8, 28)))
+	assert.True(t, cal.IsOpenOn(utcDay(2023, 8, 29)))
+
+	// a branch with no hours recorded is open every day but for its closures
+	cal, err = repo.GetBranchCalendar(context.Background(), 2, utcDay(2023, 8, 1), utcDay(2023, 8, 31))
+	require.NoError(t, err)
+	assert.Empty(t, cal.Hours)
+	require.Len(t, cal.Closures, 1)
+	assert.Equal(t, "Roof repairs", cal.Closures[0].Name)
+
+	_, err = repo.GetBranchCalendar(context.Background(), 99, utcDay(2023, 8, 1), utcDay(2023, 8, 31))
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
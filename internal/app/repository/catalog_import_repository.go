@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+// ImportCatalogRecords writes a batch of catalog records in one transaction.
+// A record whose book has an ID updates that book; any other record creates
+// the book under the work with the same title and author, together with its
+// copies. The saved books are returned in the order of the records.
+func (r *PostgresRepository) ImportCatalogRecords(ctx context.Context, records []model.CatalogRecord) ([]*model.Book, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := r.importCatalogRecords(ctx, tx, records)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+func (r *PostgresRepository) importCatalogRecords(ctx context.Context, db sqlContextGetter, records []model.CatalogRecord) ([]*model.Book, common.Error) {
+	books := make([]*model.Book, 0, len(records))
+	for _, rec := range records {
+		if rec.Book.ID != 0 {
+			book, err := r.updateBook(ctx, db, rec.Book)
+			if err != nil {
+				return nil, err
+			}
+			books = append(books, book)
+			continue
+		}
+
+		work, err := r.findOrCreateWork(ctx, db, model.NewWork(rec.WorkTitle, rec.Book.Author))
+		if err != nil {
+			return nil, err
+		}
+
+		param := rec.Book
+		param.WorkID = work.ID
+		book, err := r.createBook(ctx, db, param)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range rec.Copies {
+			c.BookID = book.ID
+			if _, err := r.createBookCopy(ctx, db, c, model.SystemActor); err != nil {
+				return nil, err
+			}
+		}
+		books = append(books, book)
+	}
+
+	return books, nil
+}
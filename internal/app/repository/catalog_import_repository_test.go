@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogImportRepository_ImportCatalogRecords(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+	)
+
+	existing, err := repo.GetBookByID(context.Background(), 3)
+	require.NoError(t, err)
+	existing.Title = "The Little Prince (revised)"
+
+	created := model.NewBook(0, "Don Quichotte", "Miguel de Cervantes", "9782070409181",
+		time.Date(1988, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	books, err := repo.ImportCatalogRecords(context.Background(), []model.CatalogRecord{
+		{Book: *existing},
+		{Book: created, WorkTitle: "don quixote", Copies: []model.BookCopies{{Status: model.InLibrary}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	assert.Equal(t, "The Little Prince (revised)", books[0].Title)
+	assert.Equal(t, 2, books[0].WorkID)
+
+	// the new edition joins the existing work with the same title and author
+	assert.Equal(t, 1, books[1].WorkID)
+	copies, err := repo.ListBookCopiesByBookIDs(context.Background(), []int{books[1].ID})
+	require.NoError(t, err)
+	assert.Len(t, copies, 1)
+}
+
+func TestCatalogImportRepository_ImportCatalogRecords_RollsBack(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+	)
+
+	// the second record collides with an existing ISBN
+	first := model.NewBook(0, "New book", "Author", "9780306406157", time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+	second := model.NewBook(0, "Clash", "Author", "9780156012195", time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	_, err := repo.ImportCatalogRecords(context.Background(), []model.CatalogRecord{
+		{Book: first, WorkTitle: first.Title},
+		{Book: second, WorkTitle: second.Title},
+	})
+	require.Error(t, err)
+
+	books, err := repo.ListBooksByISBNs(context.Background(), []string{first.ISBN})
+	require.NoError(t, err)
+	assert.Empty(t, books)
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoCharge struct {
+	ID             int              `db:"id"`
+	UserID         int              `db:"user_id"`
+	LoanID         *int             `db:"loan_id"`
+	CopyID         *int             `db:"copy_id"`
+	Kind           model.ChargeKind `db:"kind"`
+	AmountCents    int64            `db:"amount_cents"`
+	Description    string           `db:"description"`
+	CreatedBy      string           `db:"created_by"`
+	ReversedAt     *time.Time       `db:"reversed_at"`
+	ReversedBy     string           `db:"reversed_by"`
+	ReversalReason string           `db:"reversal_reason"`
+	PaidAt         *time.Time       `db:"paid_at"`
+	PaidByUserID   *int             `db:"paid_by_user_id"`
+	CreatedAt      time.Time        `db:"created_at"`
+	UpdatedAt      time.Time        `db:"updated_at"`
+}
+
+type repoColumnPatternCharge struct {
+	ID             string
+	UserID         string
+	LoanID         string
+	CopyID         string
+	Kind           string
+	AmountCents    string
+	Description    string
+	CreatedBy      string
+	ReversedAt     string
+	ReversedBy     string
+	ReversalReason string
+	PaidAt         string
+	PaidByUserID   string
+	CreatedAt      string
+	UpdatedAt      string
+}
+
+const repoTableCharge = "patron_charges"
+
+var repoColumnCharge = repoColumnPatternCharge{
+	ID:             "id",
+	UserID:         "user_id",
+	LoanID:         "loan_id",
+	CopyID:         "copy_id",
+	Kind:           "kind",
+	AmountCents:    "amount_cents",
+	Description:    "description",
+	CreatedBy:      "created_by",
+	ReversedAt:     "reversed_at",
+	ReversedBy:     "reversed_by",
+	ReversalReason: "reversal_reason",
+	PaidAt:         "paid_at",
+	PaidByUserID:   "paid_by_user_id",
+	CreatedAt:      "created_at",
+	UpdatedAt:      "updated_at",
+}
+
+func (c *repoColumnPatternCharge) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.UserID,
+		c.LoanID,
+		c.CopyID,
+		c.Kind,
+		c.AmountCents,
+		c.Description,
+		c.CreatedBy,
+		c.ReversedAt,
+		c.ReversedBy,
+		c.ReversalReason,
+		c.PaidAt,
+		c.PaidByUserID,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (r *PostgresRepository) createCharge(ctx context.Context, db sqlContextGetter, param model.Charge) (*model.Charge, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnCharge.UserID:      param.UserID,
+		repoColumnCharge.LoanID:      param.LoanID,
+		repoColumnCharge.CopyID:      param.CopyID,
+		repoColumnCharge.Kind:        param.Kind,
+		repoColumnCharge.AmountCents: param.AmountCents,
+		repoColumnCharge.Description: param.Description,
+		repoColumnCharge.CreatedBy:   param.CreatedBy,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableCharge).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnCharge.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoCharge
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	charge := model.Charge(row)
+	return &charge, nil
+}
+
+func (r *PostgresRepository) GetChargeByID(ctx context.Context, id int) (*model.Charge, common.Error) {
+	charges, err := r.listCharges(ctx, r.db, sq.Eq{repoColumnCharge.ID: id}, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(charges) == 0 {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("charge not found"))
+	}
+	return charges[0], nil
+}
+
+// ListChargesByUserID returns the charges of a patron, newest first,
+// reversed ones included.
+func (r *PostgresRepository) ListChargesByUserID(ctx context.Context, userID int) ([]*model.Charge, common.Error) {
+	return r.listCharges(ctx, r.db, sq.Eq{repoColumnCharge.UserID: userID}, false)
+}
+
+func (r *PostgresRepository) listCharges(ctx context.Context, db sqlContextGetter, where sq.Sqlizer, forUpdate bool) ([]*model.Charge, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnCharge.columns()).
+		From(repoTableCharge).
+		Where(where).
+		OrderBy(repoColumnCharge.CreatedAt+" DESC", repoColumnCharge.ID+" DESC")
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoCharge
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	charges := make([]*model.Charge, 0, len(rows))
+	for _, row := range rows {
+		charge := model.Charge(row)
+		charges = append(charges, &charge)
+	}
+	return charges, nil
+}
+
+// ReverseCharge cancels a charge the patron no longer owes.
+func (r *PostgresRepository) ReverseCharge(ctx context.Context, id int, reversedBy, reason string) (*model.Charge, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	charge, err := r.reverseCharge(ctx, tx, id, reversedBy, reason)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return charge, nil
+}
+
+func (r *PostgresRepository) reverseCharge(ctx context.Context, db sqlContextGetter, id int, reversedBy, reason string) (*model.Charge, common.Error) {
+	charges, cErr := r.listCharges(ctx, db, sq.Eq{repoColumnCharge.ID: id}, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if len(charges) == 0 {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("charge not found"))
+	}
+	if err := model.ValidateChargeReversal(*charges[0]); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	reversed, cErr := r.updateChargesReversed(ctx, db, sq.Eq{repoColumnCharge.ID: id}, reversedBy, reason)
+	if cErr != nil {
+		return nil, cErr
+	}
+	return reversed[0], nil
+}
+
+// reverseLoanCharges reverses the outstanding charges of the given kinds
+// raised for a loan, and returns them.
+func (r *PostgresRepository) reverseLoanCharges(ctx context.Context, db sqlContextGetter, loanID int, kinds []model.ChargeKind, reversedBy, reason string) ([]*model.Charge, common.Error) {
+	return r.updateChargesReversed(ctx, db, sq.Eq{
+		repoColumnCharge.LoanID:     loanID,
+		repoColumnCharge.Kind:       kinds,
+		repoColumnCharge.ReversedAt: nil,
+		repoColumnCharge.PaidAt:     nil,
+	}, reversedBy, reason)
+}
+
+func (r *PostgresRepository) updateChargesReversed(ctx context.Context, db sqlContextGetter, where sq.Sqlizer, reversedBy, reason string) ([]*model.Charge, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableCharge).
+		SetMap(map[string]interface{}{
+			repoColumnCharge.ReversedAt:     sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnCharge.ReversedBy:     reversedBy,
+			repoColumnCharge.ReversalReason: reason,
+			repoColumnCharge.UpdatedAt:      sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(where).
+		Suffix(fmt.Sprintf("returning %s", repoColumnCharge.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoCharge
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	charges := make([]*model.Charge, 0, len(rows))
+	for _, row := range rows {
+		charge := model.Charge(row)
+		charges = append(charges, &charge)
+	}
+	return charges, nil
+}
+
+// PayCharges records the payment of charges by a patron. Every charge must
+// still be owed.
+func (r *PostgresRepository) PayCharges(ctx context.Context, ids []int, paidByUserID int) ([]*model.Charge, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	charges, err := r.payCharges(ctx, tx, ids, paidByUserID)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return charges, nil
+}
+
+func (r *PostgresRepository) payCharges(ctx context.Context, db sqlContextGetter, ids []int, paidByUserID int) ([]*model.Charge, common.Error) {
+	charges, cErr := r.listCharges(ctx, db, sq.Eq{repoColumnCharge.ID: ids}, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	found := make(map[int]bool, len(charges))
+	for _, charge := range charges {
+		found[charge.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			msg := fmt.Sprintf("charge %d not found", id)
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg(msg))
+		}
+	}
+	for _, charge := range charges {
+		if err := model.ValidateChargePayment(*charge); err != nil {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+		}
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableCharge).
+		SetMap(map[string]interface{}{
+			repoColumnCharge.PaidAt:       sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnCharge.PaidByUserID: paidByUserID,
+			repoColumnCharge.UpdatedAt:    sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnCharge.ID: ids}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnCharge.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoCharge
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	paid := make([]*model.Charge, 0, len(rows))
+	for _, row := range rows {
+		charge := model.Charge(row)
+		paid = append(paid, &charge)
+	}
+	return paid, nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChargeRepository_ReverseCharge(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataCharge),
+	)
+
+	charges, err := repo.ListChargesByUserID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.False(t, charges[0].IsReversed())
+
+	charge, err := repo.ReverseCharge(context.Background(), 1, "supervisor", "waived")
+	require.NoError(t, err)
+	assert.True(t, charge.IsReversed())
+	assert.Equal(t, "waived", charge.ReversalReason)
+
+	_, err = repo.ReverseCharge(context.Background(), 1, "supervisor", "again")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	_, err = repo.GetChargeByID(context.Background(), 99)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
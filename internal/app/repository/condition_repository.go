@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoConditionReport struct {
+	ID         int       `db:"id"`
+	CopyID     int       `db:"copy_id"`
+	Note       string    `db:"note"`
+	Damaged    bool      `db:"damaged"`
+	RecordedBy string    `db:"recorded_by"`
+	ChargeID   *int      `db:"charge_id"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type repoColumnPatternConditionReport struct {
+	ID         string
+	CopyID     string
+	Note       string
+	Damaged    string
+	RecordedBy string
+	ChargeID   string
+	CreatedAt  string
+}
+
+const repoTableConditionReport = "copy_condition_reports"
+
+var repoColumnConditionReport = repoColumnPatternConditionReport{
+	ID:         "id",
+	CopyID:     "copy_id",
+	Note:       "note",
+	Damaged:    "damaged",
+	RecordedBy: "recorded_by",
+	ChargeID:   "charge_id",
+	CreatedAt:  "created_at",
+}
+
+func (c *repoColumnPatternConditionReport) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.CopyID,
+		c.Note,
+		c.Damaged,
+		c.RecordedBy,
+		c.ChargeID,
+		c.CreatedAt,
+	}, ", ")
+}
+
+func (row repoConditionReport) toModel() model.ConditionReport {
+	return model.ConditionReport{
+		ID:         row.ID,
+		CopyID:     row.CopyID,
+		Note:       row.Note,
+		Damaged:    row.Damaged,
+		RecordedBy: row.RecordedBy,
+		ChargeID:   row.ChargeID,
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+type repoConditionPhoto struct {
+	ID          int       `db:"id"`
+	ReportID    int       `db:"report_id"`
+	BlobKey     string    `db:"blob_key"`
+	ContentType string    `db:"content_type"`
+	SizeBytes   int64     `db:"size_bytes"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type repoColumnPatternConditionPhoto struct {
+	ID          string
+	ReportID    string
+	BlobKey     string
+	ContentType string
+	SizeBytes   string
+	CreatedAt   string
+}
+
+const repoTableConditionPhoto = "copy_condition_photos"
+
+var repoColumnConditionPhoto = repoColumnPatternConditionPhoto{
+	ID:          "id",
+	ReportID:    "report_id",
+	BlobKey:     "blob_key",
+	ContentType: "content_type",
+	SizeBytes:   "size_bytes",
+	CreatedAt:   "created_at",
+}
+
+func (c *repoColumnPatternConditionPhoto) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.ReportID,
+		c.BlobKey,
+		c.ContentType,
+		c.SizeBytes,
+		c.CreatedAt,
+	}, ", ")
+}
+
+// CreateConditionReport records the condition of a copy with its photos,
+// already in the blob store. A damaged copy moves to Damaged unless it is
+// already out of circulation for it, and its last borrower is charged when
+// chargeCents is not zero. Damage to a copy on loan is recorded once it is
+// checked in.
+func (r *PostgresRepository) CreateConditionReport(ctx context.Context, param model.ConditionReport, chargeCents int64) (*model.ConditionReport, *model.Charge, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, charge, err := r.createConditionReport(ctx, tx, param, chargeCents)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, nil, err
+	}
+
+	return report, charge, nil
+}
+
+func (r *PostgresRepository) createConditionReport(ctx context.Context, db sqlContextGetter, param model.ConditionReport, chargeCents int64) (*model.ConditionReport, *model.Charge, common.Error) {
+	if err := model.ValidateConditionReport(param, chargeCents); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	bookCopy, cErr := r.getBookCopy(ctx, db, sq.Eq{"bc." + repoColumnBookCopies.ID: param.CopyID}, true)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+	// the return would put a copy on loan back on the shelf, damage and all
+	if param.Damaged && bookCopy.Status == model.Borrowed {
+		err := fmt.Errorf("copy %d is on loan", bookCopy.ID)
+		return nil, nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the copy is on loan, record the damage at check-in"))
+	}
+	if param.Damaged && bookCopy.Status != model.Damaged && bookCopy.Status != model.InRepair {
+		if _, cErr := r.changeBookCopyStatus(ctx, db, bookCopy.ID, model.Damaged, param.RecordedBy, param.Note); cErr != nil {
+			return nil, nil, cErr
+		}
+	}
+
+	var charge *model.Charge
+	if chargeCents > 0 {
+		loan, cErr := r.getLastLoanByCopyID(ctx, db, bookCopy.ID)
+		if cErr != nil {
+			return nil, nil, cErr
+		}
+		if charge, cErr = r.createCharge(ctx, db, model.DamageCharge(*loan, *bookCopy, chargeCents, param.Note, param.RecordedBy)); cErr != nil {
+			return nil, nil, cErr
+		}
+	}
+
+	insert := map[string]interface{}{
+		repoColumnConditionReport.CopyID:     param.CopyID,
+		repoColumnConditionReport.Note:       param.Note,
+		repoColumnConditionReport.Damaged:    param.Damaged,
+		repoColumnConditionReport.RecordedBy: param.RecordedBy,
+	}
+	if charge != nil {
+		insert[repoColumnConditionReport.ChargeID] = charge.ID
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableConditionReport).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnConditionReport.columns())).
+		ToSql()
+	if err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoConditionReport
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	report := row.toModel()
+
+	for _, photo := range param.Photos {
+		photo.ReportID = report.ID
+		created, cErr := r.createConditionPhoto(ctx, db, photo)
+		if cErr != nil {
+			return nil, nil, cErr
+		}
+		report.Photos = append(report.Photos, *created)
+	}
+
+	return &report, charge, nil
+}
+
+func (r *PostgresRepository) createConditionPhoto(ctx context.Context, db sqlContextGetter, param model.ConditionPhoto) (*model.ConditionPhoto, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnConditionPhoto.ReportID:    param.ReportID,
+		repoColumnConditionPhoto.BlobKey:     param.BlobKey,
+		repoColumnConditionPhoto.ContentType: param.ContentType,
+		repoColumnConditionPhoto.SizeBytes:   param.SizeBytes,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableConditionPhoto).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnConditionPhoto.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoConditionPhoto
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	photo := model.ConditionPhoto(row)
+	return &photo, nil
+}
+
+// ListConditionReportsByCopyID returns the condition reports of a copy with
+// their photos, oldest first.
+func (r *PostgresRepository) ListConditionReportsByCopyID(ctx context.Context, copyID int) ([]*model.ConditionReport, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnConditionReport.columns()).
+		From(repoTableConditionReport).
+		Where(sq.Eq{repoColumnConditionReport.CopyID: copyID}).
+		OrderBy(repoColumnConditionReport.CreatedAt, repoColumnConditionReport.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoConditionReport
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	reports := make([]*model.ConditionReport, 0, len(rows))
+	byID := make(map[int]*model.ConditionReport, len(rows))
+	reportIDs := make([]int, 0, len(rows))
+	for _, row := range rows {
+		report := row.toModel()
+		reports = append(reports, &report)
+		byID[report.ID] = &report
+		reportIDs = append(reportIDs, report.ID)
+	}
+
+	photos, cErr := r.listConditionPhotos(ctx, sq.Eq{repoColumnConditionPhoto.ReportID: reportIDs})
+	if cErr != nil {
+		return nil, cErr
+	}
+	for _, photo := range photos {
+		report := byID[photo.ReportID]
+		report.Photos = append(report.Photos, *photo)
+	}
+
+	return reports, nil
+}
+
+func (r *PostgresRepository) GetConditionPhotoByID(ctx context.Context, id int) (*model.ConditionPhoto, common.Error) {
+	photos, err := r.listConditionPhotos(ctx, sq.Eq{repoColumnConditionPhoto.ID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) == 0 {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("photo not found"))
+	}
+	return photos[0], nil
+}
+
+func (r *PostgresRepository) listConditionPhotos(ctx context.Context, where sq.Sqlizer) ([]*model.ConditionPhoto, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnConditionPhoto.columns()).
+		From(repoTableConditionPhoto).
+		Where(where).
+		OrderBy(repoColumnConditionPhoto.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoConditionPhoto
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	photos := make([]*model.ConditionPhoto, 0, len(rows))
+	for _, row := range rows {
+		photo := model.ConditionPhoto(row)
+		photos = append(photos, &photo)
+	}
+	return photos, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initConditionRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataBorrowedBook),
+		testdata.Path(testdata.TestDataCharge),
+		testdata.Path(testdata.TestDataVendor),
+		testdata.Path(testdata.TestDataCondition),
+		testdata.Path(testdata.TestDataPhoto),
+		testdata.Path(testdata.TestDataRepair),
+	)
+}
+
+func TestConditionRepository_ListConditionReportsByCopyID(t *testing.T) {
+	repo := initConditionRepository(t)
+
+	reports, err := repo.ListConditionReportsByCopyID(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0].Photos, 1)
+	assert.Equal(t, "image/jpeg", reports[0].Photos[0].ContentType)
+
+	photo, err := repo.GetConditionPhotoByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, photo.ReportID)
+
+	_, err = repo.GetConditionPhotoByID(context.Background(), 99)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
+
+func TestConditionRepository_CreateConditionReport(t *testing.T) {
+	repo := initConditionRepository(t)
+
+	// damage found on copy 2 is charged to user 3, its last borrower
+	param := model.NewConditionReport(2, "coffee stains", true, "desk")
+	param.Photos = []model.ConditionPhoto{{BlobKey: "condition/2/a.jpg", ContentType: "image/jpeg", SizeBytes: 1024}}
+	report, charge, err := repo.CreateConditionReport(context.Background(), param, 1500)
+	require.NoError(t, err)
+	require.Len(t, report.Photos, 1)
+	require.NotNil(t, charge)
+	assert.Equal(t, 3, charge.UserID)
+	assert.Equal(t, model.ChargeDamage, charge.Kind)
+	assert.Equal(t, charge.ID, *report.ChargeID)
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.Damaged, bookCopy.Status)
+
+	// a note on a damaged copy leaves it Damaged
+	_, charge, err = repo.CreateConditionReport(context.Background(), model.NewConditionReport(2, "pages 12-14 torn too", true, "desk"), 0)
+	require.NoError(t, err)
+	assert.Nil(t, charge)
+
+	reports, err := repo.ListConditionReportsByCopyID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, reports, 3)
+}
+
+func TestConditionRepository_CreateConditionReport_OnLoan(t *testing.T) {
+	repo := initConditionRepository(t)
+
+	// copy 1 is on loan 2, so the damage waits for the check-in
+	_, _, err := repo.CreateConditionReport(context.Background(), model.NewConditionReport(1, "cover torn", true, "desk"), 0)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	_, _, err = repo.ReturnLoan(context.Background(), 2, time.Now(), nil, "desk")
+	require.NoError(t, err)
+
+	_, charge, err := repo.CreateConditionReport(context.Background(), model.NewConditionReport(1, "cover torn", true, "desk"), 900)
+	require.NoError(t, err)
+	require.NotNil(t, charge)
+	assert.Equal(t, 2, charge.UserID)
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, model.Damaged, bookCopy.Status)
+}
+
+func TestConditionRepository_CreateConditionReport_NeverBorrowed(t *testing.T) {
+	repo := initConditionRepository(t)
+
+	bookCopy, err := repo.CreateBookCopy(context.Background(), model.NewBookCopies(1, "", model.InLibrary), "desk")
+	require.NoError(t, err)
+
+	_, _, err = repo.CreateConditionReport(context.Background(), model.NewConditionReport(bookCopy.ID, "scratched", true, "desk"), 800)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+
+	// nothing is recorded
+	reports, err := repo.ListConditionReportsByCopyID(context.Background(), bookCopy.ID)
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
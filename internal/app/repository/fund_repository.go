@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoFund struct {
+	ID              int       `db:"id"`
+	Code            string    `db:"code"`
+	Name            string    `db:"name"`
+	FiscalYear      int       `db:"fiscal_year"`
+	BudgetCents     int64     `db:"budget_cents"`
+	EncumberedCents int64     `db:"encumbered_cents"`
+	SpentCents      int64     `db:"spent_cents"`
+	CreatedAt       time.Time `db:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+type repoColumnPatternFund struct {
+	ID          string
+	Code        string
+	Name        string
+	FiscalYear  string
+	BudgetCents string
+	CreatedAt   string
+	UpdatedAt   string
+}
+
+const repoTableFund = "funds"
+
+var repoColumnFund = repoColumnPatternFund{
+	ID:          "id",
+	Code:        "code",
+	Name:        "name",
+	FiscalYear:  "fiscal_year",
+	BudgetCents: "budget_cents",
+	CreatedAt:   "created_at",
+	UpdatedAt:   "updated_at",
+}
+
+// repoFundBalance sums the order lines charged to each fund: the copies
+// expected on open orders are encumbered, the copies received are spent,
+// whatever became of their order since.
+const repoFundBalance = `
+	(SELECT COALESCE(SUM((l.quantity - l.received_quantity) * l.unit_price_cents), 0)::BIGINT
+		FROM purchase_order_lines l JOIN purchase_orders o ON o.id = l.order_id
+		WHERE l.fund_id = funds.id AND o.status IN ('Ordered', 'PartiallyReceived')) AS encumbered_cents,
+	(SELECT COALESCE(SUM(l.received_quantity * l.unit_price_cents), 0)::BIGINT
+		FROM purchase_order_lines l
+		WHERE l.fund_id = funds.id) AS spent_cents`
+
+func (c *repoColumnPatternFund) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.Code,
+		c.Name,
+		c.FiscalYear,
+		c.BudgetCents,
+		c.CreatedAt,
+		c.UpdatedAt,
+		repoFundBalance,
+	}, ", ")
+}
+
+func (r *PostgresRepository) CreateFund(ctx context.Context, param model.Fund) (*model.Fund, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnFund.Code:        param.Code,
+		repoColumnFund.Name:        param.Name,
+		repoColumnFund.FiscalYear:  param.FiscalYear,
+		repoColumnFund.BudgetCents: param.BudgetCents,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableFund).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnFund.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoFund
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("fund code %s is already in use in %d", param.Code, param.FiscalYear)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	fund := model.Fund(row)
+	return &fund, nil
+}
+
+func (r *PostgresRepository) GetFundByID(ctx context.Context, id int) (*model.Fund, common.Error) {
+	funds, err := r.listFunds(ctx, r.db, sq.Eq{repoColumnFund.ID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(funds) == 0 {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("fund not found"))
+	}
+	return funds[0], nil
+}
+
+// ListFunds returns the funds of a fiscal year by code, or of every year
+// when fiscalYear is zero.
+func (r *PostgresRepository) ListFunds(ctx context.Context, fiscalYear int) ([]*model.Fund, common.Error) {
+	where := sq.And{}
+	if fiscalYear != 0 {
+		where = append(where, sq.Eq{repoColumnFund.FiscalYear: fiscalYear})
+	}
+	return r.listFunds(ctx, r.db, where)
+}
+
+func (r *PostgresRepository) listFunds(ctx context.Context, db sqlContextGetter, where sq.Sqlizer) ([]*model.Fund, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnFund.columns()).
+		From(repoTableFund).
+		Where(where).
+		OrderBy(repoColumnFund.FiscalYear+" DESC", repoColumnFund.Code, repoColumnFund.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoFund
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var funds []*model.Fund
+	for _, row := range rows {
+		fund := model.Fund(row)
+		funds = append(funds, &fund)
+	}
+
+	return funds, nil
+}
+
+// lockFunds takes the funds an order is charged to, so that their balance
+// holds until the transaction ends. Funds are locked in ID order to avoid
+// deadlocks between orders sharing funds.
+func (r *PostgresRepository) lockFunds(ctx context.Context, db sqlContextGetter, ids []int) ([]*model.Fund, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnFund.ID).
+		From(repoTableFund).
+		Where(sq.Eq{repoColumnFund.ID: ids}).
+		OrderBy(repoColumnFund.ID).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var locked []int
+	if err = db.SelectContext(ctx, &locked, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	return r.listFunds(ctx, db, sq.Eq{repoColumnFund.ID: ids})
+}
+
+// UpdateFund renames a fund and changes its budget, which may not drop
+// below what the fund has already committed.
+func (r *PostgresRepository) UpdateFund(ctx context.Context, param model.Fund) (*model.Fund, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	fund, err := r.updateFund(ctx, tx, param)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return fund, nil
+}
+
+func (r *PostgresRepository) updateFund(ctx context.Context, db sqlContextGetter, param model.Fund) (*model.Fund, common.Error) {
+	funds, cErr := r.lockFunds(ctx, db, []int{param.ID})
+	if cErr != nil {
+		return nil, cErr
+	}
+	if len(funds) == 0 {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("fund not found"))
+	}
+	if err := model.ValidateFundBudget(*funds[0], param.BudgetCents); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	update := map[string]interface{}{
+		repoColumnFund.Code:        param.Code,
+		repoColumnFund.Name:        param.Name,
+		repoColumnFund.FiscalYear:  param.FiscalYear,
+		repoColumnFund.BudgetCents: param.BudgetCents,
+		repoColumnFund.UpdatedAt:   sq.Expr("CURRENT_TIMESTAMP"),
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableFund).
+		SetMap(update).
+		Where(sq.Eq{repoColumnFund.ID: param.ID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnFund.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoFund
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("fund not found"))
+		}
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("fund code %s is already in use in %d", param.Code, param.FiscalYear)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	fund := model.Fund(row)
+	return &fund, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFundRepository_ListFunds(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataFund))
+
+	funds, err := repo.ListFunds(context.Background(), 2023)
+	require.NoError(t, err)
+	assert.Len(t, funds, 2)
+
+	funds, err = repo.ListFunds(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Len(t, funds, 3)
+}
+
+func TestFundRepository_CreateAndUpdateFund(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataFund))
+
+	created, err := repo.CreateFund(context.Background(), model.NewFund("CHILD", "Children's books", 2024, 8000))
+	require.NoError(t, err)
+	assert.Equal(t, int64(8000), created.AvailableCents())
+
+	// codes are unique within a fiscal year
+	_, err = repo.CreateFund(context.Background(), model.NewFund("CHILD", "Children's books", 2024, 8000))
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	created.BudgetCents = 9000
+	updated, err := repo.UpdateFund(context.Background(), *created)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9000), updated.BudgetCents)
+
+	created.ID = 99
+	_, err = repo.UpdateFund(context.Background(), *created)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
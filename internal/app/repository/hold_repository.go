@@ -0,0 +1,443 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoHold struct {
+	ID             int              `db:"id"`
+	UserID         int              `db:"user_id"`
+	WorkID         int              `db:"work_id"`
+	PickupBranchID int              `db:"pickup_branch_id"`
+	CopyID         *int             `db:"copy_id"`
+	Status         model.HoldStatus `db:"status"`
+	ReadyAt        *time.Time       `db:"ready_at"`
+	CreatedAt      time.Time        `db:"created_at"`
+	UpdatedAt      time.Time        `db:"updated_at"`
+}
+
+type repoColumnPatternHold struct {
+	ID             string
+	UserID         string
+	WorkID         string
+	PickupBranchID string
+	CopyID         string
+	Status         string
+	ReadyAt        string
+	CreatedAt      string
+	UpdatedAt      string
+}
+
+const repoTableHold = "holds"
+
+var repoColumnHold = repoColumnPatternHold{
+	ID:             "id",
+	UserID:         "user_id",
+	WorkID:         "work_id",
+	PickupBranchID: "pickup_branch_id",
+	CopyID:         "copy_id",
+	Status:         "status",
+	ReadyAt:        "ready_at",
+	CreatedAt:      "created_at",
+	UpdatedAt:      "updated_at",
+}
+
+func (c *repoColumnPatternHold) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.UserID,
+		c.WorkID,
+		c.PickupBranchID,
+		c.CopyID,
+		c.Status,
+		c.ReadyAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+var activeHoldStatuses = []model.HoldStatus{model.HoldPending, model.HoldInTransit, model.HoldReady}
+
+func (r *PostgresRepository) CreateHold(ctx context.Context, param model.Hold) (*model.Hold, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnHold.UserID:         param.UserID,
+		repoColumnHold.WorkID:         param.WorkID,
+		repoColumnHold.PickupBranchID: param.PickupBranchID,
+		repoColumnHold.Status:         model.HoldPending,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableHold).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnHold.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHold
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the patron already has an active hold on this work"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	hold := model.Hold(row)
+	return &hold, nil
+}
+
+func (r *PostgresRepository) GetHoldByID(ctx context.Context, id int) (*model.Hold, common.Error) {
+	where := sq.And{
+		sq.Eq{repoColumnHold.ID: id},
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnHold.columns()).
+		From(repoTableHold).
+		Where(where).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHold
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	hold := model.Hold(row)
+	return &hold, nil
+}
+
+// ListActiveHoldsByWorkID returns the hold queue of a work, oldest first.
+func (r *PostgresRepository) ListActiveHoldsByWorkID(ctx context.Context, workID int) ([]*model.Hold, common.Error) {
+	where := sq.And{
+		sq.Eq{repoColumnHold.WorkID: workID},
+		sq.Eq{repoColumnHold.Status: activeHoldStatuses},
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnHold.columns()).
+		From(repoTableHold).
+		Where(where).
+		OrderBy(repoColumnHold.CreatedAt, repoColumnHold.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoHold
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var holds []*model.Hold
+	for _, row := range rows {
+		hold := model.Hold(row)
+		holds = append(holds, &hold)
+	}
+
+	return holds, nil
+}
+
+// ListActiveHoldsByUserID returns the active holds of a patron, oldest first.
+func (r *PostgresRepository) ListActiveHoldsByUserID(ctx context.Context, userID int) ([]*model.Hold, common.Error) {
+	where := sq.And{
+		sq.Eq{repoColumnHold.UserID: userID},
+		sq.Eq{repoColumnHold.Status: activeHoldStatuses},
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnHold.columns()).
+		From(repoTableHold).
+		Where(where).
+		OrderBy(repoColumnHold.CreatedAt, repoColumnHold.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoHold
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	holds := make([]*model.Hold, 0, len(rows))
+	for _, row := range rows {
+		hold := model.Hold(row)
+		holds = append(holds, &hold)
+	}
+
+	return holds, nil
+}
+
+// ListHoldsByUserID returns every hold of a patron, active or not, the latest first.
+func (r *PostgresRepository) ListHoldsByUserID(ctx context.Context, userID int) ([]*model.Hold, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnHold.columns()).
+		From(repoTableHold).
+		Where(sq.Eq{repoColumnHold.UserID: userID}).
+		OrderBy(repoColumnHold.CreatedAt+" DESC", repoColumnHold.ID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoHold
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	holds := make([]*model.Hold, 0, len(rows))
+	for _, row := range rows {
+		hold := model.Hold(row)
+		holds = append(holds, &hold)
+	}
+
+	return holds, nil
+}
+
+// CancelHold cancels an active hold and puts any copy it was holding back on the shelf.
+func (r *PostgresRepository) CancelHold(ctx context.Context, id int) (*model.Hold, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := r.cancelHold(ctx, tx, id)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (r *PostgresRepository) cancelHold(ctx context.Context, db sqlContextGetter, id int) (*model.Hold, common.Error) {
+	where := sq.And{
+		sq.Eq{repoColumnHold.ID: id},
+		sq.Eq{repoColumnHold.Status: activeHoldStatuses},
+	}
+
+	// lock the hold to learn which copy it was holding
+	query, args, err := r.pgsq.Select(repoColumnHold.CopyID, repoColumnHold.Status).
+		From(repoTableHold).
+		Where(where).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	var held struct {
+		CopyID *int             `db:"copy_id"`
+		Status model.HoldStatus `db:"status"`
+	}
+	if err = db.GetContext(ctx, &held, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("active hold not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	// build SQL query
+	query, args, err = r.pgsq.Update(repoTableHold).
+		SetMap(map[string]interface{}{
+			repoColumnHold.Status:    model.HoldCancelled,
+			repoColumnHold.CopyID:    nil,
+			repoColumnHold.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(where).
+		Suffix(fmt.Sprintf("returning %s", repoColumnHold.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHold
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	reason := fmt.Sprintf("hold %d cancelled", id)
+	switch held.Status {
+	case model.HoldReady:
+		if _, err := r.changeBookCopyStatus(ctx, db, *held.CopyID, model.InLibrary, model.SystemActor, reason); err != nil {
+			return nil, err
+		}
+	case model.HoldInTransit:
+		// a copy already on the road finishes its trip and is shelved at the pickup branch
+		if err := r.cancelRequestedHoldTransfer(ctx, db, id); err != nil {
+			return nil, err
+		}
+	}
+
+	hold := model.Hold(row)
+	return &hold, nil
+}
+
+// AssignCopyToNextHold traps one on-shelf copy of any edition of the work for
+// the oldest pending hold. It returns nil when there is no pending hold or no
+// copy available.
+func (r *PostgresRepository) AssignCopyToNextHold(ctx context.Context, workID int) (*model.Hold, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := r.assignCopyToNextHold(ctx, tx, workID)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (r *PostgresRepository) assignCopyToNextHold(ctx context.Context, db sqlContextGetter, workID int) (*model.Hold, common.Error) {
+	// lock the work, so that concurrent traps serve its queue one at a time
+	// and each sees the holds the previous one filled
+	query, args, err := r.pgsq.Select(repoColumnWork.ID).
+		From(repoTableWork).
+		Where(sq.Eq{repoColumnWork.ID: workID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	var lockedID int
+	if err = db.GetContext(ctx, &lockedID, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	// lock the head of the queue; skipping a locked head would serve the
+	// holds out of order
+	query, args, err = r.pgsq.Select(repoColumnHold.ID, repoColumnHold.PickupBranchID).
+		From(repoTableHold).
+		Where(sq.And{
+			sq.Eq{repoColumnHold.WorkID: workID},
+			sq.Eq{repoColumnHold.Status: model.HoldPending},
+		}).
+		OrderBy(repoColumnHold.CreatedAt, repoColumnHold.ID).
+		Limit(1).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	var head struct {
+		ID             int `db:"id"`
+		PickupBranchID int `db:"pickup_branch_id"`
+	}
+	if err = db.GetContext(ctx, &head, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	// lock an on-shelf copy of any edition which is neither held for another
+	// hold nor about to travel, preferring copies at the pickup branch
+	query, args, err = r.pgsq.Select("bc."+repoColumnBookCopies.ID, "bc."+repoColumnBookCopies.CurrentBranchID).
+		From(repoTableBookCopies+" bc").
+		Join(fmt.Sprintf("%s b ON b.%s = bc.book_id", repoTableBook, repoColumnBook.ID)).
+		Where(sq.And{
+			sq.Eq{"b." + repoColumnBook.WorkID: workID},
+			sq.Eq{"bc." + repoColumnBookCopies.Status: model.InLibrary.String()},
+			sq.Eq{"bc." + repoColumnBookCopies.RetiredAt: nil},
+			sq.Expr(fmt.Sprintf(
+				"NOT EXISTS (SELECT 1 FROM %s h WHERE h.%s = bc.id AND h.%s IN (?, ?))",
+				repoTableHold, repoColumnHold.CopyID, repoColumnHold.Status,
+			), model.HoldInTransit, model.HoldReady),
+			sq.Expr(fmt.Sprintf(
+				"NOT EXISTS (SELECT 1 FROM %s t WHERE t.%s = bc.id AND t.%s IN (?, ?))",
+				repoTableTransfer, repoColumnTransfer.CopyID, repoColumnTransfer.Status,
+			), model.TransferRequested, model.TransferInTransit),
+		}).
+		OrderByClause(fmt.Sprintf("bc.%s = ? DESC", repoColumnBookCopies.CurrentBranchID), head.PickupBranchID).
+		OrderBy("bc." + repoColumnBookCopies.ID).
+		Limit(1).
+		Suffix("FOR UPDATE OF bc SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	var trapped struct {
+		ID              int `db:"id"`
+		CurrentBranchID int `db:"current_branch_id"`
+	}
+	if err = db.GetContext(ctx, &trapped, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	// a copy at another branch is sent to the pickup branch first
+	atPickup := trapped.CurrentBranchID == head.PickupBranchID
+	update := map[string]interface{}{
+		repoColumnHold.Status:    model.HoldInTransit,
+		repoColumnHold.CopyID:    trapped.ID,
+		repoColumnHold.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+	}
+	if atPickup {
+		update[repoColumnHold.Status] = model.HoldReady
+		update[repoColumnHold.ReadyAt] = sq.Expr("CURRENT_TIMESTAMP")
+	}
+
+	// build SQL query
+	query, args, err = r.pgsq.Update(repoTableHold).
+		SetMap(update).
+		Where(sq.Eq{repoColumnHold.ID: head.ID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnHold.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHold
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	reason := fmt.Sprintf("trapped for hold %d", head.ID)
+	if atPickup {
+		if _, err := r.changeBookCopyStatus(ctx, db, trapped.ID, model.OnHoldShelf, model.SystemActor, reason); err != nil {
+			return nil, err
+		}
+	} else {
+		transfer := model.NewTransfer(trapped.ID, trapped.CurrentBranchID, head.PickupBranchID, model.SystemActor, reason)
+		transfer.HoldID = &head.ID
+		if _, err := r.createTransfer(ctx, db, transfer); err != nil {
+			return nil, err
+		}
+	}
+
+	hold := model.Hold(row)
+	return &hold, nil
+}
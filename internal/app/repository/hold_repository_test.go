@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initHoldRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataHold),
+	)
+}
+
+func TestHoldRepository_CreateHold(t *testing.T) {
+	repo := initHoldRepository(t)
+
+	hold, err := repo.CreateHold(context.Background(), model.NewHold(3, 1, 1))
+	require.NoError(t, err)
+	assert.Equal(t, model.HoldPending, hold.Status)
+	assert.Nil(t, hold.CopyID)
+
+	// the same user cannot queue twice for the same work
+	_, err = repo.CreateHold(context.Background(), model.NewHold(3, 1, 1))
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+}
+
+func TestHoldRepository_AssignCopyToNextHold(t *testing.T) {
+	repo := initHoldRepository(t)
+
+	// copy 2 is an English edition, but it fills the oldest hold on the work
+	hold, err := repo.AssignCopyToNextHold(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+	assert.Equal(t, 1, hold.ID)
+	assert.Equal(t, model.HoldReady, hold.Status)
+	require.NotNil(t, hold.CopyID)
+	assert.Equal(t, 2, *hold.CopyID)
+
+	// the copy waits on the hold shelf
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.OnHoldShelf, bookCopy.Status)
+
+	// no copy left for the second patron
+	hold, err = repo.AssignCopyToNextHold(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Nil(t, hold)
+
+	// the only copy of work 2 is on loan
+	hold, err = repo.AssignCopyToNextHold(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Nil(t, hold)
+}
+
+func TestHoldRepository_CancelHold(t *testing.T) {
+	repo := initHoldRepository(t)
+
+	hold, err := repo.CancelHold(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, model.HoldCancelled, hold.Status)
+
+	_, err = repo.CancelHold(context.Background(), 1)
+	require.Error(t, err)
+
+	holds, err := repo.ListActiveHoldsByWorkID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, holds, 1)
+	assert.Equal(t, 2, holds[0].ID)
+}
+
+func TestHoldRepository_CancelReadyHold(t *testing.T) {
+	repo := initHoldRepository(t)
+
+	hold, err := repo.AssignCopyToNextHold(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+
+	_, err = repo.CancelHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+
+	// the trapped copy goes back on the shelf
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), *hold.CopyID)
+	require.NoError(t, err)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+
+	history, err := repo.ListBookStatusHistory(context.Background(), *hold.CopyID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, model.OnHoldShelf, history[0].To)
+	assert.Equal(t, model.InLibrary, history[1].To)
+	assert.Equal(t, model.SystemActor, history[1].ChangedBy)
+}
+
+func TestHoldRepository_AssignCopyAtAnotherBranch(t *testing.T) {
+	repo := initHoldRepository(t)
+
+	// the only copy on the shelf of work 2 sits at the east branch
+	param := model.NewBookCopies(3, "30000000000038", model.InLibrary)
+	param.HomeBranchID = 2
+	bookCopy, err := repo.CreateBookCopy(context.Background(), param, "librarian")
+	require.NoError(t, err)
+
+	hold, err := repo.AssignCopyToNextHold(context.Background(), 2)
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+	assert.Equal(t, 3, hold.ID)
+	assert.Equal(t, model.HoldInTransit, hold.Status)
+	require.NotNil(t, hold.CopyID)
+	assert.Equal(t, bookCopy.ID, *hold.CopyID)
+
+	// the copy is sent to the pickup branch
+	transfers, err := repo.ListTransfers(context.Background(), 2, model.TransferRequested)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	transfer := transfers[0]
+	assert.Equal(t, bookCopy.ID, transfer.CopyID)
+	assert.Equal(t, 2, transfer.FromBranchID)
+	assert.Equal(t, 1, transfer.ToBranchID)
+	require.NotNil(t, transfer.HoldID)
+	assert.Equal(t, hold.ID, *transfer.HoldID)
+
+	_, err = repo.DispatchTransfer(context.Background(), transfer.ID, "east desk")
+	require.NoError(t, err)
+	_, err = repo.ReceiveTransfer(context.Background(), transfer.ID, "main desk")
+	require.NoError(t, err)
+
+	// on arrival the copy waits on the hold shelf of the pickup branch
+	bookCopy, err = repo.GetBookCopyByID(context.Background(), bookCopy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.OnHoldShelf, bookCopy.Status)
+	assert.Equal(t, 1, bookCopy.CurrentBranchID)
+	assert.Equal(t, 2, bookCopy.HomeBranchID)
+
+	hold, err = repo.GetHoldByID(context.Background(), hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.HoldReady, hold.Status)
+	assert.NotNil(t, hold.ReadyAt)
+}
+
+func TestHoldRepository_CancelHoldInTransit(t *testing.T) {
+	repo := initHoldRepository(t)
+
+	param := model.NewBookCopies(3, "30000000000038", model.InLibrary)
+	param.HomeBranchID = 2
+	bookCopy, err := repo.CreateBookCopy(context.Background(), param, "librarian")
+	require.NoError(t, err)
+
+	hold, err := repo.AssignCopyToNextHold(context.Background(), 2)
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+
+	_, err = repo.CancelHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+
+	// the transfer had not left, so it is dropped and the copy stays on its shelf
+	transfers, err := repo.ListTransfers(context.Background(), 2, "")
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	assert.Equal(t, model.TransferCancelled, transfers[0].Status)
+
+	bookCopy, err = repo.GetBookCopyByID(context.Background(), bookCopy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+	assert.Equal(t, 2, bookCopy.CurrentBranchID)
+}
@@ -0,0 +1,549 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoHousehold struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type repoColumnPatternHousehold struct {
+	ID        string
+	Name      string
+	CreatedAt string
+	UpdatedAt string
+}
+
+const repoTableHousehold = "households"
+
+var repoColumnHousehold = repoColumnPatternHousehold{
+	ID:        "id",
+	Name:      "name",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (c *repoColumnPatternHousehold) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.Name,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+type repoHouseholdMember struct {
+	HouseholdID        int                 `db:"household_id"`
+	UserID             int                 `db:"user_id"`
+	Role               model.HouseholdRole `db:"role"`
+	CanManageLoans     bool                `db:"can_manage_loans"`
+	CanManageHolds     bool                `db:"can_manage_holds"`
+	CanPayCharges      bool                `db:"can_pay_charges"`
+	CanSetRestrictions bool                `db:"can_set_restrictions"`
+	CreatedAt          time.Time           `db:"created_at"`
+	UpdatedAt          time.Time           `db:"updated_at"`
+}
+
+type repoColumnPatternHouseholdMember struct {
+	HouseholdID        string
+	UserID             string
+	Role               string
+	CanManageLoans     string
+	CanManageHolds     string
+	CanPayCharges      string
+	CanSetRestrictions string
+	CreatedAt          string
+	UpdatedAt          string
+}
+
+const repoTableHouseholdMember = "household_members"
+
+var repoColumnHouseholdMember = repoColumnPatternHouseholdMember{
+	HouseholdID:        "household_id",
+	UserID:             "user_id",
+	Role:               "role",
+	CanManageLoans:     "can_manage_loans",
+	CanManageHolds:     "can_manage_holds",
+	CanPayCharges:      "can_pay_charges",
+	CanSetRestrictions: "can_set_restrictions",
+	CreatedAt:          "created_at",
+	UpdatedAt:          "updated_at",
+}
+
+func (c *repoColumnPatternHouseholdMember) columns() string {
+	return strings.Join([]string{
+		c.HouseholdID,
+		c.UserID,
+		c.Role,
+		c.CanManageLoans,
+		c.CanManageHolds,
+		c.CanPayCharges,
+		c.CanSetRestrictions,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (row repoHouseholdMember) toModel() model.HouseholdMember {
+	return model.HouseholdMember{
+		HouseholdID: row.HouseholdID,
+		UserID:      row.UserID,
+		Role:        row.Role,
+		Permissions: model.GuardianPermissions{
+			ManageLoans:     row.CanManageLoans,
+			ManageHolds:     row.CanManageHolds,
+			PayCharges:      row.CanPayCharges,
+			SetRestrictions: row.CanSetRestrictions,
+		},
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+// repoHouseholdMemberValues returns the role and permission columns of a member.
+func repoHouseholdMemberValues(m model.HouseholdMember) map[string]interface{} {
+	return map[string]interface{}{
+		repoColumnHouseholdMember.Role:               m.Role,
+		repoColumnHouseholdMember.CanManageLoans:     m.Permissions.ManageLoans,
+		repoColumnHouseholdMember.CanManageHolds:     m.Permissions.ManageHolds,
+		repoColumnHouseholdMember.CanPayCharges:      m.Permissions.PayCharges,
+		repoColumnHouseholdMember.CanSetRestrictions: m.Permissions.SetRestrictions,
+	}
+}
+
+type repoContentRestriction struct {
+	UserID      int       `db:"user_id"`
+	SubjectID   int       `db:"subject_id"`
+	SetByUserID int       `db:"set_by_user_id"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type repoColumnPatternContentRestriction struct {
+	UserID      string
+	SubjectID   string
+	SetByUserID string
+	CreatedAt   string
+}
+
+const repoTableContentRestriction = "content_restrictions"
+
+var repoColumnContentRestriction = repoColumnPatternContentRestriction{
+	UserID:      "user_id",
+	SubjectID:   "subject_id",
+	SetByUserID: "set_by_user_id",
+	CreatedAt:   "created_at",
+}
+
+func (c *repoColumnPatternContentRestriction) columns() string {
+	return strings.Join([]string{
+		c.UserID,
+		c.SubjectID,
+		c.SetByUserID,
+		c.CreatedAt,
+	}, ", ")
+}
+
+// subjectAncestorsCTE selects the IDs of the subjects of some books and of
+// all the broader subjects above them.
+const subjectAncestorsCTE = `WITH RECURSIVE ancestors AS (
+	SELECT subject_id AS id FROM book_subjects WHERE book_id = ANY(?)
+	UNION
+	SELECT s.parent_id FROM subjects s JOIN ancestors a ON s.id = a.id WHERE s.parent_id IS NOT NULL
+)`
+
+func (r *PostgresRepository) CreateHousehold(ctx context.Context, param model.Household) (*model.Household, common.Error) {
+	if err := model.ValidateHousehold(param); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableHousehold).
+		SetMap(map[string]interface{}{
+			repoColumnHousehold.Name: param.Name,
+		}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnHousehold.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHousehold
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	household := model.Household(row)
+	return &household, nil
+}
+
+func (r *PostgresRepository) GetHouseholdByID(ctx context.Context, id int) (*model.Household, common.Error) {
+	return r.getHousehold(ctx, r.db, id, false)
+}
+
+func (r *PostgresRepository) getHousehold(ctx context.Context, db sqlContextGetter, id int, forUpdate bool) (*model.Household, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnHousehold.columns()).
+		From(repoTableHousehold).
+		Where(sq.Eq{repoColumnHousehold.ID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHousehold
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("household not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	household := model.Household(row)
+	return &household, nil
+}
+
+// ListHouseholdMembers returns the members of a household, guardians first.
+func (r *PostgresRepository) ListHouseholdMembers(ctx context.Context, householdID int) ([]*model.HouseholdMember, common.Error) {
+	return r.listHouseholdMembers(ctx, r.db, sq.Eq{repoColumnHouseholdMember.HouseholdID: householdID})
+}
+
+// ListHouseholdMembersByUserID returns the members of the household of a
+// user, the user included, or none when they belong to no household.
+func (r *PostgresRepository) ListHouseholdMembersByUserID(ctx context.Context, userID int) ([]*model.HouseholdMember, common.Error) {
+	return r.listHouseholdMembers(ctx, r.db, sq.Expr(
+		fmt.Sprintf("%s = (SELECT %s FROM %s WHERE %s = ?)",
+			repoColumnHouseholdMember.HouseholdID, repoColumnHouseholdMember.HouseholdID,
+			repoTableHouseholdMember, repoColumnHouseholdMember.UserID),
+		userID,
+	))
+}
+
+func (r *PostgresRepository) listHouseholdMembers(ctx context.Context, db sqlContextGetter, where sq.Sqlizer) ([]*model.HouseholdMember, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnHouseholdMember.columns()).
+		From(repoTableHouseholdMember).
+		Where(where).
+		OrderBy(repoColumnHouseholdMember.Role, repoColumnHouseholdMember.UserID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoHouseholdMember
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	members := make([]*model.HouseholdMember, 0, len(rows))
+	for _, row := range rows {
+		member := row.toModel()
+		members = append(members, &member)
+	}
+	return members, nil
+}
+
+// AddHouseholdMember adds a user to a household. A user belongs to one
+// household at most.
+func (r *PostgresRepository) AddHouseholdMember(ctx context.Context, param model.HouseholdMember) (*model.HouseholdMember, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := r.changeHouseholdMember(ctx, tx, param, func() (*model.HouseholdMember, common.Error) {
+		return r.insertHouseholdMember(ctx, tx, param)
+	})
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// UpdateHouseholdMember changes the role and permissions of a member.
+func (r *PostgresRepository) UpdateHouseholdMember(ctx context.Context, param model.HouseholdMember) (*model.HouseholdMember, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := r.changeHouseholdMember(ctx, tx, param, func() (*model.HouseholdMember, common.Error) {
+		return r.updateHouseholdMember(ctx, tx, param)
+	})
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// RemoveHouseholdMember takes a user out of a household. The last guardian
+// of dependents may not leave.
+func (r *PostgresRepository) RemoveHouseholdMember(ctx context.Context, householdID, userID int) common.Error {
+	tx, err := r.beginTx()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.changeHouseholdMember(ctx, tx, model.HouseholdMember{HouseholdID: householdID, UserID: userID}, func() (*model.HouseholdMember, common.Error) {
+		return nil, r.deleteHouseholdMember(ctx, tx, householdID, userID)
+	})
+	return r.finishTx(err, tx)
+}
+
+// changeHouseholdMember locks a household, changes a member, and checks the
+// household still has a guardian for its dependents.
+func (r *PostgresRepository) changeHouseholdMember(ctx context.Context, db sqlContextGetter, param model.HouseholdMember, change func() (*model.HouseholdMember, common.Error)) (*model.HouseholdMember, common.Error) {
+	if _, cErr := r.getHousehold(ctx, db, param.HouseholdID, true); cErr != nil {
+		return nil, cErr
+	}
+
+	member, cErr := change()
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	members, cErr := r.listHouseholdMembers(ctx, db, sq.Eq{repoColumnHouseholdMember.HouseholdID: param.HouseholdID})
+	if cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidateHouseholdChange(members); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return member, nil
+}
+
+func (r *PostgresRepository) insertHouseholdMember(ctx context.Context, db sqlContextGetter, param model.HouseholdMember) (*model.HouseholdMember, common.Error) {
+	if err := model.ValidateHouseholdMember(param); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	insert := repoHouseholdMemberValues(param)
+	insert[repoColumnHouseholdMember.HouseholdID] = param.HouseholdID
+	insert[repoColumnHouseholdMember.UserID] = param.UserID
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableHouseholdMember).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnHouseholdMember.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHouseholdMember
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("user %d already belongs to a household", param.UserID)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		if isForeignKeyViolation(err) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	member := row.toModel()
+	return &member, nil
+}
+
+func (r *PostgresRepository) updateHouseholdMember(ctx context.Context, db sqlContextGetter, param model.HouseholdMember) (*model.HouseholdMember, common.Error) {
+	if err := model.ValidateHouseholdMember(param); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	update := repoHouseholdMemberValues(param)
+	update[repoColumnHouseholdMember.UpdatedAt] = sq.Expr("CURRENT_TIMESTAMP")
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableHouseholdMember).
+		SetMap(update).
+		Where(sq.Eq{
+			repoColumnHouseholdMember.HouseholdID: param.HouseholdID,
+			repoColumnHouseholdMember.UserID:      param.UserID,
+		}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnHouseholdMember.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHouseholdMember
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("household member not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	member := row.toModel()
+	return &member, nil
+}
+
+func (r *PostgresRepository) deleteHouseholdMember(ctx context.Context, db sqlContextGetter, householdID, userID int) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Delete(repoTableHouseholdMember).
+		Where(sq.Eq{
+			repoColumnHouseholdMember.HouseholdID: householdID,
+			repoColumnHouseholdMember.UserID:      userID,
+		}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	} else if n == 0 {
+		return common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("household member not found"))
+	}
+	return nil
+}
+
+// SetContentRestrictions replaces the subjects a patron is restricted from.
+func (r *PostgresRepository) SetContentRestrictions(ctx context.Context, userID int, subjectIDs []int, setByUserID int) ([]*model.ContentRestriction, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	restrictions, err := r.setContentRestrictions(ctx, tx, userID, subjectIDs, setByUserID)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return restrictions, nil
+}
+
+func (r *PostgresRepository) setContentRestrictions(ctx context.Context, db sqlContextGetter, userID int, subjectIDs []int, setByUserID int) ([]*model.ContentRestriction, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Delete(repoTableContentRestriction).
+		Where(sq.Eq{repoColumnContentRestriction.UserID: userID}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	if len(subjectIDs) > 0 {
+		insert := r.pgsq.Insert(repoTableContentRestriction).
+			Columns(repoColumnContentRestriction.UserID, repoColumnContentRestriction.SubjectID, repoColumnContentRestriction.SetByUserID)
+		for _, id := range subjectIDs {
+			insert = insert.Values(userID, id, setByUserID)
+		}
+
+		// build SQL query
+		query, args, err = insert.Suffix("ON CONFLICT DO NOTHING").ToSql()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+
+		// execute SQL query
+		if _, err = db.ExecContext(ctx, query, args...); err != nil {
+			if isForeignKeyViolation(err) {
+				return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("subject not found"))
+			}
+			return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+		}
+	}
+
+	return r.listContentRestrictions(ctx, db, userID)
+}
+
+// ListContentRestrictions returns the subjects a patron is restricted from.
+func (r *PostgresRepository) ListContentRestrictions(ctx context.Context, userID int) ([]*model.ContentRestriction, common.Error) {
+	return r.listContentRestrictions(ctx, r.db, userID)
+}
+
+func (r *PostgresRepository) listContentRestrictions(ctx context.Context, db sqlContextGetter, userID int) ([]*model.ContentRestriction, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnContentRestriction.columns()).
+		From(repoTableContentRestriction).
+		Where(sq.Eq{repoColumnContentRestriction.UserID: userID}).
+		OrderBy(repoColumnContentRestriction.SubjectID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoContentRestriction
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	restrictions := make([]*model.ContentRestriction, 0, len(rows))
+	for _, row := range rows {
+		restriction := model.ContentRestriction(row)
+		restrictions = append(restrictions, &restriction)
+	}
+	return restrictions, nil
+}
+
+// ListRestrictingSubjects returns the subjects a patron is restricted from
+// that any of the books falls under, through its own subjects or the
+// broader ones above them.
+func (r *PostgresRepository) ListRestrictingSubjects(ctx context.Context, userID int, bookIDs []int) ([]*model.Subject, common.Error) {
+	if len(bookIDs) == 0 {
+		return nil, nil
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(aliasColumns("s", repoColumnSubject.columns())).
+		Prefix(subjectAncestorsCTE, pq.Array(bookIDs)).
+		From(repoTableSubject + " s").
+		Join(fmt.Sprintf("%s cr ON cr.%s = s.%s", repoTableContentRestriction, repoColumnContentRestriction.SubjectID, repoColumnSubject.ID)).
+		Where(sq.And{
+			sq.Eq{"cr." + repoColumnContentRestriction.UserID: userID},
+			sq.Expr("s." + repoColumnSubject.ID + " IN (SELECT id FROM ancestors)"),
+		}).
+		OrderBy("s." + repoColumnSubject.Name).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoSubject
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	subjects := make([]*model.Subject, 0, len(rows))
+	for _, row := range rows {
+		subject := model.Subject(row)
+		subjects = append(subjects, &subject)
+	}
+	return subjects, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initHouseholdRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataSubject),
+		testdata.Path(testdata.TestDataBookSubject),
+		testdata.Path(testdata.TestDataCharge),
+		testdata.Path(testdata.TestDataHousehold),
+		testdata.Path(testdata.TestDataMember),
+		testdata.Path(testdata.TestDataRestriction),
+	)
+}
+
+func TestHouseholdRepository_Members(t *testing.T) {
+	repo := initHouseholdRepository(t)
+
+	members, err := repo.ListHouseholdMembersByUserID(context.Background(), 3)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.NoError(t, model.AuthorizeGuardian(members, 1, 3, model.GuardianPayCharges))
+
+	// the only guardian of a household with dependents stays
+	err = repo.RemoveHouseholdMember(context.Background(), 1, 1)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	// a user belongs to one household at most
+	other, err := repo.CreateHousehold(context.Background(), model.NewHousehold("user2 family"))
+	require.NoError(t, err)
+	_, err = repo.AddHouseholdMember(context.Background(), model.NewHouseholdMember(other.ID, 3, model.HouseholdDependent, model.GuardianPermissions{}))
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	member, err := repo.AddHouseholdMember(context.Background(), model.NewHouseholdMember(1, 2, model.HouseholdGuardian, model.GuardianPermissions{ManageLoans: true}))
+	require.NoError(t, err)
+	assert.True(t, member.Permissions.ManageLoans)
+	assert.False(t, member.Permissions.PayCharges)
+
+	member, err = repo.UpdateHouseholdMember(context.Background(), model.NewHouseholdMember(1, 2, model.HouseholdGuardian, model.FullGuardianPermissions))
+	require.NoError(t, err)
+	assert.Equal(t, model.FullGuardianPermissions, member.Permissions)
+
+	require.NoError(t, repo.RemoveHouseholdMember(context.Background(), 1, 1))
+	members, err = repo.ListHouseholdMembers(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+}
+
+func TestHouseholdRepository_ContentRestrictions(t *testing.T) {
+	repo := initHouseholdRepository(t)
+
+	// Classics covers the Spanish classics of book 1
+	subjects, err := repo.ListRestrictingSubjects(context.Background(), 3, []int{1, 3})
+	require.NoError(t, err)
+	require.Len(t, subjects, 1)
+	assert.Equal(t, 2, subjects[0].ID)
+
+	subjects, err = repo.ListRestrictingSubjects(context.Background(), 1, []int{1})
+	require.NoError(t, err)
+	assert.Empty(t, subjects)
+
+	restrictions, err := repo.SetContentRestrictions(context.Background(), 3, []int{4}, 1)
+	require.NoError(t, err)
+	require.Len(t, restrictions, 1)
+	assert.Equal(t, 4, restrictions[0].SubjectID)
+
+	subjects, err = repo.ListRestrictingSubjects(context.Background(), 3, []int{1, 3})
+	require.NoError(t, err)
+	require.Len(t, subjects, 1)
+	assert.Equal(t, 4, subjects[0].ID)
+}
+
+func TestHouseholdRepository_PayCharges(t *testing.T) {
+	repo := initHouseholdRepository(t)
+
+	charges, err := repo.PayCharges(context.Background(), []int{1}, 1)
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.True(t, charges[0].IsPaid())
+	require.NotNil(t, charges[0].PaidByUserID)
+	assert.Equal(t, 1, *charges[0].PaidByUserID)
+
+	_, err = repo.PayCharges(context.Background(), []int{1}, 1)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	_, err = repo.ReverseCharge(context.Background(), 1, "desk", "waived")
+	require.Error(t, err)
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoImportReport struct {
+	ID        int       `db:"id"`
+	Source    string    `db:"source"`
+	DryRun    bool      `db:"dry_run"`
+	Created   int       `db:"created"`
+	Updated   int       `db:"updated"`
+	Failed    int       `db:"failed"`
+	Errors    []byte    `db:"errors"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type repoColumnPatternImportReport struct {
+	ID        string
+	Source    string
+	DryRun    string
+	Created   string
+	Updated   string
+	Failed    string
+	Errors    string
+	CreatedAt string
+}
+
+const repoTableImportReport = "import_reports"
+
+var repoColumnImportReport = repoColumnPatternImportReport{
+	ID:        "id",
+	Source:    "source",
+	DryRun:    "dry_run",
+	Created:   "created",
+	Updated:   "updated",
+	Failed:    "failed",
+	Errors:    "errors",
+	CreatedAt: "created_at",
+}
+
+func (c *repoColumnPatternImportReport) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.Source,
+		c.DryRun,
+		c.Created,
+		c.Updated,
+		c.Failed,
+		c.Errors,
+		c.CreatedAt,
+	}, ", ")
+}
+
+type repoImportRow struct {
+	Index   int                `json:"index"`
+	Key     string             `json:"key,omitempty"`
+	Action  model.ImportAction `json:"action"`
+	ID      int                `json:"id,omitempty"`
+	Message string             `json:"message,omitempty"`
+}
+
+func (row repoImportReport) toModel() (model.ImportReport, error) {
+	var rows []repoImportRow
+	if err := json.Unmarshal(row.Errors, &rows); err != nil {
+		return model.ImportReport{}, err
+	}
+
+	report := model.ImportReport{
+		ID:        row.ID,
+		Source:    row.Source,
+		DryRun:    row.DryRun,
+		Created:   row.Created,
+		Updated:   row.Updated,
+		Failed:    row.Failed,
+		CreatedAt: row.CreatedAt,
+	}
+	for _, r := range rows {
+		report.Rows = append(report.Rows, model.ImportRow(r))
+	}
+	return report, nil
+}
+
+// CreateImportReport stores the totals and the failed rows of an import.
+func (r *PostgresRepository) CreateImportReport(ctx context.Context, param model.ImportReport) (*model.ImportReport, common.Error) {
+	rows := make([]repoImportRow, 0, param.Failed)
+	for _, row := range param.Errors() {
+		rows = append(rows, repoImportRow(row))
+	}
+	errorsJSON, err := json.Marshal(rows)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	insert := map[string]interface{}{
+		repoColumnImportReport.Source:  param.Source,
+		repoColumnImportReport.DryRun:  param.DryRun,
+		repoColumnImportReport.Created: param.Created,
+		repoColumnImportReport.Updated: param.Updated,
+		repoColumnImportReport.Failed:  param.Failed,
+		repoColumnImportReport.Errors:  string(errorsJSON),
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableImportReport).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnImportReport.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoImportReport
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	report, err := row.toModel()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return &report, nil
+}
+
+func (r *PostgresRepository) GetImportReportByID(ctx context.Context, id int) (*model.ImportReport, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnImportReport.columns()).
+		From(repoTableImportReport).
+		Where(sq.Eq{repoColumnImportReport.ID: id}).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoImportReport
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	report, err := row.toModel()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return &report, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoCopyLabel struct {
+	CopyID     int                        `db:"copy_id"`
+	Barcode    string                     `db:"barcode"`
+	Title      string                     `db:"title"`
+	Scheme     model.ClassificationScheme `db:"scheme"`
+	CallNumber string                     `db:"call_number"`
+}
+
+// ListCopyLabels returns what is printed on the labels of copies, with the
+// effective call number of each: its own, or else the one of its book.
+func (r *PostgresRepository) ListCopyLabels(ctx context.Context, copyIDs []int) ([]*model.CopyLabel, common.Error) {
+	if len(copyIDs) == 0 {
+		return nil, nil
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(
+		"bc.id AS copy_id",
+		"COALESCE(bc.barcode, '') AS barcode",
+		"b.title",
+		"COALESCE(b.classification::text, '') AS scheme",
+		"COALESCE(bc.call_number, b.call_number, '') AS call_number",
+	).
+		From(repoTableBookCopies + " bc").
+		Join(repoTableBook + " b ON b.id = bc.book_id").
+		Where(sq.Eq{"bc." + repoColumnBookCopies.ID: copyIDs}).
+		OrderBy("bc." + repoColumnBookCopies.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoCopyLabel
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	labels := make([]*model.CopyLabel, 0, len(rows))
+	for _, row := range rows {
+		label := model.CopyLabel(row)
+		labels = append(labels, &label)
+	}
+	return labels, nil
+}
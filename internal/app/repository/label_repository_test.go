@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelRepository_ListCopyLabels(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+	)
+
+	labels, err := repo.ListCopyLabels(context.Background(), []int{3, 1, 404})
+	require.NoError(t, err)
+	require.Len(t, labels, 2)
+
+	assert.Equal(t, 1, labels[0].CopyID)
+	assert.Equal(t, "30000000000012", labels[0].Barcode)
+	assert.Equal(t, model.ClassificationDewey, labels[0].Scheme)
+	assert.Equal(t, "863.3 CER", labels[0].CallNumber)
+
+	// copy 3 was catalogued before barcodes
+	assert.Equal(t, 3, labels[1].CopyID)
+	assert.Empty(t, labels[1].Barcode)
+}
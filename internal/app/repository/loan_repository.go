@@ -0,0 +1,634 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoBorrowedBook struct {
+	ID         int        `db:"id"`
+	UserID     *int       `db:"user_id"`
+	CopyID     int        `db:"copy_id"`
+	BorrowDate time.Time  `db:"borrow_date"`
+	DueDate    time.Time  `db:"due_date"`
+	ReturnDate *time.Time `db:"return_date"`
+	LostAt     *time.Time `db:"lost_at"`
+	BranchID   *int       `db:"branch_id"`
+	Renewals   int        `db:"renewals"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
+}
+
+type repoColumnPatternBorrowedBook struct {
+	ID         string
+	UserID     string
+	CopyID     string
+	BorrowDate string
+	DueDate    string
+	ReturnDate string
+	LostAt     string
+	BranchID   string
+	Renewals   string
+	CreatedAt  string
+	UpdatedAt  string
+}
+
+const repoTableBorrowedBook = "borrowed_books"
+
+var repoColumnBorrowedBook = repoColumnPatternBorrowedBook{
+	ID:         "id",
+	UserID:     "user_id",
+	CopyID:     "copy_id",
+	BorrowDate: "borrow_date",
+	DueDate:    "due_date",
+	ReturnDate: "return_date",
+	LostAt:     "lost_at",
+	BranchID:   "branch_id",
+	Renewals:   "renewals",
+	CreatedAt:  "created_at",
+	UpdatedAt:  "updated_at",
+}
+
+func (c *repoColumnPatternBorrowedBook) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.UserID,
+		c.CopyID,
+		c.BorrowDate,
+		c.DueDate,
+		c.ReturnDate,
+		c.LostAt,
+		c.BranchID,
+		c.Renewals,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (row repoBorrowedBook) toModel() model.BorrowedBook {
+	loan := model.BorrowedBook{
+		ID:         row.ID,
+		CopyID:     row.CopyID,
+		BorrowDate: row.BorrowDate,
+		DueDate:    row.DueDate,
+		ReturnDate: row.ReturnDate,
+		LostAt:     row.LostAt,
+		BranchID:   row.BranchID,
+		Renewals:   row.Renewals,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+	if row.UserID != nil {
+		loan.UserID = *row.UserID
+	}
+	return loan
+}
+
+// getOpenLoanByCopyID returns the loan of a copy not returned yet.
+func (r *PostgresRepository) getOpenLoanByCopyID(ctx context.Context, db sqlContextGetter, copyID int, forUpdate bool) (*model.BorrowedBook, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnBorrowedBook.columns()).
+		From(repoTableBorrowedBook).
+		Where(sq.Eq{
+			repoColumnBorrowedBook.CopyID:     copyID,
+			repoColumnBorrowedBook.ReturnDate: nil,
+		}).
+		OrderBy(repoColumnBorrowedBook.BorrowDate + " DESC").
+		Limit(1)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBorrowedBook
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("copy is not on loan"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	loan := row.toModel()
+	return &loan, nil
+}
+
+// getLastLoanByCopyID returns the latest loan of a copy, returned or not.
+func (r *PostgresRepository) getLastLoanByCopyID(ctx context.Context, db sqlContextGetter, copyID int) (*model.BorrowedBook, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnBorrowedBook.columns()).
+		From(repoTableBorrowedBook).
+		Where(sq.Eq{repoColumnBorrowedBook.CopyID: copyID}).
+		OrderBy(repoColumnBorrowedBook.BorrowDate+" DESC", repoColumnBorrowedBook.ID+" DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBorrowedBook
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("copy was never borrowed"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	loan := row.toModel()
+	return &loan, nil
+}
+
+func (r *PostgresRepository) GetOpenLoanByCopyID(ctx context.Context, copyID int) (*model.BorrowedBook, common.Error) {
+	return r.getOpenLoanByCopyID(ctx, r.db, copyID, false)
+}
+
+// ListOverdueLoans returns the open loans due before a time whose copy is
+// not declared lost yet, the longest overdue first.
+func (r *PostgresRepository) ListOverdueLoans(ctx context.Context, dueBefore time.Time) ([]*model.BorrowedBook, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnBorrowedBook.columns()).
+		From(repoTableBorrowedBook).
+		Where(sq.And{
+			sq.Eq{repoColumnBorrowedBook.ReturnDate: nil},
+			sq.Eq{repoColumnBorrowedBook.LostAt: nil},
+			sq.Lt{repoColumnBorrowedBook.DueDate: dueBefore},
+		}).
+		OrderBy(repoColumnBorrowedBook.DueDate, repoColumnBorrowedBook.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoBorrowedBook
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	loans := make([]*model.BorrowedBook, 0, len(rows))
+	for _, row := range rows {
+		loan := row.toModel()
+		loans = append(loans, &loan)
+	}
+	return loans, nil
+}
+
+// DeclareLoanLost declares the copy out on a loan lost. The copy moves to
+// Lost, the loan is flagged and the borrower is charged as the policy says.
+func (r *PostgresRepository) DeclareLoanLost(ctx context.Context, copyID int, policy model.LostItemPolicy, changedBy, reason string) (*model.BorrowedBook, []*model.Charge, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loan, charges, err := r.declareLoanLost(ctx, tx, copyID, policy, changedBy, reason)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, nil, err
+	}
+
+	return loan, charges, nil
+}
+
+func (r *PostgresRepository) declareLoanLost(ctx context.Context, db sqlContextGetter, copyID int, policy model.LostItemPolicy, changedBy, reason string) (*model.BorrowedBook, []*model.Charge, common.Error) {
+	loan, cErr := r.getOpenLoanByCopyID(ctx, db, copyID, true)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+	if err := model.ValidateDeclareLost(*loan); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	bookCopy, cErr := r.changeBookCopyStatus(ctx, db, copyID, model.Lost, changedBy, reason)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+
+	// build SQL query
+	now := time.Now()
+	query, args, err := r.pgsq.Update(repoTableBorrowedBook).
+		SetMap(map[string]interface{}{
+			repoColumnBorrowedBook.LostAt:    now,
+			repoColumnBorrowedBook.UpdatedAt: now,
+		}).
+		Where(sq.Eq{repoColumnBorrowedBook.ID: loan.ID}).
+		ToSql()
+	if err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	loan.LostAt = &now
+	loan.UpdatedAt = now
+
+	var charges []*model.Charge
+	for _, c := range policy.LostCharges(*loan, *bookCopy, changedBy) {
+		charge, cErr := r.createCharge(ctx, db, c)
+		if cErr != nil {
+			return nil, nil, cErr
+		}
+		charges = append(charges, charge)
+	}
+	return loan, charges, nil
+}
+
+// ReturnLostCopy puts a lost copy back in the collection. When it was lost
+// on loan, the loan is closed and the charges for the loss are reversed.
+func (r *PostgresRepository) ReturnLostCopy(ctx context.Context, copyID int, changedBy string) (*model.BookCopies, []*model.Charge, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bookCopy, charges, err := r.returnLostCopy(ctx, tx, copyID, changedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, nil, err
+	}
+
+	return bookCopy, charges, nil
+}
+
+func (r *PostgresRepository) returnLostCopy(ctx context.Context, db sqlContextGetter, copyID int, changedBy string) (*model.BookCopies, []*model.Charge, common.Error) {
+	bookCopy, cErr := r.getBookCopy(ctx, db, sq.Eq{"bc." + repoColumnBookCopies.ID: copyID}, true)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+	if bookCopy.Status != model.Lost {
+		err := errors.New("copy is not lost")
+		return nil, nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if bookCopy, cErr = r.changeBookCopyStatus(ctx, db, copyID, model.InLibrary, changedBy, "lost copy found"); cErr != nil {
+		return nil, nil, cErr
+	}
+
+	// a copy missing from the shelf was lost without a loan
+	loan, cErr := r.getOpenLoanByCopyID(ctx, db, copyID, true)
+	if cErr != nil {
+		if de, ok := cErr.(common.DomainError); ok && de.Name() == common.ErrorCodeResourceNotFound.Name {
+			return bookCopy, nil, nil
+		}
+		return nil, nil, cErr
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBorrowedBook).
+		SetMap(map[string]interface{}{
+			repoColumnBorrowedBook.ReturnDate: sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnBorrowedBook.UpdatedAt:  sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnBorrowedBook.ID: loan.ID}).
+		ToSql()
+	if err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	reversed, cErr := r.reverseLoanCharges(ctx, db, loan.ID,
+		[]model.ChargeKind{model.ChargeReplacement, model.ChargeProcessingFee}, changedBy, "lost copy returned")
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+	return bookCopy, reversed, nil
+}
+
+func (r *PostgresRepository) GetLoanByID(ctx context.Context, id int) (*model.BorrowedBook, common.Error) {
+	return r.getLoan(ctx, r.db, id, false)
+}
+
+func (r *PostgresRepository) getLoan(ctx context.Context, db sqlContextGetter, id int, forUpdate bool) (*model.BorrowedBook, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnBorrowedBook.columns()).
+		From(repoTableBorrowedBook).
+		Where(sq.Eq{repoColumnBorrowedBook.ID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBorrowedBook
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("loan not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	loan := row.toModel()
+	return &loan, nil
+}
+
+// ListLoansByUserID returns the loans of a user, the latest first. With
+// openOnly, returned loans are left out.
+func (r *PostgresRepository) ListLoansByUserID(ctx context.Context, userID int, openOnly bool) ([]*model.BorrowedBook, common.Error) {
+	where := sq.And{sq.Eq{repoColumnBorrowedBook.UserID: userID}}
+	if openOnly {
+		where = append(where, sq.Eq{repoColumnBorrowedBook.ReturnDate: nil})
+	}
+
+	return r.listLoans(ctx, r.db, where)
+}
+
+func (r *PostgresRepository) listLoans(ctx context.Context, db sqlContextGetter, where sq.Sqlizer) ([]*model.BorrowedBook, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnBorrowedBook.columns()).
+		From(repoTableBorrowedBook).
+		Where(where).
+		OrderBy(repoColumnBorrowedBook.BorrowDate+" DESC", repoColumnBorrowedBook.ID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoBorrowedBook
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	loans := make([]*model.BorrowedBook, 0, len(rows))
+	for _, row := range rows {
+		loan := row.toModel()
+		loans = append(loans, &loan)
+	}
+	return loans, nil
+}
+
+// CreateLoan lends a copy to a patron in good standing. A copy on the hold
+// shelf goes only to the patron it was trapped for, whose hold is fulfilled,
+// and a copy waiting to leave for another branch stays put.
+func (r *PostgresRepository) CreateLoan(ctx context.Context, param model.BorrowedBook, standing model.StandingPolicy, changedBy string) (*model.BorrowedBook, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	loan, err := r.createLoan(ctx, tx, param, standing, changedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+func (r *PostgresRepository) createLoan(ctx context.Context, db sqlContextGetter, param model.BorrowedBook, standing model.StandingPolicy, changedBy string) (*model.BorrowedBook, common.Error) {
+	// the patron stays locked until the loan is made, so that requests
+	// racing this one see it
+	if cErr := r.checkAccountStanding(ctx, db, param.UserID, standing, param.BorrowDate); cErr != nil {
+		return nil, cErr
+	}
+	bookCopy, cErr := r.getBookCopy(ctx, db, sq.Eq{"bc." + repoColumnBookCopies.ID: param.CopyID}, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	readyHold, cErr := r.getReadyHoldByCopyID(ctx, db, param.CopyID)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidateCheckout(*bookCopy, readyHold, param.UserID); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if readyHold == nil {
+		pending, cErr := r.hasRequestedTransfer(ctx, db, param.CopyID)
+		if cErr != nil {
+			return nil, cErr
+		}
+		if pending {
+			err := errors.New("copy is waiting to be sent to another branch")
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+		}
+	}
+
+	if _, cErr = r.changeBookCopyStatus(ctx, db, param.CopyID, model.Borrowed, changedBy, "checked out"); cErr != nil {
+		return nil, cErr
+	}
+	if readyHold != nil {
+		// build SQL query
+		query, args, err := r.pgsq.Update(repoTableHold).
+			SetMap(map[string]interface{}{
+				repoColumnHold.Status:    model.HoldFulfilled,
+				repoColumnHold.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+			}).
+			Where(sq.Eq{repoColumnHold.ID: readyHold.ID}).
+			ToSql()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+
+		// execute SQL query
+		if _, err = db.ExecContext(ctx, query, args...); err != nil {
+			return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+		}
+	}
+
+	insert := map[string]interface{}{
+		repoColumnBorrowedBook.UserID:     param.UserID,
+		repoColumnBorrowedBook.CopyID:     param.CopyID,
+		repoColumnBorrowedBook.BorrowDate: param.BorrowDate,
+		repoColumnBorrowedBook.DueDate:    param.DueDate,
+		repoColumnBorrowedBook.BranchID:   param.BranchID,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableBorrowedBook).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBorrowedBook.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBorrowedBook
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("copy is already on loan"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	loan := row.toModel()
+	return &loan, nil
+}
+
+// getReadyHoldByCopyID returns the hold a copy waits on the hold shelf for,
+// if any.
+func (r *PostgresRepository) getReadyHoldByCopyID(ctx context.Context, db sqlContextGetter, copyID int) (*model.Hold, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnHold.columns()).
+		From(repoTableHold).
+		Where(sq.Eq{
+			repoColumnHold.CopyID: copyID,
+			repoColumnHold.Status: model.HoldReady,
+		}).
+		Limit(1).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoHold
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	hold := model.Hold(row)
+	return &hold, nil
+}
+
+// hasRequestedTransfer reports whether a copy is due to leave its branch.
+func (r *PostgresRepository) hasRequestedTransfer(ctx context.Context, db sqlContextGetter, copyID int) (bool, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select("COUNT(*) > 0").
+		From(repoTableTransfer).
+		Where(sq.Eq{
+			repoColumnTransfer.CopyID: copyID,
+			repoColumnTransfer.Status: model.TransferRequested,
+		}).
+		ToSql()
+	if err != nil {
+		return false, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var exists bool
+	if err = db.GetContext(ctx, &exists, query, args...); err != nil {
+		return false, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return exists, nil
+}
+
+// RenewLoan pushes the due date of a loan back to newDue, as the policy
+// allows, for a patron in good standing.
+func (r *PostgresRepository) RenewLoan(ctx context.Context, id int, newDue time.Time, policy model.LoanPolicy, standing model.StandingPolicy) (*model.BorrowedBook, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	loan, err := r.renewLoan(ctx, tx, id, newDue, policy, standing)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+func (r *PostgresRepository) renewLoan(ctx context.Context, db sqlContextGetter, id int, newDue time.Time, policy model.LoanPolicy, standing model.StandingPolicy) (*model.BorrowedBook, common.Error) {
+	loan, cErr := r.getLoan(ctx, db, id, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if cErr = r.checkAccountStanding(ctx, db, loan.UserID, standing, time.Now()); cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidateRenewal(*loan, policy, newDue); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBorrowedBook).
+		SetMap(map[string]interface{}{
+			repoColumnBorrowedBook.DueDate:   newDue,
+			repoColumnBorrowedBook.Renewals:  sq.Expr(repoColumnBorrowedBook.Renewals + " + 1"),
+			repoColumnBorrowedBook.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnBorrowedBook.ID: id}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBorrowedBook.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBorrowedBook
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	renewed := row.toModel()
+	return &renewed, nil
+}
+
+// ReturnLoan checks in the copy of a loan at returnedAt. The copy goes back
+// on the shelf and the borrower is charged the fine, if any.
+func (r *PostgresRepository) ReturnLoan(ctx context.Context, id int, returnedAt time.Time, fine *model.Charge, returnedBy string) (*model.BorrowedBook, *model.Charge, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loan, charge, err := r.returnLoan(ctx, tx, id, returnedAt, fine, returnedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, nil, err
+	}
+
+	return loan, charge, nil
+}
+
+func (r *PostgresRepository) returnLoan(ctx context.Context, db sqlContextGetter, id int, returnedAt time.Time, fine *model.Charge, returnedBy string) (*model.BorrowedBook, *model.Charge, common.Error) {
+	loan, cErr := r.getLoan(ctx, db, id, true)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+	if err := model.ValidateReturn(*loan); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if _, cErr = r.changeBookCopyStatus(ctx, db, loan.CopyID, model.InLibrary, returnedBy, "checked in"); cErr != nil {
+		return nil, nil, cErr
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBorrowedBook).
+		SetMap(map[string]interface{}{
+			repoColumnBorrowedBook.ReturnDate: returnedAt,
+			repoColumnBorrowedBook.UpdatedAt:  sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnBorrowedBook.ID: id}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnBorrowedBook.columns())).
+		ToSql()
+	if err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoBorrowedBook
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	returned := row.toModel()
+
+	if fine == nil {
+		return &returned, nil, nil
+	}
+	charge, cErr := r.createCharge(ctx, db, *fine)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+	return &returned, charge, nil
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initLoanRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataBorrowedBook),
+		testdata.Path(testdata.TestDataCharge),
+	)
+}
+
+func TestLoanRepository_ListOverdueLoans(t *testing.T) {
+	repo := initLoanRepository(t)
+
+	loans, err := repo.ListOverdueLoans(context.Background(), time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, loans, 1)
+	assert.Equal(t, 2, loans[0].ID)
+
+	loans, err = repo.ListOverdueLoans(context.Background(), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Len(t, loans, 2)
+}
+
+func TestLoanRepository_DeclareLoanLostAndReturn(t *testing.T) {
+	repo := initLoanRepository(t)
+	policy := model.LostItemPolicy{ProcessingFeeCents: 500, DefaultReplacementCents: 2500}
+
+	price := int64(1800)
+	_, err := repo.SetBookCopyPrice(context.Background(), 1, &price)
+	require.NoError(t, err)
+
+	loan, charges, err := repo.DeclareLoanLost(context.Background(), 1, policy, "desk", "patron reported it lost")
+	require.NoError(t, err)
+	assert.Equal(t, 2, loan.ID)
+	assert.True(t, loan.IsLost())
+	require.Len(t, charges, 2)
+	assert.Equal(t, model.ChargeReplacement, charges[0].Kind)
+	assert.Equal(t, int64(1800), charges[0].AmountCents)
+	assert.Equal(t, model.ChargeProcessingFee, charges[1].Kind)
+	assert.Equal(t, 2, charges[1].UserID)
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, model.Lost, bookCopy.Status)
+
+	// a lost loan is not declared twice
+	_, _, err = repo.DeclareLoanLost(context.Background(), 1, policy, "desk", "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	bookCopy, reversed, err := repo.ReturnLostCopy(context.Background(), 1, "desk")
+	require.NoError(t, err)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+	assert.Len(t, reversed, 2)
+
+	owed, err := repo.ListChargesByUserID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Zero(t, model.OutstandingCents(owed))
+
+	_, err = repo.GetOpenLoanByCopyID(context.Background(), 1)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
+
+func TestLoanRepository_DeclareLoanLost_DefaultReplacement(t *testing.T) {
+	repo := initLoanRepository(t)
+	policy := model.LostItemPolicy{DefaultReplacementCents: 2500}
+
+	_, charges, err := repo.DeclareLoanLost(context.Background(), 3, policy, "system", "overdue")
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, int64(2500), charges[0].AmountCents)
+
+	// copy 2 is on the shelf
+	_, _, err = repo.DeclareLoanLost(context.Background(), 2, policy, "system", "overdue")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
+
+func TestLoanRepository_ReturnLostCopy_WithoutLoan(t *testing.T) {
+	repo := initLoanRepository(t)
+
+	_, _, err := repo.ReturnLostCopy(context.Background(), 2, "desk")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	_, err = repo.changeBookCopyStatus(context.Background(), repo.db, 2, model.Lost, "desk", "missing at stocktake")
+	require.NoError(t, err)
+
+	bookCopy, reversed, err := repo.ReturnLostCopy(context.Background(), 2, "desk")
+	require.NoError(t, err)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+	assert.Empty(t, reversed)
+}
+
+func TestLoanRepository_DueDateInLibraryTimeZone(t *testing.T) {
+	repo := initLoanRepository(t)
+	loc, err := time.LoadLocation("Australia/Brisbane")
+	require.NoError(t, err)
+
+	// due at closing time, which is past midnight UTC on the day before
+	borrowedAt := time.Date(2023, 9, 1, 9, 0, 0, 0, loc)
+	dueDate := time.Date(2023, 9, 22, 23, 59, 59, 0, loc)
+	loan, err := repo.CreateLoan(context.Background(), model.NewBorrowdBook(2, 2, borrowedAt, dueDate), model.StandingPolicy{}, "desk")
+	require.NoError(t, err)
+
+	got, err := repo.GetLoanByID(context.Background(), loan.ID)
+	require.NoError(t, err)
+	assert.True(t, dueDate.Equal(got.DueDate), "due %s, stored %s", dueDate, got.DueDate)
+	assert.True(t, borrowedAt.Equal(got.BorrowDate))
+	assert.Equal(t, "2023-09-22", got.DueDate.In(loc).Format("2006-01-02"))
+
+	// the loan is overdue from the next morning in the library, not before
+	overdue, err := repo.ListOverdueLoans(context.Background(), time.Date(2023, 9, 22, 20, 0, 0, 0, loc))
+	require.NoError(t, err)
+	for _, l := range overdue {
+		assert.NotEqual(t, loan.ID, l.ID)
+	}
+	overdue, err = repo.ListOverdueLoans(context.Background(), time.Date(2023, 9, 23, 0, 30, 0, 0, loc))
+	require.NoError(t, err)
+	var found bool
+	for _, l := range overdue {
+		found = found || l.ID == loan.ID
+	}
+	assert.True(t, found)
+}
+
+func TestLoanRepository_CheckoutRenewReturn(t *testing.T) {
+	repo := initLoanRepository(t)
+	policy := model.LoanPolicy{LoanDays: 21, MaxRenewals: 1}
+
+	borrowedAt := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	branchID := 1
+	param := model.NewBorrowdBook(2, 2, borrowedAt, borrowedAt.AddDate(0, 0, 21))
+	param.BranchID = &branchID
+	loan, err := repo.CreateLoan(context.Background(), param, model.StandingPolicy{}, "desk")
+	require.NoError(t, err)
+	assert.Equal(t, 2, loan.UserID)
+	require.NotNil(t, loan.BranchID)
+	assert.Zero(t, loan.Renewals)
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.Borrowed, bookCopy.Status)
+
+	// a copy out on loan is not lent again
+	_, err = repo.CreateLoan(context.Background(), model.NewBorrowdBook(3, 2, borrowedAt, borrowedAt), model.StandingPolicy{}, "desk")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	renewed, err := repo.RenewLoan(context.Background(), loan.ID, loan.DueDate.AddDate(0, 0, 21), policy, model.StandingPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, renewed.Renewals)
+
+	_, err = repo.RenewLoan(context.Background(), loan.ID, renewed.DueDate.AddDate(0, 0, 21), policy, model.StandingPolicy{})
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	fine := model.OverdueFineCharge(*renewed, *bookCopy, 75, 3, "desk")
+	returned, charge, err := repo.ReturnLoan(context.Background(), loan.ID, renewed.DueDate.AddDate(0, 0, 3), &fine, "desk")
+	require.NoError(t, err)
+	assert.False(t, returned.IsOpen())
+	require.NotNil(t, charge)
+	assert.Equal(t, model.ChargeOverdueFine, charge.Kind)
+	assert.Equal(t, int64(75), charge.AmountCents)
+
+	bookCopy, err = repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+
+	_, _, err = repo.ReturnLoan(context.Background(), loan.ID, time.Now(), nil, "desk")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+}
+
+func TestLoanRepository_ListLoansByUserID(t *testing.T) {
+	repo := initLoanRepository(t)
+
+	loans, err := repo.ListLoansByUserID(context.Background(), 1, false)
+	require.NoError(t, err)
+	require.Len(t, loans, 2)
+	assert.Equal(t, 1, loans[0].ID)
+
+	loans, err = repo.ListLoansByUserID(context.Background(), 1, true)
+	require.NoError(t, err)
+	assert.Empty(t, loans)
+
+	_, err = repo.GetLoanByID(context.Background(), 99)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
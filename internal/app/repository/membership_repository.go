@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoLibraryCard struct {
+	ID           int        `db:"id"`
+	UserID       int        `db:"user_id"`
+	Barcode      string     `db:"barcode"`
+	IssuedBy     string     `db:"issued_by"`
+	RevokedAt    *time.Time `db:"revoked_at"`
+	RevokedBy    string     `db:"revoked_by"`
+	RevokeReason string     `db:"revoke_reason"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
+}
+
+type repoColumnPatternLibraryCard struct {
+	ID           string
+	UserID       string
+	Barcode      string
+	IssuedBy     string
+	RevokedAt    string
+	RevokedBy    string
+	RevokeReason string
+	CreatedAt    string
+	UpdatedAt    string
+}
+
+const repoTableLibraryCard = "library_cards"
+
+var repoColumnLibraryCard = repoColumnPatternLibraryCard{
+	ID:           "id",
+	UserID:       "user_id",
+	Barcode:      "barcode",
+	IssuedBy:     "issued_by",
+	RevokedAt:    "revoked_at",
+	RevokedBy:    "revoked_by",
+	RevokeReason: "revoke_reason",
+	CreatedAt:    "created_at",
+	UpdatedAt:    "updated_at",
+}
+
+func (c *repoColumnPatternLibraryCard) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.UserID,
+		c.Barcode,
+		c.IssuedBy,
+		c.RevokedAt,
+		c.RevokedBy,
+		c.RevokeReason,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+// NextCardBarcodeNumber returns a number never handed out before, to generate
+// the barcode of a card issued without a preprinted one.
+func (r *PostgresRepository) NextCardBarcodeNumber(ctx context.Context) (int64, common.Error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, "SELECT nextval('library_cards_barcode_seq')"); err != nil {
+		return 0, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return seq, nil
+}
+
+// IssueLibraryCard gives a patron a new card. The card they hold, if any, is
+// revoked for replaceReason, which is required then.
+func (r *PostgresRepository) IssueLibraryCard(ctx context.Context, param model.LibraryCard, replaceReason string) (*model.LibraryCard, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	card, err := r.issueLibraryCard(ctx, tx, param, replaceReason)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+func (r *PostgresRepository) issueLibraryCard(ctx context.Context, db sqlContextGetter, param model.LibraryCard, replaceReason string) (*model.LibraryCard, common.Error) {
+	// cards of a patron are issued one at a time
+	if _, cErr := r.lockUser(ctx, db, param.UserID); cErr != nil {
+		return nil, cErr
+	}
+
+	cards, cErr := r.listLibraryCards(ctx, db, sq.Eq{
+		repoColumnLibraryCard.UserID:    param.UserID,
+		repoColumnLibraryCard.RevokedAt: nil,
+	})
+	if cErr != nil {
+		return nil, cErr
+	}
+	for _, card := range cards {
+		if err := model.ValidateCardRevocation(*card, replaceReason); err != nil {
+			msg := fmt.Sprintf("the patron already holds card %s; a reason is required to replace it", card.Barcode)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		if _, cErr := r.revokeLibraryCard(ctx, db, card.ID, param.IssuedBy, replaceReason); cErr != nil {
+			return nil, cErr
+		}
+	}
+
+	insert := map[string]interface{}{
+		repoColumnLibraryCard.UserID:   param.UserID,
+		repoColumnLibraryCard.Barcode:  param.Barcode,
+		repoColumnLibraryCard.IssuedBy: param.IssuedBy,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableLibraryCard).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnLibraryCard.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoLibraryCard
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("card barcode %s is already in use", param.Barcode)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	card := model.LibraryCard(row)
+	return &card, nil
+}
+
+func (r *PostgresRepository) GetLibraryCardByID(ctx context.Context, id int) (*model.LibraryCard, common.Error) {
+	return r.getLibraryCard(ctx, r.db, sq.Eq{repoColumnLibraryCard.ID: id}, false)
+}
+
+// GetLibraryCardByBarcode returns the card carrying a barcode, revoked or not.
+func (r *PostgresRepository) GetLibraryCardByBarcode(ctx context.Context, barcode string) (*model.LibraryCard, common.Error) {
+	return r.getLibraryCard(ctx, r.db, sq.Eq{repoColumnLibraryCard.Barcode: barcode}, false)
+}
+
+func (r *PostgresRepository) getLibraryCard(ctx context.Context, db sqlContextGetter, where sq.Sqlizer, forUpdate bool) (*model.LibraryCard, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnLibraryCard.columns()).
+		From(repoTableLibraryCard).
+		Where(where)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoLibraryCard
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("card not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	card := model.LibraryCard(row)
+	return &card, nil
+}
+
+// ListLibraryCardsByUserID returns the cards issued to a patron, the latest first.
+func (r *PostgresRepository) ListLibraryCardsByUserID(ctx context.Context, userID int) ([]*model.LibraryCard, common.Error) {
+	return r.listLibraryCards(ctx, r.db, sq.Eq{repoColumnLibraryCard.UserID: userID})
+}
+
+func (r *PostgresRepository) listLibraryCards(ctx context.Context, db sqlContextGetter, where sq.Sqlizer) ([]*model.LibraryCard, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnLibraryCard.columns()).
+		From(repoTableLibraryCard).
+		Where(where).
+		OrderBy(repoColumnLibraryCard.CreatedAt+" DESC", repoColumnLibraryCard.ID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoLibraryCard
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	cards := make([]*model.LibraryCard, 0, len(rows))
+	for _, row := range rows {
+		card := model.LibraryCard(row)
+		cards = append(cards, &card)
+	}
+	return cards, nil
+}
+
+// RevokeLibraryCard revokes a card, e.g. one reported lost, so that it no
+// longer identifies its patron.
+func (r *PostgresRepository) RevokeLibraryCard(ctx context.Context, id int, revokedBy, reason string) (*model.LibraryCard, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	card, err := r.revokeLibraryCard(ctx, tx, id, revokedBy, reason)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+func (r *PostgresRepository) revokeLibraryCard(ctx context.Context, db sqlContextGetter, id int, revokedBy, reason string) (*model.LibraryCard, common.Error) {
+	card, cErr := r.getLibraryCard(ctx, db, sq.Eq{repoColumnLibraryCard.ID: id}, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidateCardRevocation(*card, reason); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableLibraryCard).
+		SetMap(map[string]interface{}{
+			repoColumnLibraryCard.RevokedAt:    sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnLibraryCard.RevokedBy:    revokedBy,
+			repoColumnLibraryCard.RevokeReason: reason,
+			repoColumnLibraryCard.UpdatedAt:    sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnLibraryCard.ID: id}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnLibraryCard.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoLibraryCard
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	revoked := model.LibraryCard(row)
+	return &revoked, nil
+}
+
+// UpdateMembership sets the category of a patron and the days their
+// membership runs.
+func (r *PostgresRepository) UpdateMembership(ctx context.Context, userID int, m model.Membership) (*model.User, common.Error) {
+	if err := model.ValidateMembership(m); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return r.updateMembership(ctx, r.db, userID, m)
+}
+
+func (r *PostgresRepository) updateMembership(ctx context.Context, db sqlContextGetter, userID int, m model.Membership) (*model.User, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableUser).
+		SetMap(map[string]interface{}{
+			repoColumnUser.Category:            m.Category,
+			repoColumnUser.MembershipStartsAt:  m.StartsAt,
+			repoColumnUser.MembershipExpiresAt: m.ExpiresAt,
+			repoColumnUser.UpdatedAt:           sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnUser.ID: userID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnUser.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoUser
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	user := model.User(row)
+	return &user, nil
+}
+
+// RenewMembership extends the membership of a patron by the term of their
+// category, as of the day today.
+func (r *PostgresRepository) RenewMembership(ctx context.Context, userID int, terms model.MembershipTerms, today time.Time) (*model.User, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.renewMembership(ctx, tx, userID, terms, today)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *PostgresRepository) renewMembership(ctx context.Context, db sqlContextGetter, userID int, terms model.MembershipTerms, today time.Time) (*model.User, common.Error) {
+	user, cErr := r.lockUser(ctx, db, userID)
+	if cErr != nil {
+		return nil, cErr
+	}
+	renewed, err := terms.Renew(user.Membership(), today)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return r.updateMembership(ctx, db, userID, renewed)
+}
+
+// lockUser takes a user until the transaction ends.
+func (r *PostgresRepository) lockUser(ctx context.Context, db sqlContextGetter, id int) (*model.User, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnUser.columns()).
+		From(repoTableUser).
+		Where(sq.Eq{repoColumnUser.ID: id}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoUser
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	user := model.User(row)
+	return &user, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initMembershipRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataLibraryCard),
+	)
+}
+
+func TestMembershipRepository_LibraryCards(t *testing.T) {
+	repo := initMembershipRepository(t)
+
+	card, err := repo.GetLibraryCardByBarcode(context.Background(), "20000000000022")
+	require.NoError(t, err)
+	assert.Equal(t, 1, card.UserID)
+	assert.True(t, card.IsActive())
+
+	// the patron already holds a card
+	_, err = repo.IssueLibraryCard(context.Background(), model.NewLibraryCard(1, "20000000000030", "desk"), "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	_, err = repo.IssueLibraryCard(context.Background(), model.NewLibraryCard(2, "20000000000014", "desk"), "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	issued, err := repo.IssueLibraryCard(context.Background(), model.NewLibraryCard(1, "20000000000030", "desk"), "damaged")
+	require.NoError(t, err)
+	assert.True(t, issued.IsActive())
+
+	cards, err := repo.ListLibraryCardsByUserID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, cards, 3)
+	assert.Equal(t, issued.ID, cards[0].ID)
+	assert.False(t, cards[1].IsActive())
+	assert.Equal(t, "damaged", cards[1].RevokeReason)
+
+	revoked, err := repo.RevokeLibraryCard(context.Background(), issued.ID, "desk", "lost")
+	require.NoError(t, err)
+	assert.False(t, revoked.IsActive())
+
+	_, err = repo.RevokeLibraryCard(context.Background(), issued.ID, "desk", "lost")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	seq, err := repo.NextCardBarcodeNumber(context.Background())
+	require.NoError(t, err)
+	assert.Positive(t, seq)
+}
+
+func TestMembershipRepository_RenewMembership(t *testing.T) {
+	repo := initMembershipRepository(t)
+	today := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	// the guest membership lapsed and runs again from today
+	user, err := repo.RenewMembership(context.Background(), 3, model.DefaultMembershipTerms, today)
+	require.NoError(t, err)
+	require.NotNil(t, user.MembershipExpiresAt)
+	assert.Equal(t, "2023-11-30", user.MembershipExpiresAt.Format("2006-01-02"))
+	assert.Equal(t, "2022-11-01", user.MembershipStartsAt.Format("2006-01-02"))
+
+	_, err = repo.RenewMembership(context.Background(), 2, model.DefaultMembershipTerms, today)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	expiry := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	user, err = repo.UpdateMembership(context.Background(), 2, model.Membership{
+		Category:  model.PatronChild,
+		StartsAt:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt: &expiry,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.PatronChild, user.Category)
+	assert.Equal(t, "2023-12-31", user.MembershipExpiresAt.Format("2006-01-02"))
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoNotificationPreferences struct {
+	UserID       int                        `db:"user_id"`
+	Types        pq.StringArray             `db:"types"`
+	Channel      model.NotificationChannel  `db:"channel"`
+	Delivery     model.NotificationDelivery `db:"delivery"`
+	TimeZone     string                     `db:"time_zone"`
+	QuietStart   int                        `db:"quiet_start"`
+	QuietEnd     int                        `db:"quiet_end"`
+	DigestTime   int                        `db:"digest_time"`
+	LastDigestAt *time.Time                 `db:"last_digest_at"`
+	CreatedAt    time.Time                  `db:"created_at"`
+	UpdatedAt    time.Time                  `db:"updated_at"`
+}
+
+type repoColumnPatternNotificationPreferences struct {
+	UserID       string
+	Types        string
+	Channel      string
+	Delivery     string
+	TimeZone     string
+	QuietStart   string
+	QuietEnd     string
+	DigestTime   string
+	LastDigestAt string
+	CreatedAt    string
+	UpdatedAt    string
+}
+
+const repoTableNotificationPreferences = "notification_preferences"
+
+var repoColumnNotificationPreferences = repoColumnPatternNotificationPreferences{
+	UserID:       "user_id",
+	Types:        "types",
+	Channel:      "channel",
+	Delivery:     "delivery",
+	TimeZone:     "time_zone",
+	QuietStart:   "quiet_start",
+	QuietEnd:     "quiet_end",
+	DigestTime:   "digest_time",
+	LastDigestAt: "last_digest_at",
+	CreatedAt:    "created_at",
+	UpdatedAt:    "updated_at",
+}
+
+func (c *repoColumnPatternNotificationPreferences) columns() string {
+	return strings.Join([]string{
+		c.UserID,
+		c.Types,
+		c.Channel,
+		c.Delivery,
+		c.TimeZone,
+		c.QuietStart,
+		c.QuietEnd,
+		c.DigestTime,
+		c.LastDigestAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (p repoNotificationPreferences) toModel() model.NotificationPreferences {
+	types := make([]model.NotificationType, 0, len(p.Types))
+	for _, t := range p.Types {
+		types = append(types, model.NotificationType(t))
+	}
+	return model.NotificationPreferences{
+		UserID:       p.UserID,
+		Types:        types,
+		Channel:      p.Channel,
+		Delivery:     p.Delivery,
+		TimeZone:     p.TimeZone,
+		QuietStart:   model.TimeOfDay(p.QuietStart),
+		QuietEnd:     model.TimeOfDay(p.QuietEnd),
+		DigestTime:   model.TimeOfDay(p.DigestTime),
+		LastDigestAt: p.LastDigestAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+}
+
+// GetNotificationPreferences returns the preferences a patron set.
+func (r *PostgresRepository) GetNotificationPreferences(ctx context.Context, userID int) (*model.NotificationPreferences, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnNotificationPreferences.columns()).
+		From(repoTableNotificationPreferences).
+		Where(sq.Eq{repoColumnNotificationPreferences.UserID: userID}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoNotificationPreferences
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("notification preferences not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	prefs := row.toModel()
+	return &prefs, nil
+}
+
+// ListNotificationPreferences returns the preferences set by any of the
+// patrons. Patrons who set none are left out.
+func (r *PostgresRepository) ListNotificationPreferences(ctx context.Context, userIDs []int) ([]*model.NotificationPreferences, common.Error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnNotificationPreferences.columns()).
+		From(repoTableNotificationPreferences).
+		Where(sq.Eq{repoColumnNotificationPreferences.UserID: userIDs}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoNotificationPreferences
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	prefs := make([]*model.NotificationPreferences, 0, len(rows))
+	for _, row := range rows {
+		p := row.toModel()
+		prefs = append(prefs, &p)
+	}
+	return prefs, nil
+}
+
+// SetNotificationPreferences saves the preferences of a patron, keeping when
+// their last digest went out.
+func (r *PostgresRepository) SetNotificationPreferences(ctx context.Context, param model.NotificationPreferences) (*model.NotificationPreferences, common.Error) {
+	types := make([]string, 0, len(param.Types))
+	for _, t := range param.Types {
+		types = append(types, string(t))
+	}
+
+	updated := []string{
+		repoColumnNotificationPreferences.Types,
+		repoColumnNotificationPreferences.Channel,
+		repoColumnNotificationPreferences.Delivery,
+		repoColumnNotificationPreferences.TimeZone,
+		repoColumnNotificationPreferences.QuietStart,
+		repoColumnNotificationPreferences.QuietEnd,
+		repoColumnNotificationPreferences.DigestTime,
+	}
+	sets := make([]string, 0, len(updated)+1)
+	for _, c := range updated {
+		sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+	}
+	sets = append(sets, fmt.Sprintf("%s = CURRENT_TIMESTAMP", repoColumnNotificationPreferences.UpdatedAt))
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableNotificationPreferences).
+		SetMap(map[string]interface{}{
+			repoColumnNotificationPreferences.UserID:     param.UserID,
+			repoColumnNotificationPreferences.Types:      sq.Expr("?::notification_type[]", pq.Array(types)),
+			repoColumnNotificationPreferences.Channel:    param.Channel,
+			repoColumnNotificationPreferences.Delivery:   param.Delivery,
+			repoColumnNotificationPreferences.TimeZone:   param.TimeZone,
+			repoColumnNotificationPreferences.QuietStart: int(param.QuietStart),
+			repoColumnNotificationPreferences.QuietEnd:   int(param.QuietEnd),
+			repoColumnNotificationPreferences.DigestTime: int(param.DigestTime),
+		}).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s returning %s",
+			repoColumnNotificationPreferences.UserID, strings.Join(sets, ", "), repoColumnNotificationPreferences.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoNotificationPreferences
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	prefs := row.toModel()
+	return &prefs, nil
+}
+
+// MarkDigestSent records when the last digest of a patron went out.
+func (r *PostgresRepository) MarkDigestSent(ctx context.Context, userID int, sentAt time.Time) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableNotificationPreferences).
+		Set(repoColumnNotificationPreferences.LastDigestAt, sentAt).
+		Where(sq.Eq{repoColumnNotificationPreferences.UserID: userID}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = r.db.ExecContext(ctx, query, args...); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return nil
+}
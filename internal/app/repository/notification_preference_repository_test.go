@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationPreferenceRepository(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataUser))
+	ctx := context.Background()
+
+	_, err := repo.GetNotificationPreferences(ctx, 1)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+
+	p := model.DefaultNotificationPreferences(1, model.ChannelLog, "Europe/Madrid")
+	p.Types = []model.NotificationType{model.NotificationOverdue, model.NotificationHoldReady}
+	p.Delivery = model.DeliveryDigest
+	p.QuietStart, p.QuietEnd = 22*60, 7*60
+	set, err := repo.SetNotificationPreferences(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, p.Types, set.Types)
+	assert.Equal(t, model.TimeOfDay(22*60), set.QuietStart)
+	assert.Nil(t, set.LastDigestAt)
+
+	sentAt := time.Date(2023, 6, 1, 6, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.MarkDigestSent(ctx, 1, sentAt))
+
+	// saving again keeps when the last digest went out
+	p.Types = nil
+	p.Delivery = model.DeliveryImmediate
+	_, err = repo.SetNotificationPreferences(ctx, p)
+	require.NoError(t, err)
+	got, err := repo.GetNotificationPreferences(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, got.Types)
+	assert.Equal(t, model.DeliveryImmediate, got.Delivery)
+	assert.Equal(t, "Europe/Madrid", got.TimeZone)
+	require.NotNil(t, got.LastDigestAt)
+	assert.True(t, sentAt.Equal(*got.LastDigestAt))
+
+	prefs, err := repo.ListNotificationPreferences(ctx, []int{1, 2})
+	require.NoError(t, err)
+	require.Len(t, prefs, 1)
+	assert.Equal(t, 1, prefs[0].UserID)
+
+	p.UserID = 99
+	_, err = repo.SetNotificationPreferences(ctx, p)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoSentNotification struct {
+	ID          int                       `db:"id"`
+	UserID      int                       `db:"user_id"`
+	Type        model.NotificationType    `db:"type"`
+	Channel     model.NotificationChannel `db:"channel"`
+	ReferenceID int                       `db:"reference_id"`
+	DedupKey    string                    `db:"dedup_key"`
+	Digest      bool                      `db:"digest"`
+	Locale      string                    `db:"locale"`
+	Subject     string                    `db:"subject"`
+	Body        string                    `db:"body"`
+	SentAt      time.Time                 `db:"sent_at"`
+}
+
+type repoColumnPatternSentNotification struct {
+	ID          string
+	UserID      string
+	Type        string
+	Channel     string
+	ReferenceID string
+	DedupKey    string
+	Digest      string
+	Locale      string
+	Subject     string
+	Body        string
+	SentAt      string
+}
+
+const repoTableSentNotification = "sent_notifications"
+
+var repoColumnSentNotification = repoColumnPatternSentNotification{
+	ID:          "id",
+	UserID:      "user_id",
+	Type:        "type",
+	Channel:     "channel",
+	ReferenceID: "reference_id",
+	DedupKey:    "dedup_key",
+	Digest:      "digest",
+	Locale:      "locale",
+	Subject:     "subject",
+	Body:        "body",
+	SentAt:      "sent_at",
+}
+
+func (c *repoColumnPatternSentNotification) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.UserID,
+		c.Type,
+		c.Channel,
+		c.ReferenceID,
+		c.DedupKey,
+		c.Digest,
+		c.Locale,
+		c.Subject,
+		c.Body,
+		c.SentAt,
+	}, ", ")
+}
+
+type repoNotice struct {
+	ReferenceID int        `db:"reference_id"`
+	UserID      int        `db:"user_id"`
+	Email       string     `db:"email"`
+	Name        string     `db:"name"`
+	Title       string     `db:"title"`
+	DueDate     *time.Time `db:"due_date"`
+	BranchName  string     `db:"branch_name"`
+	ReadyAt     *time.Time `db:"ready_at"`
+}
+
+func (n repoNotice) toModel(typ model.NotificationType) model.Notice {
+	notice := model.Notice{
+		Type:        typ,
+		UserID:      n.UserID,
+		Email:       n.Email,
+		Name:        n.Name,
+		ReferenceID: n.ReferenceID,
+		Title:       n.Title,
+		BranchName:  n.BranchName,
+	}
+	if n.DueDate != nil {
+		notice.DueDate = *n.DueDate
+	}
+	if n.ReadyAt != nil {
+		notice.ReadyAt = *n.ReadyAt
+	}
+	return notice
+}
+
+// selectLoanNotices selects the open loans of patrons, with what notices
+// about them tell. Lost copies are charged for rather than reminded of.
+func (r *PostgresRepository) selectLoanNotices() sq.SelectBuilder {
+	return r.pgsq.Select(
+		"bb.id AS reference_id",
+		"u.id AS user_id",
+		"u.email",
+		"u.name",
+		"b.title",
+		"bb.due_date",
+	).
+		From(repoTableBorrowedBook+" bb").
+		Join(repoTableUser+" u ON u.id = bb.user_id").
+		Join(repoTableBookCopies+" bc ON bc.id = bb.copy_id").
+		Join(repoTableBook+" b ON b.id = bc.book_id").
+		Where(sq.Eq{
+			"bb." + repoColumnBorrowedBook.ReturnDate: nil,
+			"bb." + repoColumnBorrowedBook.LostAt:     nil,
+			"u." + repoColumnUser.ErasedAt:            nil,
+		}).
+		OrderBy("bb.due_date", "bb.id")
+}
+
+func (r *PostgresRepository) listNotices(ctx context.Context, typ model.NotificationType, builder sq.SelectBuilder) ([]*model.Notice, common.Error) {
+	// build SQL query
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoNotice
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	notices := make([]*model.Notice, 0, len(rows))
+	for _, row := range rows {
+		notice := row.toModel(typ)
+		notices = append(notices, &notice)
+	}
+	return notices, nil
+}
+
+// ListDueSoonNotices returns the reminders owed for the loans due after one
+// time and up to another.
+func (r *PostgresRepository) ListDueSoonNotices(ctx context.Context, dueAfter, dueUntil time.Time) ([]*model.Notice, common.Error) {
+	return r.listNotices(ctx, model.NotificationDueSoon, r.selectLoanNotices().
+		Where(sq.Gt{"bb." + repoColumnBorrowedBook.DueDate: dueAfter}).
+		Where(sq.LtOrEq{"bb." + repoColumnBorrowedBook.DueDate: dueUntil}))
+}
+
+// ListOverdueNotices returns the notices owed for the loans due before a time.
+func (r *PostgresRepository) ListOverdueNotices(ctx context.Context, dueBefore time.Time) ([]*model.Notice, common.Error) {
+	return r.listNotices(ctx, model.NotificationOverdue, r.selectLoanNotices().
+		Where(sq.Lt{"bb." + repoColumnBorrowedBook.DueDate: dueBefore}))
+}
+
+// ListHoldReadyNotices returns the notices owed for the holds whose copy
+// waits at its pickup branch.
+func (r *PostgresRepository) ListHoldReadyNotices(ctx context.Context) ([]*model.Notice, common.Error) {
+	return r.listNotices(ctx, model.NotificationHoldReady, r.pgsq.Select(
+		"h.id AS reference_id",
+		"u.id AS user_id",
+		"u.email",
+		"u.name",
+		"w.title",
+		"br.name AS branch_name",
+		"COALESCE(h.ready_at, h.updated_at) AS ready_at",
+	).
+		From(repoTableHold+" h").
+		Join(repoTableUser+" u ON u.id = h.user_id").
+		Join(repoTableWork+" w ON w.id = h.work_id").
+		Join(repoTableBranch+" br ON br.id = h.pickup_branch_id").
+		Where(sq.Eq{
+			"h." + repoColumnHold.Status:   model.HoldReady,
+			"u." + repoColumnUser.ErasedAt: nil,
+		}).
+		OrderBy("ready_at", "h.id"))
+}
+
+// ClaimNotification records a notification about to be sent. It returns nil
+// if a notification with the same dedup key was already claimed, in which
+// case it is not to be sent again.
+func (r *PostgresRepository) ClaimNotification(ctx context.Context, param model.SentNotification) (*model.SentNotification, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableSentNotification).
+		SetMap(map[string]interface{}{
+			repoColumnSentNotification.UserID:      param.UserID,
+			repoColumnSentNotification.Type:        param.Type,
+			repoColumnSentNotification.Channel:     param.Channel,
+			repoColumnSentNotification.ReferenceID: param.ReferenceID,
+			repoColumnSentNotification.DedupKey:    param.DedupKey,
+			repoColumnSentNotification.Digest:      param.Digest,
+			repoColumnSentNotification.Locale:      param.Locale,
+			repoColumnSentNotification.Subject:     param.Subject,
+			repoColumnSentNotification.Body:        param.Body,
+		}).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s) DO NOTHING returning %s",
+			repoColumnSentNotification.DedupKey, repoColumnSentNotification.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoSentNotification
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	sent := model.SentNotification(row)
+	return &sent, nil
+}
+
+// ReleaseNotification forgets a claimed notification that could not be sent,
+// so that the next run tries again.
+func (r *PostgresRepository) ReleaseNotification(ctx context.Context, id int) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Delete(repoTableSentNotification).
+		Where(sq.Eq{repoColumnSentNotification.ID: id}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = r.db.ExecContext(ctx, query, args...); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return nil
+}
+
+// ListSentNotificationsByUserID returns the notifications sent to a patron,
+// the latest first.
+func (r *PostgresRepository) ListSentNotificationsByUserID(ctx context.Context, userID int) ([]*model.SentNotification, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnSentNotification.columns()).
+		From(repoTableSentNotification).
+		Where(sq.Eq{repoColumnSentNotification.UserID: userID}).
+		OrderBy(repoColumnSentNotification.SentAt+" DESC", repoColumnSentNotification.ID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoSentNotification
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	sent := make([]*model.SentNotification, 0, len(rows))
+	for _, row := range rows {
+		n := model.SentNotification(row)
+		sent = append(sent, &n)
+	}
+	return sent, nil
+}
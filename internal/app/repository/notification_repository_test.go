@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initNotificationRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataBorrowedBook),
+		testdata.Path(testdata.TestDataHold),
+	)
+}
+
+func TestNotificationRepository_ListNotices(t *testing.T) {
+	repo := initNotificationRepository(t)
+
+	// loan 2 is due on 2023-05-22
+	notices, err := repo.ListDueSoonNotices(context.Background(),
+		time.Date(2023, 5, 19, 10, 0, 0, 0, time.UTC), time.Date(2023, 5, 22, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, notices, 1)
+	assert.Equal(t, model.NotificationDueSoon, notices[0].Type)
+	assert.Equal(t, 2, notices[0].ReferenceID)
+	assert.Equal(t, 2, notices[0].UserID)
+	assert.Equal(t, "user2@pageturnerpro.com", notices[0].Email)
+	assert.Equal(t, "Don Quijote de la Mancha", notices[0].Title)
+
+	notices, err = repo.ListOverdueNotices(context.Background(), time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, notices, 2)
+	assert.Equal(t, 2, notices[0].ReferenceID)
+	assert.Equal(t, 5, notices[1].ReferenceID)
+	assert.Equal(t, "The Little Prince", notices[1].Title)
+
+	notices, err = repo.ListHoldReadyNotices(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, notices)
+
+	readyAt := time.Date(2023, 6, 2, 9, 0, 0, 0, time.UTC)
+	_, dbErr := repo.db.Exec("UPDATE holds SET status = 'Ready', copy_id = 2, ready_at = $1 WHERE id = 1", readyAt)
+	require.NoError(t, dbErr)
+	notices, err = repo.ListHoldReadyNotices(context.Background())
+	require.NoError(t, err)
+	require.Len(t, notices, 1)
+	assert.Equal(t, model.NotificationHoldReady, notices[0].Type)
+	assert.Equal(t, 1, notices[0].ReferenceID)
+	assert.Equal(t, "Don Quixote", notices[0].Title)
+	assert.Equal(t, "Main Library", notices[0].BranchName)
+	assert.True(t, readyAt.Equal(notices[0].ReadyAt))
+}
+
+func TestNotificationRepository_ClaimNotification(t *testing.T) {
+	repo := initNotificationRepository(t)
+
+	param := model.SentNotification{
+		UserID:      2,
+		Type:        model.NotificationOverdue,
+		Channel:     model.ChannelEmail,
+		ReferenceID: 2,
+		DedupKey:    "Overdue:loan:2:1684749600",
+		Locale:      "en",
+		Subject:     "Overdue: Don Quijote de la Mancha",
+		Body:        "Please return it.",
+	}
+	claimed, err := repo.ClaimNotification(context.Background(), param)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, param.Subject, claimed.Subject)
+
+	// a rerun does not claim it again
+	again, err := repo.ClaimNotification(context.Background(), param)
+	require.NoError(t, err)
+	assert.Nil(t, again)
+
+	sent, err := repo.ListSentNotificationsByUserID(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, claimed.ID, sent[0].ID)
+
+	// once released, it may be claimed again
+	require.NoError(t, repo.ReleaseNotification(context.Background(), claimed.ID))
+	again, err = repo.ClaimNotification(context.Background(), param)
+	require.NoError(t, err)
+	assert.NotNil(t, again)
+}
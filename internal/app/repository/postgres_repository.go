@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/hashicorp/go-multierror"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
 )
 
@@ -53,3 +56,25 @@ func (r *PostgresRepository) finishTx(err common.Error, tx *sqlx.Tx) common.Erro
 		return nil
 	}
 }
+
+// aliasColumns qualifies every column of a comma-separated column list with a table alias.
+func aliasColumns(alias string, columns string) string {
+	cols := strings.Split(columns, ", ")
+	for i, c := range cols {
+		cols[i] = alias + "." + c
+	}
+	return strings.Join(cols, ", ")
+}
+
+// isUniqueViolation reports whether err was raised by a unique constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation reports whether err was raised by a foreign key
+// referencing a missing row.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+// ErasePatron erases the personal data of a patron who has no copies out and
+// owes nothing. Their loans are unlinked from them and still count in
+// statistics, active holds are cancelled, and cards, blocks, restrictions,
+// credentials, notifications and their preferences, and household membership
+// are dropped. The user row stays, without name or address, so charges and
+// other records keep pointing somewhere.
+func (r *PostgresRepository) ErasePatron(ctx context.Context, userID int, uid string) (*model.User, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.erasePatron(ctx, tx, userID, uid)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *PostgresRepository) erasePatron(ctx context.Context, db sqlContextGetter, userID int, uid string) (*model.User, common.Error) {
+	user, cErr := r.lockUser(ctx, db, userID)
+	if cErr != nil {
+		return nil, cErr
+	}
+	loans, cErr := r.listLoans(ctx, db, sq.Eq{
+		repoColumnBorrowedBook.UserID:     userID,
+		repoColumnBorrowedBook.ReturnDate: nil,
+	})
+	if cErr != nil {
+		return nil, cErr
+	}
+	charges, cErr := r.listCharges(ctx, db, sq.Eq{repoColumnCharge.UserID: userID}, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidateErasure(*user, loans, charges); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	if cErr = r.cancelActiveHoldsOfUser(ctx, db, userID); cErr != nil {
+		return nil, cErr
+	}
+	if cErr = r.leaveHousehold(ctx, db, userID); cErr != nil {
+		return nil, cErr
+	}
+
+	// unlink the loans, keeping them for statistics
+	query, args, err := r.pgsq.Update(repoTableBorrowedBook).
+		SetMap(map[string]interface{}{
+			repoColumnBorrowedBook.UserID:    nil,
+			repoColumnBorrowedBook.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnBorrowedBook.UserID: userID}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	for _, table := range []string{
+		repoTableLibraryCard, repoTableManualBlock, repoTableContentRestriction,
+		repoTableCredential, repoTableAccountToken, repoTableSentNotification,
+		repoTableNotificationPreferences,
+	} {
+		// build SQL query
+		query, args, err := r.pgsq.Delete(table).
+			Where(sq.Eq{"user_id": userID}).
+			ToSql()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+
+		// execute SQL query
+		if _, err = db.ExecContext(ctx, query, args...); err != nil {
+			return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+		}
+	}
+
+	erased := model.ErasedUser(*user, uid)
+
+	// build SQL query
+	query, args, err = r.pgsq.Update(repoTableUser).
+		SetMap(map[string]interface{}{
+			repoColumnUser.UID:             erased.UID,
+			repoColumnUser.Email:           erased.Email,
+			repoColumnUser.Name:            erased.Name,
+			repoColumnUser.EmailVerifiedAt: nil,
+			repoColumnUser.ErasedAt:        sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnUser.UpdatedAt:       sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnUser.ID: userID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnUser.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoUser
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	erased = model.User(row)
+	return &erased, nil
+}
+
+// cancelActiveHoldsOfUser cancels the active holds of a patron, putting the
+// copies they held back on the shelf.
+func (r *PostgresRepository) cancelActiveHoldsOfUser(ctx context.Context, db sqlContextGetter, userID int) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnHold.ID).
+		From(repoTableHold).
+		Where(sq.Eq{
+			repoColumnHold.UserID: userID,
+			repoColumnHold.Status: activeHoldStatuses,
+		}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var ids []int
+	if err = db.SelectContext(ctx, &ids, query, args...); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	for _, id := range ids {
+		if _, cErr := r.cancelHold(ctx, db, id); cErr != nil {
+			return cErr
+		}
+	}
+	return nil
+}
+
+// leaveHousehold takes a patron out of their household, if any. The last
+// guardian of dependents cannot leave.
+func (r *PostgresRepository) leaveHousehold(ctx context.Context, db sqlContextGetter, userID int) common.Error {
+	members, cErr := r.listHouseholdMembers(ctx, db, sq.Eq{repoColumnHouseholdMember.UserID: userID})
+	if cErr != nil {
+		return cErr
+	}
+	for _, m := range members {
+		_, cErr = r.changeHouseholdMember(ctx, db, *m, func() (*model.HouseholdMember, common.Error) {
+			return m, r.deleteHouseholdMember(ctx, db, m.HouseholdID, m.UserID)
+		})
+		if cErr != nil {
+			return cErr
+		}
+	}
+	return nil
+}
+
+// UpdateReadingHistoryPreference records whether a patron keeps their
+// returned loans.
+func (r *PostgresRepository) UpdateReadingHistoryPreference(ctx context.Context, userID int, keep bool) (*model.User, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableUser).
+		SetMap(map[string]interface{}{
+			repoColumnUser.KeepReadingHistory: keep,
+			repoColumnUser.UpdatedAt:          sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{
+			repoColumnUser.ID:       userID,
+			repoColumnUser.ErasedAt: nil,
+		}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnUser.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoUser
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	user := model.User(row)
+	return &user, nil
+}
+
+// PurgeReadingHistory unlinks the loans returned before a cutoff from their
+// patrons, unless the patron opted in to keeping them. The loans still count
+// in statistics. It returns how many loans were unlinked.
+func (r *PostgresRepository) PurgeReadingHistory(ctx context.Context, returnedBefore time.Time) (int, common.Error) {
+	keepers := fmt.Sprintf("%s NOT IN (SELECT %s FROM %s WHERE %s)",
+		repoColumnBorrowedBook.UserID, repoColumnUser.ID, repoTableUser, repoColumnUser.KeepReadingHistory)
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableBorrowedBook).
+		SetMap(map[string]interface{}{
+			repoColumnBorrowedBook.UserID:    nil,
+			repoColumnBorrowedBook.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.And{
+			sq.NotEq{repoColumnBorrowedBook.UserID: nil},
+			sq.Lt{repoColumnBorrowedBook.ReturnDate: returnedBefore},
+			sq.Expr(keepers),
+		}).
+		ToSql()
+	if err != nil {
+		return 0, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return int(n), nil
+}
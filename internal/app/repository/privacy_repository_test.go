@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initPrivacyRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataBorrowedBook),
+		testdata.Path(testdata.TestDataHold),
+		testdata.Path(testdata.TestDataCharge),
+		testdata.Path(testdata.TestDataManualBlock),
+		testdata.Path(testdata.TestDataLibraryCard),
+	)
+}
+
+func TestPrivacyRepository_ErasePatron(t *testing.T) {
+	repo := initPrivacyRepository(t)
+
+	// user 3 has a copy out
+	_, err := repo.ErasePatron(context.Background(), 3, "0f6b1a43-8d5e-4c4e-9d7a-3a0c2f7e4b11")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	// user 1 owes a processing fee
+	_, err = repo.ErasePatron(context.Background(), 1, "0f6b1a43-8d5e-4c4e-9d7a-3a0c2f7e4b11")
+	require.Error(t, err)
+	assert.Equal(t, "patron 1 cannot be erased: owes 3.00", err.(common.DomainError).ClientMsg())
+
+	_, err = repo.PayCharges(context.Background(), []int{1}, 1)
+	require.NoError(t, err)
+
+	user, err := repo.ErasePatron(context.Background(), 1, "0f6b1a43-8d5e-4c4e-9d7a-3a0c2f7e4b11")
+	require.NoError(t, err)
+	assert.True(t, user.IsErased())
+	assert.Empty(t, user.Name)
+	assert.Equal(t, "0f6b1a43-8d5e-4c4e-9d7a-3a0c2f7e4b11@"+model.ErasedEmailDomain, user.Email)
+
+	loans, err := repo.ListLoansByUserID(context.Background(), 1, false)
+	require.NoError(t, err)
+	assert.Empty(t, loans)
+
+	// the loans still count
+	var count int
+	require.NoError(t, repo.db.GetContext(context.Background(), &count, "SELECT COUNT(*) FROM borrowed_books WHERE user_id IS NULL"))
+	assert.Equal(t, 2, count)
+	loan, err := repo.getLastLoanByCopyID(context.Background(), repo.db, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, loan.ID)
+
+	holds, err := repo.ListActiveHoldsByUserID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Empty(t, holds)
+	cards, err := repo.ListLibraryCardsByUserID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Empty(t, cards)
+	charges, err := repo.ListChargesByUserID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Len(t, charges, 1)
+
+	_, err = repo.ErasePatron(context.Background(), 1, "5b0f8d2e-1c3a-4f6b-8e9d-7a2c4b6d8f10")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+}
+
+func TestPrivacyRepository_PurgeReadingHistory(t *testing.T) {
+	repo := initPrivacyRepository(t)
+
+	// user 3 keeps their reading history; open loans are never purged
+	purged, err := repo.PurgeReadingHistory(context.Background(), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	loans, err := repo.ListLoansByUserID(context.Background(), 1, false)
+	require.NoError(t, err)
+	assert.Empty(t, loans)
+	loans, err = repo.ListLoansByUserID(context.Background(), 3, false)
+	require.NoError(t, err)
+	assert.Len(t, loans, 2)
+
+	user, err := repo.UpdateReadingHistoryPreference(context.Background(), 3, false)
+	require.NoError(t, err)
+	assert.False(t, user.KeepReadingHistory)
+
+	purged, err = repo.PurgeReadingHistory(context.Background(), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = repo.UpdateReadingHistoryPreference(context.Background(), 99, true)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
@@ -0,0 +1,587 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoPurchaseOrder struct {
+	ID        int                       `db:"id"`
+	VendorID  int                       `db:"vendor_id"`
+	BranchID  int                       `db:"branch_id"`
+	Reference string                    `db:"reference"`
+	Notes     string                    `db:"notes"`
+	Status    model.PurchaseOrderStatus `db:"status"`
+	CreatedBy string                    `db:"created_by"`
+	OrderedAt *time.Time                `db:"ordered_at"`
+	ClosedAt  *time.Time                `db:"closed_at"`
+	CreatedAt time.Time                 `db:"created_at"`
+	UpdatedAt time.Time                 `db:"updated_at"`
+}
+
+type repoColumnPatternPurchaseOrder struct {
+	ID        string
+	VendorID  string
+	BranchID  string
+	Reference string
+	Notes     string
+	Status    string
+	CreatedBy string
+	OrderedAt string
+	ClosedAt  string
+	CreatedAt string
+	UpdatedAt string
+}
+
+const repoTablePurchaseOrder = "purchase_orders"
+
+var repoColumnPurchaseOrder = repoColumnPatternPurchaseOrder{
+	ID:        "id",
+	VendorID:  "vendor_id",
+	BranchID:  "branch_id",
+	Reference: "reference",
+	Notes:     "notes",
+	Status:    "status",
+	CreatedBy: "created_by",
+	OrderedAt: "ordered_at",
+	ClosedAt:  "closed_at",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (c *repoColumnPatternPurchaseOrder) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.VendorID,
+		c.BranchID,
+		c.Reference,
+		c.Notes,
+		c.Status,
+		c.CreatedBy,
+		c.OrderedAt,
+		c.ClosedAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (row repoPurchaseOrder) toModel() model.PurchaseOrder {
+	return model.PurchaseOrder{
+		ID:        row.ID,
+		VendorID:  row.VendorID,
+		BranchID:  row.BranchID,
+		Reference: row.Reference,
+		Notes:     row.Notes,
+		Status:    row.Status,
+		CreatedBy: row.CreatedBy,
+		OrderedAt: row.OrderedAt,
+		ClosedAt:  row.ClosedAt,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+type repoPurchaseOrderLine struct {
+	ID               int       `db:"id"`
+	OrderID          int       `db:"order_id"`
+	BookID           int       `db:"book_id"`
+	FundID           int       `db:"fund_id"`
+	Quantity         int       `db:"quantity"`
+	ReceivedQuantity int       `db:"received_quantity"`
+	UnitPriceCents   int64     `db:"unit_price_cents"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+type repoColumnPatternPurchaseOrderLine struct {
+	ID               string
+	OrderID          string
+	BookID           string
+	FundID           string
+	Quantity         string
+	ReceivedQuantity string
+	UnitPriceCents   string
+	CreatedAt        string
+	UpdatedAt        string
+}
+
+const repoTablePurchaseOrderLine = "purchase_order_lines"
+
+var repoColumnPurchaseOrderLine = repoColumnPatternPurchaseOrderLine{
+	ID:               "id",
+	OrderID:          "order_id",
+	BookID:           "book_id",
+	FundID:           "fund_id",
+	Quantity:         "quantity",
+	ReceivedQuantity: "received_quantity",
+	UnitPriceCents:   "unit_price_cents",
+	CreatedAt:        "created_at",
+	UpdatedAt:        "updated_at",
+}
+
+func (c *repoColumnPatternPurchaseOrderLine) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.OrderID,
+		c.BookID,
+		c.FundID,
+		c.Quantity,
+		c.ReceivedQuantity,
+		c.UnitPriceCents,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+const repoTablePurchaseOrderReceipt = "purchase_order_receipts"
+
+// invalidPurchaseOrderStatus reports an action an order's status does not allow.
+func invalidPurchaseOrderStatus(o *model.PurchaseOrder, action string) common.Error {
+	err := fmt.Errorf("purchase order %d is %s and cannot be %s", o.ID, o.Status, action)
+	return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+}
+
+// CreatePurchaseOrder drafts an order with no lines. It is delivered to the
+// main branch unless a branch is given.
+func (r *PostgresRepository) CreatePurchaseOrder(ctx context.Context, param model.PurchaseOrder) (*model.PurchaseOrder, common.Error) {
+	branch := interface{}(param.BranchID)
+	if param.BranchID == 0 {
+		branch = sq.Expr(repoMainBranchID)
+	}
+	insert := map[string]interface{}{
+		repoColumnPurchaseOrder.VendorID:  param.VendorID,
+		repoColumnPurchaseOrder.BranchID:  branch,
+		repoColumnPurchaseOrder.Reference: param.Reference,
+		repoColumnPurchaseOrder.Notes:     param.Notes,
+		repoColumnPurchaseOrder.Status:    param.Status,
+		repoColumnPurchaseOrder.CreatedBy: param.CreatedBy,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTablePurchaseOrder).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnPurchaseOrder.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoPurchaseOrder
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	order := row.toModel()
+	return &order, nil
+}
+
+// GetPurchaseOrderByID returns an order with its lines.
+func (r *PostgresRepository) GetPurchaseOrderByID(ctx context.Context, id int) (*model.PurchaseOrder, common.Error) {
+	return r.getPurchaseOrder(ctx, r.db, id, false)
+}
+
+func (r *PostgresRepository) getPurchaseOrder(ctx context.Context, db sqlContextGetter, id int, forUpdate bool) (*model.PurchaseOrder, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnPurchaseOrder.columns()).
+		From(repoTablePurchaseOrder).
+		Where(sq.Eq{repoColumnPurchaseOrder.ID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoPurchaseOrder
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("purchase order not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	order := row.toModel()
+	lines, cErr := r.listPurchaseOrderLines(ctx, db, []int{id})
+	if cErr != nil {
+		return nil, cErr
+	}
+	order.Lines = lines
+	return &order, nil
+}
+
+// ListPurchaseOrders returns orders with their lines, newest first. A zero
+// vendor ID or an empty status matches any.
+func (r *PostgresRepository) ListPurchaseOrders(ctx context.Context, vendorID int, status model.PurchaseOrderStatus) ([]*model.PurchaseOrder, common.Error) {
+	where := sq.And{}
+	if vendorID != 0 {
+		where = append(where, sq.Eq{repoColumnPurchaseOrder.VendorID: vendorID})
+	}
+	if status != "" {
+		where = append(where, sq.Eq{repoColumnPurchaseOrder.Status: status})
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnPurchaseOrder.columns()).
+		From(repoTablePurchaseOrder).
+		Where(where).
+		OrderBy(repoColumnPurchaseOrder.CreatedAt+" DESC", repoColumnPurchaseOrder.ID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoPurchaseOrder
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	lines, cErr := r.listPurchaseOrderLines(ctx, r.db, ids)
+	if cErr != nil {
+		return nil, cErr
+	}
+	linesByOrder := map[int][]*model.PurchaseOrderLine{}
+	for _, l := range lines {
+		linesByOrder[l.OrderID] = append(linesByOrder[l.OrderID], l)
+	}
+
+	orders := make([]*model.PurchaseOrder, 0, len(rows))
+	for _, row := range rows {
+		order := row.toModel()
+		order.Lines = linesByOrder[order.ID]
+		orders = append(orders, &order)
+	}
+	return orders, nil
+}
+
+func (r *PostgresRepository) listPurchaseOrderLines(ctx context.Context, db sqlContextGetter, orderIDs []int) ([]*model.PurchaseOrderLine, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnPurchaseOrderLine.columns()).
+		From(repoTablePurchaseOrderLine).
+		Where(sq.Eq{repoColumnPurchaseOrderLine.OrderID: orderIDs}).
+		OrderBy(repoColumnPurchaseOrderLine.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoPurchaseOrderLine
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var lines []*model.PurchaseOrderLine
+	for _, row := range rows {
+		line := model.PurchaseOrderLine(row)
+		lines = append(lines, &line)
+	}
+	return lines, nil
+}
+
+// AddPurchaseOrderLine adds a line to a draft order.
+func (r *PostgresRepository) AddPurchaseOrderLine(ctx context.Context, orderID int, param model.PurchaseOrderLine) (*model.PurchaseOrder, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := r.addPurchaseOrderLine(ctx, tx, orderID, param)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (r *PostgresRepository) addPurchaseOrderLine(ctx context.Context, db sqlContextGetter, orderID int, param model.PurchaseOrderLine) (*model.PurchaseOrder, common.Error) {
+	order, cErr := r.getPurchaseOrder(ctx, db, orderID, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if order.Status != model.PurchaseOrderDraft {
+		return nil, invalidPurchaseOrderStatus(order, "changed")
+	}
+
+	insert := map[string]interface{}{
+		repoColumnPurchaseOrderLine.OrderID:        orderID,
+		repoColumnPurchaseOrderLine.BookID:         param.BookID,
+		repoColumnPurchaseOrderLine.FundID:         param.FundID,
+		repoColumnPurchaseOrderLine.Quantity:       param.Quantity,
+		repoColumnPurchaseOrderLine.UnitPriceCents: param.UnitPriceCents,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTablePurchaseOrderLine).
+		SetMap(insert).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	return r.getPurchaseOrder(ctx, db, orderID, false)
+}
+
+// RemovePurchaseOrderLine drops a line of a draft order.
+func (r *PostgresRepository) RemovePurchaseOrderLine(ctx context.Context, orderID, lineID int) (*model.PurchaseOrder, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := r.removePurchaseOrderLine(ctx, tx, orderID, lineID)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (r *PostgresRepository) removePurchaseOrderLine(ctx context.Context, db sqlContextGetter, orderID, lineID int) (*model.PurchaseOrder, common.Error) {
+	order, cErr := r.getPurchaseOrder(ctx, db, orderID, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if order.Status != model.PurchaseOrderDraft {
+		return nil, invalidPurchaseOrderStatus(order, "changed")
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Delete(repoTablePurchaseOrderLine).
+		Where(sq.Eq{
+			repoColumnPurchaseOrderLine.ID:      lineID,
+			repoColumnPurchaseOrderLine.OrderID: orderID,
+		}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	} else if n == 0 {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("purchase order line not found"))
+	}
+
+	return r.getPurchaseOrder(ctx, db, orderID, false)
+}
+
+// PlacePurchaseOrder sends a draft order to its vendor. Its lines encumber
+// their funds, which must have the money left.
+func (r *PostgresRepository) PlacePurchaseOrder(ctx context.Context, id int) (*model.PurchaseOrder, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := r.placePurchaseOrder(ctx, tx, id)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (r *PostgresRepository) placePurchaseOrder(ctx context.Context, db sqlContextGetter, id int) (*model.PurchaseOrder, common.Error) {
+	order, cErr := r.getPurchaseOrder(ctx, db, id, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidatePurchaseOrderPlacement(*order); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	commitments := order.CommitmentsByFund()
+	fundIDs := make([]int, 0, len(commitments))
+	for fundID := range commitments {
+		fundIDs = append(fundIDs, fundID)
+	}
+	funds, cErr := r.lockFunds(ctx, db, fundIDs)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidateFundCommitments(funds, commitments); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	return r.updatePurchaseOrderStatus(ctx, db, id, model.PurchaseOrderOrdered, repoColumnPurchaseOrder.OrderedAt)
+}
+
+// ReceivePurchaseOrderLine adds the copies delivered for an order line. The
+// copies are shelved at the branch of the order and priced at the unit price
+// of the line, and the order is received once every line is.
+func (r *PostgresRepository) ReceivePurchaseOrderLine(ctx context.Context, orderID, lineID int, copies []model.BookCopies, receivedBy string) ([]*model.BookCopies, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.receivePurchaseOrderLine(ctx, tx, orderID, lineID, copies, receivedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *PostgresRepository) receivePurchaseOrderLine(ctx context.Context, db sqlContextGetter, orderID, lineID int, copies []model.BookCopies, receivedBy string) ([]*model.BookCopies, common.Error) {
+	order, cErr := r.getPurchaseOrder(ctx, db, orderID, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	var line *model.PurchaseOrderLine
+	for _, l := range order.Lines {
+		if l.ID == lineID {
+			line = l
+		}
+	}
+	if line == nil {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows, common.WithMsg("purchase order line not found"))
+	}
+	if err := model.ValidateReceipt(*order, *line, len(copies)); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	created := make([]*model.BookCopies, 0, len(copies))
+	for _, c := range copies {
+		c.BookID = line.BookID
+		c.HomeBranchID = order.BranchID
+		c.Status = model.InLibrary
+		c.PriceCents = &line.UnitPriceCents
+		bookCopy, cErr := r.createBookCopy(ctx, db, c, receivedBy)
+		if cErr != nil {
+			return nil, cErr
+		}
+		created = append(created, bookCopy)
+
+		// build SQL query
+		query, args, err := r.pgsq.Insert(repoTablePurchaseOrderReceipt).
+			SetMap(map[string]interface{}{
+				"line_id":     lineID,
+				"copy_id":     bookCopy.ID,
+				"received_by": receivedBy,
+			}).
+			ToSql()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+
+		// execute SQL query
+		if _, err = db.ExecContext(ctx, query, args...); err != nil {
+			return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+		}
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTablePurchaseOrderLine).
+		SetMap(map[string]interface{}{
+			repoColumnPurchaseOrderLine.ReceivedQuantity: sq.Expr(repoColumnPurchaseOrderLine.ReceivedQuantity+" + ?", len(copies)),
+			repoColumnPurchaseOrderLine.UpdatedAt:        sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnPurchaseOrderLine.ID: lineID}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	line.ReceivedQuantity += len(copies)
+	status := order.ReceivedStatus()
+	stampColumn := ""
+	if status == model.PurchaseOrderReceived {
+		stampColumn = repoColumnPurchaseOrder.ClosedAt
+	}
+	if _, cErr := r.updatePurchaseOrderStatus(ctx, db, orderID, status, stampColumn); cErr != nil {
+		return nil, cErr
+	}
+	return created, nil
+}
+
+// CancelPurchaseOrder drops an order which is not fully received. The copies
+// still expected stop encumbering their funds; those already received stay
+// spent.
+func (r *PostgresRepository) CancelPurchaseOrder(ctx context.Context, id int) (*model.PurchaseOrder, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := r.cancelPurchaseOrder(ctx, tx, id)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (r *PostgresRepository) cancelPurchaseOrder(ctx context.Context, db sqlContextGetter, id int) (*model.PurchaseOrder, common.Error) {
+	order, err := r.getPurchaseOrder(ctx, db, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.PurchaseOrderDraft && !order.Status.IsOpen() {
+		return nil, invalidPurchaseOrderStatus(order, "cancelled")
+	}
+
+	return r.updatePurchaseOrderStatus(ctx, db, id, model.PurchaseOrderCancelled, repoColumnPurchaseOrder.ClosedAt)
+}
+
+// updatePurchaseOrderStatus moves an order to a status, stamping the given
+// time column if any, and returns it with its lines.
+func (r *PostgresRepository) updatePurchaseOrderStatus(ctx context.Context, db sqlContextGetter, id int, status model.PurchaseOrderStatus, stampColumn string) (*model.PurchaseOrder, common.Error) {
+	update := map[string]interface{}{
+		repoColumnPurchaseOrder.Status:    status,
+		repoColumnPurchaseOrder.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+	}
+	if stampColumn != "" {
+		update[stampColumn] = sq.Expr("CURRENT_TIMESTAMP")
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTablePurchaseOrder).
+		SetMap(update).
+		Where(sq.Eq{repoColumnPurchaseOrder.ID: id}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	return r.getPurchaseOrder(ctx, db, id, false)
+}
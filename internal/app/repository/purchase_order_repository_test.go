@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initPurchaseOrderRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataVendor),
+		testdata.Path(testdata.TestDataFund),
+	)
+}
+
+func addTestPurchaseOrderLine(t *testing.T, repo *PostgresRepository, orderID, bookID, fundID, quantity int, unitPriceCents int64) *model.PurchaseOrder {
+	line, lineErr := model.NewPurchaseOrderLine(bookID, fundID, quantity, unitPriceCents)
+	require.NoError(t, lineErr)
+	order, err := repo.AddPurchaseOrderLine(context.Background(), orderID, line)
+	require.NoError(t, err)
+	return order
+}
+
+func TestPurchaseOrderRepository_Lifecycle(t *testing.T) {
+	repo := initPurchaseOrderRepository(t)
+
+	order, err := repo.CreatePurchaseOrder(context.Background(), model.NewPurchaseOrder(1, 0, "PO-1", "", "librarian"))
+	require.NoError(t, err)
+	assert.Equal(t, model.PurchaseOrderDraft, order.Status)
+	assert.Equal(t, 1, order.BranchID)
+
+	addTestPurchaseOrderLine(t, repo, order.ID, 3, 1, 2, 1500)
+	order = addTestPurchaseOrderLine(t, repo, order.ID, 1, 2, 1, 2000)
+	require.Len(t, order.Lines, 2)
+	assert.Equal(t, int64(5000), order.TotalCents())
+
+	// drafts encumber nothing
+	fund, err := repo.GetFundByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), fund.EncumberedCents)
+
+	order, err = repo.PlacePurchaseOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PurchaseOrderOrdered, order.Status)
+	assert.NotNil(t, order.OrderedAt)
+
+	fund, err = repo.GetFundByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), fund.EncumberedCents)
+
+	// placed orders are not changed
+	_, err = repo.RemovePurchaseOrderLine(context.Background(), order.ID, order.Lines[0].ID)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	copies, err := repo.ReceivePurchaseOrderLine(context.Background(), order.ID, order.Lines[0].ID, []model.BookCopies{
+		model.NewBookCopies(0, "30000000000046", model.InLibrary),
+	}, "receiving desk")
+	require.NoError(t, err)
+	require.Len(t, copies, 1)
+	assert.Equal(t, 3, copies[0].BookID)
+	assert.Equal(t, model.InLibrary, copies[0].Status)
+
+	order, err = repo.GetPurchaseOrderByID(context.Background(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PurchaseOrderPartiallyReceived, order.Status)
+	assert.Equal(t, 1, order.Lines[0].ReceivedQuantity)
+
+	fund, err = repo.GetFundByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), fund.EncumberedCents)
+	assert.Equal(t, int64(1500), fund.SpentCents)
+
+	// more copies than ordered are refused
+	_, err = repo.ReceivePurchaseOrderLine(context.Background(), order.ID, order.Lines[1].ID, []model.BookCopies{
+		model.NewBookCopies(0, "30000000000053", model.InLibrary),
+		model.NewBookCopies(0, "30000000000061", model.InLibrary),
+	}, "receiving desk")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	// cancelling releases what is still expected, what was received stays spent
+	order, err = repo.CancelPurchaseOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PurchaseOrderCancelled, order.Status)
+	assert.NotNil(t, order.ClosedAt)
+
+	fund, err = repo.GetFundByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), fund.EncumberedCents)
+	assert.Equal(t, int64(1500), fund.SpentCents)
+}
+
+func TestPurchaseOrderRepository_ReceiveAll(t *testing.T) {
+	repo := initPurchaseOrderRepository(t)
+
+	order, err := repo.CreatePurchaseOrder(context.Background(), model.NewPurchaseOrder(2, 2, "", "", "librarian"))
+	require.NoError(t, err)
+	order = addTestPurchaseOrderLine(t, repo, order.ID, 3, 1, 1, 1000)
+	_, err = repo.PlacePurchaseOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+
+	copies, err := repo.ReceivePurchaseOrderLine(context.Background(), order.ID, order.Lines[0].ID, []model.BookCopies{
+		model.NewBookCopies(0, "30000000000046", model.InLibrary),
+	}, "east desk")
+	require.NoError(t, err)
+	assert.Equal(t, 2, copies[0].HomeBranchID)
+	assert.Equal(t, 2, copies[0].CurrentBranchID)
+
+	order, err = repo.GetPurchaseOrderByID(context.Background(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PurchaseOrderReceived, order.Status)
+	assert.NotNil(t, order.ClosedAt)
+
+	_, err = repo.CancelPurchaseOrder(context.Background(), order.ID)
+	require.Error(t, err)
+}
+
+func TestPurchaseOrderRepository_PlaceOverBudget(t *testing.T) {
+	repo := initPurchaseOrderRepository(t)
+
+	order, err := repo.CreatePurchaseOrder(context.Background(), model.NewPurchaseOrder(1, 0, "", "", "librarian"))
+	require.NoError(t, err)
+
+	// an empty order cannot be placed
+	_, err = repo.PlacePurchaseOrder(context.Background(), order.ID)
+	require.Error(t, err)
+
+	// fund 2 has 5000 left
+	addTestPurchaseOrderLine(t, repo, order.ID, 3, 2, 3, 2000)
+	_, err = repo.PlacePurchaseOrder(context.Background(), order.ID)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	order, err = repo.GetPurchaseOrderByID(context.Background(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PurchaseOrderDraft, order.Status)
+
+	order, err = repo.RemovePurchaseOrderLine(context.Background(), order.ID, order.Lines[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, order.Lines)
+}
+
+func TestPurchaseOrderRepository_ListPurchaseOrders(t *testing.T) {
+	repo := initPurchaseOrderRepository(t)
+
+	_, err := repo.CreatePurchaseOrder(context.Background(), model.NewPurchaseOrder(1, 0, "", "", "librarian"))
+	require.NoError(t, err)
+	second, err := repo.CreatePurchaseOrder(context.Background(), model.NewPurchaseOrder(2, 0, "", "", "librarian"))
+	require.NoError(t, err)
+
+	orders, err := repo.ListPurchaseOrders(context.Background(), 0, "")
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, second.ID, orders[0].ID)
+
+	orders, err = repo.ListPurchaseOrders(context.Background(), 2, model.PurchaseOrderDraft)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+
+	orders, err = repo.ListPurchaseOrders(context.Background(), 0, model.PurchaseOrderOrdered)
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+}
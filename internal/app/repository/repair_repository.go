@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoRepair struct {
+	ID           int        `db:"id"`
+	CopyID       int        `db:"copy_id"`
+	VendorID     int        `db:"vendor_id"`
+	ReportID     *int       `db:"report_id"`
+	Note         string     `db:"note"`
+	SentBy       string     `db:"sent_by"`
+	ExpectedBack time.Time  `db:"expected_back"`
+	ReturnedAt   *time.Time `db:"returned_at"`
+	ReturnedBy   string     `db:"returned_by"`
+	Repaired     *bool      `db:"repaired"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
+}
+
+type repoColumnPatternRepair struct {
+	ID           string
+	CopyID       string
+	VendorID     string
+	ReportID     string
+	Note         string
+	SentBy       string
+	ExpectedBack string
+	ReturnedAt   string
+	ReturnedBy   string
+	Repaired     string
+	CreatedAt    string
+	UpdatedAt    string
+}
+
+const repoTableRepair = "copy_repairs"
+
+var repoColumnRepair = repoColumnPatternRepair{
+	ID:           "id",
+	CopyID:       "copy_id",
+	VendorID:     "vendor_id",
+	ReportID:     "report_id",
+	Note:         "note",
+	SentBy:       "sent_by",
+	ExpectedBack: "expected_back",
+	ReturnedAt:   "returned_at",
+	ReturnedBy:   "returned_by",
+	Repaired:     "repaired",
+	CreatedAt:    "created_at",
+	UpdatedAt:    "updated_at",
+}
+
+func (c *repoColumnPatternRepair) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.CopyID,
+		c.VendorID,
+		c.ReportID,
+		c.Note,
+		c.SentBy,
+		c.ExpectedBack,
+		c.ReturnedAt,
+		c.ReturnedBy,
+		c.Repaired,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+// SendCopyToRepair sends a damaged copy to a repair vendor. The copy is
+// InRepair until it comes back.
+func (r *PostgresRepository) SendCopyToRepair(ctx context.Context, param model.Repair) (*model.Repair, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	repair, err := r.sendCopyToRepair(ctx, tx, param)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return repair, nil
+}
+
+func (r *PostgresRepository) sendCopyToRepair(ctx context.Context, db sqlContextGetter, param model.Repair) (*model.Repair, common.Error) {
+	if err := model.ValidateRepair(param, time.Now()); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	reason := "sent to repair"
+	if param.Note != "" {
+		reason += ": " + param.Note
+	}
+	if _, cErr := r.changeBookCopyStatus(ctx, db, param.CopyID, model.InRepair, param.SentBy, reason); cErr != nil {
+		return nil, cErr
+	}
+
+	insert := map[string]interface{}{
+		repoColumnRepair.CopyID:       param.CopyID,
+		repoColumnRepair.VendorID:     param.VendorID,
+		repoColumnRepair.ReportID:     param.ReportID,
+		repoColumnRepair.Note:         param.Note,
+		repoColumnRepair.SentBy:       param.SentBy,
+		repoColumnRepair.ExpectedBack: param.ExpectedBack,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableRepair).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnRepair.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoRepair
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("copy %d is already at repair", param.CopyID)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	repair := model.Repair(row)
+	return &repair, nil
+}
+
+// ReturnCopyFromRepair closes a repair. A repaired copy goes back on the
+// shelf; one the vendor could not repair is Damaged again, for staff to
+// withdraw or send elsewhere.
+func (r *PostgresRepository) ReturnCopyFromRepair(ctx context.Context, id int, repaired bool, returnedBy string) (*model.Repair, *model.BookCopies, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repair, bookCopy, err := r.returnCopyFromRepair(ctx, tx, id, repaired, returnedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, nil, err
+	}
+
+	return repair, bookCopy, nil
+}
+
+func (r *PostgresRepository) returnCopyFromRepair(ctx context.Context, db sqlContextGetter, id int, repaired bool, returnedBy string) (*model.Repair, *model.BookCopies, common.Error) {
+	repair, cErr := r.getRepair(ctx, db, id, true)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+	if err := model.ValidateRepairReturn(*repair); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	to, reason := model.InLibrary, "back from repair"
+	if !repaired {
+		to, reason = model.Damaged, "back from repair unrepaired"
+	}
+	bookCopy, cErr := r.changeBookCopyStatus(ctx, db, repair.CopyID, to, returnedBy, reason)
+	if cErr != nil {
+		return nil, nil, cErr
+	}
+
+	update := map[string]interface{}{
+		repoColumnRepair.ReturnedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		repoColumnRepair.ReturnedBy: returnedBy,
+		repoColumnRepair.Repaired:   repaired,
+		repoColumnRepair.UpdatedAt:  sq.Expr("CURRENT_TIMESTAMP"),
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableRepair).
+		SetMap(update).
+		Where(sq.Eq{repoColumnRepair.ID: id}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnRepair.columns())).
+		ToSql()
+	if err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoRepair
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	returned := model.Repair(row)
+	return &returned, bookCopy, nil
+}
+
+func (r *PostgresRepository) GetRepairByID(ctx context.Context, id int) (*model.Repair, common.Error) {
+	return r.getRepair(ctx, r.db, id, false)
+}
+
+func (r *PostgresRepository) getRepair(ctx context.Context, db sqlContextGetter, id int, forUpdate bool) (*model.Repair, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnRepair.columns()).
+		From(repoTableRepair).
+		Where(sq.Eq{repoColumnRepair.ID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoRepair
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("repair not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	repair := model.Repair(row)
+	return &repair, nil
+}
+
+// ListRepairs returns the repairs matching a filter, those expected back
+// first.
+func (r *PostgresRepository) ListRepairs(ctx context.Context, filter model.RepairFilter) ([]*model.Repair, common.Error) {
+	where := sq.And{}
+	if filter.CopyID != 0 {
+		where = append(where, sq.Eq{repoColumnRepair.CopyID: filter.CopyID})
+	}
+	if filter.VendorID != 0 {
+		where = append(where, sq.Eq{repoColumnRepair.VendorID: filter.VendorID})
+	}
+	if filter.OpenOnly {
+		where = append(where, sq.Eq{repoColumnRepair.ReturnedAt: nil})
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnRepair.columns()).
+		From(repoTableRepair).
+		Where(where).
+		OrderBy(repoColumnRepair.ExpectedBack, repoColumnRepair.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoRepair
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	repairs := make([]*model.Repair, 0, len(rows))
+	for _, row := range rows {
+		repair := model.Repair(row)
+		repairs = append(repairs, &repair)
+	}
+	return repairs, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairRepository_SendAndReturn(t *testing.T) {
+	repo := initConditionRepository(t)
+	expectedBack := time.Now().UTC().AddDate(0, 0, 14).Truncate(24 * time.Hour)
+
+	// only damaged copies go to repair
+	_, err := repo.SendCopyToRepair(context.Background(), model.NewRepair(2, 2, nil, expectedBack, "rebind", "desk"))
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	_, err = repo.ChangeBookCopyStatus(context.Background(), 2, model.Damaged, "desk", "spine broken")
+	require.NoError(t, err)
+
+	reportID := 1
+	repair, err := repo.SendCopyToRepair(context.Background(), model.NewRepair(2, 2, &reportID, expectedBack, "rebind", "desk"))
+	require.NoError(t, err)
+	assert.True(t, repair.IsOpen())
+	assert.Equal(t, expectedBack.Format("2006-01-02"), repair.ExpectedBack.Format("2006-01-02"))
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.InRepair, bookCopy.Status)
+
+	open, err := repo.ListRepairs(context.Background(), model.RepairFilter{VendorID: 2, OpenOnly: true})
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, repair.ID, open[0].ID)
+
+	all, err := repo.ListRepairs(context.Background(), model.RepairFilter{CopyID: 2})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	returned, bookCopy, err := repo.ReturnCopyFromRepair(context.Background(), repair.ID, true, "desk")
+	require.NoError(t, err)
+	assert.False(t, returned.IsOpen())
+	assert.True(t, *returned.Repaired)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+
+	_, _, err = repo.ReturnCopyFromRepair(context.Background(), repair.ID, true, "desk")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+}
+
+func TestRepairRepository_ReturnUnrepaired(t *testing.T) {
+	repo := initConditionRepository(t)
+	expectedBack := time.Now().UTC().AddDate(0, 0, 7).Truncate(24 * time.Hour)
+
+	_, err := repo.ChangeBookCopyStatus(context.Background(), 2, model.Damaged, "desk", "water damage")
+	require.NoError(t, err)
+	repair, err := repo.SendCopyToRepair(context.Background(), model.NewRepair(2, 2, nil, expectedBack, "", "desk"))
+	require.NoError(t, err)
+
+	_, bookCopy, err := repo.ReturnCopyFromRepair(context.Background(), repair.ID, false, "desk")
+	require.NoError(t, err)
+	assert.Equal(t, model.Damaged, bookCopy.Status)
+
+	_, err = repo.GetRepairByID(context.Background(), 99)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoShelfItem struct {
+	CopyID     int                        `db:"copy_id"`
+	BookID     int                        `db:"book_id"`
+	Title      string                     `db:"title"`
+	Author     string                     `db:"author"`
+	Scheme     model.ClassificationScheme `db:"scheme"`
+	CallNumber string                     `db:"call_number"`
+	SortKey    string                     `db:"sort_key"`
+}
+
+type repoColumnPatternShelfItem struct {
+	CopyID     string
+	BookID     string
+	Title      string
+	Author     string
+	Scheme     string
+	CallNumber string
+	SortKey    string
+}
+
+// repoTableShelf lists every shelved copy with its effective call number: a
+// copy's own call number wins over the one of its book.
+const repoTableShelf = `(
+	SELECT bc.id AS copy_id, b.id AS book_id, b.title, b.author, b.classification AS scheme,
+		COALESCE(bc.call_number, b.call_number) AS call_number,
+		COALESCE(bc.call_number_sort, b.call_number_sort) AS sort_key
+	FROM book_copies bc
+	JOIN books b ON b.id = bc.book_id
+	WHERE b.classification IS NOT NULL
+		AND COALESCE(bc.call_number_sort, b.call_number_sort) IS NOT NULL
+		AND bc.status <> 'Lost'
+		AND bc.retired_at IS NULL
+) shelf`
+
+var repoColumnShelfItem = repoColumnPatternShelfItem{
+	CopyID:     "copy_id",
+	BookID:     "book_id",
+	Title:      "title",
+	Author:     "author",
+	Scheme:     "scheme",
+	CallNumber: "call_number",
+	SortKey:    "sort_key",
+}
+
+func (c *repoColumnPatternShelfItem) columns() string {
+	return strings.Join([]string{
+		c.CopyID,
+		c.BookID,
+		c.Title,
+		c.Author,
+		c.Scheme,
+		c.CallNumber,
+		c.SortKey,
+	}, ", ")
+}
+
+// SetCopyCallNumber overrides the call number of a single copy, or falls back
+// to the book's call number when cn is nil.
+func (r *PostgresRepository) SetCopyCallNumber(ctx context.Context, copyID int, cn *model.CallNumber) common.Error {
+	update := map[string]interface{}{
+		"call_number":      nil,
+		"call_number_sort": nil,
+		"updated_at":       sq.Expr("CURRENT_TIMESTAMP"),
+	}
+	if cn != nil {
+		update["call_number"] = cn.Raw
+		update["call_number_sort"] = cn.SortKey
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update("book_copies").
+		SetMap(update).
+		Where(sq.Eq{"id": copyID}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) GetShelfItemByCopyID(ctx context.Context, copyID int) (*model.ShelfItem, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnShelfItem.columns()).
+		From(repoTableShelf).
+		Where(sq.Eq{repoColumnShelfItem.CopyID: copyID}).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoShelfItem
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("copy is not shelved under a call number"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	item := model.ShelfItem(row)
+	return &item, nil
+}
+
+// ListShelfItemsBefore returns up to limit items shelved right before the
+// position (sortKey, copyID), nearest first.
+func (r *PostgresRepository) ListShelfItemsBefore(ctx context.Context, scheme model.ClassificationScheme, sortKey string, copyID int, limit int) ([]*model.ShelfItem, common.Error) {
+	return r.listShelfItems(ctx, scheme, sq.Expr(`(sort_key COLLATE "C", copy_id) < (? COLLATE "C", ?)`, sortKey, copyID),
+		`sort_key COLLATE "C" DESC, copy_id DESC`, limit)
+}
+
+// ListShelfItemsAfter returns up to limit items shelved right after the
+// position (sortKey, copyID), nearest first.
+func (r *PostgresRepository) ListShelfItemsAfter(ctx context.Context, scheme model.ClassificationScheme, sortKey string, copyID int, limit int) ([]*model.ShelfItem, common.Error) {
+	return r.listShelfItems(ctx, scheme, sq.Expr(`(sort_key COLLATE "C", copy_id) > (? COLLATE "C", ?)`, sortKey, copyID),
+		`sort_key COLLATE "C" ASC, copy_id ASC`, limit)
+}
+
+func (r *PostgresRepository) listShelfItems(ctx context.Context, scheme model.ClassificationScheme, position sq.Sqlizer, orderBy string, limit int) ([]*model.ShelfItem, common.Error) {
+	where := sq.And{
+		sq.Eq{repoColumnShelfItem.Scheme: scheme},
+		position,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnShelfItem.columns()).
+		From(repoTableShelf).
+		Where(where).
+		OrderBy(orderBy).
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoShelfItem
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var items []*model.ShelfItem
+	for _, row := range rows {
+		item := model.ShelfItem(row)
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
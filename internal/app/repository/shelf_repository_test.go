@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initShelfRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+	)
+}
+
+func TestShelfRepository_ListShelfItems(t *testing.T) {
+	repo := initShelfRepository(t)
+
+	anchor, err := repo.GetShelfItemByCopyID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "863.3 CER", anchor.CallNumber)
+
+	// 843.912 shelves before 863.3, and copy 1 shares the call number with a lower ID
+	before, err := repo.ListShelfItemsBefore(context.Background(), model.ClassificationDewey, anchor.SortKey, anchor.CopyID, 5)
+	require.NoError(t, err)
+	require.Len(t, before, 2)
+	assert.Equal(t, 1, before[0].CopyID)
+	assert.Equal(t, 3, before[1].CopyID)
+
+	after, err := repo.ListShelfItemsAfter(context.Background(), model.ClassificationDewey, anchor.SortKey, anchor.CopyID, 5)
+	require.NoError(t, err)
+	assert.Empty(t, after)
+}
+
+func TestShelfRepository_SetCopyCallNumber(t *testing.T) {
+	repo := initShelfRepository(t)
+
+	cn, parseErr := model.ParseCallNumber(model.ClassificationDewey, "999 ZZZ")
+	require.NoError(t, parseErr)
+
+	err := repo.SetCopyCallNumber(context.Background(), 1, &cn)
+	require.NoError(t, err)
+
+	item, err := repo.GetShelfItemByCopyID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "999 ZZZ", item.CallNumber)
+
+	// clearing the override falls back to the book's call number
+	err = repo.SetCopyCallNumber(context.Background(), 1, nil)
+	require.NoError(t, err)
+
+	item, err = repo.GetShelfItemByCopyID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "863.3 CER", item.CallNumber)
+}
@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoManualBlock struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	Reason    string     `db:"reason"`
+	BlockedBy string     `db:"blocked_by"`
+	LiftedAt  *time.Time `db:"lifted_at"`
+	LiftedBy  string     `db:"lifted_by"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+}
+
+type repoColumnPatternManualBlock struct {
+	ID        string
+	UserID    string
+	Reason    string
+	BlockedBy string
+	LiftedAt  string
+	LiftedBy  string
+	CreatedAt string
+	UpdatedAt string
+}
+
+const repoTableManualBlock = "patron_blocks"
+
+var repoColumnManualBlock = repoColumnPatternManualBlock{
+	ID:        "id",
+	UserID:    "user_id",
+	Reason:    "reason",
+	BlockedBy: "blocked_by",
+	LiftedAt:  "lifted_at",
+	LiftedBy:  "lifted_by",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (c *repoColumnPatternManualBlock) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.UserID,
+		c.Reason,
+		c.BlockedBy,
+		c.LiftedAt,
+		c.LiftedBy,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (r *PostgresRepository) CreateManualBlock(ctx context.Context, param model.ManualBlock) (*model.ManualBlock, common.Error) {
+	if err := model.ValidateManualBlock(param); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	insert := map[string]interface{}{
+		repoColumnManualBlock.UserID:    param.UserID,
+		repoColumnManualBlock.Reason:    param.Reason,
+		repoColumnManualBlock.BlockedBy: param.BlockedBy,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableManualBlock).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnManualBlock.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoManualBlock
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	block := model.ManualBlock(row)
+	return &block, nil
+}
+
+func (r *PostgresRepository) GetManualBlockByID(ctx context.Context, id int) (*model.ManualBlock, common.Error) {
+	return r.getManualBlock(ctx, r.db, id, false)
+}
+
+func (r *PostgresRepository) getManualBlock(ctx context.Context, db sqlContextGetter, id int, forUpdate bool) (*model.ManualBlock, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnManualBlock.columns()).
+		From(repoTableManualBlock).
+		Where(sq.Eq{repoColumnManualBlock.ID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoManualBlock
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("block not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	block := model.ManualBlock(row)
+	return &block, nil
+}
+
+// ListManualBlocksByUserID returns the blocks put on an account, the latest
+// first. With activeOnly, lifted blocks are left out.
+func (r *PostgresRepository) ListManualBlocksByUserID(ctx context.Context, userID int, activeOnly bool) ([]*model.ManualBlock, common.Error) {
+	return r.listManualBlocks(ctx, r.db, userID, activeOnly)
+}
+
+func (r *PostgresRepository) listManualBlocks(ctx context.Context, db sqlContextGetter, userID int, activeOnly bool) ([]*model.ManualBlock, common.Error) {
+	where := sq.And{sq.Eq{repoColumnManualBlock.UserID: userID}}
+	if activeOnly {
+		where = append(where, sq.Eq{repoColumnManualBlock.LiftedAt: nil})
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnManualBlock.columns()).
+		From(repoTableManualBlock).
+		Where(where).
+		OrderBy(repoColumnManualBlock.CreatedAt+" DESC", repoColumnManualBlock.ID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoManualBlock
+	if err = db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	blocks := make([]*model.ManualBlock, 0, len(rows))
+	for _, row := range rows {
+		block := model.ManualBlock(row)
+		blocks = append(blocks, &block)
+	}
+	return blocks, nil
+}
+
+// LiftManualBlock lifts a block staff put on an account.
+func (r *PostgresRepository) LiftManualBlock(ctx context.Context, id int, liftedBy string) (*model.ManualBlock, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := r.liftManualBlock(ctx, tx, id, liftedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+func (r *PostgresRepository) liftManualBlock(ctx context.Context, db sqlContextGetter, id int, liftedBy string) (*model.ManualBlock, common.Error) {
+	block, cErr := r.getManualBlock(ctx, db, id, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidateBlockLift(*block); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableManualBlock).
+		SetMap(map[string]interface{}{
+			repoColumnManualBlock.LiftedAt:  sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnManualBlock.LiftedBy:  liftedBy,
+			repoColumnManualBlock.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnManualBlock.ID: id}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnManualBlock.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoManualBlock
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	lifted := model.ManualBlock(row)
+	return &lifted, nil
+}
+
+// GetAccountFacts gathers what the standing of an account is judged on at a
+// time.
+func (r *PostgresRepository) GetAccountFacts(ctx context.Context, userID int, now time.Time) (*model.AccountFacts, common.Error) {
+	return r.getAccountFacts(ctx, r.db, userID, now, false)
+}
+
+// getAccountFacts gathers the facts of an account. With forUpdate, the user
+// is locked so that requests of the same patron are judged one at a time
+// until the transaction ends.
+func (r *PostgresRepository) getAccountFacts(ctx context.Context, db sqlContextGetter, userID int, now time.Time, forUpdate bool) (*model.AccountFacts, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnUser.MembershipExpiresAt).
+		From(repoTableUser).
+		Where(sq.Eq{repoColumnUser.ID: userID})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	facts := model.AccountFacts{UserID: userID}
+	if err = db.GetContext(ctx, &facts.MembershipExpiresAt, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	// build SQL query
+	query, args, err = r.pgsq.Select("COALESCE(SUM(" + repoColumnCharge.AmountCents + "), 0)").
+		From(repoTableCharge).
+		Where(sq.Eq{
+			repoColumnCharge.UserID:     userID,
+			repoColumnCharge.ReversedAt: nil,
+			repoColumnCharge.PaidAt:     nil,
+		}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if err = db.GetContext(ctx, &facts.OutstandingCents, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	// build SQL query
+	// copies declared lost are billed instead, so they no longer count as overdue
+	query, args, err = r.pgsq.Select("COUNT(*)").
+		From(repoTableBorrowedBook).
+		Where(sq.And{
+			sq.Eq{repoColumnBorrowedBook.UserID: userID},
+			sq.Eq{repoColumnBorrowedBook.ReturnDate: nil},
+			sq.Eq{repoColumnBorrowedBook.LostAt: nil},
+			sq.Lt{repoColumnBorrowedBook.DueDate: now},
+		}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if err = db.GetContext(ctx, &facts.OverdueLoans, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	blocks, cErr := r.listManualBlocks(ctx, db, userID, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	for _, b := range blocks {
+		facts.ManualBlocks = append(facts.ManualBlocks, *b)
+	}
+	return &facts, nil
+}
+
+// checkAccountStanding locks a user and refuses them service while their
+// account is blocked.
+func (r *PostgresRepository) checkAccountStanding(ctx context.Context, db sqlContextGetter, userID int, policy model.StandingPolicy, now time.Time) common.Error {
+	facts, cErr := r.getAccountFacts(ctx, db, userID, now, true)
+	if cErr != nil {
+		return cErr
+	}
+	standing := policy.Assess(*facts, now)
+	if standing.IsBlocked() {
+		return blockedError(standing)
+	}
+	return nil
+}
+
+// blockedError refuses a blocked patron, telling staff why.
+func blockedError(standing model.AccountStanding) common.Error {
+	msg := standing.Explain()
+	return common.NewError(common.ErrorCodeAuthPermissionDenied, errors.New(msg), common.WithMsg(msg),
+		common.WithDetail(map[string]interface{}{"blocks": standing.BlockKinds()}))
+}
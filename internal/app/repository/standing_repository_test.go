@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initStandingRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataBorrowedBook),
+		testdata.Path(testdata.TestDataCharge),
+		testdata.Path(testdata.TestDataManualBlock),
+	)
+}
+
+func TestStandingRepository_GetAccountFacts(t *testing.T) {
+	repo := initStandingRepository(t)
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	facts, err := repo.GetAccountFacts(context.Background(), 1, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), facts.OutstandingCents)
+	assert.Zero(t, facts.OverdueLoans)
+	assert.Nil(t, facts.MembershipExpiresAt)
+	assert.Empty(t, facts.ManualBlocks)
+
+	facts, err = repo.GetAccountFacts(context.Background(), 3, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, facts.OverdueLoans)
+	require.NotNil(t, facts.MembershipExpiresAt)
+	assert.Equal(t, "2023-01-31", facts.MembershipExpiresAt.Format("2006-01-02"))
+	require.Len(t, facts.ManualBlocks, 1)
+	assert.Equal(t, "disputed damage charge", facts.ManualBlocks[0].Reason)
+
+	// loan 5 of user 3 is not due yet
+	facts, err = repo.GetAccountFacts(context.Background(), 3, time.Date(2023, 6, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Zero(t, facts.OverdueLoans)
+
+	_, err = repo.GetAccountFacts(context.Background(), 99, now)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
+
+func TestStandingRepository_GetAccountFacts_LostLoan(t *testing.T) {
+	repo := initStandingRepository(t)
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	policy := model.LostItemPolicy{ProcessingFeeCents: 500, DefaultReplacementCents: 2500}
+
+	before, err := repo.GetAccountFacts(context.Background(), 3, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, before.OverdueLoans)
+
+	// the overdue loan 5 of user 3 is billed once declared lost, not counted as overdue
+	_, _, err = repo.DeclareLoanLost(context.Background(), 3, policy, "desk", "patron reported it lost")
+	require.NoError(t, err)
+
+	facts, err := repo.GetAccountFacts(context.Background(), 3, now)
+	require.NoError(t, err)
+	assert.Zero(t, facts.OverdueLoans)
+	assert.Equal(t, before.OutstandingCents+3000, facts.OutstandingCents)
+}
+
+func TestStandingRepository_CreateLoan_Blocked(t *testing.T) {
+	repo := initStandingRepository(t)
+	now := time.Now()
+
+	_, err := repo.CreateLoan(context.Background(), model.NewBorrowdBook(3, 2, now, now.AddDate(0, 0, 21)), model.StandingPolicy{}, "desk")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeAuthPermissionDenied.Name, err.(common.DomainError).Name())
+	assert.Equal(t, []model.BlockKind{model.BlockMembershipExpired, model.BlockManual}, err.(common.DomainError).Detail()["blocks"])
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+
+	_, err = repo.CreateLoan(context.Background(), model.NewBorrowdBook(1, 2, now, now.AddDate(0, 0, 21)), model.StandingPolicy{MaxOutstandingCents: 200}, "desk")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeAuthPermissionDenied.Name, err.(common.DomainError).Name())
+
+	_, err = repo.CreateLoan(context.Background(), model.NewBorrowdBook(1, 2, now, now.AddDate(0, 0, 21)), model.StandingPolicy{MaxOutstandingCents: 300}, "desk")
+	require.NoError(t, err)
+}
+
+func TestStandingRepository_LiftBlockAndMembership(t *testing.T) {
+	repo := initStandingRepository(t)
+	now := time.Now()
+
+	block, err := repo.CreateManualBlock(context.Background(), model.NewManualBlock(2, "abusive at the desk", "manager"))
+	require.NoError(t, err)
+	assert.True(t, block.IsActive())
+
+	blocks, err := repo.ListManualBlocksByUserID(context.Background(), 3, true)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	lifted, err := repo.LiftManualBlock(context.Background(), blocks[0].ID, "manager")
+	require.NoError(t, err)
+	assert.False(t, lifted.IsActive())
+	assert.Equal(t, "manager", lifted.LiftedBy)
+
+	_, err = repo.LiftManualBlock(context.Background(), blocks[0].ID, "manager")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	user, err := repo.UpdateMembership(context.Background(), 3, model.Membership{
+		Category: model.PatronStaff,
+		StartsAt: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Nil(t, user.MembershipExpiresAt)
+
+	// one overdue loan is within the limit
+	_, err = repo.CreateLoan(context.Background(), model.NewBorrowdBook(3, 2, now, now.AddDate(0, 0, 21)), model.StandingPolicy{MaxOverdueLoans: 1}, "desk")
+	require.NoError(t, err)
+}
@@ -0,0 +1,493 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoStocktake struct {
+	ID            int                   `db:"id"`
+	BranchID      int                   `db:"branch_id"`
+	Scheme        *string               `db:"scheme"`
+	RangeFrom     *string               `db:"range_from"`
+	RangeFromSort *string               `db:"range_from_sort"`
+	RangeTo       *string               `db:"range_to"`
+	RangeToSort   *string               `db:"range_to_sort"`
+	Status        model.StocktakeStatus `db:"status"`
+	StartedBy     string                `db:"started_by"`
+	ClosedBy      *string               `db:"closed_by"`
+	ClosedAt      *time.Time            `db:"closed_at"`
+	CreatedAt     time.Time             `db:"created_at"`
+	UpdatedAt     time.Time             `db:"updated_at"`
+}
+
+type repoColumnPatternStocktake struct {
+	ID            string
+	BranchID      string
+	Scheme        string
+	RangeFrom     string
+	RangeFromSort string
+	RangeTo       string
+	RangeToSort   string
+	Status        string
+	StartedBy     string
+	ClosedBy      string
+	ClosedAt      string
+	CreatedAt     string
+	UpdatedAt     string
+}
+
+const (
+	repoTableStocktake     = "stocktakes"
+	repoTableStocktakeScan = "stocktake_scans"
+)
+
+var repoColumnStocktake = repoColumnPatternStocktake{
+	ID:            "id",
+	BranchID:      "branch_id",
+	Scheme:        "scheme",
+	RangeFrom:     "range_from",
+	RangeFromSort: "range_from_sort",
+	RangeTo:       "range_to",
+	RangeToSort:   "range_to_sort",
+	Status:        "status",
+	StartedBy:     "started_by",
+	ClosedBy:      "closed_by",
+	ClosedAt:      "closed_at",
+	CreatedAt:     "created_at",
+	UpdatedAt:     "updated_at",
+}
+
+func (c *repoColumnPatternStocktake) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.BranchID,
+		c.Scheme,
+		c.RangeFrom,
+		c.RangeFromSort,
+		c.RangeTo,
+		c.RangeToSort,
+		c.Status,
+		c.StartedBy,
+		c.ClosedBy,
+		c.ClosedAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (row repoStocktake) toModel() model.Stocktake {
+	s := model.Stocktake{
+		ID:        row.ID,
+		BranchID:  row.BranchID,
+		Status:    row.Status,
+		StartedBy: row.StartedBy,
+		ClosedAt:  row.ClosedAt,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if row.ClosedBy != nil {
+		s.ClosedBy = *row.ClosedBy
+	}
+	if row.Scheme != nil && row.RangeFromSort != nil && row.RangeToSort != nil {
+		scheme := model.ClassificationScheme(*row.Scheme)
+		s.Range = &model.ShelfRange{
+			Scheme: scheme,
+			From:   model.CallNumber{Scheme: scheme, Raw: derefString(row.RangeFrom), SortKey: *row.RangeFromSort},
+			To:     model.CallNumber{Scheme: scheme, Raw: derefString(row.RangeTo), SortKey: *row.RangeToSort},
+		}
+	}
+	return s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// repoStocktakeItem is a copy with its effective call number. Its copy
+// columns are NULL for a scanned barcode labelling no copy.
+type repoStocktakeItem struct {
+	CopyID          *int    `db:"copy_id"`
+	Barcode         *string `db:"barcode"`
+	BookID          *int    `db:"book_id"`
+	Title           *string `db:"title"`
+	Status          *string `db:"status"`
+	CurrentBranchID *int    `db:"current_branch_id"`
+	Scheme          *string `db:"scheme"`
+	CallNumber      *string `db:"call_number"`
+	SortKey         *string `db:"sort_key"`
+}
+
+// repoStocktakeItemColumns selects a copy bc of a book b as a stocktake item.
+var repoStocktakeItemColumns = []string{
+	"bc.id AS copy_id",
+	"b.id AS book_id",
+	"b.title",
+	"bc.status",
+	"bc.current_branch_id",
+	"b.classification AS scheme",
+	"COALESCE(bc.call_number, b.call_number) AS call_number",
+	"COALESCE(bc.call_number_sort, b.call_number_sort) AS sort_key",
+}
+
+func (row repoStocktakeItem) toModel() (model.StocktakeItem, error) {
+	item := model.StocktakeItem{
+		CopyID:     row.CopyID,
+		Barcode:    derefString(row.Barcode),
+		Title:      derefString(row.Title),
+		Scheme:     model.ClassificationScheme(derefString(row.Scheme)),
+		CallNumber: derefString(row.CallNumber),
+		SortKey:    derefString(row.SortKey),
+	}
+	if row.CopyID == nil {
+		return item, nil
+	}
+	if row.BookID != nil {
+		item.BookID = *row.BookID
+	}
+	if row.CurrentBranchID != nil {
+		item.CurrentBranchID = *row.CurrentBranchID
+	}
+	if row.Status != nil {
+		status, err := bookStatusFromRepo(*row.Status)
+		if err != nil {
+			return model.StocktakeItem{}, err
+		}
+		item.Status = status
+	}
+	return item, nil
+}
+
+func (r *PostgresRepository) CreateStocktake(ctx context.Context, param model.Stocktake) (*model.Stocktake, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnStocktake.BranchID:  param.BranchID,
+		repoColumnStocktake.Status:    model.StocktakeOpen,
+		repoColumnStocktake.StartedBy: param.StartedBy,
+	}
+	if rng := param.Range; rng != nil {
+		insert[repoColumnStocktake.Scheme] = string(rng.Scheme)
+		insert[repoColumnStocktake.RangeFrom] = rng.From.Raw
+		insert[repoColumnStocktake.RangeFromSort] = rng.From.SortKey
+		insert[repoColumnStocktake.RangeTo] = rng.To.Raw
+		insert[repoColumnStocktake.RangeToSort] = rng.To.SortKey
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableStocktake).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnStocktake.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoStocktake
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	stocktake := row.toModel()
+	return &stocktake, nil
+}
+
+func (r *PostgresRepository) GetStocktakeByID(ctx context.Context, id int) (*model.Stocktake, common.Error) {
+	return r.getStocktake(ctx, r.db, id, false)
+}
+
+func (r *PostgresRepository) getStocktake(ctx context.Context, db sqlContextGetter, id int, forUpdate bool) (*model.Stocktake, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnStocktake.columns()).
+		From(repoTableStocktake).
+		Where(sq.Eq{repoColumnStocktake.ID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoStocktake
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("stocktake not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	stocktake := row.toModel()
+	return &stocktake, nil
+}
+
+// ListStocktakes returns the stocktakes of a branch, or of every branch when
+// branchID is zero, newest first.
+func (r *PostgresRepository) ListStocktakes(ctx context.Context, branchID int) ([]*model.Stocktake, common.Error) {
+	where := sq.And{}
+	if branchID != 0 {
+		where = append(where, sq.Eq{repoColumnStocktake.BranchID: branchID})
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnStocktake.columns()).
+		From(repoTableStocktake).
+		Where(where).
+		OrderBy(repoColumnStocktake.CreatedAt+" DESC", repoColumnStocktake.ID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoStocktake
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var stocktakes []*model.Stocktake
+	for _, row := range rows {
+		stocktake := row.toModel()
+		stocktakes = append(stocktakes, &stocktake)
+	}
+
+	return stocktakes, nil
+}
+
+// AddStocktakeScans records scanned barcodes in an open stocktake and
+// resolves the copies they label. A barcode scanned twice is recorded once.
+// It returns the number of barcodes newly recorded.
+func (r *PostgresRepository) AddStocktakeScans(ctx context.Context, id int, barcodes []string, scannedBy string) (int, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return 0, err
+	}
+
+	added, err := r.addStocktakeScans(ctx, tx, id, barcodes, scannedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return 0, err
+	}
+
+	return added, nil
+}
+
+func (r *PostgresRepository) addStocktakeScans(ctx context.Context, db sqlContextGetter, id int, barcodes []string, scannedBy string) (int, common.Error) {
+	stocktake, cErr := r.getStocktake(ctx, db, id, true)
+	if cErr != nil {
+		return 0, cErr
+	}
+	if stocktake.Status != model.StocktakeOpen {
+		return 0, stocktakeClosedError(stocktake)
+	}
+	if len(barcodes) == 0 {
+		return 0, nil
+	}
+
+	builder := r.pgsq.Insert(repoTableStocktakeScan).
+		Columns("stocktake_id", "barcode", "copy_id", "scanned_by")
+	for _, barcode := range barcodes {
+		copyID := sq.Expr(fmt.Sprintf("(SELECT %s FROM %s WHERE %s = ?)",
+			repoColumnBookCopies.ID, repoTableBookCopies, repoColumnBookCopies.Barcode), barcode)
+		builder = builder.Values(id, barcode, copyID, scannedBy)
+	}
+
+	// build SQL query
+	query, args, err := builder.Suffix("ON CONFLICT ON CONSTRAINT stocktake_scans_barcode_key DO NOTHING").ToSql()
+	if err != nil {
+		return 0, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return 0, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return int(added), nil
+}
+
+// CloseStocktake ends the scanning of a stocktake.
+func (r *PostgresRepository) CloseStocktake(ctx context.Context, id int, closedBy string) (*model.Stocktake, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableStocktake).
+		SetMap(map[string]interface{}{
+			repoColumnStocktake.Status:    model.StocktakeClosed,
+			repoColumnStocktake.ClosedBy:  closedBy,
+			repoColumnStocktake.ClosedAt:  sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnStocktake.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.And{
+			sq.Eq{repoColumnStocktake.ID: id},
+			sq.Eq{repoColumnStocktake.Status: model.StocktakeOpen},
+		}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnStocktake.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoStocktake
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			stocktake, cErr := r.GetStocktakeByID(ctx, id)
+			if cErr != nil {
+				return nil, cErr
+			}
+			return nil, stocktakeClosedError(stocktake)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	stocktake := row.toModel()
+	return &stocktake, nil
+}
+
+// stocktakeMissingWhere selects the copies a stocktake expects on its shelves
+// which were not scanned: copies of the branch marked InLibrary, within the
+// shelf range if any.
+func stocktakeMissingWhere(s model.Stocktake) sq.And {
+	where := sq.And{
+		sq.Eq{"bc." + repoColumnBookCopies.CurrentBranchID: s.BranchID},
+		sq.Eq{"bc." + repoColumnBookCopies.Status: model.InLibrary.String()},
+		sq.Eq{"bc." + repoColumnBookCopies.RetiredAt: nil},
+		sq.Expr(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s s WHERE s.stocktake_id = ? AND s.copy_id = bc.id)", repoTableStocktakeScan), s.ID),
+	}
+	if rng := s.Range; rng != nil {
+		sortKey := "COALESCE(bc.call_number_sort, b.call_number_sort)"
+		where = append(where,
+			sq.Eq{"b." + repoColumnBook.Classification: string(rng.Scheme)},
+			sq.Expr(sortKey+` COLLATE "C" >= ? COLLATE "C"`, rng.From.SortKey),
+			sq.Expr(fmt.Sprintf(`left(%s, ?) COLLATE "C" <= ? COLLATE "C"`, sortKey), len(rng.To.SortKey), rng.To.SortKey),
+		)
+	}
+	return where
+}
+
+// ListStocktakeMissingItems returns the copies expected on the shelves of a
+// stocktake which were not scanned, in shelf order.
+func (r *PostgresRepository) ListStocktakeMissingItems(ctx context.Context, s model.Stocktake) ([]*model.StocktakeItem, common.Error) {
+	columns := append([]string{"bc." + repoColumnBookCopies.Barcode}, repoStocktakeItemColumns...)
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(columns...).
+		From(repoTableBookCopies+" bc").
+		Join(fmt.Sprintf("%s b ON b.%s = bc.%s", repoTableBook, repoColumnBook.ID, repoColumnBookCopies.BookID)).
+		Where(stocktakeMissingWhere(s)).
+		OrderBy(`COALESCE(bc.call_number_sort, b.call_number_sort) COLLATE "C"`, "bc."+repoColumnBookCopies.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	return r.listStocktakeItems(ctx, query, args)
+}
+
+// ListStocktakeScannedItems returns what was scanned during a stocktake, in scan order.
+func (r *PostgresRepository) ListStocktakeScannedItems(ctx context.Context, id int) ([]*model.StocktakeItem, common.Error) {
+	columns := append([]string{"s.barcode"}, repoStocktakeItemColumns...)
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(columns...).
+		From(repoTableStocktakeScan + " s").
+		LeftJoin(fmt.Sprintf("%s bc ON bc.%s = s.copy_id", repoTableBookCopies, repoColumnBookCopies.ID)).
+		LeftJoin(fmt.Sprintf("%s b ON b.%s = bc.%s", repoTableBook, repoColumnBook.ID, repoColumnBookCopies.BookID)).
+		Where(sq.Eq{"s.stocktake_id": id}).
+		OrderBy("s.id").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	return r.listStocktakeItems(ctx, query, args)
+}
+
+func (r *PostgresRepository) listStocktakeItems(ctx context.Context, query string, args []interface{}) ([]*model.StocktakeItem, common.Error) {
+	// execute SQL query
+	var rows []repoStocktakeItem
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var items []*model.StocktakeItem
+	for _, row := range rows {
+		item, err := row.toModel()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+// MarkStocktakeMissingLost marks Lost every copy a closed stocktake expected
+// on its shelves but did not find. It returns the IDs of the copies marked.
+func (r *PostgresRepository) MarkStocktakeMissingLost(ctx context.Context, id int, changedBy string) ([]int, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	copyIDs, err := r.markStocktakeMissingLost(ctx, tx, id, changedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return copyIDs, nil
+}
+
+func (r *PostgresRepository) markStocktakeMissingLost(ctx context.Context, db sqlContextGetter, id int, changedBy string) ([]int, common.Error) {
+	stocktake, cErr := r.getStocktake(ctx, db, id, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if stocktake.Status != model.StocktakeClosed {
+		err := fmt.Errorf("stocktake %d is still open", id)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("close the stocktake before marking missing copies lost"))
+	}
+
+	// lock the missing copies so none leaves the shelf meanwhile
+	query, args, err := r.pgsq.Select("bc." + repoColumnBookCopies.ID).
+		From(repoTableBookCopies + " bc").
+		Join(fmt.Sprintf("%s b ON b.%s = bc.%s", repoTableBook, repoColumnBook.ID, repoColumnBookCopies.BookID)).
+		Where(stocktakeMissingWhere(*stocktake)).
+		OrderBy("bc." + repoColumnBookCopies.ID).
+		Suffix("FOR UPDATE OF bc").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	var copyIDs []int
+	if err = db.SelectContext(ctx, &copyIDs, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	reason := fmt.Sprintf("missing at stocktake %d", id)
+	for _, copyID := range copyIDs {
+		if _, err := r.changeBookCopyStatus(ctx, db, copyID, model.Lost, changedBy, reason); err != nil {
+			return nil, err
+		}
+	}
+	return copyIDs, nil
+}
+
+func stocktakeClosedError(s *model.Stocktake) common.Error {
+	err := fmt.Errorf("stocktake %d is closed", s.ID)
+	return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initStocktakeRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataHold),
+	)
+}
+
+func TestStocktakeRepository_Lifecycle(t *testing.T) {
+	repo := initStocktakeRepository(t)
+
+	stocktake, err := repo.CreateStocktake(context.Background(), model.NewStocktake(1, nil, "librarian"))
+	require.NoError(t, err)
+	assert.Equal(t, model.StocktakeOpen, stocktake.Status)
+
+	// copy 1 is on loan, the other barcode labels no copy
+	added, err := repo.AddStocktakeScans(context.Background(), stocktake.ID, []string{"30000000000012", "UNKNOWN"}, "librarian")
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	added, err = repo.AddStocktakeScans(context.Background(), stocktake.ID, []string{"30000000000012"}, "librarian")
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	scanned, err := repo.ListStocktakeScannedItems(context.Background(), stocktake.ID)
+	require.NoError(t, err)
+	require.Len(t, scanned, 2)
+	require.NotNil(t, scanned[0].CopyID)
+	assert.Equal(t, 1, *scanned[0].CopyID)
+	assert.Equal(t, model.Borrowed, scanned[0].Status)
+	assert.Nil(t, scanned[1].CopyID)
+
+	// copy 2 sits on the shelf but was not scanned
+	missing, err := repo.ListStocktakeMissingItems(context.Background(), *stocktake)
+	require.NoError(t, err)
+	require.Len(t, missing, 1)
+	assert.Equal(t, 2, *missing[0].CopyID)
+
+	// missing copies are only marked lost once the count is over
+	_, err = repo.MarkStocktakeMissingLost(context.Background(), stocktake.ID, "librarian")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	stocktake, err = repo.CloseStocktake(context.Background(), stocktake.ID, "head librarian")
+	require.NoError(t, err)
+	assert.Equal(t, model.StocktakeClosed, stocktake.Status)
+	assert.NotNil(t, stocktake.ClosedAt)
+
+	_, err = repo.AddStocktakeScans(context.Background(), stocktake.ID, []string{"30000000000020"}, "librarian")
+	require.Error(t, err)
+
+	copyIDs, err := repo.MarkStocktakeMissingLost(context.Background(), stocktake.ID, "head librarian")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, copyIDs)
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.Lost, bookCopy.Status)
+}
+
+func TestStocktakeRepository_ShelfRange(t *testing.T) {
+	repo := initStocktakeRepository(t)
+
+	// copy 2 is shelved at 863.3, outside the range
+	rng, rErr := model.NewShelfRange(model.ClassificationDewey, "800", "849")
+	require.NoError(t, rErr)
+	stocktake, err := repo.CreateStocktake(context.Background(), model.NewStocktake(1, &rng, "librarian"))
+	require.NoError(t, err)
+	require.NotNil(t, stocktake.Range)
+	assert.Equal(t, "849", stocktake.Range.To.Raw)
+
+	missing, err := repo.ListStocktakeMissingItems(context.Background(), *stocktake)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	stocktakes, err := repo.ListStocktakes(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Len(t, stocktakes, 1)
+}
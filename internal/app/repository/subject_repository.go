@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoSubject struct {
+	ID        int       `db:"id"`
+	ParentID  *int      `db:"parent_id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type repoColumnPatternSubject struct {
+	ID        string
+	ParentID  string
+	Name      string
+	CreatedAt string
+	UpdatedAt string
+}
+
+const (
+	repoTableSubject     = "subjects"
+	repoTableBookSubject = "book_subjects"
+)
+
+var repoColumnSubject = repoColumnPatternSubject{
+	ID:        "id",
+	ParentID:  "parent_id",
+	Name:      "name",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (c *repoColumnPatternSubject) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.ParentID,
+		c.Name,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+// subjectSubtreeCTE selects the IDs of a subject and all of its descendants.
+const subjectSubtreeCTE = `WITH RECURSIVE subtree AS (
+	SELECT id FROM subjects WHERE id = ?
+	UNION ALL
+	SELECT s.id FROM subjects s JOIN subtree t ON s.parent_id = t.id
+)`
+
+func (r *PostgresRepository) CreateSubject(ctx context.Context, param model.Subject) (*model.Subject, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnSubject.ParentID: param.ParentID,
+		repoColumnSubject.Name:     param.Name,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableSubject).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnSubject.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoSubject
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	subject := model.Subject(row)
+	return &subject, nil
+}
+
+func (r *PostgresRepository) GetSubjectByID(ctx context.Context, id int) (*model.Subject, common.Error) {
+	where := sq.And{
+		sq.Eq{repoColumnSubject.ID: id},
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnSubject.columns()).
+		From(repoTableSubject).
+		Where(where).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoSubject
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	subject := model.Subject(row)
+	return &subject, nil
+}
+
+// ListSubjects returns the whole taxonomy as a flat list ordered by name.
+func (r *PostgresRepository) ListSubjects(ctx context.Context) ([]*model.Subject, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnSubject.columns()).
+		From(repoTableSubject).
+		OrderBy(repoColumnSubject.Name, repoColumnSubject.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoSubject
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var subjects []*model.Subject
+	for _, row := range rows {
+		subject := model.Subject(row)
+		subjects = append(subjects, &subject)
+	}
+
+	return subjects, nil
+}
+
+// UpdateSubject renames a subject and moves it under a new parent.
+func (r *PostgresRepository) UpdateSubject(ctx context.Context, param model.Subject) (*model.Subject, common.Error) {
+	update := map[string]interface{}{
+		repoColumnSubject.ParentID:  param.ParentID,
+		repoColumnSubject.Name:      param.Name,
+		repoColumnSubject.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableSubject).
+		SetMap(update).
+		Where(sq.Eq{repoColumnSubject.ID: param.ID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnSubject.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoSubject
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	subject := model.Subject(row)
+	return &subject, nil
+}
+
+func (r *PostgresRepository) DeleteSubject(ctx context.Context, id int) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Delete(repoTableSubject).
+		Where(sq.Eq{repoColumnSubject.ID: id}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return common.NewError(common.ErrorCodeResourceNotFound, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// SetBookSubjects replaces the subjects a book is linked to.
+func (r *PostgresRepository) SetBookSubjects(ctx context.Context, bookID int, subjectIDs []int) common.Error {
+	tx, err := r.beginTx()
+	if err != nil {
+		return err
+	}
+
+	err = r.setBookSubjects(ctx, tx, bookID, subjectIDs)
+	return r.finishTx(err, tx)
+}
+
+func (r *PostgresRepository) setBookSubjects(ctx context.Context, db sqlContextGetter, bookID int, subjectIDs []int) common.Error {
+	query, args, err := r.pgsq.Delete(repoTableBookSubject).
+		Where(sq.Eq{"book_id": bookID}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	if len(subjectIDs) == 0 {
+		return nil
+	}
+
+	insert := r.pgsq.Insert(repoTableBookSubject).Columns("book_id", "subject_id")
+	for _, id := range subjectIDs {
+		insert = insert.Values(bookID, id)
+	}
+	query, args, err = insert.Suffix("ON CONFLICT DO NOTHING").ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) ListSubjectsByBookID(ctx context.Context, bookID int) ([]*model.Subject, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(aliasColumns("s", repoColumnSubject.columns())).
+		From(repoTableSubject + " s").
+		Join(repoTableBookSubject + " bs ON bs.subject_id = s.id").
+		Where(sq.Eq{"bs.book_id": bookID}).
+		OrderBy("s." + repoColumnSubject.Name).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoSubject
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var subjects []*model.Subject
+	for _, row := range rows {
+		subject := model.Subject(row)
+		subjects = append(subjects, &subject)
+	}
+
+	return subjects, nil
+}
+
+// ListBooksBySubjectTree returns books linked to the subject or any of its descendants.
+func (r *PostgresRepository) ListBooksBySubjectTree(ctx context.Context, subjectID int, limit, offset int) ([]*model.Book, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(aliasColumns("b", repoColumnBook.columns())).
+		Prefix(subjectSubtreeCTE, subjectID).
+		From(repoTableBook+" b").
+		Where(sq.Expr(fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %s bs WHERE bs.book_id = b.id AND bs.subject_id IN (SELECT id FROM subtree))",
+			repoTableBookSubject,
+		))).
+		OrderBy("b."+repoColumnBook.Title, "b."+repoColumnBook.ID).
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoBook
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var books []*model.Book
+	for _, row := range rows {
+		book := row.toModel()
+		books = append(books, &book)
+	}
+
+	return books, nil
+}
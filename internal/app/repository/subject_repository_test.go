@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initSubjectRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataSubject),
+		testdata.Path(testdata.TestDataBookSubject),
+	)
+}
+
+func TestSubjectRepository_CreateSubject(t *testing.T) {
+	repo := initSubjectRepository(t)
+	parentID := 1
+
+	subject, err := repo.CreateSubject(context.Background(), model.NewSubject("Fantasy", &parentID))
+	require.NoError(t, err)
+	require.NotNil(t, subject.ParentID)
+	assert.Equal(t, parentID, *subject.ParentID)
+
+	// sibling names are unique regardless of case
+	_, err = repo.CreateSubject(context.Background(), model.NewSubject("fantasy", &parentID))
+	require.Error(t, err)
+}
+
+func TestSubjectRepository_ListBooksBySubjectTree(t *testing.T) {
+	repo := initSubjectRepository(t)
+
+	tests := []struct {
+		Name      string
+		SubjectID int
+		Count     int
+	}{
+		{Name: "root includes all descendants", SubjectID: 1, Count: 2},
+		{Name: "inner node", SubjectID: 2, Count: 2},
+		{Name: "leaf", SubjectID: 3, Count: 1},
+		{Name: "other tree", SubjectID: 4, Count: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			books, err := repo.ListBooksBySubjectTree(context.Background(), tt.SubjectID, 10, 0)
+			require.NoError(t, err)
+			assert.Len(t, books, tt.Count)
+		})
+	}
+}
+
+func TestSubjectRepository_SetBookSubjects(t *testing.T) {
+	repo := initSubjectRepository(t)
+
+	err := repo.SetBookSubjects(context.Background(), 3, []int{1, 4})
+	require.NoError(t, err)
+
+	subjects, err := repo.ListSubjectsByBookID(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Len(t, subjects, 2)
+
+	err = repo.SetBookSubjects(context.Background(), 3, nil)
+	require.NoError(t, err)
+
+	subjects, err = repo.ListSubjectsByBookID(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Empty(t, subjects)
+}
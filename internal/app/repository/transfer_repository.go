@@ -0,0 +1,438 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoTransfer struct {
+	ID           int                  `db:"id"`
+	CopyID       int                  `db:"copy_id"`
+	FromBranchID int                  `db:"from_branch_id"`
+	ToBranchID   int                  `db:"to_branch_id"`
+	HoldID       *int                 `db:"hold_id"`
+	Status       model.TransferStatus `db:"status"`
+	RequestedBy  string               `db:"requested_by"`
+	Reason       string               `db:"reason"`
+	DispatchedAt *time.Time           `db:"dispatched_at"`
+	ReceivedAt   *time.Time           `db:"received_at"`
+	CreatedAt    time.Time            `db:"created_at"`
+	UpdatedAt    time.Time            `db:"updated_at"`
+}
+
+type repoColumnPatternTransfer struct {
+	ID           string
+	CopyID       string
+	FromBranchID string
+	ToBranchID   string
+	HoldID       string
+	Status       string
+	RequestedBy  string
+	Reason       string
+	DispatchedAt string
+	ReceivedAt   string
+	CreatedAt    string
+	UpdatedAt    string
+}
+
+const repoTableTransfer = "transfers"
+
+var repoColumnTransfer = repoColumnPatternTransfer{
+	ID:           "id",
+	CopyID:       "copy_id",
+	FromBranchID: "from_branch_id",
+	ToBranchID:   "to_branch_id",
+	HoldID:       "hold_id",
+	Status:       "status",
+	RequestedBy:  "requested_by",
+	Reason:       "reason",
+	DispatchedAt: "dispatched_at",
+	ReceivedAt:   "received_at",
+	CreatedAt:    "created_at",
+	UpdatedAt:    "updated_at",
+}
+
+func (c *repoColumnPatternTransfer) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.CopyID,
+		c.FromBranchID,
+		c.ToBranchID,
+		c.HoldID,
+		c.Status,
+		c.RequestedBy,
+		c.Reason,
+		c.DispatchedAt,
+		c.ReceivedAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+// CreateTransfer asks for a copy on the shelf to be sent to another branch.
+// The copy leaves from the branch it currently sits at.
+func (r *PostgresRepository) CreateTransfer(ctx context.Context, copyID, toBranchID int, requestedBy, reason string) (*model.Transfer, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := r.requestTransfer(ctx, tx, copyID, toBranchID, requestedBy, reason)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (r *PostgresRepository) requestTransfer(ctx context.Context, db sqlContextGetter, copyID, toBranchID int, requestedBy, reason string) (*model.Transfer, common.Error) {
+	bookCopy, cErr := r.getBookCopy(ctx, db, sq.Eq{"bc." + repoColumnBookCopies.ID: copyID}, true)
+	if cErr != nil {
+		return nil, cErr
+	}
+	if err := model.ValidateTransferRequest(*bookCopy, toBranchID); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	transfer := model.NewTransfer(copyID, bookCopy.CurrentBranchID, toBranchID, requestedBy, reason)
+	return r.createTransfer(ctx, db, transfer)
+}
+
+func (r *PostgresRepository) createTransfer(ctx context.Context, db sqlContextGetter, param model.Transfer) (*model.Transfer, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnTransfer.CopyID:       param.CopyID,
+		repoColumnTransfer.FromBranchID: param.FromBranchID,
+		repoColumnTransfer.ToBranchID:   param.ToBranchID,
+		repoColumnTransfer.HoldID:       param.HoldID,
+		repoColumnTransfer.Status:       param.Status,
+		repoColumnTransfer.RequestedBy:  param.RequestedBy,
+		repoColumnTransfer.Reason:       param.Reason,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableTransfer).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnTransfer.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoTransfer
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("copy %d is already being transferred", param.CopyID)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	transfer := model.Transfer(row)
+	return &transfer, nil
+}
+
+func (r *PostgresRepository) GetTransferByID(ctx context.Context, id int) (*model.Transfer, common.Error) {
+	return r.getTransfer(ctx, r.db, id, false)
+}
+
+func (r *PostgresRepository) getTransfer(ctx context.Context, db sqlContextGetter, id int, forUpdate bool) (*model.Transfer, common.Error) {
+	// build SQL query
+	builder := r.pgsq.Select(repoColumnTransfer.columns()).
+		From(repoTableTransfer).
+		Where(sq.Eq{repoColumnTransfer.ID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoTransfer
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("transfer not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	transfer := model.Transfer(row)
+	return &transfer, nil
+}
+
+// ListTransfers returns the transfers leaving or reaching a branch, newest
+// first. A zero branch ID or an empty status matches any.
+func (r *PostgresRepository) ListTransfers(ctx context.Context, branchID int, status model.TransferStatus) ([]*model.Transfer, common.Error) {
+	where := sq.And{}
+	if branchID != 0 {
+		where = append(where, sq.Or{
+			sq.Eq{repoColumnTransfer.FromBranchID: branchID},
+			sq.Eq{repoColumnTransfer.ToBranchID: branchID},
+		})
+	}
+	if status != "" {
+		where = append(where, sq.Eq{repoColumnTransfer.Status: status})
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnTransfer.columns()).
+		From(repoTableTransfer).
+		Where(where).
+		OrderBy(repoColumnTransfer.CreatedAt+" DESC", repoColumnTransfer.ID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoTransfer
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var transfers []*model.Transfer
+	for _, row := range rows {
+		transfer := model.Transfer(row)
+		transfers = append(transfers, &transfer)
+	}
+
+	return transfers, nil
+}
+
+// DispatchTransfer records a requested transfer leaving its branch: the copy
+// goes InTransit.
+func (r *PostgresRepository) DispatchTransfer(ctx context.Context, id int, changedBy string) (*model.Transfer, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := r.dispatchTransfer(ctx, tx, id, changedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (r *PostgresRepository) dispatchTransfer(ctx context.Context, db sqlContextGetter, id int, changedBy string) (*model.Transfer, common.Error) {
+	transfer, err := r.getTransfer(ctx, db, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != model.TransferRequested {
+		return nil, invalidTransferStatus(transfer, "dispatched")
+	}
+
+	reason := fmt.Sprintf("transfer %d dispatched", id)
+	if _, err := r.changeBookCopyStatus(ctx, db, transfer.CopyID, model.InTransit, changedBy, reason); err != nil {
+		return nil, err
+	}
+
+	return r.updateTransferStatus(ctx, db, id, model.TransferInTransit, repoColumnTransfer.DispatchedAt)
+}
+
+// ReceiveTransfer records a copy arriving at the destination branch, which
+// becomes its current branch. A copy sent to fill a hold still waiting for it
+// goes on the hold shelf and the hold becomes ready; any other copy is
+// shelved.
+func (r *PostgresRepository) ReceiveTransfer(ctx context.Context, id int, changedBy string) (*model.Transfer, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := r.receiveTransfer(ctx, tx, id, changedBy)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (r *PostgresRepository) receiveTransfer(ctx context.Context, db sqlContextGetter, id int, changedBy string) (*model.Transfer, common.Error) {
+	transfer, err := r.getTransfer(ctx, db, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != model.TransferInTransit {
+		return nil, invalidTransferStatus(transfer, "received")
+	}
+
+	if err := r.setBookCopyCurrentBranch(ctx, db, transfer.CopyID, transfer.ToBranchID); err != nil {
+		return nil, err
+	}
+
+	ready := false
+	if transfer.HoldID != nil {
+		if ready, err = r.readyHoldInTransit(ctx, db, *transfer.HoldID, transfer.CopyID); err != nil {
+			return nil, err
+		}
+	}
+
+	reason := fmt.Sprintf("transfer %d received", id)
+	to := model.InLibrary
+	if ready {
+		to = model.OnHoldShelf
+		reason = fmt.Sprintf("transfer %d received for hold %d", id, *transfer.HoldID)
+	}
+	if _, err := r.changeBookCopyStatus(ctx, db, transfer.CopyID, to, changedBy, reason); err != nil {
+		return nil, err
+	}
+
+	return r.updateTransferStatus(ctx, db, id, model.TransferReceived, repoColumnTransfer.ReceivedAt)
+}
+
+// readyHoldInTransit makes a hold ready when it is still waiting for the copy.
+// It reports false when the hold was cancelled while the copy travelled.
+func (r *PostgresRepository) readyHoldInTransit(ctx context.Context, db sqlContextGetter, holdID, copyID int) (bool, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableHold).
+		SetMap(map[string]interface{}{
+			repoColumnHold.Status:    model.HoldReady,
+			repoColumnHold.ReadyAt:   sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnHold.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.And{
+			sq.Eq{repoColumnHold.ID: holdID},
+			sq.Eq{repoColumnHold.CopyID: copyID},
+			sq.Eq{repoColumnHold.Status: model.HoldInTransit},
+		}).
+		ToSql()
+	if err != nil {
+		return false, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return n == 1, nil
+}
+
+// CancelTransfer drops a transfer which has not left its branch yet. A hold
+// the copy was sent for goes back to the queue.
+func (r *PostgresRepository) CancelTransfer(ctx context.Context, id int) (*model.Transfer, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := r.cancelTransfer(ctx, tx, id)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (r *PostgresRepository) cancelTransfer(ctx context.Context, db sqlContextGetter, id int) (*model.Transfer, common.Error) {
+	transfer, err := r.getTransfer(ctx, db, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != model.TransferRequested {
+		return nil, invalidTransferStatus(transfer, "cancelled")
+	}
+
+	if transfer.HoldID != nil {
+		// build SQL query
+		query, args, err := r.pgsq.Update(repoTableHold).
+			SetMap(map[string]interface{}{
+				repoColumnHold.Status:    model.HoldPending,
+				repoColumnHold.CopyID:    nil,
+				repoColumnHold.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+			}).
+			Where(sq.And{
+				sq.Eq{repoColumnHold.ID: *transfer.HoldID},
+				sq.Eq{repoColumnHold.Status: model.HoldInTransit},
+			}).
+			ToSql()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+
+		// execute SQL query
+		if _, err = db.ExecContext(ctx, query, args...); err != nil {
+			return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+		}
+	}
+
+	return r.updateTransferStatus(ctx, db, id, model.TransferCancelled, "")
+}
+
+// cancelRequestedHoldTransfer drops the transfer of a cancelled hold's copy
+// if it has not left its branch yet. A copy already travelling finishes its
+// trip and is shelved when received.
+func (r *PostgresRepository) cancelRequestedHoldTransfer(ctx context.Context, db sqlContextGetter, holdID int) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableTransfer).
+		SetMap(map[string]interface{}{
+			repoColumnTransfer.Status:    model.TransferCancelled,
+			repoColumnTransfer.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.And{
+			sq.Eq{repoColumnTransfer.HoldID: holdID},
+			sq.Eq{repoColumnTransfer.Status: model.TransferRequested},
+		}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = db.ExecContext(ctx, query, args...); err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return nil
+}
+
+// updateTransferStatus moves a transfer to a status, stamping the given time column if any.
+func (r *PostgresRepository) updateTransferStatus(ctx context.Context, db sqlContextGetter, id int, status model.TransferStatus, stampColumn string) (*model.Transfer, common.Error) {
+	update := map[string]interface{}{
+		repoColumnTransfer.Status:    status,
+		repoColumnTransfer.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+	}
+	if stampColumn != "" {
+		update[stampColumn] = sq.Expr("CURRENT_TIMESTAMP")
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableTransfer).
+		SetMap(update).
+		Where(sq.Eq{repoColumnTransfer.ID: id}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnTransfer.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoTransfer
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	transfer := model.Transfer(row)
+	return &transfer, nil
+}
+
+func invalidTransferStatus(t *model.Transfer, action string) common.Error {
+	err := fmt.Errorf("transfer %d is %s and cannot be %s", t.ID, t.Status, action)
+	return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initTransferRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataHold),
+	)
+}
+
+func TestTransferRepository_Lifecycle(t *testing.T) {
+	repo := initTransferRepository(t)
+
+	transfer, err := repo.CreateTransfer(context.Background(), 2, 2, "librarian", "display")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferRequested, transfer.Status)
+	assert.Equal(t, 1, transfer.FromBranchID)
+	assert.Nil(t, transfer.HoldID)
+
+	// a copy travels on one transfer at a time
+	_, err = repo.CreateTransfer(context.Background(), 2, 2, "librarian", "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	// it cannot be received before leaving
+	_, err = repo.ReceiveTransfer(context.Background(), transfer.ID, "east desk")
+	require.Error(t, err)
+
+	transfer, err = repo.DispatchTransfer(context.Background(), transfer.ID, "main desk")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferInTransit, transfer.Status)
+	assert.NotNil(t, transfer.DispatchedAt)
+
+	bookCopy, err := repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.InTransit, bookCopy.Status)
+
+	transfer, err = repo.ReceiveTransfer(context.Background(), transfer.ID, "east desk")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferReceived, transfer.Status)
+	assert.NotNil(t, transfer.ReceivedAt)
+
+	// the copy is shelved at its new branch, still owned by the main branch
+	bookCopy, err = repo.GetBookCopyByID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, model.InLibrary, bookCopy.Status)
+	assert.Equal(t, 2, bookCopy.CurrentBranchID)
+	assert.Equal(t, 1, bookCopy.HomeBranchID)
+
+	_, err = repo.CancelTransfer(context.Background(), transfer.ID)
+	require.Error(t, err)
+}
+
+func TestTransferRepository_CreateTransferRefused(t *testing.T) {
+	repo := initTransferRepository(t)
+
+	// copy 1 is on loan
+	_, err := repo.CreateTransfer(context.Background(), 1, 2, "librarian", "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	// copy 2 already sits at the main branch
+	_, err = repo.CreateTransfer(context.Background(), 2, 1, "librarian", "")
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+}
+
+func TestTransferRepository_CancelHoldTransfer(t *testing.T) {
+	repo := initTransferRepository(t)
+
+	param := model.NewBookCopies(3, "30000000000038", model.InLibrary)
+	param.HomeBranchID = 2
+	_, err := repo.CreateBookCopy(context.Background(), param, "librarian")
+	require.NoError(t, err)
+
+	hold, err := repo.AssignCopyToNextHold(context.Background(), 2)
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+	transfers, err := repo.ListTransfers(context.Background(), 0, model.TransferRequested)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+
+	transfer, err := repo.CancelTransfer(context.Background(), transfers[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransferCancelled, transfer.Status)
+
+	// the hold goes back to the queue
+	hold, err = repo.GetHoldByID(context.Background(), hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.HoldPending, hold.Status)
+	assert.Nil(t, hold.CopyID)
+}
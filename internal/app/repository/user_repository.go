@@ -14,32 +14,50 @@ import (
 )
 
 type repoUser struct {
-	ID        int       `db:"id"`
-	UID       string    `db:"uid"`
-	Email     string    `db:"email"`
-	Name      string    `db:"name"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID                  int                  `db:"id"`
+	UID                 string               `db:"uid"`
+	Email               string               `db:"email"`
+	Name                string               `db:"name"`
+	Category            model.PatronCategory `db:"category"`
+	MembershipStartsAt  time.Time            `db:"membership_starts_at"`
+	MembershipExpiresAt *time.Time           `db:"membership_expires_at"`
+	EmailVerifiedAt     *time.Time           `db:"email_verified_at"`
+	KeepReadingHistory  bool                 `db:"keep_reading_history"`
+	ErasedAt            *time.Time           `db:"erased_at"`
+	CreatedAt           time.Time            `db:"created_at"`
+	UpdatedAt           time.Time            `db:"updated_at"`
 }
 
 type repoColumnPatternUser struct {
-	ID        string
-	UID       string
-	Email     string
-	Name      string
-	CreatedAt string
-	UpdatedAt string
+	ID                  string
+	UID                 string
+	Email               string
+	Name                string
+	Category            string
+	MembershipStartsAt  string
+	MembershipExpiresAt string
+	EmailVerifiedAt     string
+	KeepReadingHistory  string
+	ErasedAt            string
+	CreatedAt           string
+	UpdatedAt           string
 }
 
 const repoTableUser = "users"
 
 var repoColumnUser = repoColumnPatternUser{
-	ID:        "id",
-	UID:       "uid",
-	Email:     "email",
-	Name:      "name",
-	CreatedAt: "created_at",
-	UpdatedAt: "updated_at",
+	ID:                  "id",
+	UID:                 "uid",
+	Email:               "email",
+	Name:                "name",
+	Category:            "category",
+	MembershipStartsAt:  "membership_starts_at",
+	MembershipExpiresAt: "membership_expires_at",
+	EmailVerifiedAt:     "email_verified_at",
+	KeepReadingHistory:  "keep_reading_history",
+	ErasedAt:            "erased_at",
+	CreatedAt:           "created_at",
+	UpdatedAt:           "updated_at",
 }
 
 func (c *repoColumnPatternUser) columns() string {
@@ -48,6 +66,12 @@ func (c *repoColumnPatternUser) columns() string {
 		c.UID,
 		c.Email,
 		c.Name,
+		c.Category,
+		c.MembershipStartsAt,
+		c.MembershipExpiresAt,
+		c.EmailVerifiedAt,
+		c.KeepReadingHistory,
+		c.ErasedAt,
 		c.CreatedAt,
 		c.UpdatedAt,
 	}, ", ")
@@ -60,6 +84,10 @@ func (r *PostgresRepository) CreateUser(ctx context.Context, param model.User) (
 		repoColumnUser.Email: param.Email,
 		repoColumnUser.Name:  param.Name,
 	}
+	// the database enrols users as adults by default
+	if param.Category != "" {
+		insert[repoColumnUser.Category] = param.Category
+	}
 
 	// build SQL query
 	query, args, err := r.pgsq.Insert(repoTableUser).
@@ -102,6 +130,9 @@ func (r *PostgresRepository) GetUserByID(ctx context.Context, id int) (*model.Us
 	// execute SQL query
 	var row repoUser
 	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
 		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
 	}
 
@@ -130,6 +161,9 @@ func (r *PostgresRepository) GetUserByEmail(ctx context.Context, email string) (
 	// execute SQL query
 	var row repoUser
 	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+		}
 		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
 	}
 
@@ -165,4 +199,38 @@ func (r *PostgresRepository) GetAllUsers(ctx context.Context) ([]*model.User, co
 	return users, nil
 }
 
+// ListUsersByEmails returns the users with any of the given emails.
+func (r *PostgresRepository) ListUsersByEmails(ctx context.Context, emails []string) ([]*model.User, common.Error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	where := sq.And{
+		sq.Eq{repoColumnUser.Email: emails},
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnUser.columns()).
+		From(repoTableUser).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoUser
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var users []*model.User
+	for _, row := range rows {
+		user := model.User(row)
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
 // func (r *PostgresRepository) UpdateUser(ctx context.Context, param model.User) ()
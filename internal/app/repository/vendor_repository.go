@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoVendor struct {
+	ID        int       `db:"id"`
+	Code      string    `db:"code"`
+	Name      string    `db:"name"`
+	Email     string    `db:"email"`
+	Phone     string    `db:"phone"`
+	Address   string    `db:"address"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type repoColumnPatternVendor struct {
+	ID        string
+	Code      string
+	Name      string
+	Email     string
+	Phone     string
+	Address   string
+	CreatedAt string
+	UpdatedAt string
+}
+
+const repoTableVendor = "vendors"
+
+var repoColumnVendor = repoColumnPatternVendor{
+	ID:        "id",
+	Code:      "code",
+	Name:      "name",
+	Email:     "email",
+	Phone:     "phone",
+	Address:   "address",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (c *repoColumnPatternVendor) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.Code,
+		c.Name,
+		c.Email,
+		c.Phone,
+		c.Address,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (r *PostgresRepository) CreateVendor(ctx context.Context, param model.Vendor) (*model.Vendor, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnVendor.Code:    param.Code,
+		repoColumnVendor.Name:    param.Name,
+		repoColumnVendor.Email:   param.Email,
+		repoColumnVendor.Phone:   param.Phone,
+		repoColumnVendor.Address: param.Address,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableVendor).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnVendor.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoVendor
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("vendor code %s is already in use", param.Code)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	vendor := model.Vendor(row)
+	return &vendor, nil
+}
+
+func (r *PostgresRepository) GetVendorByID(ctx context.Context, id int) (*model.Vendor, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnVendor.columns()).
+		From(repoTableVendor).
+		Where(sq.Eq{repoColumnVendor.ID: id}).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoVendor
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("vendor not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	vendor := model.Vendor(row)
+	return &vendor, nil
+}
+
+// ListVendors returns every vendor by name.
+func (r *PostgresRepository) ListVendors(ctx context.Context) ([]*model.Vendor, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnVendor.columns()).
+		From(repoTableVendor).
+		OrderBy(repoColumnVendor.Name, repoColumnVendor.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoVendor
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var vendors []*model.Vendor
+	for _, row := range rows {
+		vendor := model.Vendor(row)
+		vendors = append(vendors, &vendor)
+	}
+
+	return vendors, nil
+}
+
+func (r *PostgresRepository) UpdateVendor(ctx context.Context, param model.Vendor) (*model.Vendor, common.Error) {
+	update := map[string]interface{}{
+		repoColumnVendor.Code:      param.Code,
+		repoColumnVendor.Name:      param.Name,
+		repoColumnVendor.Email:     param.Email,
+		repoColumnVendor.Phone:     param.Phone,
+		repoColumnVendor.Address:   param.Address,
+		repoColumnVendor.UpdatedAt: sq.Expr("CURRENT_TIMESTAMP"),
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableVendor).
+		SetMap(update).
+		Where(sq.Eq{repoColumnVendor.ID: param.ID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnVendor.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoVendor
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("vendor not found"))
+		}
+		if isUniqueViolation(err) {
+			msg := fmt.Sprintf("vendor code %s is already in use", param.Code)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(msg))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	vendor := model.Vendor(row)
+	return &vendor, nil
+}
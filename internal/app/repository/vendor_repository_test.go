@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVendorRepository_CreateAndUpdateVendor(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataVendor))
+
+	created, err := repo.CreateVendor(context.Background(), model.NewVendor("ABE", "Abbey Books", "", "", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "ABE", created.Code)
+
+	_, err = repo.CreateVendor(context.Background(), model.NewVendor("BKS", "Duplicate", "", "", ""))
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	created.Email = "sales@abbey.example"
+	updated, err := repo.UpdateVendor(context.Background(), *created)
+	require.NoError(t, err)
+	assert.Equal(t, "sales@abbey.example", updated.Email)
+
+	vendors, err := repo.ListVendors(context.Background())
+	require.NoError(t, err)
+	require.Len(t, vendors, 3)
+	assert.Equal(t, "Abbey Books", vendors[0].Name)
+
+	_, err = repo.GetVendorByID(context.Background(), 99)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
@@ -0,0 +1,523 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoWebhookSubscription struct {
+	ID          int            `db:"id"`
+	URL         string         `db:"url"`
+	Description string         `db:"description"`
+	Events      pq.StringArray `db:"events"`
+	Secret      string         `db:"secret"`
+	Active      bool           `db:"active"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+type repoColumnPatternWebhookSubscription struct {
+	ID          string
+	URL         string
+	Description string
+	Events      string
+	Secret      string
+	Active      string
+	CreatedAt   string
+	UpdatedAt   string
+}
+
+const repoTableWebhookSubscription = "webhook_subscriptions"
+
+var repoColumnWebhookSubscription = repoColumnPatternWebhookSubscription{
+	ID:          "id",
+	URL:         "url",
+	Description: "description",
+	Events:      "events",
+	Secret:      "secret",
+	Active:      "active",
+	CreatedAt:   "created_at",
+	UpdatedAt:   "updated_at",
+}
+
+func (c *repoColumnPatternWebhookSubscription) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.URL,
+		c.Description,
+		c.Events,
+		c.Secret,
+		c.Active,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (s repoWebhookSubscription) toModel() model.WebhookSubscription {
+	events := make([]model.WebhookEventType, 0, len(s.Events))
+	for _, e := range s.Events {
+		events = append(events, model.WebhookEventType(e))
+	}
+	return model.WebhookSubscription{
+		ID:          s.ID,
+		URL:         s.URL,
+		Description: s.Description,
+		Events:      events,
+		Secret:      s.Secret,
+		Active:      s.Active,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+func webhookEventsArray(events []model.WebhookEventType) sq.Sqlizer {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, string(e))
+	}
+	return sq.Expr("?::webhook_event[]", pq.Array(names))
+}
+
+type repoWebhookDelivery struct {
+	ID             int                         `db:"id"`
+	SubscriptionID int                         `db:"subscription_id"`
+	EventID        string                      `db:"event_id"`
+	EventType      model.WebhookEventType      `db:"event_type"`
+	Payload        []byte                      `db:"payload"`
+	Status         model.WebhookDeliveryStatus `db:"status"`
+	Attempts       int                         `db:"attempts"`
+	NextAttemptAt  time.Time                   `db:"next_attempt_at"`
+	LastStatusCode *int                        `db:"last_status_code"`
+	LastError      string                      `db:"last_error"`
+	DeliveredAt    *time.Time                  `db:"delivered_at"`
+	DeadLetteredAt *time.Time                  `db:"dead_lettered_at"`
+	CreatedAt      time.Time                   `db:"created_at"`
+	UpdatedAt      time.Time                   `db:"updated_at"`
+}
+
+type repoColumnPatternWebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       string
+	NextAttemptAt  string
+	LastStatusCode string
+	LastError      string
+	DeliveredAt    string
+	DeadLetteredAt string
+	CreatedAt      string
+	UpdatedAt      string
+}
+
+const repoTableWebhookDelivery = "webhook_deliveries"
+
+var repoColumnWebhookDelivery = repoColumnPatternWebhookDelivery{
+	ID:             "id",
+	SubscriptionID: "subscription_id",
+	EventID:        "event_id",
+	EventType:      "event_type",
+	Payload:        "payload",
+	Status:         "status",
+	Attempts:       "attempts",
+	NextAttemptAt:  "next_attempt_at",
+	LastStatusCode: "last_status_code",
+	LastError:      "last_error",
+	DeliveredAt:    "delivered_at",
+	DeadLetteredAt: "dead_lettered_at",
+	CreatedAt:      "created_at",
+	UpdatedAt:      "updated_at",
+}
+
+func (c *repoColumnPatternWebhookDelivery) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.SubscriptionID,
+		c.EventID,
+		c.EventType,
+		c.Payload,
+		c.Status,
+		c.Attempts,
+		c.NextAttemptAt,
+		c.LastStatusCode,
+		c.LastError,
+		c.DeliveredAt,
+		c.DeadLetteredAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func webhookDeliveriesToModel(rows []repoWebhookDelivery) []*model.WebhookDelivery {
+	deliveries := make([]*model.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		d := model.WebhookDelivery(row)
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries
+}
+
+func (r *PostgresRepository) CreateWebhookSubscription(ctx context.Context, param model.WebhookSubscription) (*model.WebhookSubscription, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableWebhookSubscription).
+		SetMap(map[string]interface{}{
+			repoColumnWebhookSubscription.URL:         param.URL,
+			repoColumnWebhookSubscription.Description: param.Description,
+			repoColumnWebhookSubscription.Events:      webhookEventsArray(param.Events),
+			repoColumnWebhookSubscription.Secret:      param.Secret,
+			repoColumnWebhookSubscription.Active:      param.Active,
+		}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnWebhookSubscription.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoWebhookSubscription
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	sub := row.toModel()
+	return &sub, nil
+}
+
+func (r *PostgresRepository) GetWebhookSubscriptionByID(ctx context.Context, id int) (*model.WebhookSubscription, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnWebhookSubscription.columns()).
+		From(repoTableWebhookSubscription).
+		Where(sq.Eq{repoColumnWebhookSubscription.ID: id}).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoWebhookSubscription
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("webhook subscription not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	sub := row.toModel()
+	return &sub, nil
+}
+
+// ListWebhookSubscriptions returns the subscriptions, the oldest first. An
+// empty event type matches any; otherwise only the active subscriptions to
+// the event are returned.
+func (r *PostgresRepository) ListWebhookSubscriptions(ctx context.Context, event model.WebhookEventType) ([]*model.WebhookSubscription, common.Error) {
+	builder := r.pgsq.Select(repoColumnWebhookSubscription.columns()).
+		From(repoTableWebhookSubscription).
+		OrderBy(repoColumnWebhookSubscription.ID)
+	if event != "" {
+		builder = builder.
+			Where(sq.Eq{repoColumnWebhookSubscription.Active: true}).
+			Where(sq.Expr(fmt.Sprintf("?::webhook_event = ANY(%s)", repoColumnWebhookSubscription.Events), event))
+	}
+
+	// build SQL query
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoWebhookSubscription
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	subs := make([]*model.WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		sub := row.toModel()
+		subs = append(subs, &sub)
+	}
+	return subs, nil
+}
+
+// UpdateWebhookSubscription changes where a subscription is sent and what.
+// Its secret stays.
+func (r *PostgresRepository) UpdateWebhookSubscription(ctx context.Context, param model.WebhookSubscription) (*model.WebhookSubscription, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableWebhookSubscription).
+		SetMap(map[string]interface{}{
+			repoColumnWebhookSubscription.URL:         param.URL,
+			repoColumnWebhookSubscription.Description: param.Description,
+			repoColumnWebhookSubscription.Events:      webhookEventsArray(param.Events),
+			repoColumnWebhookSubscription.Active:      param.Active,
+			repoColumnWebhookSubscription.UpdatedAt:   sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnWebhookSubscription.ID: param.ID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnWebhookSubscription.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoWebhookSubscription
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("webhook subscription not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	sub := row.toModel()
+	return &sub, nil
+}
+
+// DeleteWebhookSubscription drops a subscription with its deliveries.
+func (r *PostgresRepository) DeleteWebhookSubscription(ctx context.Context, id int) common.Error {
+	// build SQL query
+	query, args, err := r.pgsq.Delete(repoTableWebhookSubscription).
+		Where(sq.Eq{repoColumnWebhookSubscription.ID: id}).
+		ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	if n == 0 {
+		err := fmt.Errorf("webhook subscription %d does not exist", id)
+		return common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("webhook subscription not found"))
+	}
+	return nil
+}
+
+// EnqueueWebhookDeliveries queues deliveries for their first attempt. A
+// delivery of an event already queued for its subscription is skipped.
+func (r *PostgresRepository) EnqueueWebhookDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) common.Error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	builder := r.pgsq.Insert(repoTableWebhookDelivery).
+		Columns(
+			repoColumnWebhookDelivery.SubscriptionID,
+			repoColumnWebhookDelivery.EventID,
+			repoColumnWebhookDelivery.EventType,
+			repoColumnWebhookDelivery.Payload,
+		).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s, %s) DO NOTHING",
+			repoColumnWebhookDelivery.SubscriptionID, repoColumnWebhookDelivery.EventID))
+	for _, d := range deliveries {
+		builder = builder.Values(d.SubscriptionID, d.EventID, d.EventType, string(d.Payload))
+	}
+
+	// build SQL query
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	if _, err = r.db.ExecContext(ctx, query, args...); err != nil {
+		if isForeignKeyViolation(err) {
+			return common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("webhook subscription not found"))
+		}
+		return common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return nil
+}
+
+// ClaimDueWebhookDeliveries returns up to limit pending deliveries of active
+// subscriptions whose next attempt is due, the oldest first. Their next
+// attempt is put off by lease, so that a concurrent run does not send them
+// too; recording the attempt sets it for good.
+func (r *PostgresRepository) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableWebhookDelivery).
+		Set(repoColumnWebhookDelivery.NextAttemptAt, now.Add(lease)).
+		Where(sq.Expr(fmt.Sprintf(
+			"%s IN (SELECT d.%s FROM %s d JOIN %s s ON s.%s = d.%s WHERE d.%s = ? AND d.%s <= ? AND s.%s ORDER BY d.%s, d.%s LIMIT ? FOR UPDATE OF d SKIP LOCKED)",
+			repoColumnWebhookDelivery.ID, repoColumnWebhookDelivery.ID,
+			repoTableWebhookDelivery, repoTableWebhookSubscription,
+			repoColumnWebhookSubscription.ID, repoColumnWebhookDelivery.SubscriptionID,
+			repoColumnWebhookDelivery.Status, repoColumnWebhookDelivery.NextAttemptAt,
+			repoColumnWebhookSubscription.Active,
+			repoColumnWebhookDelivery.NextAttemptAt, repoColumnWebhookDelivery.ID,
+		), model.WebhookPending, now, limit)).
+		Suffix(fmt.Sprintf("returning %s", repoColumnWebhookDelivery.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoWebhookDelivery
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return webhookDeliveriesToModel(rows), nil
+}
+
+// RecordWebhookAttempt saves the outcome of an attempt to deliver: its
+// status, attempts, next attempt and last answer.
+func (r *PostgresRepository) RecordWebhookAttempt(ctx context.Context, param model.WebhookDelivery) (*model.WebhookDelivery, common.Error) {
+	// build SQL query
+	query, args, err := r.pgsq.Update(repoTableWebhookDelivery).
+		SetMap(map[string]interface{}{
+			repoColumnWebhookDelivery.Status:         param.Status,
+			repoColumnWebhookDelivery.Attempts:       param.Attempts,
+			repoColumnWebhookDelivery.NextAttemptAt:  param.NextAttemptAt,
+			repoColumnWebhookDelivery.LastStatusCode: param.LastStatusCode,
+			repoColumnWebhookDelivery.LastError:      param.LastError,
+			repoColumnWebhookDelivery.DeliveredAt:    param.DeliveredAt,
+			repoColumnWebhookDelivery.DeadLetteredAt: param.DeadLetteredAt,
+			repoColumnWebhookDelivery.UpdatedAt:      sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnWebhookDelivery.ID: param.ID}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnWebhookDelivery.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoWebhookDelivery
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("webhook delivery not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	delivery := model.WebhookDelivery(row)
+	return &delivery, nil
+}
+
+func (r *PostgresRepository) GetWebhookDeliveryByID(ctx context.Context, id int) (*model.WebhookDelivery, common.Error) {
+	return r.getWebhookDelivery(ctx, r.db, id, false)
+}
+
+func (r *PostgresRepository) getWebhookDelivery(ctx context.Context, db sqlContextGetter, id int, forUpdate bool) (*model.WebhookDelivery, common.Error) {
+	builder := r.pgsq.Select(repoColumnWebhookDelivery.columns()).
+		From(repoTableWebhookDelivery).
+		Where(sq.Eq{repoColumnWebhookDelivery.ID: id})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+
+	// build SQL query
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoWebhookDelivery
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("webhook delivery not found"))
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	delivery := model.WebhookDelivery(row)
+	return &delivery, nil
+}
+
+// ListWebhookDeliveries returns deliveries, the latest first. A zero
+// subscription ID or an empty status matches any.
+func (r *PostgresRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID int, status model.WebhookDeliveryStatus, limit, offset int) ([]*model.WebhookDelivery, common.Error) {
+	builder := r.pgsq.Select(repoColumnWebhookDelivery.columns()).
+		From(repoTableWebhookDelivery).
+		OrderBy(repoColumnWebhookDelivery.ID + " DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+	if subscriptionID != 0 {
+		builder = builder.Where(sq.Eq{repoColumnWebhookDelivery.SubscriptionID: subscriptionID})
+	}
+	if status != "" {
+		builder = builder.Where(sq.Eq{repoColumnWebhookDelivery.Status: status})
+	}
+
+	// build SQL query
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoWebhookDelivery
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+	return webhookDeliveriesToModel(rows), nil
+}
+
+// RequeueWebhookDelivery queues a delivered or dead-lettered delivery to be
+// sent again at once, with its attempts starting over.
+func (r *PostgresRepository) RequeueWebhookDelivery(ctx context.Context, id int) (*model.WebhookDelivery, common.Error) {
+	tx, err := r.beginTx()
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := r.requeueWebhookDelivery(ctx, tx, id)
+	if err = r.finishTx(err, tx); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *PostgresRepository) requeueWebhookDelivery(ctx context.Context, db sqlContextGetter, id int) (*model.WebhookDelivery, common.Error) {
+	delivery, err := r.getWebhookDelivery(ctx, db, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status == model.WebhookPending {
+		err := fmt.Errorf("webhook delivery %d is pending", id)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the delivery is already queued"))
+	}
+
+	// build SQL query
+	query, args, qErr := r.pgsq.Update(repoTableWebhookDelivery).
+		SetMap(map[string]interface{}{
+			repoColumnWebhookDelivery.Status:         model.WebhookPending,
+			repoColumnWebhookDelivery.Attempts:       0,
+			repoColumnWebhookDelivery.NextAttemptAt:  sq.Expr("CURRENT_TIMESTAMP"),
+			repoColumnWebhookDelivery.DeadLetteredAt: nil,
+			repoColumnWebhookDelivery.UpdatedAt:      sq.Expr("CURRENT_TIMESTAMP"),
+		}).
+		Where(sq.Eq{repoColumnWebhookDelivery.ID: id}).
+		Suffix(fmt.Sprintf("returning %s", repoColumnWebhookDelivery.columns())).
+		ToSql()
+	if qErr != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, qErr)
+	}
+
+	// execute SQL query
+	var row repoWebhookDelivery
+	if qErr = db.GetContext(ctx, &row, query, args...); qErr != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, qErr)
+	}
+
+	requeued := model.WebhookDelivery(row)
+	return &requeued, nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSubscriptionRepository(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataUser))
+	ctx := context.Background()
+
+	loans, err := repo.CreateWebhookSubscription(ctx, model.WebhookSubscription{
+		URL:    "https://example.com/loans",
+		Events: []model.WebhookEventType{model.WebhookLoanCheckedOut, model.WebhookLoanReturned},
+		Secret: "whsec_loans",
+		Active: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "whsec_loans", loans.Secret)
+	catalog, err := repo.CreateWebhookSubscription(ctx, model.WebhookSubscription{
+		URL:    "https://example.com/catalog",
+		Events: []model.WebhookEventType{model.WebhookCatalogChanged},
+		Secret: "whsec_catalog",
+		Active: true,
+	})
+	require.NoError(t, err)
+
+	subs, err := repo.ListWebhookSubscriptions(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, subs, 2)
+	subs, err = repo.ListWebhookSubscriptions(ctx, model.WebhookLoanReturned)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, loans.ID, subs[0].ID)
+
+	// pausing a subscription keeps it from being sent events; the secret is kept
+	loans.Active = false
+	loans.Secret = ""
+	loans.Description = "circulation desk"
+	updated, err := repo.UpdateWebhookSubscription(ctx, *loans)
+	require.NoError(t, err)
+	assert.False(t, updated.Active)
+	assert.Equal(t, "circulation desk", updated.Description)
+	assert.Equal(t, "whsec_loans", updated.Secret)
+	subs, err = repo.ListWebhookSubscriptions(ctx, model.WebhookLoanReturned)
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+
+	require.NoError(t, repo.DeleteWebhookSubscription(ctx, catalog.ID))
+	_, err = repo.GetWebhookSubscriptionByID(ctx, catalog.ID)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+	err = repo.DeleteWebhookSubscription(ctx, catalog.ID)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
+
+func TestWebhookDeliveryRepository(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataUser))
+	ctx := context.Background()
+
+	sub, err := repo.CreateWebhookSubscription(ctx, model.WebhookSubscription{
+		URL:    "https://example.com/hooks",
+		Events: []model.WebhookEventType{model.WebhookHoldReady},
+		Secret: "whsec_hooks",
+		Active: true,
+	})
+	require.NoError(t, err)
+
+	delivery := model.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        "evt_1",
+		EventType:      model.WebhookHoldReady,
+		Payload:        []byte(`{"id":"evt_1"}`),
+	}
+	require.NoError(t, repo.EnqueueWebhookDeliveries(ctx, []model.WebhookDelivery{delivery}))
+	// the same event is queued once
+	require.NoError(t, repo.EnqueueWebhookDeliveries(ctx, []model.WebhookDelivery{delivery}))
+
+	now := time.Now()
+	claimed, err := repo.ClaimDueWebhookDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, model.WebhookPending, claimed[0].Status)
+	assert.JSONEq(t, `{"id":"evt_1"}`, string(claimed[0].Payload))
+
+	// a claimed delivery is not claimed again until its lease ends
+	again, err := repo.ClaimDueWebhookDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	status := 500
+	d := *claimed[0]
+	d.Status = model.WebhookDeadLettered
+	d.Attempts = 1
+	d.LastStatusCode = &status
+	d.LastError = "endpoint answered 500 Internal Server Error"
+	d.DeadLetteredAt = &now
+	_, err = repo.RecordWebhookAttempt(ctx, d)
+	require.NoError(t, err)
+
+	dead, err := repo.ListWebhookDeliveries(ctx, sub.ID, model.WebhookDeadLettered, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, 500, *dead[0].LastStatusCode)
+
+	requeued, err := repo.RequeueWebhookDelivery(ctx, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.WebhookPending, requeued.Status)
+	assert.Equal(t, 0, requeued.Attempts)
+	assert.Nil(t, requeued.DeadLetteredAt)
+
+	_, err = repo.RequeueWebhookDelivery(ctx, d.ID)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeParameterInvalid.Name, err.(common.DomainError).Name())
+
+	claimed, err = repo.ClaimDueWebhookDeliveries(ctx, time.Now().Add(time.Second), time.Minute, 10)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	_, err = repo.GetWebhookDeliveryByID(ctx, d.ID+1)
+	require.Error(t, err)
+	assert.Equal(t, common.ErrorCodeResourceNotFound.Name, err.(common.DomainError).Name())
+}
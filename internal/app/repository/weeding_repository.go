@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoWeedingCopy struct {
+	CopyID         int        `db:"copy_id"`
+	Barcode        string     `db:"barcode"`
+	BookID         int        `db:"book_id"`
+	Title          string     `db:"title"`
+	Author         string     `db:"author"`
+	HomeBranchID   int        `db:"home_branch_id"`
+	Status         string     `db:"status"`
+	CallNumber     string     `db:"call_number"`
+	Loans          int        `db:"loans"`
+	LastBorrowedAt *time.Time `db:"last_borrowed_at"`
+	AddedAt        time.Time  `db:"added_at"`
+}
+
+type repoHoldPressure struct {
+	WorkID int    `db:"work_id"`
+	Title  string `db:"title"`
+	Author string `db:"author"`
+	Holds  int    `db:"holds"`
+	Copies int    `db:"copies"`
+}
+
+// repoBookInSubjectTree matches the books, aliased b, filed under the
+// subject selected by subjectSubtreeCTE.
+var repoBookInSubjectTree = fmt.Sprintf(
+	"EXISTS (SELECT 1 FROM %s bs WHERE bs.book_id = b.id AND bs.subject_id IN (SELECT id FROM subtree))",
+	repoTableBookSubject,
+)
+
+// selectWeedingCopies selects the copies in the collection matching a
+// weeding filter, with their loans.
+func (r *PostgresRepository) selectWeedingCopies(filter model.WeedingFilter) sq.SelectBuilder {
+	where := sq.And{sq.Eq{"bc." + repoColumnBookCopies.RetiredAt: nil}}
+	if filter.BranchID != 0 {
+		where = append(where, sq.Eq{"bc." + repoColumnBookCopies.HomeBranchID: filter.BranchID})
+	}
+	if filter.SubjectID != 0 {
+		where = append(where, sq.Expr(repoBookInSubjectTree))
+	}
+
+	builder := r.pgsq.Select(
+		"bc.id AS copy_id",
+		"COALESCE(bc.barcode, '') AS barcode",
+		"b.id AS book_id",
+		"b.title",
+		"b.author",
+		"bc.home_branch_id",
+		"bc.status",
+		"COALESCE(bc.call_number, b.call_number, '') AS call_number",
+		"COUNT(bb.id) AS loans",
+		"MAX(bb.borrow_date) AS last_borrowed_at",
+		"bc.created_at AS added_at",
+	).
+		From(repoTableBookCopies+" bc").
+		Join(repoTableBook+" b ON b.id = bc.book_id").
+		LeftJoin("borrowed_books bb ON bb.copy_id = bc.id").
+		Where(where).
+		GroupBy("bc.id", "b.id")
+	if filter.SubjectID != 0 {
+		builder = builder.Prefix(subjectSubtreeCTE, filter.SubjectID)
+	}
+	return builder
+}
+
+// ListDormantCopies returns the copies not borrowed since a cutoff, longest
+// dormant first. Copies added after the cutoff have not had their chance yet
+// and are left out.
+func (r *PostgresRepository) ListDormantCopies(ctx context.Context, filter model.WeedingFilter, cutoff time.Time) ([]*model.WeedingCopy, common.Error) {
+	// build SQL query
+	query, args, err := r.selectWeedingCopies(filter).
+		Where(sq.Lt{"bc." + repoColumnBookCopies.CreatedAt: cutoff}).
+		Having("MAX(bb.borrow_date) IS NULL OR MAX(bb.borrow_date) < ?", cutoff).
+		OrderBy("last_borrowed_at NULLS FIRST", "bc.created_at", "bc.id").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	return r.listWeedingCopies(ctx, query, args)
+}
+
+// ListHighCirculationCopies returns the copies borrowed at least minLoans
+// times, most borrowed first. They are worn and due for replacement.
+func (r *PostgresRepository) ListHighCirculationCopies(ctx context.Context, filter model.WeedingFilter, minLoans int) ([]*model.WeedingCopy, common.Error) {
+	// build SQL query
+	query, args, err := r.selectWeedingCopies(filter).
+		Having("COUNT(bb.id) >= ?", minLoans).
+		OrderBy("loans DESC", "bc.id").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	return r.listWeedingCopies(ctx, query, args)
+}
+
+func (r *PostgresRepository) listWeedingCopies(ctx context.Context, query string, args []interface{}) ([]*model.WeedingCopy, common.Error) {
+	// execute SQL query
+	var rows []repoWeedingCopy
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	copies := make([]*model.WeedingCopy, 0, len(rows))
+	for _, row := range rows {
+		status, err := bookStatusFromRepo(row.Status)
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+		copies = append(copies, &model.WeedingCopy{
+			CopyID:         row.CopyID,
+			Barcode:        row.Barcode,
+			BookID:         row.BookID,
+			Title:          row.Title,
+			Author:         row.Author,
+			HomeBranchID:   row.HomeBranchID,
+			Status:         status,
+			CallNumber:     row.CallNumber,
+			Loans:          row.Loans,
+			LastBorrowedAt: row.LastBorrowedAt,
+			AddedAt:        row.AddedAt,
+		})
+	}
+	return copies, nil
+}
+
+// ListHoldPressure returns the titles with more active holds than copies in
+// the collection, the largest shortfall first. With a branch, holds picked up
+// there are weighed against copies it owns.
+func (r *PostgresRepository) ListHoldPressure(ctx context.Context, filter model.WeedingFilter) ([]*model.HoldPressure, common.Error) {
+	holds := sq.And{
+		sq.Expr("h.work_id = w.id"),
+		sq.Eq{"h." + repoColumnHold.Status: activeHoldStatuses},
+	}
+	copies := sq.And{
+		sq.Expr("b.work_id = w.id"),
+		sq.Eq{"bc." + repoColumnBookCopies.RetiredAt: nil},
+	}
+	if filter.BranchID != 0 {
+		holds = append(holds, sq.Eq{"h." + repoColumnHold.PickupBranchID: filter.BranchID})
+		copies = append(copies, sq.Eq{"bc." + repoColumnBookCopies.HomeBranchID: filter.BranchID})
+	}
+	holdsSQL, holdsArgs, err := holds.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	copiesSQL, copiesArgs, err := copies.ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// build SQL query
+	builder := r.pgsq.Select(
+		"w.id AS work_id",
+		"w.title",
+		"w.author",
+	).
+		Column(fmt.Sprintf("(SELECT COUNT(*) FROM %s h WHERE %s) AS holds", repoTableHold, holdsSQL), holdsArgs...).
+		Column(fmt.Sprintf("(SELECT COUNT(*) FROM %s bc JOIN %s b ON b.id = bc.book_id WHERE %s) AS copies",
+			repoTableBookCopies, repoTableBook, copiesSQL), copiesArgs...).
+		From(repoTableWork + " w")
+	if filter.SubjectID != 0 {
+		builder = builder.
+			Prefix(subjectSubtreeCTE, filter.SubjectID).
+			Where(sq.Expr(fmt.Sprintf("EXISTS (SELECT 1 FROM %s b WHERE b.work_id = w.id AND %s)", repoTableBook, repoBookInSubjectTree)))
+	}
+	query, args, err := r.pgsq.Select("*").
+		FromSelect(builder, "t").
+		Where("holds > copies").
+		OrderBy("holds - copies DESC", "work_id").
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoHoldPressure
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	pressure := make([]*model.HoldPressure, 0, len(rows))
+	for _, row := range rows {
+		p := model.HoldPressure(row)
+		pressure = append(pressure, &p)
+	}
+	return pressure, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initWeedingRepository(t *testing.T) *PostgresRepository {
+	db := getPostgresDB()
+	return initRepository(t, db,
+		testdata.Path(testdata.TestDataUser),
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBranch),
+		testdata.Path(testdata.TestDataBook),
+		testdata.Path(testdata.TestDataBookCopies),
+		testdata.Path(testdata.TestDataHold),
+		testdata.Path(testdata.TestDataSubject),
+		testdata.Path(testdata.TestDataBookSubject),
+		testdata.Path(testdata.TestDataBorrowedBook),
+	)
+}
+
+func TestWeedingRepository_ListDormantCopies(t *testing.T) {
+	repo := initWeedingRepository(t)
+	cutoff := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// copy 2 was last borrowed in 2018, copies 1 and 3 in 2023
+	copies, err := repo.ListDormantCopies(context.Background(), model.WeedingFilter{}, cutoff)
+	require.NoError(t, err)
+	require.Len(t, copies, 1)
+	assert.Equal(t, 2, copies[0].CopyID)
+	assert.Equal(t, 2, copies[0].Loans)
+	require.NotNil(t, copies[0].LastBorrowedAt)
+	assert.Equal(t, 2018, copies[0].LastBorrowedAt.Year())
+
+	// a copy never borrowed is dormant
+	_, err = repo.CreateBookCopy(context.Background(), model.NewBookCopies(3, "30000000000038", model.InLibrary), "librarian")
+	require.NoError(t, err)
+	copies, err = repo.ListDormantCopies(context.Background(), model.WeedingFilter{}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, copies, 4)
+	assert.Nil(t, copies[0].LastBorrowedAt)
+
+	// copy 2 is filed under Classics, a child of Fiction
+	copies, err = repo.ListDormantCopies(context.Background(), model.WeedingFilter{SubjectID: 1}, cutoff)
+	require.NoError(t, err)
+	assert.Len(t, copies, 1)
+	copies, err = repo.ListDormantCopies(context.Background(), model.WeedingFilter{SubjectID: 4}, cutoff)
+	require.NoError(t, err)
+	assert.Empty(t, copies)
+
+	copies, err = repo.ListDormantCopies(context.Background(), model.WeedingFilter{BranchID: 2}, cutoff)
+	require.NoError(t, err)
+	assert.Empty(t, copies)
+}
+
+func TestWeedingRepository_ListHighCirculationCopies(t *testing.T) {
+	repo := initWeedingRepository(t)
+
+	copies, err := repo.ListHighCirculationCopies(context.Background(), model.WeedingFilter{}, 2)
+	require.NoError(t, err)
+	require.Len(t, copies, 2)
+	assert.Equal(t, 1, copies[0].CopyID)
+	assert.Equal(t, 2, copies[1].CopyID)
+
+	copies, err = repo.ListHighCirculationCopies(context.Background(), model.WeedingFilter{SubjectID: 3}, 2)
+	require.NoError(t, err)
+	require.Len(t, copies, 1)
+	assert.Equal(t, 1, copies[0].CopyID)
+	assert.Equal(t, "863.3 CER", copies[0].CallNumber)
+}
+
+func TestWeedingRepository_ListHoldPressure(t *testing.T) {
+	repo := initWeedingRepository(t)
+
+	// work 1 has two holds for two copies
+	pressure, err := repo.ListHoldPressure(context.Background(), model.WeedingFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, pressure)
+
+	_, err = repo.CreateHold(context.Background(), model.NewHold(3, 1, 2))
+	require.NoError(t, err)
+
+	pressure, err = repo.ListHoldPressure(context.Background(), model.WeedingFilter{})
+	require.NoError(t, err)
+	require.Len(t, pressure, 1)
+	assert.Equal(t, 1, pressure[0].WorkID)
+	assert.Equal(t, 3, pressure[0].Holds)
+	assert.Equal(t, 2, pressure[0].Copies)
+
+	// the east branch owns no copy for its hold
+	pressure, err = repo.ListHoldPressure(context.Background(), model.WeedingFilter{BranchID: 2})
+	require.NoError(t, err)
+	require.Len(t, pressure, 1)
+	assert.Equal(t, 1, pressure[0].Shortfall())
+
+	pressure, err = repo.ListHoldPressure(context.Background(), model.WeedingFilter{SubjectID: 4})
+	require.NoError(t, err)
+	assert.Empty(t, pressure)
+}
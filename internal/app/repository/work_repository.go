@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type repoWork struct {
+	ID        int       `db:"id"`
+	Title     string    `db:"title"`
+	Author    string    `db:"author"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type repoColumnPatternWork struct {
+	ID        string
+	Title     string
+	Author    string
+	CreatedAt string
+	UpdatedAt string
+}
+
+const repoTableWork = "works"
+
+var repoColumnWork = repoColumnPatternWork{
+	ID:        "id",
+	Title:     "title",
+	Author:    "author",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+func (c *repoColumnPatternWork) columns() string {
+	return strings.Join([]string{
+		c.ID,
+		c.Title,
+		c.Author,
+		c.CreatedAt,
+		c.UpdatedAt,
+	}, ", ")
+}
+
+func (r *PostgresRepository) CreateWork(ctx context.Context, param model.Work) (*model.Work, common.Error) {
+	return r.createWork(ctx, r.db, param)
+}
+
+func (r *PostgresRepository) createWork(ctx context.Context, db sqlContextGetter, param model.Work) (*model.Work, common.Error) {
+	insert := map[string]interface{}{
+		repoColumnWork.Title:  param.Title,
+		repoColumnWork.Author: param.Author,
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Insert(repoTableWork).
+		SetMap(insert).
+		Suffix(fmt.Sprintf("returning %s", repoColumnWork.columns())).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoWork
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	work := model.Work(row)
+	return &work, nil
+}
+
+// findOrCreateWork returns the work with the same title and author, ignoring
+// case, or creates it.
+func (r *PostgresRepository) findOrCreateWork(ctx context.Context, db sqlContextGetter, param model.Work) (*model.Work, common.Error) {
+	where := sq.And{
+		sq.Expr(fmt.Sprintf("LOWER(%s) = LOWER(?)", repoColumnWork.Title), param.Title),
+		sq.Expr(fmt.Sprintf("LOWER(%s) = LOWER(?)", repoColumnWork.Author), param.Author),
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnWork.columns()).
+		From(repoTableWork).
+		Where(where).
+		OrderBy(repoColumnWork.ID).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoWork
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return r.createWork(ctx, db, param)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	work := model.Work(row)
+	return &work, nil
+}
+
+func (r *PostgresRepository) GetWorkByID(ctx context.Context, id int) (*model.Work, common.Error) {
+	where := sq.And{
+		sq.Eq{repoColumnWork.ID: id},
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnWork.columns()).
+		From(repoTableWork).
+		Where(where).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var row repoWork
+	if err = r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err)
+		}
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	work := model.Work(row)
+	return &work, nil
+}
+
+// ListWorksByIDs returns the works with the given IDs, ordered by ID.
+func (r *PostgresRepository) ListWorksByIDs(ctx context.Context, ids []int) ([]*model.Work, common.Error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnWork.columns()).
+		From(repoTableWork).
+		Where(sq.Eq{repoColumnWork.ID: ids}).
+		OrderBy(repoColumnWork.ID).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoWork
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var works []*model.Work
+	for _, row := range rows {
+		work := model.Work(row)
+		works = append(works, &work)
+	}
+
+	return works, nil
+}
+
+// SearchWorks returns works whose own title or author, or the title or ISBN
+// of any of their editions, matches the keyword.
+func (r *PostgresRepository) SearchWorks(ctx context.Context, keyword string, limit, offset int) ([]*model.Work, common.Error) {
+	where := sq.And{}
+	if keyword != "" {
+		pattern := likePattern(keyword)
+		where = append(where, sq.Or{
+			sq.ILike{repoColumnWork.Title: pattern},
+			sq.ILike{repoColumnWork.Author: pattern},
+			sq.Expr(fmt.Sprintf(
+				"EXISTS (SELECT 1 FROM %s b WHERE b.%s = %s.%s AND (b.%s ILIKE ? OR b.%s = ?))",
+				repoTableBook, repoColumnBook.WorkID, repoTableWork, repoColumnWork.ID,
+				repoColumnBook.Title, repoColumnBook.ISBN,
+			), pattern, keyword),
+		})
+	}
+
+	// build SQL query
+	query, args, err := r.pgsq.Select(repoColumnWork.columns()).
+		From(repoTableWork).
+		Where(where).
+		OrderBy(repoColumnWork.Title, repoColumnWork.ID).
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		ToSql()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	// execute SQL query
+	var rows []repoWork
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	var works []*model.Work
+	for _, row := range rows {
+		work := model.Work(row)
+		works = append(works, &work)
+	}
+
+	return works, nil
+}
+
+// likePattern escapes LIKE wildcards in keyword and wraps it for a substring match.
+func likePattern(keyword string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(keyword) + "%"
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/lzzzzl/page-turner-pro/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkRepository_CreateWork(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db)
+
+	// Args
+	type Args struct {
+		model.Work
+	}
+	var args Args
+	_ = faker.FakeData(&args)
+
+	work, err := repo.CreateWork(context.Background(), args.Work)
+	require.NoError(t, err)
+	assert.Equal(t, args.Title, work.Title)
+	assert.Equal(t, args.Author, work.Author)
+}
+
+func TestWorkRepository_GetWorkByID(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db, testdata.Path(testdata.TestDataWork))
+
+	work, err := repo.GetWorkByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Don Quixote", work.Title)
+
+	_, err = repo.GetWorkByID(context.Background(), 999)
+	require.Error(t, err)
+}
+
+func TestWorkRepository_SearchWorks(t *testing.T) {
+	db := getPostgresDB()
+	repo := initRepository(t, db,
+		testdata.Path(testdata.TestDataWork),
+		testdata.Path(testdata.TestDataBook),
+	)
+
+	tests := []struct {
+		Name    string
+		Keyword string
+		Count   int
+	}{
+		{Name: "all works", Keyword: "", Count: 2},
+		{Name: "match work title", Keyword: "little prince", Count: 1},
+		{Name: "match edition title only", Keyword: "Quijote", Count: 1},
+		{Name: "match edition isbn", Keyword: "9780060934347", Count: 1},
+		{Name: "no match", Keyword: "Ulysses", Count: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			works, err := repo.SearchWorks(context.Background(), tt.Keyword, 10, 0)
+			require.NoError(t, err)
+			assert.Len(t, works, tt.Count)
+		})
+	}
+}
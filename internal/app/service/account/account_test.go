@@ -0,0 +1,178 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/mailer"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryRepository keeps accounts the way the database does, for the flows
+// to run without one.
+type memoryRepository struct {
+	users     []*model.User
+	passwords map[int]string
+	tokens    []*model.AccountToken
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{passwords: map[int]string{}}
+}
+
+func (r *memoryRepository) GetUserByEmail(_ context.Context, email string) (*model.User, common.Error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, common.NewError(common.ErrorCodeResourceNotFound, errors.New("no user"))
+}
+
+func (r *memoryRepository) CreateAccount(ctx context.Context, param model.User, passwordHash string, token model.AccountToken) (*model.User, common.Error) {
+	if _, err := r.GetUserByEmail(ctx, param.Email); err == nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, errors.New("taken"))
+	}
+	param.ID = len(r.users) + 1
+	r.users = append(r.users, &param)
+	r.passwords[param.ID] = passwordHash
+	token.UserID = param.ID
+	r.tokens = append(r.tokens, &token)
+	return &param, nil
+}
+
+func (r *memoryRepository) IssueAccountToken(_ context.Context, param model.AccountToken) (*model.AccountToken, common.Error) {
+	now := time.Now()
+	for _, t := range r.tokens {
+		if t.UserID == param.UserID && t.Purpose == param.Purpose && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	r.tokens = append(r.tokens, &param)
+	return &param, nil
+}
+
+func (r *memoryRepository) useToken(tokenHash string, purpose model.AccountTokenPurpose, now time.Time) (*model.User, common.Error) {
+	for _, t := range r.tokens {
+		if t.TokenHash != tokenHash {
+			continue
+		}
+		if err := model.ValidateAccountToken(*t, purpose, now); err != nil {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err)
+		}
+		t.UsedAt = &now
+		u := r.users[t.UserID-1]
+		u.EmailVerifiedAt = &now
+		return u, nil
+	}
+	return nil, common.NewError(common.ErrorCodeParameterInvalid, errors.New("no token"))
+}
+
+func (r *memoryRepository) VerifyEmail(_ context.Context, tokenHash string, now time.Time) (*model.User, common.Error) {
+	return r.useToken(tokenHash, model.TokenVerifyEmail, now)
+}
+
+func (r *memoryRepository) ResetPassword(_ context.Context, tokenHash, passwordHash string, now time.Time) (*model.User, common.Error) {
+	u, err := r.useToken(tokenHash, model.TokenResetPassword, now)
+	if err != nil {
+		return nil, err
+	}
+	r.passwords[u.ID] = passwordHash
+	return u, nil
+}
+
+var tokenPattern = regexp.MustCompile(`https?://\S+`)
+
+// mailedToken returns the token linked to in a message.
+func mailedToken(t *testing.T, msg mailer.Message) string {
+	link := tokenPattern.FindString(msg.Body)
+	require.NotEmpty(t, link)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func newTestService() (*AccountService, *memoryRepository, *mailer.MemoryMailer) {
+	repo := newMemoryRepository()
+	mail := mailer.NewMemoryMailer()
+	s := NewAccountService(context.Background(), AccountServiceParam{
+		UserRepo:        repo,
+		AccountRepo:     repo,
+		Mailer:          mail,
+		BaseURL:         "https://library.example/",
+		VerificationTTL: 48 * time.Hour,
+		ResetTTL:        time.Hour,
+	})
+	return s, repo, mail
+}
+
+func TestAccountService_Signup(t *testing.T) {
+	s, repo, mail := newTestService()
+	ctx := context.Background()
+
+	_, err := s.Signup(ctx, SignupParam{Email: "reader@example.com", Name: "Reader", Password: "short"})
+	require.Error(t, err)
+	assert.Equal(t, "password is shorter than 8 characters", err.ClientMsg())
+
+	user, err := s.Signup(ctx, SignupParam{Email: "Reader@Example.com", Name: "Reader", Password: "correct horse"})
+	require.NoError(t, err)
+	assert.Equal(t, "reader@example.com", user.Email)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(repo.passwords[user.ID]), []byte("correct horse")))
+
+	msgs := mail.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "reader@example.com", msgs[0].To)
+	assert.Contains(t, msgs[0].Body, "https://library.example/verify_email?token=")
+
+	// a fresh link supersedes the first one
+	require.NoError(t, s.ResendVerification(ctx, "reader@example.com"))
+	msgs = mail.Messages()
+	require.Len(t, msgs, 2)
+	_, err = s.VerifyEmail(ctx, mailedToken(t, msgs[0]))
+	require.Error(t, err)
+
+	verified, err := s.VerifyEmail(ctx, mailedToken(t, msgs[1]))
+	require.NoError(t, err)
+	assert.NotNil(t, verified.EmailVerifiedAt)
+
+	// nothing is sent for verified or unknown addresses
+	require.NoError(t, s.ResendVerification(ctx, "reader@example.com"))
+	require.NoError(t, s.ResendVerification(ctx, "stranger@example.com"))
+	assert.Len(t, mail.Messages(), 2)
+}
+
+func TestAccountService_ResetPassword(t *testing.T) {
+	s, repo, mail := newTestService()
+	ctx := context.Background()
+
+	user, err := s.Signup(ctx, SignupParam{Email: "reader@example.com", Name: "Reader", Password: "correct horse"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.RequestPasswordReset(ctx, "stranger@example.com"))
+	assert.Len(t, mail.Messages(), 1)
+
+	require.NoError(t, s.RequestPasswordReset(ctx, "reader@example.com"))
+	msgs := mail.Messages()
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "Reset your password", msgs[1].Subject)
+	token := mailedToken(t, msgs[1])
+
+	_, err = s.ResetPassword(ctx, token, "short")
+	require.Error(t, err)
+
+	_, err = s.ResetPassword(ctx, token, "battery staple")
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(repo.passwords[user.ID]), []byte("battery staple")))
+
+	// the link works once
+	_, err = s.ResetPassword(ctx, token, "another staple")
+	require.Error(t, err)
+}
@@ -0,0 +1,20 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type UserRepository interface {
+	GetUserByEmail(ctx context.Context, email string) (*model.User, common.Error)
+}
+
+type AccountRepository interface {
+	CreateAccount(ctx context.Context, param model.User, passwordHash string, token model.AccountToken) (*model.User, common.Error)
+	IssueAccountToken(ctx context.Context, param model.AccountToken) (*model.AccountToken, common.Error)
+	VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (*model.User, common.Error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (*model.User, common.Error)
+}
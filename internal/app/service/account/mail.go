@@ -0,0 +1,40 @@
+package account
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/mailer"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+// tokenLink returns the link on the library site a token is to be used at.
+func tokenLink(baseURL, path, secret string) string {
+	return fmt.Sprintf("%s/%s?token=%s", strings.TrimRight(baseURL, "/"), path, url.QueryEscape(secret))
+}
+
+func verificationMessage(user model.User, link string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Please confirm your email address by opening the link below:\n\n"+
+			"%s\n\n"+
+			"The link is valid for %s. If you did not sign up at the library, ignore this email.\n",
+			user.Name, link, ttl),
+	}
+}
+
+func resetMessage(user model.User, link string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Someone asked to reset the password of your library account. To choose a new one, open the link below:\n\n"+
+			"%s\n\n"+
+			"The link is valid for %s and works once. If you did not ask for it, ignore this email; your password stays as it is.\n",
+			user.Name, link, ttl),
+	}
+}
@@ -0,0 +1,35 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// RequestPasswordReset mails a patron a link to choose a new password,
+// superseding earlier ones. As with ResendVerification, the caller is not told
+// whether the address is known.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) common.Error {
+	user, cErr := s.findPatron(ctx, email)
+	if cErr != nil || user == nil {
+		return cErr
+	}
+	return s.issueAndSend(ctx, *user, model.TokenResetPassword, s.resetTTL, "reset_password", resetMessage)
+}
+
+// ResetPassword sets the password of a patron with the token mailed to them.
+func (s *AccountService) ResetPassword(ctx context.Context, secret, password string) (*model.User, common.Error) {
+	hash, cErr := hashPassword(password)
+	if cErr != nil {
+		return nil, cErr
+	}
+	user, cErr := s.accountRepo.ResetPassword(ctx, model.HashAccountToken(secret), hash, time.Now())
+	if cErr != nil {
+		zerolog.Ctx(ctx).Error().Err(cErr).Msg("failed to reset password")
+		return nil, cErr
+	}
+	return user, nil
+}
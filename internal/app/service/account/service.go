@@ -0,0 +1,44 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/mailer"
+)
+
+type AccountService struct {
+	userRepo    UserRepository
+	accountRepo AccountRepository
+	mailer      mailer.Mailer
+
+	baseURL         string
+	verificationTTL time.Duration
+	resetTTL        time.Duration
+}
+
+type AccountServiceParam struct {
+	UserRepo    UserRepository
+	AccountRepo AccountRepository
+	Mailer      mailer.Mailer
+
+	// BaseURL is where patrons reach the library site, which the links in
+	// account mails point to.
+	BaseURL string
+	// VerificationTTL and ResetTTL are how long email verification and
+	// password reset tokens stay valid.
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+}
+
+func NewAccountService(_ context.Context, param AccountServiceParam) *AccountService {
+	return &AccountService{
+		userRepo:    param.UserRepo,
+		accountRepo: param.AccountRepo,
+		mailer:      param.Mailer,
+
+		baseURL:         param.BaseURL,
+		verificationTTL: param.VerificationTTL,
+		resetTTL:        param.ResetTTL,
+	}
+}
@@ -0,0 +1,130 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lzzzzl/page-turner-pro/internal/app/mailer"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SignupParam struct {
+	Email    string
+	Name     string
+	Password string
+}
+
+// Signup creates the account of a patron and mails them a link verifying
+// their address. The account is created even if the mail cannot be sent; the
+// patron may ask for another link.
+func (s *AccountService) Signup(ctx context.Context, param SignupParam) (*model.User, common.Error) {
+	email, err := model.NormalizeEmail(param.Email)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	hash, cErr := hashPassword(param.Password)
+	if cErr != nil {
+		return nil, cErr
+	}
+	token, secret, err := model.NewAccountToken(0, model.TokenVerifyEmail, time.Now(), s.verificationTTL)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+
+	user, cErr := s.accountRepo.CreateAccount(ctx, model.User{
+		UID:   uuid.NewString(),
+		Email: email,
+		Name:  param.Name,
+	}, hash, token)
+	if cErr != nil {
+		zerolog.Ctx(ctx).Error().Err(cErr).Str("email", email).Msg("failed to create account")
+		return nil, cErr
+	}
+
+	msg := verificationMessage(*user, tokenLink(s.baseURL, "verify_email", secret), s.verificationTTL)
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", user.ID).Msg("failed to send verification email")
+	}
+	return user, nil
+}
+
+// VerifyEmail marks the address of a patron verified with the token mailed to
+// them.
+func (s *AccountService) VerifyEmail(ctx context.Context, secret string) (*model.User, common.Error) {
+	user, err := s.accountRepo.VerifyEmail(ctx, model.HashAccountToken(secret), time.Now())
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to verify email")
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResendVerification mails a new verification link, superseding earlier ones.
+// Nothing is sent for unknown or already verified addresses, and the caller
+// is not told, so the addresses of patrons cannot be probed.
+func (s *AccountService) ResendVerification(ctx context.Context, email string) common.Error {
+	user, cErr := s.findPatron(ctx, email)
+	if cErr != nil || user == nil || user.EmailVerifiedAt != nil {
+		return cErr
+	}
+	return s.issueAndSend(ctx, *user, model.TokenVerifyEmail, s.verificationTTL, "verify_email", verificationMessage)
+}
+
+// findPatron returns the patron with an address, or nil if there is none.
+func (s *AccountService) findPatron(ctx context.Context, email string) (*model.User, common.Error) {
+	email, err := model.NormalizeEmail(email)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	user, cErr := s.userRepo.GetUserByEmail(ctx, email)
+	if cErr != nil {
+		if isResourceNotFound(cErr) {
+			return nil, nil
+		}
+		zerolog.Ctx(ctx).Error().Err(cErr).Str("email", email).Msg("failed to get user")
+		return nil, cErr
+	}
+	if user.IsErased() {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// issueAndSend issues a token to a patron and mails it to them.
+func (s *AccountService) issueAndSend(ctx context.Context, user model.User, purpose model.AccountTokenPurpose, ttl time.Duration, path string,
+	message func(model.User, string, time.Duration) mailer.Message) common.Error {
+	token, secret, err := model.NewAccountToken(user.ID, purpose, time.Now(), ttl)
+	if err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	if _, cErr := s.accountRepo.IssueAccountToken(ctx, token); cErr != nil {
+		zerolog.Ctx(ctx).Error().Err(cErr).Int("user_id", user.ID).Str("purpose", string(purpose)).Msg("failed to issue account token")
+		return cErr
+	}
+	if err := s.mailer.Send(ctx, message(user, tokenLink(s.baseURL, path, secret), ttl)); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", user.ID).Str("purpose", string(purpose)).Msg("failed to send account email")
+		return common.NewError(common.ErrorCodeRemoteProcess, err, common.WithMsg("the email could not be sent"))
+	}
+	return nil
+}
+
+// hashPassword checks and hashes a password.
+func hashPassword(password string) (string, common.Error) {
+	if err := model.ValidatePassword(password); err != nil {
+		return "", common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return string(hash), nil
+}
+
+func isResourceNotFound(err common.Error) bool {
+	de, ok := err.(common.DomainError)
+	return ok && de.Name() == common.ErrorCodeResourceNotFound.Name
+}
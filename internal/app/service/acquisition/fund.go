@@ -0,0 +1,80 @@
+package acquisition
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+type FundParam struct {
+	Code        string
+	Name        string
+	FiscalYear  int
+	BudgetCents int64
+}
+
+func (p FundParam) toModel() (model.Fund, common.Error) {
+	code, err := model.NormalizeFundCode(p.Code)
+	if err != nil {
+		return model.Fund{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		err := fmt.Errorf("fund name is empty")
+		return model.Fund{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if p.FiscalYear < 1900 || p.FiscalYear > 9999 {
+		err := fmt.Errorf("fiscal year %d is invalid", p.FiscalYear)
+		return model.Fund{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if p.BudgetCents < 0 {
+		err := fmt.Errorf("budget %d is negative", p.BudgetCents)
+		return model.Fund{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return model.NewFund(code, name, p.FiscalYear, p.BudgetCents), nil
+}
+
+func (s *AcquisitionService) CreateFund(ctx context.Context, param FundParam) (*model.Fund, common.Error) {
+	fund, err := param.toModel()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.fundRepo.CreateFund(ctx, fund)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("code", fund.Code).Int("fiscal_year", fund.FiscalYear).Msg("failed to create fund")
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetFund returns a fund with what it has encumbered and spent.
+func (s *AcquisitionService) GetFund(ctx context.Context, id int) (*model.Fund, common.Error) {
+	return s.fundRepo.GetFundByID(ctx, id)
+}
+
+// ListFunds returns the funds of a fiscal year, of every year when it is zero.
+func (s *AcquisitionService) ListFunds(ctx context.Context, fiscalYear int) ([]*model.Fund, common.Error) {
+	return s.fundRepo.ListFunds(ctx, fiscalYear)
+}
+
+// UpdateFund renames a fund or changes its budget. A budget cannot drop below
+// what the fund has already encumbered and spent.
+func (s *AcquisitionService) UpdateFund(ctx context.Context, id int, param FundParam) (*model.Fund, common.Error) {
+	fund, err := param.toModel()
+	if err != nil {
+		return nil, err
+	}
+	fund.ID = id
+
+	updated, err := s.fundRepo.UpdateFund(ctx, fund)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("fund_id", id).Msg("failed to update fund")
+		return nil, err
+	}
+	return updated, nil
+}
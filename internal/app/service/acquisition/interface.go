@@ -0,0 +1,50 @@
+package acquisition
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type VendorRepository interface {
+	CreateVendor(ctx context.Context, param model.Vendor) (*model.Vendor, common.Error)
+	GetVendorByID(ctx context.Context, id int) (*model.Vendor, common.Error)
+	ListVendors(ctx context.Context) ([]*model.Vendor, common.Error)
+	UpdateVendor(ctx context.Context, param model.Vendor) (*model.Vendor, common.Error)
+}
+
+type FundRepository interface {
+	CreateFund(ctx context.Context, param model.Fund) (*model.Fund, common.Error)
+	GetFundByID(ctx context.Context, id int) (*model.Fund, common.Error)
+	ListFunds(ctx context.Context, fiscalYear int) ([]*model.Fund, common.Error)
+	UpdateFund(ctx context.Context, param model.Fund) (*model.Fund, common.Error)
+}
+
+type PurchaseOrderRepository interface {
+	CreatePurchaseOrder(ctx context.Context, param model.PurchaseOrder) (*model.PurchaseOrder, common.Error)
+	GetPurchaseOrderByID(ctx context.Context, id int) (*model.PurchaseOrder, common.Error)
+	ListPurchaseOrders(ctx context.Context, vendorID int, status model.PurchaseOrderStatus) ([]*model.PurchaseOrder, common.Error)
+	AddPurchaseOrderLine(ctx context.Context, orderID int, param model.PurchaseOrderLine) (*model.PurchaseOrder, common.Error)
+	RemovePurchaseOrderLine(ctx context.Context, orderID, lineID int) (*model.PurchaseOrder, common.Error)
+	PlacePurchaseOrder(ctx context.Context, id int) (*model.PurchaseOrder, common.Error)
+	ReceivePurchaseOrderLine(ctx context.Context, orderID, lineID int, copies []model.BookCopies, receivedBy string) ([]*model.BookCopies, common.Error)
+	CancelPurchaseOrder(ctx context.Context, id int) (*model.PurchaseOrder, common.Error)
+}
+
+type BookRepository interface {
+	GetBookByID(ctx context.Context, id int) (*model.Book, common.Error)
+}
+
+type BranchRepository interface {
+	GetBranchByID(ctx context.Context, id int) (*model.Branch, common.Error)
+}
+
+type CopyRepository interface {
+	NextCopyBarcodeNumber(ctx context.Context) (int64, common.Error)
+}
+
+// HoldTrapper hands copies back on the shelf to the holds waiting for their work.
+type HoldTrapper interface {
+	TrapAvailableCopies(ctx context.Context, workID int) ([]*model.Hold, common.Error)
+}
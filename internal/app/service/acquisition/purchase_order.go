@@ -0,0 +1,262 @@
+package acquisition
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// maxBarcodeAttempts bounds the retries when a generated barcode is already
+// printed on a label entered by hand.
+const maxBarcodeAttempts = 3
+
+type CreatePurchaseOrderParam struct {
+	VendorID int
+	// BranchID is the branch the order is delivered to, the main branch when zero.
+	BranchID  int
+	Reference string
+	Notes     string
+	CreatedBy string
+}
+
+// CreatePurchaseOrder drafts an order with a vendor. Lines are added to the
+// draft before it is placed.
+func (s *AcquisitionService) CreatePurchaseOrder(ctx context.Context, param CreatePurchaseOrderParam) (*model.PurchaseOrder, common.Error) {
+	if err := requireActor(param.CreatedBy); err != nil {
+		return nil, err
+	}
+	if _, err := s.vendorRepo.GetVendorByID(ctx, param.VendorID); err != nil {
+		return nil, err
+	}
+	if param.BranchID != 0 {
+		if _, err := s.branchRepo.GetBranchByID(ctx, param.BranchID); err != nil {
+			return nil, err
+		}
+	}
+
+	order := model.NewPurchaseOrder(param.VendorID, param.BranchID, strings.TrimSpace(param.Reference), strings.TrimSpace(param.Notes), param.CreatedBy)
+	created, err := s.orderRepo.CreatePurchaseOrder(ctx, order)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("vendor_id", param.VendorID).Msg("failed to create purchase order")
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *AcquisitionService) GetPurchaseOrder(ctx context.Context, id int) (*model.PurchaseOrder, common.Error) {
+	return s.orderRepo.GetPurchaseOrderByID(ctx, id)
+}
+
+// ListPurchaseOrders lists orders, newest first, optionally of a vendor or in a status.
+func (s *AcquisitionService) ListPurchaseOrders(ctx context.Context, vendorID int, status model.PurchaseOrderStatus) ([]*model.PurchaseOrder, common.Error) {
+	if status != "" && !status.IsValid() {
+		err := fmt.Errorf("purchase order status %q is invalid", status)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return s.orderRepo.ListPurchaseOrders(ctx, vendorID, status)
+}
+
+type PurchaseOrderLineParam struct {
+	BookID         int
+	FundID         int
+	Quantity       int
+	UnitPriceCents int64
+}
+
+// AddPurchaseOrderLine orders copies of a book on a draft order, charged to a fund.
+func (s *AcquisitionService) AddPurchaseOrderLine(ctx context.Context, orderID int, param PurchaseOrderLineParam) (*model.PurchaseOrder, common.Error) {
+	line, lineErr := model.NewPurchaseOrderLine(param.BookID, param.FundID, param.Quantity, param.UnitPriceCents)
+	if lineErr != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, lineErr, common.WithMsg(lineErr.Error()))
+	}
+	if _, err := s.bookRepo.GetBookByID(ctx, param.BookID); err != nil {
+		return nil, err
+	}
+	if _, err := s.fundRepo.GetFundByID(ctx, param.FundID); err != nil {
+		return nil, err
+	}
+
+	order, err := s.orderRepo.AddPurchaseOrderLine(ctx, orderID, line)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("order_id", orderID).Int("book_id", param.BookID).Msg("failed to add purchase order line")
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *AcquisitionService) RemovePurchaseOrderLine(ctx context.Context, orderID, lineID int) (*model.PurchaseOrder, common.Error) {
+	order, err := s.orderRepo.RemovePurchaseOrderLine(ctx, orderID, lineID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("order_id", orderID).Int("line_id", lineID).Msg("failed to remove purchase order line")
+		return nil, err
+	}
+	return order, nil
+}
+
+// PlacePurchaseOrder sends a draft to its vendor, encumbering the funds of its
+// lines. It fails when a fund has not enough budget left.
+func (s *AcquisitionService) PlacePurchaseOrder(ctx context.Context, id int) (*model.PurchaseOrder, common.Error) {
+	order, err := s.orderRepo.PlacePurchaseOrder(ctx, id)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("order_id", id).Msg("failed to place purchase order")
+		return nil, err
+	}
+	return order, nil
+}
+
+// CancelPurchaseOrder drops an order not fully received, releasing what it
+// still encumbers.
+func (s *AcquisitionService) CancelPurchaseOrder(ctx context.Context, id int) (*model.PurchaseOrder, common.Error) {
+	order, err := s.orderRepo.CancelPurchaseOrder(ctx, id)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("order_id", id).Msg("failed to cancel purchase order")
+		return nil, err
+	}
+	return order, nil
+}
+
+type ReceivePurchaseOrderLineParam struct {
+	OrderID int
+	LineID  int
+	// Quantity is the number of copies delivered.
+	Quantity int
+	// Barcodes are the labels of the copies delivered, one per copy. Barcodes
+	// are generated when none are given.
+	Barcodes []string
+	// ReceivedBy is the staff member receiving the delivery, recorded in the status history of the copies.
+	ReceivedBy string
+}
+
+// ReceivePurchaseOrderLine adds the copies delivered for an order line to the
+// collection, at the branch of the order, and moves their cost from
+// encumbered to spent. The new copies are handed to waiting holds.
+func (s *AcquisitionService) ReceivePurchaseOrderLine(ctx context.Context, param ReceivePurchaseOrderLineParam) ([]*model.BookCopies, common.Error) {
+	if err := requireActor(param.ReceivedBy); err != nil {
+		return nil, err
+	}
+	quantity := param.Quantity
+	if quantity == 0 {
+		quantity = len(param.Barcodes)
+	}
+	if len(param.Barcodes) > 0 && len(param.Barcodes) != quantity {
+		err := fmt.Errorf("%d barcodes given for %d copies", len(param.Barcodes), quantity)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if quantity <= 0 {
+		err := fmt.Errorf("quantity %d is not positive", quantity)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	order, err := s.orderRepo.GetPurchaseOrderByID(ctx, param.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	var line *model.PurchaseOrderLine
+	for _, l := range order.Lines {
+		if l.ID == param.LineID {
+			line = l
+		}
+	}
+	if line == nil {
+		err := fmt.Errorf("line %d not on purchase order %d", param.LineID, param.OrderID)
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("purchase order line not found"))
+	}
+	if receiptErr := model.ValidateReceipt(*order, *line, quantity); receiptErr != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, receiptErr, common.WithMsg(receiptErr.Error()))
+	}
+
+	var copies []*model.BookCopies
+	if len(param.Barcodes) > 0 {
+		copies, err = s.receiveLabelledCopies(ctx, param, line.BookID)
+	} else {
+		copies, err = s.receiveUnlabelledCopies(ctx, param, line.BookID, quantity)
+	}
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("order_id", param.OrderID).Int("line_id", param.LineID).Msg("failed to receive purchase order line")
+		return nil, err
+	}
+
+	s.trapHolds(ctx, line.BookID)
+	return copies, nil
+}
+
+func (s *AcquisitionService) receiveLabelledCopies(ctx context.Context, param ReceivePurchaseOrderLineParam, bookID int) ([]*model.BookCopies, common.Error) {
+	copies := make([]model.BookCopies, 0, len(param.Barcodes))
+	seen := make(map[string]bool, len(param.Barcodes))
+	for _, raw := range param.Barcodes {
+		barcode, err := s.barcodeFormats.Validate(raw)
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+		}
+		if seen[barcode] {
+			err := fmt.Errorf("barcode %s is given twice", barcode)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+		}
+		seen[barcode] = true
+		copies = append(copies, model.NewBookCopies(bookID, barcode, model.InLibrary))
+	}
+	return s.orderRepo.ReceivePurchaseOrderLine(ctx, param.OrderID, param.LineID, copies, param.ReceivedBy)
+}
+
+func (s *AcquisitionService) receiveUnlabelledCopies(ctx context.Context, param ReceivePurchaseOrderLineParam, bookID, quantity int) ([]*model.BookCopies, common.Error) {
+	for attempt := 1; ; attempt++ {
+		copies := make([]model.BookCopies, 0, quantity)
+		for i := 0; i < quantity; i++ {
+			barcode, err := s.generateBarcode(ctx)
+			if err != nil {
+				return nil, err
+			}
+			copies = append(copies, model.NewBookCopies(bookID, barcode, model.InLibrary))
+		}
+		created, err := s.orderRepo.ReceivePurchaseOrderLine(ctx, param.OrderID, param.LineID, copies, param.ReceivedBy)
+		if err == nil {
+			return created, nil
+		}
+		if attempt == maxBarcodeAttempts || !isParameterInvalid(err) {
+			return nil, err
+		}
+	}
+}
+
+func (s *AcquisitionService) generateBarcode(ctx context.Context) (string, common.Error) {
+	seq, err := s.copyRepo.NextCopyBarcodeNumber(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to number copy barcode")
+		return "", err
+	}
+	barcode, genErr := s.barcodeFormats.Generate(seq)
+	if genErr != nil {
+		return "", common.NewError(common.ErrorCodeInternalProcess, genErr)
+	}
+	return barcode, nil
+}
+
+// trapHolds hands the copies received to the holds on their work. The copies
+// are received either way, so a failure is only logged.
+func (s *AcquisitionService) trapHolds(ctx context.Context, bookID int) {
+	book, err := s.bookRepo.GetBookByID(ctx, bookID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("book_id", bookID).Msg("failed to get book of received copies")
+		return
+	}
+	if _, err := s.holdTrapper.TrapAvailableCopies(ctx, book.WorkID); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("work_id", book.WorkID).Msg("failed to trap holds on received copies")
+	}
+}
+
+func isParameterInvalid(err common.Error) bool {
+	de, ok := err.(common.DomainError)
+	return ok && de.Name() == common.ErrorCodeParameterInvalid.Name
+}
+
+func requireActor(changedBy string) common.Error {
+	if strings.TrimSpace(changedBy) == "" {
+		err := fmt.Errorf("changed_by is empty")
+		return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the staff member making the change is required"))
+	}
+	return nil
+}
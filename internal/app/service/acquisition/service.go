@@ -0,0 +1,46 @@
+package acquisition
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type AcquisitionService struct {
+	vendorRepo VendorRepository
+	fundRepo   FundRepository
+	orderRepo  PurchaseOrderRepository
+	bookRepo   BookRepository
+	branchRepo BranchRepository
+	copyRepo   CopyRepository
+
+	holdTrapper    HoldTrapper
+	barcodeFormats model.BarcodeFormats
+}
+
+type AcquisitionServiceParam struct {
+	VendorRepo VendorRepository
+	FundRepo   FundRepository
+	OrderRepo  PurchaseOrderRepository
+	BookRepo   BookRepository
+	BranchRepo BranchRepository
+	CopyRepo   CopyRepository
+
+	HoldTrapper HoldTrapper
+	// BarcodeFormats are the accepted copy barcodes; received copies are labelled in the first format.
+	BarcodeFormats model.BarcodeFormats
+}
+
+func NewAcquisitionService(_ context.Context, param AcquisitionServiceParam) *AcquisitionService {
+	return &AcquisitionService{
+		vendorRepo: param.VendorRepo,
+		fundRepo:   param.FundRepo,
+		orderRepo:  param.OrderRepo,
+		bookRepo:   param.BookRepo,
+		branchRepo: param.BranchRepo,
+		copyRepo:   param.CopyRepo,
+
+		holdTrapper:    param.HoldTrapper,
+		barcodeFormats: param.BarcodeFormats,
+	}
+}
@@ -0,0 +1,69 @@
+package acquisition
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+type VendorParam struct {
+	Code    string
+	Name    string
+	Email   string
+	Phone   string
+	Address string
+}
+
+func (p VendorParam) toModel() (model.Vendor, common.Error) {
+	code, err := model.NormalizeVendorCode(p.Code)
+	if err != nil {
+		return model.Vendor{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		err := fmt.Errorf("vendor name is empty")
+		return model.Vendor{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return model.NewVendor(code, name, strings.TrimSpace(p.Email), strings.TrimSpace(p.Phone), strings.TrimSpace(p.Address)), nil
+}
+
+func (s *AcquisitionService) CreateVendor(ctx context.Context, param VendorParam) (*model.Vendor, common.Error) {
+	vendor, err := param.toModel()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.vendorRepo.CreateVendor(ctx, vendor)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("code", vendor.Code).Msg("failed to create vendor")
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *AcquisitionService) GetVendor(ctx context.Context, id int) (*model.Vendor, common.Error) {
+	return s.vendorRepo.GetVendorByID(ctx, id)
+}
+
+func (s *AcquisitionService) ListVendors(ctx context.Context) ([]*model.Vendor, common.Error) {
+	return s.vendorRepo.ListVendors(ctx)
+}
+
+func (s *AcquisitionService) UpdateVendor(ctx context.Context, id int, param VendorParam) (*model.Vendor, common.Error) {
+	vendor, err := param.toModel()
+	if err != nil {
+		return nil, err
+	}
+	vendor.ID = id
+
+	updated, err := s.vendorRepo.UpdateVendor(ctx, vendor)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("vendor_id", id).Msg("failed to update vendor")
+		return nil, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,66 @@
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+type CreateBookParam struct {
+	// WorkID groups the book with its other editions. A new work is created
+	// from the book's title and author when it is zero.
+	WorkID        int
+	Title         string
+	Author        string
+	ISBN          string
+	PublishedYear int
+	Edition       string
+	Language      string
+	// Classification and CallNumber are optional, but must be given together.
+	Classification model.ClassificationScheme
+	CallNumber     string
+}
+
+func (s *CatalogService) CreateBook(ctx context.Context, param CreateBookParam) (*model.Book, common.Error) {
+	callNumber, err := parseOptionalCallNumber(param.Classification, param.CallNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	book := model.NewBook(param.WorkID, param.Title, param.Author, param.ISBN,
+		time.Date(param.PublishedYear, time.January, 1, 0, 0, 0, 0, time.UTC))
+	book.Edition = param.Edition
+	book.Language = param.Language
+	book.CallNumber = callNumber
+
+	if param.WorkID == 0 {
+		// the work is created along with the book, so that a refused book
+		// leaves neither a work nor its event behind
+		work, created, err := s.bookRepo.CreateBookWithWork(ctx, model.NewWork(param.Title, param.Author), book)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create book with its work")
+			return nil, err
+		}
+		s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogWork, work.ID, model.CatalogCreated))
+		s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogBook, created.ID, model.CatalogCreated))
+		return created, nil
+	}
+
+	if _, err := s.workRepo.GetWorkByID(ctx, param.WorkID); err != nil {
+		return nil, err
+	}
+	created, err := s.bookRepo.CreateBook(ctx, book)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create book")
+		return nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogBook, created.ID, model.CatalogCreated))
+	return created, nil
+}
+
+func (s *CatalogService) GetBook(ctx context.Context, id int) (*model.Book, common.Error) {
+	return s.bookRepo.GetBookByID(ctx, id)
+}
@@ -0,0 +1,250 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// maxBarcodeAttempts bounds the retries when a generated barcode is already
+// printed on a label entered by hand.
+const maxBarcodeAttempts = 3
+
+type AddBookCopyParam struct {
+	BookID int
+	// Barcode is the scanned label. A barcode is generated when it is empty.
+	Barcode string
+	// CallNumber is an optional copy call number in the scheme of the book.
+	CallNumber string
+	// HomeBranchID is the branch owning the copy, the main branch when zero.
+	HomeBranchID int
+	// PriceCents is what the copy cost, charged when it is lost. Optional.
+	PriceCents *int64
+	// ChangedBy is the staff member adding the copy, recorded in its status history.
+	ChangedBy string
+}
+
+// AddBookCopy adds an on-shelf copy of a book.
+func (s *CatalogService) AddBookCopy(ctx context.Context, param AddBookCopyParam) (*model.BookCopies, common.Error) {
+	if err := requireActor(param.ChangedBy); err != nil {
+		return nil, err
+	}
+	book, err := s.bookRepo.GetBookByID(ctx, param.BookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePrice(param.PriceCents); err != nil {
+		return nil, err
+	}
+
+	bookCopy := model.NewBookCopies(book.ID, "", model.InLibrary)
+	bookCopy.PriceCents = param.PriceCents
+	if param.HomeBranchID != 0 {
+		if _, err := s.branchRepo.GetBranchByID(ctx, param.HomeBranchID); err != nil {
+			return nil, err
+		}
+		bookCopy.HomeBranchID = param.HomeBranchID
+	}
+	if param.CallNumber != "" {
+		if book.CallNumber == nil {
+			err := fmt.Errorf("book %d has no classification", book.ID)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("book has no classification for the copy call number"))
+		}
+		if bookCopy.CallNumber, err = parseOptionalCallNumber(book.CallNumber.Scheme, param.CallNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	if param.Barcode != "" {
+		if bookCopy.Barcode, err = s.validateBarcode(param.Barcode); err != nil {
+			return nil, err
+		}
+		return s.createBookCopy(ctx, bookCopy, param.ChangedBy)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if bookCopy.Barcode, err = s.generateBarcode(ctx); err != nil {
+			return nil, err
+		}
+		created, err := s.copyRepo.CreateBookCopy(ctx, bookCopy, param.ChangedBy)
+		if err == nil {
+			s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogCopy, created.ID, model.CatalogCreated))
+			return created, nil
+		}
+		if attempt == maxBarcodeAttempts || !isParameterInvalid(err) {
+			zerolog.Ctx(ctx).Error().Err(err).Int("book_id", book.ID).Msg("failed to add book copy")
+			return nil, err
+		}
+	}
+}
+
+func (s *CatalogService) createBookCopy(ctx context.Context, bookCopy model.BookCopies, changedBy string) (*model.BookCopies, common.Error) {
+	created, err := s.copyRepo.CreateBookCopy(ctx, bookCopy, changedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("book_id", bookCopy.BookID).Msg("failed to add book copy")
+		return nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogCopy, created.ID, model.CatalogCreated))
+	return created, nil
+}
+
+func (s *CatalogService) generateBarcode(ctx context.Context) (string, common.Error) {
+	seq, err := s.copyRepo.NextCopyBarcodeNumber(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to number copy barcode")
+		return "", err
+	}
+	barcode, genErr := s.barcodeFormats.Generate(seq)
+	if genErr != nil {
+		return "", common.NewError(common.ErrorCodeInternalProcess, genErr)
+	}
+	return barcode, nil
+}
+
+func (s *CatalogService) validateBarcode(raw string) (string, common.Error) {
+	barcode, err := s.barcodeFormats.Validate(raw)
+	if err != nil {
+		return "", common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return barcode, nil
+}
+
+func isParameterInvalid(err common.Error) bool {
+	de, ok := err.(common.DomainError)
+	return ok && de.Name() == common.ErrorCodeParameterInvalid.Name
+}
+
+// ListBookCopies lists the copies of a book. Retired copies are left out unless asked for.
+func (s *CatalogService) ListBookCopies(ctx context.Context, bookID int, includeRetired bool) ([]*model.BookCopies, common.Error) {
+	if _, err := s.bookRepo.GetBookByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	copies, err := s.copyRepo.ListBookCopiesByBookIDs(ctx, []int{bookID})
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("book_id", bookID).Msg("failed to list book copies")
+		return nil, err
+	}
+	if includeRetired {
+		return copies, nil
+	}
+
+	inCollection := make([]*model.BookCopies, 0, len(copies))
+	for _, c := range copies {
+		if !c.IsRetired() {
+			inCollection = append(inCollection, c)
+		}
+	}
+	return inCollection, nil
+}
+
+func (s *CatalogService) GetBookCopy(ctx context.Context, id int) (*model.BookCopies, common.Error) {
+	return s.copyRepo.GetBookCopyByID(ctx, id)
+}
+
+// LookupBookCopy finds the copy labelled with a scanned barcode, together with its book.
+func (s *CatalogService) LookupBookCopy(ctx context.Context, rawBarcode string) (*model.BookCopies, *model.Book, common.Error) {
+	barcode, err := s.validateBarcode(rawBarcode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bookCopy, err := s.copyRepo.GetBookCopyByBarcode(ctx, barcode)
+	if err != nil {
+		return nil, nil, err
+	}
+	book, err := s.bookRepo.GetBookByID(ctx, bookCopy.BookID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", bookCopy.ID).Msg("failed to get book of copy")
+		return nil, nil, err
+	}
+	return bookCopy, book, nil
+}
+
+type ChangeCopyStatusParam struct {
+	CopyID int
+	Status model.BookStatus
+	// ChangedBy and Reason are recorded in the status history of the copy.
+	ChangedBy string
+	Reason    string
+}
+
+// ChangeBookCopyStatus moves a copy along its lifecycle by hand, e.g. from
+// Damaged to InRepair. Loans, holds, transfers and losses move copies through
+// their own endpoints.
+func (s *CatalogService) ChangeBookCopyStatus(ctx context.Context, param ChangeCopyStatusParam) (*model.BookCopies, common.Error) {
+	if err := requireActor(param.ChangedBy); err != nil {
+		return nil, err
+	}
+
+	bookCopy, err := s.copyRepo.ChangeBookCopyStatus(ctx, param.CopyID, param.Status, param.ChangedBy, strings.TrimSpace(param.Reason))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", param.CopyID).Str("status", param.Status.String()).Msg("failed to change copy status")
+		return nil, err
+	}
+	if param.Status == model.Withdrawn {
+		s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogCopy, bookCopy.ID, model.CatalogRetired))
+	}
+	return bookCopy, nil
+}
+
+// RetireBookCopy withdraws a copy from the collection, e.g. when it is worn out or discarded.
+func (s *CatalogService) RetireBookCopy(ctx context.Context, id int, changedBy, reason string) (*model.BookCopies, common.Error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		err := fmt.Errorf("retire reason is empty")
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("a reason is required to retire a copy"))
+	}
+
+	return s.ChangeBookCopyStatus(ctx, ChangeCopyStatusParam{
+		CopyID:    id,
+		Status:    model.Withdrawn,
+		ChangedBy: changedBy,
+		Reason:    reason,
+	})
+}
+
+// SetBookCopyPrice records what a copy cost, or clears it when nil so that
+// the default replacement cost applies.
+func (s *CatalogService) SetBookCopyPrice(ctx context.Context, id int, priceCents *int64) (*model.BookCopies, common.Error) {
+	if err := validatePrice(priceCents); err != nil {
+		return nil, err
+	}
+
+	bookCopy, err := s.copyRepo.SetBookCopyPrice(ctx, id, priceCents)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", id).Msg("failed to set copy price")
+		return nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogCopy, bookCopy.ID, model.CatalogUpdated))
+	return bookCopy, nil
+}
+
+func validatePrice(priceCents *int64) common.Error {
+	if priceCents != nil && *priceCents < 0 {
+		err := fmt.Errorf("price %d is negative", *priceCents)
+		return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the price may not be negative"))
+	}
+	return nil
+}
+
+// ListBookCopyStatusHistory returns every status a copy went through, oldest first.
+func (s *CatalogService) ListBookCopyStatusHistory(ctx context.Context, copyID int) ([]*model.BookStatusChange, common.Error) {
+	if _, err := s.copyRepo.GetBookCopyByID(ctx, copyID); err != nil {
+		return nil, err
+	}
+	return s.copyRepo.ListBookStatusHistory(ctx, copyID)
+}
+
+func requireActor(changedBy string) common.Error {
+	if strings.TrimSpace(changedBy) == "" {
+		err := fmt.Errorf("changed_by is empty")
+		return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the staff member making the change is required"))
+	}
+	return nil
+}
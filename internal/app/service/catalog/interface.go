@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type WorkRepository interface {
+	CreateWork(ctx context.Context, param model.Work) (*model.Work, common.Error)
+	GetWorkByID(ctx context.Context, id int) (*model.Work, common.Error)
+	SearchWorks(ctx context.Context, keyword string, limit, offset int) ([]*model.Work, common.Error)
+}
+
+type BookRepository interface {
+	CreateBook(ctx context.Context, param model.Book) (*model.Book, common.Error)
+	CreateBookWithWork(ctx context.Context, work model.Work, book model.Book) (*model.Work, *model.Book, common.Error)
+	GetBookByID(ctx context.Context, id int) (*model.Book, common.Error)
+	ListBooksByWorkIDs(ctx context.Context, workIDs []int) ([]*model.Book, common.Error)
+}
+
+type SubjectRepository interface {
+	CreateSubject(ctx context.Context, param model.Subject) (*model.Subject, common.Error)
+	GetSubjectByID(ctx context.Context, id int) (*model.Subject, common.Error)
+	ListSubjects(ctx context.Context) ([]*model.Subject, common.Error)
+	UpdateSubject(ctx context.Context, param model.Subject) (*model.Subject, common.Error)
+	DeleteSubject(ctx context.Context, id int) common.Error
+	SetBookSubjects(ctx context.Context, bookID int, subjectIDs []int) common.Error
+	ListSubjectsByBookID(ctx context.Context, bookID int) ([]*model.Subject, common.Error)
+	ListBooksBySubjectTree(ctx context.Context, subjectID int, limit, offset int) ([]*model.Book, common.Error)
+}
+
+type ShelfRepository interface {
+	SetBookCallNumber(ctx context.Context, bookID int, cn *model.CallNumber) (*model.Book, common.Error)
+	SetCopyCallNumber(ctx context.Context, copyID int, cn *model.CallNumber) common.Error
+	GetShelfItemByCopyID(ctx context.Context, copyID int) (*model.ShelfItem, common.Error)
+	ListShelfItemsBefore(ctx context.Context, scheme model.ClassificationScheme, sortKey string, copyID int, limit int) ([]*model.ShelfItem, common.Error)
+	ListShelfItemsAfter(ctx context.Context, scheme model.ClassificationScheme, sortKey string, copyID int, limit int) ([]*model.ShelfItem, common.Error)
+}
+
+type CatalogExchangeRepository interface {
+	ListBooksByISBNs(ctx context.Context, isbns []string) ([]*model.Book, common.Error)
+	ListBooksByIDs(ctx context.Context, ids []int) ([]*model.Book, common.Error)
+	ListBooksAfterID(ctx context.Context, afterID int, limit int) ([]*model.Book, common.Error)
+	ListWorksByIDs(ctx context.Context, ids []int) ([]*model.Work, common.Error)
+	ListBookCopiesByBookIDs(ctx context.Context, bookIDs []int) ([]*model.BookCopies, common.Error)
+	ImportCatalogRecords(ctx context.Context, records []model.CatalogRecord) ([]*model.Book, common.Error)
+}
+
+type CopyRepository interface {
+	CreateBookCopy(ctx context.Context, param model.BookCopies, changedBy string) (*model.BookCopies, common.Error)
+	NextCopyBarcodeNumber(ctx context.Context) (int64, common.Error)
+	GetBookCopyByID(ctx context.Context, id int) (*model.BookCopies, common.Error)
+	GetBookCopyByBarcode(ctx context.Context, barcode string) (*model.BookCopies, common.Error)
+	ListBookCopiesByBookIDs(ctx context.Context, bookIDs []int) ([]*model.BookCopies, common.Error)
+	ChangeBookCopyStatus(ctx context.Context, id int, to model.BookStatus, changedBy, reason string) (*model.BookCopies, common.Error)
+	ListBookStatusHistory(ctx context.Context, copyID int) ([]*model.BookStatusChange, common.Error)
+	SetBookCopyPrice(ctx context.Context, id int, priceCents *int64) (*model.BookCopies, common.Error)
+}
+
+type BranchRepository interface {
+	GetBranchByID(ctx context.Context, id int) (*model.Branch, common.Error)
+}
+
+type LabelRepository interface {
+	ListCopyLabels(ctx context.Context, copyIDs []int) ([]*model.CopyLabel, common.Error)
+}
@@ -0,0 +1,149 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/label"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// maxLabelCopies bounds the copies printed in one batch.
+const maxLabelCopies = 1000
+
+type LabelKind string
+
+const (
+	LabelBarcode LabelKind = "barcode"
+	LabelSpine   LabelKind = "spine"
+	// LabelBoth prints the barcode label of a copy followed by its spine label.
+	LabelBoth LabelKind = "both"
+)
+
+type LabelParam struct {
+	Kind      LabelKind
+	Symbology label.Symbology
+	// Stock names the label stock; labels are sized for it.
+	Stock string
+}
+
+type LabelSheetParam struct {
+	LabelParam
+	CopyIDs []int
+	// Skip is the number of labels already used on the first sheet.
+	Skip int
+}
+
+func (s *CatalogService) ListLabelStocks() []label.Stock {
+	return label.Stocks()
+}
+
+// DrawCopyLabel draws the barcode or the spine label of a copy.
+func (s *CatalogService) DrawCopyLabel(ctx context.Context, copyID int, param LabelParam) (*label.Drawing, common.Error) {
+	if param.Kind == LabelBoth {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, fmt.Errorf("one label at a time"), common.WithMsg("pick a barcode or a spine label"))
+	}
+
+	drawings, err := s.drawCopyLabels(ctx, []int{copyID}, param)
+	if err != nil {
+		return nil, err
+	}
+	return &drawings[0], nil
+}
+
+// PrintLabelSheets lays the labels of a batch of copies out on sheets of
+// label stock, as a PDF document ready to print.
+func (s *CatalogService) PrintLabelSheets(ctx context.Context, param LabelSheetParam) ([]byte, common.Error) {
+	if len(param.CopyIDs) == 0 || len(param.CopyIDs) > maxLabelCopies {
+		err := fmt.Errorf("%d copies to label, want 1 to %d", len(param.CopyIDs), maxLabelCopies)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	drawings, err := s.drawCopyLabels(ctx, param.CopyIDs, param.LabelParam)
+	if err != nil {
+		return nil, err
+	}
+
+	stock, _ := label.LookupStock(param.Stock)
+	pdf, sErr := label.Sheets(stock, drawings, param.Skip)
+	if sErr != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, sErr, common.WithMsg(sErr.Error()))
+	}
+	return pdf, nil
+}
+
+// drawCopyLabels draws the labels of copies in the order of their IDs.
+func (s *CatalogService) drawCopyLabels(ctx context.Context, copyIDs []int, param LabelParam) ([]label.Drawing, common.Error) {
+	stock, err := label.LookupStock(param.Stock)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	symbology, err := label.ParseSymbology(string(param.Symbology))
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	kind := param.Kind
+	switch kind {
+	case "":
+		kind = LabelBarcode
+	case LabelBarcode, LabelSpine, LabelBoth:
+	default:
+		err := fmt.Errorf("unknown label kind %q", kind)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	labels, cErr := s.labelRepo.ListCopyLabels(ctx, copyIDs)
+	if cErr != nil {
+		zerolog.Ctx(ctx).Error().Err(cErr).Msg("failed to list copy labels")
+		return nil, cErr
+	}
+	byID := make(map[int]*model.CopyLabel, len(labels))
+	for _, l := range labels {
+		byID[l.CopyID] = l
+	}
+
+	var drawings []label.Drawing
+	for _, id := range copyIDs {
+		l, ok := byID[id]
+		if !ok {
+			err := fmt.Errorf("copy %d not found", id)
+			return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg(err.Error()))
+		}
+		if kind == LabelBarcode || kind == LabelBoth {
+			d, err := drawBarcodeLabel(stock, symbology, l)
+			if err != nil {
+				return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+			}
+			drawings = append(drawings, d)
+		}
+		if kind == LabelSpine || kind == LabelBoth {
+			d, err := drawSpineLabel(stock, l)
+			if err != nil {
+				return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+			}
+			drawings = append(drawings, d)
+		}
+	}
+	return drawings, nil
+}
+
+func drawBarcodeLabel(stock label.Stock, symbology label.Symbology, l *model.CopyLabel) (label.Drawing, error) {
+	if l.Barcode == "" {
+		return label.Drawing{}, fmt.Errorf("copy %d has no barcode", l.CopyID)
+	}
+	code, err := label.Encode(symbology, l.Barcode)
+	if err != nil {
+		return label.Drawing{}, fmt.Errorf("copy %d: %w", l.CopyID, err)
+	}
+	return label.BarcodeLabel(stock.LabelWidth, stock.LabelHeight, code, l.Title)
+}
+
+func drawSpineLabel(stock label.Stock, l *model.CopyLabel) (label.Drawing, error) {
+	lines := model.SpineLines(l.Scheme, l.CallNumber)
+	if len(lines) == 0 {
+		return label.Drawing{}, fmt.Errorf("copy %d has no call number", l.CopyID)
+	}
+	return label.SpineLabel(stock.LabelWidth, stock.LabelHeight, lines)
+}
@@ -0,0 +1,282 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/marc"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultImportBatchSize = 100
+	maxImportBatchSize     = 1000
+	exportPageSize         = 500
+)
+
+type ImportParam struct {
+	// DryRun validates and plans the import without writing anything.
+	DryRun    bool
+	BatchSize int
+}
+
+// pendingImport is a record waiting in the current batch.
+type pendingImport struct {
+	index  int
+	record model.CatalogRecord
+}
+
+// ImportMARC imports bibliographic records in batches. A record whose ISBN
+// is already in the catalog updates that book, any other record creates a
+// book with its copies. Each batch is written in one transaction, and every
+// record gets a row in the report.
+func (s *CatalogService) ImportMARC(ctx context.Context, reader marc.RecordReader, param ImportParam) (*model.ImportReport, common.Error) {
+	batchSize := param.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+	if batchSize > maxImportBatchSize {
+		batchSize = maxImportBatchSize
+	}
+
+	report := &model.ImportReport{DryRun: param.DryRun}
+	seen := make(map[string]int)
+	var batch []pendingImport
+
+	for index := 1; ; index++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// the rest of the stream cannot be located reliably after a broken record
+			report.Add(model.ImportRow{Index: index, Action: model.ImportActionError, Message: fmt.Sprintf("unreadable record: %s", err)})
+			break
+		}
+
+		key := record.ControlField("001")
+		rec, err := marc.ToCatalogRecord(record)
+		if err != nil {
+			report.Add(model.ImportRow{Index: index, Key: key, Action: model.ImportActionError, Message: err.Error()})
+			continue
+		}
+		if err := s.validateCopyBarcodes(rec.Copies); err != nil {
+			report.Add(model.ImportRow{Index: index, Key: rec.Book.ISBN, Action: model.ImportActionError, Message: err.Error()})
+			continue
+		}
+		if first, ok := seen[rec.Book.ISBN]; ok {
+			report.Add(model.ImportRow{Index: index, Key: rec.Book.ISBN, Action: model.ImportActionError,
+				Message: fmt.Sprintf("duplicate of record %d", first)})
+			continue
+		}
+		seen[rec.Book.ISBN] = index
+
+		batch = append(batch, pendingImport{index: index, record: rec})
+		if len(batch) == batchSize {
+			if err := s.importBatch(ctx, batch, report); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := s.importBatch(ctx, batch, report); err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Bool("dry_run", report.DryRun).
+		Int("created", report.Created).
+		Int("updated", report.Updated).
+		Int("failed", report.Failed).
+		Msg("MARC import finished")
+	return report, nil
+}
+
+func (s *CatalogService) importBatch(ctx context.Context, batch []pendingImport, report *model.ImportReport) common.Error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	isbns := make([]string, 0, len(batch))
+	for _, p := range batch {
+		isbns = append(isbns, p.record.Book.ISBN)
+	}
+	existing, err := s.exchangeRepo.ListBooksByISBNs(ctx, isbns)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to look up existing books")
+		return err
+	}
+	byISBN := make(map[string]*model.Book, len(existing))
+	for _, b := range existing {
+		byISBN[b.ISBN] = b
+	}
+
+	rows := make([]model.ImportRow, 0, len(batch))
+	records := make([]model.CatalogRecord, 0, len(batch))
+	for _, p := range batch {
+		rec := p.record
+		row := model.ImportRow{Index: p.index, Key: rec.Book.ISBN, Action: model.ImportActionCreate}
+		if b, ok := byISBN[rec.Book.ISBN]; ok {
+			rec.Book.ID = b.ID
+			rec.Book.WorkID = b.WorkID
+			row.Action = model.ImportActionUpdate
+			row.ID = b.ID
+			if len(rec.Copies) > 0 {
+				row.Message = fmt.Sprintf("%d copies ignored for an existing book", len(rec.Copies))
+				rec.Copies = nil
+			}
+		}
+		rows = append(rows, row)
+		records = append(records, rec)
+	}
+
+	if report.DryRun {
+		for _, row := range rows {
+			report.Add(row)
+		}
+		return nil
+	}
+
+	books, err := s.exchangeRepo.ImportCatalogRecords(ctx, records)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("first_index", batch[0].index).Msg("failed to import batch")
+		for _, row := range rows {
+			row.Action = model.ImportActionError
+			row.Message = fmt.Sprintf("batch rolled back: %s", err.Error())
+			report.Add(row)
+		}
+		return nil
+	}
+
+	for i, row := range rows {
+		row.ID = books[i].ID
+		report.Add(row)
+		s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogBook, row.ID, row.Action.CatalogAction()))
+	}
+	return nil
+}
+
+// validateCopyBarcodes normalizes the barcodes read from 852$p in place.
+func (s *CatalogService) validateCopyBarcodes(copies []model.BookCopies) error {
+	seen := make(map[string]bool, len(copies))
+	for i := range copies {
+		if copies[i].Barcode == "" {
+			continue
+		}
+		barcode, err := s.barcodeFormats.Validate(copies[i].Barcode)
+		if err != nil {
+			return fmt.Errorf("invalid copy barcode (852$p): %w", err)
+		}
+		if seen[barcode] {
+			return fmt.Errorf("duplicate copy barcode %s", barcode)
+		}
+		seen[barcode] = true
+		copies[i].Barcode = barcode
+	}
+	return nil
+}
+
+type ExportFilter struct {
+	// BookIDs limits the export to the given books.
+	BookIDs []int
+	// SubjectID limits the export to books under the subject and its descendants.
+	SubjectID int
+}
+
+// ExportMARC writes the whole catalog, or the subset chosen by the filter, as
+// bibliographic records with their copies. The writer is closed at the end.
+func (s *CatalogService) ExportMARC(ctx context.Context, writer marc.RecordWriter, filter ExportFilter) common.Error {
+	var err common.Error
+	switch {
+	case len(filter.BookIDs) > 0:
+		for start := 0; start < len(filter.BookIDs) && err == nil; start += exportPageSize {
+			end := start + exportPageSize
+			if end > len(filter.BookIDs) {
+				end = len(filter.BookIDs)
+			}
+			var books []*model.Book
+			if books, err = s.exchangeRepo.ListBooksByIDs(ctx, filter.BookIDs[start:end]); err == nil {
+				err = s.exportBooks(ctx, writer, books)
+			}
+		}
+	case filter.SubjectID != 0:
+		if _, err = s.subjectRepo.GetSubjectByID(ctx, filter.SubjectID); err != nil {
+			return err
+		}
+		for offset := 0; err == nil; offset += exportPageSize {
+			var books []*model.Book
+			if books, err = s.subjectRepo.ListBooksBySubjectTree(ctx, filter.SubjectID, exportPageSize, offset); err != nil || len(books) == 0 {
+				break
+			}
+			err = s.exportBooks(ctx, writer, books)
+		}
+	default:
+		for afterID := 0; err == nil; {
+			var books []*model.Book
+			if books, err = s.exchangeRepo.ListBooksAfterID(ctx, afterID, exportPageSize); err != nil || len(books) == 0 {
+				break
+			}
+			afterID = books[len(books)-1].ID
+			err = s.exportBooks(ctx, writer, books)
+		}
+	}
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to export MARC records")
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return nil
+}
+
+func (s *CatalogService) exportBooks(ctx context.Context, writer marc.RecordWriter, books []*model.Book) common.Error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	bookIDs := make([]int, 0, len(books))
+	workIDs := make([]int, 0, len(books))
+	for _, b := range books {
+		bookIDs = append(bookIDs, b.ID)
+		workIDs = append(workIDs, b.WorkID)
+	}
+
+	copies, err := s.exchangeRepo.ListBookCopiesByBookIDs(ctx, bookIDs)
+	if err != nil {
+		return err
+	}
+	copiesByBook := make(map[int][]model.BookCopies)
+	for _, c := range copies {
+		if c.IsRetired() {
+			continue
+		}
+		copiesByBook[c.BookID] = append(copiesByBook[c.BookID], *c)
+	}
+
+	works, err := s.exchangeRepo.ListWorksByIDs(ctx, workIDs)
+	if err != nil {
+		return err
+	}
+	workTitles := make(map[int]string, len(works))
+	for _, w := range works {
+		workTitles[w.ID] = w.Title
+	}
+
+	for _, b := range books {
+		record := marc.FromCatalogRecord(model.CatalogRecord{
+			Book:      *b,
+			WorkTitle: workTitles[b.WorkID],
+			Copies:    copiesByBook[b.ID],
+		})
+		if err := writer.Write(record); err != nil {
+			return common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+	}
+	return nil
+}
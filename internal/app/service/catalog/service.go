@@ -0,0 +1,55 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type CatalogService struct {
+	workRepo     WorkRepository
+	bookRepo     BookRepository
+	subjectRepo  SubjectRepository
+	shelfRepo    ShelfRepository
+	exchangeRepo CatalogExchangeRepository
+	copyRepo     CopyRepository
+	branchRepo   BranchRepository
+	labelRepo    LabelRepository
+
+	eventPublisher webhook.Events
+	barcodeFormats model.BarcodeFormats
+}
+
+type CatalogServiceParam struct {
+	WorkRepo     WorkRepository
+	BookRepo     BookRepository
+	SubjectRepo  SubjectRepository
+	ShelfRepo    ShelfRepository
+	ExchangeRepo CatalogExchangeRepository
+	CopyRepo     CopyRepository
+	BranchRepo   BranchRepository
+	LabelRepo    LabelRepository
+
+	// EventPublisher tells integrations about works, editions and copies
+	// added to, updated in or retired from the catalog.
+	EventPublisher webhook.Publisher
+	// BarcodeFormats are the accepted copy barcodes; new ones are generated in the first format.
+	BarcodeFormats model.BarcodeFormats
+}
+
+func NewCatalogService(_ context.Context, param CatalogServiceParam) *CatalogService {
+	return &CatalogService{
+		workRepo:     param.WorkRepo,
+		bookRepo:     param.BookRepo,
+		subjectRepo:  param.SubjectRepo,
+		shelfRepo:    param.ShelfRepo,
+		exchangeRepo: param.ExchangeRepo,
+		copyRepo:     param.CopyRepo,
+		branchRepo:   param.BranchRepo,
+		labelRepo:    param.LabelRepo,
+
+		eventPublisher: webhook.NewEvents(param.EventPublisher),
+		barcodeFormats: param.BarcodeFormats,
+	}
+}
@@ -0,0 +1,123 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultShelfWindow = 5
+	maxShelfWindow     = 50
+)
+
+// parseOptionalCallNumber returns nil when neither a scheme nor a call number is given.
+func parseOptionalCallNumber(scheme model.ClassificationScheme, raw string) (*model.CallNumber, common.Error) {
+	if scheme == "" && raw == "" {
+		return nil, nil
+	}
+
+	cn, err := model.ParseCallNumber(scheme, raw)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return &cn, nil
+}
+
+// SetBookCallNumber classifies a book. An empty scheme and call number clear it.
+func (s *CatalogService) SetBookCallNumber(ctx context.Context, bookID int, scheme model.ClassificationScheme, raw string) (*model.Book, common.Error) {
+	cn, err := parseOptionalCallNumber(scheme, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.shelfRepo.SetBookCallNumber(ctx, bookID, cn)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("book_id", bookID).Msg("failed to set book call number")
+		return nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogBook, book.ID, model.CatalogUpdated))
+	return book, nil
+}
+
+// SetCopyCallNumber gives a copy its own call number in the scheme of its
+// book. An empty call number makes the copy fall back to the book's.
+func (s *CatalogService) SetCopyCallNumber(ctx context.Context, copyID int, raw string) (*model.ShelfItem, common.Error) {
+	item, err := s.shelfRepo.GetShelfItemByCopyID(ctx, copyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cn *model.CallNumber
+	if raw != "" {
+		if cn, err = parseOptionalCallNumber(item.Scheme, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.shelfRepo.SetCopyCallNumber(ctx, copyID, cn); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", copyID).Msg("failed to set copy call number")
+		return nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogCopy, copyID, model.CatalogUpdated))
+	return s.shelfRepo.GetShelfItemByCopyID(ctx, copyID)
+}
+
+// BrowseShelfAroundCopy returns the copies shelved on either side of a copy.
+func (s *CatalogService) BrowseShelfAroundCopy(ctx context.Context, copyID int, window int) (*model.ShelfBrowse, common.Error) {
+	anchor, err := s.shelfRepo.GetShelfItemByCopyID(ctx, copyID)
+	if err != nil {
+		return nil, err
+	}
+
+	browse, err := s.browseShelf(ctx, anchor.Scheme, anchor.SortKey, anchor.CopyID, window)
+	if err != nil {
+		return nil, err
+	}
+	browse.Anchor = anchor
+	return browse, nil
+}
+
+// BrowseShelfAtCallNumber returns the copies shelved around the place a call
+// number belongs, which tells staff where to put a copy back.
+func (s *CatalogService) BrowseShelfAtCallNumber(ctx context.Context, scheme model.ClassificationScheme, raw string, window int) (*model.ShelfBrowse, common.Error) {
+	cn, err := model.ParseCallNumber(scheme, raw)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	// copy ID 0 places the position before every copy sharing the call number
+	return s.browseShelf(ctx, cn.Scheme, cn.SortKey, 0, window)
+}
+
+func (s *CatalogService) browseShelf(ctx context.Context, scheme model.ClassificationScheme, sortKey string, copyID int, window int) (*model.ShelfBrowse, common.Error) {
+	if window <= 0 {
+		window = defaultShelfWindow
+	}
+	if window > maxShelfWindow {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid,
+			fmt.Errorf("shelf window %d exceeds %d", window, maxShelfWindow),
+			common.WithMsg(fmt.Sprintf("window must not exceed %d", maxShelfWindow)))
+	}
+
+	before, err := s.shelfRepo.ListShelfItemsBefore(ctx, scheme, sortKey, copyID, window)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list shelf items before position")
+		return nil, err
+	}
+	after, err := s.shelfRepo.ListShelfItemsAfter(ctx, scheme, sortKey, copyID, window)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list shelf items after position")
+		return nil, err
+	}
+
+	// the repository returns the items before the position nearest first
+	for i, j := 0, len(before)-1; i < j; i, j = i+1, j-1 {
+		before[i], before[j] = before[j], before[i]
+	}
+
+	return &model.ShelfBrowse{Before: before, After: after}, nil
+}
@@ -0,0 +1,128 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// GetSubjectTree returns the whole taxonomy as a forest of root subjects.
+func (s *CatalogService) GetSubjectTree(ctx context.Context) ([]*model.SubjectNode, common.Error) {
+	subjects, err := s.subjectRepo.ListSubjects(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list subjects")
+		return nil, err
+	}
+	return model.BuildSubjectTree(subjects), nil
+}
+
+// GetSubjectSubtree returns a subject with all of its descendants.
+func (s *CatalogService) GetSubjectSubtree(ctx context.Context, id int) (*model.SubjectNode, common.Error) {
+	roots, err := s.GetSubjectTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	node := model.FindSubjectNode(roots, id)
+	if node == nil {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, fmt.Errorf("subject %d not found", id))
+	}
+	return node, nil
+}
+
+func (s *CatalogService) CreateSubject(ctx context.Context, name string, parentID *int) (*model.Subject, common.Error) {
+	if parentID != nil {
+		if _, err := s.subjectRepo.GetSubjectByID(ctx, *parentID); err != nil {
+			return nil, err
+		}
+	}
+
+	subject, err := s.subjectRepo.CreateSubject(ctx, model.NewSubject(name, parentID))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create subject")
+		return nil, err
+	}
+	return subject, nil
+}
+
+// UpdateSubject renames a subject and moves it, with its subtree, under another parent.
+func (s *CatalogService) UpdateSubject(ctx context.Context, id int, name string, parentID *int) (*model.Subject, common.Error) {
+	node, err := s.GetSubjectSubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		if node.Contains(*parentID) {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid,
+				fmt.Errorf("subject %d cannot move under %d", id, *parentID),
+				common.WithMsg("a subject cannot be moved under itself or its descendants"))
+		}
+		if _, err := s.subjectRepo.GetSubjectByID(ctx, *parentID); err != nil {
+			return nil, err
+		}
+	}
+
+	subject := node.Subject
+	subject.Name = name
+	subject.ParentID = parentID
+
+	updated, err := s.subjectRepo.UpdateSubject(ctx, subject)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("subject_id", id).Msg("failed to update subject")
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteSubject removes a leaf subject. Books linked to it are unlinked.
+func (s *CatalogService) DeleteSubject(ctx context.Context, id int) common.Error {
+	node, err := s.GetSubjectSubtree(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(node.Children) > 0 {
+		return common.NewError(common.ErrorCodeParameterInvalid,
+			fmt.Errorf("subject %d has %d children", id, len(node.Children)),
+			common.WithMsg("subject still has child subjects"))
+	}
+
+	return s.subjectRepo.DeleteSubject(ctx, id)
+}
+
+// SetBookSubjects replaces the subjects a book is classified under.
+func (s *CatalogService) SetBookSubjects(ctx context.Context, bookID int, subjectIDs []int) ([]*model.Subject, common.Error) {
+	if _, err := s.bookRepo.GetBookByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	for _, id := range subjectIDs {
+		if _, err := s.subjectRepo.GetSubjectByID(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.subjectRepo.SetBookSubjects(ctx, bookID, subjectIDs); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("book_id", bookID).Msg("failed to set book subjects")
+		return nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogBook, bookID, model.CatalogUpdated))
+	return s.subjectRepo.ListSubjectsByBookID(ctx, bookID)
+}
+
+func (s *CatalogService) ListBookSubjects(ctx context.Context, bookID int) ([]*model.Subject, common.Error) {
+	if _, err := s.bookRepo.GetBookByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return s.subjectRepo.ListSubjectsByBookID(ctx, bookID)
+}
+
+// ListBooksBySubject returns the books classified under the subject or any subject below it.
+func (s *CatalogService) ListBooksBySubject(ctx context.Context, subjectID int, limit, offset int) ([]*model.Book, common.Error) {
+	if _, err := s.subjectRepo.GetSubjectByID(ctx, subjectID); err != nil {
+		return nil, err
+	}
+	return s.subjectRepo.ListBooksBySubjectTree(ctx, subjectID, clampLimit(limit), offset)
+}
@@ -0,0 +1,93 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func (s *CatalogService) CreateWork(ctx context.Context, title, author string) (*model.Work, common.Error) {
+	work, err := s.workRepo.CreateWork(ctx, model.NewWork(title, author))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create work")
+		return nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogWork, work.ID, model.CatalogCreated))
+	return work, nil
+}
+
+func (s *CatalogService) GetWork(ctx context.Context, id int) (*model.WorkWithEditions, common.Error) {
+	work, err := s.workRepo.GetWorkByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	works, err := s.withEditions(ctx, []*model.Work{work})
+	if err != nil {
+		return nil, err
+	}
+	return works[0], nil
+}
+
+type SearchWorksParam struct {
+	Keyword string
+	Limit   int
+	Offset  int
+}
+
+// SearchWorks collapses matching editions into their works, so every work
+// appears once no matter how many of its editions match.
+func (s *CatalogService) SearchWorks(ctx context.Context, param SearchWorksParam) ([]*model.WorkWithEditions, common.Error) {
+	works, err := s.workRepo.SearchWorks(ctx, param.Keyword, clampLimit(param.Limit), param.Offset)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to search works")
+		return nil, err
+	}
+
+	return s.withEditions(ctx, works)
+}
+
+// clampLimit applies the default page size and caps oversized pages.
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		return maxSearchLimit
+	}
+	return limit
+}
+
+func (s *CatalogService) withEditions(ctx context.Context, works []*model.Work) ([]*model.WorkWithEditions, common.Error) {
+	workIDs := make([]int, 0, len(works))
+	for _, w := range works {
+		workIDs = append(workIDs, w.ID)
+	}
+
+	books, err := s.bookRepo.ListBooksByWorkIDs(ctx, workIDs)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list editions")
+		return nil, err
+	}
+
+	editions := make(map[int][]model.Book)
+	for _, b := range books {
+		editions[b.WorkID] = append(editions[b.WorkID], *b)
+	}
+
+	results := make([]*model.WorkWithEditions, 0, len(works))
+	for _, w := range works {
+		results = append(results, &model.WorkWithEditions{
+			Work:     *w,
+			Editions: editions[w.ID],
+		})
+	}
+	return results, nil
+}
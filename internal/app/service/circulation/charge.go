@@ -0,0 +1,41 @@
+package circulation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// ListUserCharges returns the charges of a user, reversed ones included.
+func (s *CirculationService) ListUserCharges(ctx context.Context, userID int) ([]*model.Charge, common.Error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.chargeRepo.ListChargesByUserID(ctx, userID)
+}
+
+func (s *CirculationService) GetCharge(ctx context.Context, id int) (*model.Charge, common.Error) {
+	return s.chargeRepo.GetChargeByID(ctx, id)
+}
+
+// ReverseCharge waives a charge. Staff must say why.
+func (s *CirculationService) ReverseCharge(ctx context.Context, id int, reversedBy, reason string) (*model.Charge, common.Error) {
+	if err := requireActor(reversedBy); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(reason) == "" {
+		err := fmt.Errorf("reason is empty")
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("a reason for the reversal is required"))
+	}
+
+	charge, err := s.chargeRepo.ReverseCharge(ctx, id, reversedBy, reason)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("charge_id", id).Msg("failed to reverse charge")
+		return nil, err
+	}
+	return charge, nil
+}
@@ -0,0 +1,99 @@
+package circulation
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// PlaceHold queues a user in good standing for a work, to be collected at a
+// branch. Any edition's copy can fill the hold, so a copy already on the
+// shelf is trapped right away, or sent to the pickup branch when it sits
+// elsewhere.
+func (s *CirculationService) PlaceHold(ctx context.Context, userID, workID, pickupBranchID int) (*model.Hold, common.Error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.requireGoodStanding(ctx, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.workRepo.GetWorkByID(ctx, workID); err != nil {
+		return nil, err
+	}
+	books, err := s.bookRepo.ListBooksByWorkIDs(ctx, []int{workID})
+	if err != nil {
+		return nil, err
+	}
+	bookIDs := make([]int, 0, len(books))
+	for _, b := range books {
+		bookIDs = append(bookIDs, b.ID)
+	}
+	if err := s.checkContentRestrictions(ctx, userID, bookIDs); err != nil {
+		return nil, err
+	}
+	if _, err := s.branchRepo.GetBranchByID(ctx, pickupBranchID); err != nil {
+		return nil, err
+	}
+
+	hold, err := s.holdRepo.CreateHold(ctx, model.NewHold(userID, workID, pickupBranchID))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("work_id", workID).Msg("failed to create hold")
+		return nil, err
+	}
+
+	trapped, err := s.TrapAvailableCopies(ctx, workID)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range trapped {
+		if h.ID == hold.ID {
+			return h, nil
+		}
+	}
+
+	return hold, nil
+}
+
+func (s *CirculationService) CancelHold(ctx context.Context, id int) (*model.Hold, common.Error) {
+	hold, err := s.holdRepo.CancelHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// the released copy can go to the next patron in the queue
+	if _, err := s.TrapAvailableCopies(ctx, hold.WorkID); err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (s *CirculationService) ListHoldQueue(ctx context.Context, workID int) ([]*model.Hold, common.Error) {
+	if _, err := s.workRepo.GetWorkByID(ctx, workID); err != nil {
+		return nil, err
+	}
+	return s.holdRepo.ListActiveHoldsByWorkID(ctx, workID)
+}
+
+// TrapAvailableCopies hands on-shelf copies of any edition of the work to
+// pending holds in queue order until either runs out.
+func (s *CirculationService) TrapAvailableCopies(ctx context.Context, workID int) ([]*model.Hold, common.Error) {
+	var trapped []*model.Hold
+	for {
+		hold, err := s.holdRepo.AssignCopyToNextHold(ctx, workID)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Int("work_id", workID).Msg("failed to assign copy to hold")
+			return nil, err
+		}
+		if hold == nil {
+			return trapped, nil
+		}
+		// a copy trapped at another branch is ready once it arrives
+		if hold.Status == model.HoldReady {
+			s.eventPublisher.Publish(ctx, model.HoldReadyEvent(*hold))
+		}
+		trapped = append(trapped, hold)
+	}
+}
@@ -0,0 +1,157 @@
+package circulation
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// authorizeGuardian checks a patron may act on an account, their own or
+// that of a dependent of their household.
+func (s *CirculationService) authorizeGuardian(ctx context.Context, actorID, patronID int, action model.GuardianAction) common.Error {
+	members, err := s.householdRepo.ListHouseholdMembersByUserID(ctx, actorID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", actorID).Msg("failed to list household members")
+		return err
+	}
+	if authErr := model.AuthorizeGuardian(members, actorID, patronID, action); authErr != nil {
+		return common.NewError(common.ErrorCodeAuthPermissionDenied, authErr, common.WithMsg(authErr.Error()))
+	}
+	return nil
+}
+
+// checkContentRestrictions refuses a patron books of subjects their guardian
+// restricted them from.
+func (s *CirculationService) checkContentRestrictions(ctx context.Context, userID int, bookIDs []int) common.Error {
+	subjects, err := s.householdRepo.ListRestrictingSubjects(ctx, userID, bookIDs)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to check content restrictions")
+		return err
+	}
+	if len(subjects) > 0 {
+		restrictErr := model.RestrictedContentError(userID, subjects)
+		return common.NewError(common.ErrorCodeAuthPermissionDenied, restrictErr, common.WithMsg(restrictErr.Error()))
+	}
+	return nil
+}
+
+// ListHouseholdAccounts returns the accounts a guardian looks after: their
+// own, then those of the dependents of their household.
+func (s *CirculationService) ListHouseholdAccounts(ctx context.Context, guardianID int) ([]*model.PatronAccount, common.Error) {
+	if _, err := s.userRepo.GetUserByID(ctx, guardianID); err != nil {
+		return nil, err
+	}
+	members, err := s.householdRepo.ListHouseholdMembersByUserID(ctx, guardianID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", guardianID).Msg("failed to list household members")
+		return nil, err
+	}
+
+	own := &model.PatronAccount{UserID: guardianID}
+	for _, m := range members {
+		if m.UserID == guardianID {
+			own.Role = m.Role
+		}
+	}
+	accounts := []*model.PatronAccount{own}
+	for _, id := range model.Dependents(members, guardianID, model.GuardianViewAccount) {
+		accounts = append(accounts, &model.PatronAccount{UserID: id, Role: model.HouseholdDependent})
+	}
+
+	for _, account := range accounts {
+		if account.Loans, err = s.loanRepo.ListLoansByUserID(ctx, account.UserID, true); err != nil {
+			return nil, err
+		}
+		if account.Holds, err = s.holdRepo.ListActiveHoldsByUserID(ctx, account.UserID); err != nil {
+			return nil, err
+		}
+		if account.Charges, err = s.chargeRepo.ListChargesByUserID(ctx, account.UserID); err != nil {
+			return nil, err
+		}
+		account.OutstandingCents = model.OutstandingCents(account.Charges)
+	}
+	return accounts, nil
+}
+
+// RenewLoanFor renews a loan of the patron or of one of their dependents.
+func (s *CirculationService) RenewLoanFor(ctx context.Context, actorID, loanID int) (*model.BorrowedBook, common.Error) {
+	loan, err := s.loanRepo.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeGuardian(ctx, actorID, loan.UserID, model.GuardianRenewLoans); err != nil {
+		return nil, err
+	}
+	return s.RenewLoan(ctx, loanID)
+}
+
+// PlaceHoldFor places a hold for the patron or for one of their dependents.
+func (s *CirculationService) PlaceHoldFor(ctx context.Context, actorID, userID, workID, pickupBranchID int) (*model.Hold, common.Error) {
+	if err := s.authorizeGuardian(ctx, actorID, userID, model.GuardianManageHolds); err != nil {
+		return nil, err
+	}
+	return s.PlaceHold(ctx, userID, workID, pickupBranchID)
+}
+
+// CancelHoldFor cancels a hold of the patron or of one of their dependents.
+func (s *CirculationService) CancelHoldFor(ctx context.Context, actorID, holdID int) (*model.Hold, common.Error) {
+	hold, err := s.holdRepo.GetHoldByID(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeGuardian(ctx, actorID, hold.UserID, model.GuardianManageHolds); err != nil {
+		return nil, err
+	}
+	return s.CancelHold(ctx, holdID)
+}
+
+// PayCharges records a patron paying charges, their own or those of the
+// dependents they pay for. With no charge given, every charge they may pay
+// and still owed is paid.
+func (s *CirculationService) PayCharges(ctx context.Context, payerID int, chargeIDs []int) ([]*model.Charge, common.Error) {
+	if _, err := s.userRepo.GetUserByID(ctx, payerID); err != nil {
+		return nil, err
+	}
+
+	if len(chargeIDs) == 0 {
+		members, err := s.householdRepo.ListHouseholdMembersByUserID(ctx, payerID)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Int("user_id", payerID).Msg("failed to list household members")
+			return nil, err
+		}
+		userIDs := append([]int{payerID}, model.Dependents(members, payerID, model.GuardianPayCharges)...)
+		for _, userID := range userIDs {
+			charges, err := s.chargeRepo.ListChargesByUserID(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range charges {
+				if c.IsOutstanding() {
+					chargeIDs = append(chargeIDs, c.ID)
+				}
+			}
+		}
+		if len(chargeIDs) == 0 {
+			return []*model.Charge{}, nil
+		}
+	} else {
+		for _, id := range chargeIDs {
+			charge, err := s.chargeRepo.GetChargeByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.authorizeGuardian(ctx, payerID, charge.UserID, model.GuardianPayCharges); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	paid, err := s.chargeRepo.PayCharges(ctx, chargeIDs, payerID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", payerID).Msg("failed to pay charges")
+		return nil, err
+	}
+	return paid, nil
+}
@@ -0,0 +1,74 @@
+package circulation
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type HoldRepository interface {
+	CreateHold(ctx context.Context, param model.Hold) (*model.Hold, common.Error)
+	GetHoldByID(ctx context.Context, id int) (*model.Hold, common.Error)
+	ListActiveHoldsByWorkID(ctx context.Context, workID int) ([]*model.Hold, common.Error)
+	ListActiveHoldsByUserID(ctx context.Context, userID int) ([]*model.Hold, common.Error)
+	CancelHold(ctx context.Context, id int) (*model.Hold, common.Error)
+	AssignCopyToNextHold(ctx context.Context, workID int) (*model.Hold, common.Error)
+}
+
+type WorkRepository interface {
+	GetWorkByID(ctx context.Context, id int) (*model.Work, common.Error)
+}
+
+type UserRepository interface {
+	GetUserByID(ctx context.Context, id int) (*model.User, common.Error)
+}
+
+type BranchRepository interface {
+	GetBranchByID(ctx context.Context, id int) (*model.Branch, common.Error)
+}
+
+type BookRepository interface {
+	GetBookByID(ctx context.Context, id int) (*model.Book, common.Error)
+	ListBooksByWorkIDs(ctx context.Context, workIDs []int) ([]*model.Book, common.Error)
+}
+
+type CopyRepository interface {
+	GetBookCopyByID(ctx context.Context, id int) (*model.BookCopies, common.Error)
+}
+
+type CalendarRepository interface {
+	GetBranchCalendar(ctx context.Context, branchID int, from, to time.Time) (*model.BranchCalendar, common.Error)
+}
+
+type LoanRepository interface {
+	GetLoanByID(ctx context.Context, id int) (*model.BorrowedBook, common.Error)
+	GetOpenLoanByCopyID(ctx context.Context, copyID int) (*model.BorrowedBook, common.Error)
+	ListLoansByUserID(ctx context.Context, userID int, openOnly bool) ([]*model.BorrowedBook, common.Error)
+	CreateLoan(ctx context.Context, param model.BorrowedBook, standing model.StandingPolicy, changedBy string) (*model.BorrowedBook, common.Error)
+	RenewLoan(ctx context.Context, id int, newDue time.Time, policy model.LoanPolicy, standing model.StandingPolicy) (*model.BorrowedBook, common.Error)
+	ReturnLoan(ctx context.Context, id int, returnedAt time.Time, fine *model.Charge, returnedBy string) (*model.BorrowedBook, *model.Charge, common.Error)
+	ListOverdueLoans(ctx context.Context, dueBefore time.Time) ([]*model.BorrowedBook, common.Error)
+	DeclareLoanLost(ctx context.Context, copyID int, policy model.LostItemPolicy, changedBy, reason string) (*model.BorrowedBook, []*model.Charge, common.Error)
+	ReturnLostCopy(ctx context.Context, copyID int, changedBy string) (*model.BookCopies, []*model.Charge, common.Error)
+}
+
+type ChargeRepository interface {
+	GetChargeByID(ctx context.Context, id int) (*model.Charge, common.Error)
+	ListChargesByUserID(ctx context.Context, userID int) ([]*model.Charge, common.Error)
+	ReverseCharge(ctx context.Context, id int, reversedBy, reason string) (*model.Charge, common.Error)
+	PayCharges(ctx context.Context, ids []int, paidByUserID int) ([]*model.Charge, common.Error)
+}
+
+type StandingRepository interface {
+	GetAccountFacts(ctx context.Context, userID int, now time.Time) (*model.AccountFacts, common.Error)
+	CreateManualBlock(ctx context.Context, param model.ManualBlock) (*model.ManualBlock, common.Error)
+	ListManualBlocksByUserID(ctx context.Context, userID int, activeOnly bool) ([]*model.ManualBlock, common.Error)
+	LiftManualBlock(ctx context.Context, id int, liftedBy string) (*model.ManualBlock, common.Error)
+}
+
+type HouseholdRepository interface {
+	ListHouseholdMembersByUserID(ctx context.Context, userID int) ([]*model.HouseholdMember, common.Error)
+	ListRestrictingSubjects(ctx context.Context, userID int, bookIDs []int) ([]*model.Subject, common.Error)
+}
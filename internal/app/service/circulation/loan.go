@@ -0,0 +1,173 @@
+package circulation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// Checkout lends a copy to a user in good standing. The loan is due at closing time of the
+// first day the lending branch is open once the loan period has passed.
+func (s *CirculationService) Checkout(ctx context.Context, userID, copyID int, changedBy string) (*model.BorrowedBook, common.Error) {
+	if err := requireActor(changedBy); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	bookCopy, err := s.copyRepo.GetBookCopyByID(ctx, copyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkContentRestrictions(ctx, userID, []int{bookCopy.BookID}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	due, err := s.dueDate(ctx, bookCopy.CurrentBranchID, now)
+	if err != nil {
+		return nil, err
+	}
+	loan := model.NewBorrowdBook(userID, copyID, now, due)
+	loan.BranchID = &bookCopy.CurrentBranchID
+
+	created, err := s.loanRepo.CreateLoan(ctx, loan, s.standingPolicy, changedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", copyID).Msg("failed to check out copy")
+		return nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.LoanEvent(model.WebhookLoanCheckedOut, *created))
+	return created, nil
+}
+
+// RenewLoan lends a copy for another loan period from today, unless another
+// patron waits for its work or the borrower is blocked.
+func (s *CirculationService) RenewLoan(ctx context.Context, id int) (*model.BorrowedBook, common.Error) {
+	loan, err := s.loanRepo.GetLoanByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	bookCopy, err := s.copyRepo.GetBookCopyByID(ctx, loan.CopyID)
+	if err != nil {
+		return nil, err
+	}
+	book, err := s.bookRepo.GetBookByID(ctx, bookCopy.BookID)
+	if err != nil {
+		return nil, err
+	}
+	holds, err := s.holdRepo.ListActiveHoldsByWorkID(ctx, book.WorkID)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range holds {
+		if h.Status == model.HoldPending {
+			err := fmt.Errorf("work %d has pending holds", book.WorkID)
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("another patron is waiting for this work"))
+		}
+	}
+
+	due, err := s.dueDate(ctx, loanBranchID(*loan), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	renewed, err := s.loanRepo.RenewLoan(ctx, id, due, s.loanPolicy, s.standingPolicy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("loan_id", id).Msg("failed to renew loan")
+		return nil, err
+	}
+	return renewed, nil
+}
+
+// ReturnCopy checks in a copy on loan. A copy returned late is fined for the
+// days its lending branch was open past the due date, and holds waiting for
+// its work may take it at once.
+func (s *CirculationService) ReturnCopy(ctx context.Context, copyID int, returnedBy string) (*model.BorrowedBook, *model.Charge, common.Error) {
+	if err := requireActor(returnedBy); err != nil {
+		return nil, nil, err
+	}
+	loan, err := s.loanRepo.GetOpenLoanByCopyID(ctx, copyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	bookCopy, err := s.copyRepo.GetBookCopyByID(ctx, copyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	cal, err := s.branchCalendar(ctx, loanBranchID(*loan), loan.DueDate, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fine *model.Charge
+	if amount, days := s.loanPolicy.OverdueFine(*cal, *loan, now); amount > 0 {
+		charge := model.OverdueFineCharge(*loan, *bookCopy, amount, days, returnedBy)
+		fine = &charge
+	}
+
+	returned, charge, err := s.loanRepo.ReturnLoan(ctx, loan.ID, now, fine, returnedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", copyID).Msg("failed to return copy")
+		return nil, nil, err
+	}
+	s.eventPublisher.Publish(ctx, model.LoanEvent(model.WebhookLoanReturned, *returned))
+
+	// the copy is back whatever happens to the holds
+	if err := s.trapHoldsForBook(ctx, bookCopy.BookID); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", copyID).Msg("failed to trap holds for returned copy")
+	}
+	return returned, charge, nil
+}
+
+func (s *CirculationService) GetLoan(ctx context.Context, id int) (*model.BorrowedBook, common.Error) {
+	return s.loanRepo.GetLoanByID(ctx, id)
+}
+
+func (s *CirculationService) ListUserLoans(ctx context.Context, userID int, openOnly bool) ([]*model.BorrowedBook, common.Error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.loanRepo.ListLoansByUserID(ctx, userID, openOnly)
+}
+
+// dueDate is when a copy lent by a branch at a time is due back.
+func (s *CirculationService) dueDate(ctx context.Context, branchID int, lentAt time.Time) (time.Time, common.Error) {
+	// a year past the end of the loan period covers any run of closures
+	// the due date may slip across
+	until := lentAt.AddDate(1, 0, s.loanPolicy.LoanDays)
+	cal, err := s.branchCalendar(ctx, branchID, lentAt, until)
+	if err != nil {
+		return time.Time{}, err
+	}
+	due, dErr := cal.DueDate(lentAt, s.loanPolicy.LoanDays)
+	if dErr != nil {
+		return time.Time{}, common.NewError(common.ErrorCodeParameterInvalid, dErr, common.WithMsg(dErr.Error()))
+	}
+	return due, nil
+}
+
+// branchCalendar returns the calendar of a branch, or of the main branch when
+// branchID is zero, with the closures between two times.
+func (s *CirculationService) branchCalendar(ctx context.Context, branchID int, from, to time.Time) (*model.BranchCalendar, common.Error) {
+	cal, err := s.calendarRepo.GetBranchCalendar(ctx, branchID,
+		model.CalendarDay(from, s.location), model.CalendarDay(to, s.location))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("branch_id", branchID).Msg("failed to get branch calendar")
+		return nil, err
+	}
+	cal.Location = s.location
+	return cal, nil
+}
+
+// loanBranchID is the branch whose calendar a loan follows; loans made
+// before branches were recorded follow the main branch.
+func loanBranchID(loan model.BorrowedBook) int {
+	if loan.BranchID == nil {
+		return 0
+	}
+	return *loan.BranchID
+}
@@ -0,0 +1,93 @@
+package circulation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// DeclareCopyLost declares the copy out on loan lost and charges its
+// borrower for the replacement and its processing.
+func (s *CirculationService) DeclareCopyLost(ctx context.Context, copyID int, declaredBy, reason string) (*model.BorrowedBook, []*model.Charge, common.Error) {
+	if err := requireActor(declaredBy); err != nil {
+		return nil, nil, err
+	}
+	if strings.TrimSpace(reason) == "" {
+		reason = "declared lost"
+	}
+
+	loan, charges, err := s.loanRepo.DeclareLoanLost(ctx, copyID, s.lostItemPolicy, declaredBy, reason)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", copyID).Msg("failed to declare copy lost")
+		return nil, nil, err
+	}
+	return loan, charges, nil
+}
+
+// DeclareOverdueLoansLost declares lost the copies overdue for longer than
+// the policy allows. A loan failing does not stop the others; the number of
+// copies declared lost is returned.
+func (s *CirculationService) DeclareOverdueLoansLost(ctx context.Context) (int, common.Error) {
+	if s.lostItemPolicy.LostAfterDays <= 0 {
+		return 0, nil
+	}
+
+	loans, err := s.loanRepo.ListOverdueLoans(ctx, s.lostItemPolicy.OverdueCutoff(time.Now()))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list overdue loans")
+		return 0, err
+	}
+
+	declared := 0
+	reason := fmt.Sprintf("overdue for more than %d days", s.lostItemPolicy.LostAfterDays)
+	for _, loan := range loans {
+		if _, _, err := s.loanRepo.DeclareLoanLost(ctx, loan.CopyID, s.lostItemPolicy, model.SystemActor, reason); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Int("loan_id", loan.ID).Msg("failed to declare overdue loan lost")
+			continue
+		}
+		declared++
+	}
+	return declared, nil
+}
+
+// ReturnLostCopy puts a lost copy found again back on the shelf and reverses
+// the charges of its loss. Holds waiting for its work may take it at once.
+func (s *CirculationService) ReturnLostCopy(ctx context.Context, copyID int, changedBy string) (*model.BookCopies, []*model.Charge, common.Error) {
+	if err := requireActor(changedBy); err != nil {
+		return nil, nil, err
+	}
+
+	bookCopy, reversed, err := s.loanRepo.ReturnLostCopy(ctx, copyID, changedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", copyID).Msg("failed to return lost copy")
+		return nil, nil, err
+	}
+
+	// the copy is back whatever happens to the holds
+	if err := s.trapHoldsForBook(ctx, bookCopy.BookID); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", copyID).Msg("failed to trap holds for found copy")
+	}
+	return bookCopy, reversed, nil
+}
+
+func (s *CirculationService) trapHoldsForBook(ctx context.Context, bookID int) common.Error {
+	book, err := s.bookRepo.GetBookByID(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	_, err = s.TrapAvailableCopies(ctx, book.WorkID)
+	return err
+}
+
+func requireActor(changedBy string) common.Error {
+	if strings.TrimSpace(changedBy) == "" {
+		err := fmt.Errorf("changed_by is empty")
+		return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the staff member making the change is required"))
+	}
+	return nil
+}
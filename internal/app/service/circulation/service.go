@@ -0,0 +1,74 @@
+package circulation
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type CirculationService struct {
+	holdRepo      HoldRepository
+	workRepo      WorkRepository
+	userRepo      UserRepository
+	branchRepo    BranchRepository
+	bookRepo      BookRepository
+	copyRepo      CopyRepository
+	calendarRepo  CalendarRepository
+	loanRepo      LoanRepository
+	chargeRepo    ChargeRepository
+	standingRepo  StandingRepository
+	householdRepo HouseholdRepository
+
+	eventPublisher webhook.Events
+	location       *time.Location
+	loanPolicy     model.LoanPolicy
+	standingPolicy model.StandingPolicy
+	lostItemPolicy model.LostItemPolicy
+}
+
+type CirculationServiceParam struct {
+	HoldRepo      HoldRepository
+	WorkRepo      WorkRepository
+	UserRepo      UserRepository
+	BranchRepo    BranchRepository
+	BookRepo      BookRepository
+	CopyRepo      CopyRepository
+	CalendarRepo  CalendarRepository
+	LoanRepo      LoanRepository
+	ChargeRepo    ChargeRepository
+	StandingRepo  StandingRepository
+	HouseholdRepo HouseholdRepository
+
+	// EventPublisher tells integrations about checkouts, returns and holds
+	// becoming ready.
+	EventPublisher webhook.Publisher
+	// Location is the time zone of the library, in which due dates fall.
+	Location       *time.Location
+	LoanPolicy     model.LoanPolicy
+	StandingPolicy model.StandingPolicy
+	LostItemPolicy model.LostItemPolicy
+}
+
+func NewCirculationService(_ context.Context, param CirculationServiceParam) *CirculationService {
+	return &CirculationService{
+		holdRepo:      param.HoldRepo,
+		workRepo:      param.WorkRepo,
+		userRepo:      param.UserRepo,
+		branchRepo:    param.BranchRepo,
+		bookRepo:      param.BookRepo,
+		copyRepo:      param.CopyRepo,
+		calendarRepo:  param.CalendarRepo,
+		loanRepo:      param.LoanRepo,
+		chargeRepo:    param.ChargeRepo,
+		standingRepo:  param.StandingRepo,
+		householdRepo: param.HouseholdRepo,
+
+		eventPublisher: webhook.NewEvents(param.EventPublisher),
+		location:       param.Location,
+		loanPolicy:     param.LoanPolicy,
+		standingPolicy: param.StandingPolicy,
+		lostItemPolicy: param.LostItemPolicy,
+	}
+}
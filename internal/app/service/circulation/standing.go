@@ -0,0 +1,79 @@
+package circulation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// GetAccountStanding judges whether a patron may borrow, renew and place
+// holds right now, and if not, why.
+func (s *CirculationService) GetAccountStanding(ctx context.Context, userID int) (*model.AccountStanding, common.Error) {
+	now := time.Now()
+	facts, err := s.standingRepo.GetAccountFacts(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	standing := s.standingPolicy.Assess(*facts, now)
+	return &standing, nil
+}
+
+// requireGoodStanding refuses a blocked patron, telling staff why.
+func (s *CirculationService) requireGoodStanding(ctx context.Context, userID int) common.Error {
+	standing, err := s.GetAccountStanding(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if standing.IsBlocked() {
+		msg := standing.Explain()
+		return common.NewError(common.ErrorCodeAuthPermissionDenied, errors.New(msg), common.WithMsg(msg),
+			common.WithDetail(map[string]interface{}{"blocks": standing.BlockKinds()}))
+	}
+	return nil
+}
+
+// BlockAccount puts a manual block on an account until staff lift it.
+func (s *CirculationService) BlockAccount(ctx context.Context, userID int, reason, blockedBy string) (*model.ManualBlock, common.Error) {
+	if err := requireActor(blockedBy); err != nil {
+		return nil, err
+	}
+	block := model.NewManualBlock(userID, reason, blockedBy)
+	if err := model.ValidateManualBlock(block); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	created, err := s.standingRepo.CreateManualBlock(ctx, block)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to block account")
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *CirculationService) LiftBlock(ctx context.Context, id int, liftedBy string) (*model.ManualBlock, common.Error) {
+	if err := requireActor(liftedBy); err != nil {
+		return nil, err
+	}
+
+	block, err := s.standingRepo.LiftManualBlock(ctx, id, liftedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("block_id", id).Msg("failed to lift block")
+		return nil, err
+	}
+	return block, nil
+}
+
+// ListAccountBlocks returns the manual blocks of an account, lifted ones included.
+func (s *CirculationService) ListAccountBlocks(ctx context.Context, userID int) ([]*model.ManualBlock, common.Error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.standingRepo.ListManualBlocksByUserID(ctx, userID, false)
+}
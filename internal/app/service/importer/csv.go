@@ -0,0 +1,137 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+)
+
+// Entity is the kind of record a CSV file holds.
+type Entity string
+
+const (
+	EntityBooks  Entity = "books"
+	EntityCopies Entity = "copies"
+	EntityUsers  Entity = "users"
+)
+
+type csvField struct {
+	name     string
+	required bool
+}
+
+var entityFields = map[Entity][]csvField{
+	EntityBooks: {
+		{name: "isbn", required: true},
+		{name: "title", required: true},
+		{name: "author", required: true},
+		{name: "published_year", required: true},
+		{name: "edition"},
+		{name: "language"},
+		{name: "classification"},
+		{name: "call_number"},
+		{name: "work_title"},
+	},
+	EntityCopies: {
+		{name: "isbn", required: true},
+		{name: "barcode"},
+		{name: "branch"},
+		{name: "status"},
+		{name: "call_number"},
+	},
+	EntityUsers: {
+		{name: "email", required: true},
+		{name: "name", required: true},
+	},
+}
+
+// csvRow is a data row with its values keyed by field name.
+type csvRow struct {
+	// line is the spreadsheet row number, counting the header as row 1.
+	line   int
+	values map[string]string
+}
+
+// csvSource reads data rows of a CSV file whose header is mapped to the fields of an entity.
+type csvSource struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+// newCSVSource reads the header and resolves the column of every field. A
+// field is read from the column named in mapping, or from the column named
+// like the field itself. Header names are matched case-insensitively.
+func newCSVSource(r io.Reader, entity Entity, mapping map[string]string) (*csvSource, common.Error) {
+	fields, ok := entityFields[entity]
+	if !ok {
+		return nil, invalidParameter(fmt.Errorf("unknown import entity %q", entity))
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.name] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, invalidParameter(fmt.Errorf("unknown field %q in column mapping", field))
+		}
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, invalidParameter(fmt.Errorf("failed to read CSV header: %w", err))
+	}
+	headerIndex := make(map[string]int, len(header))
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		headerIndex[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	columns := make(map[string]int)
+	for _, f := range fields {
+		column := f.name
+		if mapped, ok := mapping[f.name]; ok {
+			column = mapped
+		}
+		idx, ok := headerIndex[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			if f.required {
+				return nil, invalidParameter(fmt.Errorf("column %q for required field %q is missing", column, f.name))
+			}
+			continue
+		}
+		columns[f.name] = idx
+	}
+
+	return &csvSource{r: cr, columns: columns}, nil
+}
+
+// next returns the next data row, or io.EOF after the last one. Blank lines are skipped.
+func (s *csvSource) next() (*csvRow, error) {
+	record, err := s.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	line, _ := s.r.FieldPos(0)
+	row := &csvRow{line: line, values: make(map[string]string, len(s.columns))}
+	for field, idx := range s.columns {
+		if idx < len(record) {
+			row.values[field] = strings.TrimSpace(record[idx])
+		}
+	}
+	return row, nil
+}
+
+func invalidParameter(err error) common.Error {
+	return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+}
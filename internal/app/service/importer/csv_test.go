@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCSVSource_Mapping(t *testing.T) {
+	data := "\ufeffISBN13,Book Title,Writer,Year\n" +
+		"978-0-06-093434-7,Don Quixote,Miguel de Cervantes,2003\n" +
+		"\n" +
+		"bad,,,\n"
+
+	source, err := newCSVSource(strings.NewReader(data), EntityBooks, map[string]string{
+		"isbn":           "isbn13",
+		"title":          "Book Title",
+		"author":         "writer",
+		"published_year": "YEAR",
+	})
+	require.NoError(t, err)
+
+	row, readErr := source.next()
+	require.NoError(t, readErr)
+	assert.Equal(t, 2, row.line)
+
+	rec, parseErr := parseBookRow(row)
+	require.NoError(t, parseErr)
+	assert.Equal(t, "9780060934347", rec.Book.ISBN)
+	assert.Equal(t, "Don Quixote", rec.WorkTitle)
+	assert.Equal(t, 2003, rec.Book.PublishedYear.Year())
+
+	// blank lines are skipped but still counted
+	row, readErr = source.next()
+	require.NoError(t, readErr)
+	assert.Equal(t, 4, row.line)
+	_, parseErr = parseBookRow(row)
+	assert.Error(t, parseErr)
+
+	_, readErr = source.next()
+	assert.Equal(t, io.EOF, readErr)
+}
+
+func TestNewCSVSource_Invalid(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Entity  Entity
+		Data    string
+		Mapping map[string]string
+	}{
+		{Name: "unknown entity", Entity: "loans", Data: "a\n"},
+		{Name: "missing required column", Entity: EntityUsers, Data: "email\nx@example.com\n"},
+		{Name: "unknown mapped field", Entity: EntityUsers, Data: "email,name\n", Mapping: map[string]string{"phone": "Phone"}},
+		{Name: "empty file", Entity: EntityUsers, Data: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			_, err := newCSVSource(strings.NewReader(tt.Data), tt.Entity, tt.Mapping)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseBookRow_CallNumber(t *testing.T) {
+	row := &csvRow{line: 2, values: map[string]string{
+		"isbn":           "9780156012195",
+		"title":          "The Little Prince",
+		"author":         "Antoine de Saint-Exupéry",
+		"published_year": "1943",
+		"classification": "ddc",
+		"call_number":    "843.912 SAI",
+		"work_title":     "Le Petit Prince",
+	}}
+
+	rec, err := parseBookRow(row)
+	require.NoError(t, err)
+	require.NotNil(t, rec.Book.CallNumber)
+	assert.Equal(t, "843912 SAI", rec.Book.CallNumber.SortKey)
+	assert.Equal(t, "Le Petit Prince", rec.WorkTitle)
+
+	row.values["classification"] = ""
+	_, err = parseBookRow(row)
+	assert.Error(t, err)
+}
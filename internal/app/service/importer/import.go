@@ -0,0 +1,503 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// lookupChunkSize bounds the number of keys looked up in one query.
+const lookupChunkSize = 1000
+
+type CSVImportParam struct {
+	Entity Entity
+	// Mapping maps a field name to the CSV column holding it.
+	Mapping map[string]string
+	// Upsert updates records matched by ISBN or email instead of rejecting them.
+	Upsert bool
+	// DryRun validates the file and reports what would happen without writing anything.
+	DryRun bool
+}
+
+// ImportCSV validates every row of a CSV file, loads the valid rows in one
+// transaction and stores a report whose failed rows can be downloaded later.
+func (s *ImportService) ImportCSV(ctx context.Context, r io.Reader, param CSVImportParam) (*model.ImportReport, common.Error) {
+	source, err := newCSVSource(r, param.Entity, param.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []model.ImportRow
+	switch param.Entity {
+	case EntityBooks:
+		rows, err = s.importBooks(ctx, source, param)
+	case EntityCopies:
+		rows, err = s.importCopies(ctx, source, param)
+	case EntityUsers:
+		rows, err = s.importUsers(ctx, source, param)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := model.ImportReport{Source: "csv:" + string(param.Entity), DryRun: param.DryRun}
+	for _, row := range rows {
+		report.Add(row)
+	}
+
+	saved, err := s.reportRepo.CreateImportReport(ctx, report)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to save import report")
+		return nil, err
+	}
+	report.ID = saved.ID
+	report.CreatedAt = saved.CreatedAt
+
+	zerolog.Ctx(ctx).Info().
+		Int("report_id", report.ID).
+		Str("source", report.Source).
+		Bool("dry_run", report.DryRun).
+		Int("created", report.Created).
+		Int("updated", report.Updated).
+		Int("failed", report.Failed).
+		Msg("CSV import finished")
+	return &report, nil
+}
+
+func (s *ImportService) GetImportReport(ctx context.Context, id int) (*model.ImportReport, common.Error) {
+	return s.reportRepo.GetImportReportByID(ctx, id)
+}
+
+// WriteErrorReport writes the failed rows of a stored import report as CSV.
+func (s *ImportService) WriteErrorReport(ctx context.Context, id int, w io.Writer) common.Error {
+	report, err := s.reportRepo.GetImportReportByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"row", "key", "error"})
+	for _, row := range report.Errors() {
+		_ = cw.Write([]string{strconv.Itoa(row.Index), row.Key, row.Message})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return nil
+}
+
+// readRows reads every data row, turning unparsable lines into error rows.
+func readRows(source *csvSource, parse func(row *csvRow) (key string, err error)) ([]model.ImportRow, []int, common.Error) {
+	var rows []model.ImportRow
+	var valid []int
+	seen := make(map[string]int)
+
+	for {
+		row, err := source.next()
+		if err == io.EOF {
+			return rows, valid, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, model.ImportRow{Index: parseErr.Line, Action: model.ImportActionError, Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, invalidParameter(fmt.Errorf("failed to read CSV: %w", err))
+		}
+
+		key, err := parse(row)
+		if err != nil {
+			rows = append(rows, model.ImportRow{Index: row.line, Key: key, Action: model.ImportActionError, Message: err.Error()})
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			rows = append(rows, model.ImportRow{Index: row.line, Key: key, Action: model.ImportActionError,
+				Message: fmt.Sprintf("duplicate of row %d", first)})
+			continue
+		}
+		seen[key] = row.line
+
+		rows = append(rows, model.ImportRow{Index: row.line, Key: key, Action: model.ImportActionCreate})
+		valid = append(valid, len(rows)-1)
+	}
+}
+
+// resolveAction marks rows whose key already exists as updates, or as errors
+// when updates are not allowed.
+func resolveAction(row *model.ImportRow, existingID int, upsert bool) {
+	if existingID == 0 {
+		return
+	}
+	if !upsert {
+		row.Action = model.ImportActionError
+		row.Message = "already exists"
+		return
+	}
+	row.Action = model.ImportActionUpdate
+	row.ID = existingID
+}
+
+// failRows turns every pending row into an error after the load was rolled back.
+func failRows(rows []model.ImportRow, valid []int, err error) {
+	for _, i := range valid {
+		if rows[i].Action == model.ImportActionError {
+			continue
+		}
+		rows[i].Action = model.ImportActionError
+		rows[i].ID = 0
+		rows[i].Message = fmt.Sprintf("load rolled back: %s", err.Error())
+	}
+}
+
+func (s *ImportService) importBooks(ctx context.Context, source *csvSource, param CSVImportParam) ([]model.ImportRow, common.Error) {
+	records := make(map[int]model.CatalogRecord)
+	rows, valid, err := readRows(source, func(row *csvRow) (string, error) {
+		rec, err := parseBookRow(row)
+		if err != nil {
+			return row.values["isbn"], err
+		}
+		records[row.line] = rec
+		return rec.Book.ISBN, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	isbns := make([]string, 0, len(valid))
+	for _, i := range valid {
+		isbns = append(isbns, rows[i].Key)
+	}
+	existing, err := s.lookupBooksByISBNs(ctx, isbns)
+	if err != nil {
+		return nil, err
+	}
+
+	var load []model.CatalogRecord
+	for _, i := range valid {
+		existingID := 0
+		if b, ok := existing[rows[i].Key]; ok {
+			existingID = b.ID
+		}
+		resolveAction(&rows[i], existingID, param.Upsert)
+		if rows[i].Action != model.ImportActionError {
+			load = append(load, records[rows[i].Index])
+		}
+	}
+	if param.DryRun {
+		return rows, nil
+	}
+
+	ids, err := s.bulkRepo.BulkUpsertBooks(ctx, load, param.Upsert)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to load books")
+		failRows(rows, valid, err)
+		return rows, nil
+	}
+	for _, i := range valid {
+		if id, ok := ids[rows[i].Key]; ok {
+			rows[i].ID = id
+			s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogBook, id, rows[i].Action.CatalogAction()))
+		}
+	}
+	return rows, nil
+}
+
+func (s *ImportService) importCopies(ctx context.Context, source *csvSource, param CSVImportParam) ([]model.ImportRow, common.Error) {
+	type copyRow struct {
+		isbn       string
+		barcode    string
+		branch     string
+		status     model.BookStatus
+		callNumber string
+	}
+	parsed := make(map[int]copyRow)
+
+	// a copy is known by its barcode; unlabelled copies are kept apart by their row number
+	rows, valid, err := readRows(source, func(row *csvRow) (string, error) {
+		isbn, err := model.NormalizeISBN(row.values["isbn"])
+		if err != nil {
+			return row.values["isbn"], err
+		}
+		var barcode string
+		if raw := row.values["barcode"]; raw != "" {
+			if barcode, err = s.barcodeFormats.Validate(raw); err != nil {
+				return raw, err
+			}
+		}
+		var branch string
+		if raw := row.values["branch"]; raw != "" {
+			if branch, err = model.NormalizeBranchCode(raw); err != nil {
+				return isbn, err
+			}
+		}
+		status := model.InLibrary
+		if raw := row.values["status"]; raw != "" {
+			if status, err = model.ParseBookStatus(raw); err != nil {
+				return isbn, err
+			}
+			// a loaded copy has no loan, hold or transfer behind it
+			if !status.IsManual() {
+				return isbn, fmt.Errorf("a copy cannot be imported as %s", status)
+			}
+		}
+		parsed[row.line] = copyRow{isbn: isbn, barcode: barcode, branch: branch, status: status, callNumber: row.values["call_number"]}
+		if barcode != "" {
+			return barcode, nil
+		}
+		return fmt.Sprintf("%s#%d", isbn, row.line), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	isbns := make([]string, 0, len(valid))
+	var barcodes []string
+	for _, i := range valid {
+		p := parsed[rows[i].Index]
+		isbns = append(isbns, p.isbn)
+		if p.barcode != "" {
+			barcodes = append(barcodes, p.barcode)
+		}
+	}
+	books, err := s.lookupBooksByISBNs(ctx, isbns)
+	if err != nil {
+		return nil, err
+	}
+	labelled, err := s.lookupCopiesByBarcodes(ctx, barcodes)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := s.lookupBranchesByCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var load []model.BookCopies
+	for _, i := range valid {
+		p := parsed[rows[i].Index]
+		if p.barcode == "" {
+			rows[i].Key = p.isbn
+		}
+		book, ok := books[p.isbn]
+		if !ok {
+			rows[i].Action = model.ImportActionError
+			rows[i].Message = "no book with this ISBN"
+			continue
+		}
+		if labelled[p.barcode] {
+			rows[i].Action = model.ImportActionError
+			rows[i].Message = "barcode is already in use"
+			continue
+		}
+
+		c := model.NewBookCopies(book.ID, p.barcode, p.status)
+		// copies without a branch belong to the main branch
+		if p.branch != "" {
+			branchID, ok := branches[p.branch]
+			if !ok {
+				rows[i].Action = model.ImportActionError
+				rows[i].Message = "no branch with this code"
+				continue
+			}
+			c.HomeBranchID = branchID
+		}
+		if p.callNumber != "" {
+			if book.CallNumber == nil {
+				rows[i].Action = model.ImportActionError
+				rows[i].Message = "book has no classification for the copy call number"
+				continue
+			}
+			cn, err := model.ParseCallNumber(book.CallNumber.Scheme, p.callNumber)
+			if err != nil {
+				rows[i].Action = model.ImportActionError
+				rows[i].Message = err.Error()
+				continue
+			}
+			c.CallNumber = &cn
+		}
+		load = append(load, c)
+	}
+	if param.DryRun {
+		return rows, nil
+	}
+
+	if err := s.bulkRepo.BulkCreateBookCopies(ctx, load); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to load book copies")
+		failRows(rows, valid, err)
+		return rows, nil
+	}
+
+	// the bulk load does not tell the IDs of the copies, so integrations hear
+	// of the books which gained copies
+	updated := make(map[int]bool)
+	for _, c := range load {
+		if !updated[c.BookID] {
+			updated[c.BookID] = true
+			s.eventPublisher.Publish(ctx, model.CatalogEvent(model.CatalogBook, c.BookID, model.CatalogUpdated))
+		}
+	}
+	return rows, nil
+}
+
+func (s *ImportService) importUsers(ctx context.Context, source *csvSource, param CSVImportParam) ([]model.ImportRow, common.Error) {
+	users := make(map[int]model.User)
+	rows, valid, err := readRows(source, func(row *csvRow) (string, error) {
+		address, err := mail.ParseAddress(row.values["email"])
+		if err != nil {
+			return row.values["email"], fmt.Errorf("invalid email: %w", err)
+		}
+		email := strings.ToLower(address.Address)
+		name := row.values["name"]
+		if name == "" {
+			return email, fmt.Errorf("name is empty")
+		}
+		users[row.line] = model.NewUser(uuid.NewString(), email, name)
+		return email, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]string, 0, len(valid))
+	for _, i := range valid {
+		emails = append(emails, rows[i].Key)
+	}
+	existing := make(map[string]int)
+	for start := 0; start < len(emails); start += lookupChunkSize {
+		end := start + lookupChunkSize
+		if end > len(emails) {
+			end = len(emails)
+		}
+		found, err := s.bulkRepo.ListUsersByEmails(ctx, emails[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range found {
+			existing[u.Email] = u.ID
+		}
+	}
+
+	var load []model.User
+	for _, i := range valid {
+		resolveAction(&rows[i], existing[rows[i].Key], param.Upsert)
+		if rows[i].Action != model.ImportActionError {
+			load = append(load, users[rows[i].Index])
+		}
+	}
+	if param.DryRun {
+		return rows, nil
+	}
+
+	ids, err := s.bulkRepo.BulkUpsertUsers(ctx, load, param.Upsert)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to load users")
+		failRows(rows, valid, err)
+		return rows, nil
+	}
+	for _, i := range valid {
+		if id, ok := ids[rows[i].Key]; ok {
+			rows[i].ID = id
+		}
+	}
+	return rows, nil
+}
+
+func (s *ImportService) lookupBooksByISBNs(ctx context.Context, isbns []string) (map[string]*model.Book, common.Error) {
+	books := make(map[string]*model.Book)
+	for start := 0; start < len(isbns); start += lookupChunkSize {
+		end := start + lookupChunkSize
+		if end > len(isbns) {
+			end = len(isbns)
+		}
+		found, err := s.bulkRepo.ListBooksByISBNs(ctx, isbns[start:end])
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to look up books by ISBN")
+			return nil, err
+		}
+		for _, b := range found {
+			books[b.ISBN] = b
+		}
+	}
+	return books, nil
+}
+
+// lookupBranchesByCode returns the IDs of the branches by code.
+func (s *ImportService) lookupBranchesByCode(ctx context.Context) (map[string]int, common.Error) {
+	branches, err := s.bulkRepo.ListBranches(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list branches")
+		return nil, err
+	}
+	ids := make(map[string]int, len(branches))
+	for _, b := range branches {
+		ids[b.Code] = b.ID
+	}
+	return ids, nil
+}
+
+// lookupCopiesByBarcodes returns the set of the given barcodes which already label a copy.
+func (s *ImportService) lookupCopiesByBarcodes(ctx context.Context, barcodes []string) (map[string]bool, common.Error) {
+	labelled := make(map[string]bool, len(barcodes))
+	for start := 0; start < len(barcodes); start += lookupChunkSize {
+		end := start + lookupChunkSize
+		if end > len(barcodes) {
+			end = len(barcodes)
+		}
+		found, err := s.bulkRepo.ListBookCopiesByBarcodes(ctx, barcodes[start:end])
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to look up copies by barcode")
+			return nil, err
+		}
+		for _, c := range found {
+			labelled[c.Barcode] = true
+		}
+	}
+	return labelled, nil
+}
+
+func parseBookRow(row *csvRow) (model.CatalogRecord, error) {
+	v := row.values
+
+	isbn, err := model.NormalizeISBN(v["isbn"])
+	if err != nil {
+		return model.CatalogRecord{}, err
+	}
+	if v["title"] == "" {
+		return model.CatalogRecord{}, fmt.Errorf("title is empty")
+	}
+	year, err := strconv.Atoi(v["published_year"])
+	if err != nil || year < 1 || year > time.Now().Year()+1 {
+		return model.CatalogRecord{}, fmt.Errorf("invalid published_year %q", v["published_year"])
+	}
+
+	book := model.NewBook(0, v["title"], v["author"], isbn, time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC))
+	book.Edition = v["edition"]
+	book.Language = v["language"]
+
+	if v["classification"] != "" || v["call_number"] != "" {
+		cn, err := model.ParseCallNumber(model.ClassificationScheme(strings.ToUpper(v["classification"])), v["call_number"])
+		if err != nil {
+			return model.CatalogRecord{}, err
+		}
+		book.CallNumber = &cn
+	}
+
+	workTitle := v["work_title"]
+	if workTitle == "" {
+		workTitle = book.Title
+	}
+	return model.CatalogRecord{Book: book, WorkTitle: workTitle}, nil
+}
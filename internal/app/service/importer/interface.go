@@ -0,0 +1,23 @@
+package importer
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type BulkRepository interface {
+	ListBooksByISBNs(ctx context.Context, isbns []string) ([]*model.Book, common.Error)
+	ListBookCopiesByBarcodes(ctx context.Context, barcodes []string) ([]*model.BookCopies, common.Error)
+	ListBranches(ctx context.Context) ([]*model.Branch, common.Error)
+	ListUsersByEmails(ctx context.Context, emails []string) ([]*model.User, common.Error)
+	BulkUpsertBooks(ctx context.Context, records []model.CatalogRecord, upsert bool) (map[string]int, common.Error)
+	BulkCreateBookCopies(ctx context.Context, copies []model.BookCopies) common.Error
+	BulkUpsertUsers(ctx context.Context, users []model.User, upsert bool) (map[string]int, common.Error)
+}
+
+type ImportReportRepository interface {
+	CreateImportReport(ctx context.Context, param model.ImportReport) (*model.ImportReport, common.Error)
+	GetImportReportByID(ctx context.Context, id int) (*model.ImportReport, common.Error)
+}
@@ -0,0 +1,32 @@
+package importer
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type ImportService struct {
+	bulkRepo       BulkRepository
+	reportRepo     ImportReportRepository
+	barcodeFormats model.BarcodeFormats
+	eventPublisher webhook.Events
+}
+
+type ImportServiceParam struct {
+	BulkRepo       BulkRepository
+	ReportRepo     ImportReportRepository
+	BarcodeFormats model.BarcodeFormats
+	// EventPublisher tells integrations about the books and copies loaded.
+	EventPublisher webhook.Publisher
+}
+
+func NewImportService(_ context.Context, param ImportServiceParam) *ImportService {
+	return &ImportService{
+		bulkRepo:       param.BulkRepo,
+		reportRepo:     param.ReportRepo,
+		barcodeFormats: param.BarcodeFormats,
+		eventPublisher: webhook.NewEvents(param.EventPublisher),
+	}
+}
@@ -0,0 +1,144 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/paulmach/orb"
+	"github.com/rs/zerolog"
+)
+
+type BranchParam struct {
+	Code    string
+	Name    string
+	Address string
+	// Latitude and Longitude locate the branch; they are given together or not at all.
+	Latitude  *float64
+	Longitude *float64
+}
+
+func (p BranchParam) toModel() (model.Branch, common.Error) {
+	code, err := model.NormalizeBranchCode(p.Code)
+	if err != nil {
+		return model.Branch{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		err := fmt.Errorf("branch name is empty")
+		return model.Branch{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	branch := model.NewBranch(code, name, strings.TrimSpace(p.Address))
+
+	if (p.Latitude == nil) != (p.Longitude == nil) {
+		err := fmt.Errorf("latitude and longitude go together")
+		return model.Branch{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if p.Latitude != nil {
+		location, err := model.NewLocation(*p.Latitude, *p.Longitude)
+		if err != nil {
+			return model.Branch{}, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+		}
+		branch.Location = &location
+	}
+	return branch, nil
+}
+
+func (s *InventoryService) CreateBranch(ctx context.Context, param BranchParam) (*model.Branch, common.Error) {
+	branch, err := param.toModel()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.branchRepo.CreateBranch(ctx, branch)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("code", branch.Code).Msg("failed to create branch")
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *InventoryService) GetBranch(ctx context.Context, id int) (*model.Branch, common.Error) {
+	return s.branchRepo.GetBranchByID(ctx, id)
+}
+
+func (s *InventoryService) ListBranches(ctx context.Context) ([]*model.Branch, common.Error) {
+	return s.branchRepo.ListBranches(ctx)
+}
+
+func (s *InventoryService) UpdateBranch(ctx context.Context, id int, param BranchParam) (*model.Branch, common.Error) {
+	branch, err := param.toModel()
+	if err != nil {
+		return nil, err
+	}
+	branch.ID = id
+
+	updated, err := s.branchRepo.UpdateBranch(ctx, branch)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("branch_id", id).Msg("failed to update branch")
+		return nil, err
+	}
+	return updated, nil
+}
+
+// GetWorkAvailability counts the copies of every edition of a work at each branch.
+func (s *InventoryService) GetWorkAvailability(ctx context.Context, workID int) ([]*model.BranchAvailability, common.Error) {
+	if _, err := s.workRepo.GetWorkByID(ctx, workID); err != nil {
+		return nil, err
+	}
+	books, err := s.bookRepo.ListBooksByWorkIDs(ctx, []int{workID})
+	if err != nil {
+		return nil, err
+	}
+
+	bookIDs := make([]int, 0, len(books))
+	for _, b := range books {
+		bookIDs = append(bookIDs, b.ID)
+	}
+	return s.listAvailability(ctx, bookIDs)
+}
+
+// GetBookAvailability counts the copies of an edition at each branch.
+func (s *InventoryService) GetBookAvailability(ctx context.Context, bookID int) ([]*model.BranchAvailability, common.Error) {
+	if _, err := s.bookRepo.GetBookByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return s.listAvailability(ctx, []int{bookID})
+}
+
+func (s *InventoryService) listAvailability(ctx context.Context, bookIDs []int) ([]*model.BranchAvailability, common.Error) {
+	availability, err := s.branchRepo.ListAvailabilityByBranch(ctx, bookIDs)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Ints("book_ids", bookIDs).Msg("failed to count copies by branch")
+		return nil, err
+	}
+	return availability, nil
+}
+
+// FindNearestBranchesWithCopy lists the branches with a copy of a book on the
+// shelf right now, nearest to a point first. At most limit branches are
+// returned, all of them when limit is zero.
+func (s *InventoryService) FindNearestBranchesWithCopy(ctx context.Context, bookID int, from orb.Point, limit int) ([]*model.BranchDistance, common.Error) {
+	availability, err := s.GetBookAvailability(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[int]int, len(availability))
+	for _, a := range availability {
+		available[a.BranchID] = a.Available()
+	}
+
+	branches, err := s.branchRepo.ListBranches(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list branches")
+		return nil, err
+	}
+
+	nearby := model.SortBranchesByDistance(from, branches, available)
+	if limit > 0 && len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+	return nearby, nil
+}
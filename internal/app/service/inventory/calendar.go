@@ -0,0 +1,133 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/ical"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// icalProdID identifies the library system in exported calendars.
+const icalProdID = "-//page-turner-pro//library calendar//EN"
+
+func (s *InventoryService) ListOpeningHours(ctx context.Context, branchID int) ([]model.OpeningHours, common.Error) {
+	if _, err := s.branchRepo.GetBranchByID(ctx, branchID); err != nil {
+		return nil, err
+	}
+	return s.calendarRepo.ListOpeningHours(ctx, branchID)
+}
+
+// SetOpeningHours replaces the weekly hours of a branch. A weekday left out
+// is a day the branch is closed.
+func (s *InventoryService) SetOpeningHours(ctx context.Context, branchID int, hours []model.OpeningHours) ([]model.OpeningHours, common.Error) {
+	if err := model.ValidateOpeningHours(hours); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if _, err := s.branchRepo.GetBranchByID(ctx, branchID); err != nil {
+		return nil, err
+	}
+
+	set, err := s.calendarRepo.SetOpeningHours(ctx, branchID, hours)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("branch_id", branchID).Msg("failed to set opening hours")
+		return nil, err
+	}
+	return set, nil
+}
+
+type ClosureParam struct {
+	// BranchID is nil to close every branch.
+	BranchID  *int
+	Kind      model.ClosureKind
+	Name      string
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+func (s *InventoryService) CreateClosure(ctx context.Context, param ClosureParam) (*model.Closure, common.Error) {
+	closure := model.NewClosure(param.BranchID, param.Kind, param.Name, param.StartDate, param.EndDate)
+	if err := model.ValidateClosure(closure); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if param.BranchID != nil {
+		if _, err := s.branchRepo.GetBranchByID(ctx, *param.BranchID); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := s.calendarRepo.CreateClosure(ctx, closure)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("name", closure.Name).Msg("failed to create closure")
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListClosures returns the closures overlapping a run of days. With a branch
+// ID, only the closures of that branch and of every branch are listed.
+func (s *InventoryService) ListClosures(ctx context.Context, branchID int, from, to time.Time) ([]*model.Closure, common.Error) {
+	if to.Before(from) {
+		err := fmt.Errorf("range ends before it starts")
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return s.calendarRepo.ListClosures(ctx, branchID, model.CalendarDay(from, time.UTC), model.CalendarDay(to, time.UTC))
+}
+
+func (s *InventoryService) DeleteClosure(ctx context.Context, id int) common.Error {
+	if err := s.calendarRepo.DeleteClosure(ctx, id); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("closure_id", id).Msg("failed to delete closure")
+		return err
+	}
+	return nil
+}
+
+// WriteBranchCalendar writes the opening hours of a branch as weekly events
+// and its closures from a month ago to a year ahead as all-day events, in
+// iCalendar format.
+func (s *InventoryService) WriteBranchCalendar(ctx context.Context, branchID int, w io.Writer) common.Error {
+	branch, err := s.branchRepo.GetBranchByID(ctx, branchID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	today := model.CalendarDay(now, s.location)
+	cal, err := s.calendarRepo.GetBranchCalendar(ctx, branchID, today.AddDate(0, -1, 0), today.AddDate(1, 0, 0))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("branch_id", branchID).Msg("failed to get branch calendar")
+		return err
+	}
+
+	feed := ical.Calendar{ProdID: icalProdID, Name: branch.Name, Stamp: now}
+	// the hours repeat from the days of the current week
+	weekStart := today.AddDate(0, 0, -int(today.Weekday()))
+	for _, h := range cal.Hours {
+		day := weekStart.AddDate(0, 0, int(h.Weekday))
+		feed.Events = append(feed.Events, ical.Event{
+			UID:     fmt.Sprintf("hours-%d-%d@page-turner-pro", branch.ID, h.Weekday),
+			Summary: fmt.Sprintf("%s open", branch.Name),
+			Start:   h.Opens.On(day, s.location),
+			End:     h.Closes.On(day, s.location),
+			Weekly:  true,
+		})
+	}
+	for _, c := range cal.Closures {
+		feed.Events = append(feed.Events, ical.Event{
+			UID:         fmt.Sprintf("closure-%d@page-turner-pro", c.ID),
+			Summary:     fmt.Sprintf("%s closed", branch.Name),
+			Description: c.Name,
+			Start:       c.StartDate,
+			End:         c.EndDate.AddDate(0, 0, 1),
+			AllDay:      true,
+		})
+	}
+
+	if err := feed.Write(w); err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/blobstore"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// PhotoParam is an uploaded photo. Its type is sniffed from its content
+// rather than trusted from the upload.
+type PhotoParam struct {
+	Size    int64
+	Content io.Reader
+}
+
+type RecordConditionParam struct {
+	CopyID  int
+	Note    string
+	Damaged bool
+	Photos  []PhotoParam
+	// ChargeCents is charged to the last borrower of a damaged copy. Optional.
+	ChargeCents int64
+	// RecordedBy is the staff member examining the copy.
+	RecordedBy string
+}
+
+// RecordCondition records the condition of a copy, e.g. at check-in, with
+// photos as evidence. Photos are stored first and removed again when the
+// report cannot be recorded.
+func (s *InventoryService) RecordCondition(ctx context.Context, param RecordConditionParam) (*model.ConditionReport, *model.Charge, common.Error) {
+	if err := requireActor(param.RecordedBy); err != nil {
+		return nil, nil, err
+	}
+	report := model.NewConditionReport(param.CopyID, param.Note, param.Damaged, param.RecordedBy)
+	report.Photos = make([]model.ConditionPhoto, len(param.Photos))
+	if err := model.ValidateConditionReport(report, param.ChargeCents); err != nil {
+		return nil, nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if _, err := s.copyRepo.GetBookCopyByID(ctx, param.CopyID); err != nil {
+		return nil, nil, err
+	}
+
+	for i, photo := range param.Photos {
+		stored, err := s.storePhoto(ctx, param.CopyID, photo)
+		if err != nil {
+			s.deletePhotos(ctx, report.Photos[:i])
+			return nil, nil, err
+		}
+		report.Photos[i] = *stored
+	}
+
+	created, charge, err := s.conditionRepo.CreateConditionReport(ctx, report, param.ChargeCents)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", param.CopyID).Msg("failed to record copy condition")
+		s.deletePhotos(ctx, report.Photos)
+		return nil, nil, err
+	}
+	return created, charge, nil
+}
+
+func (s *InventoryService) storePhoto(ctx context.Context, copyID int, photo PhotoParam) (*model.ConditionPhoto, common.Error) {
+	if photo.Size > model.MaxConditionPhotoBytes {
+		err := fmt.Errorf("photo of %d bytes is over the %d bytes limit", photo.Size, model.MaxConditionPhotoBytes)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	content := bufio.NewReader(photo.Content)
+	head, err := content.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("failed to read photo"))
+	}
+	contentType := http.DetectContentType(head)
+	ext, err := model.ConditionPhotoExtension(contentType)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	key := model.ConditionPhotoKey(copyID, name, ext)
+	if err := s.photoStore.Put(ctx, key, io.LimitReader(content, model.MaxConditionPhotoBytes)); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("key", key).Msg("failed to store condition photo")
+		return nil, common.NewError(common.ErrorCodeRemoteProcess, err)
+	}
+
+	return &model.ConditionPhoto{BlobKey: key, ContentType: contentType, SizeBytes: photo.Size}, nil
+}
+
+// deletePhotos removes stored photos no report refers to. Failures are only
+// logged: an orphan photo is harmless.
+func (s *InventoryService) deletePhotos(ctx context.Context, photos []model.ConditionPhoto) {
+	for _, photo := range photos {
+		if err := s.photoStore.Delete(ctx, photo.BlobKey); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Str("key", photo.BlobKey).Msg("failed to delete condition photo")
+		}
+	}
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ListConditionReports returns the condition history of a copy, oldest first.
+func (s *InventoryService) ListConditionReports(ctx context.Context, copyID int) ([]*model.ConditionReport, common.Error) {
+	if _, err := s.copyRepo.GetBookCopyByID(ctx, copyID); err != nil {
+		return nil, err
+	}
+	return s.conditionRepo.ListConditionReportsByCopyID(ctx, copyID)
+}
+
+// OpenConditionPhoto opens a photo for reading; the caller closes it.
+func (s *InventoryService) OpenConditionPhoto(ctx context.Context, id int) (*model.ConditionPhoto, io.ReadCloser, common.Error) {
+	photo, err := s.conditionRepo.GetConditionPhotoByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, openErr := s.photoStore.Get(ctx, photo.BlobKey)
+	if errors.Is(openErr, blobstore.ErrNotFound) {
+		return nil, nil, common.NewError(common.ErrorCodeResourceNotFound, openErr, common.WithMsg("photo content not found"))
+	}
+	if openErr != nil {
+		zerolog.Ctx(ctx).Error().Err(openErr).Int("photo_id", id).Msg("failed to open condition photo")
+		return nil, nil, common.NewError(common.ErrorCodeRemoteProcess, openErr)
+	}
+	return photo, content, nil
+}
@@ -0,0 +1,85 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type BranchRepository interface {
+	CreateBranch(ctx context.Context, param model.Branch) (*model.Branch, common.Error)
+	GetBranchByID(ctx context.Context, id int) (*model.Branch, common.Error)
+	ListBranches(ctx context.Context) ([]*model.Branch, common.Error)
+	UpdateBranch(ctx context.Context, param model.Branch) (*model.Branch, common.Error)
+	ListAvailabilityByBranch(ctx context.Context, bookIDs []int) ([]*model.BranchAvailability, common.Error)
+}
+
+type TransferRepository interface {
+	CreateTransfer(ctx context.Context, copyID, toBranchID int, requestedBy, reason string) (*model.Transfer, common.Error)
+	GetTransferByID(ctx context.Context, id int) (*model.Transfer, common.Error)
+	ListTransfers(ctx context.Context, branchID int, status model.TransferStatus) ([]*model.Transfer, common.Error)
+	DispatchTransfer(ctx context.Context, id int, changedBy string) (*model.Transfer, common.Error)
+	ReceiveTransfer(ctx context.Context, id int, changedBy string) (*model.Transfer, common.Error)
+	CancelTransfer(ctx context.Context, id int) (*model.Transfer, common.Error)
+}
+
+type BookRepository interface {
+	GetBookByID(ctx context.Context, id int) (*model.Book, common.Error)
+	ListBooksByWorkIDs(ctx context.Context, workIDs []int) ([]*model.Book, common.Error)
+}
+
+type CopyRepository interface {
+	GetBookCopyByID(ctx context.Context, id int) (*model.BookCopies, common.Error)
+}
+
+type WorkRepository interface {
+	GetWorkByID(ctx context.Context, id int) (*model.Work, common.Error)
+}
+
+type HoldRepository interface {
+	GetHoldByID(ctx context.Context, id int) (*model.Hold, common.Error)
+}
+
+// HoldTrapper hands copies back on the shelf to the holds waiting for their work.
+type HoldTrapper interface {
+	TrapAvailableCopies(ctx context.Context, workID int) ([]*model.Hold, common.Error)
+}
+
+type StocktakeRepository interface {
+	CreateStocktake(ctx context.Context, param model.Stocktake) (*model.Stocktake, common.Error)
+	GetStocktakeByID(ctx context.Context, id int) (*model.Stocktake, common.Error)
+	ListStocktakes(ctx context.Context, branchID int) ([]*model.Stocktake, common.Error)
+	AddStocktakeScans(ctx context.Context, id int, barcodes []string, scannedBy string) (int, common.Error)
+	CloseStocktake(ctx context.Context, id int, closedBy string) (*model.Stocktake, common.Error)
+	ListStocktakeMissingItems(ctx context.Context, s model.Stocktake) ([]*model.StocktakeItem, common.Error)
+	ListStocktakeScannedItems(ctx context.Context, id int) ([]*model.StocktakeItem, common.Error)
+	MarkStocktakeMissingLost(ctx context.Context, id int, changedBy string) ([]int, common.Error)
+}
+
+type ConditionRepository interface {
+	CreateConditionReport(ctx context.Context, param model.ConditionReport, chargeCents int64) (*model.ConditionReport, *model.Charge, common.Error)
+	ListConditionReportsByCopyID(ctx context.Context, copyID int) ([]*model.ConditionReport, common.Error)
+	GetConditionPhotoByID(ctx context.Context, id int) (*model.ConditionPhoto, common.Error)
+}
+
+type RepairRepository interface {
+	SendCopyToRepair(ctx context.Context, param model.Repair) (*model.Repair, common.Error)
+	ReturnCopyFromRepair(ctx context.Context, id int, repaired bool, returnedBy string) (*model.Repair, *model.BookCopies, common.Error)
+	GetRepairByID(ctx context.Context, id int) (*model.Repair, common.Error)
+	ListRepairs(ctx context.Context, filter model.RepairFilter) ([]*model.Repair, common.Error)
+}
+
+type VendorRepository interface {
+	GetVendorByID(ctx context.Context, id int) (*model.Vendor, common.Error)
+}
+
+type CalendarRepository interface {
+	ListOpeningHours(ctx context.Context, branchID int) ([]model.OpeningHours, common.Error)
+	SetOpeningHours(ctx context.Context, branchID int, hours []model.OpeningHours) ([]model.OpeningHours, common.Error)
+	CreateClosure(ctx context.Context, param model.Closure) (*model.Closure, common.Error)
+	ListClosures(ctx context.Context, branchID int, from, to time.Time) ([]*model.Closure, common.Error)
+	DeleteClosure(ctx context.Context, id int) common.Error
+	GetBranchCalendar(ctx context.Context, branchID int, from, to time.Time) (*model.BranchCalendar, common.Error)
+}
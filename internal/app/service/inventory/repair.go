@@ -0,0 +1,87 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+type SendToRepairParam struct {
+	CopyID   int
+	VendorID int
+	// ReportID is the condition report that found the damage. Optional.
+	ReportID     *int
+	ExpectedBack time.Time
+	Note         string
+	SentBy       string
+}
+
+// SendToRepair sends a damaged copy to a repair vendor.
+func (s *InventoryService) SendToRepair(ctx context.Context, param SendToRepairParam) (*model.Repair, common.Error) {
+	if err := requireActor(param.SentBy); err != nil {
+		return nil, err
+	}
+	if _, err := s.vendorRepo.GetVendorByID(ctx, param.VendorID); err != nil {
+		return nil, err
+	}
+	if param.ReportID != nil {
+		if err := s.checkConditionReport(ctx, param.CopyID, *param.ReportID); err != nil {
+			return nil, err
+		}
+	}
+
+	repair := model.NewRepair(param.CopyID, param.VendorID, param.ReportID, param.ExpectedBack, param.Note, param.SentBy)
+	created, err := s.repairRepo.SendCopyToRepair(ctx, repair)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", param.CopyID).Msg("failed to send copy to repair")
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *InventoryService) checkConditionReport(ctx context.Context, copyID, reportID int) common.Error {
+	reports, err := s.conditionRepo.ListConditionReportsByCopyID(ctx, copyID)
+	if err != nil {
+		return err
+	}
+	for _, r := range reports {
+		if r.ID == reportID {
+			return nil
+		}
+	}
+	cErr := fmt.Errorf("condition report %d is not about copy %d", reportID, copyID)
+	return common.NewError(common.ErrorCodeParameterInvalid, cErr, common.WithMsg(cErr.Error()))
+}
+
+// ReturnFromRepair records a copy coming back from its vendor. A repaired
+// copy may fill a hold at once.
+func (s *InventoryService) ReturnFromRepair(ctx context.Context, id int, repaired bool, returnedBy string) (*model.Repair, common.Error) {
+	if err := requireActor(returnedBy); err != nil {
+		return nil, err
+	}
+
+	repair, bookCopy, err := s.repairRepo.ReturnCopyFromRepair(ctx, id, repaired, returnedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("repair_id", id).Msg("failed to return copy from repair")
+		return nil, err
+	}
+
+	if bookCopy.Status == model.InLibrary {
+		if err := s.trapCopyWork(ctx, bookCopy.ID); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", bookCopy.ID).Msg("failed to trap holds for repaired copy")
+		}
+	}
+	return repair, nil
+}
+
+func (s *InventoryService) GetRepair(ctx context.Context, id int) (*model.Repair, common.Error) {
+	return s.repairRepo.GetRepairByID(ctx, id)
+}
+
+func (s *InventoryService) ListRepairs(ctx context.Context, filter model.RepairFilter) ([]*model.Repair, common.Error) {
+	return s.repairRepo.ListRepairs(ctx, filter)
+}
@@ -0,0 +1,71 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/blobstore"
+	"github.com/lzzzzl/page-turner-pro/internal/app/service/webhook"
+)
+
+type InventoryService struct {
+	branchRepo    BranchRepository
+	transferRepo  TransferRepository
+	stocktakeRepo StocktakeRepository
+	bookRepo      BookRepository
+	copyRepo      CopyRepository
+	workRepo      WorkRepository
+	conditionRepo ConditionRepository
+	repairRepo    RepairRepository
+	vendorRepo    VendorRepository
+	calendarRepo  CalendarRepository
+	holdRepo      HoldRepository
+	holdTrapper   HoldTrapper
+	photoStore    blobstore.Store
+	location      *time.Location
+
+	eventPublisher webhook.Events
+}
+
+type InventoryServiceParam struct {
+	BranchRepo    BranchRepository
+	TransferRepo  TransferRepository
+	StocktakeRepo StocktakeRepository
+	BookRepo      BookRepository
+	CopyRepo      CopyRepository
+	WorkRepo      WorkRepository
+	ConditionRepo ConditionRepository
+	RepairRepo    RepairRepository
+	VendorRepo    VendorRepository
+	CalendarRepo  CalendarRepository
+	HoldRepo      HoldRepository
+	HoldTrapper   HoldTrapper
+	// PhotoStore keeps the photos of condition reports.
+	PhotoStore blobstore.Store
+	// Location is the time zone of the library, in which opening hours fall.
+	Location *time.Location
+
+	// EventPublisher tells integrations about holds made ready by transfers.
+	EventPublisher webhook.Publisher
+}
+
+func NewInventoryService(_ context.Context, param InventoryServiceParam) *InventoryService {
+	return &InventoryService{
+		branchRepo:    param.BranchRepo,
+		transferRepo:  param.TransferRepo,
+		stocktakeRepo: param.StocktakeRepo,
+		bookRepo:      param.BookRepo,
+		copyRepo:      param.CopyRepo,
+		workRepo:      param.WorkRepo,
+		conditionRepo: param.ConditionRepo,
+		repairRepo:    param.RepairRepo,
+		vendorRepo:    param.VendorRepo,
+		calendarRepo:  param.CalendarRepo,
+		holdRepo:      param.HoldRepo,
+		holdTrapper:   param.HoldTrapper,
+		photoStore:    param.PhotoStore,
+		location:      param.Location,
+
+		eventPublisher: webhook.NewEvents(param.EventPublisher),
+	}
+}
@@ -0,0 +1,139 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// maxScanBatch bounds the barcodes sent in one request by a scanner.
+const maxScanBatch = 1000
+
+type StartStocktakeParam struct {
+	BranchID int
+	// Scheme, From and To narrow the stocktake to a shelf range; all or none are given.
+	Scheme    model.ClassificationScheme
+	From      string
+	To        string
+	StartedBy string
+}
+
+// StartStocktake opens a stocktake of a branch or of a shelf range in it.
+func (s *InventoryService) StartStocktake(ctx context.Context, param StartStocktakeParam) (*model.Stocktake, common.Error) {
+	if err := requireActor(param.StartedBy); err != nil {
+		return nil, err
+	}
+	if _, err := s.branchRepo.GetBranchByID(ctx, param.BranchID); err != nil {
+		return nil, err
+	}
+
+	var shelfRange *model.ShelfRange
+	if param.Scheme != "" || param.From != "" || param.To != "" {
+		rng, err := model.NewShelfRange(param.Scheme, param.From, param.To)
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+		}
+		shelfRange = &rng
+	}
+
+	stocktake, err := s.stocktakeRepo.CreateStocktake(ctx, model.NewStocktake(param.BranchID, shelfRange, param.StartedBy))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("branch_id", param.BranchID).Msg("failed to start stocktake")
+		return nil, err
+	}
+	return stocktake, nil
+}
+
+func (s *InventoryService) GetStocktake(ctx context.Context, id int) (*model.Stocktake, common.Error) {
+	return s.stocktakeRepo.GetStocktakeByID(ctx, id)
+}
+
+// ListStocktakes lists the stocktakes of a branch, or of every branch when branchID is zero.
+func (s *InventoryService) ListStocktakes(ctx context.Context, branchID int) ([]*model.Stocktake, common.Error) {
+	return s.stocktakeRepo.ListStocktakes(ctx, branchID)
+}
+
+// ScanStocktakeBarcodes records a batch of scanned barcodes. Blank lines are
+// skipped and a barcode already scanned is counted once. It returns the
+// number of new barcodes.
+func (s *InventoryService) ScanStocktakeBarcodes(ctx context.Context, id int, barcodes []string, scannedBy string) (int, common.Error) {
+	if err := requireActor(scannedBy); err != nil {
+		return 0, err
+	}
+	if len(barcodes) > maxScanBatch {
+		err := fmt.Errorf("%d barcodes in one batch, at most %d are accepted", len(barcodes), maxScanBatch)
+		return 0, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	seen := make(map[string]bool, len(barcodes))
+	normalized := make([]string, 0, len(barcodes))
+	for _, raw := range barcodes {
+		barcode := model.NormalizeScannedBarcode(raw)
+		if barcode == "" || seen[barcode] {
+			continue
+		}
+		seen[barcode] = true
+		normalized = append(normalized, barcode)
+	}
+
+	added, err := s.stocktakeRepo.AddStocktakeScans(ctx, id, normalized, scannedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("stocktake_id", id).Msg("failed to record stocktake scans")
+		return 0, err
+	}
+	return added, nil
+}
+
+// CloseStocktake ends the scanning; the discrepancies are final from then on.
+func (s *InventoryService) CloseStocktake(ctx context.Context, id int, closedBy string) (*model.Stocktake, common.Error) {
+	if err := requireActor(closedBy); err != nil {
+		return nil, err
+	}
+
+	stocktake, err := s.stocktakeRepo.CloseStocktake(ctx, id, closedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("stocktake_id", id).Msg("failed to close stocktake")
+		return nil, err
+	}
+	return stocktake, nil
+}
+
+// ListStocktakeDiscrepancies reconciles the scanned barcodes with the
+// catalogue. It can run while scanning goes on to follow the progress.
+func (s *InventoryService) ListStocktakeDiscrepancies(ctx context.Context, id int) ([]*model.StocktakeDiscrepancy, common.Error) {
+	stocktake, err := s.stocktakeRepo.GetStocktakeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	missing, err := s.stocktakeRepo.ListStocktakeMissingItems(ctx, *stocktake)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("stocktake_id", id).Msg("failed to list missing copies")
+		return nil, err
+	}
+	scanned, err := s.stocktakeRepo.ListStocktakeScannedItems(ctx, id)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("stocktake_id", id).Msg("failed to list scanned copies")
+		return nil, err
+	}
+
+	return model.ReconcileStocktake(*stocktake, missing, scanned), nil
+}
+
+// MarkStocktakeMissingLost marks Lost the copies a closed stocktake did not
+// find. It returns the IDs of the copies marked.
+func (s *InventoryService) MarkStocktakeMissingLost(ctx context.Context, id int, changedBy string) ([]int, common.Error) {
+	if err := requireActor(changedBy); err != nil {
+		return nil, err
+	}
+
+	copyIDs, err := s.stocktakeRepo.MarkStocktakeMissingLost(ctx, id, changedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("stocktake_id", id).Msg("failed to mark missing copies lost")
+		return nil, err
+	}
+	return copyIDs, nil
+}
@@ -0,0 +1,139 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+type RequestTransferParam struct {
+	CopyID     int
+	ToBranchID int
+	// RequestedBy is the staff member asking for the copy.
+	RequestedBy string
+	Reason      string
+}
+
+// RequestTransfer asks for an on-shelf copy to be sent to another branch.
+func (s *InventoryService) RequestTransfer(ctx context.Context, param RequestTransferParam) (*model.Transfer, common.Error) {
+	if err := requireActor(param.RequestedBy); err != nil {
+		return nil, err
+	}
+	if _, err := s.branchRepo.GetBranchByID(ctx, param.ToBranchID); err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.transferRepo.CreateTransfer(ctx, param.CopyID, param.ToBranchID, param.RequestedBy, strings.TrimSpace(param.Reason))
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("copy_id", param.CopyID).Msg("failed to request transfer")
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (s *InventoryService) GetTransfer(ctx context.Context, id int) (*model.Transfer, common.Error) {
+	return s.transferRepo.GetTransferByID(ctx, id)
+}
+
+// ListTransfers lists the transfers leaving or reaching a branch. A zero
+// branch ID or an empty status matches any.
+func (s *InventoryService) ListTransfers(ctx context.Context, branchID int, status model.TransferStatus) ([]*model.Transfer, common.Error) {
+	if status != "" && !status.IsValid() {
+		err := fmt.Errorf("unknown transfer status %q", status)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return s.transferRepo.ListTransfers(ctx, branchID, status)
+}
+
+// DispatchTransfer records the copy leaving its branch.
+func (s *InventoryService) DispatchTransfer(ctx context.Context, id int, changedBy string) (*model.Transfer, common.Error) {
+	if err := requireActor(changedBy); err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.transferRepo.DispatchTransfer(ctx, id, changedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("transfer_id", id).Msg("failed to dispatch transfer")
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// ReceiveTransfer records the copy arriving at its destination. A copy which
+// did not arrive for a waiting hold is shelved and offered to the queue of its
+// work.
+func (s *InventoryService) ReceiveTransfer(ctx context.Context, id int, changedBy string) (*model.Transfer, common.Error) {
+	if err := requireActor(changedBy); err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.transferRepo.ReceiveTransfer(ctx, id, changedBy)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("transfer_id", id).Msg("failed to receive transfer")
+		return nil, err
+	}
+	if transfer.HoldID != nil {
+		s.publishHoldReady(ctx, *transfer)
+	}
+
+	if err := s.trapCopyWork(ctx, transfer.CopyID); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// CancelTransfer drops a transfer which has not been dispatched. A hold the
+// copy was trapped for goes back to the queue.
+func (s *InventoryService) CancelTransfer(ctx context.Context, id int) (*model.Transfer, common.Error) {
+	transfer, err := s.transferRepo.CancelTransfer(ctx, id)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("transfer_id", id).Msg("failed to cancel transfer")
+		return nil, err
+	}
+
+	if transfer.HoldID != nil {
+		if err := s.trapCopyWork(ctx, transfer.CopyID); err != nil {
+			return nil, err
+		}
+	}
+	return transfer, nil
+}
+
+// publishHoldReady tells integrations the hold a copy travelled for is ready,
+// unless it was cancelled on the way.
+func (s *InventoryService) publishHoldReady(ctx context.Context, transfer model.Transfer) {
+	hold, err := s.holdRepo.GetHoldByID(ctx, *transfer.HoldID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("hold_id", *transfer.HoldID).Msg("failed to get hold of received transfer")
+		return
+	}
+	if hold.Status == model.HoldReady && hold.CopyID != nil && *hold.CopyID == transfer.CopyID {
+		s.eventPublisher.Publish(ctx, model.HoldReadyEvent(*hold))
+	}
+}
+
+func (s *InventoryService) trapCopyWork(ctx context.Context, copyID int) common.Error {
+	bookCopy, err := s.copyRepo.GetBookCopyByID(ctx, copyID)
+	if err != nil {
+		return err
+	}
+	book, err := s.bookRepo.GetBookByID(ctx, bookCopy.BookID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.holdTrapper.TrapAvailableCopies(ctx, book.WorkID)
+	return err
+}
+
+func requireActor(changedBy string) common.Error {
+	if strings.TrimSpace(changedBy) == "" {
+		err := fmt.Errorf("changed_by is empty")
+		return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the staff member making the change is required"))
+	}
+	return nil
+}
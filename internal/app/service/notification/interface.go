@@ -0,0 +1,29 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type NotificationRepository interface {
+	ListDueSoonNotices(ctx context.Context, dueAfter, dueUntil time.Time) ([]*model.Notice, common.Error)
+	ListOverdueNotices(ctx context.Context, dueBefore time.Time) ([]*model.Notice, common.Error)
+	ListHoldReadyNotices(ctx context.Context) ([]*model.Notice, common.Error)
+	ClaimNotification(ctx context.Context, param model.SentNotification) (*model.SentNotification, common.Error)
+	ReleaseNotification(ctx context.Context, id int) common.Error
+	ListSentNotificationsByUserID(ctx context.Context, userID int) ([]*model.SentNotification, common.Error)
+}
+
+type PreferenceRepository interface {
+	GetNotificationPreferences(ctx context.Context, userID int) (*model.NotificationPreferences, common.Error)
+	ListNotificationPreferences(ctx context.Context, userIDs []int) ([]*model.NotificationPreferences, common.Error)
+	SetNotificationPreferences(ctx context.Context, param model.NotificationPreferences) (*model.NotificationPreferences, common.Error)
+	MarkDigestSent(ctx context.Context, userID int, sentAt time.Time) common.Error
+}
+
+type UserRepository interface {
+	GetUserByID(ctx context.Context, id int) (*model.User, common.Error)
+}
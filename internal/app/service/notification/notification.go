@@ -0,0 +1,261 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/notifier"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// SendNotifications sends the due soon, overdue and hold ready notices owed
+// to patrons, as their preferences say: only the types they want, on their
+// channel, at once or in a daily digest, and never during their quiet hours.
+// Notices held back are sent by a later run. Notices already sent are
+// skipped, so the job may rerun at will. A patron failing does not stop the
+// others; the number of notices sent is returned.
+func (s *NotificationService) SendNotifications(ctx context.Context) (int, common.Error) {
+	now := time.Now()
+
+	notices, err := s.listNotices(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var userIDs []int
+	byUser := map[int][]model.Notice{}
+	for _, notice := range notices {
+		if _, ok := byUser[notice.UserID]; !ok {
+			userIDs = append(userIDs, notice.UserID)
+		}
+		byUser[notice.UserID] = append(byUser[notice.UserID], *notice)
+	}
+	prefs, err := s.preferencesOf(ctx, userIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		p := prefs[userID]
+		if p.InQuietHours(now) {
+			continue
+		}
+		var wanted []model.Notice
+		for _, notice := range byUser[userID] {
+			if p.Wants(notice.Type) {
+				wanted = append(wanted, notice)
+			}
+		}
+		if len(wanted) == 0 {
+			continue
+		}
+
+		if p.Delivery == model.DeliveryDigest {
+			if !p.DigestDue(now) {
+				continue
+			}
+			n, err := s.sendDigest(ctx, p, wanted, now)
+			if err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to send notification digest")
+			}
+			sent += n
+			continue
+		}
+
+		for _, notice := range wanted {
+			ok, err := s.send(ctx, p, notice)
+			if err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).
+					Int("user_id", notice.UserID).
+					Str("type", string(notice.Type)).
+					Int("reference_id", notice.ReferenceID).
+					Msg("failed to send notification")
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+// listNotices returns the notices owed to patrons at a time.
+func (s *NotificationService) listNotices(ctx context.Context, now time.Time) ([]*model.Notice, common.Error) {
+	var notices []*model.Notice
+	if s.dueSoonDays > 0 {
+		dueSoon, err := s.notificationRepo.ListDueSoonNotices(ctx, now, now.AddDate(0, 0, s.dueSoonDays))
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list due soon notices")
+			return nil, err
+		}
+		notices = append(notices, dueSoon...)
+	}
+	overdue, err := s.notificationRepo.ListOverdueNotices(ctx, now)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list overdue notices")
+		return nil, err
+	}
+	notices = append(notices, overdue...)
+	holdReady, err := s.notificationRepo.ListHoldReadyNotices(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list hold ready notices")
+		return nil, err
+	}
+	return append(notices, holdReady...), nil
+}
+
+// preferencesOf returns the preferences of patrons, the defaults for those
+// who set none.
+func (s *NotificationService) preferencesOf(ctx context.Context, userIDs []int) (map[int]model.NotificationPreferences, common.Error) {
+	set, err := s.preferenceRepo.ListNotificationPreferences(ctx, userIDs)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list notification preferences")
+		return nil, err
+	}
+
+	prefs := make(map[int]model.NotificationPreferences, len(userIDs))
+	for _, userID := range userIDs {
+		prefs[userID] = s.defaultPreferences(userID)
+	}
+	for _, p := range set {
+		prefs[p.UserID] = *p
+	}
+	return prefs, nil
+}
+
+func (s *NotificationService) defaultPreferences(userID int) model.NotificationPreferences {
+	return model.DefaultNotificationPreferences(userID, s.channel, s.location.String())
+}
+
+// inZone tells the dates of a notice in the time zone of its patron.
+func inZone(notice model.Notice, loc *time.Location) model.Notice {
+	notice.DueDate = notice.DueDate.In(loc)
+	notice.ReadyAt = notice.ReadyAt.In(loc)
+	return notice
+}
+
+func (s *NotificationService) channelOf(p model.NotificationPreferences) (notifier.Channel, error) {
+	channel, ok := s.channels[p.Channel]
+	if !ok {
+		return nil, fmt.Errorf("no %s notification channel", p.Channel)
+	}
+	return channel, nil
+}
+
+// claim records a notice about to be sent with its message, returning nil if
+// it was sent before.
+func (s *NotificationService) claim(ctx context.Context, p model.NotificationPreferences, notice model.Notice, msg notifier.Message, locale string, digest bool) (*model.SentNotification, common.Error) {
+	return s.notificationRepo.ClaimNotification(ctx, model.SentNotification{
+		UserID:      notice.UserID,
+		Type:        notice.Type,
+		Channel:     p.Channel,
+		ReferenceID: notice.ReferenceID,
+		DedupKey:    notice.DedupKey(),
+		Digest:      digest,
+		Locale:      locale,
+		Subject:     msg.Subject,
+		Body:        msg.Body,
+	})
+}
+
+// release forgets claimed notices that could not be sent, so that a later
+// run tries again.
+func (s *NotificationService) release(ctx context.Context, claimed []*model.SentNotification) {
+	for _, c := range claimed {
+		if err := s.notificationRepo.ReleaseNotification(ctx, c.ID); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Int("notification_id", c.ID).Msg("failed to release notification")
+		}
+	}
+}
+
+// send renders a notice and delivers it, unless it was sent before. It
+// reports whether the notice was sent now.
+func (s *NotificationService) send(ctx context.Context, p model.NotificationPreferences, notice model.Notice) (bool, error) {
+	channel, err := s.channelOf(p)
+	if err != nil {
+		return false, err
+	}
+
+	notice = inZone(notice, p.Location())
+	msg, locale, err := s.templates.Render(s.locale, notice)
+	if err != nil {
+		return false, err
+	}
+
+	claimed, cErr := s.claim(ctx, p, notice, msg, locale, false)
+	if cErr != nil {
+		return false, cErr
+	}
+	if claimed == nil {
+		return false, nil
+	}
+
+	if err := channel.Deliver(ctx, msg); err != nil {
+		s.release(ctx, []*model.SentNotification{claimed})
+		return false, err
+	}
+	return true, nil
+}
+
+// sendDigest gathers the notices of a patron not sent before into one
+// message and delivers it. It returns how many notices it sent.
+func (s *NotificationService) sendDigest(ctx context.Context, p model.NotificationPreferences, notices []model.Notice, now time.Time) (int, error) {
+	channel, err := s.channelOf(p)
+	if err != nil {
+		return 0, err
+	}
+
+	var claimed []*model.SentNotification
+	var included []model.Notice
+	for _, notice := range notices {
+		notice = inZone(notice, p.Location())
+		msg, locale, err := s.templates.Render(s.locale, notice)
+		if err != nil {
+			s.release(ctx, claimed)
+			return 0, err
+		}
+		c, cErr := s.claim(ctx, p, notice, msg, locale, true)
+		if cErr != nil {
+			s.release(ctx, claimed)
+			return 0, cErr
+		}
+		if c == nil {
+			continue
+		}
+		claimed = append(claimed, c)
+		included = append(included, notice)
+	}
+	if len(included) == 0 {
+		return 0, nil
+	}
+
+	msg, _, err := s.templates.RenderDigest(s.locale, included[0].Email, included[0].Name, included)
+	if err != nil {
+		s.release(ctx, claimed)
+		return 0, err
+	}
+	if err := channel.Deliver(ctx, msg); err != nil {
+		s.release(ctx, claimed)
+		return 0, err
+	}
+	if err := s.preferenceRepo.MarkDigestSent(ctx, p.UserID, now); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", p.UserID).Msg("failed to mark digest sent")
+	}
+	return len(included), nil
+}
+
+// ListSentNotifications returns the notifications sent to a patron, the
+// latest first.
+func (s *NotificationService) ListSentNotifications(ctx context.Context, userID int) ([]*model.SentNotification, common.Error) {
+	sent, err := s.notificationRepo.ListSentNotificationsByUserID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to list sent notifications")
+		return nil, err
+	}
+	return sent, nil
+}
@@ -0,0 +1,269 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/notifier"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepository owes the notices it is given, claims dedup keys the way
+// the database does and keeps the preferences set.
+type memoryRepository struct {
+	dueSoon, overdue, holdReady []*model.Notice
+	sent                        []*model.SentNotification
+	prefs                       map[int]*model.NotificationPreferences
+	users                       map[int]*model.User
+}
+
+func (r *memoryRepository) ListDueSoonNotices(context.Context, time.Time, time.Time) ([]*model.Notice, common.Error) {
+	return r.dueSoon, nil
+}
+
+func (r *memoryRepository) ListOverdueNotices(context.Context, time.Time) ([]*model.Notice, common.Error) {
+	return r.overdue, nil
+}
+
+func (r *memoryRepository) ListHoldReadyNotices(context.Context) ([]*model.Notice, common.Error) {
+	return r.holdReady, nil
+}
+
+func (r *memoryRepository) ClaimNotification(_ context.Context, param model.SentNotification) (*model.SentNotification, common.Error) {
+	for _, n := range r.sent {
+		if n.DedupKey == param.DedupKey {
+			return nil, nil
+		}
+	}
+	param.ID = len(r.sent) + 1
+	r.sent = append(r.sent, &param)
+	return &param, nil
+}
+
+func (r *memoryRepository) ReleaseNotification(_ context.Context, id int) common.Error {
+	for i, n := range r.sent {
+		if n.ID == id {
+			r.sent = append(r.sent[:i], r.sent[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) ListSentNotificationsByUserID(_ context.Context, userID int) ([]*model.SentNotification, common.Error) {
+	var sent []*model.SentNotification
+	for _, n := range r.sent {
+		if n.UserID == userID {
+			sent = append(sent, n)
+		}
+	}
+	return sent, nil
+}
+
+func (r *memoryRepository) GetNotificationPreferences(_ context.Context, userID int) (*model.NotificationPreferences, common.Error) {
+	p, ok := r.prefs[userID]
+	if !ok {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, errors.New("no preferences"))
+	}
+	return p, nil
+}
+
+func (r *memoryRepository) ListNotificationPreferences(_ context.Context, userIDs []int) ([]*model.NotificationPreferences, common.Error) {
+	var prefs []*model.NotificationPreferences
+	for _, id := range userIDs {
+		if p, ok := r.prefs[id]; ok {
+			prefs = append(prefs, p)
+		}
+	}
+	return prefs, nil
+}
+
+func (r *memoryRepository) SetNotificationPreferences(_ context.Context, param model.NotificationPreferences) (*model.NotificationPreferences, common.Error) {
+	if r.prefs == nil {
+		r.prefs = map[int]*model.NotificationPreferences{}
+	}
+	if p, ok := r.prefs[param.UserID]; ok {
+		param.LastDigestAt = p.LastDigestAt
+	}
+	r.prefs[param.UserID] = &param
+	return &param, nil
+}
+
+func (r *memoryRepository) MarkDigestSent(_ context.Context, userID int, sentAt time.Time) common.Error {
+	if p, ok := r.prefs[userID]; ok {
+		p.LastDigestAt = &sentAt
+	}
+	return nil
+}
+
+func (r *memoryRepository) GetUserByID(_ context.Context, id int) (*model.User, common.Error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, errors.New("no user"))
+	}
+	return u, nil
+}
+
+// recordingChannel keeps the messages delivered, failing while down.
+type recordingChannel struct {
+	down     bool
+	messages []notifier.Message
+}
+
+func (c *recordingChannel) Deliver(_ context.Context, msg notifier.Message) error {
+	if c.down {
+		return errors.New("channel down")
+	}
+	c.messages = append(c.messages, msg)
+	return nil
+}
+
+func TestNotificationService_SendNotifications(t *testing.T) {
+	templates, err := notifier.LoadTemplates()
+	require.NoError(t, err)
+	// Brisbane is UTC+10 all year
+	loc, err := time.LoadLocation("Australia/Brisbane")
+	require.NoError(t, err)
+	due := time.Date(2023, 5, 21, 23, 30, 0, 0, time.UTC)
+
+	repo := &memoryRepository{
+		dueSoon: []*model.Notice{{Type: model.NotificationDueSoon, UserID: 2, Email: "user2@pageturnerpro.com", Name: "user2", ReferenceID: 2, Title: "Don Quixote", DueDate: due}},
+		holdReady: []*model.Notice{{Type: model.NotificationHoldReady, UserID: 1, Email: "user1@pageturnerpro.com", Name: "user1", ReferenceID: 1,
+			Title: "The Little Prince", BranchName: "Main Library", ReadyAt: due}},
+	}
+	channel := &recordingChannel{down: true}
+	s := NewNotificationService(context.Background(), NotificationServiceParam{
+		NotificationRepo: repo,
+		PreferenceRepo:   repo,
+		UserRepo:         repo,
+		Templates:        templates,
+		Channels:         map[model.NotificationChannel]notifier.Channel{model.ChannelLog: channel},
+		Channel:          model.ChannelLog,
+		Locale:           "en",
+		Location:         loc,
+		DueSoonDays:      3,
+	})
+
+	// nothing is recorded as sent while the channel is down
+	sent, cErr := s.SendNotifications(context.Background())
+	require.NoError(t, cErr)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, repo.sent)
+
+	channel.down = false
+	sent, cErr = s.SendNotifications(context.Background())
+	require.NoError(t, cErr)
+	assert.Equal(t, 2, sent)
+	require.Len(t, channel.messages, 2)
+	assert.Equal(t, "user2@pageturnerpro.com", channel.messages[0].To)
+	// due dates are told in the time of the library, where it is Monday
+	assert.Contains(t, channel.messages[0].Body, "Monday 22 May 2023")
+
+	history, cErr := s.ListSentNotifications(context.Background(), 2)
+	require.NoError(t, cErr)
+	require.Len(t, history, 1)
+	assert.Equal(t, model.NotificationDueSoon, history[0].Type)
+	assert.Equal(t, model.ChannelLog, history[0].Channel)
+	assert.Equal(t, "en", history[0].Locale)
+
+	// rerunning the job sends nothing twice
+	sent, cErr = s.SendNotifications(context.Background())
+	require.NoError(t, cErr)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, channel.messages, 2)
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	templates, err := notifier.LoadTemplates()
+	require.NoError(t, err)
+	due := time.Now().Add(24 * time.Hour)
+	notices := func(userID int) []*model.Notice {
+		return []*model.Notice{
+			{Type: model.NotificationOverdue, UserID: userID, Email: "user@pageturnerpro.com", Name: "user", ReferenceID: userID*10 + 1, Title: "Don Quixote", DueDate: due.AddDate(0, 0, -7)},
+			{Type: model.NotificationOverdue, UserID: userID, Email: "user@pageturnerpro.com", Name: "user", ReferenceID: userID*10 + 2, Title: "Hamlet", DueDate: due.AddDate(0, 0, -7)},
+		}
+	}
+
+	repo := &memoryRepository{
+		users: map[int]*model.User{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}},
+	}
+	repo.overdue = append(append(notices(1), notices(2)...), notices(3)...)
+	repo.holdReady = []*model.Notice{{Type: model.NotificationHoldReady, UserID: 1, Email: "user@pageturnerpro.com", ReferenceID: 1, Title: "The Little Prince", ReadyAt: due}}
+
+	email := &recordingChannel{}
+	log := &recordingChannel{}
+	s := NewNotificationService(context.Background(), NotificationServiceParam{
+		NotificationRepo: repo,
+		PreferenceRepo:   repo,
+		UserRepo:         repo,
+		Templates:        templates,
+		Channels:         map[model.NotificationChannel]notifier.Channel{model.ChannelEmail: email, model.ChannelLog: log},
+		Channel:          model.ChannelEmail,
+		Locale:           "en",
+		Location:         time.UTC,
+		DueSoonDays:      3,
+	})
+	ctx := context.Background()
+
+	defaults, cErr := s.GetPreferences(ctx, 1)
+	require.NoError(t, cErr)
+	assert.Equal(t, model.NotificationTypes, defaults.Types)
+	assert.Equal(t, model.ChannelEmail, defaults.Channel)
+	assert.Equal(t, "UTC", defaults.TimeZone)
+
+	// user 1 wants overdue notices only, in a digest on the log
+	p := model.DefaultNotificationPreferences(1, model.ChannelLog, "")
+	p.Types = []model.NotificationType{model.NotificationOverdue}
+	p.Delivery = model.DeliveryDigest
+	_, cErr = s.SetPreferences(ctx, p)
+	require.NoError(t, cErr)
+	// user 2 is in quiet hours from an hour ago to an hour from now
+	now := time.Now().UTC()
+	minute := now.Hour()*60 + now.Minute()
+	p = model.DefaultNotificationPreferences(2, model.ChannelEmail, "UTC")
+	p.QuietStart = model.TimeOfDay((minute + 23*60) % (24 * 60))
+	p.QuietEnd = model.TimeOfDay((minute + 60) % (24 * 60))
+	_, cErr = s.SetPreferences(ctx, p)
+	require.NoError(t, cErr)
+
+	p.TimeZone = "Mars/Olympus_Mons"
+	_, cErr = s.SetPreferences(ctx, p)
+	assert.True(t, isParameterInvalid(cErr))
+
+	sent, cErr := s.SendNotifications(ctx)
+	require.NoError(t, cErr)
+	// two notices in the digest of user 1, two notices on their own for user 3
+	assert.Equal(t, 4, sent)
+	require.Len(t, log.messages, 1)
+	assert.Equal(t, "Your library update: 2 notices", log.messages[0].Subject)
+	assert.NotContains(t, log.messages[0].Body, "The Little Prince")
+	require.Len(t, email.messages, 2)
+
+	history, cErr := s.ListSentNotifications(ctx, 1)
+	require.NoError(t, cErr)
+	require.Len(t, history, 2)
+	assert.True(t, history[0].Digest)
+	require.NotNil(t, repo.prefs[1].LastDigestAt)
+
+	// a new notice waits for the next digest
+	repo.overdue = append(repo.overdue, &model.Notice{Type: model.NotificationOverdue, UserID: 1, Email: "user@pageturnerpro.com", ReferenceID: 13, Title: "Ulysses", DueDate: due.AddDate(0, 0, -7)})
+	sent, cErr = s.SendNotifications(ctx)
+	require.NoError(t, cErr)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, log.messages, 1)
+
+	// an erased patron has no preferences to tell
+	erasedAt := time.Now()
+	repo.users[4] = &model.User{ID: 4, ErasedAt: &erasedAt}
+	_, cErr = s.GetPreferences(ctx, 4)
+	assert.True(t, isResourceNotFound(cErr))
+}
+
+func isParameterInvalid(err common.Error) bool {
+	de, ok := err.(common.DomainError)
+	return ok && de.Name() == common.ErrorCodeParameterInvalid.Name
+}
@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// GetPreferences returns how a patron wants to be notified, the defaults if
+// they never said.
+func (s *NotificationService) GetPreferences(ctx context.Context, userID int) (*model.NotificationPreferences, common.Error) {
+	prefs, err := s.preferenceRepo.GetNotificationPreferences(ctx, userID)
+	if err == nil {
+		return prefs, nil
+	}
+	if !isResourceNotFound(err) {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to get notification preferences")
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to get user")
+		return nil, err
+	}
+	if user.IsErased() {
+		err := fmt.Errorf("patron %d is erased", userID)
+		return nil, common.NewError(common.ErrorCodeResourceNotFound, err, common.WithMsg("user not found"))
+	}
+	defaults := s.defaultPreferences(userID)
+	return &defaults, nil
+}
+
+// SetPreferences saves how a patron wants to be notified.
+func (s *NotificationService) SetPreferences(ctx context.Context, param model.NotificationPreferences) (*model.NotificationPreferences, common.Error) {
+	if param.TimeZone == "" {
+		param.TimeZone = s.location.String()
+	}
+	if err := model.ValidateNotificationPreferences(param); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	prefs, err := s.preferenceRepo.SetNotificationPreferences(ctx, param)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", param.UserID).Msg("failed to set notification preferences")
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func isResourceNotFound(err common.Error) bool {
+	de, ok := err.(common.DomainError)
+	return ok && de.Name() == common.ErrorCodeResourceNotFound.Name
+}
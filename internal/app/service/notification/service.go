@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/notifier"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type NotificationService struct {
+	notificationRepo NotificationRepository
+	preferenceRepo   PreferenceRepository
+	userRepo         UserRepository
+
+	templates   *notifier.Templates
+	channels    map[model.NotificationChannel]notifier.Channel
+	channel     model.NotificationChannel
+	locale      string
+	location    *time.Location
+	dueSoonDays int
+}
+
+type NotificationServiceParam struct {
+	NotificationRepo NotificationRepository
+	PreferenceRepo   PreferenceRepository
+	UserRepo         UserRepository
+
+	Templates *notifier.Templates
+	// Channels are the channels notifications may go out on, and Channel the
+	// one they do for patrons who did not pick one.
+	Channels map[model.NotificationChannel]notifier.Channel
+	Channel  model.NotificationChannel
+	// Locale is the locale notifications are written in.
+	Locale string
+	// Location is the time zone of the library, which patrons are in unless
+	// they say otherwise.
+	Location *time.Location
+	// DueSoonDays is how long before its due date a loan is reminded of.
+	DueSoonDays int
+}
+
+func NewNotificationService(_ context.Context, param NotificationServiceParam) *NotificationService {
+	return &NotificationService{
+		notificationRepo: param.NotificationRepo,
+		preferenceRepo:   param.PreferenceRepo,
+		userRepo:         param.UserRepo,
+
+		templates:   param.Templates,
+		channels:    param.Channels,
+		channel:     param.Channel,
+		locale:      param.Locale,
+		location:    param.Location,
+		dueSoonDays: param.DueSoonDays,
+	}
+}
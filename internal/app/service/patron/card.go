@@ -0,0 +1,113 @@
+package patron
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+type IssueCardParam struct {
+	UserID int
+	// Barcode is the barcode of a preprinted card; a barcode is generated
+	// when it is empty.
+	Barcode  string
+	IssuedBy string
+	// ReplaceReason tells why the card the patron holds is replaced, e.g.
+	// lost or damaged.
+	ReplaceReason string
+}
+
+// IssueCard gives a patron a library card, revoking the one they hold.
+func (s *PatronService) IssueCard(ctx context.Context, param IssueCardParam) (*model.LibraryCard, common.Error) {
+	if err := requireActor(param.IssuedBy); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, param.UserID); err != nil {
+		return nil, err
+	}
+
+	var barcode string
+	var err common.Error
+	if strings.TrimSpace(param.Barcode) == "" {
+		barcode, err = s.generateBarcode(ctx)
+	} else {
+		barcode, err = s.validateBarcode(param.Barcode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	card, err := s.cardRepo.IssueLibraryCard(ctx, model.NewLibraryCard(param.UserID, barcode, param.IssuedBy), param.ReplaceReason)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", param.UserID).Msg("failed to issue library card")
+		return nil, err
+	}
+	return card, nil
+}
+
+// RevokeCard revokes a card, e.g. one reported lost, so that it no longer
+// identifies its patron.
+func (s *PatronService) RevokeCard(ctx context.Context, id int, revokedBy, reason string) (*model.LibraryCard, common.Error) {
+	if err := requireActor(revokedBy); err != nil {
+		return nil, err
+	}
+
+	card, err := s.cardRepo.RevokeLibraryCard(ctx, id, revokedBy, reason)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("card_id", id).Msg("failed to revoke library card")
+		return nil, err
+	}
+	return card, nil
+}
+
+// ListCards returns the cards issued to a patron, revoked ones included.
+func (s *PatronService) ListCards(ctx context.Context, userID int) ([]*model.LibraryCard, common.Error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.cardRepo.ListLibraryCardsByUserID(ctx, userID)
+}
+
+// LookupCard finds the patron a scanned card belongs to. A revoked card is
+// returned as well, for the desk to see it no longer identifies them.
+func (s *PatronService) LookupCard(ctx context.Context, rawBarcode string) (*model.LibraryCard, *model.User, common.Error) {
+	barcode, err := s.validateBarcode(rawBarcode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	card, err := s.cardRepo.GetLibraryCardByBarcode(ctx, barcode)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.userRepo.GetUserByID(ctx, card.UserID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("card_id", card.ID).Msg("failed to get patron of card")
+		return nil, nil, err
+	}
+	return card, user, nil
+}
+
+func (s *PatronService) generateBarcode(ctx context.Context) (string, common.Error) {
+	seq, err := s.cardRepo.NextCardBarcodeNumber(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to number card barcode")
+		return "", err
+	}
+	barcode, genErr := s.barcodeFormats.Generate(seq)
+	if genErr != nil {
+		return "", common.NewError(common.ErrorCodeInternalProcess, genErr)
+	}
+	return barcode, nil
+}
+
+func (s *PatronService) validateBarcode(raw string) (string, common.Error) {
+	barcode, err := s.barcodeFormats.Validate(raw)
+	if err != nil {
+		return "", common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	return barcode, nil
+}
@@ -0,0 +1,113 @@
+package patron
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+func (s *PatronService) CreateHousehold(ctx context.Context, name string) (*model.Household, common.Error) {
+	household := model.NewHousehold(name)
+	if err := model.ValidateHousehold(household); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	created, err := s.householdRepo.CreateHousehold(ctx, household)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to create household")
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetHousehold returns a household with its members, guardians first.
+func (s *PatronService) GetHousehold(ctx context.Context, id int) (*model.Household, []*model.HouseholdMember, common.Error) {
+	household, err := s.householdRepo.GetHouseholdByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := s.householdRepo.ListHouseholdMembers(ctx, id)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("household_id", id).Msg("failed to list household members")
+		return nil, nil, err
+	}
+	return household, members, nil
+}
+
+type HouseholdMemberParam struct {
+	HouseholdID int
+	UserID      int
+	Role        model.HouseholdRole
+	// Permissions are those of a guardian; they are ignored for a dependent.
+	Permissions model.GuardianPermissions
+}
+
+// AddHouseholdMember adds a user to a household as a guardian or a dependent.
+func (s *PatronService) AddHouseholdMember(ctx context.Context, param HouseholdMemberParam) (*model.HouseholdMember, common.Error) {
+	member := model.NewHouseholdMember(param.HouseholdID, param.UserID, param.Role, param.Permissions)
+	if err := model.ValidateHouseholdMember(member); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, param.UserID); err != nil {
+		return nil, err
+	}
+
+	added, err := s.householdRepo.AddHouseholdMember(ctx, member)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("household_id", param.HouseholdID).Int("user_id", param.UserID).Msg("failed to add household member")
+		return nil, err
+	}
+	return added, nil
+}
+
+// UpdateHouseholdMember changes the role and permissions of a member.
+func (s *PatronService) UpdateHouseholdMember(ctx context.Context, param HouseholdMemberParam) (*model.HouseholdMember, common.Error) {
+	member := model.NewHouseholdMember(param.HouseholdID, param.UserID, param.Role, param.Permissions)
+	if err := model.ValidateHouseholdMember(member); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	updated, err := s.householdRepo.UpdateHouseholdMember(ctx, member)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("household_id", param.HouseholdID).Int("user_id", param.UserID).Msg("failed to update household member")
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *PatronService) RemoveHouseholdMember(ctx context.Context, householdID, userID int) common.Error {
+	if err := s.householdRepo.RemoveHouseholdMember(ctx, householdID, userID); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("household_id", householdID).Int("user_id", userID).Msg("failed to remove household member")
+		return err
+	}
+	return nil
+}
+
+// SetContentRestrictions lets a guardian replace the subjects a dependent
+// may not borrow or place holds on.
+func (s *PatronService) SetContentRestrictions(ctx context.Context, guardianID, userID int, subjectIDs []int) ([]*model.ContentRestriction, common.Error) {
+	members, err := s.householdRepo.ListHouseholdMembersByUserID(ctx, guardianID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", guardianID).Msg("failed to list household members")
+		return nil, err
+	}
+	if authErr := model.AuthorizeGuardian(members, guardianID, userID, model.GuardianSetRestrictions); authErr != nil {
+		return nil, common.NewError(common.ErrorCodeAuthPermissionDenied, authErr, common.WithMsg(authErr.Error()))
+	}
+
+	restrictions, err := s.householdRepo.SetContentRestrictions(ctx, userID, subjectIDs, guardianID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to set content restrictions")
+		return nil, err
+	}
+	return restrictions, nil
+}
+
+func (s *PatronService) ListContentRestrictions(ctx context.Context, userID int) ([]*model.ContentRestriction, common.Error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.householdRepo.ListContentRestrictions(ctx, userID)
+}
@@ -0,0 +1,60 @@
+package patron
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type UserRepository interface {
+	GetUserByID(ctx context.Context, id int) (*model.User, common.Error)
+}
+
+type MembershipRepository interface {
+	UpdateMembership(ctx context.Context, userID int, m model.Membership) (*model.User, common.Error)
+	RenewMembership(ctx context.Context, userID int, terms model.MembershipTerms, today time.Time) (*model.User, common.Error)
+}
+
+type CardRepository interface {
+	NextCardBarcodeNumber(ctx context.Context) (int64, common.Error)
+	IssueLibraryCard(ctx context.Context, param model.LibraryCard, replaceReason string) (*model.LibraryCard, common.Error)
+	GetLibraryCardByBarcode(ctx context.Context, barcode string) (*model.LibraryCard, common.Error)
+	ListLibraryCardsByUserID(ctx context.Context, userID int) ([]*model.LibraryCard, common.Error)
+	RevokeLibraryCard(ctx context.Context, id int, revokedBy, reason string) (*model.LibraryCard, common.Error)
+}
+
+type HouseholdRepository interface {
+	CreateHousehold(ctx context.Context, param model.Household) (*model.Household, common.Error)
+	GetHouseholdByID(ctx context.Context, id int) (*model.Household, common.Error)
+	ListHouseholdMembers(ctx context.Context, householdID int) ([]*model.HouseholdMember, common.Error)
+	ListHouseholdMembersByUserID(ctx context.Context, userID int) ([]*model.HouseholdMember, common.Error)
+	AddHouseholdMember(ctx context.Context, param model.HouseholdMember) (*model.HouseholdMember, common.Error)
+	UpdateHouseholdMember(ctx context.Context, param model.HouseholdMember) (*model.HouseholdMember, common.Error)
+	RemoveHouseholdMember(ctx context.Context, householdID, userID int) common.Error
+	SetContentRestrictions(ctx context.Context, userID int, subjectIDs []int, setByUserID int) ([]*model.ContentRestriction, common.Error)
+	ListContentRestrictions(ctx context.Context, userID int) ([]*model.ContentRestriction, common.Error)
+}
+
+type LoanRepository interface {
+	ListLoansByUserID(ctx context.Context, userID int, openOnly bool) ([]*model.BorrowedBook, common.Error)
+}
+
+type HoldRepository interface {
+	ListHoldsByUserID(ctx context.Context, userID int) ([]*model.Hold, common.Error)
+}
+
+type ChargeRepository interface {
+	ListChargesByUserID(ctx context.Context, userID int) ([]*model.Charge, common.Error)
+}
+
+type NotificationRepository interface {
+	ListSentNotificationsByUserID(ctx context.Context, userID int) ([]*model.SentNotification, common.Error)
+}
+
+type PrivacyRepository interface {
+	ErasePatron(ctx context.Context, userID int, uid string) (*model.User, common.Error)
+	UpdateReadingHistoryPreference(ctx context.Context, userID int, keep bool) (*model.User, common.Error)
+	PurgeReadingHistory(ctx context.Context, returnedBefore time.Time) (int, common.Error)
+}
@@ -0,0 +1,67 @@
+package patron
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+type MembershipParam struct {
+	Category model.PatronCategory
+	// StartsAt is the first day of the membership; nil starts it today.
+	StartsAt *time.Time
+	// ExpiresAt is the last day of the membership; nil runs it for the term
+	// of the category.
+	ExpiresAt *time.Time
+}
+
+// UpdateMembership enrols a patron in a category, or moves them to another,
+// starting a new membership.
+func (s *PatronService) UpdateMembership(ctx context.Context, userID int, param MembershipParam) (*model.User, common.Error) {
+	startsAt := s.today()
+	if param.StartsAt != nil {
+		startsAt = model.CalendarDay(*param.StartsAt, time.UTC)
+	}
+	membership, err := s.membershipTerms.Enrol(param.Category, startsAt)
+	if err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if param.ExpiresAt != nil {
+		day := model.CalendarDay(*param.ExpiresAt, time.UTC)
+		membership.ExpiresAt = &day
+	}
+	if err := model.ValidateMembership(membership); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	user, cErr := s.membershipRepo.UpdateMembership(ctx, userID, membership)
+	if cErr != nil {
+		zerolog.Ctx(ctx).Error().Err(cErr).Int("user_id", userID).Msg("failed to update membership")
+		return nil, cErr
+	}
+	return user, nil
+}
+
+// RenewMembership extends the membership of a patron by the term of their
+// category.
+func (s *PatronService) RenewMembership(ctx context.Context, userID int) (*model.User, common.Error) {
+	user, err := s.membershipRepo.RenewMembership(ctx, userID, s.membershipTerms, s.today())
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to renew membership")
+		return nil, err
+	}
+	return user, nil
+}
+
+func requireActor(changedBy string) common.Error {
+	if strings.TrimSpace(changedBy) == "" {
+		err := fmt.Errorf("changed_by is empty")
+		return common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg("the staff member making the change is required"))
+	}
+	return nil
+}
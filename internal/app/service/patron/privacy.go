@@ -0,0 +1,90 @@
+package patron
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// ExportPatronData gathers the personal data kept about a patron.
+func (s *PatronService) ExportPatronData(ctx context.Context, userID int) (*model.PatronExport, common.Error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to get user")
+		return nil, err
+	}
+	cards, err := s.cardRepo.ListLibraryCardsByUserID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to list library cards")
+		return nil, err
+	}
+	loans, err := s.loanRepo.ListLoansByUserID(ctx, userID, false)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to list loans")
+		return nil, err
+	}
+	holds, err := s.holdRepo.ListHoldsByUserID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to list holds")
+		return nil, err
+	}
+	charges, err := s.chargeRepo.ListChargesByUserID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to list charges")
+		return nil, err
+	}
+	notifications, err := s.notificationRepo.ListSentNotificationsByUserID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to list sent notifications")
+		return nil, err
+	}
+
+	return &model.PatronExport{
+		GeneratedAt:   time.Now(),
+		Profile:       *user,
+		Cards:         cards,
+		Loans:         loans,
+		Holds:         holds,
+		Charges:       charges,
+		Notifications: notifications,
+	}, nil
+}
+
+// ErasePatron erases the personal data of a patron. It is refused while the
+// patron has copies out or owes the library.
+func (s *PatronService) ErasePatron(ctx context.Context, userID int) (*model.User, common.Error) {
+	user, err := s.privacyRepo.ErasePatron(ctx, userID, uuid.NewString())
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to erase patron")
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetReadingHistoryPreference records whether a patron opts in to the
+// library keeping their returned loans.
+func (s *PatronService) SetReadingHistoryPreference(ctx context.Context, userID int, keep bool) (*model.User, common.Error) {
+	user, err := s.privacyRepo.UpdateReadingHistoryPreference(ctx, userID, keep)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("user_id", userID).Msg("failed to update reading history preference")
+		return nil, err
+	}
+	return user, nil
+}
+
+// PurgeReadingHistory unlinks returned loans older than the retention window
+// from the patrons who did not opt in to keeping them. It returns how many
+// loans were unlinked.
+func (s *PatronService) PurgeReadingHistory(ctx context.Context) (int, common.Error) {
+	cutoff := model.ReadingHistoryCutoff(time.Now(), s.readingHistoryDays)
+	purged, err := s.privacyRepo.PurgeReadingHistory(ctx, cutoff)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Time("returned_before", cutoff).Msg("failed to purge reading history")
+		return 0, err
+	}
+	return purged, nil
+}
@@ -0,0 +1,70 @@
+package patron
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type PatronService struct {
+	userRepo         UserRepository
+	membershipRepo   MembershipRepository
+	cardRepo         CardRepository
+	householdRepo    HouseholdRepository
+	loanRepo         LoanRepository
+	holdRepo         HoldRepository
+	chargeRepo       ChargeRepository
+	privacyRepo      PrivacyRepository
+	notificationRepo NotificationRepository
+
+	location           *time.Location
+	barcodeFormats     model.BarcodeFormats
+	membershipTerms    model.MembershipTerms
+	readingHistoryDays int
+}
+
+type PatronServiceParam struct {
+	UserRepo         UserRepository
+	MembershipRepo   MembershipRepository
+	CardRepo         CardRepository
+	HouseholdRepo    HouseholdRepository
+	LoanRepo         LoanRepository
+	HoldRepo         HoldRepository
+	ChargeRepo       ChargeRepository
+	PrivacyRepo      PrivacyRepository
+	NotificationRepo NotificationRepository
+
+	// Location is the time zone of the library, in which memberships start and end.
+	Location *time.Location
+	// BarcodeFormats are the patron barcode formats accepted at the desk.
+	BarcodeFormats  model.BarcodeFormats
+	MembershipTerms model.MembershipTerms
+	// ReadingHistoryDays is how long returned loans stay linked to patrons
+	// who did not opt in to keeping their reading history.
+	ReadingHistoryDays int
+}
+
+func NewPatronService(_ context.Context, param PatronServiceParam) *PatronService {
+	return &PatronService{
+		userRepo:         param.UserRepo,
+		membershipRepo:   param.MembershipRepo,
+		cardRepo:         param.CardRepo,
+		householdRepo:    param.HouseholdRepo,
+		loanRepo:         param.LoanRepo,
+		holdRepo:         param.HoldRepo,
+		chargeRepo:       param.ChargeRepo,
+		privacyRepo:      param.PrivacyRepo,
+		notificationRepo: param.NotificationRepo,
+
+		location:           param.Location,
+		barcodeFormats:     param.BarcodeFormats,
+		membershipTerms:    param.MembershipTerms,
+		readingHistoryDays: param.ReadingHistoryDays,
+	}
+}
+
+// today is the current day in the library, as a UTC midnight.
+func (s *PatronService) today() time.Time {
+	return model.CalendarDay(time.Now(), s.location)
+}
@@ -0,0 +1,23 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type WeedingRepository interface {
+	ListDormantCopies(ctx context.Context, filter model.WeedingFilter, cutoff time.Time) ([]*model.WeedingCopy, common.Error)
+	ListHighCirculationCopies(ctx context.Context, filter model.WeedingFilter, minLoans int) ([]*model.WeedingCopy, common.Error)
+	ListHoldPressure(ctx context.Context, filter model.WeedingFilter) ([]*model.HoldPressure, common.Error)
+}
+
+type BranchRepository interface {
+	GetBranchByID(ctx context.Context, id int) (*model.Branch, common.Error)
+}
+
+type SubjectRepository interface {
+	GetSubjectByID(ctx context.Context, id int) (*model.Subject, common.Error)
+}
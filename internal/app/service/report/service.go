@@ -0,0 +1,23 @@
+package report
+
+import "context"
+
+type ReportService struct {
+	weedingRepo WeedingRepository
+	branchRepo  BranchRepository
+	subjectRepo SubjectRepository
+}
+
+type ReportServiceParam struct {
+	WeedingRepo WeedingRepository
+	BranchRepo  BranchRepository
+	SubjectRepo SubjectRepository
+}
+
+func NewReportService(_ context.Context, param ReportServiceParam) *ReportService {
+	return &ReportService{
+		weedingRepo: param.WeedingRepo,
+		branchRepo:  param.BranchRepo,
+		subjectRepo: param.SubjectRepo,
+	}
+}
@@ -0,0 +1,165 @@
+package report
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// DefaultDormantYears is how long a copy sits on the shelf unborrowed before it is reported.
+	DefaultDormantYears = 3
+	// DefaultHighCirculationLoans is how many loans wear a copy out.
+	DefaultHighCirculationLoans = 50
+
+	maxDormantYears = 100
+)
+
+type DormantCopiesParam struct {
+	model.WeedingFilter
+	// Years without a loan, DefaultDormantYears when zero.
+	Years int
+}
+
+// ListDormantCopies lists the copies not borrowed for some years, weeding
+// candidates. Copies added within that time are not listed.
+func (s *ReportService) ListDormantCopies(ctx context.Context, param DormantCopiesParam) ([]*model.WeedingCopy, common.Error) {
+	years := param.Years
+	if years == 0 {
+		years = DefaultDormantYears
+	}
+	if years < 0 || years > maxDormantYears {
+		err := fmt.Errorf("years %d is not between 1 and %d", years, maxDormantYears)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if err := s.checkWeedingFilter(ctx, param.WeedingFilter); err != nil {
+		return nil, err
+	}
+
+	cutoff := model.DormantCutoff(time.Now(), years)
+	copies, err := s.weedingRepo.ListDormantCopies(ctx, param.WeedingFilter, cutoff)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("years", years).Msg("failed to list dormant copies")
+		return nil, err
+	}
+	return copies, nil
+}
+
+type HighCirculationParam struct {
+	model.WeedingFilter
+	// MinLoans is the number of loans reported, DefaultHighCirculationLoans when zero.
+	MinLoans int
+}
+
+// ListHighCirculationCopies lists the copies borrowed so often they are
+// candidates for replacement.
+func (s *ReportService) ListHighCirculationCopies(ctx context.Context, param HighCirculationParam) ([]*model.WeedingCopy, common.Error) {
+	minLoans := param.MinLoans
+	if minLoans == 0 {
+		minLoans = DefaultHighCirculationLoans
+	}
+	if minLoans < 0 {
+		err := fmt.Errorf("min_loans %d is negative", minLoans)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if err := s.checkWeedingFilter(ctx, param.WeedingFilter); err != nil {
+		return nil, err
+	}
+
+	copies, err := s.weedingRepo.ListHighCirculationCopies(ctx, param.WeedingFilter, minLoans)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("min_loans", minLoans).Msg("failed to list high circulation copies")
+		return nil, err
+	}
+	return copies, nil
+}
+
+// ListHoldPressure lists the titles with more holds than copies, which need
+// more copies bought.
+func (s *ReportService) ListHoldPressure(ctx context.Context, filter model.WeedingFilter) ([]*model.HoldPressure, common.Error) {
+	if err := s.checkWeedingFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	pressure, err := s.weedingRepo.ListHoldPressure(ctx, filter)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("failed to list titles with more holds than copies")
+		return nil, err
+	}
+	return pressure, nil
+}
+
+func (s *ReportService) checkWeedingFilter(ctx context.Context, filter model.WeedingFilter) common.Error {
+	if filter.BranchID != 0 {
+		if _, err := s.branchRepo.GetBranchByID(ctx, filter.BranchID); err != nil {
+			return err
+		}
+	}
+	if filter.SubjectID != 0 {
+		if _, err := s.subjectRepo.GetSubjectByID(ctx, filter.SubjectID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteWeedingCopiesCSV writes the copies of a weeding report as CSV.
+func WriteWeedingCopiesCSV(w io.Writer, copies []*model.WeedingCopy) common.Error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"copy_id", "barcode", "book_id", "title", "author", "home_branch_id",
+		"status", "call_number", "loans", "last_borrowed_at", "added_at",
+	})
+	for _, c := range copies {
+		lastBorrowedAt := ""
+		if c.LastBorrowedAt != nil {
+			lastBorrowedAt = c.LastBorrowedAt.Format(time.RFC3339)
+		}
+		_ = cw.Write([]string{
+			strconv.Itoa(c.CopyID),
+			c.Barcode,
+			strconv.Itoa(c.BookID),
+			c.Title,
+			c.Author,
+			strconv.Itoa(c.HomeBranchID),
+			c.Status.String(),
+			c.CallNumber,
+			strconv.Itoa(c.Loans),
+			lastBorrowedAt,
+			c.AddedAt.Format(time.RFC3339),
+		})
+	}
+	return flushCSV(cw)
+}
+
+// WriteHoldPressureCSV writes the titles with more holds than copies as CSV.
+func WriteHoldPressureCSV(w io.Writer, pressure []*model.HoldPressure) common.Error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"work_id", "title", "author", "holds", "copies", "shortfall"})
+	for _, p := range pressure {
+		_ = cw.Write([]string{
+			strconv.Itoa(p.WorkID),
+			p.Title,
+			p.Author,
+			strconv.Itoa(p.Holds),
+			strconv.Itoa(p.Copies),
+			strconv.Itoa(p.Shortfall()),
+		})
+	}
+	return flushCSV(cw)
+}
+
+func flushCSV(cw *csv.Writer) common.Error {
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, err)
+	}
+	return nil
+}
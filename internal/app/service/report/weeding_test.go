@@ -0,0 +1,42 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteWeedingCopiesCSV(t *testing.T) {
+	borrowed := time.Date(2018, 6, 2, 10, 0, 0, 0, time.UTC)
+	copies := []*model.WeedingCopy{
+		{
+			CopyID: 2, Barcode: "30000000000020", BookID: 2, Title: "Don Quixote, Part One", Author: "Miguel de Cervantes",
+			HomeBranchID: 1, Status: model.InLibrary, CallNumber: "863.3 CER", Loans: 2,
+			LastBorrowedAt: &borrowed, AddedAt: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{CopyID: 4, BookID: 3, Title: "The Little Prince", Status: model.InLibrary, AddedAt: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteWeedingCopiesCSV(&buf, copies))
+	assert.Equal(t,
+		"copy_id,barcode,book_id,title,author,home_branch_id,status,call_number,loans,last_borrowed_at,added_at\n"+
+			"2,30000000000020,2,\"Don Quixote, Part One\",Miguel de Cervantes,1,InLibrary,863.3 CER,2,2018-06-02T10:00:00Z,2015-01-01T00:00:00Z\n"+
+			"4,,3,The Little Prince,,0,InLibrary,,0,,2016-01-01T00:00:00Z\n",
+		buf.String())
+}
+
+func TestWriteHoldPressureCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteHoldPressureCSV(&buf, []*model.HoldPressure{
+		{WorkID: 1, Title: "Don Quixote", Author: "Miguel de Cervantes", Holds: 5, Copies: 2},
+	}))
+	assert.Equal(t,
+		"work_id,title,author,holds,copies,shortfall\n"+
+			"1,Don Quixote,Miguel de Cervantes,5,2,3\n",
+		buf.String())
+}
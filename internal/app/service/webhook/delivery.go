@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+const (
+	deliverBatchSize    = 10
+	defaultListLimit    = 50
+	maxListLimit        = 500
+	maxRecordedErrorLen = 1000
+)
+
+// DeliverWebhooks attempts the deliveries which are due. A failed attempt is
+// retried after a backoff doubling each time; a delivery running out of
+// attempts is dead-lettered. It returns the number delivered.
+func (s *WebhookService) DeliverWebhooks(ctx context.Context) (int, common.Error) {
+	// a batch is claimed for as long as attempting all of it may take
+	lease := time.Duration(deliverBatchSize)*s.sendTimeout + time.Minute
+	subs := map[int]*model.WebhookSubscription{}
+
+	delivered := 0
+	for {
+		due, err := s.webhookRepo.ClaimDueWebhookDeliveries(ctx, time.Now(), lease, deliverBatchSize)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to claim webhook deliveries")
+			return delivered, err
+		}
+		if len(due) == 0 {
+			return delivered, nil
+		}
+
+		for _, d := range due {
+			sub, ok := subs[d.SubscriptionID]
+			if !ok {
+				if sub, err = s.webhookRepo.GetWebhookSubscriptionByID(ctx, d.SubscriptionID); err != nil {
+					zerolog.Ctx(ctx).Error().Err(err).Int("delivery_id", d.ID).Msg("failed to get webhook subscription")
+					continue
+				}
+				subs[d.SubscriptionID] = sub
+			}
+
+			recorded, err := s.attempt(ctx, *sub, *d)
+			if err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Int("delivery_id", d.ID).Msg("failed to record webhook attempt")
+				continue
+			}
+			switch recorded.Status {
+			case model.WebhookDelivered:
+				delivered++
+			case model.WebhookDeadLettered:
+				zerolog.Ctx(ctx).Warn().Int("delivery_id", d.ID).Int("subscription_id", sub.ID).
+					Int("attempts", recorded.Attempts).Str("last_error", recorded.LastError).
+					Msg("webhook delivery dead-lettered")
+			}
+		}
+	}
+}
+
+// attempt sends a delivery once and records how it went.
+func (s *WebhookService) attempt(ctx context.Context, sub model.WebhookSubscription, d model.WebhookDelivery) (*model.WebhookDelivery, common.Error) {
+	code, sendErr := s.sender.Send(ctx, webhook.Request{
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		DeliveryID: d.ID,
+		EventID:    d.EventID,
+		EventType:  string(d.EventType),
+		Body:       d.Payload,
+	})
+
+	now := time.Now()
+	d.Attempts++
+	d.LastStatusCode = nil
+	if code != 0 {
+		d.LastStatusCode = &code
+	}
+	d.LastError = ""
+	switch {
+	case sendErr == nil:
+		d.Status = model.WebhookDelivered
+		d.DeliveredAt = &now
+		d.NextAttemptAt = now
+	case s.retryPolicy.Exhausted(d.Attempts):
+		d.LastError = truncate(sendErr.Error(), maxRecordedErrorLen)
+		d.Status = model.WebhookDeadLettered
+		d.DeadLetteredAt = &now
+		d.NextAttemptAt = now
+	default:
+		d.LastError = truncate(sendErr.Error(), maxRecordedErrorLen)
+		d.NextAttemptAt = now.Add(s.retryPolicy.Backoff(d.Attempts))
+	}
+	return s.webhookRepo.RecordWebhookAttempt(ctx, d)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, id int) (*model.WebhookDelivery, common.Error) {
+	return s.webhookRepo.GetWebhookDeliveryByID(ctx, id)
+}
+
+// ListDeliveries lists deliveries, the latest first. A zero subscription ID
+// or an empty status matches any; the dead-lettered status lists the dead
+// letters.
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID int, status model.WebhookDeliveryStatus, limit, offset int) ([]*model.WebhookDelivery, common.Error) {
+	if status != "" && !status.IsValid() {
+		err := fmt.Errorf("unknown delivery status %q", status)
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+	if subscriptionID != 0 {
+		if _, err := s.webhookRepo.GetWebhookSubscriptionByID(ctx, subscriptionID); err != nil {
+			return nil, err
+		}
+	}
+	return s.webhookRepo.ListWebhookDeliveries(ctx, subscriptionID, status, clampLimit(limit), offset)
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultListLimit
+	}
+	if limit > maxListLimit {
+		return maxListLimit
+	}
+	return limit
+}
+
+// Redeliver queues a dead-lettered or delivered delivery to be sent again, with
+// the same body and a fresh set of attempts.
+func (s *WebhookService) Redeliver(ctx context.Context, id int) (*model.WebhookDelivery, common.Error) {
+	d, err := s.webhookRepo.RequeueWebhookDelivery(ctx, id)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("delivery_id", id).Msg("failed to requeue webhook delivery")
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// envelope is the body every webhook request carries.
+type envelope struct {
+	ID         string                 `json:"id"`
+	Type       model.WebhookEventType `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       interface{}            `json:"data"`
+}
+
+type loanData struct {
+	LoanID     int        `json:"loan_id"`
+	UserID     int        `json:"user_id"`
+	CopyID     int        `json:"copy_id"`
+	BranchID   *int       `json:"branch_id,omitempty"`
+	BorrowedAt time.Time  `json:"borrowed_at"`
+	DueDate    time.Time  `json:"due_date"`
+	ReturnedAt *time.Time `json:"returned_at,omitempty"`
+}
+
+type holdData struct {
+	HoldID         int        `json:"hold_id"`
+	UserID         int        `json:"user_id"`
+	WorkID         int        `json:"work_id"`
+	CopyID         *int       `json:"copy_id,omitempty"`
+	PickupBranchID int        `json:"pickup_branch_id"`
+	ReadyAt        *time.Time `json:"ready_at,omitempty"`
+}
+
+type catalogData struct {
+	Entity model.CatalogEntity `json:"entity"`
+	ID     int                 `json:"id"`
+	Action model.CatalogAction `json:"action"`
+}
+
+func eventData(event model.WebhookEvent) (interface{}, error) {
+	switch {
+	case event.Loan != nil:
+		l := event.Loan
+		return loanData{
+			LoanID:     l.ID,
+			UserID:     l.UserID,
+			CopyID:     l.CopyID,
+			BranchID:   l.BranchID,
+			BorrowedAt: l.BorrowDate,
+			DueDate:    l.DueDate,
+			ReturnedAt: l.ReturnDate,
+		}, nil
+	case event.Hold != nil:
+		h := event.Hold
+		return holdData{
+			HoldID:         h.ID,
+			UserID:         h.UserID,
+			WorkID:         h.WorkID,
+			CopyID:         h.CopyID,
+			PickupBranchID: h.PickupBranchID,
+			ReadyAt:        h.ReadyAt,
+		}, nil
+	case event.Catalog != nil:
+		return catalogData(*event.Catalog), nil
+	}
+	return nil, fmt.Errorf("%s event says nothing about what happened", event.Type)
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	return "evt_" + hex.EncodeToString(b), nil
+}
+
+// Publish queues an event for the active subscriptions to its type. It is
+// sent by DeliverWebhooks, so that a slow endpoint never holds up the desk.
+func (s *WebhookService) Publish(ctx context.Context, event model.WebhookEvent) common.Error {
+	subs, err := s.webhookRepo.ListWebhookSubscriptions(ctx, event.Type)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("event", string(event.Type)).Msg("failed to list webhook subscriptions")
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	data, dErr := eventData(event)
+	if dErr != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, dErr)
+	}
+	id, dErr := newEventID()
+	if dErr != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, dErr)
+	}
+	payload, dErr := json.Marshal(envelope{ID: id, Type: event.Type, OccurredAt: event.OccurredAt, Data: data})
+	if dErr != nil {
+		return common.NewError(common.ErrorCodeInternalProcess, dErr)
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        id,
+			EventType:      event.Type,
+			Payload:        payload,
+		})
+	}
+	if err := s.webhookRepo.EnqueueWebhookDeliveries(ctx, deliveries); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("event", string(event.Type)).Msg("failed to queue webhook deliveries")
+		return err
+	}
+	return nil
+}
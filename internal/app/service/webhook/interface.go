@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type WebhookRepository interface {
+	CreateWebhookSubscription(ctx context.Context, param model.WebhookSubscription) (*model.WebhookSubscription, common.Error)
+	GetWebhookSubscriptionByID(ctx context.Context, id int) (*model.WebhookSubscription, common.Error)
+	ListWebhookSubscriptions(ctx context.Context, event model.WebhookEventType) ([]*model.WebhookSubscription, common.Error)
+	UpdateWebhookSubscription(ctx context.Context, param model.WebhookSubscription) (*model.WebhookSubscription, common.Error)
+	DeleteWebhookSubscription(ctx context.Context, id int) common.Error
+	EnqueueWebhookDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) common.Error
+	ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, common.Error)
+	RecordWebhookAttempt(ctx context.Context, param model.WebhookDelivery) (*model.WebhookDelivery, common.Error)
+	GetWebhookDeliveryByID(ctx context.Context, id int) (*model.WebhookDelivery, common.Error)
+	ListWebhookDeliveries(ctx context.Context, subscriptionID int, status model.WebhookDeliveryStatus, limit, offset int) ([]*model.WebhookDelivery, common.Error)
+	RequeueWebhookDelivery(ctx context.Context, id int) (*model.WebhookDelivery, common.Error)
+}
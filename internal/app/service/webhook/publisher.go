@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+// Publisher queues events for the subscriptions wanting them, as
+// WebhookService does.
+type Publisher interface {
+	Publish(ctx context.Context, event model.WebhookEvent) common.Error
+}
+
+// Events is how other services tell integrations what happened. The change
+// an event reports is made already by the time it is published, so failing
+// to queue the event is logged rather than failing the change.
+type Events struct {
+	publisher Publisher
+}
+
+func NewEvents(publisher Publisher) Events {
+	return Events{publisher: publisher}
+}
+
+func (e Events) Publish(ctx context.Context, event model.WebhookEvent) {
+	if err := e.publisher.Publish(ctx, event); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("event", string(event.Type)).Msg("failed to publish event")
+	}
+}
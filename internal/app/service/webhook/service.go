@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+type WebhookService struct {
+	webhookRepo WebhookRepository
+
+	sender      webhook.Sender
+	retryPolicy model.WebhookRetryPolicy
+	sendTimeout time.Duration
+}
+
+type WebhookServiceParam struct {
+	WebhookRepo WebhookRepository
+
+	Sender      webhook.Sender
+	RetryPolicy model.WebhookRetryPolicy
+	// SendTimeout is how long the sender waits for an endpoint; deliveries
+	// are claimed for long enough to be attempted within it.
+	SendTimeout time.Duration
+}
+
+func NewWebhookService(_ context.Context, param WebhookServiceParam) *WebhookService {
+	return &WebhookService{
+		webhookRepo: param.WebhookRepo,
+
+		sender:      param.Sender,
+		retryPolicy: param.RetryPolicy,
+		sendTimeout: param.SendTimeout,
+	}
+}
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/rs/zerolog"
+)
+
+type CreateSubscriptionParam struct {
+	URL         string
+	Description string
+	Events      []model.WebhookEventType
+	// Secret signs the requests; one is generated when empty.
+	Secret string
+}
+
+// CreateSubscription subscribes an endpoint to events. The subscription is
+// returned with its secret, for the integration to verify requests with.
+func (s *WebhookService) CreateSubscription(ctx context.Context, param CreateSubscriptionParam) (*model.WebhookSubscription, common.Error) {
+	sub := model.WebhookSubscription{
+		URL:         strings.TrimSpace(param.URL),
+		Description: strings.TrimSpace(param.Description),
+		Events:      param.Events,
+		Secret:      param.Secret,
+		Active:      true,
+	}
+	if sub.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			return nil, common.NewError(common.ErrorCodeInternalProcess, err)
+		}
+		sub.Secret = secret
+	}
+	if err := model.ValidateWebhookSubscription(sub); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	created, err := s.webhookRepo.CreateWebhookSubscription(ctx, sub)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("url", sub.URL).Msg("failed to create webhook subscription")
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id int) (*model.WebhookSubscription, common.Error) {
+	return s.webhookRepo.GetWebhookSubscriptionByID(ctx, id)
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, common.Error) {
+	return s.webhookRepo.ListWebhookSubscriptions(ctx, "")
+}
+
+type UpdateSubscriptionParam struct {
+	ID          int
+	URL         string
+	Description string
+	Events      []model.WebhookEventType
+	Active      bool
+}
+
+// UpdateSubscription changes the endpoint and events of a subscription, or
+// pauses it. Events happening while it is paused are not queued for it.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, param UpdateSubscriptionParam) (*model.WebhookSubscription, common.Error) {
+	sub, err := s.webhookRepo.GetWebhookSubscriptionByID(ctx, param.ID)
+	if err != nil {
+		return nil, err
+	}
+	sub.URL = strings.TrimSpace(param.URL)
+	sub.Description = strings.TrimSpace(param.Description)
+	sub.Events = param.Events
+	sub.Active = param.Active
+	if err := model.ValidateWebhookSubscription(*sub); err != nil {
+		return nil, common.NewError(common.ErrorCodeParameterInvalid, err, common.WithMsg(err.Error()))
+	}
+
+	updated, err := s.webhookRepo.UpdateWebhookSubscription(ctx, *sub)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("subscription_id", param.ID).Msg("failed to update webhook subscription")
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteSubscription drops a subscription with its deliveries, dead letters
+// included.
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int) common.Error {
+	if err := s.webhookRepo.DeleteWebhookSubscription(ctx, id); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Int("subscription_id", id).Msg("failed to delete webhook subscription")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook/webhooktest"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/common"
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepository keeps subscriptions and deliveries the way the database
+// does, without the locking.
+type memoryRepository struct {
+	subs       []*model.WebhookSubscription
+	deliveries []*model.WebhookDelivery
+}
+
+func notFound(what string) common.Error {
+	return common.NewError(common.ErrorCodeResourceNotFound, errors.New(what+" not found"))
+}
+
+func (r *memoryRepository) CreateWebhookSubscription(_ context.Context, param model.WebhookSubscription) (*model.WebhookSubscription, common.Error) {
+	param.ID = len(r.subs) + 1
+	r.subs = append(r.subs, &param)
+	return &param, nil
+}
+
+func (r *memoryRepository) GetWebhookSubscriptionByID(_ context.Context, id int) (*model.WebhookSubscription, common.Error) {
+	for _, s := range r.subs {
+		if s.ID == id {
+			sub := *s
+			return &sub, nil
+		}
+	}
+	return nil, notFound("webhook subscription")
+}
+
+func (r *memoryRepository) ListWebhookSubscriptions(_ context.Context, event model.WebhookEventType) ([]*model.WebhookSubscription, common.Error) {
+	var subs []*model.WebhookSubscription
+	for _, s := range r.subs {
+		if event == "" || s.Wants(event) {
+			subs = append(subs, s)
+		}
+	}
+	return subs, nil
+}
+
+func (r *memoryRepository) UpdateWebhookSubscription(_ context.Context, param model.WebhookSubscription) (*model.WebhookSubscription, common.Error) {
+	for i, s := range r.subs {
+		if s.ID == param.ID {
+			r.subs[i] = &param
+			return &param, nil
+		}
+	}
+	return nil, notFound("webhook subscription")
+}
+
+func (r *memoryRepository) DeleteWebhookSubscription(_ context.Context, id int) common.Error {
+	for i, s := range r.subs {
+		if s.ID == id {
+			r.subs = append(r.subs[:i], r.subs[i+1:]...)
+			return nil
+		}
+	}
+	return notFound("webhook subscription")
+}
+
+func (r *memoryRepository) EnqueueWebhookDeliveries(_ context.Context, deliveries []model.WebhookDelivery) common.Error {
+	for _, d := range deliveries {
+		d.ID = len(r.deliveries) + 1
+		d.Status = model.WebhookPending
+		d.NextAttemptAt = time.Now()
+		r.deliveries = append(r.deliveries, &d)
+	}
+	return nil
+}
+
+func (r *memoryRepository) ClaimDueWebhookDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, common.Error) {
+	var due []*model.WebhookDelivery
+	for _, d := range r.deliveries {
+		if len(due) == limit {
+			break
+		}
+		if d.Status == model.WebhookPending && !d.NextAttemptAt.After(now) {
+			d.NextAttemptAt = now.Add(lease)
+			claimed := *d
+			due = append(due, &claimed)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryRepository) RecordWebhookAttempt(_ context.Context, param model.WebhookDelivery) (*model.WebhookDelivery, common.Error) {
+	for i, d := range r.deliveries {
+		if d.ID == param.ID {
+			r.deliveries[i] = &param
+			return &param, nil
+		}
+	}
+	return nil, notFound("webhook delivery")
+}
+
+func (r *memoryRepository) GetWebhookDeliveryByID(_ context.Context, id int) (*model.WebhookDelivery, common.Error) {
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, notFound("webhook delivery")
+}
+
+func (r *memoryRepository) ListWebhookDeliveries(_ context.Context, subscriptionID int, status model.WebhookDeliveryStatus, limit, offset int) ([]*model.WebhookDelivery, common.Error) {
+	var deliveries []*model.WebhookDelivery
+	for _, d := range r.deliveries {
+		if (subscriptionID == 0 || d.SubscriptionID == subscriptionID) && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *memoryRepository) RequeueWebhookDelivery(_ context.Context, id int) (*model.WebhookDelivery, common.Error) {
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			if d.Status == model.WebhookPending {
+				return nil, common.NewError(common.ErrorCodeParameterInvalid, errors.New("pending"))
+			}
+			d.Status = model.WebhookPending
+			d.Attempts = 0
+			d.NextAttemptAt = time.Now()
+			d.DeadLetteredAt = nil
+			return d, nil
+		}
+	}
+	return nil, notFound("webhook delivery")
+}
+
+// catchUp makes every pending delivery due, as if its backoff had passed.
+func (r *memoryRepository) catchUp() {
+	for _, d := range r.deliveries {
+		if d.Status == model.WebhookPending {
+			d.NextAttemptAt = time.Now().Add(-time.Second)
+		}
+	}
+}
+
+func TestWebhookService(t *testing.T) {
+	ctx := context.Background()
+	receiver := webhooktest.NewReceiver("locker-secret")
+	defer receiver.Close()
+
+	repo := &memoryRepository{}
+	s := NewWebhookService(ctx, WebhookServiceParam{
+		WebhookRepo: repo,
+		Sender:      webhook.NewClient(time.Second),
+		RetryPolicy: model.WebhookRetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		SendTimeout: time.Second,
+	})
+
+	_, cErr := s.CreateSubscription(ctx, CreateSubscriptionParam{URL: "ftp://locker.example.com", Events: []model.WebhookEventType{model.WebhookHoldReady}})
+	assert.Error(t, cErr)
+	sub, cErr := s.CreateSubscription(ctx, CreateSubscriptionParam{
+		URL:         receiver.URL,
+		Description: "pickup lockers",
+		Events:      []model.WebhookEventType{model.WebhookHoldReady},
+		Secret:      "locker-secret",
+	})
+	require.NoError(t, cErr)
+	assert.True(t, sub.Active)
+
+	// the lockers did not subscribe to checkouts
+	require.NoError(t, s.Publish(ctx, model.LoanEvent(model.WebhookLoanCheckedOut, model.BorrowedBook{ID: 1, UserID: 2, CopyID: 3})))
+	assert.Empty(t, repo.deliveries)
+
+	copyID := 4
+	readyAt := time.Date(2023, 6, 2, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Publish(ctx, model.HoldReadyEvent(model.Hold{ID: 1, UserID: 2, WorkID: 3, CopyID: &copyID, PickupBranchID: 1, Status: model.HoldReady, ReadyAt: &readyAt})))
+	require.Len(t, repo.deliveries, 1)
+
+	// the endpoint is down: each failure waits twice as long as the last
+	receiver.FailNext(10)
+	delivered, cErr := s.DeliverWebhooks(ctx)
+	require.NoError(t, cErr)
+	assert.Equal(t, 0, delivered)
+	d := repo.deliveries[0]
+	assert.Equal(t, model.WebhookPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	require.NotNil(t, d.LastStatusCode)
+	assert.Equal(t, 503, *d.LastStatusCode)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), d.NextAttemptAt, 5*time.Second)
+
+	// nothing is due before the backoff passes
+	delivered, cErr = s.DeliverWebhooks(ctx)
+	require.NoError(t, cErr)
+	assert.Equal(t, 0, delivered)
+	assert.Equal(t, 1, receiver.Attempts())
+
+	repo.catchUp()
+	_, cErr = s.DeliverWebhooks(ctx)
+	require.NoError(t, cErr)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), repo.deliveries[0].NextAttemptAt, 5*time.Second)
+
+	// the third failure dead-letters it
+	repo.catchUp()
+	_, cErr = s.DeliverWebhooks(ctx)
+	require.NoError(t, cErr)
+	deadLetters, cErr := s.ListDeliveries(ctx, sub.ID, model.WebhookDeadLettered, 0, 0)
+	require.NoError(t, cErr)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, 3, deadLetters[0].Attempts)
+	assert.NotNil(t, deadLetters[0].DeadLetteredAt)
+	assert.Equal(t, "endpoint answered 503 Service Unavailable", deadLetters[0].LastError)
+	assert.Empty(t, receiver.Received())
+
+	// redelivered once the endpoint is back, with the same body
+	receiver.FailNext(0)
+	requeued, cErr := s.Redeliver(ctx, deadLetters[0].ID)
+	require.NoError(t, cErr)
+	assert.Equal(t, model.WebhookPending, requeued.Status)
+	_, cErr = s.Redeliver(ctx, deadLetters[0].ID)
+	assert.Error(t, cErr, "already queued")
+	delivered, cErr = s.DeliverWebhooks(ctx)
+	require.NoError(t, cErr)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, model.WebhookDelivered, repo.deliveries[0].Status)
+
+	received := receiver.Received()
+	require.Len(t, received, 1)
+	assert.Equal(t, "hold.ready", received[0].Event)
+	var body struct {
+		ID   string                 `json:"id"`
+		Type string                 `json:"type"`
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(received[0].Body, &body))
+	assert.Equal(t, received[0].EventID, body.ID)
+	assert.Equal(t, "hold.ready", body.Type)
+	assert.Equal(t, float64(1), body.Data["hold_id"])
+	assert.Equal(t, float64(4), body.Data["copy_id"])
+	assert.Equal(t, "2023-06-02T09:00:00Z", body.Data["ready_at"])
+
+	// a paused subscription is not queued new events
+	_, cErr = s.UpdateSubscription(ctx, UpdateSubscriptionParam{ID: sub.ID, URL: receiver.URL, Events: sub.Events, Active: false})
+	require.NoError(t, cErr)
+	require.NoError(t, s.Publish(ctx, model.HoldReadyEvent(model.Hold{ID: 2})))
+	assert.Len(t, repo.deliveries, 1)
+}
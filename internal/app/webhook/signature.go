@@ -0,0 +1,85 @@
+// Package webhook posts events to the endpoints of integrations. Each request
+// is signed with the secret of its subscription, so that receivers can tell
+// it came from the library and was not replayed long after.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderSignature carries the signature of a request, as
+	// t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">.
+	HeaderSignature = "X-PageTurner-Signature"
+	// HeaderEvent carries the type of the event sent.
+	HeaderEvent = "X-PageTurner-Event"
+	// HeaderEventID carries the ID of the event, the same for every attempt
+	// and every subscription, for receivers to drop duplicates.
+	HeaderEventID = "X-PageTurner-Event-Id"
+	// HeaderDelivery carries the ID of the delivery, to redeliver it.
+	HeaderDelivery = "X-PageTurner-Delivery"
+)
+
+// NewSecret returns a random secret to sign the requests of a subscription.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header of a body sent at a time.
+func Sign(secret string, at time.Time, body []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify checks a signature header was made with the secret for the body,
+// no longer than tolerance before now.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return fmt.Errorf("malformed signature header %q", header)
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("signature made %s away from now", age)
+	}
+
+	want := mac(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature does not match")
+}
+
+func mac(secret, ts string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,70 @@
+package webhook_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook"
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook/webhooktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"loan.returned"}`)
+	at := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	header := webhook.Sign("secret", at, body)
+	assert.Regexp(t, `^t=1685620800,v1=[0-9a-f]{64}$`, header)
+
+	assert.NoError(t, webhook.Verify("secret", header, body, at.Add(time.Minute), 5*time.Minute))
+	assert.EqualError(t, webhook.Verify("other", header, body, at, 5*time.Minute), "signature does not match")
+	assert.EqualError(t, webhook.Verify("secret", header, []byte(`{}`), at, 5*time.Minute), "signature does not match")
+	assert.Error(t, webhook.Verify("secret", header, body, at.Add(time.Hour), 5*time.Minute), "replayed too late")
+	assert.Error(t, webhook.Verify("secret", "v1=abc", body, at, 5*time.Minute))
+
+	secret, err := webhook.NewSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, len("whsec_")+64)
+}
+
+func TestClient_Send(t *testing.T) {
+	receiver := webhooktest.NewReceiver("secret")
+	defer receiver.Close()
+	client := webhook.NewClient(time.Second)
+
+	req := webhook.Request{
+		URL:        receiver.URL,
+		Secret:     "secret",
+		DeliveryID: 7,
+		EventID:    "evt_1",
+		EventType:  "hold.ready",
+		Body:       []byte(`{"type":"hold.ready"}`),
+	}
+	code, err := client.Send(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 204, code)
+	require.Len(t, receiver.Received(), 1)
+	got := receiver.Received()[0]
+	assert.Equal(t, "hold.ready", got.Event)
+	assert.Equal(t, "evt_1", got.EventID)
+	assert.Equal(t, "7", got.DeliveryID)
+	assert.Equal(t, req.Body, got.Body)
+
+	receiver.FailNext(1)
+	code, err = client.Send(context.Background(), req)
+	assert.EqualError(t, err, "endpoint answered 503 Service Unavailable")
+	assert.Equal(t, 503, code)
+
+	req.Secret = "wrong"
+	code, err = client.Send(context.Background(), req)
+	assert.Error(t, err)
+	assert.Equal(t, 401, code)
+	assert.Len(t, receiver.Received(), 1)
+	assert.Equal(t, 3, receiver.Attempts())
+
+	receiver.Close()
+	code, err = client.Send(context.Background(), req)
+	assert.Error(t, err)
+	assert.Equal(t, 0, code)
+}
@@ -0,0 +1,92 @@
+// Package webhooktest runs a local endpoint receiving webhooks, for tests and
+// for trying integrations out.
+package webhooktest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/app/webhook"
+)
+
+// Received is a request the receiver accepted.
+type Received struct {
+	Event      string
+	EventID    string
+	DeliveryID string
+	Body       []byte
+}
+
+// Receiver is a local HTTP endpoint which checks the signature of every
+// request and keeps the ones it accepts. It answers 401 to requests with a
+// bad signature and 503 while told to fail.
+type Receiver struct {
+	*httptest.Server
+
+	secret string
+
+	mu       sync.Mutex
+	failures int
+	attempts int
+	received []Received
+}
+
+// NewReceiver starts a receiver verifying signatures with a secret. Close it
+// when done.
+func NewReceiver(secret string) *Receiver {
+	r := &Receiver{secret: secret}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+// FailNext makes the receiver fail the next n requests.
+func (r *Receiver) FailNext(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = n
+}
+
+// Attempts is the number of requests the receiver got, accepted or not.
+func (r *Receiver) Attempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attempts
+}
+
+// Received returns the requests accepted so far.
+func (r *Receiver) Received() []Received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Received(nil), r.received...)
+}
+
+func (r *Receiver) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if err := webhook.Verify(r.secret, req.Header.Get(webhook.HeaderSignature), body, time.Now(), 5*time.Minute); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	r.received = append(r.received, Received{
+		Event:      req.Header.Get(webhook.HeaderEvent),
+		EventID:    req.Header.Get(webhook.HeaderEventID),
+		DeliveryID: req.Header.Get(webhook.HeaderDelivery),
+		Body:       body,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
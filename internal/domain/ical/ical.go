@@ -0,0 +1,114 @@
+// Package ical writes iCalendar (RFC 5545) feeds of events, such as the
+// opening hours and closures of a branch.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxLineOctets is the length content lines are folded at.
+const maxLineOctets = 75
+
+// Event is a VEVENT. Timed events are written in the time zone of their
+// start, all-day events as dates.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	// End is exclusive: the day after the last day of an all-day event.
+	End    time.Time
+	AllDay bool
+	// Weekly repeats the event every week on the weekday of its start.
+	Weekly bool
+}
+
+// Calendar is a VCALENDAR holding events.
+type Calendar struct {
+	ProdID string
+	Name   string
+	// Stamp is written as the DTSTAMP of every event.
+	Stamp  time.Time
+	Events []Event
+}
+
+// Write writes the calendar with CRLF line ends and long lines folded.
+func (c Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", escapeText(c.ProdID))
+	line("CALSCALE", "GREGORIAN")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escapeText(e.UID))
+		line("DTSTAMP", c.Stamp.UTC().Format("20060102T150405Z"))
+		writeFolded(bw, "DTSTART"+formatTime(e.Start, e.AllDay))
+		writeFolded(bw, "DTEND"+formatTime(e.End, e.AllDay))
+		if e.Weekly {
+			line("RRULE", "FREQ=WEEKLY;BYDAY="+weekdayCodes[e.Start.Weekday()])
+		}
+		line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escapeText(e.Description))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// formatTime returns the parameters and value of a date-time property.
+// Times in UTC are written as such, others with the TZID of their zone.
+func formatTime(t time.Time, allDay bool) string {
+	switch {
+	case allDay:
+		return ";VALUE=DATE:" + t.Format("20060102")
+	case t.Location() == time.UTC:
+		return ":" + t.Format("20060102T150405Z")
+	default:
+		return fmt.Sprintf(";TZID=%s:%s", t.Location(), t.Format("20060102T150405"))
+	}
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded writes a content line, folding it into lines of at most 75
+// octets without splitting a UTF-8 sequence.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// continuation lines start with a space
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,71 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_Write(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	cal := Calendar{
+		ProdID: "-//Page Turner PRO//Calendar//EN",
+		Name:   "Central Library",
+		Stamp:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Events: []Event{
+			{
+				UID:     "hours-1-1@page-turner-pro",
+				Summary: "Open",
+				Start:   time.Date(2024, 1, 1, 9, 0, 0, 0, loc),
+				End:     time.Date(2024, 1, 1, 17, 0, 0, 0, loc),
+				Weekly:  true,
+			},
+			{
+				UID:         "closure-3@page-turner-pro",
+				Summary:     "Closed: Christmas, Boxing Day",
+				Description: "Holiday; all branches",
+				Start:       time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+				End:         time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC),
+				AllDay:      true,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, cal.Write(&buf))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "DTSTART;TZID=Europe/London:20240101T090000\r\n")
+	assert.Contains(t, out, "RRULE:FREQ=WEEKLY;BYDAY=MO\r\n")
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20241225\r\n")
+	assert.Contains(t, out, "DTEND;VALUE=DATE:20241227\r\n")
+	assert.Contains(t, out, `SUMMARY:Closed: Christmas\, Boxing Day`)
+	assert.Contains(t, out, `DESCRIPTION:Holiday\; all branches`)
+	assert.Contains(t, out, "DTSTAMP:20240102T030405Z\r\n")
+}
+
+func TestWriteFolded(t *testing.T) {
+	var buf bytes.Buffer
+	cal := Calendar{Stamp: time.Now(), Events: []Event{{
+		UID:     "long",
+		Summary: strings.Repeat("é", 100),
+		Start:   time.Now().UTC(),
+		End:     time.Now().UTC(),
+	}}}
+	require.NoError(t, cal.Write(&buf))
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, "line splits a UTF-8 sequence: %q", line)
+	}
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	assert.Contains(t, unfolded, "SUMMARY:"+strings.Repeat("é", 100)+"\r\n")
+}
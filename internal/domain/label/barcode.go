@@ -0,0 +1,55 @@
+// Package label draws the barcode and spine labels stuck on copies, as SVG
+// or PNG images, and lays them out as PDF sheets of common label stock.
+package label
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Symbology is the kind of barcode printed on a label.
+type Symbology string
+
+const (
+	Code128 Symbology = "code128"
+	Code39  Symbology = "code39"
+)
+
+func ParseSymbology(name string) (Symbology, error) {
+	switch s := Symbology(strings.ToLower(strings.TrimSpace(name))); s {
+	case Code128, Code39:
+		return s, nil
+	case "":
+		return Code128, nil
+	default:
+		return "", fmt.Errorf("unknown barcode symbology %q, want %s or %s", name, Code128, Code39)
+	}
+}
+
+// Barcode is an encoded barcode: the widths, in modules, of its bars and
+// spaces alternating from a bar.
+type Barcode struct {
+	Symbology Symbology
+	Data      string
+	Widths    []int
+}
+
+func Encode(symbology Symbology, data string) (Barcode, error) {
+	switch symbology {
+	case Code128:
+		return encodeCode128(data)
+	case Code39:
+		return encodeCode39(data)
+	default:
+		return Barcode{}, fmt.Errorf("unknown barcode symbology %q", symbology)
+	}
+}
+
+// Modules is the width of the barcode in modules, without quiet zones.
+func (b Barcode) Modules() int {
+	n := 0
+	for _, w := range b.Widths {
+		n += w
+	}
+	return n
+}
@@ -0,0 +1,99 @@
+package label
+
+import "fmt"
+
+// code128Patterns are the bar and space widths of the Code 128 symbols by
+// value. 103 to 105 start code sets A, B and C, and 106 is the stop symbol.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// encodeCode128 encodes printable ASCII in code set B, switching to code set
+// C, two digits a symbol, for runs of digits long enough to pay for the
+// switch. A barcode made of digits only is thus encoded in code set C.
+func encodeCode128(data string) (Barcode, error) {
+	if data == "" {
+		return Barcode{}, fmt.Errorf("barcode is empty")
+	}
+	for _, c := range data {
+		if c < ' ' || c > '~' {
+			return Barcode{}, fmt.Errorf("code 128 cannot encode %q", c)
+		}
+	}
+
+	digitRun := func(i int) int {
+		n := 0
+		for i+n < len(data) && data[i+n] >= '0' && data[i+n] <= '9' {
+			n++
+		}
+		return n
+	}
+
+	var values []int
+	codeC := false
+	if run := digitRun(0); run >= 4 || run == len(data) && run%2 == 0 {
+		values = append(values, code128StartC)
+		codeC = true
+	} else {
+		values = append(values, code128StartB)
+	}
+
+	for i := 0; i < len(data); {
+		run := digitRun(i)
+		if codeC {
+			if run >= 2 {
+				values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+				i += 2
+				continue
+			}
+			values = append(values, code128CodeB)
+			codeC = false
+			continue
+		}
+		// a switch and back costs two symbols, saved by six digits, or by
+		// four at the end of the data
+		if run >= 6 || run >= 4 && i+run == len(data) {
+			if run%2 == 1 {
+				values = append(values, int(data[i])-' ')
+				i++
+			}
+			values = append(values, code128CodeC)
+			codeC = true
+			continue
+		}
+		values = append(values, int(data[i])-' ')
+		i++
+	}
+
+	check := values[0]
+	for i, v := range values[1:] {
+		check += (i + 1) * v
+	}
+	values = append(values, check%103, code128Stop)
+
+	var widths []int
+	for _, v := range values {
+		for _, w := range code128Patterns[v] {
+			widths = append(widths, int(w-'0'))
+		}
+	}
+	return Barcode{Symbology: Code128, Data: data, Widths: widths}, nil
+}
@@ -0,0 +1,53 @@
+package label
+
+import (
+	"fmt"
+	"strings"
+)
+
+// code39Patterns mark the wide elements of each Code 39 character, bars and
+// spaces alternating from a bar. The asterisk starts and stops a barcode.
+var code39Patterns = map[rune]string{
+	'0': "000110100", '1': "100100001", '2': "001100001", '3': "101100000", '4': "000110001",
+	'5': "100110000", '6': "001110000", '7': "000100101", '8': "100100100", '9': "001100100",
+	'A': "100001001", 'B': "001001001", 'C': "101001000", 'D': "000011001", 'E': "100011000",
+	'F': "001011000", 'G': "000001101", 'H': "100001100", 'I': "001001100", 'J': "000011100",
+	'K': "100000011", 'L': "001000011", 'M': "101000010", 'N': "000010011", 'O': "100010010",
+	'P': "001010010", 'Q': "000000111", 'R': "100000110", 'S': "001000110", 'T': "000010110",
+	'U': "110000001", 'V': "011000001", 'W': "111000000", 'X': "010010001", 'Y': "110010000",
+	'Z': "011010000", '-': "010000101", '.': "110000100", ' ': "011000100", '$': "010101000",
+	'/': "010100010", '+': "010001010", '%': "000101010", '*': "010010100",
+}
+
+// code39Wide is the width of a wide element in narrow ones.
+const code39Wide = 3
+
+// encodeCode39 encodes digits, upper-case letters and -. $/+% in Code 39,
+// without a check character. Lower-case letters are upper-cased.
+func encodeCode39(data string) (Barcode, error) {
+	data = strings.ToUpper(data)
+	if data == "" {
+		return Barcode{}, fmt.Errorf("barcode is empty")
+	}
+	for _, c := range data {
+		if _, ok := code39Patterns[c]; !ok || c == '*' {
+			return Barcode{}, fmt.Errorf("code 39 cannot encode %q", c)
+		}
+	}
+
+	var widths []int
+	for i, c := range "*" + data + "*" {
+		if i > 0 {
+			// narrow gap between characters
+			widths = append(widths, 1)
+		}
+		for _, wide := range code39Patterns[c] {
+			if wide == '1' {
+				widths = append(widths, code39Wide)
+			} else {
+				widths = append(widths, 1)
+			}
+		}
+	}
+	return Barcode{Symbology: Code39, Data: data, Widths: widths}, nil
+}
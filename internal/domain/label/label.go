@@ -0,0 +1,148 @@
+package label
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Drawing is a label made of black rectangles and lines of text, measured in
+// points from its top left corner.
+type Drawing struct {
+	Width  float64
+	Height float64
+	Rects  []Rect
+	Texts  []Text
+}
+
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// Text is a line of monospaced text centered on X, with its baseline at Y.
+// It holds printable ASCII only, so that every output format can draw it.
+type Text struct {
+	X, Y  float64
+	Size  float64
+	Value string
+}
+
+const (
+	// charAdvance is the width of a character in ems, the one of Courier.
+	charAdvance = 0.6
+	lineHeight  = 1.2
+	// minModuleWidth is the narrowest bar printed, 5 mil: narrower bars
+	// blur on common label printers.
+	minModuleWidth = 0.36
+	// quietZone is the blank margin, in modules, on either side of a barcode.
+	quietZone      = 10
+	minTextSize    = 4.0
+	maxSpineText   = 14.0
+	maxCaptionText = 9.0
+)
+
+// asciiText folds accented letters to their base letter and replaces what is
+// left outside printable ASCII with '?'.
+func asciiText(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// fitText shortens a line of text to the width available, with an ellipsis.
+func fitText(s string, width, size float64) string {
+	max := int(width / (charAdvance * size))
+	if len(s) <= max {
+		return s
+	}
+	if max <= 3 {
+		return ""
+	}
+	return strings.TrimSpace(s[:max-3]) + "..."
+}
+
+func (d *Drawing) addText(x, baseline, size float64, value string) {
+	if value == "" {
+		return
+	}
+	d.Texts = append(d.Texts, Text{X: x, Y: baseline, Size: size, Value: value})
+}
+
+// BarcodeLabel draws a barcode with its data printed underneath and, when
+// given, a heading above it such as the title of the book.
+func BarcodeLabel(width, height float64, code Barcode, heading string) (Drawing, error) {
+	d := Drawing{Width: width, Height: height}
+	pad := math.Min(width, height) * 0.06
+	size := math.Max(minTextSize, math.Min(height*0.14, maxCaptionText))
+
+	top := pad
+	if heading = fitText(asciiText(heading), width-2*pad, size); heading != "" {
+		d.addText(width/2, top+size*0.75, size, heading)
+		top += size * lineHeight
+	}
+	bottom := height - pad - size*lineHeight
+	if bottom-top < height*0.3 {
+		return Drawing{}, fmt.Errorf("a %.0fx%.0fpt label is too small for a barcode", width, height)
+	}
+
+	modules := float64(code.Modules() + 2*quietZone)
+	module := (width - 2*pad) / modules
+	if module < minModuleWidth {
+		return Drawing{}, fmt.Errorf("%s barcode %q is too wide for a %.0fpt label", code.Symbology, code.Data, width)
+	}
+
+	x := (width - float64(code.Modules())*module) / 2
+	for i, w := range code.Widths {
+		if i%2 == 0 {
+			d.Rects = append(d.Rects, Rect{X: x, Y: top, W: float64(w) * module, H: bottom - top})
+		}
+		x += float64(w) * module
+	}
+	d.addText(width/2, height-pad-size*0.1, size, asciiText(code.Data))
+	return d, nil
+}
+
+// SpineLabel draws the lines of a call number centered on the label, as
+// large as the label allows.
+func SpineLabel(width, height float64, lines []string) (Drawing, error) {
+	if len(lines) == 0 {
+		return Drawing{}, fmt.Errorf("nothing to print on a spine label")
+	}
+	d := Drawing{Width: width, Height: height}
+	pad := math.Min(width, height) * 0.08
+
+	folded := make([]string, len(lines))
+	longest := 0
+	for i, line := range lines {
+		folded[i] = asciiText(line)
+		if len(folded[i]) > longest {
+			longest = len(folded[i])
+		}
+	}
+	size := math.Min((height-2*pad)/(lineHeight*float64(len(lines))), maxSpineText)
+	if longest > 0 {
+		size = math.Min(size, (width-2*pad)/(charAdvance*float64(longest)))
+	}
+	if size < minTextSize {
+		return Drawing{}, fmt.Errorf("call number %q does not fit a %.0fx%.0fpt label", strings.Join(lines, " "), width, height)
+	}
+
+	top := (height - size*lineHeight*float64(len(lines))) / 2
+	for i, line := range folded {
+		d.addText(width/2, top+size*lineHeight*float64(i)+size*0.95, size, line)
+	}
+	return d, nil
+}
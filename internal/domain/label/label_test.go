@@ -0,0 +1,226 @@
+package label
+
+import (
+	"bytes"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode128Patterns(t *testing.T) {
+	seen := map[string]bool{}
+	for v, p := range code128Patterns {
+		sum, bars := 0, 0
+		for i, c := range p {
+			sum += int(c - '0')
+			if i%2 == 0 {
+				bars += int(c - '0')
+			}
+		}
+		want := 11
+		if v == code128Stop {
+			want = 13
+		}
+		assert.Equal(t, want, sum, "symbol %d", v)
+		// the bars of every symbol add up to an even number of modules
+		assert.Zero(t, bars%2, "symbol %d", v)
+		assert.False(t, seen[p], "symbol %d", v)
+		seen[p] = true
+	}
+}
+
+// decodeCode128 reads back the symbol values of a barcode.
+func decodeCode128(t *testing.T, b Barcode) []int {
+	index := map[string]int{}
+	for v, p := range code128Patterns {
+		index[p] = v
+	}
+	var values []int
+	for i := 0; i < len(b.Widths); {
+		n := 6
+		if len(b.Widths)-i == 7 {
+			n = 7
+		}
+		var p strings.Builder
+		for _, w := range b.Widths[i : i+n] {
+			p.WriteString(strconv.Itoa(w))
+		}
+		v, ok := index[p.String()]
+		require.True(t, ok, "pattern %s", p.String())
+		values = append(values, v)
+		i += n
+	}
+	return values
+}
+
+func TestEncodeCode128(t *testing.T) {
+	b, err := Encode(Code128, "PJJ123C")
+	require.NoError(t, err)
+	assert.Equal(t, []int{code128StartB, 48, 42, 42, 17, 18, 19, 35, 55, code128Stop}, decodeCode128(t, b))
+
+	// a library barcode is encoded two digits a symbol
+	b, err = Encode(Code128, "30000000000012")
+	require.NoError(t, err)
+	values := decodeCode128(t, b)
+	assert.Equal(t, []int{code128StartC, 30, 0, 0, 0, 0, 0, 12}, values[:8])
+	assert.Equal(t, 11*9+13, b.Modules())
+
+	// a long run of digits inside text switches code set
+	b, err = Encode(Code128, "AB1234567")
+	require.NoError(t, err)
+	assert.Equal(t, []int{code128StartB, 33, 34, 17, code128CodeC, 23, 45, 67}, decodeCode128(t, b)[:8])
+
+	_, err = Encode(Code128, "")
+	assert.Error(t, err)
+	_, err = Encode(Code128, "café")
+	assert.Error(t, err)
+}
+
+func TestEncodeCode39(t *testing.T) {
+	for c, p := range code39Patterns {
+		assert.Equal(t, 3, strings.Count(p, "1"), "character %q", c)
+	}
+
+	b, err := Encode(Code39, "ab-12")
+	require.NoError(t, err)
+	assert.Equal(t, "AB-12", b.Data)
+	// 7 characters with the asterisks, 6 narrow and 3 wide elements each,
+	// and 6 gaps between them
+	assert.Equal(t, 7*(6+3*code39Wide)+6, b.Modules())
+
+	_, err = Encode(Code39, "A*B")
+	assert.Error(t, err)
+	_, err = Encode(Code39, "A_B")
+	assert.Error(t, err)
+}
+
+func TestParseSymbology(t *testing.T) {
+	s, err := ParseSymbology("")
+	require.NoError(t, err)
+	assert.Equal(t, Code128, s)
+	s, err = ParseSymbology("Code39")
+	require.NoError(t, err)
+	assert.Equal(t, Code39, s)
+	_, err = ParseSymbology("qr")
+	assert.Error(t, err)
+}
+
+func TestBarcodeLabel(t *testing.T) {
+	stock, err := LookupStock("")
+	require.NoError(t, err)
+	b, err := Encode(Code128, "30000000000012")
+	require.NoError(t, err)
+
+	d, err := BarcodeLabel(stock.LabelWidth, stock.LabelHeight, b, "Cien años de soledad, a very long title indeed")
+	require.NoError(t, err)
+	require.Len(t, d.Texts, 2)
+	assert.True(t, strings.HasPrefix(d.Texts[0].Value, "Cien anos de soledad"))
+	assert.True(t, strings.HasSuffix(d.Texts[0].Value, "..."))
+	assert.Equal(t, "30000000000012", d.Texts[1].Value)
+	assert.Len(t, d.Rects, (len(b.Widths)+1)/2)
+	for _, r := range d.Rects {
+		assert.True(t, r.X >= 0 && r.X+r.W <= d.Width)
+	}
+
+	// code 39 is wider than code 128 for the same data
+	small, err := LookupStock("avery-l7651")
+	require.NoError(t, err)
+	b, err = Encode(Code128, "30000000000012345")
+	require.NoError(t, err)
+	_, err = BarcodeLabel(small.LabelWidth, small.LabelHeight, b, "")
+	assert.NoError(t, err)
+	b, err = Encode(Code39, "30000000000012345")
+	require.NoError(t, err)
+	_, err = BarcodeLabel(small.LabelWidth, small.LabelHeight, b, "")
+	assert.Error(t, err)
+}
+
+func TestSpineLabel(t *testing.T) {
+	d, err := SpineLabel(126, 36, []string{"J", "823.912", "ORW"})
+	require.NoError(t, err)
+	require.Len(t, d.Texts, 3)
+	assert.True(t, d.Texts[0].Y < d.Texts[1].Y)
+	assert.LessOrEqual(t, d.Texts[0].Y+d.Texts[0].Size*0.3, 36.0)
+
+	_, err = SpineLabel(126, 36, nil)
+	assert.Error(t, err)
+	_, err = SpineLabel(20, 10, []string{"QA", "76.73", ".G63", "D66", "2016"})
+	assert.Error(t, err)
+}
+
+func TestDrawing_Render(t *testing.T) {
+	b, err := Encode(Code128, "30000000000012")
+	require.NoError(t, err)
+	d, err := BarcodeLabel(189, 72, b, "Don Quixote")
+	require.NoError(t, err)
+
+	svg := string(d.SVG())
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="189pt" height="72pt"`))
+	assert.Equal(t, len(d.Rects)+1, strings.Count(svg, "<rect"))
+	assert.Contains(t, svg, ">Don Quixote</text>")
+
+	data, err := d.PNG(300)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 788, img.Bounds().Dx())
+	assert.Equal(t, 300, img.Bounds().Dy())
+
+	_, err = d.PNG(0)
+	assert.Error(t, err)
+}
+
+func TestSheets(t *testing.T) {
+	stock, err := LookupStock("avery-5160")
+	require.NoError(t, err)
+	b, err := Encode(Code128, "30000000000012")
+	require.NoError(t, err)
+	d, err := BarcodeLabel(stock.LabelWidth, stock.LabelHeight, b, "Don Quixote (2nd ed.)")
+	require.NoError(t, err)
+
+	labels := make([]Drawing, 31)
+	for i := range labels {
+		labels[i] = d
+	}
+	// two labels already used: the 31 labels spill onto a second sheet
+	pdf, err := Sheets(stock, labels, 2)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.Contains(t, string(pdf), "/Count 2")
+
+	// the cross-reference table points at each object
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	require.NotNil(t, m)
+	xref, _ := strconv.Atoi(string(m[1]))
+	require.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n")))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	require.Len(t, entries, 7)
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(strconv.Itoa(i+1)+" 0 obj")), "object %d", i+1)
+	}
+
+	_, err = Sheets(stock, labels, stock.PerSheet())
+	assert.Error(t, err)
+	_, err = Sheets(stock, nil, 0)
+	assert.Error(t, err)
+	small, err := LookupStock("avery-5167")
+	require.NoError(t, err)
+	_, err = Sheets(small, labels, 0)
+	assert.Error(t, err)
+}
+
+func TestStocks(t *testing.T) {
+	for _, s := range Stocks() {
+		x, y := s.position(s.PerSheet() - 1)
+		assert.LessOrEqual(t, x+s.LabelWidth, s.PageWidth, s.Name)
+		assert.LessOrEqual(t, y+s.LabelHeight, s.PageHeight+0.01, s.Name)
+	}
+	_, err := LookupStock("avery-0000")
+	assert.Error(t, err)
+}
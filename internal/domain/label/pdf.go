@@ -0,0 +1,110 @@
+package label
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// Sheets lays labels out on sheets of a stock as a PDF document, a page a
+// sheet, across rows. The first skip positions are left blank to use up a
+// sheet already partly used.
+func Sheets(stock Stock, labels []Drawing, skip int) ([]byte, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no labels to lay out")
+	}
+	if skip < 0 || skip >= stock.PerSheet() {
+		return nil, fmt.Errorf("%d positions to skip on a sheet of %d labels", skip, stock.PerSheet())
+	}
+	for _, l := range labels {
+		if l.Width > stock.LabelWidth+0.01 || l.Height > stock.LabelHeight+0.01 {
+			return nil, fmt.Errorf("a %.0fx%.0fpt label does not fit stock %s", l.Width, l.Height, stock.Name)
+		}
+	}
+
+	var pages []*bytes.Buffer
+	for i, l := range labels {
+		slot := skip + i
+		if slot%stock.PerSheet() == 0 || len(pages) == 0 {
+			pages = append(pages, &bytes.Buffer{})
+		}
+		x, y := stock.position(slot % stock.PerSheet())
+		writeLabel(pages[len(pages)-1], stock.PageHeight, x, y, l)
+	}
+
+	// objects 1 to 3 are the catalog, the page tree and the font; each page
+	// is followed by its content stream
+	var doc pdfDocument
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	doc.add("<< /Type /Catalog /Pages 2 0 R >>")
+	doc.add(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	doc.add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		doc.add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			num(stock.PageWidth), num(stock.PageHeight), 5+2*i))
+		stream, err := deflate(content.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		doc.add(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+	return doc.bytes(), nil
+}
+
+// writeLabel draws a label whose top left corner is at x, y from the top
+// left of the page. PDF measures from the bottom left.
+func writeLabel(w *bytes.Buffer, pageHeight, x, y float64, l Drawing) {
+	for _, r := range l.Rects {
+		fmt.Fprintf(w, "%s %s %s %s re f\n", num(x+r.X), num(pageHeight-y-r.Y-r.H), num(r.W), num(r.H))
+	}
+	for _, t := range l.Texts {
+		left := x + t.X - charAdvance*t.Size*float64(len(t.Value))/2
+		fmt.Fprintf(w, "BT /F1 %s Tf %s %s Td (%s) Tj ET\n", num(t.Size), num(left), num(pageHeight-y-t.Y), pdfEscaper.Replace(t.Value))
+	}
+}
+
+var pdfEscaper = strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+
+func deflate(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// pdfDocument collects the objects of a PDF file, numbered from 1.
+type pdfDocument struct {
+	objects []string
+}
+
+func (d *pdfDocument) add(object string) {
+	d.objects = append(d.objects, object)
+}
+
+// bytes writes the objects with the cross-reference table locating them.
+func (d *pdfDocument) bytes() []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(d.objects))
+	for i, object := range d.objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, xref)
+	return b.Bytes()
+}
@@ -0,0 +1,60 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// DefaultDPI is the resolution of common thermal label printers.
+const DefaultDPI = 300
+
+const maxDPI = 1200
+
+// PNG renders the label in black and white at a resolution in dots per inch.
+// Text is drawn with a built-in pixel font.
+func (d Drawing) PNG(dpi int) ([]byte, error) {
+	if dpi <= 0 || dpi > maxDPI {
+		return nil, fmt.Errorf("resolution %d dpi is out of range 1 to %d", dpi, maxDPI)
+	}
+	scale := float64(dpi) / 72
+
+	img := image.NewGray(image.Rect(0, 0, int(math.Ceil(d.Width*scale)), int(math.Ceil(d.Height*scale))))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	fill := func(r Rect) {
+		px := image.Rect(
+			int(math.Round(r.X*scale)), int(math.Round(r.Y*scale)),
+			int(math.Round((r.X+r.W)*scale)), int(math.Round((r.Y+r.H)*scale)),
+		)
+		draw.Draw(img, px, image.Black, image.Point{}, draw.Src)
+	}
+	for _, r := range d.Rects {
+		fill(r)
+	}
+	for _, t := range d.Texts {
+		// a glyph cell is 6x7 units, its advance is 0.6 em
+		unit := t.Size / 10
+		x := t.X - charAdvance*t.Size*float64(len(t.Value))/2
+		for _, c := range t.Value {
+			for row, bits := range glyph(c) {
+				for col, bit := range bits {
+					if bit == '1' {
+						fill(Rect{X: x + float64(col)*unit, Y: t.Y - float64(7-row)*unit, W: unit, H: unit})
+					}
+				}
+			}
+			x += charAdvance * t.Size
+		}
+	}
+
+	var b bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
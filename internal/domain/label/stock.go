@@ -0,0 +1,103 @@
+package label
+
+import (
+	"fmt"
+	"sort"
+)
+
+// mm converts millimetres to points.
+func mm(v float64) float64 {
+	return v * 72 / 25.4
+}
+
+// Stock is a sheet of label stock: a grid of equal labels on a page, in points.
+type Stock struct {
+	Name        string
+	Description string
+	PageWidth   float64
+	PageHeight  float64
+	Columns     int
+	Rows        int
+	LabelWidth  float64
+	LabelHeight float64
+	// Left and Top place the first label, the pitches separate the labels of
+	// a row and of a column.
+	Left            float64
+	Top             float64
+	HorizontalPitch float64
+	VerticalPitch   float64
+}
+
+// DefaultStock is the stock labels are drawn for when none is asked for.
+const DefaultStock = "avery-5160"
+
+var stocks = map[string]Stock{
+	"avery-5160": {
+		Description: "Letter, 3x10 labels of 2.625x1 in",
+		PageWidth:   612, PageHeight: 792,
+		Columns: 3, Rows: 10,
+		LabelWidth: 189, LabelHeight: 72,
+		Left: 13.5, Top: 36,
+		HorizontalPitch: 198, VerticalPitch: 72,
+	},
+	"avery-5167": {
+		Description: "Letter, 4x20 labels of 1.75x0.5 in",
+		PageWidth:   612, PageHeight: 792,
+		Columns: 4, Rows: 20,
+		LabelWidth: 126, LabelHeight: 36,
+		Left: 21.6, Top: 36,
+		HorizontalPitch: 147.6, VerticalPitch: 36,
+	},
+	"avery-l7160": {
+		Description: "A4, 3x7 labels of 63.5x38.1 mm",
+		PageWidth:   mm(210), PageHeight: mm(297),
+		Columns: 3, Rows: 7,
+		LabelWidth: mm(63.5), LabelHeight: mm(38.1),
+		Left: mm(7.2), Top: mm(15.15),
+		HorizontalPitch: mm(66.04), VerticalPitch: mm(38.1),
+	},
+	"avery-l7651": {
+		Description: "A4, 5x13 labels of 38.1x21.2 mm",
+		PageWidth:   mm(210), PageHeight: mm(297),
+		Columns: 5, Rows: 13,
+		LabelWidth: mm(38.1), LabelHeight: mm(21.2),
+		Left: mm(4.75), Top: mm(10.7),
+		HorizontalPitch: mm(40.64), VerticalPitch: mm(21.2),
+	},
+}
+
+// LookupStock returns a stock by name, or the default stock for an empty name.
+func LookupStock(name string) (Stock, error) {
+	if name == "" {
+		name = DefaultStock
+	}
+	s, ok := stocks[name]
+	if !ok {
+		return Stock{}, fmt.Errorf("unknown label stock %q", name)
+	}
+	s.Name = name
+	return s, nil
+}
+
+// Stocks lists the known label stocks by name.
+func Stocks() []Stock {
+	list := make([]Stock, 0, len(stocks))
+	for name := range stocks {
+		s, _ := LookupStock(name)
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// PerSheet is the number of labels on a sheet.
+func (s Stock) PerSheet() int {
+	return s.Columns * s.Rows
+}
+
+// position returns the top left corner of the label at an index of a sheet,
+// counted across rows.
+func (s Stock) position(i int) (x, y float64) {
+	col, row := i%s.Columns, i/s.Columns
+	return s.Left + float64(col)*s.HorizontalPitch, s.Top + float64(row)*s.VerticalPitch
+}
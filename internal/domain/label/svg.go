@@ -0,0 +1,33 @@
+package label
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// SVG renders the label at its size in points.
+func (d Drawing) SVG() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%spt" height="%spt" viewBox="0 0 %s %s">`,
+		num(d.Width), num(d.Height), num(d.Width), num(d.Height))
+	fmt.Fprintf(&b, `<rect width="%s" height="%s" fill="#fff"/>`, num(d.Width), num(d.Height))
+	for _, r := range d.Rects {
+		fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s"/>`, num(r.X), num(r.Y), num(r.W), num(r.H))
+	}
+	for _, t := range d.Texts {
+		fmt.Fprintf(&b, `<text x="%s" y="%s" font-family="Courier New, Courier, monospace" font-size="%s" text-anchor="middle" xml:space="preserve">`,
+			num(t.X), num(t.Y), num(t.Size))
+		_ = xml.EscapeText(&b, []byte(t.Value))
+		b.WriteString(`</text>`)
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// num writes a length to a hundredth of a point.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,227 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Reader decodes records in the MARC21 transmission format.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record, or io.EOF when the input is exhausted.
+func (d *Reader) Read() (*Record, error) {
+	// skip line breaks some tools put between records
+	for {
+		b, err := d.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != '\n' && b[0] != '\r' {
+			break
+		}
+		_, _ = d.r.ReadByte()
+	}
+
+	head := make([]byte, 5)
+	if _, err := io.ReadFull(d.r, head); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated record length")
+		}
+		return nil, err
+	}
+	length, ok := parseNumber(head)
+	if !ok || length < leaderLength+2 {
+		return nil, fmt.Errorf("invalid record length %q", head)
+	}
+
+	data := make([]byte, length)
+	copy(data, head)
+	if _, err := io.ReadFull(d.r, data[5:]); err != nil {
+		return nil, fmt.Errorf("truncated record: %w", err)
+	}
+
+	return decodeRecord(data)
+}
+
+func decodeRecord(data []byte) (*Record, error) {
+	if data[len(data)-1] != recordTerminator {
+		return nil, fmt.Errorf("missing record terminator")
+	}
+
+	leader := data[:leaderLength]
+	base, ok := parseNumber(leader[12:17])
+	if !ok || base <= leaderLength || base > len(data) {
+		return nil, fmt.Errorf("invalid base address of data %q", leader[12:17])
+	}
+
+	directory := data[leaderLength : base-1]
+	if len(directory)%directoryEntrySize != 0 {
+		return nil, fmt.Errorf("invalid directory length %d", len(directory))
+	}
+
+	record := &Record{Leader: string(leader)}
+	for i := 0; i < len(directory); i += directoryEntrySize {
+		entry := directory[i : i+directoryEntrySize]
+		tag := string(entry[0:3])
+		fieldLength, ok1 := parseNumber(entry[3:7])
+		start, ok2 := parseNumber(entry[7:12])
+		if !ok1 || !ok2 || start < 0 {
+			return nil, fmt.Errorf("invalid directory entry %q", entry)
+		}
+		if base+start+fieldLength > len(data) || fieldLength < 1 {
+			return nil, fmt.Errorf("field %s exceeds record length", tag)
+		}
+
+		// drop the field terminator
+		field := data[base+start : base+start+fieldLength-1]
+		if isControlTag(tag) {
+			record.ControlFields = append(record.ControlFields, ControlField{Tag: tag, Value: string(field)})
+			continue
+		}
+
+		df, err := decodeDataField(tag, field)
+		if err != nil {
+			return nil, err
+		}
+		record.DataFields = append(record.DataFields, df)
+	}
+
+	return record, nil
+}
+
+// parseNumber reads the unsigned decimal numbers of the leader and directory.
+// Unlike strconv.Atoi it rejects signs, so an offset cannot point before the
+// data.
+func parseNumber(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
+
+func decodeDataField(tag string, field []byte) (DataField, error) {
+	if len(field) < 2 {
+		return DataField{}, fmt.Errorf("field %s is missing indicators", tag)
+	}
+
+	df := DataField{Tag: tag, Ind1: field[0], Ind2: field[1]}
+	for _, chunk := range bytes.Split(field[2:], []byte{subfieldDelimiter}) {
+		if len(chunk) == 0 {
+			continue
+		}
+		df.Subfields = append(df.Subfields, Subfield{Code: chunk[0], Value: string(chunk[1:])})
+	}
+	return df, nil
+}
+
+// Writer encodes records in the MARC21 transmission format.
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (e *Writer) Write(r *Record) error {
+	data, err := encodeRecord(r)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+// Close flushes buffered output. It does not close the underlying writer.
+func (e *Writer) Close() error {
+	return e.w.Flush()
+}
+
+func encodeRecord(r *Record) ([]byte, error) {
+	var directory, fields bytes.Buffer
+
+	addField := func(tag string, data []byte) error {
+		if len(tag) != 3 {
+			return fmt.Errorf("invalid tag %q", tag)
+		}
+		length := len(data) + 1
+		if length > 9999 || fields.Len() > 99999 {
+			return fmt.Errorf("field %s is too long", tag)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", tag, length, fields.Len())
+		fields.Write(data)
+		fields.WriteByte(fieldTerminator)
+		return nil
+	}
+
+	for _, f := range r.ControlFields {
+		if err := addField(f.Tag, []byte(f.Value)); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range r.DataFields {
+		var buf bytes.Buffer
+		buf.WriteByte(indicator(f.Ind1))
+		buf.WriteByte(indicator(f.Ind2))
+		for _, s := range f.Subfields {
+			buf.WriteByte(subfieldDelimiter)
+			buf.WriteByte(s.Code)
+			buf.WriteString(s.Value)
+		}
+		if err := addField(f.Tag, buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	directory.WriteByte(fieldTerminator)
+
+	base := leaderLength + directory.Len()
+	length := base + fields.Len() + 1
+	if length > 99999 {
+		return nil, fmt.Errorf("record is too long")
+	}
+
+	leader := []byte(normalizeLeader(r.Leader))
+	copy(leader[0:5], fmt.Sprintf("%05d", length))
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+
+	out := make([]byte, 0, length)
+	out = append(out, leader...)
+	out = append(out, directory.Bytes()...)
+	out = append(out, fields.Bytes()...)
+	out = append(out, recordTerminator)
+	return out, nil
+}
+
+// normalizeLeader pads a leader to its fixed length and sets the positions
+// this package always writes: UTF-8 encoding, two indicators, one-character
+// subfield codes and the standard entry map.
+func normalizeLeader(leader string) string {
+	b := []byte(fmt.Sprintf("%-24.24s", leader))
+	b[9] = 'a'
+	b[10] = '2'
+	b[11] = '2'
+	copy(b[20:24], "4500")
+	return string(b)
+}
+
+func indicator(b byte) byte {
+	if b == 0 {
+		return ' '
+	}
+	return b
+}
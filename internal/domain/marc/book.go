@@ -0,0 +1,238 @@
+package marc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+)
+
+var yearPattern = regexp.MustCompile(`\d{4}`)
+
+// ToCatalogRecord maps a bibliographic record to a book, its work and its
+// copies. Each 852 holdings field becomes one copy.
+func ToCatalogRecord(r *Record) (model.CatalogRecord, error) {
+	var rec model.CatalogRecord
+
+	isbn, err := recordISBN(r)
+	if err != nil {
+		return rec, err
+	}
+
+	title := recordTitle(r)
+	if title == "" {
+		return rec, fmt.Errorf("record has no title (245$a)")
+	}
+
+	year, ok := recordYear(r)
+	if !ok {
+		return rec, fmt.Errorf("record has no publication year (264$c, 260$c or 008/07-10)")
+	}
+
+	author := recordAuthor(r)
+	book := model.NewBook(0, title, author, isbn, time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if f, ok := r.Field("250"); ok {
+		book.Edition = trimPunctuation(f.Subfield('a'))
+	}
+	book.Language = recordLanguage(r)
+
+	cn, err := recordCallNumber(r)
+	if err != nil {
+		return rec, err
+	}
+	book.CallNumber = cn
+
+	rec.Book = book
+	rec.WorkTitle = title
+	for _, tag := range []string{"240", "130"} {
+		if f, ok := r.Field(tag); ok && f.Subfield('a') != "" {
+			rec.WorkTitle = trimPunctuation(f.Subfield('a'))
+			break
+		}
+	}
+
+	for _, f := range r.Fields("852") {
+		c := model.BookCopies{Barcode: strings.TrimSpace(f.Subfield('p')), Status: model.InLibrary}
+		if raw := strings.TrimSpace(strings.Join(f.SubfieldValues("hi"), " ")); raw != "" && cn != nil {
+			copyCN, err := model.ParseCallNumber(cn.Scheme, raw)
+			if err != nil {
+				return rec, fmt.Errorf("invalid copy call number (852$h$i): %w", err)
+			}
+			if copyCN.SortKey != cn.SortKey {
+				c.CallNumber = &copyCN
+			}
+		}
+		rec.Copies = append(rec.Copies, c)
+	}
+
+	return rec, nil
+}
+
+// FromCatalogRecord maps a book, its work and its copies to a bibliographic record.
+func FromCatalogRecord(rec model.CatalogRecord) *Record {
+	book := rec.Book
+	r := NewRecord()
+
+	r.AddControlField("001", strconv.Itoa(book.ID))
+	r.AddControlField("008", fixedLengthData(book))
+
+	r.AddDataField("020", ' ', ' ', Subfield{Code: 'a', Value: book.ISBN})
+	if book.Language != "" {
+		r.AddDataField("041", '0', ' ', Subfield{Code: 'a', Value: book.Language})
+	}
+	if cn := book.CallNumber; cn != nil {
+		switch cn.Scheme {
+		case model.ClassificationLC:
+			r.AddDataField("050", ' ', '4', Subfield{Code: 'a', Value: cn.Raw})
+		case model.ClassificationDewey:
+			r.AddDataField("082", '0', '4', Subfield{Code: 'a', Value: cn.Raw})
+		}
+	}
+	r.AddDataField("100", '1', ' ', Subfield{Code: 'a', Value: book.Author})
+	if rec.WorkTitle != "" && rec.WorkTitle != book.Title {
+		r.AddDataField("240", '1', '0', Subfield{Code: 'a', Value: rec.WorkTitle})
+	}
+	r.AddDataField("245", '1', '0', Subfield{Code: 'a', Value: book.Title})
+	r.AddDataField("250", ' ', ' ', Subfield{Code: 'a', Value: book.Edition})
+	r.AddDataField("264", ' ', '1', Subfield{Code: 'c', Value: strconv.Itoa(book.PublishedYear.Year())})
+
+	for _, c := range rec.Copies {
+		callNumber := ""
+		if c.CallNumber != nil {
+			callNumber = c.CallNumber.Raw
+		} else if book.CallNumber != nil {
+			callNumber = book.CallNumber.Raw
+		}
+		// an 852 field without subfields would be dropped, so always name the copy
+		subfields := []Subfield{{Code: 'h', Value: callNumber}}
+		if c.Barcode != "" {
+			subfields = append(subfields, Subfield{Code: 'p', Value: c.Barcode})
+		}
+		subfields = append(subfields, Subfield{Code: 'x', Value: fmt.Sprintf("copy %d", c.ID)})
+		r.AddDataField("852", ' ', ' ', subfields...)
+	}
+
+	return r
+}
+
+func recordISBN(r *Record) (string, error) {
+	var lastErr error
+	for _, f := range r.Fields("020") {
+		raw := f.Subfield('a')
+		if raw == "" {
+			continue
+		}
+		isbn, err := model.NormalizeISBN(raw)
+		if err == nil {
+			return isbn, nil
+		}
+		lastErr = err
+	}
+	if lastErr != nil {
+		return "", lastErr
+	}
+	return "", fmt.Errorf("record has no ISBN (020$a)")
+}
+
+func recordTitle(r *Record) string {
+	f, ok := r.Field("245")
+	if !ok {
+		return ""
+	}
+	parts := []string{}
+	for _, v := range f.SubfieldValues("ab") {
+		if v = trimPunctuation(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+func recordAuthor(r *Record) string {
+	for _, tag := range []string{"100", "110", "111", "700"} {
+		if f, ok := r.Field(tag); ok && f.Subfield('a') != "" {
+			return trimPunctuation(f.Subfield('a'))
+		}
+	}
+	return ""
+}
+
+func recordYear(r *Record) (int, bool) {
+	var candidates []string
+	for _, f := range r.Fields("264") {
+		if f.Ind2 == '1' {
+			candidates = append(candidates, f.Subfield('c'))
+		}
+	}
+	for _, f := range r.Fields("260") {
+		candidates = append(candidates, f.Subfield('c'))
+	}
+	if fixed := r.ControlField("008"); len(fixed) >= 11 {
+		candidates = append(candidates, fixed[7:11])
+	}
+
+	for _, c := range candidates {
+		if m := yearPattern.FindString(c); m != "" {
+			year, _ := strconv.Atoi(m)
+			return year, true
+		}
+	}
+	return 0, false
+}
+
+func recordLanguage(r *Record) string {
+	if fixed := r.ControlField("008"); len(fixed) >= 38 {
+		lang := strings.TrimSpace(fixed[35:38])
+		if lang != "" && !strings.Contains(lang, "|") {
+			return lang
+		}
+	}
+	if f, ok := r.Field("041"); ok {
+		return f.Subfield('a')
+	}
+	return ""
+}
+
+// recordCallNumber prefers the Dewey number in 082 over the LC number in 050.
+// Dewey numbers often carry prime marks ("823/.914") that are not part of the
+// shelf address.
+func recordCallNumber(r *Record) (*model.CallNumber, error) {
+	if f, ok := r.Field("082"); ok && f.Subfield('a') != "" {
+		raw := strings.NewReplacer("/", "", "'", "").Replace(strings.Join(f.SubfieldValues("ab"), " "))
+		cn, err := model.ParseCallNumber(model.ClassificationDewey, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Dewey number (082): %w", err)
+		}
+		return &cn, nil
+	}
+	if f, ok := r.Field("050"); ok && f.Subfield('a') != "" {
+		cn, err := model.ParseCallNumber(model.ClassificationLC, strings.Join(f.SubfieldValues("ab"), " "))
+		if err != nil {
+			return nil, fmt.Errorf("invalid LC number (050): %w", err)
+		}
+		return &cn, nil
+	}
+	return nil, nil
+}
+
+// fixedLengthData builds the 40 character 008 field with the publication
+// date and language filled in.
+func fixedLengthData(book model.Book) string {
+	b := []byte(strings.Repeat(" ", 40))
+	copy(b[0:6], book.CreatedAt.Format("060102"))
+	b[6] = 's'
+	copy(b[7:11], fmt.Sprintf("%04d", book.PublishedYear.Year()))
+	if len(book.Language) == 3 {
+		copy(b[35:38], book.Language)
+	}
+	b[39] = 'd'
+	return string(b)
+}
+
+// trimPunctuation removes the ISBD punctuation catalogers put at the end of subfields.
+func trimPunctuation(s string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), " /:;,."))
+}
@@ -0,0 +1,185 @@
+package marc
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lzzzzl/page-turner-pro/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleRecord() *Record {
+	r := NewRecord()
+	r.AddControlField("001", "42")
+	r.AddControlField("008", "230101s2003    nyu           000 1 eng d")
+	r.AddDataField("020", ' ', ' ', Subfield{Code: 'a', Value: "0060934344 (pbk.)"})
+	r.AddDataField("082", '0', '4', Subfield{Code: 'a', Value: "863/.3"}, Subfield{Code: 'b', Value: "CER"})
+	r.AddDataField("100", '1', ' ', Subfield{Code: 'a', Value: "Cervantes Saavedra, Miguel de,"})
+	r.AddDataField("240", '1', '0', Subfield{Code: 'a', Value: "Don Quijote de la Mancha."})
+	r.AddDataField("245", '1', '0', Subfield{Code: 'a', Value: "Don Quixote /"}, Subfield{Code: 'b', Value: ""})
+	r.AddDataField("250", ' ', ' ', Subfield{Code: 'a', Value: "1st ed."})
+	r.AddDataField("264", ' ', '1', Subfield{Code: 'c', Value: "[2003]"})
+	r.AddDataField("852", ' ', ' ', Subfield{Code: 'h', Value: "863.3 CER"}, Subfield{Code: 'p', Value: "30000000000012"})
+	r.AddDataField("852", ' ', ' ', Subfield{Code: 'h', Value: "863.3 CER"}, Subfield{Code: 'i', Value: "c.2"})
+	return r
+}
+
+func TestBinary_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(sampleRecord()))
+	require.NoError(t, w.Write(sampleRecord()))
+	require.NoError(t, w.Close())
+
+	// the leader carries the record length and base address
+	length := buf.Bytes()[:5]
+	assert.NotEqual(t, "00000", string(length))
+
+	r := NewReader(&buf)
+	for i := 0; i < 2; i++ {
+		record, err := r.Read()
+		require.NoError(t, err)
+		assert.Equal(t, sampleRecord().ControlFields, record.ControlFields)
+		assert.Equal(t, sampleRecord().DataFields, record.DataFields)
+		assert.Equal(t, byte('a'), record.Leader[9])
+	}
+	_, err := r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBinary_Truncated(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(sampleRecord()))
+	require.NoError(t, w.Close())
+
+	_, err := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-10])).Read()
+	assert.Error(t, err)
+
+	_, err = NewReader(strings.NewReader("abcde")).Read()
+	assert.Error(t, err)
+}
+
+func TestBinary_MalformedDirectory(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(sampleRecord()))
+	require.NoError(t, w.Close())
+	record := buf.Bytes()
+
+	// the first directory entry starts after the leader: tag, length, offset
+	for _, offset := range []string{"-9999", "+0000", " 0000"} {
+		data := append([]byte(nil), record...)
+		copy(data[leaderLength+7:leaderLength+12], offset)
+
+		_, err := NewReader(bytes.NewReader(data)).Read()
+		assert.Error(t, err, offset)
+	}
+
+	data := append([]byte(nil), record...)
+	copy(data[leaderLength+3:leaderLength+7], "-001")
+	_, err := NewReader(bytes.NewReader(data)).Read()
+	assert.Error(t, err)
+}
+
+func TestXML_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewXMLWriter(&buf)
+	require.NoError(t, w.Write(sampleRecord()))
+	require.NoError(t, w.Close())
+	assert.Contains(t, buf.String(), XMLNamespace)
+
+	r := NewXMLReader(&buf)
+	record, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, sampleRecord().DataFields, record.DataFields)
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestXML_SingleRecord(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<record xmlns="http://www.loc.gov/MARC21/slim">
+  <leader>00000nam a2200000 i 4500</leader>
+  <controlfield tag="001">7</controlfield>
+  <datafield tag="245" ind1="1" ind2="0"><subfield code="a">Title</subfield></datafield>
+</record>`
+
+	record, err := NewXMLReader(strings.NewReader(doc)).Read()
+	require.NoError(t, err)
+	assert.Equal(t, "7", record.ControlField("001"))
+	f, ok := record.Field("245")
+	require.True(t, ok)
+	assert.Equal(t, "Title", f.Subfield('a'))
+}
+
+func TestToCatalogRecord(t *testing.T) {
+	rec, err := ToCatalogRecord(sampleRecord())
+	require.NoError(t, err)
+
+	assert.Equal(t, "0060934344", rec.Book.ISBN)
+	assert.Equal(t, "Don Quixote", rec.Book.Title)
+	assert.Equal(t, "Cervantes Saavedra, Miguel de", rec.Book.Author)
+	assert.Equal(t, "Don Quijote de la Mancha", rec.WorkTitle)
+	assert.Equal(t, "1st ed", rec.Book.Edition)
+	assert.Equal(t, "eng", rec.Book.Language)
+	assert.Equal(t, 2003, rec.Book.PublishedYear.Year())
+	require.NotNil(t, rec.Book.CallNumber)
+	assert.Equal(t, model.ClassificationDewey, rec.Book.CallNumber.Scheme)
+	assert.Equal(t, "863.3 CER", rec.Book.CallNumber.Raw)
+
+	require.Len(t, rec.Copies, 2)
+	assert.Nil(t, rec.Copies[0].CallNumber)
+	assert.Equal(t, "30000000000012", rec.Copies[0].Barcode)
+	assert.Empty(t, rec.Copies[1].Barcode)
+	require.NotNil(t, rec.Copies[1].CallNumber)
+	assert.Equal(t, "863.3 CER c.2", rec.Copies[1].CallNumber.Raw)
+}
+
+func TestToCatalogRecord_Invalid(t *testing.T) {
+	noISBN := NewRecord()
+	noISBN.AddDataField("245", '1', '0', Subfield{Code: 'a', Value: "Title"})
+	_, err := ToCatalogRecord(noISBN)
+	assert.Error(t, err)
+
+	noYear := NewRecord()
+	noYear.AddDataField("020", ' ', ' ', Subfield{Code: 'a', Value: "9780060934347"})
+	noYear.AddDataField("245", '1', '0', Subfield{Code: 'a', Value: "Title"})
+	_, err = ToCatalogRecord(noYear)
+	assert.Error(t, err)
+}
+
+func TestFromCatalogRecord(t *testing.T) {
+	cn, err := model.ParseCallNumber(model.ClassificationLC, "PQ6323 .A1 2003")
+	require.NoError(t, err)
+
+	book := model.NewBook(1, "Don Quixote", "Cervantes Saavedra, Miguel de", "9780060934347",
+		time.Date(2003, time.January, 1, 0, 0, 0, 0, time.UTC))
+	book.ID = 5
+	book.CallNumber = &cn
+
+	r := FromCatalogRecord(model.CatalogRecord{
+		Book:      book,
+		WorkTitle: "Don Quijote de la Mancha",
+		Copies:    []model.BookCopies{{ID: 9, Barcode: "30000000000012"}},
+	})
+
+	// mapping back yields the same book
+	rec, err := ToCatalogRecord(r)
+	require.NoError(t, err)
+	assert.Equal(t, book.ISBN, rec.Book.ISBN)
+	assert.Equal(t, book.Title, rec.Book.Title)
+	assert.Equal(t, book.Author, rec.Book.Author)
+	assert.Equal(t, 2003, rec.Book.PublishedYear.Year())
+	assert.Equal(t, "Don Quijote de la Mancha", rec.WorkTitle)
+	require.NotNil(t, rec.Book.CallNumber)
+	assert.Equal(t, cn.SortKey, rec.Book.CallNumber.SortKey)
+	require.Len(t, rec.Copies, 1)
+	assert.Equal(t, "30000000000012", rec.Copies[0].Barcode)
+	assert.Equal(t, "5", r.ControlField("001"))
+}
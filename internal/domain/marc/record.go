@@ -0,0 +1,132 @@
+// Package marc reads and writes bibliographic records in the MARC21
+// transmission format (ISO 2709) and in MARCXML.
+package marc
+
+import "strings"
+
+const (
+	leaderLength       = 24
+	directoryEntrySize = 12
+
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+// Record is a single MARC record.
+type Record struct {
+	Leader        string
+	ControlFields []ControlField
+	DataFields    []DataField
+}
+
+// ControlField is a 00X field, which carries data without indicators or subfields.
+type ControlField struct {
+	Tag   string
+	Value string
+}
+
+// DataField is a variable field with two indicators and a list of subfields.
+type DataField struct {
+	Tag       string
+	Ind1      byte
+	Ind2      byte
+	Subfields []Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// RecordReader reads records one at a time and returns io.EOF after the last one.
+type RecordReader interface {
+	Read() (*Record, error)
+}
+
+// RecordWriter writes records and must be closed to flush trailing output.
+type RecordWriter interface {
+	Write(r *Record) error
+	Close() error
+}
+
+// NewRecord returns an empty record with a leader for a monograph encoded in UTF-8.
+func NewRecord() *Record {
+	return &Record{Leader: "00000nam a2200000 i 4500"}
+}
+
+// ControlField returns the value of the first control field with the tag.
+func (r *Record) ControlField(tag string) string {
+	for _, f := range r.ControlFields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Fields returns all data fields with the tag, in record order.
+func (r *Record) Fields(tag string) []DataField {
+	var fields []DataField
+	for _, f := range r.DataFields {
+		if f.Tag == tag {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Field returns the first data field with the tag.
+func (r *Record) Field(tag string) (DataField, bool) {
+	for _, f := range r.DataFields {
+		if f.Tag == tag {
+			return f, true
+		}
+	}
+	return DataField{}, false
+}
+
+// AddControlField appends a control field.
+func (r *Record) AddControlField(tag, value string) {
+	r.ControlFields = append(r.ControlFields, ControlField{Tag: tag, Value: value})
+}
+
+// AddDataField appends a data field, skipping subfields with empty values.
+// Nothing is added when every subfield is empty.
+func (r *Record) AddDataField(tag string, ind1, ind2 byte, subfields ...Subfield) {
+	var kept []Subfield
+	for _, s := range subfields {
+		if s.Value != "" {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	r.DataFields = append(r.DataFields, DataField{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: kept})
+}
+
+// Subfield returns the value of the first subfield with the code.
+func (f DataField) Subfield(code byte) string {
+	for _, s := range f.Subfields {
+		if s.Code == code {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// SubfieldValues returns the values of all subfields with one of the codes, in field order.
+func (f DataField) SubfieldValues(codes string) []string {
+	var values []string
+	for _, s := range f.Subfields {
+		if strings.IndexByte(codes, s.Code) >= 0 {
+			values = append(values, s.Value)
+		}
+	}
+	return values
+}
+
+func isControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
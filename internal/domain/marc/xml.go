@@ -0,0 +1,142 @@
+package marc
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// XMLNamespace is the namespace of MARCXML documents.
+const XMLNamespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// XMLReader decodes records from a MARCXML document. Both a single record and
+// a collection of records are accepted.
+type XMLReader struct {
+	d *xml.Decoder
+}
+
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{d: xml.NewDecoder(r)}
+}
+
+// Read returns the next record, or io.EOF when the document is exhausted.
+func (x *XMLReader) Read() (*Record, error) {
+	for {
+		token, err := x.d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var xr xmlRecord
+		if err := x.d.DecodeElement(&xr, &start); err != nil {
+			return nil, fmt.Errorf("invalid MARCXML record: %w", err)
+		}
+		return xr.toRecord()
+	}
+}
+
+func (xr xmlRecord) toRecord() (*Record, error) {
+	record := &Record{Leader: xr.Leader}
+	for _, f := range xr.ControlFields {
+		record.ControlFields = append(record.ControlFields, ControlField{Tag: f.Tag, Value: f.Value})
+	}
+	for _, f := range xr.DataFields {
+		df := DataField{Tag: f.Tag, Ind1: xmlIndicator(f.Ind1), Ind2: xmlIndicator(f.Ind2)}
+		for _, s := range f.Subfields {
+			if len(s.Code) != 1 {
+				return nil, fmt.Errorf("field %s has invalid subfield code %q", f.Tag, s.Code)
+			}
+			df.Subfields = append(df.Subfields, Subfield{Code: s.Code[0], Value: s.Value})
+		}
+		record.DataFields = append(record.DataFields, df)
+	}
+	return record, nil
+}
+
+func xmlIndicator(s string) byte {
+	if s == "" {
+		return ' '
+	}
+	return s[0]
+}
+
+// XMLWriter encodes records as a MARCXML collection.
+type XMLWriter struct {
+	w       *bufio.Writer
+	e       *xml.Encoder
+	started bool
+}
+
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	bw := bufio.NewWriter(w)
+	return &XMLWriter{w: bw, e: xml.NewEncoder(bw)}
+}
+
+func (x *XMLWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+	_, err := fmt.Fprintf(x.w, "%s<collection xmlns=%q>", xml.Header, XMLNamespace)
+	return err
+}
+
+func (x *XMLWriter) Write(r *Record) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	xr := xmlRecord{Leader: normalizeLeader(r.Leader)}
+	for _, f := range r.ControlFields {
+		xr.ControlFields = append(xr.ControlFields, xmlControlField(f))
+	}
+	for _, f := range r.DataFields {
+		xf := xmlDataField{Tag: f.Tag, Ind1: string(indicator(f.Ind1)), Ind2: string(indicator(f.Ind2))}
+		for _, s := range f.Subfields {
+			xf.Subfields = append(xf.Subfields, xmlSubfield{Code: string(s.Code), Value: s.Value})
+		}
+		xr.DataFields = append(xr.DataFields, xf)
+	}
+	return x.e.Encode(xr)
+}
+
+// Close ends the collection and flushes buffered output. It does not close the underlying writer.
+func (x *XMLWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if _, err := x.w.WriteString("</collection>\n"); err != nil {
+		return err
+	}
+	return x.w.Flush()
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MinPasswordLength is the fewest characters a password may have.
+	MinPasswordLength = 8
+	// MaxPasswordBytes is the most a password may take, as bcrypt ignores
+	// anything beyond.
+	MaxPasswordBytes = 72
+)
+
+// ValidatePassword checks a password is long enough to be worth hashing and
+// short enough to be hashed whole.
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("password is shorter than %d characters", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordBytes {
+		return fmt.Errorf("password is longer than %d bytes", MaxPasswordBytes)
+	}
+	return nil
+}
+
+// NormalizeEmail returns the bare, lower-cased address of an email.
+func NormalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return "", fmt.Errorf("invalid email %q", email)
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+type AccountTokenPurpose string
+
+const (
+	// TokenVerifyEmail confirms the patron owns the address they signed up with.
+	TokenVerifyEmail AccountTokenPurpose = "VerifyEmail"
+	// TokenResetPassword lets a patron who forgot their password set a new one.
+	TokenResetPassword AccountTokenPurpose = "ResetPassword"
+)
+
+// AccountToken is a single-use secret mailed to a patron. Only its hash is
+// kept, so the tokens cannot be read back from the database.
+type AccountToken struct {
+	ID        int
+	UserID    int
+	Purpose   AccountTokenPurpose
+	TokenHash string
+	ExpiresAt time.Time
+	// UsedAt is set once the token is used, or once a newer token of the
+	// same purpose supersedes it.
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewAccountToken draws a token for a patron, valid for ttl from now. The
+// secret is to be mailed; it is not kept.
+func NewAccountToken(userID int, purpose AccountTokenPurpose, now time.Time, ttl time.Duration) (AccountToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return AccountToken{}, "", fmt.Errorf("failed to draw token: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	return AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: HashAccountToken(secret),
+		ExpiresAt: now.Add(ttl),
+	}, secret, nil
+}
+
+// HashAccountToken returns the hash a token is looked up by.
+func HashAccountToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateAccountToken checks a token can be used for a purpose now.
+func ValidateAccountToken(t AccountToken, purpose AccountTokenPurpose, now time.Time) error {
+	if t.Purpose != purpose {
+		return fmt.Errorf("token is not valid for this request")
+	}
+	if t.UsedAt != nil {
+		return fmt.Errorf("token was already used")
+	}
+	if !now.Before(t.ExpiresAt) {
+		return fmt.Errorf("token expired")
+	}
+	return nil
+}
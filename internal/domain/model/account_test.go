@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePassword(t *testing.T) {
+	assert.EqualError(t, ValidatePassword("short"), "password is shorter than 8 characters")
+	assert.NoError(t, ValidatePassword("correct horse"))
+	assert.NoError(t, ValidatePassword(strings.Repeat("a", MaxPasswordBytes)))
+	assert.EqualError(t, ValidatePassword(strings.Repeat("a", MaxPasswordBytes+1)), "password is longer than 72 bytes")
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	email, err := NormalizeEmail(" Reader <Reader@PageTurnerPro.com> ")
+	require.NoError(t, err)
+	assert.Equal(t, "reader@pageturnerpro.com", email)
+
+	_, err = NormalizeEmail("reader")
+	assert.EqualError(t, err, `invalid email "reader"`)
+}
+
+func TestAccountToken(t *testing.T) {
+	now := time.Date(2023, 9, 1, 9, 0, 0, 0, time.UTC)
+	token, secret, err := NewAccountToken(1, TokenVerifyEmail, now, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, HashAccountToken(secret), token.TokenHash)
+	assert.NotContains(t, token.TokenHash, secret)
+	assert.Equal(t, now.Add(time.Hour), token.ExpiresAt)
+
+	other, otherSecret, err := NewAccountToken(1, TokenVerifyEmail, now, time.Hour)
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, otherSecret)
+	assert.NotEqual(t, token.TokenHash, other.TokenHash)
+
+	assert.NoError(t, ValidateAccountToken(token, TokenVerifyEmail, now))
+	assert.EqualError(t, ValidateAccountToken(token, TokenResetPassword, now), "token is not valid for this request")
+	assert.EqualError(t, ValidateAccountToken(token, TokenVerifyEmail, now.Add(time.Hour)), "token expired")
+	token.UsedAt = &now
+	assert.EqualError(t, ValidateAccountToken(token, TokenVerifyEmail, now), "token was already used")
+}
@@ -0,0 +1,223 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CheckDigit is the algorithm computing the last character of a barcode.
+type CheckDigit string
+
+const (
+	CheckDigitNone CheckDigit = "none"
+	// CheckDigitMod10 is the Luhn algorithm used by most Codabar library labels.
+	CheckDigitMod10 CheckDigit = "mod10"
+	// CheckDigitMod11 weighs digits 2, 3, 4... from the right; a remainder of 10 is written X.
+	CheckDigitMod11 CheckDigit = "mod11"
+)
+
+func (c CheckDigit) IsValid() bool {
+	return c == CheckDigitNone || c == CheckDigitMod10 || c == CheckDigitMod11
+}
+
+// BarcodeFormat describes the barcodes printed on the labels of a library.
+type BarcodeFormat struct {
+	Prefix string
+	// Length is the length of the whole barcode, or 0 for any length.
+	Length     int
+	CheckDigit CheckDigit
+}
+
+// DefaultCopyBarcodeFormat is the 14-digit Codabar item barcode: a leading 3, and a Luhn check digit.
+var DefaultCopyBarcodeFormat = BarcodeFormat{Prefix: "3", Length: 14, CheckDigit: CheckDigitMod10}
+
+const maxBarcodeLength = 64
+
+// ParseBarcodeFormat parses a format written as prefix:length:check, e.g. "3:14:mod10".
+func ParseBarcodeFormat(spec string) (BarcodeFormat, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	if len(parts) != 3 {
+		return BarcodeFormat{}, fmt.Errorf("invalid barcode format %q, want prefix:length:check", spec)
+	}
+	length, err := strconv.Atoi(parts[1])
+	if err != nil || length < 0 || length > maxBarcodeLength {
+		return BarcodeFormat{}, fmt.Errorf("invalid barcode length in format %q", spec)
+	}
+	f := BarcodeFormat{
+		Prefix:     strings.ToUpper(parts[0]),
+		Length:     length,
+		CheckDigit: CheckDigit(strings.ToLower(parts[2])),
+	}
+	if !f.CheckDigit.IsValid() {
+		return BarcodeFormat{}, fmt.Errorf("unknown check digit %q in format %q", parts[2], spec)
+	}
+	if f.Length > 0 && f.Length <= len(f.Prefix)+f.checkLength() {
+		return BarcodeFormat{}, fmt.Errorf("barcode format %q leaves no room for a number", spec)
+	}
+	return f, nil
+}
+
+func (f BarcodeFormat) String() string {
+	return fmt.Sprintf("%s:%d:%s", f.Prefix, f.Length, f.CheckDigit)
+}
+
+func (f BarcodeFormat) checkLength() int {
+	if f.CheckDigit == CheckDigitNone {
+		return 0
+	}
+	return 1
+}
+
+// Validate normalizes a scanned barcode and checks it against the format.
+func (f BarcodeFormat) Validate(raw string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(raw))
+	if code == "" {
+		return "", fmt.Errorf("barcode is empty")
+	}
+	if len(code) > maxBarcodeLength {
+		return "", fmt.Errorf("barcode %q is longer than %d characters", raw, maxBarcodeLength)
+	}
+	if f.Length > 0 && len(code) != f.Length {
+		return "", fmt.Errorf("barcode %q is not %d characters long", raw, f.Length)
+	}
+	if !strings.HasPrefix(code, f.Prefix) {
+		return "", fmt.Errorf("barcode %q does not start with %q", raw, f.Prefix)
+	}
+
+	body := code[len(f.Prefix):]
+	if f.CheckDigit == CheckDigitNone {
+		for _, c := range body {
+			if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c == '-') {
+				return "", fmt.Errorf("barcode %q has an invalid character %q", raw, c)
+			}
+		}
+		return code, nil
+	}
+
+	if len(code) < 2 {
+		return "", fmt.Errorf("barcode %q is too short", raw)
+	}
+	payload, check := code[:len(code)-1], code[len(code)-1:]
+	want, err := f.computeCheckDigit(payload)
+	if err != nil {
+		return "", fmt.Errorf("barcode %q: %w", raw, err)
+	}
+	if check != want {
+		return "", fmt.Errorf("invalid barcode check digit %q", raw)
+	}
+	return code, nil
+}
+
+// Generate builds the barcode numbered seq: the prefix, seq padded with zeros
+// to the length of the format, and the check digit.
+func (f BarcodeFormat) Generate(seq int64) (string, error) {
+	if seq < 0 {
+		return "", fmt.Errorf("negative barcode number %d", seq)
+	}
+	number := strconv.FormatInt(seq, 10)
+	if f.Length > 0 {
+		width := f.Length - len(f.Prefix) - f.checkLength()
+		if len(number) > width {
+			return "", fmt.Errorf("barcode number %d does not fit format %s", seq, f)
+		}
+		number = strings.Repeat("0", width-len(number)) + number
+	}
+
+	code := f.Prefix + number
+	if f.CheckDigit == CheckDigitNone {
+		return code, nil
+	}
+	check, err := f.computeCheckDigit(code)
+	if err != nil {
+		return "", err
+	}
+	return code + check, nil
+}
+
+func (f BarcodeFormat) computeCheckDigit(payload string) (string, error) {
+	digits := make([]int, len(payload))
+	for i, c := range payload {
+		if c < '0' || c > '9' {
+			return "", fmt.Errorf("check digits need a numeric barcode")
+		}
+		digits[i] = int(c - '0')
+	}
+
+	switch f.CheckDigit {
+	case CheckDigitMod10:
+		sum := 0
+		for i := range digits {
+			d := digits[len(digits)-1-i]
+			// the digit next to the check digit is doubled
+			if i%2 == 0 {
+				d *= 2
+				if d > 9 {
+					d -= 9
+				}
+			}
+			sum += d
+		}
+		return strconv.Itoa((10 - sum%10) % 10), nil
+	case CheckDigitMod11:
+		sum := 0
+		for i := range digits {
+			sum += digits[len(digits)-1-i] * (i + 2)
+		}
+		check := (11 - sum%11) % 11
+		if check == 10 {
+			return "X", nil
+		}
+		return strconv.Itoa(check), nil
+	}
+	return "", fmt.Errorf("unknown check digit %q", f.CheckDigit)
+}
+
+// BarcodeFormats are the formats a library accepts. Labels are printed in
+// the first one; the others keep older labels scannable.
+type BarcodeFormats []BarcodeFormat
+
+// ParseBarcodeFormats parses a comma-separated list of formats.
+func ParseBarcodeFormats(spec string) (BarcodeFormats, error) {
+	var formats BarcodeFormats
+	for _, s := range strings.Split(spec, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		f, err := ParseBarcodeFormat(s)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, f)
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no barcode format in %q", spec)
+	}
+	return formats, nil
+}
+
+// Validate normalizes a barcode and checks it against every format in turn.
+func (fs BarcodeFormats) Validate(raw string) (string, error) {
+	if len(fs) == 0 {
+		return "", fmt.Errorf("no barcode format is configured")
+	}
+	var firstErr error
+	for _, f := range fs {
+		code, err := f.Validate(raw)
+		if err == nil {
+			return code, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return "", firstErr
+}
+
+// Generate builds the barcode numbered seq in the first format.
+func (fs BarcodeFormats) Generate(seq int64) (string, error) {
+	if len(fs) == 0 {
+		return "", fmt.Errorf("no barcode format is configured")
+	}
+	return fs[0].Generate(seq)
+}
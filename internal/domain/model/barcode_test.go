@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBarcodeFormat_Validate(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Format   string
+		Raw      string
+		Expected string
+	}{
+		{Name: "luhn", Format: "7:11:mod10", Raw: "79927398713", Expected: "79927398713"},
+		{Name: "codabar item", Format: "3:14:mod10", Raw: " 30000000000012 ", Expected: "30000000000012"},
+		{Name: "mod11 with X", Format: ":10:mod11", Raw: "080442957x", Expected: "080442957X"},
+		{Name: "no check digit", Format: "lib-:0:none", Raw: "lib-a17", Expected: "LIB-A17"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			f, err := ParseBarcodeFormat(tt.Format)
+			require.NoError(t, err)
+			code, err := f.Validate(tt.Raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.Expected, code)
+		})
+	}
+
+	f := DefaultCopyBarcodeFormat
+	for _, raw := range []string{"", "30000000000017", "3000000000001", "40000000000016", "3000000000001A"} {
+		_, err := f.Validate(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestBarcodeFormat_Generate(t *testing.T) {
+	code, err := DefaultCopyBarcodeFormat.Generate(1)
+	require.NoError(t, err)
+	assert.Equal(t, "30000000000012", code)
+
+	_, err = DefaultCopyBarcodeFormat.Validate(code)
+	assert.NoError(t, err)
+
+	_, err = DefaultCopyBarcodeFormat.Generate(1000000000000)
+	assert.Error(t, err)
+
+	f := BarcodeFormat{Prefix: "C", CheckDigit: CheckDigitNone}
+	code, err = f.Generate(42)
+	require.NoError(t, err)
+	assert.Equal(t, "C42", code)
+}
+
+func TestParseBarcodeFormats(t *testing.T) {
+	formats, err := ParseBarcodeFormats("3:14:mod10, LIB:0:none")
+	require.NoError(t, err)
+	require.Len(t, formats, 2)
+
+	code, err := formats.Validate("lib123")
+	require.NoError(t, err)
+	assert.Equal(t, "LIB123", code)
+
+	code, err = formats.Generate(7)
+	require.NoError(t, err)
+	assert.Equal(t, "3", code[:1])
+
+	for _, spec := range []string{"", "3:14", "3:x:mod10", "3:14:crc", "3333:4:mod10"} {
+		_, err := ParseBarcodeFormats(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -4,16 +4,21 @@ import "time"
 
 type Book struct {
 	ID            int
+	WorkID        int
 	Title         string
 	Author        string
 	PublishedYear time.Time
 	ISBN          string
+	Edition       string
+	Language      string
+	CallNumber    *CallNumber // CallNumber is nil until the book is classified.
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
 
-func NewBook(title, author, isbn string, publishedYear time.Time) Book {
+func NewBook(workID int, title, author, isbn string, publishedYear time.Time) Book {
 	return Book{
+		WorkID:        workID,
 		Title:         title,
 		Author:        author,
 		ISBN:          isbn,
@@ -1,26 +1,199 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type BookStatus int
 
+// The values are stored by name in the book_status enum of the database, so
+// a new status needs a migration adding its name to the enum.
 const (
-	InLibrary BookStatus = 0
-	Borrowed  BookStatus = 1
-	Lost      BookStatus = 2
+	InLibrary   BookStatus = 0
+	Borrowed    BookStatus = 1
+	Lost        BookStatus = 2
+	Damaged     BookStatus = 3
+	InRepair    BookStatus = 4
+	InTransit   BookStatus = 5
+	OnHoldShelf BookStatus = 6
+	Withdrawn   BookStatus = 7
 )
 
+var bookStatusNames = map[BookStatus]string{
+	InLibrary:   "InLibrary",
+	Borrowed:    "Borrowed",
+	Lost:        "Lost",
+	Damaged:     "Damaged",
+	InRepair:    "InRepair",
+	InTransit:   "InTransit",
+	OnHoldShelf: "OnHoldShelf",
+	Withdrawn:   "Withdrawn",
+}
+
+// bookStatusTransitions lists the statuses a copy may move to from each status.
+var bookStatusTransitions = map[BookStatus][]BookStatus{
+	InLibrary:   {Borrowed, Lost, Damaged, InTransit, OnHoldShelf, Withdrawn},
+	Borrowed:    {InLibrary, Lost, Damaged, InTransit},
+	Lost:        {InLibrary, Withdrawn},
+	Damaged:     {InLibrary, InRepair, Withdrawn},
+	InRepair:    {InLibrary, Damaged, Withdrawn},
+	InTransit:   {InLibrary, OnHoldShelf, Lost},
+	OnHoldShelf: {InLibrary, Borrowed, InTransit},
+	Withdrawn:   {},
+}
+
+// BookStatuses returns every status in the order of its value.
+func BookStatuses() []BookStatus {
+	statuses := make([]BookStatus, 0, len(bookStatusNames))
+	for s := BookStatus(0); int(s) < len(bookStatusNames); s++ {
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// ParseBookStatus parses a status name, ignoring case, spaces and underscores.
+func ParseBookStatus(name string) (BookStatus, error) {
+	normalized := strings.NewReplacer(" ", "", "_", "").Replace(strings.ToLower(name))
+	for s, n := range bookStatusNames {
+		if strings.ToLower(n) == normalized {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown book status %q", name)
+}
+
+func (s BookStatus) IsValid() bool {
+	_, ok := bookStatusNames[s]
+	return ok
+}
+
+func (s BookStatus) String() string {
+	if name, ok := bookStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("BookStatus(%d)", int(s))
+}
+
+// MarshalText writes a status by name, e.g. in JSON.
+func (s BookStatus) MarshalText() ([]byte, error) {
+	if !s.IsValid() {
+		return nil, fmt.Errorf("unknown book status %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+func (s *BookStatus) UnmarshalText(text []byte) error {
+	status, err := ParseBookStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// IsManual reports whether staff may set the status by hand. The others are
+// owned by the loan, hold, transfer and lost item workflows, which keep their
+// own records alongside the status.
+func (s BookStatus) IsManual() bool {
+	switch s {
+	case InLibrary, Damaged, InRepair, Withdrawn:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether a copy may move from s to the status to.
+func (s BookStatus) CanTransitionTo(to BookStatus) bool {
+	for _, allowed := range bookStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns an error when a copy may not move from one status to another.
+func ValidateTransition(from, to BookStatus) error {
+	if !to.IsValid() {
+		return fmt.Errorf("unknown book status %d", int(to))
+	}
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("a copy cannot go from %s to %s", from, to)
+	}
+	return nil
+}
+
+// ValidateManualTransition returns an error unless staff may move a copy from
+// one status to another by hand. Statuses owned by a workflow are refused both
+// ways, so that their loans, holds, transfers and charges stay in step.
+func ValidateManualTransition(from, to BookStatus) error {
+	if !from.IsManual() {
+		return fmt.Errorf("a copy in %s only leaves it through its own workflow", from)
+	}
+	if to.IsValid() && !to.IsManual() {
+		return fmt.Errorf("a copy only goes to %s through its own workflow", to)
+	}
+	return ValidateTransition(from, to)
+}
+
 type BookCopies struct {
-	ID        int
-	BookID    int
-	Status    BookStatus
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID     int
+	BookID int
+	// Barcode is the label scanned at the desk. Copies catalogued before
+	// barcodes were introduced may have none.
+	Barcode string
+	// HomeBranchID owns the copy; CurrentBranchID is where it sits now.
+	HomeBranchID    int
+	CurrentBranchID int
+	Status          BookStatus
+	// CallNumber overrides the call number of the book when it is set.
+	CallNumber *CallNumber
+	// PriceCents is what the copy cost, in minor units of the library
+	// currency, and what replacing it is charged. It is nil when unknown.
+	PriceCents    *int64
+	RetiredAt     *time.Time
+	RetiredReason string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
-func NewBookCopies(bookID int, status BookStatus) BookCopies {
+func NewBookCopies(bookID int, barcode string, status BookStatus) BookCopies {
 	return BookCopies{
-		ID:     bookID,
-		Status: status,
+		BookID:  bookID,
+		Barcode: barcode,
+		Status:  status,
+	}
+}
+
+// IsRetired reports whether the copy has left the collection.
+func (c BookCopies) IsRetired() bool {
+	return c.RetiredAt != nil
+}
+
+// SystemActor records the status changes made by the library system itself,
+// e.g. when a copy is trapped for a hold.
+const SystemActor = "system"
+
+// BookStatusChange is an entry of the status history of a copy.
+type BookStatusChange struct {
+	ID     int
+	CopyID int
+	// From is nil for the status a copy was created with.
+	From      *BookStatus
+	To        BookStatus
+	ChangedBy string
+	Reason    string
+	CreatedAt time.Time
+}
+
+func NewBookStatusChange(copyID int, from *BookStatus, to BookStatus, changedBy, reason string) BookStatusChange {
+	return BookStatusChange{
+		CopyID:    copyID,
+		From:      from,
+		To:        to,
+		ChangedBy: changedBy,
+		Reason:    reason,
 	}
 }
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookStatuses(t *testing.T) {
+	statuses := BookStatuses()
+	require.Len(t, statuses, 8)
+	for i, s := range statuses {
+		assert.Equal(t, BookStatus(i), s)
+		assert.True(t, s.IsValid())
+
+		parsed, err := ParseBookStatus(s.String())
+		require.NoError(t, err)
+		assert.Equal(t, s, parsed)
+
+		// every status has an entry in the transition table
+		_, ok := bookStatusTransitions[s]
+		assert.True(t, ok, s.String())
+	}
+
+	status, err := ParseBookStatus("on_hold_shelf")
+	require.NoError(t, err)
+	assert.Equal(t, OnHoldShelf, status)
+
+	_, err = ParseBookStatus("Missing")
+	assert.Error(t, err)
+	assert.False(t, BookStatus(8).IsValid())
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		Name    string
+		From    BookStatus
+		To      BookStatus
+		Allowed bool
+	}{
+		{Name: "checkout", From: InLibrary, To: Borrowed, Allowed: true},
+		{Name: "return", From: Borrowed, To: InLibrary, Allowed: true},
+		{Name: "found", From: Lost, To: InLibrary, Allowed: true},
+		{Name: "lost to borrowed", From: Lost, To: Borrowed},
+		{Name: "repair", From: Damaged, To: InRepair, Allowed: true},
+		{Name: "repair skips damage", From: InLibrary, To: InRepair},
+		{Name: "hold pickup", From: OnHoldShelf, To: Borrowed, Allowed: true},
+		{Name: "withdrawn is final", From: Withdrawn, To: InLibrary},
+		{Name: "same status", From: InLibrary, To: InLibrary},
+		{Name: "unknown status", From: InLibrary, To: BookStatus(42)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := ValidateTransition(tt.From, tt.To)
+			if tt.Allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestBookStatus_IsManual(t *testing.T) {
+	for _, s := range []BookStatus{InLibrary, Damaged, InRepair, Withdrawn} {
+		assert.True(t, s.IsManual(), s.String())
+	}
+	for _, s := range []BookStatus{Borrowed, Lost, InTransit, OnHoldShelf} {
+		assert.False(t, s.IsManual(), s.String())
+	}
+}
+
+func TestValidateManualTransition(t *testing.T) {
+	tests := []struct {
+		Name    string
+		From    BookStatus
+		To      BookStatus
+		Allowed bool
+	}{
+		{Name: "damage", From: InLibrary, To: Damaged, Allowed: true},
+		{Name: "repair", From: Damaged, To: InRepair, Allowed: true},
+		{Name: "repaired", From: InRepair, To: InLibrary, Allowed: true},
+		{Name: "withdraw", From: Damaged, To: Withdrawn, Allowed: true},
+		{Name: "return", From: Borrowed, To: InLibrary},
+		{Name: "lost on loan", From: Borrowed, To: Lost},
+		{Name: "transfer received", From: InTransit, To: InLibrary},
+		{Name: "hold shelf cleared", From: OnHoldShelf, To: InLibrary},
+		{Name: "checkout", From: InLibrary, To: Borrowed},
+		{Name: "write off", From: Lost, To: Withdrawn},
+		{Name: "lifecycle still applies", From: InLibrary, To: InRepair},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := ValidateManualTransition(tt.From, tt.To)
+			if tt.Allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestBookStatus_JSON(t *testing.T) {
+	data, err := json.Marshal(map[string]BookStatus{"status": InRepair})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"InRepair"}`, string(data))
+
+	var body struct {
+		Status BookStatus `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"status":"withdrawn"}`), &body))
+	assert.Equal(t, Withdrawn, body.Status)
+	assert.Error(t, json.Unmarshal([]byte(`{"status":"Shredded"}`), &body))
+}
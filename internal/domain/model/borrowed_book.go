@@ -2,23 +2,47 @@ package model
 
 import "time"
 
+// BorrowedBook is a loan of a copy to a patron.
 type BorrowedBook struct {
-	ID         int
+	ID int
+	// UserID is zero once the loan has been unlinked from the patron, e.g.
+	// when their data was erased. The loan still counts in statistics.
 	UserID     int
 	CopyID     int
 	BorrowDate time.Time
 	DueDate    time.Time
-	ReturnDate time.Time
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	// ReturnDate is nil while the copy is out.
+	ReturnDate *time.Time
+	// LostAt is set when the copy is declared lost. The loan stays open
+	// until the copy comes back, if ever.
+	LostAt *time.Time
+	// BranchID is the branch lending the copy, whose calendar sets the due
+	// date. It is nil for loans made before branches were recorded.
+	BranchID  *int
+	Renewals  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
-func NewBorrowdBook(userID, copyID int, borrowDate, dueDate, returnDate time.Time) BorrowedBook {
+func NewBorrowdBook(userID, copyID int, borrowDate, dueDate time.Time) BorrowedBook {
 	return BorrowedBook{
 		UserID:     userID,
 		CopyID:     copyID,
 		BorrowDate: borrowDate,
 		DueDate:    dueDate,
-		ReturnDate: returnDate,
 	}
 }
+
+// IsOpen reports whether the copy has not been returned.
+func (b BorrowedBook) IsOpen() bool {
+	return b.ReturnDate == nil
+}
+
+func (b BorrowedBook) IsLost() bool {
+	return b.LostAt != nil
+}
+
+// IsOverdue reports whether the copy is still out after its due date.
+func (b BorrowedBook) IsOverdue(now time.Time) bool {
+	return b.IsOpen() && now.After(b.DueDate)
+}
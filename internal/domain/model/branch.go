@@ -0,0 +1,115 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+// Branch is a library building holding copies.
+type Branch struct {
+	ID      int
+	Code    string
+	Name    string
+	Address string
+	// IsMain marks the branch owning copies catalogued without a branch.
+	IsMain bool
+	// Location is the longitude and latitude of the branch, if known.
+	Location  *orb.Point
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// codePattern matches the short identifiers staff key in for branches,
+// vendors and funds.
+var codePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{0,15}$`)
+
+// normalizeCode upper-cases a code and checks it is a short identifier.
+func normalizeCode(kind, raw string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(raw))
+	if !codePattern.MatchString(code) {
+		return "", fmt.Errorf("invalid %s code %q", kind, raw)
+	}
+	return code, nil
+}
+
+// NormalizeBranchCode upper-cases a branch code and checks it is a short identifier.
+func NormalizeBranchCode(raw string) (string, error) {
+	return normalizeCode("branch", raw)
+}
+
+func NewBranch(code, name, address string) Branch {
+	return Branch{
+		Code:    code,
+		Name:    name,
+		Address: address,
+	}
+}
+
+// NewLocation builds the point at a latitude and a longitude in degrees.
+// Note that orb points are written longitude first.
+func NewLocation(lat, lng float64) (orb.Point, error) {
+	if lat < -90 || lat > 90 {
+		return orb.Point{}, fmt.Errorf("latitude %v is out of range", lat)
+	}
+	if lng < -180 || lng > 180 {
+		return orb.Point{}, fmt.Errorf("longitude %v is out of range", lng)
+	}
+	return orb.Point{lng, lat}, nil
+}
+
+// BranchDistance is a branch with copies on the shelf, at some distance of the patron.
+type BranchDistance struct {
+	Branch    Branch
+	Available int
+	// Meters is the great-circle distance to the branch.
+	Meters float64
+}
+
+// SortBranchesByDistance measures how far the branches with copies available
+// are from a point, nearest first. available counts the copies by branch ID.
+// Branches without a location are left out.
+func SortBranchesByDistance(from orb.Point, branches []*Branch, available map[int]int) []*BranchDistance {
+	var nearby []*BranchDistance
+	for _, b := range branches {
+		if b.Location == nil || available[b.ID] == 0 {
+			continue
+		}
+		nearby = append(nearby, &BranchDistance{
+			Branch:    *b,
+			Available: available[b.ID],
+			Meters:    geo.DistanceHaversine(from, *b.Location),
+		})
+	}
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return nearby[i].Meters < nearby[j].Meters
+	})
+	return nearby
+}
+
+// BranchAvailability counts the copies sitting at a branch by status.
+type BranchAvailability struct {
+	BranchID   int
+	BranchCode string
+	BranchName string
+	ByStatus   map[BookStatus]int
+}
+
+// Total is the number of copies at the branch.
+func (a BranchAvailability) Total() int {
+	total := 0
+	for _, n := range a.ByStatus {
+		total += n
+	}
+	return total
+}
+
+// Available is the number of copies on the shelf, ready to be borrowed.
+func (a BranchAvailability) Available() int {
+	return a.ByStatus[InLibrary]
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeBranchCode(t *testing.T) {
+	code, err := NormalizeBranchCode(" east-2 ")
+	require.NoError(t, err)
+	assert.Equal(t, "EAST-2", code)
+
+	for _, raw := range []string{"", "-EAST", "EAST SIDE", "ABCDEFGHIJKLMNOPQ"} {
+		_, err := NormalizeBranchCode(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestBranchAvailability(t *testing.T) {
+	a := BranchAvailability{ByStatus: map[BookStatus]int{InLibrary: 2, Borrowed: 3, InTransit: 1}}
+	assert.Equal(t, 6, a.Total())
+	assert.Equal(t, 2, a.Available())
+}
+
+func TestNewLocation(t *testing.T) {
+	p, err := NewLocation(48.8566, 2.3522)
+	require.NoError(t, err)
+	assert.Equal(t, 48.8566, p.Lat())
+	assert.Equal(t, 2.3522, p.Lon())
+
+	_, err = NewLocation(91, 0)
+	assert.Error(t, err)
+	_, err = NewLocation(0, -181)
+	assert.Error(t, err)
+}
+
+func TestSortBranchesByDistance(t *testing.T) {
+	locate := func(lat, lng float64) *orb.Point {
+		p, err := NewLocation(lat, lng)
+		require.NoError(t, err)
+		return &p
+	}
+	branches := []*Branch{
+		{ID: 1, Code: "MAIN", Location: locate(51.5072, -0.1276)},
+		{ID: 2, Code: "EAST", Location: locate(51.5155, -0.0722)},
+		{ID: 3, Code: "WEST", Location: locate(51.5074, -0.2240)},
+		{ID: 4, Code: "MOBILE"},
+	}
+	available := map[int]int{1: 1, 2: 2, 3: 0, 4: 5}
+
+	// from Liverpool Street, the east branch is closest
+	nearby := SortBranchesByDistance(orb.Point{-0.0823, 51.5178}, branches, available)
+	require.Len(t, nearby, 2)
+	assert.Equal(t, "EAST", nearby[0].Branch.Code)
+	assert.Equal(t, 2, nearby[0].Available)
+	assert.Equal(t, "MAIN", nearby[1].Branch.Code)
+	assert.InDelta(t, 740, nearby[0].Meters, 50)
+	assert.Less(t, nearby[0].Meters, nearby[1].Meters)
+}
@@ -0,0 +1,231 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxClosedDays bounds the search for the next open day, so that a branch
+// closed for good does not loop forever.
+const maxClosedDays = 366
+
+// TimeOfDay is a wall clock time as minutes after midnight; 24:00 closes a
+// branch open until midnight.
+type TimeOfDay int
+
+const minutesPerDay = 24 * 60
+
+// ParseTimeOfDay parses a time as HH:MM.
+func ParseTimeOfDay(raw string) (TimeOfDay, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(strings.TrimSpace(raw), "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", raw)
+	}
+	t := TimeOfDay(h*60 + m)
+	if h < 0 || m < 0 || m > 59 || t > minutesPerDay {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", raw)
+	}
+	return t, nil
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", int(t)/60, int(t)%60)
+}
+
+// On returns the time of day on the calendar day of d in loc.
+func (t TimeOfDay) On(d time.Time, loc *time.Location) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), int(t)/60, int(t)%60, 0, 0, loc)
+}
+
+// OpeningHours are the hours a branch is open on a day of the week.
+type OpeningHours struct {
+	Weekday time.Weekday
+	Opens   TimeOfDay
+	Closes  TimeOfDay
+}
+
+// ValidateOpeningHours checks the weekly hours of a branch: one range per
+// day at most, opening before closing. Days left out are closed.
+func ValidateOpeningHours(hours []OpeningHours) error {
+	seen := map[time.Weekday]bool{}
+	for _, h := range hours {
+		if h.Weekday < time.Sunday || h.Weekday > time.Saturday {
+			return fmt.Errorf("invalid weekday %d", h.Weekday)
+		}
+		if seen[h.Weekday] {
+			return fmt.Errorf("%s has more than one range of hours", h.Weekday)
+		}
+		seen[h.Weekday] = true
+		if h.Opens < 0 || h.Closes > minutesPerDay || h.Opens >= h.Closes {
+			return fmt.Errorf("%s opens at %s and closes at %s", h.Weekday, h.Opens, h.Closes)
+		}
+	}
+	return nil
+}
+
+type ClosureKind string
+
+const (
+	// ClosureHoliday is a public holiday or another planned closure.
+	ClosureHoliday ClosureKind = "Holiday"
+	// ClosureOneOff is an unplanned closure, e.g. for a burst pipe.
+	ClosureOneOff ClosureKind = "Closure"
+)
+
+func (k ClosureKind) IsValid() bool {
+	return k == ClosureHoliday || k == ClosureOneOff
+}
+
+// Closure is a run of days a branch, or every branch, is closed whatever
+// its opening hours.
+type Closure struct {
+	ID int
+	// BranchID is nil for a closure of every branch.
+	BranchID *int
+	Kind     ClosureKind
+	Name     string
+	// StartDate and EndDate are the first and last days closed, as UTC midnights.
+	StartDate time.Time
+	EndDate   time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewClosure(branchID *int, kind ClosureKind, name string, startDate, endDate time.Time) Closure {
+	return Closure{
+		BranchID:  branchID,
+		Kind:      kind,
+		Name:      strings.TrimSpace(name),
+		StartDate: CalendarDay(startDate, time.UTC),
+		EndDate:   CalendarDay(endDate, time.UTC),
+	}
+}
+
+// ValidateClosure checks a closure has a name and a run of days.
+func ValidateClosure(c Closure) error {
+	if !c.Kind.IsValid() {
+		return fmt.Errorf("unknown closure kind %q", c.Kind)
+	}
+	if c.Name == "" {
+		return fmt.Errorf("closure name is empty")
+	}
+	if c.EndDate.Before(c.StartDate) {
+		return fmt.Errorf("closure ends before it starts")
+	}
+	if c.EndDate.Sub(c.StartDate) > maxClosedDays*24*time.Hour {
+		return fmt.Errorf("closure is longer than %d days", maxClosedDays)
+	}
+	return nil
+}
+
+// Covers reports whether the closure includes a calendar day.
+func (c Closure) Covers(day time.Time) bool {
+	return !day.Before(c.StartDate) && !day.After(c.EndDate)
+}
+
+// CalendarDay returns the day t falls on in loc, as a UTC midnight so that
+// days compare alike whatever the time zone.
+func CalendarDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// BranchCalendar tells the days a branch is open. A branch with no opening
+// hours recorded is open every day but for its closures.
+type BranchCalendar struct {
+	BranchID int
+	// Location is the time zone of the library.
+	Location *time.Location
+	Hours    []OpeningHours
+	Closures []Closure
+}
+
+func (c BranchCalendar) hoursOn(day time.Time) (OpeningHours, bool) {
+	if len(c.Hours) == 0 {
+		return OpeningHours{Weekday: day.Weekday(), Opens: 0, Closes: minutesPerDay}, true
+	}
+	for _, h := range c.Hours {
+		if h.Weekday == day.Weekday() {
+			return h, true
+		}
+	}
+	return OpeningHours{}, false
+}
+
+// IsOpenOn reports whether the branch opens on a calendar day.
+func (c BranchCalendar) IsOpenOn(day time.Time) bool {
+	if _, ok := c.hoursOn(day); !ok {
+		return false
+	}
+	for _, closure := range c.Closures {
+		if closure.Covers(day) {
+			return false
+		}
+	}
+	return true
+}
+
+// NextOpenDay returns the first calendar day from day on the branch opens.
+func (c BranchCalendar) NextOpenDay(day time.Time) (time.Time, error) {
+	for i := 0; i <= maxClosedDays; i++ {
+		if c.IsOpenOn(day) {
+			return day, nil
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, fmt.Errorf("branch %d is not open in the next %d days", c.BranchID, maxClosedDays)
+}
+
+// DueDate is when a copy borrowed at a time is due back after a number of
+// days: closing time of the first open day once they have passed.
+func (c BranchCalendar) DueDate(borrowedAt time.Time, loanDays int) (time.Time, error) {
+	day, err := c.NextOpenDay(CalendarDay(borrowedAt, c.Location).AddDate(0, 0, loanDays))
+	if err != nil {
+		return time.Time{}, err
+	}
+	hours, _ := c.hoursOn(day)
+	due := hours.Closes.On(day, c.Location)
+	if hours.Closes == minutesPerDay {
+		// a copy due at midnight is due by the end of the day
+		due = due.Add(-time.Second)
+	}
+	return due, nil
+}
+
+// OpenDaysLate counts the days a copy due at a time was kept past it: the
+// open days after the due day, up to the day of its return.
+func (c BranchCalendar) OpenDaysLate(due, returned time.Time) int {
+	if !returned.After(due) {
+		return 0
+	}
+	last := CalendarDay(returned, c.Location)
+	days := 0
+	for day := CalendarDay(due, c.Location).AddDate(0, 0, 1); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if c.IsOpenOn(day) {
+			days++
+		}
+	}
+	return days
+}
+
+// LoanPolicy sets how long copies are lent and what keeping them late costs.
+type LoanPolicy struct {
+	LoanDays    int
+	MaxRenewals int
+	// FinePerDayCents is charged for every day the branch is open past the due date.
+	FinePerDayCents int64
+	// MaxFineCents caps the fine of a loan; zero is no cap.
+	MaxFineCents int64
+}
+
+// OverdueFine is the fine for a copy returned at a time, counting only the
+// days its branch was open.
+func (p LoanPolicy) OverdueFine(cal BranchCalendar, loan BorrowedBook, returned time.Time) (int64, int) {
+	days := cal.OpenDaysLate(loan.DueDate, returned)
+	fine := int64(days) * p.FinePerDayCents
+	if p.MaxFineCents > 0 && fine > p.MaxFineCents {
+		fine = p.MaxFineCents
+	}
+	return fine, days
+}
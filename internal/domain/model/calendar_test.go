@@ -0,0 +1,123 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func weekdayHours(opens, closes TimeOfDay, days ...time.Weekday) []OpeningHours {
+	var hours []OpeningHours
+	for _, d := range days {
+		hours = append(hours, OpeningHours{Weekday: d, Opens: opens, Closes: closes})
+	}
+	return hours
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	tod, err := ParseTimeOfDay("09:30")
+	require.NoError(t, err)
+	assert.Equal(t, TimeOfDay(570), tod)
+	assert.Equal(t, "09:30", tod.String())
+
+	tod, err = ParseTimeOfDay("24:00")
+	require.NoError(t, err)
+	assert.Equal(t, TimeOfDay(minutesPerDay), tod)
+
+	for _, raw := range []string{"", "9", "25:00", "10:60", "-1:00", "24:01"} {
+		_, err := ParseTimeOfDay(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestValidateOpeningHours(t *testing.T) {
+	assert.NoError(t, ValidateOpeningHours(weekdayHours(540, 1020, time.Monday, time.Tuesday)))
+	assert.NoError(t, ValidateOpeningHours(nil))
+	assert.Error(t, ValidateOpeningHours(weekdayHours(540, 1020, time.Monday, time.Monday)))
+	assert.Error(t, ValidateOpeningHours(weekdayHours(1020, 540, time.Monday)))
+	assert.Error(t, ValidateOpeningHours(weekdayHours(540, 1020, time.Weekday(7))))
+}
+
+func TestValidateClosure(t *testing.T) {
+	day := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, ValidateClosure(NewClosure(nil, ClosureHoliday, "Christmas", day, day.AddDate(0, 0, 1))))
+	assert.Error(t, ValidateClosure(NewClosure(nil, ClosureHoliday, " ", day, day)))
+	assert.Error(t, ValidateClosure(NewClosure(nil, ClosureHoliday, "Christmas", day, day.AddDate(0, 0, -1))))
+	assert.Error(t, ValidateClosure(NewClosure(nil, ClosureKind("Strike"), "Strike", day, day)))
+	assert.Error(t, ValidateClosure(NewClosure(nil, ClosureOneOff, "Works", day, day.AddDate(2, 0, 0))))
+}
+
+func TestBranchCalendar_DueDate(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	cal := BranchCalendar{
+		BranchID: 1,
+		Location: loc,
+		// closed on Sundays
+		Hours: weekdayHours(540, 1020, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday),
+		Closures: []Closure{
+			NewClosure(nil, ClosureHoliday, "Christmas", time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 26, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	// borrowed on Monday 4 December, due three weeks later on Christmas Day:
+	// pushed to Wednesday 27 at closing time
+	due, err := cal.DueDate(time.Date(2023, 12, 4, 11, 0, 0, 0, loc), 21)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 12, 27, 17, 0, 0, 0, loc), due)
+
+	// a due date on a Sunday moves to Monday
+	due, err = cal.DueDate(time.Date(2023, 11, 5, 11, 0, 0, 0, loc), 7)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 11, 13, 17, 0, 0, 0, loc), due)
+
+	// the day is taken in the library time zone: 23:30 UTC is already the next day in summer
+	due, err = cal.DueDate(time.Date(2023, 7, 3, 23, 30, 0, 0, time.UTC), 1)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 7, 5, 17, 0, 0, 0, loc), due)
+}
+
+func TestBranchCalendar_NoHours(t *testing.T) {
+	cal := BranchCalendar{BranchID: 1, Location: time.UTC}
+
+	due, err := cal.DueDate(time.Date(2023, 11, 5, 11, 0, 0, 0, time.UTC), 7)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 11, 12, 23, 59, 59, 0, time.UTC), due)
+
+	// closed for good
+	cal.Closures = []Closure{NewClosure(nil, ClosureOneOff, "Demolition", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))}
+	cal.Closures = append(cal.Closures, NewClosure(nil, ClosureOneOff, "Demolition", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)))
+	_, err = cal.DueDate(time.Date(2023, 1, 1, 11, 0, 0, 0, time.UTC), 1)
+	assert.Error(t, err)
+}
+
+func TestLoanPolicy_OverdueFine(t *testing.T) {
+	cal := BranchCalendar{
+		BranchID: 1,
+		Location: time.UTC,
+		Hours:    weekdayHours(540, 1020, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday),
+		Closures: []Closure{
+			NewClosure(nil, ClosureHoliday, "Christmas", time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 26, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	policy := LoanPolicy{FinePerDayCents: 25, MaxFineCents: 200}
+	loan := BorrowedBook{DueDate: time.Date(2023, 12, 22, 17, 0, 0, 0, time.UTC)}
+
+	// on time, or late on the due day
+	fine, days := policy.OverdueFine(cal, loan, time.Date(2023, 12, 22, 16, 0, 0, 0, time.UTC))
+	assert.Zero(t, fine)
+	fine, days = policy.OverdueFine(cal, loan, time.Date(2023, 12, 22, 18, 0, 0, 0, time.UTC))
+	assert.Zero(t, fine)
+	assert.Zero(t, days)
+
+	// Saturday 23 and Wednesday 27 are open; Sunday 24 and Christmas are not
+	fine, days = policy.OverdueFine(cal, loan, time.Date(2023, 12, 27, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, 2, days)
+	assert.Equal(t, int64(50), fine)
+
+	// capped
+	fine, _ = policy.OverdueFine(cal, loan, time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, int64(200), fine)
+}
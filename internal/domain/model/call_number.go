@@ -0,0 +1,148 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ClassificationScheme is the scheme a call number is written in.
+type ClassificationScheme string
+
+const (
+	ClassificationDewey ClassificationScheme = "DDC"
+	ClassificationLC    ClassificationScheme = "LCC"
+)
+
+func (s ClassificationScheme) IsValid() bool {
+	return s == ClassificationDewey || s == ClassificationLC
+}
+
+// CallNumber is a shelf address together with the key used to order the shelf.
+type CallNumber struct {
+	Scheme  ClassificationScheme
+	Raw     string
+	SortKey string
+}
+
+// ShelfItem is a copy as it sits on the shelf.
+type ShelfItem struct {
+	CopyID     int
+	BookID     int
+	Title      string
+	Author     string
+	Scheme     ClassificationScheme
+	CallNumber string
+	SortKey    string
+}
+
+var (
+	deweyPattern = regexp.MustCompile(`^(?:([A-Z]+(?: [A-Z]+)*) )?(\d{1,3})(?:\.(\d+))?(?: (.*))?$`)
+	lcPattern    = regexp.MustCompile(`^([A-Z]{1,3}) ?(\d{1,4})(?:\.(\d+))?(.*)$`)
+	lcCutter     = regexp.MustCompile(`^\.?([A-Z])(\d+)$`)
+)
+
+// ParseCallNumber validates a call number and computes a sort key whose
+// byte-wise order is the shelf order of the scheme.
+func ParseCallNumber(scheme ClassificationScheme, raw string) (CallNumber, error) {
+	normalized := strings.Join(strings.Fields(strings.ToUpper(raw)), " ")
+	if normalized == "" {
+		return CallNumber{}, fmt.Errorf("call number is empty")
+	}
+
+	var key string
+	var err error
+	switch scheme {
+	case ClassificationDewey:
+		key, err = deweySortKey(normalized)
+	case ClassificationLC:
+		key, err = lcSortKey(normalized)
+	default:
+		return CallNumber{}, fmt.Errorf("unknown classification scheme %q", scheme)
+	}
+	if err != nil {
+		return CallNumber{}, err
+	}
+
+	return CallNumber{Scheme: scheme, Raw: strings.TrimSpace(raw), SortKey: key}, nil
+}
+
+// deweySortKey orders Dewey numbers such as "J 823.912 ORW". Collection
+// prefixes sort first, the class number is compared as a decimal fraction
+// after a zero-padded integer part, and the remaining cutter and date
+// tokens are compared as text.
+func deweySortKey(cn string) (string, error) {
+	m := deweyPattern.FindStringSubmatch(cn)
+	if m == nil {
+		return "", fmt.Errorf("invalid Dewey call number %q", cn)
+	}
+	prefix, integer, fraction, rest := m[1], m[2], m[3], m[4]
+
+	n, _ := strconv.Atoi(integer)
+	parts := []string{}
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+	// A space sorts before every digit, so 823 shelves before 823.1.
+	parts = append(parts, fmt.Sprintf("%03d%s", n, fraction))
+	if rest != "" {
+		parts = append(parts, strings.ReplaceAll(rest, ".", ""))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// lcSortKey orders Library of Congress numbers such as "QA76.73.G63 D66 2016".
+// Class letters are padded so Q sorts before QA, the class number is compared
+// as a padded integer plus a decimal fraction, and cutters are compared as a
+// letter followed by a decimal fraction.
+func lcSortKey(cn string) (string, error) {
+	m := lcPattern.FindStringSubmatch(cn)
+	if m == nil {
+		return "", fmt.Errorf("invalid Library of Congress call number %q", cn)
+	}
+	letters, integer, fraction, rest := m[1], m[2], m[3], m[4]
+
+	n, _ := strconv.Atoi(integer)
+	parts := []string{
+		fmt.Sprintf("%-3s", letters),
+		fmt.Sprintf("%04d%s", n, fraction),
+	}
+
+	// Split ".G63 D66 2016" into cutters and trailing tokens. A cutter may
+	// follow the class number without a space, as in ".G63".
+	rest = strings.TrimSpace(strings.ReplaceAll(rest, ".", " ."))
+	for _, token := range strings.Fields(rest) {
+		if c := lcCutter.FindStringSubmatch(token); c != nil {
+			parts = append(parts, c[1]+c[2])
+			continue
+		}
+		parts = append(parts, strings.TrimPrefix(token, "."))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// SpineLines splits a call number into the lines of its spine label: a token
+// a line, and for Library of Congress numbers the class letters, the class
+// number and each cutter on their own lines.
+func SpineLines(scheme ClassificationScheme, raw string) []string {
+	normalized := strings.Join(strings.Fields(strings.ToUpper(raw)), " ")
+	if scheme == ClassificationLC {
+		if m := lcPattern.FindStringSubmatch(normalized); m != nil {
+			lines := []string{m[1], m[2]}
+			if m[3] != "" {
+				lines[1] += "." + m[3]
+			}
+			return append(lines, strings.Fields(strings.ReplaceAll(m[4], ".", " ."))...)
+		}
+	}
+	return strings.Fields(normalized)
+}
+
+// ShelfBrowse is a window of the shelf around a position. Before and After
+// are both in shelf order.
+type ShelfBrowse struct {
+	Anchor *ShelfItem
+	Before []*ShelfItem
+	After  []*ShelfItem
+}
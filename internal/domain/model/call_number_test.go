@@ -0,0 +1,93 @@
+package model
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertShelfOrder(t *testing.T, scheme ClassificationScheme, expected []string) {
+	shuffled := make([]string, len(expected))
+	for i := range expected {
+		shuffled[i] = expected[len(expected)-1-i]
+	}
+
+	keys := make(map[string]string, len(expected))
+	for _, raw := range shuffled {
+		cn, err := ParseCallNumber(scheme, raw)
+		require.NoError(t, err, raw)
+		keys[raw] = cn.SortKey
+	}
+
+	sort.Slice(shuffled, func(i, j int) bool {
+		return keys[shuffled[i]] < keys[shuffled[j]]
+	})
+	assert.Equal(t, expected, shuffled)
+}
+
+func TestParseCallNumber_DeweyOrder(t *testing.T) {
+	assertShelfOrder(t, ClassificationDewey, []string{
+		"5.133 KNU",
+		"005.74 DAT",
+		"823 AUS",
+		"823.1 ABC",
+		"823.9 ZZZ",
+		"823.912 ORW",
+		"823.92 ADA",
+		"941.06 CHU",
+		"J 398.2 GRI",
+	})
+}
+
+func TestParseCallNumber_LCOrder(t *testing.T) {
+	assertShelfOrder(t, ClassificationLC, []string{
+		"P35 .A1",
+		"PA6 .B2",
+		"Q1 .A2",
+		"QA9 .C7",
+		"QA76 .A1",
+		"QA76.5 .B2",
+		"QA76.73.G63 D66 2016",
+		"QA76.73.J38 S54 2005",
+		"QA76.76 .O63",
+		"QA300 .R8",
+	})
+}
+
+func TestParseCallNumber_Invalid(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Scheme ClassificationScheme
+		Raw    string
+	}{
+		{Name: "empty", Scheme: ClassificationDewey, Raw: "  "},
+		{Name: "dewey without class", Scheme: ClassificationDewey, Raw: "ORW"},
+		{Name: "lc without class number", Scheme: ClassificationLC, Raw: "QA .G63"},
+		{Name: "unknown scheme", Scheme: "UDC", Raw: "821.111"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			_, err := ParseCallNumber(tt.Scheme, tt.Raw)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseCallNumber_Normalizes(t *testing.T) {
+	a, err := ParseCallNumber(ClassificationLC, "qa76.73.g63  d66")
+	require.NoError(t, err)
+	b, err := ParseCallNumber(ClassificationLC, "QA76.73 .G63 D66")
+	require.NoError(t, err)
+	assert.Equal(t, a.SortKey, b.SortKey)
+	assert.Equal(t, "qa76.73.g63  d66", a.Raw)
+}
+
+func TestSpineLines(t *testing.T) {
+	assert.Equal(t, []string{"J", "823.912", "ORW"}, SpineLines(ClassificationDewey, "j 823.912  orw"))
+	assert.Equal(t, []string{"QA", "76.73", ".G63", "D66", "2016"}, SpineLines(ClassificationLC, "QA76.73.G63 D66 2016"))
+	assert.Equal(t, []string{"PS", "3545", ".I345", "Z46"}, SpineLines(ClassificationLC, "PS 3545.I345 Z46"))
+	assert.Empty(t, SpineLines(ClassificationDewey, " "))
+}
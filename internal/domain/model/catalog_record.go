@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// CatalogRecord is a book together with its work and copies, as exchanged
+// with other library systems.
+type CatalogRecord struct {
+	Book Book
+	// WorkTitle is the uniform title grouping the book with its other editions.
+	WorkTitle string
+	Copies    []BookCopies
+}
+
+type ImportAction string
+
+const (
+	ImportActionCreate ImportAction = "create"
+	ImportActionUpdate ImportAction = "update"
+	ImportActionError  ImportAction = "error"
+)
+
+// ImportRow is the outcome of importing a single record or row.
+type ImportRow struct {
+	// Index is the 1-based position of the record in the source file.
+	Index   int
+	Key     string
+	Action  ImportAction
+	ID      int
+	Message string
+}
+
+// ImportReport summarizes a bulk import. In a dry run nothing is written and
+// the report tells what would have happened.
+type ImportReport struct {
+	ID int
+	// Source names what was imported, such as "csv:books".
+	Source    string
+	DryRun    bool
+	Created   int
+	Updated   int
+	Failed    int
+	Rows      []ImportRow
+	CreatedAt time.Time
+}
+
+// Add records the outcome of one row and updates the totals.
+func (r *ImportReport) Add(row ImportRow) {
+	switch row.Action {
+	case ImportActionCreate:
+		r.Created++
+	case ImportActionUpdate:
+		r.Updated++
+	case ImportActionError:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+// Errors returns the rows that failed.
+func (r *ImportReport) Errors() []ImportRow {
+	var rows []ImportRow
+	for _, row := range r.Rows {
+		if row.Action == ImportActionError {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
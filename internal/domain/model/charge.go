@@ -0,0 +1,108 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type ChargeKind string
+
+const (
+	// ChargeReplacement is the cost of replacing a lost copy.
+	ChargeReplacement ChargeKind = "Replacement"
+	// ChargeProcessingFee covers the staff time of handling a lost copy.
+	ChargeProcessingFee ChargeKind = "ProcessingFee"
+	// ChargeDamage is the cost of damage to a copy found at its return.
+	ChargeDamage ChargeKind = "Damage"
+	// ChargeOverdueFine is charged for a copy returned late.
+	ChargeOverdueFine ChargeKind = "OverdueFine"
+)
+
+func (k ChargeKind) IsValid() bool {
+	switch k {
+	case ChargeReplacement, ChargeProcessingFee, ChargeDamage, ChargeOverdueFine:
+		return true
+	}
+	return false
+}
+
+// Charge is money a patron owes the library, in minor units of the library
+// currency. A charge raised by mistake or no longer due is reversed rather
+// than deleted.
+type Charge struct {
+	ID     int
+	UserID int
+	// LoanID and CopyID are the loan and copy the charge is about, if any.
+	LoanID      *int
+	CopyID      *int
+	Kind        ChargeKind
+	AmountCents int64
+	Description string
+	CreatedBy   string
+	ReversedAt  *time.Time
+	ReversedBy  string
+	// ReversalReason says why the charge was reversed, e.g. the copy was found.
+	ReversalReason string
+	// PaidAt is when the charge was paid, and PaidByUserID the patron who
+	// paid it, e.g. the guardian of the patron charged.
+	PaidAt       *time.Time
+	PaidByUserID *int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func NewCharge(userID int, kind ChargeKind, amountCents int64, description, createdBy string) Charge {
+	return Charge{
+		UserID:      userID,
+		Kind:        kind,
+		AmountCents: amountCents,
+		Description: description,
+		CreatedBy:   createdBy,
+	}
+}
+
+func (c Charge) IsReversed() bool {
+	return c.ReversedAt != nil
+}
+
+func (c Charge) IsPaid() bool {
+	return c.PaidAt != nil
+}
+
+// IsOutstanding reports whether the charge is still owed.
+func (c Charge) IsOutstanding() bool {
+	return !c.IsReversed() && !c.IsPaid()
+}
+
+// ValidateChargeReversal checks a charge can be reversed.
+func ValidateChargeReversal(c Charge) error {
+	if c.IsReversed() {
+		return fmt.Errorf("charge %d is already reversed", c.ID)
+	}
+	if c.IsPaid() {
+		return fmt.Errorf("charge %d is already paid", c.ID)
+	}
+	return nil
+}
+
+// ValidateChargePayment checks a charge can be paid.
+func ValidateChargePayment(c Charge) error {
+	if c.IsReversed() {
+		return fmt.Errorf("charge %d is reversed", c.ID)
+	}
+	if c.IsPaid() {
+		return fmt.Errorf("charge %d is already paid", c.ID)
+	}
+	return nil
+}
+
+// OutstandingCents sums the charges neither reversed nor paid.
+func OutstandingCents(charges []*Charge) int64 {
+	var total int64
+	for _, c := range charges {
+		if c.IsOutstanding() {
+			total += c.AmountCents
+		}
+	}
+	return total
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxConditionPhotos bounds the photos attached to one condition report.
+	MaxConditionPhotos = 10
+	// MaxConditionPhotoBytes bounds the size of one photo.
+	MaxConditionPhotoBytes = 10 << 20
+)
+
+// conditionPhotoTypes maps the accepted photo content types to the file
+// extension they are stored with.
+var conditionPhotoTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// ConditionReport is the state of a copy as staff found it, e.g. when it
+// came back from a loan. Reports are kept as evidence in disputes about who
+// damaged a copy, so they are never edited.
+type ConditionReport struct {
+	ID     int
+	CopyID int
+	Note   string
+	// Damaged reports move the copy to Damaged.
+	Damaged    bool
+	RecordedBy string
+	// ChargeID is the damage charge raised to the last borrower, if any.
+	ChargeID  *int
+	Photos    []ConditionPhoto
+	CreatedAt time.Time
+}
+
+// ConditionPhoto is a photo of a condition report. The image itself sits in
+// the blob store under BlobKey.
+type ConditionPhoto struct {
+	ID          int
+	ReportID    int
+	BlobKey     string
+	ContentType string
+	SizeBytes   int64
+	CreatedAt   time.Time
+}
+
+func NewConditionReport(copyID int, note string, damaged bool, recordedBy string) ConditionReport {
+	return ConditionReport{
+		CopyID:     copyID,
+		Note:       strings.TrimSpace(note),
+		Damaged:    damaged,
+		RecordedBy: recordedBy,
+	}
+}
+
+// ValidateConditionReport checks a report can be recorded, with a damage
+// charge to the last borrower when chargeCents is not zero.
+func ValidateConditionReport(r ConditionReport, chargeCents int64) error {
+	if !r.Damaged && r.Note == "" && len(r.Photos) == 0 {
+		return fmt.Errorf("a condition report needs a note, a photo or damage")
+	}
+	if len(r.Photos) > MaxConditionPhotos {
+		return fmt.Errorf("%d photos attached, at most %d are accepted", len(r.Photos), MaxConditionPhotos)
+	}
+	if chargeCents < 0 {
+		return fmt.Errorf("damage charge %d is negative", chargeCents)
+	}
+	if chargeCents > 0 && !r.Damaged {
+		return fmt.Errorf("only damage is charged to the borrower")
+	}
+	return nil
+}
+
+// ConditionPhotoExtension returns the file extension a photo of a content
+// type is stored with, or an error for a type not accepted.
+func ConditionPhotoExtension(contentType string) (string, error) {
+	ext, ok := conditionPhotoTypes[contentType]
+	if !ok {
+		return "", fmt.Errorf("photo type %q is not accepted, use JPEG, PNG or WebP", contentType)
+	}
+	return ext, nil
+}
+
+// ConditionPhotoKey is the blob key of a photo of a copy; name must be unique.
+func ConditionPhotoKey(copyID int, name, ext string) string {
+	return fmt.Sprintf("condition/%d/%s%s", copyID, name, ext)
+}
+
+// DamageCharge is the charge to the borrower of a loan for damage found on
+// its copy.
+func DamageCharge(loan BorrowedBook, c BookCopies, amountCents int64, note, createdBy string) Charge {
+	description := fmt.Sprintf("damage to copy %s", copyRef(c))
+	if note != "" {
+		description += ": " + note
+	}
+	// descriptions are stored in 255 characters
+	if len(description) > 255 {
+		description = strings.ToValidUTF8(description[:252], "") + "..."
+	}
+	charge := NewCharge(loan.UserID, ChargeDamage, amountCents, description, createdBy)
+	charge.LoanID = &loan.ID
+	charge.CopyID = &c.ID
+	return charge
+}
+
+// Repair is a copy sent out to a repair vendor, e.g. a bindery.
+type Repair struct {
+	ID       int
+	CopyID   int
+	VendorID int
+	// ReportID is the condition report that found the damage, if any.
+	ReportID *int
+	Note     string
+	SentBy   string
+	// ExpectedBack is the day the vendor promised the copy back.
+	ExpectedBack time.Time
+	ReturnedAt   *time.Time
+	ReturnedBy   string
+	// Repaired is set on return: false when the vendor could not repair the copy.
+	Repaired  *bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewRepair(copyID, vendorID int, reportID *int, expectedBack time.Time, note, sentBy string) Repair {
+	return Repair{
+		CopyID:       copyID,
+		VendorID:     vendorID,
+		ReportID:     reportID,
+		ExpectedBack: expectedBack,
+		Note:         strings.TrimSpace(note),
+		SentBy:       sentBy,
+	}
+}
+
+func (r Repair) IsOpen() bool {
+	return r.ReturnedAt == nil
+}
+
+// IsOverdue reports whether the copy is still out after the day it was
+// expected back.
+func (r Repair) IsOverdue(now time.Time) bool {
+	return r.IsOpen() && now.After(r.ExpectedBack.AddDate(0, 0, 1))
+}
+
+// ValidateRepair checks a copy can be sent to repair.
+func ValidateRepair(r Repair, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if r.ExpectedBack.Before(today) {
+		return fmt.Errorf("expected return %s is in the past", r.ExpectedBack.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// ValidateRepairReturn checks a repair can be closed.
+func ValidateRepairReturn(r Repair) error {
+	if !r.IsOpen() {
+		return fmt.Errorf("repair %d is already closed", r.ID)
+	}
+	return nil
+}
+
+// RepairFilter narrows a list of repairs. Zero fields match every repair.
+type RepairFilter struct {
+	CopyID   int
+	VendorID int
+	OpenOnly bool
+}
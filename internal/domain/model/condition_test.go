@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConditionReport(t *testing.T) {
+	damaged := NewConditionReport(1, "water damage on the cover", true, "desk")
+	assert.NoError(t, ValidateConditionReport(damaged, 0))
+	assert.NoError(t, ValidateConditionReport(damaged, 1200))
+	assert.Error(t, ValidateConditionReport(damaged, -1))
+
+	// a report says something
+	assert.Error(t, ValidateConditionReport(NewConditionReport(1, "  ", false, "desk"), 0))
+
+	// only damage is charged
+	worn := NewConditionReport(1, "spine worn", false, "desk")
+	assert.NoError(t, ValidateConditionReport(worn, 0))
+	assert.Error(t, ValidateConditionReport(worn, 500))
+
+	worn.Photos = make([]ConditionPhoto, MaxConditionPhotos+1)
+	assert.Error(t, ValidateConditionReport(worn, 0))
+}
+
+func TestConditionPhotoExtension(t *testing.T) {
+	ext, err := ConditionPhotoExtension("image/jpeg")
+	require.NoError(t, err)
+	assert.Equal(t, ".jpg", ext)
+	assert.Equal(t, "condition/4/abc.jpg", ConditionPhotoKey(4, "abc", ext))
+
+	_, err = ConditionPhotoExtension("application/pdf")
+	assert.Error(t, err)
+}
+
+func TestDamageCharge(t *testing.T) {
+	loan := BorrowedBook{ID: 5, UserID: 3, CopyID: 2}
+
+	charge := DamageCharge(loan, BookCopies{ID: 2, Barcode: "30000000000020"}, 1500, "torn pages", "desk")
+	assert.Equal(t, ChargeDamage, charge.Kind)
+	assert.Equal(t, 3, charge.UserID)
+	assert.Equal(t, 5, *charge.LoanID)
+	assert.Equal(t, "damage to copy 30000000000020: torn pages", charge.Description)
+
+	charge = DamageCharge(loan, BookCopies{ID: 2}, 1500, strings.Repeat("é", 200), "desk")
+	assert.LessOrEqual(t, len(charge.Description), 255)
+}
+
+func TestRepair(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	repair := NewRepair(1, 2, nil, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), "rebind", "desk")
+	assert.NoError(t, ValidateRepair(repair, now))
+	assert.False(t, repair.IsOverdue(now))
+	assert.True(t, repair.IsOverdue(now.AddDate(0, 0, 1)))
+
+	repair.ExpectedBack = repair.ExpectedBack.AddDate(0, 0, -1)
+	assert.Error(t, ValidateRepair(repair, now))
+
+	returned := now
+	repair.ReturnedAt = &returned
+	assert.False(t, repair.IsOverdue(now.AddDate(0, 1, 0)))
+	assert.Error(t, ValidateRepairReturn(repair))
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Fund is a budget of a fiscal year that orders are charged to. Amounts are
+// in minor units of the library currency. Ordering encumbers money, which is
+// spent as the copies are received.
+type Fund struct {
+	ID          int
+	Code        string
+	Name        string
+	FiscalYear  int
+	BudgetCents int64
+	// EncumberedCents is the cost of the copies ordered but not received.
+	EncumberedCents int64
+	// SpentCents is the cost of the copies received.
+	SpentCents int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// NormalizeFundCode upper-cases a fund code and checks it is a short identifier.
+func NormalizeFundCode(raw string) (string, error) {
+	return normalizeCode("fund", raw)
+}
+
+func NewFund(code, name string, fiscalYear int, budgetCents int64) Fund {
+	return Fund{
+		Code:        code,
+		Name:        name,
+		FiscalYear:  fiscalYear,
+		BudgetCents: budgetCents,
+	}
+}
+
+// AvailableCents is the budget left to order with.
+func (f Fund) AvailableCents() int64 {
+	return f.BudgetCents - f.EncumberedCents - f.SpentCents
+}
+
+// ValidateFundBudget checks a fund is given a budget covering what it has
+// already committed.
+func ValidateFundBudget(f Fund, budgetCents int64) error {
+	if budgetCents < 0 {
+		return fmt.Errorf("budget %d is negative", budgetCents)
+	}
+	if committed := f.EncumberedCents + f.SpentCents; budgetCents < committed {
+		return fmt.Errorf("budget %d is below the %d already committed by fund %s", budgetCents, committed, f.Code)
+	}
+	return nil
+}
+
+// ValidateFundCommitments checks the funds can cover new commitments, given
+// in cents by fund ID.
+func ValidateFundCommitments(funds []*Fund, commitments map[int]int64) error {
+	byID := make(map[int]*Fund, len(funds))
+	for _, f := range funds {
+		byID[f.ID] = f
+	}
+	for id, cents := range commitments {
+		f, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("fund %d not found", id)
+		}
+		if cents > f.AvailableCents() {
+			return fmt.Errorf("fund %s has %d left, the order needs %d", f.Code, f.AvailableCents(), cents)
+		}
+	}
+	return nil
+}
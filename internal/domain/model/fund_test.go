@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFundBudget(t *testing.T) {
+	f := Fund{Code: "ADULT", BudgetCents: 10000, EncumberedCents: 3000, SpentCents: 2000}
+	assert.Equal(t, int64(5000), f.AvailableCents())
+
+	assert.NoError(t, ValidateFundBudget(f, 5000))
+	assert.Error(t, ValidateFundBudget(f, 4999))
+	assert.Error(t, ValidateFundBudget(Fund{}, -1))
+}
+
+func TestValidateFundCommitments(t *testing.T) {
+	funds := []*Fund{
+		{ID: 1, Code: "ADULT", BudgetCents: 10000, SpentCents: 4000},
+		{ID: 2, Code: "CHILD", BudgetCents: 2000},
+	}
+
+	assert.NoError(t, ValidateFundCommitments(funds, map[int]int64{1: 6000, 2: 2000}))
+	assert.Error(t, ValidateFundCommitments(funds, map[int]int64{1: 6001}))
+	assert.Error(t, ValidateFundCommitments(funds, map[int]int64{3: 1}))
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+type HoldStatus string
+
+const (
+	HoldPending HoldStatus = "Pending"
+	// HoldInTransit holds have a copy on its way to their pickup branch.
+	HoldInTransit HoldStatus = "InTransit"
+	HoldReady     HoldStatus = "Ready"
+	HoldFulfilled HoldStatus = "Fulfilled"
+	HoldCancelled HoldStatus = "Cancelled"
+)
+
+// IsActive reports whether the hold is still waiting in the queue, in transit or on the shelf.
+func (s HoldStatus) IsActive() bool {
+	return s == HoldPending || s == HoldInTransit || s == HoldReady
+}
+
+// Hold is a patron's request for any copy of a work, regardless of edition.
+type Hold struct {
+	ID     int
+	UserID int
+	WorkID int
+	// PickupBranchID is where the patron collects the copy.
+	PickupBranchID int
+	CopyID         *int
+	Status         HoldStatus
+	ReadyAt        *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func NewHold(userID, workID, pickupBranchID int) Hold {
+	return Hold{
+		UserID:         userID,
+		WorkID:         workID,
+		PickupBranchID: pickupBranchID,
+		Status:         HoldPending,
+	}
+}
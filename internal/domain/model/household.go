@@ -0,0 +1,227 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Household links the accounts of a family. Guardians look after the
+// accounts of the dependents of their household, as far as their
+// permissions go.
+type Household struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewHousehold(name string) Household {
+	return Household{Name: strings.TrimSpace(name)}
+}
+
+func ValidateHousehold(h Household) error {
+	if strings.TrimSpace(h.Name) == "" {
+		return fmt.Errorf("household name is empty")
+	}
+	return nil
+}
+
+type HouseholdRole string
+
+const (
+	HouseholdGuardian  HouseholdRole = "Guardian"
+	HouseholdDependent HouseholdRole = "Dependent"
+)
+
+func (r HouseholdRole) IsValid() bool {
+	return r == HouseholdGuardian || r == HouseholdDependent
+}
+
+// GuardianPermissions are what a guardian may do for the dependents of their
+// household. Every guardian sees the loans, holds and charges of dependents.
+type GuardianPermissions struct {
+	// ManageLoans lets the guardian renew loans.
+	ManageLoans bool
+	// ManageHolds lets the guardian place and cancel holds.
+	ManageHolds bool
+	// PayCharges lets the guardian pay fines and other charges.
+	PayCharges bool
+	// SetRestrictions lets the guardian restrict what a dependent may borrow.
+	SetRestrictions bool
+}
+
+// FullGuardianPermissions let a guardian do everything for their dependents.
+var FullGuardianPermissions = GuardianPermissions{
+	ManageLoans:     true,
+	ManageHolds:     true,
+	PayCharges:      true,
+	SetRestrictions: true,
+}
+
+// HouseholdMember is a user in a household. A user belongs to one household
+// at most.
+type HouseholdMember struct {
+	HouseholdID int
+	UserID      int
+	Role        HouseholdRole
+	// Permissions are those of a guardian; a dependent has none.
+	Permissions GuardianPermissions
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func NewHouseholdMember(householdID, userID int, role HouseholdRole, permissions GuardianPermissions) HouseholdMember {
+	if role != HouseholdGuardian {
+		permissions = GuardianPermissions{}
+	}
+	return HouseholdMember{
+		HouseholdID: householdID,
+		UserID:      userID,
+		Role:        role,
+		Permissions: permissions,
+	}
+}
+
+func ValidateHouseholdMember(m HouseholdMember) error {
+	if !m.Role.IsValid() {
+		return fmt.Errorf("unknown household role %q", m.Role)
+	}
+	if m.Role != HouseholdGuardian && m.Permissions != (GuardianPermissions{}) {
+		return fmt.Errorf("only guardians have permissions")
+	}
+	return nil
+}
+
+// ValidateHouseholdChange checks a household still has a guardian looking
+// after its dependents once its members are changed.
+func ValidateHouseholdChange(members []*HouseholdMember) error {
+	var guardians, dependents int
+	for _, m := range members {
+		switch m.Role {
+		case HouseholdGuardian:
+			guardians++
+		case HouseholdDependent:
+			dependents++
+		}
+	}
+	if dependents > 0 && guardians == 0 {
+		return fmt.Errorf("a household with dependents needs a guardian")
+	}
+	return nil
+}
+
+// GuardianAction is something a guardian does for a dependent.
+type GuardianAction string
+
+const (
+	GuardianViewAccount     GuardianAction = "ViewAccount"
+	GuardianRenewLoans      GuardianAction = "RenewLoans"
+	GuardianManageHolds     GuardianAction = "ManageHolds"
+	GuardianPayCharges      GuardianAction = "PayCharges"
+	GuardianSetRestrictions GuardianAction = "SetRestrictions"
+)
+
+// Allows reports whether the permissions cover an action.
+func (p GuardianPermissions) Allows(action GuardianAction) bool {
+	switch action {
+	case GuardianViewAccount:
+		return true
+	case GuardianRenewLoans:
+		return p.ManageLoans
+	case GuardianManageHolds:
+		return p.ManageHolds
+	case GuardianPayCharges:
+		return p.PayCharges
+	case GuardianSetRestrictions:
+		return p.SetRestrictions
+	}
+	return false
+}
+
+// AuthorizeGuardian checks a user may act on the account of a patron: a
+// patron acts on their own account, and a guardian on those of the
+// dependents of their household their permissions allow. members are the
+// members of the household of the acting user.
+func AuthorizeGuardian(members []*HouseholdMember, actorID, patronID int, action GuardianAction) error {
+	if actorID == patronID {
+		// restrictions are set by guardians only, not by the restricted patron
+		if action != GuardianSetRestrictions {
+			return nil
+		}
+	}
+
+	var actor, patron *HouseholdMember
+	for _, m := range members {
+		switch m.UserID {
+		case actorID:
+			actor = m
+		case patronID:
+			patron = m
+		}
+	}
+	if actor == nil || actor.Role != HouseholdGuardian {
+		return fmt.Errorf("user %d is not a guardian", actorID)
+	}
+	if patron == nil || patron.HouseholdID != actor.HouseholdID || patron.Role != HouseholdDependent {
+		return fmt.Errorf("user %d is not a dependent of guardian %d", patronID, actorID)
+	}
+	if !actor.Permissions.Allows(action) {
+		return fmt.Errorf("guardian %d may not %s for user %d", actorID, action.describe(), patronID)
+	}
+	return nil
+}
+
+func (a GuardianAction) describe() string {
+	switch a {
+	case GuardianRenewLoans:
+		return "renew loans"
+	case GuardianManageHolds:
+		return "manage holds"
+	case GuardianPayCharges:
+		return "pay charges"
+	case GuardianSetRestrictions:
+		return "set restrictions"
+	}
+	return "view the account"
+}
+
+// Dependents returns the dependents a guardian may act on for an action.
+func Dependents(members []*HouseholdMember, guardianID int, action GuardianAction) []int {
+	var ids []int
+	for _, m := range members {
+		if m.Role == HouseholdDependent && AuthorizeGuardian(members, guardianID, m.UserID, action) == nil {
+			ids = append(ids, m.UserID)
+		}
+	}
+	return ids
+}
+
+// ContentRestriction keeps a dependent from borrowing or placing holds on
+// books of a subject, the narrower subjects under it included.
+type ContentRestriction struct {
+	UserID    int
+	SubjectID int
+	// SetByUserID is the guardian who set the restriction.
+	SetByUserID int
+	CreatedAt   time.Time
+}
+
+// RestrictedContentError tells which restricted subjects a book falls under.
+func RestrictedContentError(userID int, subjects []*Subject) error {
+	names := make([]string, 0, len(subjects))
+	for _, s := range subjects {
+		names = append(names, s.Name)
+	}
+	return fmt.Errorf("user %d is restricted from borrowing %s", userID, strings.Join(names, ", "))
+}
+
+// PatronAccount is what a guardian sees of an account of their household.
+type PatronAccount struct {
+	UserID           int
+	Role             HouseholdRole
+	Loans            []*BorrowedBook
+	Holds            []*Hold
+	Charges          []*Charge
+	OutstandingCents int64
+}
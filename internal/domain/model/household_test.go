@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizeGuardian(t *testing.T) {
+	guardian := NewHouseholdMember(1, 1, HouseholdGuardian, GuardianPermissions{ManageLoans: true})
+	dependent := NewHouseholdMember(1, 3, HouseholdDependent, FullGuardianPermissions)
+	other := NewHouseholdMember(2, 4, HouseholdDependent, GuardianPermissions{})
+	members := []*HouseholdMember{&guardian, &dependent, &other}
+
+	// a dependent has no permissions
+	assert.Equal(t, GuardianPermissions{}, dependent.Permissions)
+
+	assert.NoError(t, AuthorizeGuardian(members, 1, 3, GuardianViewAccount))
+	assert.NoError(t, AuthorizeGuardian(members, 1, 3, GuardianRenewLoans))
+	assert.EqualError(t, AuthorizeGuardian(members, 1, 3, GuardianPayCharges), "guardian 1 may not pay charges for user 3")
+	assert.EqualError(t, AuthorizeGuardian(members, 1, 4, GuardianViewAccount), "user 4 is not a dependent of guardian 1")
+	assert.EqualError(t, AuthorizeGuardian(members, 3, 1, GuardianViewAccount), "user 3 is not a guardian")
+
+	// patrons act on their own accounts but do not restrict themselves
+	assert.NoError(t, AuthorizeGuardian(nil, 3, 3, GuardianPayCharges))
+	assert.Error(t, AuthorizeGuardian(members, 3, 3, GuardianSetRestrictions))
+
+	assert.Equal(t, []int{3}, Dependents(members, 1, GuardianRenewLoans))
+	assert.Empty(t, Dependents(members, 1, GuardianManageHolds))
+}
+
+func TestValidateHouseholdChange(t *testing.T) {
+	guardian := NewHouseholdMember(1, 1, HouseholdGuardian, FullGuardianPermissions)
+	dependent := NewHouseholdMember(1, 3, HouseholdDependent, GuardianPermissions{})
+
+	assert.NoError(t, ValidateHouseholdChange(nil))
+	assert.NoError(t, ValidateHouseholdChange([]*HouseholdMember{&guardian, &dependent}))
+	assert.Error(t, ValidateHouseholdChange([]*HouseholdMember{&dependent}))
+
+	assert.Error(t, ValidateHousehold(NewHousehold("  ")))
+	assert.Error(t, ValidateHouseholdMember(HouseholdMember{Role: "Parent"}))
+	assert.Error(t, ValidateHouseholdMember(HouseholdMember{Role: HouseholdDependent, Permissions: FullGuardianPermissions}))
+}
+
+func TestValidateChargePayment(t *testing.T) {
+	charge := Charge{ID: 1, AmountCents: 300}
+	assert.NoError(t, ValidateChargePayment(charge))
+	assert.True(t, charge.IsOutstanding())
+
+	now := time.Now()
+	paidBy := 1
+	paid := Charge{ID: 1, AmountCents: 300, PaidAt: &now, PaidByUserID: &paidBy}
+	assert.Error(t, ValidateChargePayment(paid))
+	assert.Error(t, ValidateChargeReversal(paid))
+	assert.False(t, paid.IsOutstanding())
+	assert.Zero(t, OutstandingCents([]*Charge{&paid}))
+}
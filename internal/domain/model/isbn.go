@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeISBN strips hyphens, spaces and trailing qualifiers such as
+// "(pbk.)" from an ISBN-10 or ISBN-13 and validates its check digit.
+func NormalizeISBN(raw string) (string, error) {
+	fields := strings.Fields(strings.TrimSpace(raw))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("ISBN is empty")
+	}
+	isbn := strings.ToUpper(strings.ReplaceAll(fields[0], "-", ""))
+
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, c := range isbn {
+			var d int
+			switch {
+			case c >= '0' && c <= '9':
+				d = int(c - '0')
+			case c == 'X' && i == 9:
+				d = 10
+			default:
+				return "", fmt.Errorf("invalid ISBN %q", raw)
+			}
+			sum += d * (10 - i)
+		}
+		if sum%11 != 0 {
+			return "", fmt.Errorf("invalid ISBN check digit %q", raw)
+		}
+	case 13:
+		sum := 0
+		for i, c := range isbn {
+			if c < '0' || c > '9' {
+				return "", fmt.Errorf("invalid ISBN %q", raw)
+			}
+			d := int(c - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		if sum%10 != 0 {
+			return "", fmt.Errorf("invalid ISBN check digit %q", raw)
+		}
+	default:
+		return "", fmt.Errorf("invalid ISBN length %q", raw)
+	}
+
+	return isbn, nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Raw      string
+		Expected string
+	}{
+		{Name: "isbn-13", Raw: "9780060934347", Expected: "9780060934347"},
+		{Name: "hyphenated", Raw: "978-0-06-093434-7", Expected: "9780060934347"},
+		{Name: "qualifier", Raw: "0060934344 (pbk.)", Expected: "0060934344"},
+		{Name: "isbn-10 with X", Raw: "0-8044-2957-x", Expected: "080442957X"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			isbn, err := NormalizeISBN(tt.Raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.Expected, isbn)
+		})
+	}
+
+	for _, raw := range []string{"", "9780060934348", "12345", "97800609343X7"} {
+		_, err := NormalizeISBN(raw)
+		assert.Error(t, err, raw)
+	}
+}
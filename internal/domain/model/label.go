@@ -0,0 +1,11 @@
+package model
+
+// CopyLabel is what is printed on the labels of a copy.
+type CopyLabel struct {
+	CopyID  int
+	Barcode string
+	Title   string
+	// Scheme and CallNumber are empty for a copy not yet classified.
+	Scheme     ClassificationScheme
+	CallNumber string
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ValidateCheckout checks a copy can be lent to a user. A copy on the hold
+// shelf goes only to the patron of the hold it was trapped for.
+func ValidateCheckout(c BookCopies, readyHold *Hold, userID int) error {
+	switch c.Status {
+	case InLibrary:
+		return nil
+	case OnHoldShelf:
+		if readyHold == nil || readyHold.UserID != userID {
+			return fmt.Errorf("copy %s is held for another patron", copyRef(c))
+		}
+		return nil
+	}
+	return fmt.Errorf("copy %s is %s and cannot be lent", copyRef(c), c.Status)
+}
+
+// ValidateRenewal checks a loan can be renewed until a new due date.
+func ValidateRenewal(loan BorrowedBook, policy LoanPolicy, newDue time.Time) error {
+	if !loan.IsOpen() {
+		return fmt.Errorf("loan %d is closed", loan.ID)
+	}
+	if loan.IsLost() {
+		return fmt.Errorf("loan %d is declared lost", loan.ID)
+	}
+	if loan.Renewals >= policy.MaxRenewals {
+		return fmt.Errorf("loan %d was renewed %d times, the most allowed", loan.ID, loan.Renewals)
+	}
+	if !newDue.After(loan.DueDate) {
+		return fmt.Errorf("renewing loan %d would not push its due date back", loan.ID)
+	}
+	return nil
+}
+
+// ValidateReturn checks the copy of a loan can be checked in. A copy declared
+// lost is returned as found instead, which reverses its charges.
+func ValidateReturn(loan BorrowedBook) error {
+	if !loan.IsOpen() {
+		return fmt.Errorf("loan %d is closed", loan.ID)
+	}
+	if loan.IsLost() {
+		return fmt.Errorf("copy of loan %d was declared lost, return it as found", loan.ID)
+	}
+	return nil
+}
+
+// OverdueFineCharge is the charge to the borrower for returning a copy late.
+func OverdueFineCharge(loan BorrowedBook, c BookCopies, amountCents int64, daysLate int, createdBy string) Charge {
+	description := fmt.Sprintf("copy %s returned %d open days late", copyRef(c), daysLate)
+	charge := NewCharge(loan.UserID, ChargeOverdueFine, amountCents, description, createdBy)
+	charge.LoanID = &loan.ID
+	charge.CopyID = &c.ID
+	return charge
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCheckout(t *testing.T) {
+	assert.NoError(t, ValidateCheckout(BookCopies{ID: 1, Status: InLibrary}, nil, 3))
+	assert.Error(t, ValidateCheckout(BookCopies{ID: 1, Status: Borrowed}, nil, 3))
+	assert.Error(t, ValidateCheckout(BookCopies{ID: 1, Status: Damaged}, nil, 3))
+
+	hold := &Hold{ID: 2, UserID: 3, Status: HoldReady}
+	assert.NoError(t, ValidateCheckout(BookCopies{ID: 1, Status: OnHoldShelf}, hold, 3))
+	assert.Error(t, ValidateCheckout(BookCopies{ID: 1, Status: OnHoldShelf}, hold, 4))
+	assert.Error(t, ValidateCheckout(BookCopies{ID: 1, Status: OnHoldShelf}, nil, 3))
+}
+
+func TestValidateRenewal(t *testing.T) {
+	due := time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC)
+	policy := LoanPolicy{MaxRenewals: 2}
+	loan := BorrowedBook{ID: 1, DueDate: due}
+
+	assert.NoError(t, ValidateRenewal(loan, policy, due.AddDate(0, 0, 21)))
+	assert.Error(t, ValidateRenewal(loan, policy, due))
+
+	loan.Renewals = 2
+	assert.Error(t, ValidateRenewal(loan, policy, due.AddDate(0, 0, 21)))
+
+	loan.Renewals = 0
+	loan.LostAt = &due
+	assert.Error(t, ValidateRenewal(loan, policy, due.AddDate(0, 0, 21)))
+	assert.Error(t, ValidateReturn(loan))
+
+	loan.LostAt = nil
+	assert.NoError(t, ValidateReturn(loan))
+	loan.ReturnDate = &due
+	assert.Error(t, ValidateReturn(loan))
+	assert.Error(t, ValidateRenewal(loan, policy, due.AddDate(0, 0, 21)))
+}
+
+func TestOverdueFineCharge(t *testing.T) {
+	charge := OverdueFineCharge(BorrowedBook{ID: 4, UserID: 2}, BookCopies{ID: 9, Barcode: "30000000000095"}, 75, 3, "desk")
+	assert.Equal(t, ChargeOverdueFine, charge.Kind)
+	assert.Equal(t, 2, charge.UserID)
+	assert.Equal(t, 4, *charge.LoanID)
+	assert.Equal(t, "copy 30000000000095 returned 3 open days late", charge.Description)
+}
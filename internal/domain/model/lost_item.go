@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// LostItemPolicy sets what a patron is charged for a copy lost on loan and
+// when an overdue copy is presumed lost.
+type LostItemPolicy struct {
+	// LostAfterDays is how long a copy may be overdue before it is declared
+	// lost automatically. Zero turns automatic declaration off.
+	LostAfterDays int
+	// ProcessingFeeCents is charged on top of the replacement cost.
+	ProcessingFeeCents int64
+	// DefaultReplacementCents is charged for a copy with no known price.
+	DefaultReplacementCents int64
+}
+
+// OverdueCutoff is the due date before which open loans are presumed lost.
+func (p LostItemPolicy) OverdueCutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, -p.LostAfterDays)
+}
+
+// LostCharges returns what the borrower of a lost copy is charged. Charges
+// of no amount are left out.
+func (p LostItemPolicy) LostCharges(loan BorrowedBook, c BookCopies, createdBy string) []Charge {
+	replacement := p.DefaultReplacementCents
+	if c.PriceCents != nil {
+		replacement = *c.PriceCents
+	}
+
+	var charges []Charge
+	add := func(kind ChargeKind, amount int64, description string) {
+		if amount <= 0 {
+			return
+		}
+		charge := NewCharge(loan.UserID, kind, amount, description, createdBy)
+		charge.LoanID = &loan.ID
+		charge.CopyID = &c.ID
+		charges = append(charges, charge)
+	}
+	add(ChargeReplacement, replacement, fmt.Sprintf("replacement of lost copy %s", copyRef(c)))
+	add(ChargeProcessingFee, p.ProcessingFeeCents, fmt.Sprintf("processing of lost copy %s", copyRef(c)))
+	return charges
+}
+
+// ValidateDeclareLost checks the copy of a loan can be declared lost.
+func ValidateDeclareLost(loan BorrowedBook) error {
+	if !loan.IsOpen() {
+		return fmt.Errorf("loan %d is closed, the copy was returned", loan.ID)
+	}
+	if loan.IsLost() {
+		return fmt.Errorf("loan %d is already declared lost", loan.ID)
+	}
+	return nil
+}
+
+// copyRef names a copy by its barcode, or by its ID when it has none.
+func copyRef(c BookCopies) string {
+	if c.Barcode != "" {
+		return c.Barcode
+	}
+	return fmt.Sprintf("#%d", c.ID)
+}
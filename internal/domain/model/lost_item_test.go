@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLostItemPolicy_LostCharges(t *testing.T) {
+	policy := LostItemPolicy{ProcessingFeeCents: 500, DefaultReplacementCents: 2500}
+	loan := BorrowedBook{ID: 7, UserID: 3, CopyID: 2}
+	price := int64(1899)
+
+	charges := policy.LostCharges(loan, BookCopies{ID: 2, Barcode: "30000000000020", PriceCents: &price}, "librarian")
+	require.Len(t, charges, 2)
+	assert.Equal(t, ChargeReplacement, charges[0].Kind)
+	assert.Equal(t, int64(1899), charges[0].AmountCents)
+	assert.Equal(t, 3, charges[0].UserID)
+	assert.Equal(t, 7, *charges[0].LoanID)
+	assert.Equal(t, 2, *charges[0].CopyID)
+	assert.Contains(t, charges[0].Description, "30000000000020")
+	assert.Equal(t, ChargeProcessingFee, charges[1].Kind)
+	assert.Equal(t, int64(500), charges[1].AmountCents)
+
+	// the default price stands in for an unknown one
+	charges = policy.LostCharges(loan, BookCopies{ID: 2}, "librarian")
+	require.Len(t, charges, 2)
+	assert.Equal(t, int64(2500), charges[0].AmountCents)
+	assert.Contains(t, charges[0].Description, "#2")
+
+	// a free copy and no fee charge nothing
+	free := int64(0)
+	charges = LostItemPolicy{}.LostCharges(loan, BookCopies{ID: 2, PriceCents: &free}, "librarian")
+	assert.Empty(t, charges)
+}
+
+func TestValidateDeclareLost(t *testing.T) {
+	now := time.Now()
+
+	assert.NoError(t, ValidateDeclareLost(BorrowedBook{ID: 1}))
+	assert.Error(t, ValidateDeclareLost(BorrowedBook{ID: 1, ReturnDate: &now}))
+	assert.Error(t, ValidateDeclareLost(BorrowedBook{ID: 1, LostAt: &now}))
+}
+
+func TestLostItemPolicy_OverdueCutoff(t *testing.T) {
+	now := time.Date(2023, 3, 31, 12, 0, 0, 0, time.UTC)
+	policy := LostItemPolicy{LostAfterDays: 30}
+	assert.Equal(t, time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC), policy.OverdueCutoff(now))
+}
+
+func TestOutstandingCents(t *testing.T) {
+	now := time.Now()
+	charges := []*Charge{
+		{AmountCents: 1500},
+		{AmountCents: 500, ReversedAt: &now},
+		{AmountCents: 250},
+	}
+	assert.Equal(t, int64(1750), OutstandingCents(charges))
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PatronCategory sorts patrons by who they are to the library, which sets
+// how long their membership runs.
+type PatronCategory string
+
+const (
+	PatronAdult PatronCategory = "Adult"
+	PatronChild PatronCategory = "Child"
+	PatronStaff PatronCategory = "Staff"
+	PatronGuest PatronCategory = "Guest"
+)
+
+func (c PatronCategory) IsValid() bool {
+	return c == PatronAdult || c == PatronChild || c == PatronStaff || c == PatronGuest
+}
+
+// ParsePatronCategory reads a category, whatever its case.
+func ParsePatronCategory(s string) (PatronCategory, error) {
+	for _, c := range []PatronCategory{PatronAdult, PatronChild, PatronStaff, PatronGuest} {
+		if strings.EqualFold(strings.TrimSpace(s), string(c)) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown patron category %q", s)
+}
+
+// DefaultPatronBarcodeFormat is the 14-digit Codabar patron barcode: a
+// leading 2, and a Luhn check digit.
+var DefaultPatronBarcodeFormat = BarcodeFormat{Prefix: "2", Length: 14, CheckDigit: CheckDigitMod10}
+
+// MembershipTerms are how many months a membership runs for by category. A
+// category without a term, or with a zero one, never lapses.
+type MembershipTerms map[PatronCategory]int
+
+// DefaultMembershipTerms renew adults and children yearly, guests every three
+// months, and keep staff members for as long as they work at the library.
+var DefaultMembershipTerms = MembershipTerms{
+	PatronAdult: 12,
+	PatronChild: 12,
+	PatronStaff: 0,
+	PatronGuest: 3,
+}
+
+// ParseMembershipTerms parses a comma-separated list of category:months
+// terms, e.g. "Adult:12,Guest:3".
+func ParseMembershipTerms(spec string) (MembershipTerms, error) {
+	terms := MembershipTerms{}
+	for _, s := range strings.Split(spec, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		parts := strings.Split(s, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid membership term %q, want category:months", s)
+		}
+		category, err := ParsePatronCategory(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		months, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || months < 0 {
+			return nil, fmt.Errorf("invalid number of months in membership term %q", s)
+		}
+		terms[category] = months
+	}
+	return terms, nil
+}
+
+// Membership is the category of a patron and the days their membership runs,
+// as UTC midnights.
+type Membership struct {
+	Category PatronCategory
+	StartsAt time.Time
+	// ExpiresAt is the last day of the membership; nil for one that does not lapse.
+	ExpiresAt *time.Time
+}
+
+// Enrol starts a membership of a category on a day, running for the term of
+// the category.
+func (t MembershipTerms) Enrol(category PatronCategory, startsAt time.Time) (Membership, error) {
+	if !category.IsValid() {
+		return Membership{}, fmt.Errorf("unknown patron category %q", category)
+	}
+	start := CalendarDay(startsAt, time.UTC)
+	m := Membership{Category: category, StartsAt: start}
+	if months := t[category]; months > 0 {
+		expiresAt := lastDayOfTerm(start, months)
+		m.ExpiresAt = &expiresAt
+	}
+	return m, nil
+}
+
+// Renew extends a membership by the term of its category on the day today.
+// A membership still running is extended from its last day, so that renewing
+// early loses nothing; a lapsed one runs again from today.
+func (t MembershipTerms) Renew(m Membership, today time.Time) (Membership, error) {
+	months := t[m.Category]
+	if m.ExpiresAt == nil || months == 0 {
+		return Membership{}, fmt.Errorf("the membership of a %s patron does not lapse", strings.ToLower(string(m.Category)))
+	}
+	from := CalendarDay(today, time.UTC)
+	if next := m.ExpiresAt.AddDate(0, 0, 1); next.After(from) {
+		from = next
+	}
+	expiresAt := lastDayOfTerm(from, months)
+	m.ExpiresAt = &expiresAt
+	return m, nil
+}
+
+// ValidateMembership checks a membership set by hand.
+func ValidateMembership(m Membership) error {
+	if !m.Category.IsValid() {
+		return fmt.Errorf("unknown patron category %q", m.Category)
+	}
+	if m.ExpiresAt != nil && m.ExpiresAt.Before(m.StartsAt) {
+		return fmt.Errorf("membership expires before it starts")
+	}
+	return nil
+}
+
+// lastDayOfTerm is the day before the same date months later. A term
+// starting on the 31st ends at the end of the shorter month rather than
+// spilling into the next.
+func lastDayOfTerm(start time.Time, months int) time.Time {
+	end := start.AddDate(0, months, 0)
+	if end.Day() != start.Day() {
+		// AddDate normalized e.g. 31 April into 1 May
+		end = end.AddDate(0, 0, -end.Day()+1)
+	}
+	return end.AddDate(0, 0, -1)
+}
+
+// LibraryCard is a card carrying a patron barcode, scanned at the desk to
+// find the patron. A patron has one active card at a time; a card reported
+// lost or replaced is revoked and no longer identifies them.
+type LibraryCard struct {
+	ID           int
+	UserID       int
+	Barcode      string
+	IssuedBy     string
+	RevokedAt    *time.Time
+	RevokedBy    string
+	RevokeReason string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func NewLibraryCard(userID int, barcode, issuedBy string) LibraryCard {
+	return LibraryCard{
+		UserID:   userID,
+		Barcode:  barcode,
+		IssuedBy: issuedBy,
+	}
+}
+
+func (c LibraryCard) IsActive() bool {
+	return c.RevokedAt == nil
+}
+
+// ValidateCardRevocation checks a card may be revoked.
+func ValidateCardRevocation(c LibraryCard, reason string) error {
+	if !c.IsActive() {
+		return fmt.Errorf("card %s is already revoked", c.Barcode)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("a reason is required to revoke a card")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseMembershipTerms(t *testing.T) {
+	terms, err := ParseMembershipTerms("adult:12, Guest:3,Staff:0")
+	require.NoError(t, err)
+	assert.Equal(t, MembershipTerms{PatronAdult: 12, PatronGuest: 3, PatronStaff: 0}, terms)
+
+	_, err = ParseMembershipTerms("Adult:12,Visitor:1")
+	assert.Error(t, err)
+	_, err = ParseMembershipTerms("Adult:-1")
+	assert.Error(t, err)
+	_, err = ParseMembershipTerms("Adult")
+	assert.Error(t, err)
+}
+
+func TestMembershipTerms_Enrol(t *testing.T) {
+	m, err := DefaultMembershipTerms.Enrol(PatronAdult, time.Date(2023, 3, 15, 18, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, day(2023, 3, 15), m.StartsAt)
+	require.NotNil(t, m.ExpiresAt)
+	assert.Equal(t, day(2024, 3, 14), *m.ExpiresAt)
+
+	// a term starting on the 31st ends with the shorter month
+	m, err = DefaultMembershipTerms.Enrol(PatronGuest, day(2023, 5, 31))
+	require.NoError(t, err)
+	assert.Equal(t, day(2023, 8, 30), *m.ExpiresAt)
+	m, err = MembershipTerms{PatronGuest: 1}.Enrol(PatronGuest, day(2023, 1, 31))
+	require.NoError(t, err)
+	assert.Equal(t, day(2023, 2, 28), *m.ExpiresAt)
+
+	m, err = DefaultMembershipTerms.Enrol(PatronStaff, day(2023, 3, 15))
+	require.NoError(t, err)
+	assert.Nil(t, m.ExpiresAt)
+
+	_, err = DefaultMembershipTerms.Enrol("Visitor", day(2023, 3, 15))
+	assert.Error(t, err)
+}
+
+func TestMembershipTerms_Renew(t *testing.T) {
+	expiry := day(2023, 9, 30)
+	m := Membership{Category: PatronAdult, StartsAt: day(2022, 10, 1), ExpiresAt: &expiry}
+
+	// renewing early extends from the last day
+	renewed, err := DefaultMembershipTerms.Renew(m, day(2023, 9, 1))
+	require.NoError(t, err)
+	assert.Equal(t, day(2024, 9, 30), *renewed.ExpiresAt)
+	assert.Equal(t, day(2022, 10, 1), renewed.StartsAt)
+	assert.Equal(t, day(2023, 9, 30), *m.ExpiresAt)
+
+	// a lapsed membership runs again from today
+	renewed, err = DefaultMembershipTerms.Renew(m, day(2023, 12, 10))
+	require.NoError(t, err)
+	assert.Equal(t, day(2024, 12, 9), *renewed.ExpiresAt)
+
+	_, err = DefaultMembershipTerms.Renew(Membership{Category: PatronStaff, StartsAt: day(2022, 10, 1)}, day(2023, 9, 1))
+	assert.EqualError(t, err, "the membership of a staff patron does not lapse")
+}
+
+func TestValidateMembership(t *testing.T) {
+	expiry := day(2023, 1, 1)
+	assert.Error(t, ValidateMembership(Membership{Category: PatronAdult, StartsAt: day(2023, 2, 1), ExpiresAt: &expiry}))
+	assert.Error(t, ValidateMembership(Membership{Category: "", StartsAt: day(2022, 2, 1)}))
+	assert.NoError(t, ValidateMembership(Membership{Category: PatronChild, StartsAt: day(2022, 2, 1), ExpiresAt: &expiry}))
+}
+
+func TestValidateCardRevocation(t *testing.T) {
+	card := NewLibraryCard(1, "20000000000014", "desk")
+	assert.Error(t, ValidateCardRevocation(card, " "))
+	assert.NoError(t, ValidateCardRevocation(card, "lost"))
+
+	revoked := time.Now()
+	card.RevokedAt = &revoked
+	assert.Error(t, ValidateCardRevocation(card, "lost"))
+}
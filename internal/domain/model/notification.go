@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type NotificationType string
+
+const (
+	// NotificationDueSoon reminds a patron a loan is due in a few days.
+	NotificationDueSoon NotificationType = "DueSoon"
+	// NotificationOverdue tells a patron a loan is past its due date.
+	NotificationOverdue NotificationType = "Overdue"
+	// NotificationHoldReady tells a patron a held copy waits for pickup.
+	NotificationHoldReady NotificationType = "HoldReady"
+)
+
+// IsValid reports whether the type is one notifications are sent for.
+func (t NotificationType) IsValid() bool {
+	return t == NotificationDueSoon || t == NotificationOverdue || t == NotificationHoldReady
+}
+
+type NotificationChannel string
+
+const (
+	ChannelEmail NotificationChannel = "Email"
+	// ChannelLog writes notifications out instead of sending them, for
+	// development.
+	ChannelLog NotificationChannel = "Log"
+)
+
+// ParseNotificationChannel returns the channel with a name, in any case.
+func ParseNotificationChannel(name string) (NotificationChannel, error) {
+	for _, c := range []NotificationChannel{ChannelEmail, ChannelLog} {
+		if strings.EqualFold(strings.TrimSpace(name), string(c)) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown notification channel %q", name)
+}
+
+// Notice is a notification owed to a patron, with what its message tells.
+type Notice struct {
+	Type   NotificationType
+	UserID int
+	Email  string
+	Name   string
+	// ReferenceID is the loan, or for HoldReady the hold, the notice is about.
+	ReferenceID int
+	Title       string
+	// DueDate is set for loan notices.
+	DueDate time.Time
+	// BranchName and ReadyAt are set for HoldReady, naming where the copy
+	// is to be picked up and since when.
+	BranchName string
+	ReadyAt    time.Time
+}
+
+// DedupKey identifies a notice across runs of the job, so that it is sent
+// once. A renewed loan, or a hold made ready again, is owed a new notice.
+func (n Notice) DedupKey() string {
+	switch n.Type {
+	case NotificationHoldReady:
+		return fmt.Sprintf("%s:hold:%d:%d", n.Type, n.ReferenceID, n.ReadyAt.Unix())
+	default:
+		return fmt.Sprintf("%s:loan:%d:%d", n.Type, n.ReferenceID, n.DueDate.Unix())
+	}
+}
+
+// SentNotification is a notification sent to a patron.
+type SentNotification struct {
+	ID          int
+	UserID      int
+	Type        NotificationType
+	Channel     NotificationChannel
+	ReferenceID int
+	DedupKey    string
+	// Digest is set for notifications sent as part of a daily digest.
+	Digest  bool
+	Locale  string
+	Subject string
+	Body    string
+	SentAt  time.Time
+}
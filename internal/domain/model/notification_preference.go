@@ -0,0 +1,131 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type NotificationDelivery string
+
+const (
+	// DeliveryImmediate sends each notification as soon as it is owed.
+	DeliveryImmediate NotificationDelivery = "Immediate"
+	// DeliveryDigest gathers the notifications owed into one message a day.
+	DeliveryDigest NotificationDelivery = "Digest"
+)
+
+// NotificationTypes are all the types of notification patrons may get.
+var NotificationTypes = []NotificationType{NotificationDueSoon, NotificationOverdue, NotificationHoldReady}
+
+// DefaultDigestTime is when daily digests go out unless the patron says
+// otherwise.
+const DefaultDigestTime TimeOfDay = 8 * 60
+
+// NotificationPreferences are how a patron wants to be notified.
+type NotificationPreferences struct {
+	UserID int
+	// Types are the types of notification the patron gets.
+	Types    []NotificationType
+	Channel  NotificationChannel
+	Delivery NotificationDelivery
+	// TimeZone is the IANA time zone of the patron, in which quiet hours and
+	// the digest time fall.
+	TimeZone string
+	// Nothing is sent from QuietStart until QuietEnd, which may be past
+	// midnight. Equal times mean no quiet hours.
+	QuietStart TimeOfDay
+	QuietEnd   TimeOfDay
+	// DigestTime is when the daily digest goes out.
+	DigestTime TimeOfDay
+	// LastDigestAt is when the last digest was sent.
+	LastDigestAt *time.Time
+	UpdatedAt    time.Time
+}
+
+// DefaultNotificationPreferences are the preferences of a patron who has not
+// set any: every notification, sent at once on the channel and in the time
+// zone of the library.
+func DefaultNotificationPreferences(userID int, channel NotificationChannel, timeZone string) NotificationPreferences {
+	return NotificationPreferences{
+		UserID:     userID,
+		Types:      append([]NotificationType(nil), NotificationTypes...),
+		Channel:    channel,
+		Delivery:   DeliveryImmediate,
+		TimeZone:   timeZone,
+		DigestTime: DefaultDigestTime,
+	}
+}
+
+// ValidateNotificationPreferences checks preferences before they are saved.
+func ValidateNotificationPreferences(p NotificationPreferences) error {
+	seen := map[NotificationType]bool{}
+	for _, t := range p.Types {
+		if !t.IsValid() {
+			return fmt.Errorf("unknown notification type %q", t)
+		}
+		if seen[t] {
+			return fmt.Errorf("notification type %s is listed twice", t)
+		}
+		seen[t] = true
+	}
+	if _, err := ParseNotificationChannel(string(p.Channel)); err != nil {
+		return err
+	}
+	if p.Delivery != DeliveryImmediate && p.Delivery != DeliveryDigest {
+		return fmt.Errorf("unknown delivery %q", p.Delivery)
+	}
+	if _, err := time.LoadLocation(p.TimeZone); err != nil || p.TimeZone == "" {
+		return fmt.Errorf("unknown time zone %q", p.TimeZone)
+	}
+	for _, t := range []TimeOfDay{p.QuietStart, p.QuietEnd, p.DigestTime} {
+		if t < 0 || t >= minutesPerDay {
+			return fmt.Errorf("time %s is not within a day", t)
+		}
+	}
+	return nil
+}
+
+// Wants reports whether the patron gets notifications of a type.
+func (p NotificationPreferences) Wants(t NotificationType) bool {
+	for _, w := range p.Types {
+		if w == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Location returns the time zone of the patron. Preferences are validated
+// when saved, so UTC only stands in for a zone since removed from tzdata.
+func (p NotificationPreferences) Location() *time.Location {
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// InQuietHours reports whether it is quiet hours for the patron at a time.
+func (p NotificationPreferences) InQuietHours(now time.Time) bool {
+	if p.QuietStart == p.QuietEnd {
+		return false
+	}
+	local := now.In(p.Location())
+	t := TimeOfDay(local.Hour()*60 + local.Minute())
+	if p.QuietStart < p.QuietEnd {
+		return p.QuietStart <= t && t < p.QuietEnd
+	}
+	return t >= p.QuietStart || t < p.QuietEnd
+}
+
+// DigestDue reports whether a digest is due at a time: the last digest went
+// out before the latest digest time of the patron.
+func (p NotificationPreferences) DigestDue(now time.Time) bool {
+	loc := p.Location()
+	scheduled := p.DigestTime.On(now.In(loc), loc)
+	if now.Before(scheduled) {
+		local := now.In(loc)
+		scheduled = p.DigestTime.On(time.Date(local.Year(), local.Month(), local.Day()-1, 12, 0, 0, 0, loc), loc)
+	}
+	return p.LastDigestAt == nil || p.LastDigestAt.Before(scheduled)
+}
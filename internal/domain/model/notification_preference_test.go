@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateNotificationPreferences(t *testing.T) {
+	p := DefaultNotificationPreferences(1, ChannelEmail, "Europe/Madrid")
+	assert.NoError(t, ValidateNotificationPreferences(p))
+	assert.True(t, p.Wants(NotificationHoldReady))
+
+	p.Types = []NotificationType{NotificationOverdue, NotificationOverdue}
+	assert.EqualError(t, ValidateNotificationPreferences(p), "notification type Overdue is listed twice")
+	p.Types = []NotificationType{"Birthday"}
+	assert.EqualError(t, ValidateNotificationPreferences(p), `unknown notification type "Birthday"`)
+	p.Types = nil
+	assert.NoError(t, ValidateNotificationPreferences(p), "patrons may opt out of everything")
+	assert.False(t, p.Wants(NotificationOverdue))
+
+	p.Delivery = "Weekly"
+	assert.EqualError(t, ValidateNotificationPreferences(p), `unknown delivery "Weekly"`)
+	p.Delivery = DeliveryDigest
+	p.TimeZone = "Mars/Olympus_Mons"
+	assert.EqualError(t, ValidateNotificationPreferences(p), `unknown time zone "Mars/Olympus_Mons"`)
+	p.TimeZone = "Europe/Madrid"
+	p.QuietEnd = 24 * 60
+	assert.EqualError(t, ValidateNotificationPreferences(p), "time 24:00 is not within a day")
+}
+
+func TestNotificationPreferences_InQuietHours(t *testing.T) {
+	p := DefaultNotificationPreferences(1, ChannelEmail, "Australia/Brisbane")
+	at := func(h, m int) time.Time {
+		// Brisbane is UTC+10 all year
+		return time.Date(2023, 6, 1, h, m, 0, 0, time.UTC).Add(-10 * time.Hour)
+	}
+	assert.False(t, p.InQuietHours(at(3, 0)), "no quiet hours by default")
+
+	// overnight quiet hours wrap midnight
+	p.QuietStart, p.QuietEnd = 22*60, 7*60
+	assert.True(t, p.InQuietHours(at(22, 0)))
+	assert.True(t, p.InQuietHours(at(3, 0)))
+	assert.False(t, p.InQuietHours(at(7, 0)))
+	assert.False(t, p.InQuietHours(at(12, 0)))
+
+	p.QuietStart, p.QuietEnd = 12*60, 14*60
+	assert.True(t, p.InQuietHours(at(13, 30)))
+	assert.False(t, p.InQuietHours(at(14, 0)))
+}
+
+func TestNotificationPreferences_DigestDue(t *testing.T) {
+	p := DefaultNotificationPreferences(1, ChannelEmail, "Australia/Brisbane")
+	p.Delivery = DeliveryDigest
+	// 08:00 in Brisbane is 22:00 UTC the day before
+	digestAt := time.Date(2023, 5, 31, 22, 0, 0, 0, time.UTC)
+	assert.True(t, p.DigestDue(digestAt), "never sent one")
+
+	p.LastDigestAt = &digestAt
+	assert.False(t, p.DigestDue(digestAt.Add(6*time.Hour)))
+	assert.False(t, p.DigestDue(digestAt.Add(24*time.Hour-time.Minute)))
+	assert.True(t, p.DigestDue(digestAt.Add(24*time.Hour)))
+
+	// the last one went out before yesterday's digest time
+	late := digestAt.Add(-time.Hour)
+	p.LastDigestAt = &late
+	assert.True(t, p.DigestDue(digestAt.Add(time.Hour)))
+}
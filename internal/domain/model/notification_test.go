@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNotificationChannel(t *testing.T) {
+	c, err := ParseNotificationChannel(" email ")
+	require.NoError(t, err)
+	assert.Equal(t, ChannelEmail, c)
+
+	_, err = ParseNotificationChannel("pigeon")
+	assert.EqualError(t, err, `unknown notification channel "pigeon"`)
+}
+
+func TestNotice_DedupKey(t *testing.T) {
+	due := time.Date(2023, 5, 22, 10, 0, 0, 0, time.UTC)
+	dueSoon := Notice{Type: NotificationDueSoon, ReferenceID: 2, DueDate: due}
+	overdue := Notice{Type: NotificationOverdue, ReferenceID: 2, DueDate: due}
+	assert.Equal(t, "DueSoon:loan:2:1684749600", dueSoon.DedupKey())
+	assert.NotEqual(t, dueSoon.DedupKey(), overdue.DedupKey())
+
+	// the same loan in another zone is the same notice
+	in := dueSoon
+	in.DueDate = due.In(time.FixedZone("UTC+10", 10*60*60))
+	assert.Equal(t, dueSoon.DedupKey(), in.DedupKey())
+
+	// renewed loans are owed a new reminder
+	renewed := dueSoon
+	renewed.DueDate = due.AddDate(0, 0, 21)
+	assert.NotEqual(t, dueSoon.DedupKey(), renewed.DedupKey())
+
+	ready := Notice{Type: NotificationHoldReady, ReferenceID: 2, ReadyAt: due}
+	assert.Equal(t, "HoldReady:hold:2:1684749600", ready.DedupKey())
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PatronExport is the personal data the library keeps about a patron, as
+// handed over on request.
+type PatronExport struct {
+	GeneratedAt time.Time
+	Profile     User
+	Cards       []*LibraryCard
+	// Loans are the loans still linked to the patron, returned or not.
+	Loans         []*BorrowedBook
+	Holds         []*Hold
+	Charges       []*Charge
+	Notifications []*SentNotification
+}
+
+// ErasedEmailDomain is the reserved domain the address of an erased patron
+// is moved to, keeping the address unique without pointing at anyone.
+const ErasedEmailDomain = "erased.invalid"
+
+// ErasedUser returns what is left of a patron once erased: a fresh UID and
+// no name or address, while the ID keeps charges and statistics consistent.
+func ErasedUser(u User, uid string) User {
+	u.UID = uid
+	u.Email = fmt.Sprintf("%s@%s", uid, ErasedEmailDomain)
+	u.Name = ""
+	u.EmailVerifiedAt = nil
+	return u
+}
+
+// ValidateErasure checks a patron can be erased: they may not have copies
+// out or owe the library anything. loans and charges are those of the patron.
+func ValidateErasure(u User, loans []*BorrowedBook, charges []*Charge) error {
+	if u.IsErased() {
+		return fmt.Errorf("patron %d is already erased", u.ID)
+	}
+
+	var reasons []string
+	var open int
+	for _, l := range loans {
+		if l.IsOpen() {
+			open++
+		}
+	}
+	if open > 0 {
+		reasons = append(reasons, fmt.Sprintf("has %d open loans", open))
+	}
+	if owed := OutstandingCents(charges); owed > 0 {
+		reasons = append(reasons, fmt.Sprintf("owes %s", formatCents(owed)))
+	}
+	if len(reasons) > 0 {
+		return fmt.Errorf("patron %d cannot be erased: %s", u.ID, strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// ReadingHistoryCutoff is the return date before which the returned loans of
+// patrons who did not opt in to keeping their history are unlinked from them.
+func ReadingHistoryCutoff(now time.Time, retentionDays int) time.Time {
+	return now.UTC().AddDate(0, 0, -retentionDays)
+}
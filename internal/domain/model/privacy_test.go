@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateErasure(t *testing.T) {
+	returned := time.Date(2023, 3, 20, 15, 0, 0, 0, time.UTC)
+	user := User{ID: 1, UID: "d8a4a06d-ab77-4188-a2eb-ad01ecc24e9b", Email: "user1@pageturnerpro.com", Name: "user1"}
+	loans := []*BorrowedBook{{ID: 1, ReturnDate: &returned}, {ID: 2}}
+	charges := []*Charge{{ID: 1, AmountCents: 1250}, {ID: 2, AmountCents: 300, ReversedAt: &returned}}
+
+	assert.EqualError(t, ValidateErasure(user, loans, charges), "patron 1 cannot be erased: has 1 open loans; owes 12.50")
+	assert.NoError(t, ValidateErasure(user, loans[:1], charges[1:]))
+
+	erased := ErasedUser(user, "0f6b1a43-8d5e-4c4e-9d7a-3a0c2f7e4b11")
+	assert.Equal(t, 1, erased.ID)
+	assert.Empty(t, erased.Name)
+	assert.Equal(t, "0f6b1a43-8d5e-4c4e-9d7a-3a0c2f7e4b11@erased.invalid", erased.Email)
+
+	erased.ErasedAt = &returned
+	assert.EqualError(t, ValidateErasure(erased, nil, nil), "patron 1 is already erased")
+}
+
+func TestReadingHistoryCutoff(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	now := time.Date(2023, 9, 1, 9, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2023, 8, 1, 23, 0, 0, 0, time.UTC), ReadingHistoryCutoff(now, 30))
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderDraft             PurchaseOrderStatus = "Draft"
+	PurchaseOrderOrdered           PurchaseOrderStatus = "Ordered"
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "PartiallyReceived"
+	PurchaseOrderReceived          PurchaseOrderStatus = "Received"
+	PurchaseOrderCancelled         PurchaseOrderStatus = "Cancelled"
+)
+
+func (s PurchaseOrderStatus) IsValid() bool {
+	switch s {
+	case PurchaseOrderDraft, PurchaseOrderOrdered, PurchaseOrderPartiallyReceived, PurchaseOrderReceived, PurchaseOrderCancelled:
+		return true
+	}
+	return false
+}
+
+// IsOpen reports whether copies are still expected from the vendor. The
+// lines of an open order encumber their funds.
+func (s PurchaseOrderStatus) IsOpen() bool {
+	return s == PurchaseOrderOrdered || s == PurchaseOrderPartiallyReceived
+}
+
+// PurchaseOrder is an order of copies placed with a vendor. It is drafted,
+// placed, which encumbers its funds, and received line by line.
+type PurchaseOrder struct {
+	ID       int
+	VendorID int
+	// BranchID is the branch the order is delivered to. It owns the copies received.
+	BranchID int
+	// Reference is the order number agreed with the vendor.
+	Reference string
+	Notes     string
+	Status    PurchaseOrderStatus
+	CreatedBy string
+	OrderedAt *time.Time
+	ClosedAt  *time.Time
+	Lines     []*PurchaseOrderLine
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewPurchaseOrder(vendorID, branchID int, reference, notes, createdBy string) PurchaseOrder {
+	return PurchaseOrder{
+		VendorID:  vendorID,
+		BranchID:  branchID,
+		Reference: reference,
+		Notes:     notes,
+		Status:    PurchaseOrderDraft,
+		CreatedBy: createdBy,
+	}
+}
+
+// TotalCents is the cost of the whole order.
+func (o PurchaseOrder) TotalCents() int64 {
+	var total int64
+	for _, l := range o.Lines {
+		total += l.TotalCents()
+	}
+	return total
+}
+
+// CommitmentsByFund sums the cost of the lines of the order by fund.
+func (o PurchaseOrder) CommitmentsByFund() map[int]int64 {
+	commitments := map[int]int64{}
+	for _, l := range o.Lines {
+		commitments[l.FundID] += l.TotalCents()
+	}
+	return commitments
+}
+
+// ReceivedStatus is the status of an order once some copies are received.
+func (o PurchaseOrder) ReceivedStatus() PurchaseOrderStatus {
+	for _, l := range o.Lines {
+		if l.Outstanding() > 0 {
+			return PurchaseOrderPartiallyReceived
+		}
+	}
+	return PurchaseOrderReceived
+}
+
+// ValidatePurchaseOrderPlacement checks an order can be sent to its vendor.
+func ValidatePurchaseOrderPlacement(o PurchaseOrder) error {
+	if o.Status != PurchaseOrderDraft {
+		return fmt.Errorf("purchase order %d is %s, only drafts can be placed", o.ID, o.Status)
+	}
+	if len(o.Lines) == 0 {
+		return fmt.Errorf("purchase order %d has no lines", o.ID)
+	}
+	return nil
+}
+
+// PurchaseOrderLine orders copies of a catalog record, charged to a fund.
+type PurchaseOrderLine struct {
+	ID               int
+	OrderID          int
+	BookID           int
+	FundID           int
+	Quantity         int
+	ReceivedQuantity int
+	UnitPriceCents   int64
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func NewPurchaseOrderLine(bookID, fundID, quantity int, unitPriceCents int64) (PurchaseOrderLine, error) {
+	if quantity <= 0 {
+		return PurchaseOrderLine{}, fmt.Errorf("quantity %d is not positive", quantity)
+	}
+	if unitPriceCents < 0 {
+		return PurchaseOrderLine{}, fmt.Errorf("unit price %d is negative", unitPriceCents)
+	}
+	return PurchaseOrderLine{
+		BookID:         bookID,
+		FundID:         fundID,
+		Quantity:       quantity,
+		UnitPriceCents: unitPriceCents,
+	}, nil
+}
+
+// Outstanding is the number of copies still expected.
+func (l PurchaseOrderLine) Outstanding() int {
+	return l.Quantity - l.ReceivedQuantity
+}
+
+func (l PurchaseOrderLine) TotalCents() int64 {
+	return int64(l.Quantity) * l.UnitPriceCents
+}
+
+// ValidateReceipt checks copies of an order line can be received.
+func ValidateReceipt(o PurchaseOrder, l PurchaseOrderLine, quantity int) error {
+	if !o.Status.IsOpen() {
+		return fmt.Errorf("purchase order %d is %s, copies are received on placed orders only", o.ID, o.Status)
+	}
+	if quantity <= 0 {
+		return fmt.Errorf("quantity %d is not positive", quantity)
+	}
+	if quantity > l.Outstanding() {
+		return fmt.Errorf("line %d expects %d more copies, not %d", l.ID, l.Outstanding(), quantity)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPurchaseOrderLine(t *testing.T) {
+	l, err := NewPurchaseOrderLine(1, 1, 3, 1250)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3750), l.TotalCents())
+	assert.Equal(t, 3, l.Outstanding())
+
+	_, err = NewPurchaseOrderLine(1, 1, 0, 1250)
+	assert.Error(t, err)
+	_, err = NewPurchaseOrderLine(1, 1, 1, -1)
+	assert.Error(t, err)
+}
+
+func TestPurchaseOrder_Commitments(t *testing.T) {
+	o := PurchaseOrder{Lines: []*PurchaseOrderLine{
+		{FundID: 1, Quantity: 2, UnitPriceCents: 1000},
+		{FundID: 2, Quantity: 1, UnitPriceCents: 500},
+		{FundID: 1, Quantity: 1, UnitPriceCents: 300},
+	}}
+	assert.Equal(t, int64(2800), o.TotalCents())
+	assert.Equal(t, map[int]int64{1: 2300, 2: 500}, o.CommitmentsByFund())
+}
+
+func TestPurchaseOrder_ReceivedStatus(t *testing.T) {
+	o := PurchaseOrder{Status: PurchaseOrderOrdered, Lines: []*PurchaseOrderLine{
+		{ID: 1, Quantity: 2, ReceivedQuantity: 2},
+		{ID: 2, Quantity: 3, ReceivedQuantity: 1},
+	}}
+	assert.Equal(t, PurchaseOrderPartiallyReceived, o.ReceivedStatus())
+
+	o.Lines[1].ReceivedQuantity = 3
+	assert.Equal(t, PurchaseOrderReceived, o.ReceivedStatus())
+}
+
+func TestValidatePurchaseOrderPlacement(t *testing.T) {
+	draft := NewPurchaseOrder(1, 1, "PO-1", "", "librarian")
+	assert.Error(t, ValidatePurchaseOrderPlacement(draft))
+
+	draft.Lines = []*PurchaseOrderLine{{Quantity: 1}}
+	assert.NoError(t, ValidatePurchaseOrderPlacement(draft))
+
+	draft.Status = PurchaseOrderOrdered
+	assert.Error(t, ValidatePurchaseOrderPlacement(draft))
+}
+
+func TestValidateReceipt(t *testing.T) {
+	l := PurchaseOrderLine{ID: 1, Quantity: 3, ReceivedQuantity: 1}
+
+	assert.NoError(t, ValidateReceipt(PurchaseOrder{Status: PurchaseOrderOrdered}, l, 2))
+	assert.NoError(t, ValidateReceipt(PurchaseOrder{Status: PurchaseOrderPartiallyReceived}, l, 1))
+	assert.Error(t, ValidateReceipt(PurchaseOrder{Status: PurchaseOrderOrdered}, l, 3))
+	assert.Error(t, ValidateReceipt(PurchaseOrder{Status: PurchaseOrderOrdered}, l, 0))
+	assert.Error(t, ValidateReceipt(PurchaseOrder{Status: PurchaseOrderDraft}, l, 1))
+	assert.Error(t, ValidateReceipt(PurchaseOrder{Status: PurchaseOrderCancelled}, l, 1))
+}
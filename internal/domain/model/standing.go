@@ -0,0 +1,153 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type BlockKind string
+
+const (
+	// BlockFines is set when the charges owed go over the threshold.
+	BlockFines BlockKind = "Fines"
+	// BlockMembershipExpired is set once the last day of the membership has passed.
+	BlockMembershipExpired BlockKind = "MembershipExpired"
+	// BlockOverdue is set when more loans are overdue than allowed.
+	BlockOverdue BlockKind = "Overdue"
+	// BlockManual is set while staff keep a block on the account.
+	BlockManual BlockKind = "Manual"
+)
+
+// StandingPolicy sets when a patron may no longer borrow, renew or place
+// holds. A zero limit turns its rule off.
+type StandingPolicy struct {
+	// MaxOutstandingCents is the most a patron may owe and still borrow.
+	MaxOutstandingCents int64
+	// MaxOverdueLoans is the most loans a patron may have overdue and still borrow.
+	MaxOverdueLoans int
+	// Location is the time zone of the library, in which memberships lapse.
+	Location *time.Location
+}
+
+// ManualBlock is a block staff put on an account, e.g. while a dispute is
+// settled. It holds until lifted.
+type ManualBlock struct {
+	ID        int
+	UserID    int
+	Reason    string
+	BlockedBy string
+	LiftedAt  *time.Time
+	LiftedBy  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewManualBlock(userID int, reason, blockedBy string) ManualBlock {
+	return ManualBlock{
+		UserID:    userID,
+		Reason:    strings.TrimSpace(reason),
+		BlockedBy: blockedBy,
+	}
+}
+
+func (b ManualBlock) IsActive() bool {
+	return b.LiftedAt == nil
+}
+
+// ValidateManualBlock checks staff said why they block an account.
+func ValidateManualBlock(b ManualBlock) error {
+	if b.Reason == "" {
+		return fmt.Errorf("block reason is empty")
+	}
+	return nil
+}
+
+// ValidateBlockLift checks a manual block can be lifted.
+func ValidateBlockLift(b ManualBlock) error {
+	if !b.IsActive() {
+		return fmt.Errorf("block %d is already lifted", b.ID)
+	}
+	return nil
+}
+
+// AccountFacts are what the standing of an account is judged on.
+type AccountFacts struct {
+	UserID int
+	// MembershipExpiresAt is the last day of the membership, as a UTC
+	// midnight; nil for a membership that does not lapse.
+	MembershipExpiresAt *time.Time
+	OutstandingCents    int64
+	OverdueLoans        int
+	// ManualBlocks are the blocks staff have not lifted.
+	ManualBlocks []ManualBlock
+}
+
+// Block is a reason a patron may not borrow.
+type Block struct {
+	Kind   BlockKind
+	Reason string
+}
+
+// AccountStanding is the judgement of an account: it is in good standing
+// when nothing blocks it.
+type AccountStanding struct {
+	AccountFacts
+	Blocks []Block
+}
+
+func (s AccountStanding) IsBlocked() bool {
+	return len(s.Blocks) > 0
+}
+
+// Explain tells staff at the desk every reason the patron is blocked.
+func (s AccountStanding) Explain() string {
+	if !s.IsBlocked() {
+		return fmt.Sprintf("patron %d is in good standing", s.UserID)
+	}
+	reasons := make([]string, 0, len(s.Blocks))
+	for _, b := range s.Blocks {
+		reasons = append(reasons, b.Reason)
+	}
+	return fmt.Sprintf("patron %d is blocked: %s", s.UserID, strings.Join(reasons, "; "))
+}
+
+// BlockKinds lists the kinds of the blocks on the account.
+func (s AccountStanding) BlockKinds() []BlockKind {
+	kinds := make([]BlockKind, 0, len(s.Blocks))
+	for _, b := range s.Blocks {
+		kinds = append(kinds, b.Kind)
+	}
+	return kinds
+}
+
+// Assess judges an account at a time.
+func (p StandingPolicy) Assess(f AccountFacts, now time.Time) AccountStanding {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	today := CalendarDay(now, loc)
+	standing := AccountStanding{AccountFacts: f}
+	block := func(kind BlockKind, format string, args ...interface{}) {
+		standing.Blocks = append(standing.Blocks, Block{Kind: kind, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if p.MaxOutstandingCents > 0 && f.OutstandingCents > p.MaxOutstandingCents {
+		block(BlockFines, "owes %s, over the limit of %s", formatCents(f.OutstandingCents), formatCents(p.MaxOutstandingCents))
+	}
+	if f.MembershipExpiresAt != nil && today.After(*f.MembershipExpiresAt) {
+		block(BlockMembershipExpired, "membership expired on %s", f.MembershipExpiresAt.Format("2006-01-02"))
+	}
+	if p.MaxOverdueLoans > 0 && f.OverdueLoans > p.MaxOverdueLoans {
+		block(BlockOverdue, "has %d overdue loans, over the limit of %d", f.OverdueLoans, p.MaxOverdueLoans)
+	}
+	for _, b := range f.ManualBlocks {
+		block(BlockManual, "blocked by %s: %s", b.BlockedBy, b.Reason)
+	}
+	return standing
+}
+
+func formatCents(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandingPolicy_Assess(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	policy := StandingPolicy{MaxOutstandingCents: 1000, MaxOverdueLoans: 2, Location: loc}
+	today := time.Date(2023, 9, 1, 9, 0, 0, 0, loc)
+
+	standing := policy.Assess(AccountFacts{UserID: 1, OutstandingCents: 1000, OverdueLoans: 2}, today)
+	assert.False(t, standing.IsBlocked())
+	assert.Equal(t, "patron 1 is in good standing", standing.Explain())
+
+	expiry := time.Date(2023, 8, 31, 0, 0, 0, 0, time.UTC)
+	standing = policy.Assess(AccountFacts{
+		UserID:              1,
+		MembershipExpiresAt: &expiry,
+		OutstandingCents:    1250,
+		OverdueLoans:        3,
+		ManualBlocks:        []ManualBlock{NewManualBlock(1, " disputed charge ", "desk")},
+	}, today)
+	assert.True(t, standing.IsBlocked())
+	assert.Equal(t, []BlockKind{BlockFines, BlockMembershipExpired, BlockOverdue, BlockManual}, standing.BlockKinds())
+	assert.Equal(t, "patron 1 is blocked: owes 12.50, over the limit of 10.00; membership expired on 2023-08-31; "+
+		"has 3 overdue loans, over the limit of 2; blocked by desk: disputed charge", standing.Explain())
+
+	// the membership holds through its last day in the library's time zone
+	standing = policy.Assess(AccountFacts{UserID: 1, MembershipExpiresAt: &expiry}, time.Date(2023, 8, 31, 23, 0, 0, 0, loc))
+	assert.False(t, standing.IsBlocked())
+	standing = policy.Assess(AccountFacts{UserID: 1, MembershipExpiresAt: &expiry}, time.Date(2023, 8, 31, 15, 0, 0, 0, time.UTC))
+	assert.True(t, standing.IsBlocked())
+
+	// zero limits turn their rules off
+	standing = StandingPolicy{}.Assess(AccountFacts{UserID: 1, OutstandingCents: 99999, OverdueLoans: 40}, today)
+	assert.False(t, standing.IsBlocked())
+}
+
+func TestValidateManualBlock(t *testing.T) {
+	assert.Error(t, ValidateManualBlock(NewManualBlock(1, "  ", "desk")))
+	assert.NoError(t, ValidateManualBlock(NewManualBlock(1, "lost card reported stolen", "desk")))
+
+	lifted := time.Now()
+	block := ManualBlock{ID: 3, LiftedAt: &lifted}
+	assert.Error(t, ValidateBlockLift(block))
+	assert.NoError(t, ValidateBlockLift(ManualBlock{ID: 3}))
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type StocktakeStatus string
+
+const (
+	StocktakeOpen   StocktakeStatus = "Open"
+	StocktakeClosed StocktakeStatus = "Closed"
+)
+
+// ShelfRange is a run of shelves between two call numbers of a scheme. The
+// upper bound takes in every call number it is a prefix of, so 800 to 899
+// holds 899.9 as well.
+type ShelfRange struct {
+	Scheme ClassificationScheme
+	From   CallNumber
+	To     CallNumber
+}
+
+func NewShelfRange(scheme ClassificationScheme, from, to string) (ShelfRange, error) {
+	fromCN, err := ParseCallNumber(scheme, from)
+	if err != nil {
+		return ShelfRange{}, fmt.Errorf("range start: %w", err)
+	}
+	toCN, err := ParseCallNumber(scheme, to)
+	if err != nil {
+		return ShelfRange{}, fmt.Errorf("range end: %w", err)
+	}
+	if fromCN.SortKey > toCN.SortKey {
+		return ShelfRange{}, fmt.Errorf("range start %q is after range end %q", from, to)
+	}
+	return ShelfRange{Scheme: scheme, From: fromCN, To: toCN}, nil
+}
+
+// Contains reports whether a call number is shelved within the range.
+func (r ShelfRange) Contains(scheme ClassificationScheme, sortKey string) bool {
+	if scheme != r.Scheme || sortKey == "" {
+		return false
+	}
+	prefix := sortKey
+	if len(prefix) > len(r.To.SortKey) {
+		prefix = prefix[:len(r.To.SortKey)]
+	}
+	return sortKey >= r.From.SortKey && prefix <= r.To.SortKey
+}
+
+// Stocktake is a shelf audit of a branch, or of a shelf range in a branch.
+// Staff scan every copy they find; the copies expected on the shelf but not
+// scanned are missing.
+type Stocktake struct {
+	ID       int
+	BranchID int
+	Range    *ShelfRange
+	Status   StocktakeStatus
+	// StartedBy and ClosedBy are the staff members opening and closing the session.
+	StartedBy string
+	ClosedBy  string
+	ClosedAt  *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewStocktake(branchID int, shelfRange *ShelfRange, startedBy string) Stocktake {
+	return Stocktake{
+		BranchID:  branchID,
+		Range:     shelfRange,
+		Status:    StocktakeOpen,
+		StartedBy: startedBy,
+	}
+}
+
+// NormalizeScannedBarcode cleans a barcode as read by a scanner. Barcodes
+// are not validated: an unreadable label is reported as unknown.
+func NormalizeScannedBarcode(raw string) string {
+	return strings.ToUpper(strings.TrimSpace(raw))
+}
+
+// StocktakeItem is a copy met during a stocktake, with where it belongs.
+type StocktakeItem struct {
+	// CopyID is nil for a scanned barcode labelling no copy.
+	CopyID          *int
+	Barcode         string
+	BookID          int
+	Title           string
+	Status          BookStatus
+	CurrentBranchID int
+	Scheme          ClassificationScheme
+	CallNumber      string
+	SortKey         string
+}
+
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyMissing copies are on the shelf for the catalogue but were not scanned.
+	DiscrepancyMissing DiscrepancyKind = "Missing"
+	// DiscrepancyBorrowed copies were scanned but are on loan for the catalogue.
+	DiscrepancyBorrowed DiscrepancyKind = "Borrowed"
+	// DiscrepancyWrongShelf copies were scanned outside their branch or shelf range.
+	DiscrepancyWrongShelf DiscrepancyKind = "WrongShelf"
+	// DiscrepancyUnknownBarcode barcodes were scanned but label no copy.
+	DiscrepancyUnknownBarcode DiscrepancyKind = "UnknownBarcode"
+)
+
+type StocktakeDiscrepancy struct {
+	Kind DiscrepancyKind
+	Item StocktakeItem
+}
+
+// ReconcileStocktake lists the discrepancies of a stocktake from the copies
+// expected on the shelf but not scanned and from the scanned items.
+func ReconcileStocktake(s Stocktake, missing []*StocktakeItem, scanned []*StocktakeItem) []*StocktakeDiscrepancy {
+	var discrepancies []*StocktakeDiscrepancy
+	for _, item := range missing {
+		discrepancies = append(discrepancies, &StocktakeDiscrepancy{Kind: DiscrepancyMissing, Item: *item})
+	}
+
+	for _, item := range scanned {
+		if item.CopyID == nil {
+			discrepancies = append(discrepancies, &StocktakeDiscrepancy{Kind: DiscrepancyUnknownBarcode, Item: *item})
+			continue
+		}
+		if item.Status == Borrowed {
+			discrepancies = append(discrepancies, &StocktakeDiscrepancy{Kind: DiscrepancyBorrowed, Item: *item})
+		}
+		wrongBranch := item.CurrentBranchID != s.BranchID
+		outOfRange := s.Range != nil && !s.Range.Contains(item.Scheme, item.SortKey)
+		if wrongBranch || outOfRange {
+			discrepancies = append(discrepancies, &StocktakeDiscrepancy{Kind: DiscrepancyWrongShelf, Item: *item})
+		}
+	}
+	return discrepancies
+}
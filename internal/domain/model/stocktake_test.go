@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sortKey(t *testing.T, scheme ClassificationScheme, raw string) string {
+	cn, err := ParseCallNumber(scheme, raw)
+	require.NoError(t, err)
+	return cn.SortKey
+}
+
+func TestShelfRange_Contains(t *testing.T) {
+	rng, err := NewShelfRange(ClassificationDewey, "800", "899")
+	require.NoError(t, err)
+
+	assert.True(t, rng.Contains(ClassificationDewey, sortKey(t, ClassificationDewey, "800")))
+	assert.True(t, rng.Contains(ClassificationDewey, sortKey(t, ClassificationDewey, "863.3 CER")))
+	assert.True(t, rng.Contains(ClassificationDewey, sortKey(t, ClassificationDewey, "899.9 ZAM")))
+	assert.False(t, rng.Contains(ClassificationDewey, sortKey(t, ClassificationDewey, "799.9")))
+	assert.False(t, rng.Contains(ClassificationDewey, sortKey(t, ClassificationDewey, "900")))
+	assert.False(t, rng.Contains(ClassificationLC, sortKey(t, ClassificationLC, "QA76.73")))
+	assert.False(t, rng.Contains(ClassificationDewey, ""))
+
+	_, err = NewShelfRange(ClassificationDewey, "899", "800")
+	assert.Error(t, err)
+	_, err = NewShelfRange(ClassificationDewey, "800", "")
+	assert.Error(t, err)
+}
+
+func TestReconcileStocktake(t *testing.T) {
+	rng, err := NewShelfRange(ClassificationDewey, "800", "899")
+	require.NoError(t, err)
+	s := NewStocktake(1, &rng, "librarian")
+
+	copyID := func(id int) *int { return &id }
+	onShelf := StocktakeItem{CopyID: copyID(1), Barcode: "1", Status: InLibrary, CurrentBranchID: 1, Scheme: ClassificationDewey, SortKey: sortKey(t, ClassificationDewey, "863.3 CER")}
+	onLoan := StocktakeItem{CopyID: copyID(2), Barcode: "2", Status: Borrowed, CurrentBranchID: 1, Scheme: ClassificationDewey, SortKey: sortKey(t, ClassificationDewey, "843.912 SAI")}
+	otherBranch := StocktakeItem{CopyID: copyID(3), Barcode: "3", Status: InLibrary, CurrentBranchID: 2, Scheme: ClassificationDewey, SortKey: sortKey(t, ClassificationDewey, "823 ORW")}
+	otherShelf := StocktakeItem{CopyID: copyID(4), Barcode: "4", Status: InLibrary, CurrentBranchID: 1, Scheme: ClassificationDewey, SortKey: sortKey(t, ClassificationDewey, "510 KNU")}
+	unknown := StocktakeItem{Barcode: "X"}
+	missing := StocktakeItem{CopyID: copyID(5), Barcode: "5", Status: InLibrary, CurrentBranchID: 1}
+
+	discrepancies := ReconcileStocktake(s,
+		[]*StocktakeItem{&missing},
+		[]*StocktakeItem{&onShelf, &onLoan, &otherBranch, &otherShelf, &unknown},
+	)
+
+	kinds := map[string]DiscrepancyKind{}
+	for _, d := range discrepancies {
+		kinds[d.Item.Barcode] = d.Kind
+	}
+	assert.Equal(t, map[string]DiscrepancyKind{
+		"5": DiscrepancyMissing,
+		"2": DiscrepancyBorrowed,
+		"3": DiscrepancyWrongShelf,
+		"4": DiscrepancyWrongShelf,
+		"X": DiscrepancyUnknownBarcode,
+	}, kinds)
+}
@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+// Subject is a node in the subject/genre taxonomy. Root subjects have no parent.
+type Subject struct {
+	ID        int
+	ParentID  *int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewSubject(name string, parentID *int) Subject {
+	return Subject{
+		Name:     name,
+		ParentID: parentID,
+	}
+}
+
+// SubjectNode is a subject together with its children in the taxonomy tree.
+type SubjectNode struct {
+	Subject
+	Children []*SubjectNode
+}
+
+// BuildSubjectTree arranges a flat list of subjects into trees, returning the
+// root nodes. Children keep the order of the input list. Subjects whose parent
+// is not in the list are treated as roots.
+func BuildSubjectTree(subjects []*Subject) []*SubjectNode {
+	nodes := make(map[int]*SubjectNode, len(subjects))
+	for _, s := range subjects {
+		nodes[s.ID] = &SubjectNode{Subject: *s}
+	}
+
+	var roots []*SubjectNode
+	for _, s := range subjects {
+		node := nodes[s.ID]
+		if s.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		parent, ok := nodes[*s.ParentID]
+		if !ok {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return roots
+}
+
+// FindSubjectNode looks up the node with the given ID among the trees.
+func FindSubjectNode(roots []*SubjectNode, id int) *SubjectNode {
+	for _, n := range roots {
+		if n.ID == id {
+			return n
+		}
+		if found := FindSubjectNode(n.Children, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Contains reports whether the subject with the given ID is the node itself
+// or one of its descendants.
+func (n *SubjectNode) Contains(id int) bool {
+	return FindSubjectNode([]*SubjectNode{n}, id) != nil
+}
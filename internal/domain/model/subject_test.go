@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSubjectTree(t *testing.T) {
+	fiction, fantasy, epic, history := 1, 2, 3, 4
+	subjects := []*Subject{
+		{ID: fiction, Name: "Fiction"},
+		{ID: fantasy, Name: "Fantasy", ParentID: &fiction},
+		{ID: epic, Name: "Epic fantasy", ParentID: &fantasy},
+		{ID: history, Name: "History"},
+	}
+
+	roots := BuildSubjectTree(subjects)
+	require.Len(t, roots, 2)
+	assert.Equal(t, "Fiction", roots[0].Name)
+	assert.Equal(t, "History", roots[1].Name)
+	require.Len(t, roots[0].Children, 1)
+	require.Len(t, roots[0].Children[0].Children, 1)
+	assert.Equal(t, "Epic fantasy", roots[0].Children[0].Children[0].Name)
+
+	node := FindSubjectNode(roots, fantasy)
+	require.NotNil(t, node)
+	assert.True(t, node.Contains(epic))
+	assert.True(t, node.Contains(fantasy))
+	assert.False(t, node.Contains(fiction))
+	assert.Nil(t, FindSubjectNode(roots, 99))
+}
+
+func TestBuildSubjectTree_OrphanBecomesRoot(t *testing.T) {
+	missing := 42
+	roots := BuildSubjectTree([]*Subject{{ID: 1, Name: "Orphan", ParentID: &missing}})
+	require.Len(t, roots, 1)
+	assert.Equal(t, 1, roots[0].ID)
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type TransferStatus string
+
+const (
+	TransferRequested TransferStatus = "Requested"
+	TransferInTransit TransferStatus = "InTransit"
+	TransferReceived  TransferStatus = "Received"
+	TransferCancelled TransferStatus = "Cancelled"
+)
+
+func (s TransferStatus) IsValid() bool {
+	switch s {
+	case TransferRequested, TransferInTransit, TransferReceived, TransferCancelled:
+		return true
+	}
+	return false
+}
+
+// IsOpen reports whether the copy has not reached its destination yet.
+func (s TransferStatus) IsOpen() bool {
+	return s == TransferRequested || s == TransferInTransit
+}
+
+// Transfer moves a copy from the branch it sits at to another branch. The
+// copy stays on the shelf until it is dispatched, travels as InTransit and
+// changes its current branch when it is received.
+type Transfer struct {
+	ID           int
+	CopyID       int
+	FromBranchID int
+	ToBranchID   int
+	// HoldID is set when the copy travels to the pickup branch of a hold.
+	HoldID       *int
+	Status       TransferStatus
+	RequestedBy  string
+	Reason       string
+	DispatchedAt *time.Time
+	ReceivedAt   *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func NewTransfer(copyID, fromBranchID, toBranchID int, requestedBy, reason string) Transfer {
+	return Transfer{
+		CopyID:       copyID,
+		FromBranchID: fromBranchID,
+		ToBranchID:   toBranchID,
+		Status:       TransferRequested,
+		RequestedBy:  requestedBy,
+		Reason:       reason,
+	}
+}
+
+// ValidateTransferRequest checks a copy can start travelling to a branch.
+func ValidateTransferRequest(c BookCopies, toBranchID int) error {
+	if c.IsRetired() {
+		return fmt.Errorf("copy %d is retired", c.ID)
+	}
+	if c.Status != InLibrary {
+		return fmt.Errorf("copy %d is %s, only copies on the shelf can be transferred", c.ID, c.Status)
+	}
+	if c.CurrentBranchID == toBranchID {
+		return fmt.Errorf("copy %d is already at branch %d", c.ID, toBranchID)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTransferRequest(t *testing.T) {
+	c := BookCopies{ID: 1, Status: InLibrary, HomeBranchID: 1, CurrentBranchID: 1}
+	assert.NoError(t, ValidateTransferRequest(c, 2))
+	assert.Error(t, ValidateTransferRequest(c, 1))
+
+	c.Status = Borrowed
+	assert.Error(t, ValidateTransferRequest(c, 2))
+}
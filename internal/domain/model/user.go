@@ -3,18 +3,48 @@ package model
 import "time"
 
 type User struct {
-	ID        int
-	UID       string
-	Email     string
-	Name      string
+	ID    int
+	UID   string
+	Email string
+	Name  string
+	// Category is the patron category of the user.
+	Category PatronCategory
+	// MembershipStartsAt is the first day of the membership, as a UTC midnight.
+	MembershipStartsAt time.Time
+	// MembershipExpiresAt is the last day of the membership, as a UTC
+	// midnight; nil for a membership that does not lapse.
+	MembershipExpiresAt *time.Time
+	// EmailVerifiedAt is set once the patron confirmed they own their
+	// address; nil for unverified addresses.
+	EmailVerifiedAt *time.Time
+	// KeepReadingHistory is set when the patron opted in to the library
+	// keeping their returned loans; otherwise they are unlinked from the
+	// patron once the retention window passes.
+	KeepReadingHistory bool
+	// ErasedAt is set once the personal data of the user has been erased.
+	ErasedAt  *time.Time
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+func (u User) IsErased() bool {
+	return u.ErasedAt != nil
+}
+
+// Membership returns the membership of the user.
+func (u User) Membership() Membership {
+	return Membership{
+		Category:  u.Category,
+		StartsAt:  u.MembershipStartsAt,
+		ExpiresAt: u.MembershipExpiresAt,
+	}
+}
+
 func NewUser(uid, email, name string) User {
 	return User{
-		UID:   uid,
-		Email: email,
-		Name:  name,
+		UID:      uid,
+		Email:    email,
+		Name:     name,
+		Category: PatronAdult,
 	}
 }
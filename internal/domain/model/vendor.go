@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Vendor is a bookseller or distributor the library orders from.
+type Vendor struct {
+	ID        int
+	Code      string
+	Name      string
+	Email     string
+	Phone     string
+	Address   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NormalizeVendorCode upper-cases a vendor code and checks it is a short identifier.
+func NormalizeVendorCode(raw string) (string, error) {
+	return normalizeCode("vendor", raw)
+}
+
+func NewVendor(code, name, email, phone, address string) Vendor {
+	return Vendor{
+		Code:    code,
+		Name:    name,
+		Email:   email,
+		Phone:   phone,
+		Address: address,
+	}
+}
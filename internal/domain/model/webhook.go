@@ -0,0 +1,214 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type WebhookEventType string
+
+const (
+	// WebhookLoanCheckedOut is sent when a copy is lent to a patron.
+	WebhookLoanCheckedOut WebhookEventType = "loan.checked_out"
+	// WebhookLoanReturned is sent when a copy on loan is checked in.
+	WebhookLoanReturned WebhookEventType = "loan.returned"
+	// WebhookHoldReady is sent when a held copy waits at its pickup branch.
+	WebhookHoldReady WebhookEventType = "hold.ready"
+	// WebhookCatalogChanged is sent when works, editions or copies are added
+	// to, updated in or retired from the catalog.
+	WebhookCatalogChanged WebhookEventType = "catalog.changed"
+)
+
+// WebhookEventTypes are the events integrations may subscribe to.
+var WebhookEventTypes = []WebhookEventType{
+	WebhookLoanCheckedOut,
+	WebhookLoanReturned,
+	WebhookHoldReady,
+	WebhookCatalogChanged,
+}
+
+func (t WebhookEventType) IsValid() bool {
+	for _, v := range WebhookEventTypes {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+type CatalogEntity string
+
+const (
+	CatalogWork CatalogEntity = "work"
+	CatalogBook CatalogEntity = "book"
+	CatalogCopy CatalogEntity = "copy"
+)
+
+type CatalogAction string
+
+const (
+	CatalogCreated CatalogAction = "created"
+	CatalogUpdated CatalogAction = "updated"
+	CatalogRetired CatalogAction = "retired"
+)
+
+// CatalogAction is the change integrations hear about for a record loaded by
+// an import.
+func (a ImportAction) CatalogAction() CatalogAction {
+	if a == ImportActionUpdate {
+		return CatalogUpdated
+	}
+	return CatalogCreated
+}
+
+// CatalogChange tells which record of the catalog changed and how.
+type CatalogChange struct {
+	Entity CatalogEntity
+	ID     int
+	Action CatalogAction
+}
+
+// WebhookEvent is something that happened which integrations may hear about.
+// The field matching its type tells what it happened to.
+type WebhookEvent struct {
+	Type       WebhookEventType
+	OccurredAt time.Time
+	Loan       *BorrowedBook
+	Hold       *Hold
+	Catalog    *CatalogChange
+}
+
+func LoanEvent(typ WebhookEventType, loan BorrowedBook) WebhookEvent {
+	return WebhookEvent{Type: typ, OccurredAt: time.Now(), Loan: &loan}
+}
+
+func HoldReadyEvent(hold Hold) WebhookEvent {
+	return WebhookEvent{Type: WebhookHoldReady, OccurredAt: time.Now(), Hold: &hold}
+}
+
+func CatalogEvent(entity CatalogEntity, id int, action CatalogAction) WebhookEvent {
+	return WebhookEvent{
+		Type:       WebhookCatalogChanged,
+		OccurredAt: time.Now(),
+		Catalog:    &CatalogChange{Entity: entity, ID: id, Action: action},
+	}
+}
+
+// WebhookSubscription is an endpoint of an integration which is sent the
+// events it subscribed to, signed with its secret.
+type WebhookSubscription struct {
+	ID          int
+	URL         string
+	Description string
+	Events      []WebhookEventType
+	Secret      string
+	// Active subscriptions are sent events; inactive ones keep what they
+	// were owed until they are active again.
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Wants reports whether the subscription is sent events of a type.
+func (s WebhookSubscription) Wants(t WebhookEventType) bool {
+	if !s.Active {
+		return false
+	}
+	for _, e := range s.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateWebhookSubscription checks a subscription names an HTTP endpoint
+// and events that exist.
+func ValidateWebhookSubscription(s WebhookSubscription) error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q is not an http or https URL", s.URL)
+	}
+	if len(s.Events) == 0 {
+		return fmt.Errorf("no events to subscribe to")
+	}
+	seen := map[WebhookEventType]bool{}
+	for _, e := range s.Events {
+		if !e.IsValid() {
+			return fmt.Errorf("unknown event %q", e)
+		}
+		if seen[e] {
+			return fmt.Errorf("event %s is listed twice", e)
+		}
+		seen[e] = true
+	}
+	if s.Secret == "" {
+		return fmt.Errorf("secret is empty")
+	}
+	return nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookPending deliveries wait for their next attempt.
+	WebhookPending WebhookDeliveryStatus = "Pending"
+	// WebhookDelivered deliveries were acknowledged by their endpoint.
+	WebhookDelivered WebhookDeliveryStatus = "Delivered"
+	// WebhookDeadLettered deliveries ran out of attempts. They are kept
+	// until redelivered by hand.
+	WebhookDeadLettered WebhookDeliveryStatus = "DeadLettered"
+)
+
+func (s WebhookDeliveryStatus) IsValid() bool {
+	return s == WebhookPending || s == WebhookDelivered || s == WebhookDeadLettered
+}
+
+// WebhookDelivery is one event on its way to one subscription. Its payload
+// is fixed when the event happens, so that every attempt sends the same body.
+type WebhookDelivery struct {
+	ID             int
+	SubscriptionID int
+	EventID        string
+	EventType      WebhookEventType
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      string
+	DeliveredAt    *time.Time
+	DeadLetteredAt *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookRetryPolicy spaces out the attempts to deliver an event. The wait
+// doubles after each failed attempt, from BaseDelay up to MaxDelay, and a
+// delivery is dead-lettered once MaxAttempts failed.
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff is how long to wait after a number of failed attempts.
+func (p WebhookRetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Exhausted reports whether a delivery failing that many times is given up.
+func (p WebhookRetryPolicy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWebhookSubscription(t *testing.T) {
+	s := WebhookSubscription{
+		URL:    "https://discovery.example.com/hooks",
+		Events: []WebhookEventType{WebhookLoanCheckedOut, WebhookLoanReturned},
+		Secret: "secret",
+		Active: true,
+	}
+	assert.NoError(t, ValidateWebhookSubscription(s))
+	assert.True(t, s.Wants(WebhookLoanReturned))
+	assert.False(t, s.Wants(WebhookHoldReady))
+	s.Active = false
+	assert.False(t, s.Wants(WebhookLoanReturned), "paused")
+
+	s.URL = "discovery.example.com/hooks"
+	assert.EqualError(t, ValidateWebhookSubscription(s), `url "discovery.example.com/hooks" is not an http or https URL`)
+	s.URL = "http://localhost:8081/"
+	s.Events = []WebhookEventType{WebhookHoldReady, WebhookHoldReady}
+	assert.EqualError(t, ValidateWebhookSubscription(s), "event hold.ready is listed twice")
+	s.Events = []WebhookEventType{"hold.cancelled"}
+	assert.EqualError(t, ValidateWebhookSubscription(s), `unknown event "hold.cancelled"`)
+	s.Events = nil
+	assert.EqualError(t, ValidateWebhookSubscription(s), "no events to subscribe to")
+}
+
+func TestWebhookRetryPolicy(t *testing.T) {
+	p := WebhookRetryPolicy{MaxAttempts: 8, BaseDelay: 30 * time.Second, MaxDelay: 10 * time.Minute}
+	assert.Equal(t, 30*time.Second, p.Backoff(1))
+	assert.Equal(t, time.Minute, p.Backoff(2))
+	assert.Equal(t, 8*time.Minute, p.Backoff(5))
+	assert.Equal(t, 10*time.Minute, p.Backoff(6))
+	assert.Equal(t, 10*time.Minute, p.Backoff(60))
+	assert.False(t, p.Exhausted(7))
+	assert.True(t, p.Exhausted(8))
+}
+
+func TestImportAction_CatalogAction(t *testing.T) {
+	assert.Equal(t, CatalogCreated, ImportActionCreate.CatalogAction())
+	assert.Equal(t, CatalogUpdated, ImportActionUpdate.CatalogAction())
+}
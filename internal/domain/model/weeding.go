@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// WeedingFilter narrows a weeding report to the copies owned by a branch and
+// to the books filed under a subject or any of its descendants. A zero ID
+// matches any.
+type WeedingFilter struct {
+	BranchID  int
+	SubjectID int
+}
+
+// WeedingCopy is a copy listed in a weeding report, with its circulation.
+type WeedingCopy struct {
+	CopyID       int
+	Barcode      string
+	BookID       int
+	Title        string
+	Author       string
+	HomeBranchID int
+	Status       BookStatus
+	// CallNumber is the effective call number: the copy's own, or else the one of its book.
+	CallNumber string
+	// Loans counts the times the copy was borrowed.
+	Loans int
+	// LastBorrowedAt is nil for a copy never borrowed.
+	LastBorrowedAt *time.Time
+	AddedAt        time.Time
+}
+
+// HoldPressure is a title with more active holds than copies to fill them.
+type HoldPressure struct {
+	WorkID int
+	Title  string
+	Author string
+	Holds  int
+	Copies int
+}
+
+// Shortfall is the number of copies missing to fill every hold at once.
+func (p HoldPressure) Shortfall() int {
+	return p.Holds - p.Copies
+}
+
+// DormantCutoff is the time a copy must not have been borrowed since to be
+// dormant for the given number of years.
+func DormantCutoff(now time.Time, years int) time.Time {
+	return now.AddDate(-years, 0, 0)
+}
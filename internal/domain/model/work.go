@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Work groups the editions and translations of the same intellectual work.
+type Work struct {
+	ID        int
+	Title     string
+	Author    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewWork(title, author string) Work {
+	return Work{
+		Title:  title,
+		Author: author,
+	}
+}
+
+// WorkWithEditions is a work collapsed together with all of its editions.
+type WorkWithEditions struct {
+	Work
+	Editions []Book
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS holds;
+DROP TYPE IF EXISTS hold_status;
+DROP INDEX IF EXISTS books_work_id_idx;
+ALTER TABLE books DROP COLUMN IF EXISTS language;
+ALTER TABLE books DROP COLUMN IF EXISTS edition;
+ALTER TABLE books DROP COLUMN IF EXISTS work_id;
+DROP TABLE IF EXISTS works;
@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS works (
+    id SERIAL CONSTRAINT works_pk PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE books ADD COLUMN work_id INT REFERENCES works(id);
+ALTER TABLE books ADD COLUMN edition VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN language VARCHAR(35) NOT NULL DEFAULT '';
+
+-- Every existing book becomes the only edition of its own work.
+DO $$
+DECLARE
+    b RECORD;
+    new_work_id INT;
+BEGIN
+    FOR b IN SELECT id, title, author FROM books ORDER BY id LOOP
+        INSERT INTO works (title, author) VALUES (b.title, b.author) RETURNING id INTO new_work_id;
+        UPDATE books SET work_id = new_work_id WHERE id = b.id;
+    END LOOP;
+END
+$$;
+
+ALTER TABLE books ALTER COLUMN work_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS books_work_id_idx ON books(work_id);
+
+CREATE TYPE hold_status AS ENUM (
+    'Pending',
+    'Ready',
+    'Fulfilled',
+    'Cancelled'
+);
+
+CREATE TABLE IF NOT EXISTS holds (
+    id SERIAL CONSTRAINT holds_pk PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    work_id INT NOT NULL REFERENCES works(id),
+    copy_id INT REFERENCES book_copies(id),
+    status hold_status NOT NULL DEFAULT 'Pending',
+    ready_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A patron can only queue once per work at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS holds_active_user_work_idx ON holds(user_id, work_id)
+    WHERE status IN ('Pending', 'Ready');
+CREATE INDEX IF NOT EXISTS holds_work_id_status_idx ON holds(work_id, status, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS book_subjects;
+DROP TABLE IF EXISTS subjects;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS subjects (
+    id SERIAL CONSTRAINT subjects_pk PRIMARY KEY,
+    parent_id INT REFERENCES subjects(id),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Sibling subjects must have distinct names.
+CREATE UNIQUE INDEX IF NOT EXISTS subjects_parent_name_idx ON subjects(COALESCE(parent_id, 0), LOWER(name));
+
+CREATE TABLE IF NOT EXISTS book_subjects (
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    subject_id INT NOT NULL REFERENCES subjects(id) ON DELETE CASCADE,
+    CONSTRAINT book_subjects_pk PRIMARY KEY (book_id, subject_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_subjects_subject_id_idx ON book_subjects(subject_id);
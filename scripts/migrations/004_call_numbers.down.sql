@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS book_copies_call_number_sort_idx;
+DROP INDEX IF EXISTS books_call_number_sort_idx;
+ALTER TABLE book_copies DROP COLUMN IF EXISTS call_number_sort;
+ALTER TABLE book_copies DROP COLUMN IF EXISTS call_number;
+ALTER TABLE books DROP COLUMN IF EXISTS call_number_sort;
+ALTER TABLE books DROP COLUMN IF EXISTS call_number;
+ALTER TABLE books DROP COLUMN IF EXISTS classification;
+DROP TYPE IF EXISTS classification_scheme;
@@ -0,0 +1,16 @@
+CREATE TYPE classification_scheme AS ENUM (
+    'DDC',
+    'LCC'
+);
+
+ALTER TABLE books ADD COLUMN classification classification_scheme;
+ALTER TABLE books ADD COLUMN call_number VARCHAR(255);
+ALTER TABLE books ADD COLUMN call_number_sort VARCHAR(255);
+
+-- A copy may override the call number of its book, e.g. with a volume or copy suffix.
+ALTER TABLE book_copies ADD COLUMN call_number VARCHAR(255);
+ALTER TABLE book_copies ADD COLUMN call_number_sort VARCHAR(255);
+
+-- Sort keys are compared byte-wise regardless of the database collation.
+CREATE INDEX IF NOT EXISTS books_call_number_sort_idx ON books(classification, call_number_sort COLLATE "C");
+CREATE INDEX IF NOT EXISTS book_copies_call_number_sort_idx ON book_copies(call_number_sort COLLATE "C");
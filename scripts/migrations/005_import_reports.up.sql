@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS import_reports (
+    id SERIAL CONSTRAINT import_reports_pk PRIMARY KEY,
+    source VARCHAR(64) NOT NULL,
+    dry_run BOOLEAN NOT NULL,
+    created INT NOT NULL DEFAULT 0,
+    updated INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    -- errors keeps only the failed rows, so the report stays small for large files
+    errors JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
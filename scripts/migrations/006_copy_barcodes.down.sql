@@ -0,0 +1,5 @@
+ALTER TABLE book_copies DROP COLUMN IF EXISTS retired_reason;
+ALTER TABLE book_copies DROP COLUMN IF EXISTS retired_at;
+DROP SEQUENCE IF EXISTS book_copies_barcode_seq;
+DROP INDEX IF EXISTS book_copies_barcode_idx;
+ALTER TABLE book_copies DROP COLUMN IF EXISTS barcode;
@@ -0,0 +1,10 @@
+-- Copies catalogued before barcodes were introduced have none until they are labelled.
+ALTER TABLE book_copies ADD COLUMN barcode VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS book_copies_barcode_idx ON book_copies(barcode);
+
+-- Numbers barcodes generated for copies added without a label.
+CREATE SEQUENCE IF NOT EXISTS book_copies_barcode_seq;
+
+-- A retired copy leaves the collection but keeps its loan history.
+ALTER TABLE book_copies ADD COLUMN retired_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE book_copies ADD COLUMN retired_reason VARCHAR(255);
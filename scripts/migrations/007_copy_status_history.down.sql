@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS book_status_history;
+
+-- Postgres cannot drop enum labels, so the type is rebuilt with the original ones.
+-- Withdrawn copies stay retired through retired_at.
+UPDATE book_copies SET status = 'InLibrary' WHERE status IN ('Damaged', 'InRepair', 'InTransit', 'OnHoldShelf', 'Withdrawn');
+ALTER TYPE book_status RENAME TO book_status_old;
+CREATE TYPE book_status AS ENUM (
+    'InLibrary',
+    'Borrowed',
+    'Lost'
+);
+ALTER TABLE book_copies ALTER COLUMN status TYPE book_status USING status::text::book_status;
+DROP TYPE book_status_old;
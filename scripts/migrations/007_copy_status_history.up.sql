@@ -0,0 +1,19 @@
+-- The labels are the names of model.BookStatus; the application refuses to
+-- start when the two drift apart.
+ALTER TYPE book_status ADD VALUE IF NOT EXISTS 'Damaged';
+ALTER TYPE book_status ADD VALUE IF NOT EXISTS 'InRepair';
+ALTER TYPE book_status ADD VALUE IF NOT EXISTS 'InTransit';
+ALTER TYPE book_status ADD VALUE IF NOT EXISTS 'OnHoldShelf';
+ALTER TYPE book_status ADD VALUE IF NOT EXISTS 'Withdrawn';
+
+CREATE TABLE IF NOT EXISTS book_status_history (
+    id SERIAL CONSTRAINT book_status_history_pk PRIMARY KEY,
+    copy_id INT NOT NULL REFERENCES book_copies(id),
+    -- from_status is NULL for the status a copy was created with
+    from_status book_status,
+    to_status book_status NOT NULL,
+    changed_by VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS book_status_history_copy_id_idx ON book_status_history(copy_id, created_at);
@@ -0,0 +1,25 @@
+DROP TABLE IF EXISTS transfers;
+DROP TYPE IF EXISTS transfer_status;
+
+-- Postgres cannot drop enum labels, so the type is rebuilt with the original ones.
+UPDATE holds SET status = 'Pending', copy_id = NULL WHERE status = 'InTransit';
+DROP INDEX IF EXISTS holds_active_user_work_idx;
+ALTER TABLE holds ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE hold_status RENAME TO hold_status_old;
+CREATE TYPE hold_status AS ENUM (
+    'Pending',
+    'Ready',
+    'Fulfilled',
+    'Cancelled'
+);
+ALTER TABLE holds ALTER COLUMN status TYPE hold_status USING status::text::hold_status;
+ALTER TABLE holds ALTER COLUMN status SET DEFAULT 'Pending';
+DROP TYPE hold_status_old;
+CREATE UNIQUE INDEX IF NOT EXISTS holds_active_user_work_idx ON holds(user_id, work_id)
+    WHERE status IN ('Pending', 'Ready');
+
+ALTER TABLE holds DROP COLUMN IF EXISTS pickup_branch_id;
+DROP INDEX IF EXISTS book_copies_current_branch_idx;
+ALTER TABLE book_copies DROP COLUMN IF EXISTS current_branch_id;
+ALTER TABLE book_copies DROP COLUMN IF EXISTS home_branch_id;
+DROP TABLE IF EXISTS branches;
@@ -0,0 +1,63 @@
+CREATE TABLE IF NOT EXISTS branches (
+    id SERIAL CONSTRAINT branches_pk PRIMARY KEY,
+    code VARCHAR(16) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    address VARCHAR(255) NOT NULL DEFAULT '',
+    -- copies catalogued without a branch belong to the main branch
+    is_main BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS branches_main_idx ON branches(is_main) WHERE is_main;
+
+-- Every existing copy and hold belongs to the library as it was: the main branch.
+INSERT INTO branches (code, name, is_main) VALUES ('MAIN', 'Main Library', TRUE);
+
+ALTER TABLE book_copies ADD COLUMN home_branch_id INT REFERENCES branches(id);
+ALTER TABLE book_copies ADD COLUMN current_branch_id INT REFERENCES branches(id);
+UPDATE book_copies SET
+    home_branch_id = (SELECT id FROM branches WHERE is_main),
+    current_branch_id = (SELECT id FROM branches WHERE is_main);
+ALTER TABLE book_copies ALTER COLUMN home_branch_id SET NOT NULL;
+ALTER TABLE book_copies ALTER COLUMN current_branch_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS book_copies_current_branch_idx ON book_copies(current_branch_id, book_id);
+
+ALTER TABLE holds ADD COLUMN pickup_branch_id INT REFERENCES branches(id);
+UPDATE holds SET pickup_branch_id = (SELECT id FROM branches WHERE is_main);
+ALTER TABLE holds ALTER COLUMN pickup_branch_id SET NOT NULL;
+
+-- A hold whose copy is on its way to the pickup branch. The index on active
+-- holds names only the final statuses, as a new label cannot be used in the
+-- transaction adding it.
+ALTER TYPE hold_status ADD VALUE IF NOT EXISTS 'InTransit' AFTER 'Pending';
+DROP INDEX IF EXISTS holds_active_user_work_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS holds_active_user_work_idx ON holds(user_id, work_id)
+    WHERE status NOT IN ('Fulfilled', 'Cancelled');
+
+CREATE TYPE transfer_status AS ENUM (
+    'Requested',
+    'InTransit',
+    'Received',
+    'Cancelled'
+);
+
+CREATE TABLE IF NOT EXISTS transfers (
+    id SERIAL CONSTRAINT transfers_pk PRIMARY KEY,
+    copy_id INT NOT NULL REFERENCES book_copies(id),
+    from_branch_id INT NOT NULL REFERENCES branches(id),
+    to_branch_id INT NOT NULL REFERENCES branches(id),
+    -- hold_id is set when the copy travels to fill a hold at its pickup branch
+    hold_id INT REFERENCES holds(id),
+    status transfer_status NOT NULL DEFAULT 'Requested',
+    requested_by VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    dispatched_at TIMESTAMP WITH TIME ZONE,
+    received_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- A copy travels on one transfer at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS transfers_open_copy_idx ON transfers(copy_id)
+    WHERE status IN ('Requested', 'InTransit');
+CREATE INDEX IF NOT EXISTS transfers_to_branch_idx ON transfers(to_branch_id, status);
+CREATE INDEX IF NOT EXISTS transfers_from_branch_idx ON transfers(from_branch_id, status);
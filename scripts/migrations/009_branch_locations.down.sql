@@ -0,0 +1,3 @@
+ALTER TABLE branches DROP CONSTRAINT IF EXISTS branches_location_check;
+ALTER TABLE branches DROP COLUMN IF EXISTS longitude;
+ALTER TABLE branches DROP COLUMN IF EXISTS latitude;
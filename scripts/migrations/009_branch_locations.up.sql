@@ -0,0 +1,7 @@
+-- A branch without coordinates is left out of nearest-branch searches.
+ALTER TABLE branches ADD COLUMN latitude DOUBLE PRECISION;
+ALTER TABLE branches ADD COLUMN longitude DOUBLE PRECISION;
+ALTER TABLE branches ADD CONSTRAINT branches_location_check CHECK (
+    (latitude IS NULL AND longitude IS NULL)
+    OR (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stocktake_scans;
+DROP TABLE IF EXISTS stocktakes;
+DROP TYPE IF EXISTS stocktake_status;
@@ -0,0 +1,38 @@
+CREATE TYPE stocktake_status AS ENUM (
+    'Open',
+    'Closed'
+);
+
+CREATE TABLE IF NOT EXISTS stocktakes (
+    id SERIAL CONSTRAINT stocktakes_pk PRIMARY KEY,
+    branch_id INT NOT NULL REFERENCES branches(id),
+    -- an optional shelf range, bounded by call number sort keys in one scheme
+    scheme classification_scheme,
+    range_from VARCHAR(255),
+    range_from_sort VARCHAR(255),
+    range_to VARCHAR(255),
+    range_to_sort VARCHAR(255),
+    status stocktake_status NOT NULL DEFAULT 'Open',
+    started_by VARCHAR(255) NOT NULL,
+    closed_by VARCHAR(255),
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT stocktakes_range_check CHECK (
+        (scheme IS NULL AND range_from_sort IS NULL AND range_to_sort IS NULL)
+        OR (scheme IS NOT NULL AND range_from_sort IS NOT NULL AND range_to_sort IS NOT NULL)
+    )
+);
+CREATE INDEX IF NOT EXISTS stocktakes_branch_idx ON stocktakes(branch_id, created_at);
+
+-- copy_id is resolved when the barcode is scanned and stays NULL for unknown labels.
+CREATE TABLE IF NOT EXISTS stocktake_scans (
+    id SERIAL CONSTRAINT stocktake_scans_pk PRIMARY KEY,
+    stocktake_id INT NOT NULL REFERENCES stocktakes(id) ON DELETE CASCADE,
+    barcode VARCHAR(64) NOT NULL,
+    copy_id INT REFERENCES book_copies(id),
+    scanned_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT stocktake_scans_barcode_key UNIQUE (stocktake_id, barcode)
+);
+CREATE INDEX IF NOT EXISTS stocktake_scans_copy_idx ON stocktake_scans(stocktake_id, copy_id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS purchase_order_receipts;
+DROP TABLE IF EXISTS purchase_order_lines;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TYPE IF EXISTS purchase_order_status;
+DROP TABLE IF EXISTS funds;
+DROP TABLE IF EXISTS vendors;
@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS vendors (
+    id SERIAL CONSTRAINT vendors_pk PRIMARY KEY,
+    code VARCHAR(16) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(64) NOT NULL DEFAULT '',
+    address VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Amounts are in minor units of the library currency. What a fund has
+-- encumbered and spent is summed from the order lines charged to it.
+CREATE TABLE IF NOT EXISTS funds (
+    id SERIAL CONSTRAINT funds_pk PRIMARY KEY,
+    code VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    fiscal_year INT NOT NULL,
+    budget_cents BIGINT NOT NULL CHECK (budget_cents >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT funds_code_year_key UNIQUE (code, fiscal_year)
+);
+
+CREATE TYPE purchase_order_status AS ENUM (
+    'Draft',
+    'Ordered',
+    'PartiallyReceived',
+    'Received',
+    'Cancelled'
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL CONSTRAINT purchase_orders_pk PRIMARY KEY,
+    vendor_id INT NOT NULL REFERENCES vendors(id),
+    -- the branch the order is delivered to, owning the copies received
+    branch_id INT NOT NULL REFERENCES branches(id),
+    reference VARCHAR(64) NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    status purchase_order_status NOT NULL DEFAULT 'Draft',
+    created_by VARCHAR(255) NOT NULL,
+    ordered_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS purchase_orders_vendor_idx ON purchase_orders(vendor_id, status);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id SERIAL CONSTRAINT purchase_order_lines_pk PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    book_id INT NOT NULL REFERENCES books(id),
+    fund_id INT NOT NULL REFERENCES funds(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    received_quantity INT NOT NULL DEFAULT 0,
+    unit_price_cents BIGINT NOT NULL CHECK (unit_price_cents >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT purchase_order_lines_received_check CHECK (received_quantity BETWEEN 0 AND quantity)
+);
+CREATE INDEX IF NOT EXISTS purchase_order_lines_order_idx ON purchase_order_lines(order_id);
+CREATE INDEX IF NOT EXISTS purchase_order_lines_fund_idx ON purchase_order_lines(fund_id);
+
+-- Each copy received on an order line.
+CREATE TABLE IF NOT EXISTS purchase_order_receipts (
+    id SERIAL CONSTRAINT purchase_order_receipts_pk PRIMARY KEY,
+    line_id INT NOT NULL REFERENCES purchase_order_lines(id),
+    copy_id INT NOT NULL UNIQUE REFERENCES book_copies(id),
+    received_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS purchase_order_receipts_line_idx ON purchase_order_receipts(line_id);
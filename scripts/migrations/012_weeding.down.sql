@@ -0,0 +1 @@
+DROP INDEX IF EXISTS borrowed_books_copy_id_idx;
@@ -0,0 +1,2 @@
+-- Weeding reports look up the loans of every copy.
+CREATE INDEX IF NOT EXISTS borrowed_books_copy_id_idx ON borrowed_books(copy_id, borrow_date);
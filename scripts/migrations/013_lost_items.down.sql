@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS patron_charges;
+DROP TYPE IF EXISTS charge_kind;
+DROP INDEX IF EXISTS borrowed_books_open_idx;
+ALTER TABLE borrowed_books DROP COLUMN IF EXISTS lost_at;
+ALTER TABLE book_copies DROP COLUMN IF EXISTS price_cents;
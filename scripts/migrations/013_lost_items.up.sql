@@ -0,0 +1,32 @@
+-- What a copy cost, charged when it is lost. NULL when unknown.
+ALTER TABLE book_copies ADD COLUMN price_cents BIGINT CHECK (price_cents >= 0);
+
+-- A loan whose copy is declared lost stays open until the copy comes back.
+ALTER TABLE borrowed_books ADD COLUMN lost_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS borrowed_books_open_idx ON borrowed_books(copy_id) WHERE return_date IS NULL;
+
+CREATE TYPE charge_kind AS ENUM (
+    'Replacement',
+    'ProcessingFee'
+);
+
+-- Money owed by patrons, in minor units of the library currency. Charges are
+-- reversed rather than deleted.
+CREATE TABLE IF NOT EXISTS patron_charges (
+    id SERIAL CONSTRAINT patron_charges_pk PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    loan_id INT REFERENCES borrowed_books(id),
+    copy_id INT REFERENCES book_copies(id),
+    kind charge_kind NOT NULL,
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL,
+    reversed_at TIMESTAMP WITH TIME ZONE,
+    reversed_by VARCHAR(255) NOT NULL DEFAULT '',
+    reversal_reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS patron_charges_user_id_idx ON patron_charges(user_id, created_at);
+CREATE INDEX IF NOT EXISTS patron_charges_loan_id_idx ON patron_charges(loan_id);
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS copy_repairs;
+DROP TABLE IF EXISTS copy_condition_photos;
+DROP TABLE IF EXISTS copy_condition_reports;
+
+-- Postgres cannot drop enum labels, so the type is rebuilt with the original ones.
+DELETE FROM patron_charges WHERE kind = 'Damage';
+ALTER TYPE charge_kind RENAME TO charge_kind_old;
+CREATE TYPE charge_kind AS ENUM (
+    'Replacement',
+    'ProcessingFee'
+);
+ALTER TABLE patron_charges ALTER COLUMN kind TYPE charge_kind USING kind::text::charge_kind;
+DROP TYPE charge_kind_old;
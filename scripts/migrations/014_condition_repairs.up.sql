@@ -0,0 +1,45 @@
+ALTER TYPE charge_kind ADD VALUE IF NOT EXISTS 'Damage';
+
+-- Condition reports are evidence in disputes about damage, so they are
+-- never edited. Their photos sit in the blob store.
+CREATE TABLE IF NOT EXISTS copy_condition_reports (
+    id SERIAL CONSTRAINT copy_condition_reports_pk PRIMARY KEY,
+    copy_id INT NOT NULL REFERENCES book_copies(id),
+    note TEXT NOT NULL DEFAULT '',
+    damaged BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_by VARCHAR(255) NOT NULL,
+    charge_id INT REFERENCES patron_charges(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS copy_condition_reports_copy_id_idx ON copy_condition_reports(copy_id, created_at);
+
+CREATE TABLE IF NOT EXISTS copy_condition_photos (
+    id SERIAL CONSTRAINT copy_condition_photos_pk PRIMARY KEY,
+    report_id INT NOT NULL REFERENCES copy_condition_reports(id),
+    blob_key VARCHAR(255) NOT NULL CONSTRAINT copy_condition_photos_blob_key_key UNIQUE,
+    content_type VARCHAR(64) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS copy_condition_photos_report_id_idx ON copy_condition_photos(report_id);
+
+-- A copy is at one repair vendor at a time.
+CREATE TABLE IF NOT EXISTS copy_repairs (
+    id SERIAL CONSTRAINT copy_repairs_pk PRIMARY KEY,
+    copy_id INT NOT NULL REFERENCES book_copies(id),
+    vendor_id INT NOT NULL REFERENCES vendors(id),
+    report_id INT REFERENCES copy_condition_reports(id),
+    note TEXT NOT NULL DEFAULT '',
+    sent_by VARCHAR(255) NOT NULL,
+    expected_back DATE NOT NULL,
+    returned_at TIMESTAMP WITH TIME ZONE,
+    returned_by VARCHAR(255) NOT NULL DEFAULT '',
+    repaired BOOLEAN,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS copy_repairs_open_idx ON copy_repairs(copy_id) WHERE returned_at IS NULL;
+CREATE INDEX IF NOT EXISTS copy_repairs_vendor_id_idx ON copy_repairs(vendor_id);
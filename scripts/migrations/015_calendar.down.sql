@@ -0,0 +1,19 @@
+-- Postgres cannot drop enum labels, so the type is rebuilt with the original ones.
+DELETE FROM patron_charges WHERE kind = 'OverdueFine';
+ALTER TYPE charge_kind RENAME TO charge_kind_old;
+CREATE TYPE charge_kind AS ENUM (
+    'Replacement',
+    'ProcessingFee',
+    'Damage'
+);
+ALTER TABLE patron_charges ALTER COLUMN kind TYPE charge_kind USING kind::text::charge_kind;
+DROP TYPE charge_kind_old;
+
+DROP INDEX IF EXISTS borrowed_books_one_open_idx;
+CREATE INDEX IF NOT EXISTS borrowed_books_open_idx ON borrowed_books(copy_id) WHERE return_date IS NULL;
+ALTER TABLE borrowed_books DROP COLUMN IF EXISTS renewals;
+ALTER TABLE borrowed_books DROP COLUMN IF EXISTS branch_id;
+
+DROP TABLE IF EXISTS calendar_closures;
+DROP TYPE IF EXISTS closure_kind;
+DROP TABLE IF EXISTS branch_opening_hours;
@@ -0,0 +1,40 @@
+-- Weekly opening hours, as minutes after midnight. A day with no row is
+-- closed; a branch with no rows at all is open every day.
+CREATE TABLE IF NOT EXISTS branch_opening_hours (
+    branch_id INT NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens_minute SMALLINT NOT NULL CHECK (opens_minute >= 0),
+    closes_minute SMALLINT NOT NULL CHECK (closes_minute <= 1440),
+    CONSTRAINT branch_opening_hours_pk PRIMARY KEY (branch_id, weekday),
+    CONSTRAINT branch_opening_hours_range CHECK (opens_minute < closes_minute)
+);
+
+CREATE TYPE closure_kind AS ENUM (
+    'Holiday',
+    'Closure'
+);
+
+-- Days a branch is closed whatever its hours. A closure with no branch
+-- closes every branch.
+CREATE TABLE IF NOT EXISTS calendar_closures (
+    id SERIAL CONSTRAINT calendar_closures_pk PRIMARY KEY,
+    branch_id INT REFERENCES branches(id) ON DELETE CASCADE,
+    kind closure_kind NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT calendar_closures_range CHECK (start_date <= end_date)
+);
+
+CREATE INDEX IF NOT EXISTS calendar_closures_dates_idx ON calendar_closures(end_date, start_date);
+
+-- The lending branch sets the calendar of a loan.
+ALTER TABLE borrowed_books ADD COLUMN branch_id INT REFERENCES branches(id);
+ALTER TABLE borrowed_books ADD COLUMN renewals INT NOT NULL DEFAULT 0 CHECK (renewals >= 0);
+-- a copy is out on one loan at a time
+DROP INDEX IF EXISTS borrowed_books_open_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS borrowed_books_one_open_idx ON borrowed_books(copy_id) WHERE return_date IS NULL;
+
+ALTER TYPE charge_kind ADD VALUE IF NOT EXISTS 'OverdueFine';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS borrowed_books_user_open_idx;
+DROP TABLE IF EXISTS patron_blocks;
+ALTER TABLE users DROP COLUMN IF EXISTS membership_expires_at;
@@ -0,0 +1,17 @@
+-- The last day of the membership; NULL for a membership that does not lapse.
+ALTER TABLE users ADD COLUMN membership_expires_at DATE;
+
+-- Blocks staff put on an account, which hold until lifted.
+CREATE TABLE IF NOT EXISTS patron_blocks (
+    id SERIAL CONSTRAINT patron_blocks_pk PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    blocked_by VARCHAR(255) NOT NULL,
+    lifted_at TIMESTAMP WITH TIME ZONE,
+    lifted_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS patron_blocks_active_idx ON patron_blocks(user_id) WHERE lifted_at IS NULL;
+CREATE INDEX IF NOT EXISTS borrowed_books_user_open_idx ON borrowed_books(user_id, due_date) WHERE return_date IS NULL;
@@ -0,0 +1,5 @@
+DROP SEQUENCE IF EXISTS library_cards_barcode_seq;
+DROP TABLE IF EXISTS library_cards;
+ALTER TABLE users DROP COLUMN IF EXISTS membership_starts_at;
+ALTER TABLE users DROP COLUMN IF EXISTS category;
+DROP TYPE IF EXISTS patron_category;
@@ -0,0 +1,32 @@
+CREATE TYPE patron_category AS ENUM (
+    'Adult',
+    'Child',
+    'Staff',
+    'Guest'
+);
+
+ALTER TABLE users ADD COLUMN category patron_category NOT NULL DEFAULT 'Adult';
+-- The first day of the membership; existing members joined when they signed up.
+ALTER TABLE users ADD COLUMN membership_starts_at DATE NOT NULL DEFAULT CURRENT_DATE;
+UPDATE users SET membership_starts_at = (created_at AT TIME ZONE 'UTC')::DATE;
+
+-- Library cards carry the patron barcode scanned at the desk. A card
+-- reported lost or replaced is revoked but keeps its barcode, which is never
+-- handed out again.
+CREATE TABLE IF NOT EXISTS library_cards (
+    id SERIAL CONSTRAINT library_cards_pk PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    barcode VARCHAR(64) NOT NULL CONSTRAINT library_cards_barcode_key UNIQUE,
+    issued_by VARCHAR(255) NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by VARCHAR(255) NOT NULL DEFAULT '',
+    revoke_reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- a patron has one active card at a time
+CREATE UNIQUE INDEX IF NOT EXISTS library_cards_one_active_idx ON library_cards(user_id) WHERE revoked_at IS NULL;
+
+-- Numbers barcodes generated for cards issued without a preprinted one.
+CREATE SEQUENCE IF NOT EXISTS library_cards_barcode_seq;
@@ -0,0 +1,7 @@
+ALTER TABLE patron_charges DROP CONSTRAINT IF EXISTS patron_charges_settled_once;
+ALTER TABLE patron_charges DROP COLUMN IF EXISTS paid_by_user_id;
+ALTER TABLE patron_charges DROP COLUMN IF EXISTS paid_at;
+DROP TABLE IF EXISTS content_restrictions;
+DROP TABLE IF EXISTS household_members;
+DROP TYPE IF EXISTS household_role;
+DROP TABLE IF EXISTS households;
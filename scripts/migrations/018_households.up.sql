@@ -0,0 +1,44 @@
+-- Households link the accounts of a family, whose guardians look after the
+-- accounts of its dependents.
+CREATE TABLE IF NOT EXISTS households (
+    id SERIAL CONSTRAINT households_pk PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TYPE household_role AS ENUM (
+    'Guardian',
+    'Dependent'
+);
+
+-- A user belongs to one household at most. Permissions are those of a
+-- guardian over the dependents.
+CREATE TABLE IF NOT EXISTS household_members (
+    user_id INT CONSTRAINT household_members_pk PRIMARY KEY REFERENCES users(id),
+    household_id INT NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    role household_role NOT NULL,
+    can_manage_loans BOOLEAN NOT NULL DEFAULT FALSE,
+    can_manage_holds BOOLEAN NOT NULL DEFAULT FALSE,
+    can_pay_charges BOOLEAN NOT NULL DEFAULT FALSE,
+    can_set_restrictions BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS household_members_household_id_idx ON household_members(household_id);
+
+-- Subjects a patron may not borrow or place holds on, narrower subjects
+-- included, as set by their guardian.
+CREATE TABLE IF NOT EXISTS content_restrictions (
+    user_id INT NOT NULL REFERENCES users(id),
+    subject_id INT NOT NULL REFERENCES subjects(id) ON DELETE CASCADE,
+    set_by_user_id INT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT content_restrictions_pk PRIMARY KEY (user_id, subject_id)
+);
+
+-- A charge is paid by the patron charged or by their guardian.
+ALTER TABLE patron_charges ADD COLUMN paid_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE patron_charges ADD COLUMN paid_by_user_id INT REFERENCES users(id);
+ALTER TABLE patron_charges ADD CONSTRAINT patron_charges_settled_once CHECK (paid_at IS NULL OR reversed_at IS NULL);
@@ -0,0 +1,3 @@
+-- fails while loans unlinked from their patron remain
+ALTER TABLE borrowed_books ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS erased_at;
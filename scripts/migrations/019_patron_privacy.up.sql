@@ -0,0 +1,6 @@
+-- An erased patron keeps their row, without name or address, so charges and
+-- statistics stay consistent.
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMP WITH TIME ZONE;
+
+-- Loans unlinked from their patron still count in statistics.
+ALTER TABLE borrowed_books ALTER COLUMN user_id DROP NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS borrowed_books_return_date_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS keep_reading_history;
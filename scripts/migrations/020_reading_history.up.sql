@@ -0,0 +1,5 @@
+-- Returned loans are unlinked from patrons once the retention window passes,
+-- unless the patron opts in to the library keeping their reading history.
+ALTER TABLE users ADD COLUMN keep_reading_history BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS borrowed_books_return_date_idx ON borrowed_books(return_date) WHERE user_id IS NOT NULL;
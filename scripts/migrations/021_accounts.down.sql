@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS account_tokens;
+DROP TYPE IF EXISTS account_token_purpose;
+DROP TABLE IF EXISTS user_credentials;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
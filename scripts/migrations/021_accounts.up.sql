@@ -0,0 +1,27 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Kept apart from users so that the hash never travels with the profile.
+CREATE TABLE IF NOT EXISTS user_credentials (
+    user_id INT CONSTRAINT user_credentials_pk PRIMARY KEY REFERENCES users(id),
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TYPE account_token_purpose AS ENUM (
+    'VerifyEmail',
+    'ResetPassword'
+);
+
+-- Single-use tokens mailed to patrons, kept as SHA-256 hashes only.
+CREATE TABLE IF NOT EXISTS account_tokens (
+    id SERIAL CONSTRAINT account_tokens_pk PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    purpose account_token_purpose NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS account_tokens_unused_idx ON account_tokens(user_id, purpose) WHERE used_at IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sent_notifications;
+DROP TYPE IF EXISTS notification_channel;
+DROP TYPE IF EXISTS notification_type;
@@ -0,0 +1,27 @@
+CREATE TYPE notification_type AS ENUM (
+    'DueSoon',
+    'Overdue',
+    'HoldReady'
+);
+
+CREATE TYPE notification_channel AS ENUM (
+    'Email',
+    'Log'
+);
+
+-- Notifications sent to patrons. The dedup key is claimed before sending, so
+-- that a notice is sent once however often the job runs.
+CREATE TABLE IF NOT EXISTS sent_notifications (
+    id SERIAL CONSTRAINT sent_notifications_pk PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    type notification_type NOT NULL,
+    channel notification_channel NOT NULL,
+    reference_id INT NOT NULL,
+    dedup_key VARCHAR(255) NOT NULL UNIQUE,
+    locale VARCHAR(16) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS sent_notifications_user_id_idx ON sent_notifications(user_id, sent_at);
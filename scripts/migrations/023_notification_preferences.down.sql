@@ -0,0 +1,3 @@
+ALTER TABLE sent_notifications DROP COLUMN IF EXISTS digest;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TYPE IF EXISTS notification_delivery;
@@ -0,0 +1,22 @@
+CREATE TYPE notification_delivery AS ENUM (
+    'Immediate',
+    'Digest'
+);
+
+-- Patrons without a row get every notification at once, on the channel and
+-- in the time zone of the library. Times of day are minutes after midnight.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INT CONSTRAINT notification_preferences_pk PRIMARY KEY REFERENCES users(id),
+    types notification_type[] NOT NULL,
+    channel notification_channel NOT NULL,
+    delivery notification_delivery NOT NULL DEFAULT 'Immediate',
+    time_zone VARCHAR(64) NOT NULL,
+    quiet_start SMALLINT NOT NULL DEFAULT 0 CHECK (quiet_start >= 0 AND quiet_start < 1440),
+    quiet_end SMALLINT NOT NULL DEFAULT 0 CHECK (quiet_end >= 0 AND quiet_end < 1440),
+    digest_time SMALLINT NOT NULL DEFAULT 480 CHECK (digest_time >= 0 AND digest_time < 1440),
+    last_digest_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE sent_notifications ADD COLUMN digest BOOLEAN NOT NULL DEFAULT FALSE;
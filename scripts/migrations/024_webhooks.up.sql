@@ -0,0 +1,49 @@
+CREATE TYPE webhook_event AS ENUM (
+    'loan.checked_out',
+    'loan.returned',
+    'hold.ready',
+    'catalog.changed'
+);
+
+CREATE TYPE webhook_delivery_status AS ENUM (
+    'Pending',
+    'Delivered',
+    'DeadLettered'
+);
+
+-- Endpoints of integrations, sent the events they subscribed to. Requests are
+-- signed with the secret of the subscription.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id SERIAL CONSTRAINT webhook_subscriptions_pk PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    events webhook_event[] NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One event on its way to one subscription. Pending deliveries are attempted
+-- from next_attempt_at on; those running out of attempts stay here as dead
+-- letters until redelivered.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL CONSTRAINT webhook_deliveries_pk PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id VARCHAR(64) NOT NULL,
+    event_type webhook_event NOT NULL,
+    payload JSONB NOT NULL,
+    status webhook_delivery_status NOT NULL DEFAULT 'Pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INT,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    dead_lettered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries(next_attempt_at) WHERE status = 'Pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_status_idx ON webhook_deliveries(status, id);
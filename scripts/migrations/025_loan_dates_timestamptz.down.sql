@@ -0,0 +1,4 @@
+ALTER TABLE borrowed_books
+    ALTER COLUMN borrow_date TYPE TIMESTAMP USING borrow_date AT TIME ZONE 'UTC',
+    ALTER COLUMN due_date TYPE TIMESTAMP USING due_date AT TIME ZONE 'UTC',
+    ALTER COLUMN return_date TYPE TIMESTAMP USING return_date AT TIME ZONE 'UTC';
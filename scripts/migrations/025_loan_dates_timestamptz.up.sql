@@ -0,0 +1,7 @@
+-- Due dates fall at closing time in the library's time zone, so loan dates
+-- keep their offset like every other timestamp. Existing values were read
+-- back as UTC, which is what they are taken to be.
+ALTER TABLE borrowed_books
+    ALTER COLUMN borrow_date TYPE TIMESTAMP WITH TIME ZONE USING borrow_date AT TIME ZONE 'UTC',
+    ALTER COLUMN due_date TYPE TIMESTAMP WITH TIME ZONE USING due_date AT TIME ZONE 'UTC',
+    ALTER COLUMN return_date TYPE TIMESTAMP WITH TIME ZONE USING return_date AT TIME ZONE 'UTC';
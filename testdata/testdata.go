@@ -8,7 +8,28 @@ import (
 var basepath string
 
 const (
-	TestDataUser = "users.yaml"
+	TestDataUser         = "users.yaml"
+	TestDataWork         = "works.yaml"
+	TestDataBook         = "books.yaml"
+	TestDataBookCopies   = "book_copies.yaml"
+	TestDataHold         = "holds.yaml"
+	TestDataSubject      = "subjects.yaml"
+	TestDataBookSubject  = "book_subjects.yaml"
+	TestDataBranch       = "branches.yaml"
+	TestDataVendor       = "vendors.yaml"
+	TestDataFund         = "funds.yaml"
+	TestDataBorrowedBook = "borrowed_books.yaml"
+	TestDataCharge       = "patron_charges.yaml"
+	TestDataCondition    = "copy_condition_reports.yaml"
+	TestDataPhoto        = "copy_condition_photos.yaml"
+	TestDataRepair       = "copy_repairs.yaml"
+	TestDataOpeningHours = "branch_opening_hours.yaml"
+	TestDataClosure      = "calendar_closures.yaml"
+	TestDataManualBlock  = "patron_blocks.yaml"
+	TestDataLibraryCard  = "library_cards.yaml"
+	TestDataHousehold    = "households.yaml"
+	TestDataMember       = "household_members.yaml"
+	TestDataRestriction  = "content_restrictions.yaml"
 )
 
 func init() {